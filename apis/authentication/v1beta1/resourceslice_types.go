@@ -109,6 +109,8 @@ type ResourceSliceStatus struct {
 	IngressClasses []liqov1beta1.IngressType `json:"ingressClasses,omitempty"`
 	// LoadBalancerClasses contains the list of the load balancer classes offered by the cluster.
 	LoadBalancerClasses []liqov1beta1.LoadBalancerType `json:"loadBalancerClasses,omitempty"`
+	// Gateways contains the list of the Gateway API gateways offered by the cluster.
+	Gateways []liqov1beta1.GatewayType `json:"gateways,omitempty"`
 	// NodeLabels contains the provider cluster labels.
 	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
 	// NodeSelector contains the selector to be applied to offloaded pods.
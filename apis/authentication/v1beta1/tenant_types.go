@@ -75,6 +75,9 @@ type TenantSpec struct {
 	// +kubebuilder:validation:Enum=Active;Cordoned;Drained
 	// +kubebuilder:default=Active
 	TenantCondition TenantCondition `json:"tenantCondition,omitempty"`
+	// AllowClusterScopedReflection grants the tenant the permission to reflect cluster-scoped resources
+	// (e.g., PriorityClasses and RuntimeClasses) in the local cluster. Default is false.
+	AllowClusterScopedReflection bool `json:"allowClusterScopedReflection,omitempty"`
 }
 
 // TenantCondition contains the conditions of the tenant.
@@ -311,7 +311,9 @@ func (in *ResourceSliceStatus) DeepCopyInto(out *ResourceSliceStatus) {
 	if in.StorageClasses != nil {
 		in, out := &in.StorageClasses, &out.StorageClasses
 		*out = make([]corev1beta1.StorageType, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.IngressClasses != nil {
 		in, out := &in.IngressClasses, &out.IngressClasses
@@ -323,6 +325,11 @@ func (in *ResourceSliceStatus) DeepCopyInto(out *ResourceSliceStatus) {
 		*out = make([]corev1beta1.LoadBalancerType, len(*in))
 		copy(*out, *in)
 	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]corev1beta1.GatewayType, len(*in))
+		copy(*out, *in)
+	}
 	if in.NodeLabels != nil {
 		in, out := &in.NodeLabels, &out.NodeLabels
 		*out = make(map[string]string, len(*in))
@@ -0,0 +1,77 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnouncedNetworking contains the network parameters announced by a cluster.
+type AnnouncedNetworking struct {
+	// PodCIDR is the CIDR used by the pods of the announcing cluster.
+	PodCIDR string `json:"podCIDR,omitempty"`
+	// ExternalCIDR is the CIDR used by the announcing cluster to remap the remote resources.
+	ExternalCIDR string `json:"externalCIDR,omitempty"`
+}
+
+// ClusterAnnouncementSpec defines the information published by a cluster to be discovered by the candidate peers.
+type ClusterAnnouncementSpec struct {
+	// ClusterID is the ID of the announcing cluster.
+	ClusterID ClusterID `json:"clusterID"`
+	// APIServerURL is the URL of the API server of the announcing cluster.
+	APIServerURL string `json:"apiServerUrl,omitempty"`
+	// ResourceClasses contains the classes of ResourceSlices offered by the announcing cluster.
+	ResourceClasses []string `json:"resourceClasses,omitempty"`
+	// Labels contains the labels characterizing the announcing cluster (e.g., region, provider).
+	Labels map[string]string `json:"labels,omitempty"`
+	// Networking contains the network parameters of the announcing cluster.
+	Networking AnnouncedNetworking `json:"networking,omitempty"`
+	// PublicKey is the public key (in PEM format) of the announcing cluster, used to verify the signature.
+	// It is the same key used during the authentication with the peer clusters.
+	PublicKey []byte `json:"publicKey"`
+	// ExpirationTime is the time after which the announcement is no longer valid, unless renewed.
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo,shortName=ca
+// +kubebuilder:printcolumn:name="ClusterID",type=string,JSONPath=`.spec.clusterID`
+// +kubebuilder:printcolumn:name="APIServer",type=string,priority=1,JSONPath=`.spec.apiServerUrl`
+// +kubebuilder:printcolumn:name="Expiration",type=string,JSONPath=`.spec.expirationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterAnnouncement is the Schema for the clusterannouncements API.
+// It is published by a cluster to a shared registry, to be discovered by the candidate peers.
+type ClusterAnnouncement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterAnnouncementSpec `json:"spec"`
+	// Signature is the signature of the spec, computed with the private key of the announcing cluster.
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterAnnouncementList contains a list of ClusterAnnouncement.
+type ClusterAnnouncementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAnnouncement `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterAnnouncement{}, &ClusterAnnouncementList{})
+}
@@ -14,12 +14,17 @@
 
 package v1beta1
 
+import "k8s.io/apimachinery/pkg/api/resource"
+
 // StorageType defines the type of storage offered by a resource offer.
 type StorageType struct {
 	// StorageClassName indicates the name of the storage class.
 	StorageClassName string `json:"storageClassName"`
 	// Default indicates whether this storage class is the default storage class for Liqo.
 	Default bool `json:"default,omitempty"`
+	// Capacity indicates the maximum size of a volume that can currently be provisioned through this storage class,
+	// as reported by the CSIStorageCapacity objects of the offering cluster. It is not set if the capacity is not tracked.
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
 }
 
 // IngressType defines the type of ingress offered by a resource offer.
@@ -37,3 +42,13 @@ type LoadBalancerType struct {
 	// Default indicates whether this load balancer class is the default load balancer class for Liqo.
 	Default bool `json:"default,omitempty"`
 }
+
+// GatewayType defines the Gateway API gateway offered by a resource offer.
+type GatewayType struct {
+	// Name indicates the name of the gateway.
+	Name string `json:"name"`
+	// Namespace indicates the namespace of the gateway.
+	Namespace string `json:"namespace"`
+	// Default indicates whether this gateway is the default gateway for Liqo.
+	Default bool `json:"default,omitempty"`
+}
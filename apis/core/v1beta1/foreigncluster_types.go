@@ -69,6 +69,10 @@ type ForeignClusterStatus struct {
 
 	// Generic conditions related to the foreign cluster.
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Health summarizes the status of the foreign cluster, as computed from the module and generic conditions.
+	// +kubebuilder:validation:Optional
+	Health *HealthSummary `json:"health,omitempty"`
 }
 
 // Modules contains the configuration of the modules for this foreign cluster.
@@ -84,6 +88,39 @@ type Module struct {
 	Enabled bool `json:"enabled"`
 	// Conditions contains the status conditions related to the module.
 	Conditions []Condition `json:"conditions,omitempty"`
+	// Health summarizes the status of the module, as computed from its conditions.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum="Healthy";"Degraded";"Down"
+	Health HealthStatus `json:"health,omitempty"`
+}
+
+// HealthStatus represents the health of a foreign cluster, or of one of its modules.
+type HealthStatus string
+
+const (
+	// HealthStatusHealthy indicates that all the conditions are established or ready.
+	HealthStatusHealthy HealthStatus = "Healthy"
+	// HealthStatusDegraded indicates that some conditions are pending or not completely ready.
+	HealthStatusDegraded HealthStatus = "Degraded"
+	// HealthStatusDown indicates that at least one condition is in error or not ready.
+	HealthStatusDown HealthStatus = "Down"
+)
+
+// HealthSummary summarizes the health of a foreign cluster.
+type HealthSummary struct {
+	// Status is the overall health of the foreign cluster, i.e., the worst health among its enabled modules.
+	// +kubebuilder:validation:Enum="Healthy";"Degraded";"Down"
+	Status HealthStatus `json:"status"`
+	// Score is the average health of the enabled modules and of the API server, ranging from 0 (down) to 100 (healthy).
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Score int `json:"score"`
+	// Reason is the reason of the first failing condition, if any.
+	Reason string `json:"reason,omitempty"`
+	// Message is the message of the first failing condition, if any.
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the last time the overall health status changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // ConditionType represents different conditions that a  could assume.
@@ -94,6 +131,8 @@ const (
 	// GENERIC
 	// APIServerStatusCondition shows the status of the API Server.
 	APIServerStatusCondition ConditionType = "APIServerStatus"
+	// DiscoveredCondition shows whether the foreign cluster has been discovered through a cluster registry.
+	DiscoveredCondition ConditionType = "Discovered"
 
 	// NETWORKING
 	// NetworkConfigurationStatusCondition tells whether the network configuration of the peer cluster is present.
@@ -142,7 +181,7 @@ const (
 // Condition contains details about state of a.
 type Condition struct {
 	// Type of the condition.
-	// +kubebuilder:validation:Enum="APIServerStatus";"NetworkConnectionStatus";"NetworkGatewayServerStatus";"NetworkGatewayClientStatus";"NetworkGatewayPresence";"NetworkConfigurationStatus";"AuthIdentityControlPlaneStatus";"AuthTenantStatus";"OffloadingVirtualNodeStatus";"OffloadingNodeStatus"
+	// +kubebuilder:validation:Enum="APIServerStatus";"Discovered";"NetworkConnectionStatus";"NetworkGatewayServerStatus";"NetworkGatewayClientStatus";"NetworkGatewayPresence";"NetworkConfigurationStatus";"AuthIdentityControlPlaneStatus";"AuthTenantStatus";"OffloadingVirtualNodeStatus";"OffloadingNodeStatus"
 	//
 	//nolint:lll // ignore long lines given by Kubebuilder marker annotations
 	Type ConditionType `json:"type"`
@@ -172,6 +211,10 @@ type TenantNamespaceType struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.status.role`
 // +kubebuilder:printcolumn:name="ClusterID",type=string,priority=1,JSONPath=`.spec.clusterID`
+// +kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health.status`
+// +kubebuilder:printcolumn:name="Score",type=integer,JSONPath=`.status.health.score`
+// +kubebuilder:printcolumn:name="Reason",type=string,priority=1,JSONPath=`.status.health.reason`
+// +kubebuilder:printcolumn:name="Discovered",type=string,priority=1,JSONPath=`.status.conditions[?(@.type=="Discovered")].status`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ForeignCluster is the Schema for the foreignclusters API.
@@ -43,4 +43,22 @@ var (
 
 	// ForeignClusterGroupResource is the group resource used to register the ForeignCluster CRD.
 	ForeignClusterGroupResource = schema.GroupResource{Group: GroupVersion.Group, Resource: ForeignClusterResource}
+
+	// ClusterAnnouncementKind is the kind name used to register the ClusterAnnouncement CRD.
+	ClusterAnnouncementKind = "ClusterAnnouncement"
+
+	// ClusterAnnouncementResource is the resource name used to register the ClusterAnnouncement CRD.
+	ClusterAnnouncementResource = "clusterannouncements"
+
+	// ClusterAnnouncementGroupVersionResource is the group version resource used to register the ClusterAnnouncement CRD.
+	ClusterAnnouncementGroupVersionResource = GroupVersion.WithResource(ClusterAnnouncementResource)
+
+	// PeeringKind is the kind name used to register the Peering CRD.
+	PeeringKind = "Peering"
+
+	// PeeringResource is the resource name used to register the Peering CRD.
+	PeeringResource = "peerings"
+
+	// PeeringGroupVersionResource is the group version resource used to register the Peering CRD.
+	PeeringGroupVersionResource = GroupVersion.WithResource(PeeringResource)
 )
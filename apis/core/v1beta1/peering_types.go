@@ -0,0 +1,174 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PeeringNetworking defines the configuration of the networking module for a Peering.
+type PeeringNetworking struct {
+	// Enabled indicates whether the network connectivity with the provider cluster has to be established.
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+	// ServerServiceType is the type of the service exposing the gateway server in the provider cluster.
+	// +kubebuilder:validation:Enum="LoadBalancer";"NodePort";"ClusterIP"
+	// +kubebuilder:default="LoadBalancer"
+	ServerServiceType corev1.ServiceType `json:"serverServiceType,omitempty"`
+	// ServerPort is the port of the service exposing the gateway server in the provider cluster.
+	// +kubebuilder:default=51840
+	ServerPort int32 `json:"serverPort,omitempty"`
+	// MTU is the MTU of the network interfaces of the gateways.
+	// +kubebuilder:default=1340
+	MTU int `json:"mtu,omitempty"`
+}
+
+// PeeringAuthentication defines the configuration of the authentication module for a Peering.
+type PeeringAuthentication struct {
+	// InBand indicates whether the provider API server has to be reached through the network tunnel.
+	// It requires the networking module to be enabled.
+	InBand bool `json:"inBand,omitempty"`
+	// ProxyURL is the URL of the proxy used to reach the provider API server.
+	ProxyURL string `json:"proxyURL,omitempty"`
+}
+
+// PeeringOffloading defines the configuration of the offloading module for a Peering.
+type PeeringOffloading struct {
+	// Enabled indicates whether a ResourceSlice has to be requested to the provider cluster.
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+	// ResourceSliceClass is the class of the requested ResourceSlice.
+	// +kubebuilder:default="default"
+	ResourceSliceClass string `json:"resourceSliceClass,omitempty"`
+	// Resources contains the resources requested in the ResourceSlice.
+	// If empty, the provider cluster decides the amount of resources to grant.
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+	// CreateVirtualNode indicates whether a VirtualNode has to be created for the ResourceSlice.
+	// +kubebuilder:default=true
+	CreateVirtualNode bool `json:"createVirtualNode"`
+}
+
+// PeeringSpec defines the desired state of Peering.
+type PeeringSpec struct {
+	// KubeconfigSecretRef references the secret, in the same namespace of the Peering,
+	// containing the kubeconfig (in the "kubeconfig" key) to access the provider cluster.
+	KubeconfigSecretRef corev1.LocalObjectReference `json:"kubeconfigSecretRef"`
+	// RemoteLiqoNamespace is the namespace where Liqo is installed in the provider cluster.
+	// +kubebuilder:default="liqo"
+	RemoteLiqoNamespace string `json:"remoteLiqoNamespace,omitempty"`
+
+	// Networking contains the configuration of the networking module.
+	// +kubebuilder:default={}
+	Networking PeeringNetworking `json:"networking,omitempty"`
+	// Authentication contains the configuration of the authentication module.
+	// +kubebuilder:default={}
+	Authentication PeeringAuthentication `json:"authentication,omitempty"`
+	// Offloading contains the configuration of the offloading module.
+	// +kubebuilder:default={}
+	Offloading PeeringOffloading `json:"offloading,omitempty"`
+}
+
+// PeeringConditionType represents the steps performed to establish a Peering.
+type PeeringConditionType string
+
+// These are valid types of PeeringConditions.
+const (
+	// PeeringNetworkInitializedCondition tells whether the network Configurations have been exchanged.
+	PeeringNetworkInitializedCondition PeeringConditionType = "NetworkInitialized"
+	// PeeringNetworkConnectedCondition tells whether the gateways have established the network connection.
+	PeeringNetworkConnectedCondition PeeringConditionType = "NetworkConnected"
+	// PeeringAuthenticatedCondition tells whether the consumer cluster obtained the control plane Identity.
+	PeeringAuthenticatedCondition PeeringConditionType = "Authenticated"
+	// PeeringResourceSliceAcceptedCondition tells whether the ResourceSlice has been accepted by the provider cluster.
+	PeeringResourceSliceAcceptedCondition PeeringConditionType = "ResourceSliceAccepted"
+	// PeeringVirtualNodeReadyCondition tells whether the VirtualNode associated with the ResourceSlice is ready.
+	PeeringVirtualNodeReadyCondition PeeringConditionType = "VirtualNodeReady"
+)
+
+// PeeringCondition contains the status of a step performed to establish a Peering.
+type PeeringCondition struct {
+	// Type of the condition.
+	// +kubebuilder:validation:Enum="NetworkInitialized";"NetworkConnected";"Authenticated";"ResourceSliceAccepted";"VirtualNodeReady"
+	Type PeeringConditionType `json:"type"`
+	// Status of the condition.
+	// +kubebuilder:validation:Enum="None";"Pending";"Established";"Error"
+	// +kubebuilder:default="None"
+	Status ConditionStatusType `json:"status"`
+	// LastTransitionTime -> timestamp for when the condition last transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason -> Machine-readable, UpperCamelCase text indicating the reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+	// Message -> Human-readable message indicating details about the last status transition.
+	Message string `json:"message,omitempty"`
+}
+
+// PeeringPhase represents the overall progress of a Peering.
+type PeeringPhase string
+
+// These are valid phases of a Peering.
+const (
+	// PeeringPhaseInProgress indicates that some of the steps have not been completed yet.
+	PeeringPhaseInProgress PeeringPhase = "InProgress"
+	// PeeringPhaseEstablished indicates that all the steps have been completed.
+	PeeringPhaseEstablished PeeringPhase = "Established"
+	// PeeringPhaseError indicates that a step failed.
+	PeeringPhaseError PeeringPhase = "Error"
+	// PeeringPhaseTerminating indicates that the peering is being torn down, as the Peering is being deleted.
+	PeeringPhaseTerminating PeeringPhase = "Terminating"
+)
+
+// PeeringStatus defines the observed state of Peering.
+type PeeringStatus struct {
+	// Phase is the overall progress of the Peering.
+	// +kubebuilder:validation:Enum="InProgress";"Established";"Error";"Terminating"
+	Phase PeeringPhase `json:"phase,omitempty"`
+	// RemoteClusterID is the ID of the provider cluster.
+	RemoteClusterID ClusterID `json:"remoteClusterID,omitempty"`
+	// ObservedGeneration is the generation of the Peering last processed by the controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions contains the status of each step performed to establish the Peering.
+	Conditions []PeeringCondition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="RemoteClusterID",type=string,JSONPath=`.status.remoteClusterID`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Peering is the Schema for the peerings API.
+// It declares, on the consumer cluster, the desired peering with a provider cluster.
+type Peering struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PeeringSpec   `json:"spec,omitempty"`
+	Status PeeringStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PeeringList contains a list of Peering.
+type PeeringList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Peering `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Peering{}, &PeeringList{})
+}
@@ -19,9 +19,122 @@
 package v1beta1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnnouncedNetworking) DeepCopyInto(out *AnnouncedNetworking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnnouncedNetworking.
+func (in *AnnouncedNetworking) DeepCopy() *AnnouncedNetworking {
+	if in == nil {
+		return nil
+	}
+	out := new(AnnouncedNetworking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAnnouncement) DeepCopyInto(out *ClusterAnnouncement) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAnnouncement.
+func (in *ClusterAnnouncement) DeepCopy() *ClusterAnnouncement {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAnnouncement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAnnouncement) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAnnouncementList) DeepCopyInto(out *ClusterAnnouncementList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAnnouncement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAnnouncementList.
+func (in *ClusterAnnouncementList) DeepCopy() *ClusterAnnouncementList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAnnouncementList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAnnouncementList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAnnouncementSpec) DeepCopyInto(out *ClusterAnnouncementSpec) {
+	*out = *in
+	if in.ResourceClasses != nil {
+		in, out := &in.ResourceClasses, &out.ResourceClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Networking = in.Networking
+	if in.PublicKey != nil {
+		in, out := &in.PublicKey, &out.PublicKey
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	in.ExpirationTime.DeepCopyInto(&out.ExpirationTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAnnouncementSpec.
+func (in *ClusterAnnouncementSpec) DeepCopy() *ClusterAnnouncementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAnnouncementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -124,6 +237,11 @@ func (in *ForeignClusterStatus) DeepCopyInto(out *ForeignClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(HealthSummary)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForeignClusterStatus.
@@ -136,6 +254,37 @@ func (in *ForeignClusterStatus) DeepCopy() *ForeignClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayType) DeepCopyInto(out *GatewayType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayType.
+func (in *GatewayType) DeepCopy() *GatewayType {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthSummary) DeepCopyInto(out *HealthSummary) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthSummary.
+func (in *HealthSummary) DeepCopy() *HealthSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressType) DeepCopyInto(out *IngressType) {
 	*out = *in
@@ -206,9 +355,182 @@ func (in *Modules) DeepCopy() *Modules {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Peering) DeepCopyInto(out *Peering) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Peering.
+func (in *Peering) DeepCopy() *Peering {
+	if in == nil {
+		return nil
+	}
+	out := new(Peering)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Peering) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringAuthentication) DeepCopyInto(out *PeeringAuthentication) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringAuthentication.
+func (in *PeeringAuthentication) DeepCopy() *PeeringAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringCondition) DeepCopyInto(out *PeeringCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringCondition.
+func (in *PeeringCondition) DeepCopy() *PeeringCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringList) DeepCopyInto(out *PeeringList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Peering, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringList.
+func (in *PeeringList) DeepCopy() *PeeringList {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PeeringList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringNetworking) DeepCopyInto(out *PeeringNetworking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringNetworking.
+func (in *PeeringNetworking) DeepCopy() *PeeringNetworking {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringNetworking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringOffloading) DeepCopyInto(out *PeeringOffloading) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringOffloading.
+func (in *PeeringOffloading) DeepCopy() *PeeringOffloading {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringOffloading)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringSpec) DeepCopyInto(out *PeeringSpec) {
+	*out = *in
+	out.KubeconfigSecretRef = in.KubeconfigSecretRef
+	out.Networking = in.Networking
+	out.Authentication = in.Authentication
+	in.Offloading.DeepCopyInto(&out.Offloading)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringSpec.
+func (in *PeeringSpec) DeepCopy() *PeeringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringStatus) DeepCopyInto(out *PeeringStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]PeeringCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringStatus.
+func (in *PeeringStatus) DeepCopy() *PeeringStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageType) DeepCopyInto(out *StorageType) {
 	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageType.
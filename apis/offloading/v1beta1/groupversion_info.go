@@ -92,6 +92,15 @@ var (
 	// VkOptionsTemplateGroupVersionResource is groupResourceVersion used to register these objects.
 	VkOptionsTemplateGroupVersionResource = SchemeGroupVersion.WithResource(VkOptionsTemplateResource)
 
+	// UsageRecordResource is the resource name used to register the UsageRecord CRD.
+	UsageRecordResource = "usagerecords"
+
+	// UsageRecordGroupResource is group resource used to register these objects.
+	UsageRecordGroupResource = schema.GroupResource{Group: SchemeGroupVersion.Group, Resource: UsageRecordResource}
+
+	// UsageRecordGroupVersionResource is groupResourceVersion used to register these objects.
+	UsageRecordGroupVersionResource = SchemeGroupVersion.WithResource(UsageRecordResource)
+
 	// SchemeBuilder is used to add go types to the GroupVersionKind scheme.
 	SchemeBuilder = &scheme.Builder{GroupVersion: SchemeGroupVersion}
 
@@ -45,19 +45,42 @@ type QuotaSpec struct {
 	Cordoned *bool `json:"cordoned,omitempty"`
 }
 
+// TenantUsage contains the amount of resources consumed by a tenant with respect to its quota.
+type TenantUsage struct {
+	// Requested contains the total amount of resources granted to the user (i.e., the quota limits).
+	Requested corev1.ResourceList `json:"requested,omitempty"`
+	// Used contains the amount of resources currently used by the ShadowPods of the user.
+	Used corev1.ResourceList `json:"used,omitempty"`
+	// Free contains the amount of resources still available to the user.
+	Free corev1.ResourceList `json:"free,omitempty"`
+	// ShadowPods is the number of ShadowPods currently running for the user.
+	ShadowPods int32 `json:"shadowPods,omitempty"`
+}
+
+// QuotaStatus defines the observed state of Quota.
+type QuotaStatus struct {
+	// Usage contains the resource usage of the user, as tracked by the offloading validation webhook.
+	Usage TenantUsage `json:"usage,omitempty"`
+	// LastUpdateTime is the last time the usage has been updated.
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:categories=liqo,shortName=qt
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Enforcement",type=string,JSONPath=`.spec.limitsEnforcement`
 // +kubebuilder:printcolumn:name="Cordoned",type=boolean,JSONPath=`.spec.cordoned`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.spec.user`,priority=1
+// +kubebuilder:printcolumn:name="ShadowPods",type=integer,JSONPath=`.status.usage.shadowPods`,priority=1
 
 // Quota is the Schema for the quota API.
 type Quota struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec QuotaSpec `json:"spec"`
+	Spec   QuotaSpec   `json:"spec"`
+	Status QuotaStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,82 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+// UsageRecordSpec defines the accounting period covered by a UsageRecord.
+type UsageRecordSpec struct {
+	// ClusterID is the id of the consumer cluster whose consumption is accounted.
+	ClusterID liqov1beta1.ClusterID `json:"clusterID"`
+	// PeriodStart is the beginning of the accounting period.
+	PeriodStart metav1.Time `json:"periodStart"`
+	// PeriodEnd is the end of the accounting period.
+	PeriodEnd metav1.Time `json:"periodEnd"`
+}
+
+// NamespaceUsage contains the resources consumed in a given offloaded namespace during the accounting period.
+// Quantities are integrated over time and expressed in resource-seconds (e.g., CPU core-seconds, memory byte-seconds).
+type NamespaceUsage struct {
+	// Namespace is the name of the namespace hosting the offloaded pods.
+	Namespace string `json:"namespace"`
+	// Requested contains the resource requests of the offloaded pods, integrated over time.
+	Requested corev1.ResourceList `json:"requested,omitempty"`
+	// Used contains the actual resource usage of the offloaded pods, integrated over time.
+	// It is populated only if the collection of the actual usage is enabled.
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
+// UsageRecordStatus contains the resources consumed by the consumer cluster during the accounting period.
+type UsageRecordStatus struct {
+	// Namespaces contains the consumption of each offloaded namespace.
+	Namespaces []NamespaceUsage `json:"namespaces,omitempty"`
+	// LastSampleTime is the time of the last sample accounted in this record.
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo,shortName=ur
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ClusterID",type=string,JSONPath=`.spec.clusterID`
+// +kubebuilder:printcolumn:name="Start",type=string,JSONPath=`.spec.periodStart`
+// +kubebuilder:printcolumn:name="End",type=string,JSONPath=`.spec.periodEnd`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UsageRecord contains the rollup of the resources consumed by a consumer cluster in a given accounting period.
+type UsageRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UsageRecordSpec   `json:"spec"`
+	Status UsageRecordStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UsageRecordList contains a list of UsageRecord.
+type UsageRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UsageRecord `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UsageRecord{}, &UsageRecordList{})
+}
@@ -49,6 +49,21 @@ type DeploymentTemplate struct {
 	Spec appsv1.DeploymentSpec `json:"spec,omitempty"`
 }
 
+// ClusterScopedResourceKind is the kind of a cluster-scoped resource which can be reflected towards the remote cluster.
+// +kubebuilder:validation:Enum=PriorityClass;RuntimeClass;StorageClass;IngressClass
+type ClusterScopedResourceKind string
+
+const (
+	// PriorityClassKind identifies the PriorityClass resources.
+	PriorityClassKind ClusterScopedResourceKind = "PriorityClass"
+	// RuntimeClassKind identifies the RuntimeClass resources.
+	RuntimeClassKind ClusterScopedResourceKind = "RuntimeClass"
+	// StorageClassKind identifies the StorageClass resources.
+	StorageClassKind ClusterScopedResourceKind = "StorageClass"
+	// IngressClassKind identifies the IngressClass resources.
+	IngressClassKind ClusterScopedResourceKind = "IngressClass"
+)
+
 // VirtualNodeSpec defines the desired state of VirtualNode.
 type VirtualNodeSpec struct {
 	// ClusterID contains the id of the remote cluster targeted by the created virtualKubelet.
@@ -81,6 +96,11 @@ type VirtualNodeSpec struct {
 	IngressClasses []liqov1beta1.IngressType `json:"ingressClasses,omitempty"`
 	// LoadBalancerClasses contains the list of the load balancer classes offered by the cluster.
 	LoadBalancerClasses []liqov1beta1.LoadBalancerType `json:"loadBalancerClasses,omitempty"`
+	// Gateways contains the list of the Gateway API gateways offered by the cluster.
+	Gateways []liqov1beta1.GatewayType `json:"gateways,omitempty"`
+	// ClusterScopedReflection contains the kinds of the cluster-scoped resources to be reflected towards the remote cluster.
+	// The remote cluster must grant the corresponding permission to the tenant.
+	ClusterScopedReflection []ClusterScopedResourceKind `json:"clusterScopedReflection,omitempty"`
 	// VkOptionsTemplateRef contains the namespaced reference to the VkOptionsTemplate.
 	// If not set, the default template installed with Liqo will be used.
 	// +optional
@@ -130,9 +150,43 @@ type VirtualNodeCondition struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ReflectorNamespaceStatus contains the status of a reflector for a given namespace.
+type ReflectorNamespaceStatus struct {
+	// Namespace is the name of the local namespace.
+	Namespace string `json:"namespace"`
+	// Reflected is the number of objects successfully reflected.
+	Reflected int32 `json:"reflected"`
+	// Pending is the number of objects waiting to be reflected.
+	Pending int32 `json:"pending"`
+	// Failed is the number of objects whose last reflection attempt failed.
+	Failed int32 `json:"failed"`
+	// LastError is the last error occurred while reflecting an object, if any.
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is the timestamp of the last error occurred while reflecting an object.
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+}
+
+// ReflectorStatus contains the status of a reflector.
+type ReflectorStatus struct {
+	// Resource is the name of the resource handled by the reflector.
+	Resource string `json:"resource"`
+	// Namespaces contains the status of the reflector for each reflected namespace.
+	Namespaces []ReflectorNamespaceStatus `json:"namespaces,omitempty"`
+}
+
+// ReflectionStatus contains the status of the reflection of the objects towards the remote cluster.
+type ReflectionStatus struct {
+	// LastUpdateTime is the timestamp of the last update of the reflection status.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Reflectors contains the status of each reflector.
+	Reflectors []ReflectorStatus `json:"reflectors,omitempty"`
+}
+
 // VirtualNodeStatus contains some information about remote namespace status.
 type VirtualNodeStatus struct {
 	Conditions []VirtualNodeCondition `json:"conditions,omitempty"`
+	// Reflection contains the status of the reflection towards the remote cluster, as reported by the virtual kubelet.
+	Reflection *ReflectionStatus `json:"reflection,omitempty"`
 }
 
 // +kubebuilder:object:root=true
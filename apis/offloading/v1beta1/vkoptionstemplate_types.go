@@ -39,11 +39,16 @@ type VkOptionsTemplateSpec struct {
 }
 
 // ReflectorConfig contains configuration parameters of the reflector.
+// Reflectors are identified by the name of the reflected resource (e.g., configmap) in the ReflectorsConfig map,
+// while custom resources are identified in the <resource>.<version>.<group> form (e.g., certificates.v1.cert-manager.io).
 type ReflectorConfig struct {
 	// Number of workers for the reflector.
 	NumWorkers uint `json:"workers"`
 	// Type of reflection.
 	Type ReflectionType `json:"type,omitempty"`
+	// ReflectStatus enables the reflection of the status of the remote objects back to the local ones.
+	// It is supported only by the reflectors of custom resources.
+	ReflectStatus bool `json:"reflectStatus,omitempty"`
 }
 
 // ReflectionType is the type of reflection.
@@ -302,6 +302,35 @@ func (in *NamespaceOffloadingStatus) DeepCopy() *NamespaceOffloadingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceUsage) DeepCopyInto(out *NamespaceUsage) {
+	*out = *in
+	if in.Requested != nil {
+		in, out := &in.Requested, &out.Requested
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceUsage.
+func (in *NamespaceUsage) DeepCopy() *NamespaceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OffloadingPatch) DeepCopyInto(out *OffloadingPatch) {
 	*out = *in
@@ -352,6 +381,7 @@ func (in *Quota) DeepCopyInto(out *Quota) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Quota.
@@ -431,6 +461,49 @@ func (in *QuotaSpec) DeepCopy() *QuotaSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaStatus) DeepCopyInto(out *QuotaStatus) {
+	*out = *in
+	in.Usage.DeepCopyInto(&out.Usage)
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaStatus.
+func (in *QuotaStatus) DeepCopy() *QuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReflectionStatus) DeepCopyInto(out *ReflectionStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Reflectors != nil {
+		in, out := &in.Reflectors, &out.Reflectors
+		*out = make([]ReflectorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReflectionStatus.
+func (in *ReflectionStatus) DeepCopy() *ReflectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReflectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReflectorConfig) DeepCopyInto(out *ReflectorConfig) {
 	*out = *in
@@ -446,6 +519,47 @@ func (in *ReflectorConfig) DeepCopy() *ReflectorConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReflectorNamespaceStatus) DeepCopyInto(out *ReflectorNamespaceStatus) {
+	*out = *in
+	if in.LastErrorTime != nil {
+		in, out := &in.LastErrorTime, &out.LastErrorTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReflectorNamespaceStatus.
+func (in *ReflectorNamespaceStatus) DeepCopy() *ReflectorNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReflectorNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReflectorStatus) DeepCopyInto(out *ReflectorStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]ReflectorNamespaceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReflectorStatus.
+func (in *ReflectorStatus) DeepCopy() *ReflectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReflectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteNamespaceCondition) DeepCopyInto(out *RemoteNamespaceCondition) {
 	*out = *in
@@ -662,6 +776,144 @@ func (in *ShadowPodStatus) DeepCopy() *ShadowPodStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantUsage) DeepCopyInto(out *TenantUsage) {
+	*out = *in
+	if in.Requested != nil {
+		in, out := &in.Requested, &out.Requested
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Free != nil {
+		in, out := &in.Free, &out.Free
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantUsage.
+func (in *TenantUsage) DeepCopy() *TenantUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecord) DeepCopyInto(out *UsageRecord) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecord.
+func (in *UsageRecord) DeepCopy() *UsageRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UsageRecord) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordList) DeepCopyInto(out *UsageRecordList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]UsageRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordList.
+func (in *UsageRecordList) DeepCopy() *UsageRecordList {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UsageRecordList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordSpec) DeepCopyInto(out *UsageRecordSpec) {
+	*out = *in
+	in.PeriodStart.DeepCopyInto(&out.PeriodStart)
+	in.PeriodEnd.DeepCopyInto(&out.PeriodEnd)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordSpec.
+func (in *UsageRecordSpec) DeepCopy() *UsageRecordSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordStatus) DeepCopyInto(out *UsageRecordStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSampleTime != nil {
+		in, out := &in.LastSampleTime, &out.LastSampleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordStatus.
+func (in *UsageRecordStatus) DeepCopy() *UsageRecordStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualNode) DeepCopyInto(out *VirtualNode) {
 	*out = *in
@@ -797,7 +1049,9 @@ func (in *VirtualNodeSpec) DeepCopyInto(out *VirtualNodeSpec) {
 	if in.StorageClasses != nil {
 		in, out := &in.StorageClasses, &out.StorageClasses
 		*out = make([]corev1beta1.StorageType, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.IngressClasses != nil {
 		in, out := &in.IngressClasses, &out.IngressClasses
@@ -809,6 +1063,16 @@ func (in *VirtualNodeSpec) DeepCopyInto(out *VirtualNodeSpec) {
 		*out = make([]corev1beta1.LoadBalancerType, len(*in))
 		copy(*out, *in)
 	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]corev1beta1.GatewayType, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterScopedReflection != nil {
+		in, out := &in.ClusterScopedReflection, &out.ClusterScopedReflection
+		*out = make([]ClusterScopedResourceKind, len(*in))
+		copy(*out, *in)
+	}
 	if in.VkOptionsTemplateRef != nil {
 		in, out := &in.VkOptionsTemplateRef, &out.VkOptionsTemplateRef
 		*out = new(v1.ObjectReference)
@@ -836,6 +1100,11 @@ func (in *VirtualNodeStatus) DeepCopyInto(out *VirtualNodeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Reflection != nil {
+		in, out := &in.Reflection, &out.Reflection
+		*out = new(ReflectionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualNodeStatus.
@@ -33,6 +33,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -51,6 +52,7 @@ import (
 	"github.com/liqotech/liqo/pkg/ipam"
 	remoteresourceslicecontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication/remoteresourceslice-controller"
 	foreignclustercontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/core/foreigncluster-controller"
+	peeringcontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/core/peering-controller"
 	ipmapping "github.com/liqotech/liqo/pkg/liqo-controller-manager/ipmapping"
 	quotacreatorcontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/quotacreator-controller"
 	virtualnodecreatorcontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/virtualnodecreator-controller"
@@ -63,6 +65,7 @@ import (
 	ipamips "github.com/liqotech/liqo/pkg/utils/ipam/mapping"
 	"github.com/liqotech/liqo/pkg/utils/mapper"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/rsync"
 )
 
 var (
@@ -85,6 +88,7 @@ func main() {
 	var clusterLabels argsutils.StringMap
 	var ingressClasses argsutils.ClassNameList
 	var loadBalancerClasses argsutils.ClassNameList
+	var gateways argsutils.ClassNameList
 	var defaultNodeResources argsutils.ResourceMap
 	var gatewayServerResources argsutils.StringList
 	var gatewayClientResources argsutils.StringList
@@ -92,6 +96,7 @@ func main() {
 	var caOverride string
 	var trustedCA bool
 	var awsConfig identitymanager.LocalAwsConfig
+	var discoveryResourceClasses argsutils.StringList
 
 	// Cluster-wide modules enable/disable flags.
 	networkingEnabled := pflag.Bool("networking-enabled", true, "Enable/disable the networking module")
@@ -148,6 +153,8 @@ func main() {
 		"The set of labels which characterizes the local cluster when exposed remotely as a virtual node")
 	pflag.Var(&ingressClasses, "ingress-classes", "List of ingress classes offered by the cluster. Example: \"nginx;default,traefik\"")
 	pflag.Var(&loadBalancerClasses, "load-balancer-classes", "List of load balancer classes offered by the cluster. Example:\"metallb;default\"")
+	pflag.Var(&gateways, "gateways",
+		"List of Gateway API gateways offered by the cluster, in the <namespace>/<name> form. Example: \"gateways/public;default,gateways/internal\"")
 	pflag.Var(&defaultNodeResources, "default-node-resources", "Default resources assigned to the Virtual Node Pod")
 
 	// OFFLOADING MODULE
@@ -156,13 +163,42 @@ func main() {
 	virtualStorageClassName := pflag.String("virtual-storage-class-name", "liqo", "Name of the virtual storage class")
 	realStorageClassName := pflag.String("real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
 	storageNamespace := pflag.String("storage-namespace", "liqo-storage", "Namespace where the liqo storage-related resources are stored")
+	enableStorageCapacityTracking := pflag.Bool("enable-storage-capacity-tracking", false,
+		"Enable the publication of the storage capacity of the virtual storage class, to let the scheduler account for it")
+	storageReplicationImage := pflag.String("storage-replication-image", rsync.DefaultImage,
+		"The rsync image used to replicate the virtual PVCs to a standby volume")
 	// Service continuity
 	enableNodeFailureController := pflag.Bool("enable-node-failure-controller", false, "Enable the node failure controller")
+	// Multi-Cluster Services API
+	enableMultiClusterServices := pflag.Bool("enable-multicluster-services", false,
+		"Enable the compatibility layer with the Multi-Cluster Services API (requires the ServiceExport and ServiceImport CRDs)")
+	multiClusterServicesResyncPeriod := pflag.Duration("multicluster-services-resync-period", 30*time.Second,
+		"The period the services exported by the remote clusters are checked with")
+	// Accounting
+	enableAccounting := pflag.Bool("enable-accounting", false, "Enable the accounting of the resources consumed by the offloaded pods")
+	accountingSampleInterval := pflag.Duration("accounting-sample-interval", 1*time.Minute,
+		"The interval between two samples of the resources consumed by the offloaded pods")
+	accountingRetention := pflag.Duration("accounting-retention", 7*24*time.Hour,
+		"The retention period of the UsageRecords (set to 0 to disable the garbage collection)")
+	accountingCollectUsage := pflag.Bool("accounting-collect-usage", false,
+		"Account also the actual usage of the offloaded pods, retrieved from the metrics server")
 	// Controllers workers
 	shadowPodWorkers := pflag.Int("shadow-pod-ctrl-workers", 10, "The number of workers used to reconcile ShadowPod resources.")
 	shadowEndpointSliceWorkers := pflag.Int("shadow-endpointslice-ctrl-workers", 10,
 		"The number of workers used to reconcile ShadowEndpointSlice resources.")
 
+	// DISCOVERY
+	discoveryRegistryURL := pflag.String("discovery-registry-url", "",
+		"The URL of the HTTP cluster registry used to discover the candidate peers")
+	discoveryRegistryKubeconfigSecretName := pflag.String("discovery-registry-kubeconfig-secret-name", "",
+		"The name of the secret (in the Liqo namespace) containing the kubeconfig of the cluster hosting the cluster registry")
+	discoveryRegistryNamespace := pflag.String("discovery-registry-namespace", "liqo-registry",
+		"The namespace of the cluster registry, when hosted by a Kubernetes cluster")
+	discoveryPublish := pflag.Bool("discovery-publish", false, "Publish the announcement of the local cluster to the cluster registry")
+	pflag.Var(&discoveryResourceClasses, "discovery-resource-classes", "The ResourceSlice classes advertised in the cluster announcement")
+	discoveryInterval := pflag.Duration("discovery-interval", 5*time.Minute,
+		"The interval between two publications/retrievals of the announcements to/from the cluster registry")
+
 	// CROSS MODULE
 	enableAPIServerIPRemapping := pflag.Bool("enable-api-server-ip-remapping", true, "Enable the API server IP remapping")
 
@@ -308,6 +344,7 @@ func main() {
 				LocalRealStorageClassName: *realStorageClassName,
 				IngressClasses:            ingressClasses,
 				LoadBalancerClasses:       loadBalancerClasses,
+				Gateways:                  gateways,
 				ClusterLabels:             clusterLabels.StringMap,
 				DefaultResourceQuantity:   defaultNodeResources.ToResourceList(),
 			},
@@ -319,6 +356,8 @@ func main() {
 		}
 	}
 
+	idManager := identitymanager.NewCertificateIdentityManager(ctx, mgr.GetClient(), clientset, mgr.GetConfig(), clusterID, namespaceManager)
+
 	// OFFLOADING MODULE
 	if *offloadingEnabled {
 		opts := &modules.OffloadingOption{
@@ -329,10 +368,21 @@ func main() {
 			VirtualStorageClassName:     *virtualStorageClassName,
 			RealStorageClassName:        *realStorageClassName,
 			StorageNamespace:            *storageNamespace,
+			EnableStorageCapacity:       *enableStorageCapacityTracking,
+			StorageReplicationImage:     *storageReplicationImage,
 			EnableNodeFailureController: *enableNodeFailureController,
+			EnableMultiClusterServices:  *enableMultiClusterServices,
+			MultiClusterServicesResync:  *multiClusterServicesResyncPeriod,
+			IdentityReader:              idManager,
 			ShadowPodWorkers:            *shadowPodWorkers,
 			ShadowEndpointSliceWorkers:  *shadowEndpointSliceWorkers,
 			ResyncPeriod:                *resyncPeriod,
+			EnableAccounting:            *enableAccounting,
+			AccountingSampleInterval:    *accountingSampleInterval,
+			AccountingRetention:         *accountingRetention,
+		}
+		if *enableAccounting && *accountingCollectUsage {
+			opts.AccountingMetricsClient = metricsclient.NewForConfigOrDie(config).MetricsV1beta1()
 		}
 
 		if err := modules.SetupOffloadingModule(ctx, mgr, opts); err != nil {
@@ -341,6 +391,26 @@ func main() {
 		}
 	}
 
+	// DISCOVERY MODULE
+	discoveryOpts := &modules.DiscoveryOption{
+		LocalClusterID:               clusterID,
+		LiqoNamespace:                *liqoNamespace,
+		APIServerAddressOverride:     apiServerAddressOverride,
+		ClusterLabels:                clusterLabels.StringMap,
+		RegistryURL:                  *discoveryRegistryURL,
+		RegistryKubeconfigSecretName: *discoveryRegistryKubeconfigSecretName,
+		RegistryNamespace:            *discoveryRegistryNamespace,
+		Publish:                      *discoveryPublish,
+		ResourceClasses:              discoveryResourceClasses.StringList,
+		Interval:                     *discoveryInterval,
+	}
+	if modules.IsDiscoveryEnabled(discoveryOpts) {
+		if err := modules.SetupDiscoveryModule(ctx, mgr, uncachedClient, discoveryOpts); err != nil {
+			klog.Errorf("Unable to setup the discovery module: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	// CROSS MODULE OPERATORS
 
 	// AUTHENTICATION MODULE & OFFLOADING MODULE
@@ -400,11 +470,11 @@ func main() {
 
 	// CORE OPERATORS
 	// Configure the foreigncluster controller.
-	idManager := identitymanager.NewCertificateIdentityManager(ctx, mgr.GetClient(), clientset, mgr.GetConfig(), clusterID, namespaceManager)
 	foreignClusterReconciler := &foreignclustercontroller.ForeignClusterReconciler{
-		Client:       mgr.GetClient(),
-		Scheme:       mgr.GetScheme(),
-		ResyncPeriod: *resyncPeriod,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: mgr.GetEventRecorderFor("foreigncluster-controller"),
+		ResyncPeriod:  *resyncPeriod,
 
 		NetworkingEnabled:     *networkingEnabled,
 		AuthenticationEnabled: *authenticationEnabled,
@@ -417,6 +487,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Configure the peering controller, which declaratively establishes the peerings with the provider clusters.
+	if *authenticationEnabled {
+		peeringReconciler := peeringcontroller.NewPeeringReconciler(mgr.GetClient(), mgr.GetScheme(),
+			mgr.GetEventRecorderFor("peering-controller"), clientset, namespaceManager,
+			*liqoNamespace, clusterID, *resyncPeriod)
+		if err = peeringReconciler.SetupWithManager(mgr); err != nil {
+			klog.Errorf("Unable to setup the peering reconciler: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	// Start the manager.
 	klog.Info("starting manager as controller manager")
 	if err := mgr.Start(ctx); err != nil {
@@ -0,0 +1,123 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/discovery"
+	"github.com/liqotech/liqo/pkg/utils/apiserver"
+	"github.com/liqotech/liqo/pkg/utils/kubeconfig"
+)
+
+// DiscoveryOption defines the options to setup the discovery module.
+type DiscoveryOption struct {
+	LocalClusterID           liqov1beta1.ClusterID
+	LiqoNamespace            string
+	APIServerAddressOverride string
+	ClusterLabels            map[string]string
+
+	RegistryURL                  string
+	RegistryKubeconfigSecretName string
+	RegistryNamespace            string
+	Publish                      bool
+	ResourceClasses              []string
+	Interval                     time.Duration
+}
+
+// IsDiscoveryEnabled returns whether a cluster registry has been configured.
+func IsDiscoveryEnabled(opts *DiscoveryOption) bool {
+	return opts.RegistryURL != "" || opts.RegistryKubeconfigSecretName != ""
+}
+
+// SetupDiscoveryModule setup the discovery module, which publishes the local cluster announcement
+// and retrieves the ones of the candidate peers from the cluster registry.
+func SetupDiscoveryModule(ctx context.Context, mgr manager.Manager, uncachedClient client.Client, opts *DiscoveryOption) error {
+	registry, err := newRegistry(ctx, mgr, uncachedClient, opts)
+	if err != nil {
+		klog.Errorf("Unable to configure the cluster registry: %v", err)
+		return err
+	}
+
+	if opts.Publish {
+		apiServerURL, err := apiserver.GetURL(ctx, uncachedClient, opts.APIServerAddressOverride)
+		if err != nil {
+			klog.Errorf("Unable to retrieve the API server URL: %v", err)
+			return err
+		}
+
+		publisher := &discovery.Publisher{
+			Client:        uncachedClient,
+			Registry:      registry,
+			LiqoNamespace: opts.LiqoNamespace,
+			Announcement: discovery.AnnouncementOptions{
+				ClusterID:       opts.LocalClusterID,
+				APIServerURL:    apiServerURL,
+				ResourceClasses: opts.ResourceClasses,
+				Labels:          opts.ClusterLabels,
+			},
+			Interval: opts.Interval,
+		}
+		if err := mgr.Add(publisher); err != nil {
+			klog.Errorf("Unable to add the cluster announcement publisher to the manager: %v", err)
+			return err
+		}
+	}
+
+	syncer := &discovery.Syncer{
+		Client:         mgr.GetClient(),
+		Registry:       registry,
+		LocalClusterID: opts.LocalClusterID,
+		Namespace:      opts.LiqoNamespace,
+		Interval:       opts.Interval,
+	}
+	if err := mgr.Add(syncer); err != nil {
+		klog.Errorf("Unable to add the cluster announcement syncer to the manager: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func newRegistry(ctx context.Context, mgr manager.Manager, uncachedClient client.Client, opts *DiscoveryOption) (discovery.Registry, error) {
+	if opts.RegistryURL != "" {
+		return discovery.NewHTTPRegistry(opts.RegistryURL), nil
+	}
+
+	var secret corev1.Secret
+	key := client.ObjectKey{Name: opts.RegistryKubeconfigSecretName, Namespace: opts.LiqoNamespace}
+	if err := uncachedClient.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("unable to get the registry kubeconfig secret %q: %w", key, err)
+	}
+
+	cfg, err := kubeconfig.BuildConfigFromSecret(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build the registry REST config: %w", err)
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the registry client: %w", err)
+	}
+	return discovery.NewKubernetesRegistry(cl, opts.RegistryNamespace), nil
+}
@@ -25,15 +25,17 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
 	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
-	"github.com/liqotech/liqo/pkg/consts"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/accounting"
 	mapsctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/namespacemap-controller"
 	nsoffctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/namespaceoffloading-controller"
 	nodefailurectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/nodefailure-controller"
 	podstatusctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/podstatus-controller"
+	serviceexportctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/serviceexport-controller"
 	shadowepsctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/shadowendpointslice-controller"
 	shadowpodctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/shadowpod-controller"
 	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/storageprovisioner"
@@ -51,10 +53,19 @@ type OffloadingOption struct {
 	VirtualStorageClassName     string
 	RealStorageClassName        string
 	StorageNamespace            string
+	EnableStorageCapacity       bool
+	StorageReplicationImage     string
 	EnableNodeFailureController bool
+	EnableMultiClusterServices  bool
+	MultiClusterServicesResync  time.Duration
+	IdentityReader              identitymanager.IdentityReader
 	ShadowPodWorkers            int
 	ShadowEndpointSliceWorkers  int
 	ResyncPeriod                time.Duration
+	EnableAccounting            bool
+	AccountingSampleInterval    time.Duration
+	AccountingRetention         time.Duration
+	AccountingMetricsClient     metricsv1beta1.PodMetricsesGetter
 }
 
 // SetupOffloadingModule setup the offloading module and initializes its controllers.
@@ -119,13 +130,69 @@ func SetupOffloadingModule(ctx context.Context, mgr manager.Manager, opts *Offlo
 			klog.Errorf("unable to start the liqo storage provisioner: %v", err)
 			return err
 		}
-		provisionController := controller.NewProvisionController(opts.Clientset, consts.StorageProvisionerName, liqoProvisioner,
-			controller.LeaderElection(false),
-		)
-		if err = mgr.Add(liqostorageprovisioner.StorageControllerRunnable{Ctrl: provisionController}); err != nil {
-			klog.Errorf("unable to add the storage provisioner controller to the manager: %v", err)
+		for _, provisionController := range liqostorageprovisioner.NewProvisionControllers(opts.Clientset, liqoProvisioner,
+			opts.EnableStorageCapacity) {
+			if err = mgr.Add(liqostorageprovisioner.StorageControllerRunnable{Ctrl: provisionController}); err != nil {
+				klog.Errorf("unable to add the storage provisioner controller to the manager: %v", err)
+				return err
+			}
+		}
+
+		volumeResizeReconciler := &liqostorageprovisioner.VolumeResizeReconciler{
+			Client:                  mgr.GetClient(),
+			Recorder:                mgr.GetEventRecorderFor("volume-resize-controller"),
+			VirtualStorageClassName: opts.VirtualStorageClassName,
+			StorageNamespace:        opts.StorageNamespace,
+		}
+		if err = volumeResizeReconciler.SetupWithManager(mgr); err != nil {
+			klog.Errorf("Unable to setup the volume resize reconciler: %v", err)
+			return err
+		}
+
+		volumeReplicationReconciler := &liqostorageprovisioner.VolumeReplicationReconciler{
+			Client:                  mgr.GetClient(),
+			Recorder:                mgr.GetEventRecorderFor("volume-replication-controller"),
+			VirtualStorageClassName: opts.VirtualStorageClassName,
+			RsyncImage:              opts.StorageReplicationImage,
+		}
+		if err = volumeReplicationReconciler.SetupWithManager(mgr); err != nil {
+			klog.Errorf("Unable to setup the volume replication reconciler: %v", err)
+			return err
+		}
+
+		if opts.EnableStorageCapacity {
+			storageCapacityReconciler := &liqostorageprovisioner.StorageCapacityReconciler{
+				Client:                  mgr.GetClient(),
+				VirtualStorageClassName: opts.VirtualStorageClassName,
+				RealStorageClassName:    opts.RealStorageClassName,
+				StorageNamespace:        opts.StorageNamespace,
+			}
+			if err = storageCapacityReconciler.SetupWithManager(mgr); err != nil {
+				klog.Errorf("Unable to setup the storage capacity reconciler: %v", err)
+				return err
+			}
+		}
+
+		// The CSI snapshot CRDs are not part of the core APIs, hence the snapshots are handled only if available.
+		snapshotSupported, err := liqostorageprovisioner.IsVolumeSnapshotSupported(mgr)
+		if err != nil {
+			klog.Errorf("Unable to check whether volume snapshots are supported: %v", err)
 			return err
 		}
+		if snapshotSupported {
+			volumeSnapshotReconciler := &liqostorageprovisioner.VolumeSnapshotReconciler{
+				Client:                  mgr.GetClient(),
+				Recorder:                mgr.GetEventRecorderFor("volume-snapshot-controller"),
+				VirtualStorageClassName: opts.VirtualStorageClassName,
+				StorageNamespace:        opts.StorageNamespace,
+			}
+			if err = volumeSnapshotReconciler.SetupWithManager(mgr); err != nil {
+				klog.Errorf("Unable to setup the volume snapshot reconciler: %v", err)
+				return err
+			}
+		} else {
+			klog.Info("Volume snapshots are not supported by the cluster, skipping the setup of the volume snapshot reconciler")
+		}
 	}
 
 	// Start the handler to approve the virtual kubelet certificate signing requests.
@@ -158,5 +225,33 @@ func SetupOffloadingModule(ctx context.Context, mgr manager.Manager, opts *Offlo
 		}
 	}
 
+	if opts.EnableMultiClusterServices {
+		serviceExportReconciler := &serviceexportctrl.Reconciler{
+			Client:         mgr.GetClient(),
+			Scheme:         mgr.GetScheme(),
+			LocalClusterID: opts.LocalClusterID,
+			RemoteClient:   serviceexportctrl.NewRemoteClientGetter(mgr.GetClient(), mgr.GetScheme(), opts.IdentityReader),
+			ResyncPeriod:   opts.MultiClusterServicesResync,
+		}
+		if err = serviceExportReconciler.SetupWithManager(mgr); err != nil {
+			klog.Errorf("Unable to setup the serviceexport reconciler: %v", err)
+			return err
+		}
+	}
+
+	if opts.EnableAccounting {
+		accountant := &accounting.Accountant{
+			Client:           mgr.GetClient(),
+			NamespaceManager: opts.NamespaceManager,
+			MetricsClient:    opts.AccountingMetricsClient,
+			SampleInterval:   opts.AccountingSampleInterval,
+			Retention:        opts.AccountingRetention,
+		}
+		if err = mgr.Add(accountant); err != nil {
+			klog.Errorf("Unable to add the accountant to the manager: %v", err)
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,143 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/move"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils/args"
+)
+
+const liqoctlBackupNamespaceLongHelp = `Back up a (possibly offloaded) namespace.
+
+This command captures the state of a namespace, including the manifests of the
+resources it contains and its NamespaceOffloading, along with the data of its
+volumes, either hosted by the local cluster or by remote ones. The resources
+reflected in the remote clusters are not stored, as they are recreated by Liqo
+once the namespace is restored and offloaded again.
+
+The backup is stored either in a local directory or in an S3-compatible bucket
+(i.e., when the location is in the s3://bucket/prefix form). The data of the
+volumes is backed up leveraging Restic, and encrypted with the given password.
+To ensure its consistency, the Deployments and StatefulSets mounting the volumes
+are scaled down during the process, and eventually restored.
+
+Examples:
+  $ {{ .Executable }} backup namespace foo --location /backups/foo --password $PASSWORD
+or
+  $ {{ .Executable }} backup namespace foo --location s3://backups/foo --password $PASSWORD \
+      --s3-endpoint http://minio.example.com:9000
+`
+
+func newBackupCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up a resource, along with its data",
+		Long:  "Back up a resource, along with its data.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newBackupNamespaceCommand(ctx, f))
+	return cmd
+}
+
+func newBackupNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := &move.BackupOptions{Options: move.Options{Factory: f}}
+	var flags backupFlags
+
+	cmd := &cobra.Command{
+		Use:     "namespace name",
+		Aliases: []string{"ns"},
+		Short:   "Back up a (possibly offloaded) namespace, along with its volumes",
+		Long:    WithTemplate(liqoctlBackupNamespaceLongHelp),
+
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.Namespaces(ctx, f, 1),
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			output.ExitOnErr(flags.apply(&options.Options, &options.S3))
+		},
+
+		Run: func(_ *cobra.Command, args []string) {
+			options.Namespace = args[0]
+			output.ExitOnErr(options.Run(ctx))
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Location, "location", "",
+		"The location the backup is stored to, either a local directory or an S3-compatible bucket (s3://bucket/prefix)")
+	cmd.Flags().BoolVar(&options.SkipVolumes, "skip-volumes", false, "Back up only the manifests, without the data of the volumes")
+	flags.register(f, cmd, &options.Options, &options.S3)
+
+	return cmd
+}
+
+// backupFlags holds the flags shared by the backup and restore commands, which require to be parsed before being applied to the options.
+type backupFlags struct {
+	containersCPURequests, containersCPULimits args.Quantity
+	containersRAMRequests, containersRAMLimits args.Quantity
+}
+
+func (flags *backupFlags) register(f *factory.Factory, cmd *cobra.Command, options *move.Options, s3 *move.S3Options) {
+	cmd.Flags().StringVar(&options.ResticPassword, "password", "",
+		"The password used to encrypt the data of the volumes (defaults to the RESTIC_PASSWORD environment variable)")
+	cmd.Flags().StringVar(&s3.Endpoint, "s3-endpoint", move.DefaultS3Endpoint, "The endpoint of the S3-compatible backup location")
+	cmd.Flags().StringVar(&s3.Region, "s3-region", move.DefaultS3Region, "The region of the S3-compatible backup location")
+	cmd.Flags().StringVar(&s3.AccessKeyID, "s3-access-key-id", "",
+		"The access key ID of the S3-compatible backup location (defaults to the AWS_ACCESS_KEY_ID environment variable)")
+	cmd.Flags().StringVar(&s3.SecretAccessKey, "s3-secret-access-key", "",
+		"The secret access key of the S3-compatible backup location (defaults to the AWS_SECRET_ACCESS_KEY environment variable)")
+
+	cmd.Flags().Var(&flags.containersCPURequests, "containers-cpu-requests", "The CPU requests for the Restic containers")
+	cmd.Flags().Var(&flags.containersCPULimits, "containers-cpu-limits", "The CPU limits for the Restic containers")
+	cmd.Flags().Var(&flags.containersRAMRequests, "containers-ram-requests", "The RAM requests for the Restic containers")
+	cmd.Flags().Var(&flags.containersRAMLimits, "containers-ram-limits", "The RAM limits for the Restic containers")
+	cmd.Flags().StringVar(&options.ResticServerImage, "restic-server-image", move.DefaultResticServerImage,
+		"The Restic server image to use, in case of local backup locations")
+	cmd.Flags().StringVar(&options.ResticImage, "restic-image", move.DefaultResticImage, "The Restic image to use")
+
+	f.Printer.CheckErr(cmd.MarkFlagRequired("location"))
+	f.Printer.CheckErr(cmd.MarkFlagDirname("location"))
+}
+
+func (flags *backupFlags) apply(options *move.Options, s3 *move.S3Options) error {
+	options.ContainersCPURequests = flags.containersCPURequests.Quantity
+	options.ContainersCPULimits = flags.containersCPULimits.Quantity
+	options.ContainersRAMRequests = flags.containersRAMRequests.Quantity
+	options.ContainersRAMLimits = flags.containersRAMLimits.Quantity
+
+	if options.ResticPassword == "" {
+		options.ResticPassword = os.Getenv("RESTIC_PASSWORD")
+	}
+	if s3.AccessKeyID == "" {
+		s3.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if s3.SecretAccessKey == "" {
+		s3.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	if options.ResticPassword == "" {
+		return errors.New("the password used to encrypt the data of the volumes must be specified")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/discover"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils/args"
+)
+
+const liqoctlDiscoverLongHelp = `List the candidate peers published in a cluster registry.
+
+Clusters can publish a signed announcement (cluster ID, API server URL, offered
+ResourceSlice classes, labels and network CIDRs) to a shared cluster registry,
+which can be either a namespace of a hub Kubernetes cluster or an HTTP endpoint.
+This command lists the announcements with a valid signature, optionally filtered
+by labels and offered resource class, to select the clusters to peer with.
+
+If no registry is specified, the announcements already retrieved by the local
+cluster (when configured with a cluster registry) are listed.
+
+Examples:
+  $ {{ .Executable }} discover
+or
+  $ {{ .Executable }} discover --registry-url https://registry.example.com --selector region=eu-west
+or
+  $ {{ .Executable }} discover --registry-kubeconfig hub.yaml --registry-namespace liqo-registry --resource-class gpu
+`
+
+func newDiscoverCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := discover.NewOptions(f)
+	outputFormat := args.NewEnum([]string{"table", "json", "yaml"}, "table")
+
+	cmd := &cobra.Command{
+		Use:   "discover",
+		Short: "List the candidate peers published in a cluster registry",
+		Long:  WithTemplate(liqoctlDiscoverLongHelp),
+		Args:  cobra.NoArgs,
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			options.OutputFormat = outputFormat.Value
+		},
+
+		Run: func(_ *cobra.Command, _ []string) {
+			output.ExitOnErr(options.RunDiscover(ctx))
+		},
+	}
+
+	f.AddFlags(cmd.PersistentFlags(), cmd.RegisterFlagCompletionFunc)
+	f.AddLiqoNamespaceFlag(cmd.PersistentFlags())
+
+	cmd.Flags().StringVar(&options.RegistryURL, "registry-url", "", "The URL of the HTTP cluster registry")
+	cmd.Flags().StringVar(&options.RegistryKubeconfig, "registry-kubeconfig", "",
+		"The path to the kubeconfig of the cluster hosting the cluster registry")
+	cmd.Flags().StringVar(&options.RegistryNamespace, "registry-namespace", "liqo-registry",
+		"The namespace of the cluster registry, when hosted by a Kubernetes cluster")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Only list the clusters whose announced labels match the selector")
+	cmd.Flags().StringVar(&options.ResourceClass, "resource-class", "", "Only list the clusters offering the given ResourceSlice class")
+	cmd.Flags().VarP(outputFormat, "output", "o", "Output format. Supported formats: table, json, yaml")
+
+	cmd.MarkFlagsMutuallyExclusive("registry-url", "registry-kubeconfig")
+	runtime.Must(cmd.RegisterFlagCompletionFunc("output", completion.Enumeration(outputFormat.Allowed)))
+
+	return cmd
+}
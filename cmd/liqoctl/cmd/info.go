@@ -26,6 +26,7 @@ import (
 	"github.com/liqotech/liqo/pkg/liqoctl/info"
 	"github.com/liqotech/liqo/pkg/liqoctl/info/localstatus"
 	"github.com/liqotech/liqo/pkg/liqoctl/info/peer"
+	"github.com/liqotech/liqo/pkg/liqoctl/info/tenants"
 	"github.com/liqotech/liqo/pkg/liqoctl/output"
 	"github.com/liqotech/liqo/pkg/utils/args"
 )
@@ -83,6 +84,22 @@ when a single cluster is specified, the cluster ID at the beginning of the query
   $ {{ .Executable }} info peer cluster1 --get network.cidr
 `
 
+const liqoctlInfoTenantsLongHelp = `Show the resource usage of the tenants of the local cluster.
+
+This command is meant to be executed on a provider cluster, and shows, for each
+consumer cluster (i.e., tenant), the amount of resources granted through its
+quotas, the amount currently used by the offloaded pods and the amount still
+available. The usage is periodically reported by the Liqo webhook in the status
+of the Quota resources.
+
+Examples:
+  $ {{ .Executable }} info tenants
+show the output in YAML format
+  $ {{ .Executable }} info tenants -o yaml
+get a specific field
+  $ {{ .Executable }} info tenants --get tenants
+`
+
 func infoPreRun(options *info.Options) {
 	// When the output is redirected to a file is desiderable that errors ends in the stderr output.
 	options.Printer.Error.Writer = os.Stderr
@@ -122,6 +139,29 @@ func newPeerInfoCommand(ctx context.Context, f *factory.Factory, options *info.O
 	return cmd
 }
 
+func newTenantsInfoCommand(ctx context.Context, options *info.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tenants",
+		Short: "Show the resource usage of the tenants of the local cluster",
+		Long:  WithTemplate(liqoctlInfoTenantsLongHelp),
+		Args:  cobra.NoArgs,
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			infoPreRun(options)
+		},
+
+		Run: func(_ *cobra.Command, _ []string) {
+			checkers := []info.Checker{
+				&tenants.UsageChecker{},
+			}
+
+			output.ExitOnErr(options.RunInfo(ctx, checkers))
+		},
+	}
+
+	return cmd
+}
+
 func newInfoCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 	options := info.NewOptions(f)
 
@@ -159,6 +199,7 @@ func newInfoCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 	f.Printer.CheckErr(maincmd.RegisterFlagCompletionFunc("output", completion.Enumeration(outputFormat.Allowed)))
 
 	maincmd.AddCommand(newPeerInfoCommand(ctx, f, options))
+	maincmd.AddCommand(newTenantsInfoCommand(ctx, options))
 
 	return maincmd
 }
@@ -37,16 +37,54 @@ constraints are automatically embedded in the resulting PV, to enforce each pod
 to be scheduled on the cluster where the associated storage pools are available.
 
 This command allows to *move* a volume created in a given cluster to a different
-cluster, ensuring mounting pods will then be attracted in that location. This
-process leverages Restic to backup the source data and restore it into a volume
-in the target cluster. Warning: only PVCs not currently mounted by any pod can
-be moved to a different cluster.
+cluster, ensuring mounting pods will then be attracted in that location.
+
+By default, this process leverages Restic to backup the source data and restore
+it into a volume in the target cluster. Warning: in this case, only PVCs not
+currently mounted by any pod can be moved to a different cluster.
+
+Alternatively, the stream engine synchronizes the data directly into a staging
+volume in the target cluster through incremental rsync passes, while the
+Deployments and StatefulSets mounting the PVC are still running. They are then
+scaled down for a final (brief) synchronization, and eventually restored once
+the volume has been replaced. An interrupted migration can be resumed executing
+the same command again.
+
+Finally, a PVC replicated to a standby volume in a different cluster (i.e., through
+the storage.liqo.io/replicate-to annotation) can be quickly failed over to its
+replica: the PVC is moved to the node hosting the replica, and populated from the
+replica itself, without crossing the network fabric. Changes performed after the
+last synchronization of the replica are lost.
 
 Examples:
   $ {{ .Executable }} move volume database01 --namespace foo --target-node worker-023
 or
   $ {{ .Executable }} move volume database01 --namespace foo --target-node liqo-neutral-colt
       --containers-cpu-limits 1000m --containers-ram-limits 2Gi
+or
+  $ {{ .Executable }} move volume database01 --namespace foo --target-node liqo-neutral-colt
+      --engine stream --live-passes 3
+or
+  $ {{ .Executable }} move volume database01 --namespace foo --promote-replica
+`
+
+const liqoctlMoveWorkloadLongHelp = `Move a StatefulSet or Deployment, along with its PVCs, to a different node (i.e., cluster).
+
+This command moves an entire stateful workload to a different cluster. First, the
+nodes currently hosting the workload are cordoned (i.e., excluded through node
+affinity), and the workload is scaled down. Then, each Liqo-managed PVC mounted by
+the workload is moved to the target node, leveraging the same engines available
+for the move volume command. Finally, the workload is pinned to the target node,
+scaled up to the original number of replicas, and its readiness is verified.
+
+In case of failure, the operation is rolled back: the volumes already moved are
+moved back to the original nodes, and the original configuration of the workload
+is restored.
+
+Examples:
+  $ {{ .Executable }} move workload statefulset/database --namespace foo --target-node liqo-neutral-colt
+or
+  $ {{ .Executable }} move workload deployment/web --namespace foo --target-node worker-023 --engine stream
 `
 
 // moveCmd represents the move command.
@@ -59,13 +97,13 @@ func newMoveCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 	}
 
 	cmd.AddCommand(newMoveVolumeCommand(ctx, f))
+	cmd.AddCommand(newMoveWorkloadCommand(ctx, f))
 	return cmd
 }
 
 func newMoveVolumeCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
-	options := &move.Options{Factory: f, ResticPassword: utils.RandomString(16)}
-	var containersCPURequests, containersCPULimits args.Quantity
-	var containersRAMRequests, containersRAMLimits args.Quantity
+	options := &move.Options{Factory: f, ResticPassword: utils.RandomString(16), RsyncPassword: utils.RandomString(16)}
+	var flags moveVolumeFlags
 
 	var cmd = &cobra.Command{
 		Use:     "volume",
@@ -77,10 +115,7 @@ func newMoveVolumeCommand(ctx context.Context, f *factory.Factory) *cobra.Comman
 		ValidArgsFunction: completion.PVCs(ctx, f, 1),
 
 		PreRun: func(_ *cobra.Command, _ []string) {
-			options.ContainersCPURequests = containersCPURequests.Quantity
-			options.ContainersCPULimits = containersCPULimits.Quantity
-			options.ContainersRAMRequests = containersRAMRequests.Quantity
-			options.ContainersRAMLimits = containersRAMLimits.Quantity
+			flags.apply(options)
 		},
 
 		Run: func(_ *cobra.Command, args []string) {
@@ -94,18 +129,88 @@ func newMoveVolumeCommand(ctx context.Context, f *factory.Factory) *cobra.Comman
 
 	cmd.Flags().StringVar(&options.TargetNode, "target-node", "",
 		"The target node (either physical or virtual) the PVC will be moved to")
+	cmd.Flags().BoolVar(&options.PromoteReplica, "promote-replica", false,
+		"Fail over to the standby replica of the PVC, moving the PVC to the node hosting the replica")
+	flags.register(ctx, f, cmd, options)
+
+	cmd.MarkFlagsOneRequired("target-node", "promote-replica")
+	cmd.MarkFlagsMutuallyExclusive("target-node", "promote-replica")
+
+	return cmd
+}
+
+func newMoveWorkloadCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := &move.WorkloadOptions{Options: move.Options{Factory: f,
+		ResticPassword: utils.RandomString(16), RsyncPassword: utils.RandomString(16)}}
+	var flags moveVolumeFlags
+
+	var cmd = &cobra.Command{
+		Use:     "workload",
+		Aliases: []string{"workloads"},
+		Short:   "Move a StatefulSet or Deployment, along with its PVCs, to a different node (i.e., cluster)",
+		Long:    WithTemplate(liqoctlMoveWorkloadLongHelp),
+
+		Args: cobra.ExactArgs(1),
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			flags.apply(&options.Options)
+		},
+
+		Run: func(_ *cobra.Command, args []string) {
+			var err error
+			options.WorkloadKind, options.WorkloadName, err = move.ParseWorkload(args[0])
+			output.ExitOnErr(err)
+			output.ExitOnErr(options.Run(ctx))
+		},
+	}
+
+	f.AddNamespaceFlag(cmd.Flags())
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc(factory.FlagNamespace, completion.Namespaces(ctx, f, completion.NoLimit)))
+
+	cmd.Flags().StringVar(&options.TargetNode, "target-node", "",
+		"The target node (either physical or virtual) the workload will be moved to")
+	f.Printer.CheckErr(cmd.MarkFlagRequired("target-node"))
+	cmd.Flags().DurationVar(&options.Timeout, "timeout", move.DefaultWorkloadTimeout,
+		"The timeout to wait for the moved workload to become ready")
+	cmd.Flags().BoolVar(&options.SkipRollback, "skip-rollback", false,
+		"Leave the workload scaled down and the volumes in their current location in case of failure, instead of rolling back")
+	flags.register(ctx, f, cmd, &options.Options)
+
+	return cmd
+}
 
-	cmd.Flags().Var(&containersCPURequests, "containers-cpu-requests", "The CPU requests for the Restic containers")
-	cmd.Flags().Var(&containersCPULimits, "containers-cpu-limits", "The CPU limits for the Restic containers")
-	cmd.Flags().Var(&containersRAMRequests, "containers-ram-requests", "The RAM requests for the Restic containers")
-	cmd.Flags().Var(&containersRAMLimits, "containers-ram-limits", "The RAM limits for the Restic containers")
+// moveVolumeFlags holds the flags configuring how volumes are moved, which require to be parsed before being applied to the options.
+type moveVolumeFlags struct {
+	containersCPURequests, containersCPULimits args.Quantity
+	containersRAMRequests, containersRAMLimits args.Quantity
+	engine                                     *args.StringEnum
+}
+
+func (flags *moveVolumeFlags) register(ctx context.Context, f *factory.Factory, cmd *cobra.Command, options *move.Options) {
+	flags.engine = args.NewEnum([]string{string(move.EngineRestic), string(move.EngineStream)}, string(move.EngineRestic))
+	cmd.Flags().Var(flags.engine, "engine", "The engine used to move the data of the volume (restic, stream)")
+
+	cmd.Flags().Var(&flags.containersCPURequests, "containers-cpu-requests", "The CPU requests for the Restic (or rsync) containers")
+	cmd.Flags().Var(&flags.containersCPULimits, "containers-cpu-limits", "The CPU limits for the Restic (or rsync) containers")
+	cmd.Flags().Var(&flags.containersRAMRequests, "containers-ram-requests", "The RAM requests for the Restic (or rsync) containers")
+	cmd.Flags().Var(&flags.containersRAMLimits, "containers-ram-limits", "The RAM limits for the Restic (or rsync) containers")
 	cmd.Flags().StringVar(&options.ResticServerImage, "restic-server-image", move.DefaultResticServerImage,
 		"The Restic server image to use")
 	cmd.Flags().StringVar(&options.ResticImage, "restic-image", move.DefaultResticImage,
 		"The Restic image to use")
+	cmd.Flags().StringVar(&options.RsyncImage, "rsync-image", move.DefaultRsyncImage,
+		"The rsync image to use, in case of the stream engine")
+	cmd.Flags().IntVar(&options.LivePasses, "live-passes", move.DefaultLivePasses,
+		"The number of synchronization passes performed while the workloads are running, in case of the stream engine")
 
-	f.Printer.CheckErr(cmd.MarkFlagRequired("target-node"))
 	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("target-node", completion.Nodes(ctx, f, completion.NoLimit)))
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("engine", completion.Enumeration(flags.engine.Allowed)))
+}
 
-	return cmd
+func (flags *moveVolumeFlags) apply(options *move.Options) {
+	options.ContainersCPURequests = flags.containersCPURequests.Quantity
+	options.ContainersCPULimits = flags.containersCPULimits.Quantity
+	options.ContainersRAMRequests = flags.containersRAMRequests.Quantity
+	options.ContainersRAMLimits = flags.containersRAMLimits.Quantity
+	options.Engine = move.Engine(flags.engine.Value)
 }
@@ -0,0 +1,89 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/move"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+const liqoctlRestoreNamespaceLongHelp = `Restore a namespace from a backup.
+
+This command restores a namespace previously backed up through the backup namespace
+command, possibly with a different name. First, the namespace is created, and
+offloaded again if it was offloaded at the time of the backup. Then, the volumes
+are recreated and populated with the backed up data, either in the local cluster
+or in the remote ones originally hosting them (possibly, in a fresh peering with
+the same cluster), unless a different target node is specified. Finally, the
+remaining resources are created.
+
+Examples:
+  $ {{ .Executable }} restore namespace foo --location /backups/foo --password $PASSWORD
+or
+  $ {{ .Executable }} restore namespace bar --location s3://backups/foo --password $PASSWORD \
+      --s3-endpoint http://minio.example.com:9000 --target-node liqo-neutral-colt
+`
+
+func newRestoreCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a resource from a backup",
+		Long:  "Restore a resource from a backup.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newRestoreNamespaceCommand(ctx, f))
+	return cmd
+}
+
+func newRestoreNamespaceCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := &move.RestoreOptions{Options: move.Options{Factory: f}}
+	var flags backupFlags
+
+	cmd := &cobra.Command{
+		Use:     "namespace name",
+		Aliases: []string{"ns"},
+		Short:   "Restore a namespace, along with its volumes, from a backup",
+		Long:    WithTemplate(liqoctlRestoreNamespaceLongHelp),
+
+		Args: cobra.ExactArgs(1),
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			output.ExitOnErr(flags.apply(&options.Options, &options.S3))
+		},
+
+		Run: func(_ *cobra.Command, args []string) {
+			options.Namespace = args[0]
+			output.ExitOnErr(options.Run(ctx))
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Location, "location", "",
+		"The location the backup is retrieved from, either a local directory or an S3-compatible bucket (s3://bucket/prefix)")
+	cmd.Flags().BoolVar(&options.SkipVolumes, "skip-volumes", false, "Restore only the manifests, without the data of the volumes")
+	cmd.Flags().StringVar(&options.TargetNode, "target-node", "",
+		"The node (either physical or virtual) the volumes are restored onto, instead of the cluster originally hosting them")
+	flags.register(f, cmd, &options.Options, &options.S3)
+
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc("target-node", completion.Nodes(ctx, f, completion.NoLimit)))
+
+	return cmd
+}
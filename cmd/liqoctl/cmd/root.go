@@ -40,8 +40,10 @@ import (
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/kubeconfig"
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/nonce"
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/publickey"
+	"github.com/liqotech/liqo/pkg/liqoctl/rest/reflection"
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/resourceslice"
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/tenant"
+	"github.com/liqotech/liqo/pkg/liqoctl/rest/usagerecord"
 	"github.com/liqotech/liqo/pkg/liqoctl/rest/virtualnode"
 )
 
@@ -58,6 +60,8 @@ var liqoResources = []rest.APIProvider{
 	identity.Identity,
 	resourceslice.ResourceSlice,
 	kubeconfig.Kubeconfig,
+	usagerecord.UsageRecord,
+	reflection.Reflection,
 }
 
 func init() {
@@ -125,12 +129,15 @@ func NewRootCommand(ctx context.Context) *cobra.Command {
 	cmd.AddCommand(newUninstallCommand(ctx, f))
 	cmd.AddCommand(newPeerCommand(ctx, f))
 	cmd.AddCommand(newUnpeerCommand(ctx, f))
+	cmd.AddCommand(newDiscoverCommand(ctx, f))
 	cmd.AddCommand(newNetworkCommand(ctx, f))
 	cmd.AddCommand(newAuthenticateCommand(ctx, f))
 	cmd.AddCommand(newUnauthenticateCommand(ctx, f))
 	cmd.AddCommand(newOffloadCommand(ctx, f))
 	cmd.AddCommand(newUnoffloadCommand(ctx, f))
 	cmd.AddCommand(newMoveCommand(ctx, f))
+	cmd.AddCommand(newBackupCommand(ctx, f))
+	cmd.AddCommand(newRestoreCommand(ctx, f))
 	cmd.AddCommand(newVersionCommand(ctx, f))
 	cmd.AddCommand(newDocsCommand(ctx))
 	cmd.AddCommand(newActivateCommand(ctx, f))
@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/pflag"
 	"k8s.io/klog/v2"
 
+	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
 )
@@ -35,6 +36,8 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.StringVar(&o.TenantNamespace, "tenant-namespace", o.TenantNamespace, "The tenant namespace associated with the remote cluster")
 	flags.StringVar(&o.LiqoNamespace, "liqo-namespace", o.LiqoNamespace, "The namespace where Liqo is installed")
 	flags.DurationVar(&o.InformerResyncPeriod, "resync-period", o.InformerResyncPeriod, "The resync period for the informers")
+	flags.DurationVar(&o.ReflectionStatusUpdatePeriod, "reflection-status-update-period", o.ReflectionStatusUpdatePeriod,
+		"The minimum period between the updates of the reflection status in the VirtualNode resource (0 to disable)")
 
 	flags.Var(&o.HomeCluster, "home-cluster-id", "The ID of the home cluster")
 	flags.Var(&o.ForeignCluster, "foreign-cluster-id", "The ID of the foreign cluster")
@@ -49,6 +52,10 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 
 	setReflectorsWorkers(flags, o)
 	setReflectorsType(flags, o)
+	flags.StringArrayVar(&o.CustomResourceReflectors, "custom-resource-reflection", nil,
+		"The custom resources to be reflected, in the <resource>.<version>.<group>[,workers=<n>][,type=<type>][,reflectStatus=<bool>] form")
+	flags.StringSliceVar(&o.ClusterScopedReflection, "cluster-scoped-reflection", nil,
+		"The kinds of the cluster-scoped resources to be reflected, among PriorityClass, RuntimeClass, StorageClass and IngressClass")
 
 	flags.DurationVar(&o.NodeLeaseDuration, "node-lease-duration", o.NodeLeaseDuration, "The duration of the node leases")
 	flags.DurationVar(&o.NodePingInterval, "node-ping-interval", o.NodePingInterval,
@@ -66,11 +73,16 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.BoolVar(&o.EnableStorage, "enable-storage", false, "Enable the Liqo storage reflection")
 	flags.StringVar(&o.VirtualStorageClassName, "virtual-storage-class-name", "liqo", "Name of the virtual storage class")
 	flags.StringVar(&o.RemoteRealStorageClassName, "remote-real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
+	flags.StringSliceVar(&o.RemoteStorageClasses, "remote-storage-classes", nil,
+		"Names of the real storage classes offered by the remote cluster, which can be selected through the "+consts.RemoteStorageClassAnnotation+" annotation")
 	flags.BoolVar(&o.EnableIngress, "enable-ingress", false, "Enable the Liqo ingress reflection")
 	flags.StringVar(&o.RemoteRealIngressClassName, "remote-real-ingress-class-name", "", "Name of the real ingress class to use for the actual ingress")
 	flags.BoolVar(&o.EnableLoadBalancer, "enable-load-balancer", false, "Enable the Liqo load balancer reflection")
 	flags.StringVar(&o.RemoteRealLoadBalancerClassName, "remote-real-load-balancer-class-name", "",
 		"Name of the real load balancer class to use for the actual load balancer")
+	flags.BoolVar(&o.EnableGateway, "enable-gateway", false, "Enable the Liqo Gateway API routes reflection towards the remote gateway")
+	flags.StringVar(&o.RemoteGatewayName, "remote-gateway-name", "", "Name of the remote gateway the reflected routes are attached to")
+	flags.StringVar(&o.RemoteGatewayNamespace, "remote-gateway-namespace", "", "Namespace of the remote gateway the reflected routes are attached to")
 	flags.BoolVar(&o.EnableMetrics, "metrics-enabled", false, "Enable the metrics server")
 	flags.StringVar(&o.MetricsAddress, "metrics-address", ":8080", "The address to listen to for metrics requests")
 	flags.StringVar(&o.HomeAPIServerHost, "home-api-server-host", "",
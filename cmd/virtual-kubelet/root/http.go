@@ -21,7 +21,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -33,19 +35,24 @@ import (
 
 	"github.com/virtual-kubelet/virtual-kubelet/node/api"
 	certificates "k8s.io/api/certificates/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/certificate"
 	"k8s.io/klog/v2"
 
 	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/workload"
 )
 
 type crtretriever func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 
-func setupHTTPServer(ctx context.Context, handler workload.PodHandler, localClient kubernetes.Interface,
-	remoteConfig *rest.Config, cfg *Opts) (err error) {
+// reflectionDryRunner forges the remote object corresponding to the given local one, without enforcing it.
+type reflectionDryRunner func(ctx context.Context, reflector, namespace, name string) (*manager.DryRunResult, error)
+
+func setupHTTPServer(ctx context.Context, handler workload.PodHandler, dryRunner reflectionDryRunner,
+	localClient kubernetes.Interface, remoteConfig *rest.Config, cfg *Opts) (err error) {
 	var retriever crtretriever
 
 	parsedIP := net.ParseIP(cfg.NodeIP)
@@ -85,6 +92,7 @@ func setupHTTPServer(ctx context.Context, handler workload.PodHandler, localClie
 	}
 
 	api.AttachPodRoutes(podRoutes, mux, true)
+	attachReflectionRoutes(mux, dryRunner)
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf("0.0.0.0:%d", cfg.ListenPort),
@@ -144,6 +152,34 @@ func attachMetricsRoutes(ctx context.Context, mux *http.ServeMux, cl rest.Interf
 	mux.HandleFunc("/metrics/probes", handlerFunc)
 }
 
+// attachReflectionRoutes attaches the routes to troubleshoot the reflection process, returning the remote object
+// which would be forged starting from the local one, along with the one currently existing in the remote cluster.
+func attachReflectionRoutes(mux *http.ServeMux, dryRunner reflectionDryRunner) {
+	mux.HandleFunc("GET /reflection/{reflector}/{namespace}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		klog.Infof("Received request for %s", r.RequestURI)
+
+		result, err := dryRunner(r.Context(), r.PathValue("reflector"), r.PathValue("namespace"), r.PathValue("name"))
+		switch {
+		case err == nil:
+		case kerrors.IsNotFound(err):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, manager.ErrReflectorNotFound), errors.Is(err, manager.ErrDryRunNotSupported):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			klog.Errorf("Failed to perform the dry-run reflection of %q: %v", r.RequestURI, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			klog.Error(err)
+		}
+	})
+}
+
 // newCertificateManager creates a certificate manager for the kubelet when retrieving a server certificate, or returns an error.
 // This function is inspired by the original kubelet implementation:
 // https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/certificate/kubelet.go
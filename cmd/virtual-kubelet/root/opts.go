@@ -44,6 +44,9 @@ const (
 	DefaultListenPort           = 10250
 	DefaultNodePingTimeout      = 1 * time.Second
 	DefaultNodeCheckNetwork     = true
+
+	// DefaultReflectionStatusUpdatePeriod -> the default period between the updates of the reflection status.
+	DefaultReflectionStatusUpdatePeriod = 30 * time.Second
 )
 
 // DefaultReflectorsWorkers contains the default number of workers for each reflected resource.
@@ -57,6 +60,12 @@ var DefaultReflectorsWorkers = map[resources.ResourceReflected]uint{
 	resources.ServiceAccount:        3,
 	resources.PersistentVolumeClaim: 3,
 	resources.Event:                 3,
+	resources.PodDisruptionBudget:   3,
+	resources.HTTPRoute:             3,
+	resources.GRPCRoute:             3,
+	resources.TLSRoute:              3,
+	resources.ExportedService:       0,
+	resources.VolumeSnapshot:        3,
 }
 
 // DefaultReflectorsTypes contains the default type of reflection for each reflected resource.
@@ -69,6 +78,12 @@ var DefaultReflectorsTypes = map[resources.ResourceReflected]offloadingv1beta1.R
 	resources.ServiceAccount:        offloadingv1beta1.CustomLiqo,
 	resources.PersistentVolumeClaim: offloadingv1beta1.CustomLiqo,
 	resources.Event:                 offloadingv1beta1.DenyList,
+	resources.PodDisruptionBudget:   offloadingv1beta1.DenyList,
+	resources.HTTPRoute:             offloadingv1beta1.DenyList,
+	resources.GRPCRoute:             offloadingv1beta1.DenyList,
+	resources.TLSRoute:              offloadingv1beta1.DenyList,
+	resources.ExportedService:       offloadingv1beta1.CustomLiqo,
+	resources.VolumeSnapshot:        offloadingv1beta1.CustomLiqo,
 }
 
 // Opts stores all the options for configuring the root virtual-kubelet command.
@@ -85,6 +100,8 @@ type Opts struct {
 	LiqoNamespace        string
 	InformerResyncPeriod time.Duration
 
+	ReflectionStatusUpdatePeriod time.Duration
+
 	HomeCluster         argsutils.ClusterIDFlags
 	ForeignCluster      argsutils.ClusterIDFlags
 	DisableIPReflection bool
@@ -102,6 +119,12 @@ type Opts struct {
 	// Type of reflection to use for each reflected resource
 	ReflectorsType map[string]*string
 
+	// Configuration of the reflectors of custom resources
+	CustomResourceReflectors []string
+
+	// Kinds of the cluster-scoped resources to be reflected
+	ClusterScopedReflection []string
+
 	NodeLeaseDuration time.Duration
 	NodePingInterval  time.Duration
 	NodePingTimeout   time.Duration
@@ -114,10 +137,14 @@ type Opts struct {
 	EnableStorage                   bool
 	VirtualStorageClassName         string
 	RemoteRealStorageClassName      string
+	RemoteStorageClasses            []string
 	EnableIngress                   bool
 	RemoteRealIngressClassName      string
 	EnableLoadBalancer              bool
 	RemoteRealLoadBalancerClassName string
+	EnableGateway                   bool
+	RemoteGatewayName               string
+	RemoteGatewayNamespace          string
 	EnableMetrics                   bool
 	MetricsAddress                  string
 
@@ -142,6 +169,8 @@ func NewOpts() *Opts {
 		LiqoNamespace:        consts.DefaultLiqoNamespace,
 		InformerResyncPeriod: DefaultInformerResyncPeriod,
 
+		ReflectionStatusUpdatePeriod: DefaultReflectionStatusUpdatePeriod,
+
 		DisableIPReflection: false,
 
 		CertificateType: argsutils.NewEnum([]string{CertificateTypeKubelet, CertificateTypeAWS, CertificateTypeSelfSigned}, CertificateTypeKubelet),
@@ -27,6 +27,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -50,6 +51,7 @@ import (
 	metrics "github.com/liqotech/liqo/pkg/virtualKubelet/metrics"
 	podprovider "github.com/liqotech/liqo/pkg/virtualKubelet/provider"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+	reflectionstatus "github.com/liqotech/liqo/pkg/virtualKubelet/reflection/status"
 )
 
 var (
@@ -151,6 +153,14 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 	if err != nil {
 		return err
 	}
+	customReflectorsConfigs, err := getCustomReflectorsConfigs(c)
+	if err != nil {
+		return err
+	}
+	clusterScopedReflection, err := resources.ParseClusterScopedKinds(c.ClusterScopedReflection)
+	if err != nil {
+		return err
+	}
 
 	// Get virtual node
 	vnName := os.Getenv("VIRTUALNODE_NAME")
@@ -192,16 +202,22 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		LocalPodCIDR:         c.LocalPodCIDR,
 		InformerResyncPeriod: c.InformerResyncPeriod,
 
-		ReflectorsConfigs: reflectorsConfigs,
+		ReflectorsConfigs:       reflectorsConfigs,
+		CustomReflectorsConfigs: customReflectorsConfigs,
+		ClusterScopedReflection: clusterScopedReflection,
 
 		EnableAPIServerSupport:          c.EnableAPIServerSupport,
 		EnableStorage:                   c.EnableStorage,
 		VirtualStorageClassName:         c.VirtualStorageClassName,
 		RemoteRealStorageClassName:      c.RemoteRealStorageClassName,
+		RemoteStorageClasses:            c.RemoteStorageClasses,
 		EnableIngress:                   c.EnableIngress,
 		RemoteRealIngressClassName:      c.RemoteRealIngressClassName,
 		EnableLoadBalancer:              c.EnableLoadBalancer,
 		RemoteRealLoadBalancerClassName: c.RemoteRealLoadBalancerClassName,
+		EnableGateway:                   c.EnableGateway,
+		RemoteGatewayName:               c.RemoteGatewayName,
+		RemoteGatewayNamespace:          c.RemoteGatewayNamespace,
 		EnableMetrics:                   c.EnableMetrics,
 
 		HomeAPIServerHost: c.HomeAPIServerHost,
@@ -217,6 +233,10 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		return err
 	}
 
+	if c.ReflectionStatusUpdatePeriod > 0 {
+		reflectionstatus.NewPublisher(cl, client.ObjectKeyFromObject(&vn), podProvider.ReflectionStatus, c.ReflectionStatusUpdatePeriod).Start(ctx)
+	}
+
 	initCallback := func() {
 		klog.Infof("Starting informer resync")
 		if err := podProvider.Resync(); err != nil {
@@ -248,7 +268,7 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		go leaderelection.Run(ctx, leaderElector)
 	}
 
-	err = setupHTTPServer(ctx, podProvider.PodHandler(), localClient, remoteConfig, c)
+	err = setupHTTPServer(ctx, podProvider.PodHandler(), podProvider.DryRun, localClient, remoteConfig, c)
 	if err != nil {
 		return fmt.Errorf("error while setting up HTTPS server: %w", err)
 	}
@@ -360,7 +380,8 @@ func getVersion(config *rest.Config) string {
 }
 
 func isReflectionTypeNotCustomizable(resource resources.ResourceReflected) bool {
-	return resource == resources.Pod || resource == resources.ServiceAccount || resource == resources.PersistentVolumeClaim
+	return resource == resources.Pod || resource == resources.ServiceAccount || resource == resources.PersistentVolumeClaim ||
+		resource == resources.ExportedService || resource == resources.VolumeSnapshot
 }
 
 func getReflectorsConfigs(c *Opts) (map[resources.ResourceReflected]offloadingv1beta1.ReflectorConfig, error) {
@@ -387,3 +408,15 @@ func getReflectorsConfigs(c *Opts) (map[resources.ResourceReflected]offloadingv1
 	}
 	return reflectorsConfigs, nil
 }
+
+func getCustomReflectorsConfigs(c *Opts) (map[schema.GroupVersionResource]offloadingv1beta1.ReflectorConfig, error) {
+	reflectorsConfigs := make(map[schema.GroupVersionResource]offloadingv1beta1.ReflectorConfig, len(c.CustomResourceReflectors))
+	for _, value := range c.CustomResourceReflectors {
+		gvr, config, err := resources.ParseCustomResourceReflector(value)
+		if err != nil {
+			return nil, err
+		}
+		reflectorsConfigs[gvr] = *config
+	}
+	return reflectorsConfigs, nil
+}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -87,6 +88,8 @@ func main() {
 		"Enforce offerer-side that offloaded pods do not exceed offered resources (based on container limits)")
 	refreshInterval := pflag.Duration("resource-validator-refresh-interval",
 		5*time.Minute, "The interval at which the resource validator cache is refreshed")
+	usageReportInterval := pflag.Duration("resource-usage-report-interval",
+		1*time.Minute, "The interval at which the resource usage of each tenant is reported in the status of the corresponding Quota")
 	addVirtualNodeTolerationOnOffloadedPods := pflag.Bool("add-virtual-node-toleration-on-offloaded-pods", false,
 		"Automatically add the virtual node toleration on offloaded pods")
 
@@ -149,6 +152,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := mgr.Add(manager.RunnableFunc(spv.UsageReporter(*usageReportInterval))); err != nil {
+		klog.Errorf("Unable to add the resource usage reporter to the manager: %v", err)
+		os.Exit(1)
+	}
+
+	if err := metrics.Registry.Register(shadowpodswh.NewUsageCollector(spv)); err != nil {
+		klog.Errorf("Unable to register the resource usage metrics collector: %v", err)
+		os.Exit(1)
+	}
+
 	// Options for the virtual kubelet.
 	vkOptsDefaultTemplateRef, err := argsutils.GetObjectRefFromNamespacedName(*vkOptsDefaultTemplate)
 	if err != nil {
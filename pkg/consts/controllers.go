@@ -25,6 +25,7 @@ package consts
 const (
 	// Core.
 	CtrlForeignCluster      = "foreigncluster"
+	CtrlPeering             = "peering"
 	CtrlSecretCRDReplicator = "secret_crdreplicator" //nolint:gosec // not a credential
 
 	// Networking.
@@ -68,9 +69,14 @@ const (
 	CtrlNamespaceOffloading = "namespaceoffloading"
 	CtrlNodeFailure         = "node_failure"
 	CtrlPodStatus           = "pod_status"
+	CtrlServiceExport       = "serviceexport"
 	CtrlShadowEndpointSlice = "shadowendpointslice"
 	CtrlShadowPod           = "shadowpod"
+	CtrlStorageCapacity     = "storage_capacity"
 	CtrlVirtualNode         = "virtualnode"
+	CtrlVolumeReplication   = "volume_replication"
+	CtrlVolumeResize        = "volume_resize"
+	CtrlVolumeSnapshot      = "volume_snapshot"
 
 	// Cross modules.
 	CtrlResourceSliceQuotaCreator = "resourceslice_quotacreator"
@@ -21,6 +21,9 @@ const (
 	// VirtualNodeLabel used to mark the virtual nodes.
 	VirtualNodeLabel = "liqo.io/virtual-node"
 
+	// DiscoveredLabel used to mark the ClusterAnnouncements mirrored from the cluster registry.
+	DiscoveredLabel = "liqo.io/discovered"
+
 	// LiqoAppLabelValue is the value of the label used to identify Liqo app.
 	LiqoAppLabelValue = "liqo"
 )
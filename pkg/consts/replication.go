@@ -48,6 +48,8 @@ const (
 	ManagedByShadowPodValue = "shadowpod"
 	// ManagedByShadowEndpointSliceValue it the label value used to indicate that a given resource is managed by a ShadowEndpointSlice.
 	ManagedByShadowEndpointSliceValue = "shadowendpointslice"
+	// ManagedByServiceExportValue it the label value used to indicate that a given resource is managed by a multi-cluster ServiceExport.
+	ManagedByServiceExportValue = "serviceexport"
 
 	// LocalResourceOwnership label key added to a resource when it is owned by a local component.
 	// Ex. Local networkconfigs are owned by the component that creates them. If the resource is replicated in
@@ -64,6 +66,10 @@ const (
 	// AllowReflectionAnnotationKey is the annotation key used to indicate that a given object should be reflected into a remote cluster.
 	AllowReflectionAnnotationKey = "liqo.io/allow-reflection"
 
+	// ExportToConsumerAnnotationKey is the annotation key used to indicate that a service of the provider cluster
+	// should be exported back to the consumer cluster the hosting namespace is offloaded from.
+	ExportToConsumerAnnotationKey = "liqo.io/export-to-consumer"
+
 	// PodAntiAffinityPresetKey is the annotation key used to express an anti-affinity preset to apply to offloaded pods.
 	PodAntiAffinityPresetKey = "liqo.io/anti-affinity-preset"
 
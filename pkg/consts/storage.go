@@ -17,6 +17,10 @@ package consts
 const (
 	// StorageProvisionerName is the name of the liqo storage provisioner.
 	StorageProvisionerName = "liqo.io/storage"
+	// StorageCSIDriverName is the name of the CSIDriver enabling the capacity tracking for the liqo virtual storage class.
+	// It is also used as the name of the liqo storage provisioner when the capacity tracking is enabled, since the
+	// scheduler looks up the CSIDriver named as the provisioner, and StorageProvisionerName is not a valid object name.
+	StorageCSIDriverName = "storage.liqo.io"
 
 	// StorageAvailableLabel is the label used to mark if the liqo storage is available on a virtual node.
 	StorageAvailableLabel = "storage.liqo.io/available"
@@ -26,6 +30,35 @@ const (
 	// VirtualPvcNameLabel is the label used to mark the name of a virtual PVC.
 	VirtualPvcNameLabel = "storage.liqo.io/virtual-pvc-name"
 
+	// RemoteStorageClassAnnotation is the annotation used to select the storage class of the remote PVC,
+	// among the ones offered by the remote cluster.
+	RemoteStorageClassAnnotation = "storage.liqo.io/remote-storage-class"
+
+	// VirtualVolumeSnapshotNamespaceLabel is the label used to mark the namespace of a virtual volume snapshot.
+	VirtualVolumeSnapshotNamespaceLabel = "storage.liqo.io/virtual-volumesnapshot-namespace"
+	// VirtualVolumeSnapshotNameLabel is the label used to mark the name of a virtual volume snapshot.
+	VirtualVolumeSnapshotNameLabel = "storage.liqo.io/virtual-volumesnapshot-name"
+	// VolumeSnapshotFinalizer is the finalizer ensuring the deletion of the real snapshot backing a virtual one.
+	VolumeSnapshotFinalizer = "storage.liqo.io/volume-snapshot"
+
+	// VirtualStorageCapacityLabel is the label used to mark the CSIStorageCapacity objects published for the virtual storage class.
+	VirtualStorageCapacityLabel = "storage.liqo.io/virtual-capacity"
+
+	// ReplicationTargetAnnotation is the annotation requesting the asynchronous replication of a virtual PVC
+	// to a standby volume hosted by the given node (either physical or virtual).
+	ReplicationTargetAnnotation = "storage.liqo.io/replicate-to"
+	// ReplicationIntervalAnnotation is the annotation configuring the interval between subsequent replications of a virtual PVC.
+	ReplicationIntervalAnnotation = "storage.liqo.io/replication-interval"
+	// ReplicationLastSyncAnnotation is the annotation exposing, on the virtual PV, the start time of the last successful replication.
+	ReplicationLastSyncAnnotation = "storage.liqo.io/replication-last-sync"
+	// ReplicationLagAnnotation is the annotation exposing, on the virtual PV, the replication lag measured when the last
+	// successful replication completed (i.e., its duration, as the replica reflects the data at the time it started).
+	ReplicationLagAnnotation = "storage.liqo.io/replication-lag"
+	// ReplicationSourceLabel is the label used to mark the resources replicating a virtual PVC, set to the name of the PVC.
+	ReplicationSourceLabel = "storage.liqo.io/replication-source"
+	// ReplicationComponentLabel is the label used to identify the role of the resources replicating a virtual PVC.
+	ReplicationComponentLabel = "storage.liqo.io/replication-component"
+
 	// StorageNamespaceLabel is the label used to mark the liqo storage namespace.
 	StorageNamespaceLabel = "liqo.io/storage-provisioner"
 )
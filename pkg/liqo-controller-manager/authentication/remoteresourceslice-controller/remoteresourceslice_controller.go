@@ -20,10 +20,13 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	klog "k8s.io/klog/v2"
@@ -94,6 +97,7 @@ type RemoteResourceSliceReconciler struct {
 // +kubebuilder:rbac:groups=authentication.liqo.io,resources=tenants,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=storage,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csistoragecapacities,verbs=get;list;watch
 
 // Reconcile replicated ResourceSlice resources.
 func (r *RemoteResourceSliceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
@@ -245,6 +249,7 @@ func (r *RemoteResourceSliceReconciler) handleResourcesStatus(ctx context.Contex
 
 		resourceSlice.Status.IngressClasses = getIngressClasses(r.sliceStatusOptions)
 		resourceSlice.Status.LoadBalancerClasses = getLoadBalancerClasses(r.sliceStatusOptions)
+		resourceSlice.Status.Gateways = getGateways(r.sliceStatusOptions)
 		resourceSlice.Status.NodeLabels = getNodeLabels(r.sliceStatusOptions)
 
 		acceptResources(resourceSlice, r.eventRecorder)
@@ -277,6 +282,7 @@ func (r *RemoteResourceSliceReconciler) SetupWithManager(mgr ctrl.Manager) error
 			builder.WithPredicates(predicate.And(remoteResSliceFilter, withCSR(), predicate.GenerationChangedPredicate{})),
 		).
 		Watches(&authv1beta1.Tenant{}, handler.EnqueueRequestsFromMapFunc(r.resourceSlicesEnquer())).
+		Watches(&storagev1.CSIStorageCapacity{}, handler.EnqueueRequestsFromMapFunc(r.storageCapacitiesEnquer())).
 		Complete(r)
 }
 
@@ -311,6 +317,36 @@ func (r *RemoteResourceSliceReconciler) resourceSlicesEnquer() func(ctx context.
 	}
 }
 
+// storageCapacitiesEnquer enqueues all the remote ResourceSlices when the capacity of the storage classes changes,
+// to keep the storage information shared with the consumer clusters up to date.
+func (r *RemoteResourceSliceReconciler) storageCapacitiesEnquer() handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		if r.sliceStatusOptions == nil || !r.sliceStatusOptions.EnableStorage {
+			return nil
+		}
+		if _, found := obj.GetLabels()[consts.VirtualStorageCapacityLabel]; found {
+			return nil
+		}
+
+		selector := reflection.ReplicatedResourcesLabelSelector()
+		remoteResSliceSelector, err := metav1.LabelSelectorAsSelector(&selector)
+		utilruntime.Must(err)
+
+		resSlices, err := getters.ListResourceSlicesByLabel(ctx, r.Client, corev1.NamespaceAll, remoteResSliceSelector)
+		if err != nil {
+			klog.Errorf("Failed to retrieve the ResourceSlices to update upon the change of CSIStorageCapacity %q: %v",
+				client.ObjectKeyFromObject(obj), err)
+			return nil
+		}
+
+		reqs := make([]reconcile.Request, len(resSlices))
+		for i := range resSlices {
+			reqs[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&resSlices[i])}
+		}
+		return reqs
+	}
+}
+
 func withCSR() predicate.Funcs {
 	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
 		rs, ok := obj.(*authv1beta1.ResourceSlice)
@@ -17,12 +17,15 @@ package remoteresourceslicecontroller
 import (
 	"context"
 	"sort"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
 	argutils "github.com/liqotech/liqo/pkg/utils/args"
 )
 
@@ -32,6 +35,7 @@ type SliceStatusOptions struct {
 	LocalRealStorageClassName string
 	IngressClasses            argutils.ClassNameList
 	LoadBalancerClasses       argutils.ClassNameList
+	Gateways                  argutils.ClassNameList
 	ClusterLabels             map[string]string
 	DefaultResourceQuantity   corev1.ResourceList
 }
@@ -62,6 +66,25 @@ func getLoadBalancerClasses(opts *SliceStatusOptions) []liqov1beta1.LoadBalancer
 	return loadBalancerClasses
 }
 
+func getGateways(opts *SliceStatusOptions) []liqov1beta1.GatewayType {
+	if opts == nil {
+		return []liqov1beta1.GatewayType{}
+	}
+
+	gateways := make([]liqov1beta1.GatewayType, len(opts.Gateways.Classes))
+	for i := range opts.Gateways.Classes {
+		// Gateways are specified in the <namespace>/<name> form, with the namespace defaulting to "default".
+		namespace, name, found := strings.Cut(opts.Gateways.Classes[i].Name, "/")
+		if !found {
+			namespace, name = corev1.NamespaceDefault, namespace
+		}
+		gateways[i].Name = name
+		gateways[i].Namespace = namespace
+		gateways[i].Default = opts.Gateways.Classes[i].IsDefault
+	}
+	return gateways
+}
+
 func getStorageClasses(ctx context.Context, cl client.Client, opts *SliceStatusOptions) ([]liqov1beta1.StorageType, error) {
 	if opts == nil || !opts.EnableStorage {
 		return []liqov1beta1.StorageType{}, nil
@@ -73,10 +96,16 @@ func getStorageClasses(ctx context.Context, cl client.Client, opts *SliceStatusO
 		return nil, err
 	}
 
+	capacities, err := getStorageCapacities(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
 	storageTypes := make([]liqov1beta1.StorageType, len(storageClassList.Items))
 	for i := range storageClassList.Items {
 		class := &storageClassList.Items[i]
 		storageTypes[i].StorageClassName = class.GetName()
+		storageTypes[i].Capacity = capacities[class.GetName()]
 
 		// set the storage class as default if:
 		// 1. it is the real storage class of the local cluster
@@ -98,6 +127,38 @@ func getStorageClasses(ctx context.Context, cl client.Client, opts *SliceStatusO
 	return storageTypes, nil
 }
 
+// getStorageCapacities returns, for each storage class whose capacity is tracked, the maximum size of a volume that can
+// currently be provisioned, considering the most capable topology segment (as a volume is provisioned in a single one).
+func getStorageCapacities(ctx context.Context, cl client.Client) (map[string]*resource.Quantity, error) {
+	var capacityList storagev1.CSIStorageCapacityList
+	if err := cl.List(ctx, &capacityList); err != nil {
+		return nil, err
+	}
+
+	capacities := map[string]*resource.Quantity{}
+	for i := range capacityList.Items {
+		capacity := &capacityList.Items[i]
+		// Skip the capacities published by Liqo for the virtual storage class, which refer to other clusters.
+		if _, found := capacity.GetLabels()[consts.VirtualStorageCapacityLabel]; found {
+			continue
+		}
+
+		limit := capacity.Capacity
+		if capacity.MaximumVolumeSize != nil {
+			limit = capacity.MaximumVolumeSize
+		}
+		if limit == nil {
+			continue
+		}
+
+		if current, found := capacities[capacity.StorageClassName]; !found || limit.Cmp(*current) > 0 {
+			capacities[capacity.StorageClassName] = limit
+		}
+	}
+
+	return capacities, nil
+}
+
 func getNodeLabels(opts *SliceStatusOptions) map[string]string {
 	if opts == nil {
 		return map[string]string{}
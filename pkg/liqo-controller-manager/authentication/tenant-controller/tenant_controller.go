@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/ed25519"
 	"fmt"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -47,6 +48,11 @@ var (
 	tenantClusterRolesClusterWide = []string{
 		"liqo-virtual-kubelet-remote-clusterwide",
 	}
+
+	// tenantClusterRolesClusterScoped are bound only to the tenants allowed to reflect cluster-scoped resources.
+	tenantClusterRolesClusterScoped = []string{
+		"liqo-virtual-kubelet-remote-clusterscoped",
+	}
 )
 
 // TenantReconciler manages the lifecycle of a Tenant.
@@ -63,8 +69,9 @@ type TenantReconciler struct {
 	CAOverride               []byte
 	TrustedCA                bool
 
-	tenantClusterRoles            []*rbacv1.ClusterRole
-	tenantClusterRolesClusterWide []*rbacv1.ClusterRole
+	tenantClusterRoles              []*rbacv1.ClusterRole
+	tenantClusterRolesClusterWide   []*rbacv1.ClusterRole
+	tenantClusterRolesClusterScoped []*rbacv1.ClusterRole
 }
 
 // NewTenantReconciler creates a new TenantReconciler.
@@ -236,37 +243,63 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 			r.EventRecorder.Event(tenant, corev1.EventTypeWarning, "ClusterRolesClusterWideBindingFailed", err.Error())
 			return ctrl.Result{}, err
 		}
+
+		if err = r.handleClusterScopedReflection(ctx, tenant); err != nil {
+			klog.Errorf("Unable to handle the cluster-scoped reflection permissions for the Tenant %q: %s", req.Name, err)
+			r.EventRecorder.Event(tenant, corev1.EventTypeWarning, "ClusterRolesClusterScopedBindingFailed", err.Error())
+			return ctrl.Result{}, err
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *TenantReconciler) ensureSetup(ctx context.Context) error {
-	if r.tenantClusterRoles == nil || len(r.tenantClusterRoles) == 0 {
-		r.tenantClusterRoles = make([]*rbacv1.ClusterRole, len(tenantClusterRoles))
-		for i, roleName := range tenantClusterRoles {
-			role := &rbacv1.ClusterRole{}
-			if err := r.Get(ctx, client.ObjectKey{Name: roleName}, role); err != nil {
-				return err
-			}
-			r.tenantClusterRoles[i] = role
+func (r *TenantReconciler) ensureSetup(ctx context.Context) (err error) {
+	if len(r.tenantClusterRoles) == 0 {
+		if r.tenantClusterRoles, err = r.getClusterRoles(ctx, tenantClusterRoles); err != nil {
+			return err
 		}
 	}
 
-	if r.tenantClusterRolesClusterWide == nil || len(r.tenantClusterRolesClusterWide) == 0 {
-		r.tenantClusterRolesClusterWide = make([]*rbacv1.ClusterRole, len(tenantClusterRolesClusterWide))
-		for i, roleName := range tenantClusterRolesClusterWide {
-			role := &rbacv1.ClusterRole{}
-			if err := r.Get(ctx, client.ObjectKey{Name: roleName}, role); err != nil {
-				return err
-			}
-			r.tenantClusterRolesClusterWide[i] = role
+	if len(r.tenantClusterRolesClusterWide) == 0 {
+		if r.tenantClusterRolesClusterWide, err = r.getClusterRoles(ctx, tenantClusterRolesClusterWide); err != nil {
+			return err
+		}
+	}
+
+	if len(r.tenantClusterRolesClusterScoped) == 0 {
+		if r.tenantClusterRolesClusterScoped, err = r.getClusterRoles(ctx, tenantClusterRolesClusterScoped); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// getClusterRoles retrieves the ClusterRoles with the given names.
+func (r *TenantReconciler) getClusterRoles(ctx context.Context, names []string) ([]*rbacv1.ClusterRole, error) {
+	roles := make([]*rbacv1.ClusterRole, len(names))
+	for i, roleName := range names {
+		role := &rbacv1.ClusterRole{}
+		if err := r.Get(ctx, client.ObjectKey{Name: roleName}, role); err != nil {
+			return nil, err
+		}
+		roles[i] = role
+	}
+	return roles, nil
+}
+
+// handleClusterScopedReflection binds or unbinds the permissions to reflect cluster-scoped resources,
+// depending on whether the tenant is allowed to.
+func (r *TenantReconciler) handleClusterScopedReflection(ctx context.Context, tenant *authv1beta1.Tenant) error {
+	if !tenant.Spec.AllowClusterScopedReflection {
+		return r.NamespaceManager.UnbindClusterRolesClusterWide(ctx, tenant.Spec.ClusterID, tenantClusterRolesClusterScoped...)
+	}
+
+	_, err := r.NamespaceManager.BindClusterRolesClusterWide(ctx, tenant.Spec.ClusterID, tenant, r.tenantClusterRolesClusterScoped...)
+	return err
+}
+
 // SetupWithManager sets up the TenantReconciler with the Manager.
 func (r *TenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlTenant).
@@ -333,7 +366,7 @@ func (r *TenantReconciler) handleTenantUncordoned(ctx context.Context, tenant *a
 func (r *TenantReconciler) handleTenantDrained(ctx context.Context, tenant *authv1beta1.Tenant) error {
 	// Delete binding of cluster roles cluster wide
 	if err := r.NamespaceManager.UnbindClusterRolesClusterWide(ctx, tenant.Spec.ClusterID,
-		tenantClusterRolesClusterWide...); err != nil {
+		slices.Concat(tenantClusterRolesClusterWide, tenantClusterRolesClusterScoped)...); err != nil {
 		r.EventRecorder.Event(tenant, corev1.EventTypeWarning, "ClusterRolesClusterWideUnbindingFailed", err.Error())
 		return err
 	}
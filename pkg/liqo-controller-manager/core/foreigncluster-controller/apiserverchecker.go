@@ -191,6 +191,7 @@ func (r *ForeignClusterReconciler) runAPIServerChecker(ctx context.Context, clus
 		if oldStatus != newStatus {
 			fcutils.EnsureGenericCondition(fc,
 				liqov1beta1.APIServerStatusCondition, newStatus, reason, message)
+			r.handleHealth(fc)
 			if err := r.Status().Update(ctx, fc); err != nil {
 				klog.Errorf("[%s] error while updating foreign API server status: %v", clusterID, err)
 				return false, nil
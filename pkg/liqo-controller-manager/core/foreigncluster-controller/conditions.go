@@ -60,6 +60,9 @@ const (
 	identityNotReadyReason  = "IdentityNotReady"
 	identityNotReadyMessage = "The identity is not correctly configured"
 
+	discoveredReason        = "Discovered"
+	discoveredMessageFormat = "The foreign cluster has been discovered through the cluster registry (announcement: %s)"
+
 	apiServerReadyReason  = "APIServerReady"
 	apiServerReadyMessage = "The foreign cluster API Server is ready"
 
@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/trace"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -47,8 +48,9 @@ import (
 // ForeignClusterReconciler reconciles a ForeignCluster object.
 type ForeignClusterReconciler struct {
 	client.Client
-	Scheme       *runtime.Scheme
-	ResyncPeriod time.Duration
+	Scheme        *runtime.Scheme
+	EventRecorder record.EventRecorder
+	ResyncPeriod  time.Duration
 
 	NetworkingEnabled     bool
 	AuthenticationEnabled bool
@@ -62,6 +64,7 @@ type ForeignClusterReconciler struct {
 // +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters/finalizers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.liqo.io,resources=clusterannouncements,verbs=get;list;watch
 // +kubebuilder:rbac:groups=networking.liqo.io,resources=connections,verbs=get;list;watch
 // +kubebuilder:rbac:groups=networking.liqo.io,resources=gatewayservers,verbs=get;list;watch
 // +kubebuilder:rbac:groups=networking.liqo.io,resources=gatewayclients,verbs=get;list;watch
@@ -70,6 +73,7 @@ type ForeignClusterReconciler struct {
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=virtualnodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile reconciles ForeignCluster resources.
 func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
@@ -83,6 +87,7 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	if err := r.Get(ctx, req.NamespacedName, &foreignCluster); err != nil {
 		if errors.IsNotFound(err) {
 			klog.V(4).Infof("foreignCluster %q not found", req.Name)
+			deleteHealthMetrics(req.Name)
 			return ctrl.Result{}, nil
 		}
 		klog.Errorf("unable to get foreignCluster %q: %v", req.Name, err)
@@ -103,6 +108,7 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	updateStatus := func() {
 		if updateNeeded {
 			defer tracer.Step("ForeignCluster status update")
+			r.handleHealth(&foreignCluster)
 			if newErr := r.Client.Status().Update(ctx, &foreignCluster); newErr != nil {
 				klog.Error(newErr)
 				err = newErr
@@ -138,6 +144,11 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 	tracer.Step("Handled offloading module resources")
 
+	if err := r.handleDiscoveryStatus(ctx, &foreignCluster); err != nil {
+		return ctrl.Result{}, err
+	}
+	tracer.Step("Handled discovery status")
+
 	// Set the role of the ForeignCluster depending on the presence of the different resources.
 	fcutils.SetRole(&foreignCluster, consumer, provider)
 
@@ -155,6 +166,33 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return ctrl.Result{Requeue: true, RequeueAfter: r.ResyncPeriod}, nil
 }
 
+// handleHealth refreshes the health summary of the foreign cluster, updating the metrics
+// and recording an event in case the overall health status changed.
+func (r *ForeignClusterReconciler) handleHealth(fc *liqov1beta1.ForeignCluster) {
+	previous, changed := handleHealthSummary(fc)
+	observeHealth(fc)
+	if !changed || fc.Status.Health == nil || r.EventRecorder == nil {
+		return
+	}
+
+	health := fc.Status.Health
+	switch {
+	case health.Status == liqov1beta1.HealthStatusHealthy:
+		r.EventRecorder.Eventf(fc, corev1.EventTypeNormal, "HealthChanged",
+			"Foreign cluster is %s (previously %s, score %d)", health.Status, healthOrUnknown(previous), health.Score)
+	default:
+		r.EventRecorder.Eventf(fc, corev1.EventTypeWarning, "HealthChanged",
+			"Foreign cluster is %s (previously %s, score %d): %s", health.Status, healthOrUnknown(previous), health.Score, health.Message)
+	}
+}
+
+func healthOrUnknown(health liqov1beta1.HealthStatus) string {
+	if health == "" {
+		return "unknown"
+	}
+	return string(health)
+}
+
 // SetupWithManager assigns the operator to a manager.
 func (r *ForeignClusterReconciler) SetupWithManager(mgr ctrl.Manager, workers int) error {
 	// Prevent triggering a reconciliation in case of status modifications only.
@@ -170,6 +208,7 @@ func (r *ForeignClusterReconciler) SetupWithManager(mgr ctrl.Manager, workers in
 
 	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlForeignCluster).
 		For(&liqov1beta1.ForeignCluster{}, builder.WithPredicates(foreignClusterPredicate)).
+		Watches(&liqov1beta1.ClusterAnnouncement{}, handler.EnqueueRequestsFromMapFunc(r.foreignclusterEnqueuer)).
 		Watches(&networkingv1beta1.Configuration{}, handler.EnqueueRequestsFromMapFunc(r.foreignclusterEnqueuer)).
 		Watches(&networkingv1beta1.Connection{}, handler.EnqueueRequestsFromMapFunc(r.foreignclusterEnqueuer)).
 		Watches(&networkingv1beta1.GatewayServer{}, handler.EnqueueRequestsFromMapFunc(r.foreignclusterEnqueuer)).
@@ -0,0 +1,86 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package foreignclustercontroller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+var (
+	// HealthScore is the metric that reports the health score of each foreign cluster.
+	HealthScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "liqo_foreigncluster_health_score",
+			Help: "Health score of the foreign cluster, ranging from 0 (down) to 100 (healthy).",
+		},
+		[]string{"foreign_cluster", "cluster_id"},
+	)
+
+	// ModuleHealth is the metric that reports the health of each module of the foreign clusters.
+	// For each module, the series matching the current health status is set to 1, while the others are set to 0.
+	ModuleHealth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "liqo_foreigncluster_module_health",
+			Help: "Health of the modules of the foreign cluster, with value 1 for the current status and 0 otherwise.",
+		},
+		[]string{"foreign_cluster", "cluster_id", "module", "status"},
+	)
+)
+
+var healthStatuses = []liqov1beta1.HealthStatus{
+	liqov1beta1.HealthStatusHealthy, liqov1beta1.HealthStatusDegraded, liqov1beta1.HealthStatusDown,
+}
+
+func init() {
+	metrics.Registry.MustRegister(HealthScore, ModuleHealth)
+}
+
+// observeHealth updates the health metrics of the given foreign cluster.
+func observeHealth(fc *liqov1beta1.ForeignCluster) {
+	deleteHealthMetrics(fc.Name)
+	if fc.Status.Health == nil {
+		return
+	}
+
+	clusterID := string(fc.Spec.ClusterID)
+	HealthScore.WithLabelValues(fc.Name, clusterID).Set(float64(fc.Status.Health.Score))
+
+	modules := map[string]liqov1beta1.Module{
+		"networking":     fc.Status.Modules.Networking,
+		"authentication": fc.Status.Modules.Authentication,
+		"offloading":     fc.Status.Modules.Offloading,
+	}
+	for name, module := range modules {
+		if !module.Enabled {
+			continue
+		}
+		for _, status := range healthStatuses {
+			value := 0.0
+			if module.Health == status {
+				value = 1
+			}
+			ModuleHealth.WithLabelValues(fc.Name, clusterID, name, string(status)).Set(value)
+		}
+	}
+}
+
+// deleteHealthMetrics removes the health metrics of the given foreign cluster.
+func deleteHealthMetrics(name string) {
+	HealthScore.DeletePartialMatch(prometheus.Labels{"foreign_cluster": name})
+	ModuleHealth.DeletePartialMatch(prometheus.Labels{"foreign_cluster": name})
+}
@@ -16,6 +16,8 @@ package foreignclustercontroller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -28,7 +30,9 @@ import (
 	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/gateway/forge"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/discovery"
 	"github.com/liqotech/liqo/pkg/utils"
 	fcutils "github.com/liqotech/liqo/pkg/utils/foreigncluster"
 	"github.com/liqotech/liqo/pkg/utils/getters"
@@ -59,9 +63,38 @@ func (r *ForeignClusterReconciler) clearStatusExceptConditions(foreignCluster *l
 			},
 		},
 		Conditions: foreignCluster.Status.Conditions,
+		Health:     foreignCluster.Status.Health,
 	}
 }
 
+// handleDiscoveryStatus sets the Discovered condition if a valid announcement of the foreign cluster
+// has been retrieved from the cluster registry. The ForeignClusters of the discovered candidates not yet
+// peered are created by the foreignclusterEnqueuer, as the mirrored announcements carry the cluster ID label.
+func (r *ForeignClusterReconciler) handleDiscoveryStatus(ctx context.Context, fc *liqov1beta1.ForeignCluster) error {
+	clusterID := fc.Spec.ClusterID
+
+	var announcements liqov1beta1.ClusterAnnouncementList
+	if err := r.List(ctx, &announcements, client.MatchingLabels{
+		consts.RemoteClusterID: string(clusterID),
+		consts.DiscoveredLabel: "true",
+	}); err != nil {
+		klog.Errorf("an error occurred while listing the ClusterAnnouncements for the ForeignCluster %q: %s", clusterID, err)
+		return err
+	}
+
+	for i := range announcements.Items {
+		if !discovery.IsExpired(&announcements.Items[i], time.Now()) {
+			fcutils.EnsureGenericCondition(fc, liqov1beta1.DiscoveredCondition, liqov1beta1.ConditionStatusEstablished,
+				discoveredReason, fmt.Sprintf(discoveredMessageFormat, klog.KObj(&announcements.Items[i])))
+			return nil
+		}
+	}
+
+	klog.V(6).Infof("No valid ClusterAnnouncement found for ForeignCluster %q", clusterID)
+	fcutils.DeleteGenericCondition(fc, liqov1beta1.DiscoveredCondition)
+	return nil
+}
+
 func (r *ForeignClusterReconciler) handleConnectionStatus(ctx context.Context,
 	fc *liqov1beta1.ForeignCluster, statusExceptions map[liqov1beta1.ConditionType]statusException) error {
 	clusterID := fc.Spec.ClusterID
@@ -422,6 +455,106 @@ func (r *ForeignClusterReconciler) handleOffloadingModuleStatus(ctx context.Cont
 	return nil
 }
 
+// handleHealthSummary computes the health of each enabled module and the overall health summary of the foreign cluster.
+// It returns the previous overall health status, and whether it changed.
+func handleHealthSummary(fc *liqov1beta1.ForeignCluster) (previous liqov1beta1.HealthStatus, changed bool) {
+	if fc.Status.Health != nil {
+		previous = fc.Status.Health.Status
+	}
+
+	summary := liqov1beta1.HealthSummary{Status: liqov1beta1.HealthStatusHealthy}
+	total, evaluated := 0, 0
+	evaluate := func(health liqov1beta1.HealthStatus, conditions []liqov1beta1.Condition) {
+		summary.Status = worstHealth(summary.Status, health)
+		total += healthScore(health)
+		evaluated++
+		if summary.Reason == "" {
+			if failing := firstFailingCondition(conditions); failing != nil {
+				summary.Reason, summary.Message = failing.Reason, failing.Message
+			}
+		}
+	}
+
+	for _, module := range []*liqov1beta1.Module{
+		&fc.Status.Modules.Networking, &fc.Status.Modules.Authentication, &fc.Status.Modules.Offloading,
+	} {
+		module.Health = ""
+		if !module.Enabled {
+			continue
+		}
+		module.Health = conditionsHealth(module.Conditions)
+		evaluate(module.Health, module.Conditions)
+	}
+
+	if apiServer := fcutils.GetCondition(fc.Status.Conditions, liqov1beta1.APIServerStatusCondition); apiServer != nil {
+		conditions := []liqov1beta1.Condition{*apiServer}
+		evaluate(conditionsHealth(conditions), conditions)
+	}
+
+	// Nothing to summarize, as no module is enabled and the API server is not checked.
+	if evaluated == 0 {
+		fc.Status.Health = nil
+		return previous, previous != ""
+	}
+
+	summary.Score = total / evaluated
+	summary.LastTransitionTime = metav1.Now()
+	if fc.Status.Health != nil && previous == summary.Status {
+		summary.LastTransitionTime = fc.Status.Health.LastTransitionTime
+	}
+	fc.Status.Health = &summary
+	return previous, previous != summary.Status
+}
+
+// conditionsHealth returns the worst health among the given conditions.
+func conditionsHealth(conditions []liqov1beta1.Condition) liqov1beta1.HealthStatus {
+	health := liqov1beta1.HealthStatusHealthy
+	for i := range conditions {
+		health = worstHealth(health, conditionHealth(conditions[i].Status))
+	}
+	return health
+}
+
+// conditionHealth maps the status of a condition to the corresponding health.
+func conditionHealth(status liqov1beta1.ConditionStatusType) liqov1beta1.HealthStatus {
+	switch status {
+	case liqov1beta1.ConditionStatusPending, liqov1beta1.ConditionStatusSomeNotReady:
+		return liqov1beta1.HealthStatusDegraded
+	case liqov1beta1.ConditionStatusError, liqov1beta1.ConditionStatusNotReady:
+		return liqov1beta1.HealthStatusDown
+	default:
+		return liqov1beta1.HealthStatusHealthy
+	}
+}
+
+// firstFailingCondition returns the first condition which is not healthy, if any.
+func firstFailingCondition(conditions []liqov1beta1.Condition) *liqov1beta1.Condition {
+	for i := range conditions {
+		if conditionHealth(conditions[i].Status) != liqov1beta1.HealthStatusHealthy {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func worstHealth(a, b liqov1beta1.HealthStatus) liqov1beta1.HealthStatus {
+	if healthScore(b) < healthScore(a) {
+		return b
+	}
+	return a
+}
+
+func healthScore(health liqov1beta1.HealthStatus) int {
+	switch health {
+	case liqov1beta1.HealthStatusHealthy:
+		return 100
+	case liqov1beta1.HealthStatusDegraded:
+		return 50
+	default:
+		return 0
+	}
+}
+
 func clearModule(module *liqov1beta1.Module) {
 	module.Enabled = false
 	module.Conditions = nil
@@ -0,0 +1,177 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package foreignclustercontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	fcutils "github.com/liqotech/liqo/pkg/utils/foreigncluster"
+)
+
+var _ = Describe("Health summary", func() {
+	var fc *liqov1beta1.ForeignCluster
+
+	condition := func(conditionType liqov1beta1.ConditionType, status liqov1beta1.ConditionStatusType) liqov1beta1.Condition {
+		return liqov1beta1.Condition{Type: conditionType, Status: status, Reason: string(conditionType) + string(status),
+			Message: string(conditionType) + " is " + string(status)}
+	}
+
+	BeforeEach(func() {
+		fc = &liqov1beta1.ForeignCluster{}
+		fc.Name = "cluster-1"
+		fc.Spec.ClusterID = "cluster-1"
+		fc.Status.Modules.Networking = liqov1beta1.Module{Enabled: true, Conditions: []liqov1beta1.Condition{
+			condition(liqov1beta1.NetworkConnectionStatusCondition, liqov1beta1.ConditionStatusEstablished),
+		}}
+		fc.Status.Modules.Authentication = liqov1beta1.Module{Enabled: true, Conditions: []liqov1beta1.Condition{
+			condition(liqov1beta1.AuthTenantStatusCondition, liqov1beta1.ConditionStatusReady),
+		}}
+	})
+
+	It("should report a healthy cluster if all conditions are established or ready", func() {
+		previous, changed := handleHealthSummary(fc)
+		Expect(previous).To(BeEmpty())
+		Expect(changed).To(BeTrue())
+		Expect(fc.Status.Health).ToNot(BeNil())
+		Expect(fc.Status.Health.Status).To(Equal(liqov1beta1.HealthStatusHealthy))
+		Expect(fc.Status.Health.Score).To(Equal(100))
+		Expect(fc.Status.Health.Reason).To(BeEmpty())
+		Expect(fc.Status.Modules.Networking.Health).To(Equal(liqov1beta1.HealthStatusHealthy))
+		Expect(fc.Status.Modules.Offloading.Health).To(BeEmpty())
+	})
+
+	It("should report a degraded cluster if some conditions are pending", func() {
+		fc.Status.Modules.Networking.Conditions = append(fc.Status.Modules.Networking.Conditions,
+			condition(liqov1beta1.NetworkGatewayClientStatusCondition, liqov1beta1.ConditionStatusSomeNotReady))
+
+		handleHealthSummary(fc)
+		Expect(fc.Status.Health.Status).To(Equal(liqov1beta1.HealthStatusDegraded))
+		Expect(fc.Status.Health.Score).To(Equal(75))
+		Expect(fc.Status.Health.Reason).To(Equal("NetworkGatewayClientStatusSomeNotReady"))
+		Expect(fc.Status.Modules.Networking.Health).To(Equal(liqov1beta1.HealthStatusDegraded))
+		Expect(fc.Status.Modules.Authentication.Health).To(Equal(liqov1beta1.HealthStatusHealthy))
+	})
+
+	It("should report a down cluster and the first failing reason", func() {
+		fc.Status.Modules.Authentication.Conditions[0] = condition(liqov1beta1.AuthTenantStatusCondition, liqov1beta1.ConditionStatusNotReady)
+		fc.Status.Conditions = []liqov1beta1.Condition{
+			condition(liqov1beta1.APIServerStatusCondition, liqov1beta1.ConditionStatusError),
+		}
+
+		handleHealthSummary(fc)
+		Expect(fc.Status.Health.Status).To(Equal(liqov1beta1.HealthStatusDown))
+		Expect(fc.Status.Health.Score).To(Equal(33))
+		Expect(fc.Status.Health.Reason).To(Equal("AuthTenantStatusNotReady"))
+		Expect(fc.Status.Health.Message).To(Equal("AuthTenantStatus is NotReady"))
+	})
+
+	It("should preserve the transition time if the status did not change", func() {
+		handleHealthSummary(fc)
+		transition := fc.Status.Health.LastTransitionTime
+		transition.Time = transition.Add(-1e9)
+		fc.Status.Health.LastTransitionTime = transition
+
+		previous, changed := handleHealthSummary(fc)
+		Expect(previous).To(Equal(liqov1beta1.HealthStatusHealthy))
+		Expect(changed).To(BeFalse())
+		Expect(fc.Status.Health.LastTransitionTime).To(Equal(transition))
+	})
+
+	It("should not report any health if nothing can be evaluated", func() {
+		fc.Status.Modules = liqov1beta1.Modules{}
+		_, changed := handleHealthSummary(fc)
+		Expect(changed).To(BeFalse())
+		Expect(fc.Status.Health).To(BeNil())
+	})
+
+	It("should expose the metrics and record an event on transitions", func() {
+		recorder := record.NewFakeRecorder(10)
+		r := &ForeignClusterReconciler{EventRecorder: recorder}
+
+		r.handleHealth(fc)
+		Expect(recorder.Events).To(Receive(ContainSubstring("Foreign cluster is Healthy")))
+		Expect(testutil.ToFloat64(HealthScore.WithLabelValues("cluster-1", "cluster-1"))).To(Equal(100.0))
+		Expect(testutil.ToFloat64(ModuleHealth.WithLabelValues("cluster-1", "cluster-1", "networking", "Healthy"))).To(Equal(1.0))
+
+		fc.Status.Modules.Networking.Conditions[0].Status = liqov1beta1.ConditionStatusError
+		r.handleHealth(fc)
+		Expect(recorder.Events).To(Receive(ContainSubstring("Warning HealthChanged Foreign cluster is Down")))
+		Expect(testutil.ToFloat64(ModuleHealth.WithLabelValues("cluster-1", "cluster-1", "networking", "Healthy"))).To(Equal(0.0))
+
+		r.handleHealth(fc)
+		Expect(recorder.Events).ToNot(Receive())
+
+		deleteHealthMetrics("cluster-1")
+		Expect(testutil.CollectAndCount(HealthScore)).To(Equal(0))
+	})
+})
+
+var _ = Describe("Discovery status", func() {
+	var (
+		ctx          = context.Background()
+		r            *ForeignClusterReconciler
+		announcement *liqov1beta1.ClusterAnnouncement
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		utilruntime.Must(liqov1beta1.AddToScheme(scheme))
+
+		announcement = &liqov1beta1.ClusterAnnouncement{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "liqo", Labels: map[string]string{
+				consts.RemoteClusterID: "cluster-1", consts.DiscoveredLabel: "true"}},
+			Spec: liqov1beta1.ClusterAnnouncementSpec{ClusterID: "cluster-1",
+				ExpirationTime: metav1.NewTime(time.Now().Add(time.Hour))},
+		}
+		r = &ForeignClusterReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(announcement).Build(), Scheme: scheme}
+	})
+
+	It("should create the ForeignCluster of a discovered candidate", func() {
+		Expect(r.foreignclusterEnqueuer(ctx, announcement)).To(BeEmpty())
+
+		fc, err := fcutils.GetForeignClusterByID(ctx, r.Client, "cluster-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fc.Spec.ClusterID).To(BeEquivalentTo("cluster-1"))
+	})
+
+	It("should set the Discovered condition if a valid announcement exists", func() {
+		fc := &liqov1beta1.ForeignCluster{Spec: liqov1beta1.ForeignClusterSpec{ClusterID: "cluster-1"}}
+		Expect(r.handleDiscoveryStatus(ctx, fc)).To(Succeed())
+		Expect(fcutils.GetStatus(fc.Status.Conditions, liqov1beta1.DiscoveredCondition)).To(Equal(liqov1beta1.ConditionStatusEstablished))
+		Expect(fcutils.GetMessage(fc.Status.Conditions, liqov1beta1.DiscoveredCondition)).To(ContainSubstring("liqo/cluster-1"))
+	})
+
+	It("should remove the Discovered condition if the announcement is expired", func() {
+		announcement.Spec.ExpirationTime = metav1.NewTime(time.Now().Add(-time.Minute))
+		Expect(r.Update(ctx, announcement)).To(Succeed())
+
+		fc := &liqov1beta1.ForeignCluster{Spec: liqov1beta1.ForeignClusterSpec{ClusterID: "cluster-1"}}
+		fcutils.EnsureGenericCondition(fc, liqov1beta1.DiscoveredCondition, liqov1beta1.ConditionStatusEstablished, discoveredReason, "")
+		Expect(r.handleDiscoveryStatus(ctx, fc)).To(Succeed())
+		Expect(fcutils.GetCondition(fc.Status.Conditions, liqov1beta1.DiscoveredCondition)).To(BeNil())
+	})
+})
@@ -0,0 +1,140 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	authgetters "github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication/getters"
+	authutils "github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication/utils"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+	ipamips "github.com/liqotech/liqo/pkg/utils/ipam/mapping"
+)
+
+// apiServerProxyIPName is the name of the IP resource remapping the API server proxy of a cluster.
+const apiServerProxyIPName = "api-server-proxy"
+
+// ensureAuthenticated completes the authentication challenge with the provider cluster,
+// to obtain the control plane Identity (as "liqoctl authenticate").
+func (r *PeeringReconciler) ensureAuthenticated(ctx context.Context, peering *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	// In the provider cluster, generate a nonce for the consumer cluster authentication challenge.
+	if err := authutils.EnsureNonceSecret(ctx, remote.Client, local.clusterID, remote.tenantNamespace); err != nil {
+		return false, "", err
+	}
+	nonceSecret, err := getters.GetNonceSecretByClusterID(ctx, remote.Client, local.clusterID)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to get the nonce secret: %w", err)
+	}
+	nonce, err := authgetters.GetNonceFromSecret(nonceSecret)
+	if err != nil {
+		return false, nonceSecretPendingMessage, nil
+	}
+
+	// In the consumer cluster, sign the nonce.
+	err = authutils.EnsureSignedNonceSecret(ctx, local.Client, remote.clusterID, local.tenantNamespace, ptr.To(string(nonce)))
+	if err != nil {
+		return false, "", err
+	}
+	signedNonceSecret, err := getters.GetSignedNonceSecretByClusterID(ctx, local.Client, remote.clusterID)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to get the signed nonce secret: %w", err)
+	}
+	signedNonce, err := authgetters.GetSignedNonceFromSecret(signedNonceSecret)
+	if err != nil {
+		return false, signedNonceSecretPending, nil
+	}
+
+	proxyURL := peering.Spec.Authentication.ProxyURL
+	if peering.Spec.Authentication.InBand && proxyURL == "" {
+		if proxyURL, err = inBandProxyURL(ctx, local, remote); err != nil {
+			return false, "", fmt.Errorf("unable to forge the in-band proxy URL: %w", err)
+		}
+	}
+
+	// In the provider cluster, apply the Tenant forged by the consumer cluster.
+	tenant, err := authutils.GenerateTenant(ctx, local.Client, local.clusterID, local.liqoNamespace, signedNonce, &proxyURL)
+	if err != nil {
+		return false, "", err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, remote.Client, tenant, func() error { return nil }); err != nil {
+		return false, "", fmt.Errorf("unable to apply the Tenant on the provider cluster: %w", err)
+	}
+	tenant, err = getters.GetTenantByClusterID(ctx, remote.Client, local.clusterID)
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, tenantPendingMessage, nil
+	case err != nil:
+		return false, "", fmt.Errorf("unable to retrieve the Tenant: %w", err)
+	case tenant.Status.AuthParams == nil || tenant.Status.TenantNamespace == "":
+		return false, tenantPendingMessage, nil
+	}
+
+	// In the consumer cluster, apply the Identity forged by the provider cluster.
+	identity, err := authutils.GenerateIdentityControlPlane(ctx, remote.Client, local.clusterID, local.tenantNamespace, remote.clusterID)
+	if err != nil {
+		return false, "", err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, local.Client, identity, func() error { return nil }); err != nil {
+		return false, "", fmt.Errorf("unable to apply the Identity on the consumer cluster: %w", err)
+	}
+	identity, err = getters.GetControlPlaneIdentityByClusterID(ctx, local.Client, remote.clusterID)
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, identityPendingMessage, nil
+	case err != nil:
+		return false, "", fmt.Errorf("unable to retrieve the Identity: %w", err)
+	case identity.Status.KubeconfigSecretRef == nil || identity.Status.KubeconfigSecretRef.Name == "":
+		return false, identityPendingMessage, nil
+	}
+
+	return true, authenticatedMessage, nil
+}
+
+// inBandProxyURL returns the URL of the API server proxy of the provider cluster, reachable through the network tunnel.
+func inBandProxyURL(ctx context.Context, local, remote *cluster) (string, error) {
+	var ip ipamv1alpha1.IP
+	if err := remote.Get(ctx, types.NamespacedName{Namespace: remote.liqoNamespace, Name: apiServerProxyIPName}, &ip); err != nil {
+		return "", err
+	}
+
+	var proxyIP string
+	for _, mapping := range ip.Status.IPMappings {
+		proxyIP = mapping.String()
+		break
+	}
+	if proxyIP == "" {
+		return "", fmt.Errorf("no IP found for the API server proxy, make sure the networking module is enabled and working")
+	}
+
+	conf, err := getters.GetConfigurationByClusterID(ctx, local.Client, remote.clusterID)
+	if err != nil {
+		return "", err
+	}
+	remappedIP, err := ipamips.MapAddressWithConfiguration(conf, proxyIP)
+	if err != nil {
+		return "", err
+	}
+
+	return "http://" + remappedIP + ":8118", nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+const (
+	stepEstablishedReason = "StepEstablished"
+	stepPendingReason     = "StepPending"
+	stepErrorReason       = "StepError"
+	stepTearingDownReason = "StepTearingDown"
+
+	waitingPreviousStepReason  = "WaitingPreviousStep"
+	waitingPreviousStepMessage = "Waiting for the previous steps to be completed"
+
+	networkInitializedMessage      = "The network Configurations have been exchanged"
+	networkConfigurationPending    = "Waiting for the network Configurations to be processed"
+	networkConnectedMessage        = "The network connection with the provider cluster is established"
+	gatewayServerEndpointPending   = "Waiting for the gateway server endpoint to be available"
+	gatewayServerKeyPending        = "Waiting for the gateway server public key to be available"
+	gatewayClientKeyPending        = "Waiting for the gateway client public key to be available"
+	connectionPendingMessage       = "Waiting for the network connection to be established"
+	authenticatedMessage           = "The control plane Identity of the provider cluster has been obtained"
+	nonceSecretPendingMessage      = "Waiting for the nonce to be generated by the provider cluster"
+	signedNonceSecretPending       = "Waiting for the nonce to be signed"
+	tenantPendingMessage           = "Waiting for the Tenant to be accepted by the provider cluster"
+	identityPendingMessage         = "Waiting for the control plane Identity to be processed"
+	resourceSliceAcceptedMessage   = "The ResourceSlice has been accepted by the provider cluster"
+	resourceSlicePendingMessage    = "Waiting for the ResourceSlice to be accepted by the provider cluster"
+	virtualNodeReadyMessage        = "The VirtualNode is ready"
+	virtualNodePendingMessage      = "Waiting for the VirtualNode to be created"
+	virtualNodeNotReadyMessage     = "Waiting for the VirtualNode to become ready"
+	remoteClusterUnreachableFormat = "Unable to access the provider cluster: %v"
+
+	offloadingTearingDownMessage       = "Waiting for the ResourceSlices and the VirtualNodes to be deleted"
+	gatewaysTearingDownMessage         = "Waiting for the gateways to be deleted"
+	tenantNamespacesTearingDownMessage = "Waiting for the tenant namespaces to be deleted"
+	unpeerRemoteUnreachableFormat      = "Unable to tear down the peering, as the provider cluster is unreachable: %v " +
+		"(remove the %q finalizer to delete the Peering without tearing it down, then run \"liqoctl unpeer\")"
+)
+
+// ensureCondition ensures the presence of a condition with the given type, status, reason and message.
+func ensureCondition(peering *liqov1beta1.Peering, conditionType liqov1beta1.PeeringConditionType,
+	status liqov1beta1.ConditionStatusType, reason, message string) {
+	for i := range peering.Status.Conditions {
+		cond := &peering.Status.Conditions[i]
+		if cond.Type == conditionType {
+			if cond.Status != status || reason != cond.Reason || message != cond.Message {
+				cond.Status = status
+				cond.LastTransitionTime = metav1.Now()
+				cond.Reason = reason
+				cond.Message = message
+			}
+			return
+		}
+	}
+
+	// if the type has not been found in the list, add it
+	peering.Status.Conditions = append(peering.Status.Conditions,
+		liqov1beta1.PeeringCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.Now(),
+			Reason:             reason,
+			Message:            message,
+		})
+}
+
+// deleteCondition removes the condition with the given type, if present.
+func deleteCondition(peering *liqov1beta1.Peering, conditionType liqov1beta1.PeeringConditionType) {
+	for i := range peering.Status.Conditions {
+		if peering.Status.Conditions[i].Type == conditionType {
+			peering.Status.Conditions = append(peering.Status.Conditions[:i], peering.Status.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetCondition returns the condition with the given type, or nil if not present.
+func GetCondition(peering *liqov1beta1.Peering, conditionType liqov1beta1.PeeringConditionType) *liqov1beta1.PeeringCondition {
+	for i := range peering.Status.Conditions {
+		if peering.Status.Conditions[i].Type == conditionType {
+			return &peering.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/networking/forge"
+	nwgetters "github.com/liqotech/liqo/pkg/liqo-controller-manager/networking/getters"
+	networkingutils "github.com/liqotech/liqo/pkg/liqo-controller-manager/networking/utils"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// ensureNetworkInitialized exchanges the network Configurations between the two clusters (as "liqoctl network init").
+func (r *PeeringReconciler) ensureNetworkInitialized(ctx context.Context, _ *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	localConf, err := forge.ConfigurationForRemoteCluster(ctx, local.Client, local.tenantNamespace, local.liqoNamespace)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to forge the local network Configuration: %w", err)
+	}
+	remoteConf, err := forge.ConfigurationForRemoteCluster(ctx, remote.Client, remote.tenantNamespace, remote.liqoNamespace)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to forge the remote network Configuration: %w", err)
+	}
+
+	if err := ensureConfiguration(ctx, local, remoteConf); err != nil {
+		return false, "", fmt.Errorf("unable to setup the local network Configuration: %w", err)
+	}
+	if err := ensureConfiguration(ctx, remote, localConf); err != nil {
+		return false, "", fmt.Errorf("unable to setup the remote network Configuration: %w", err)
+	}
+
+	for _, c := range []struct {
+		cluster         *cluster
+		remoteClusterID liqov1beta1.ClusterID
+	}{{local, remote.clusterID}, {remote, local.clusterID}} {
+		conf, err := getters.GetConfigurationByClusterID(ctx, c.cluster.Client, c.remoteClusterID)
+		if client.IgnoreNotFound(err) != nil {
+			return false, "", fmt.Errorf("unable to retrieve the network Configuration: %w", err)
+		}
+		if err != nil || !networkingutils.IsConfigurationStatusSet(conf.Status) {
+			return false, networkConfigurationPending, nil
+		}
+	}
+
+	return true, networkInitializedMessage, nil
+}
+
+// ensureConfiguration creates or updates the given network Configuration in the tenant namespace of the given cluster.
+func ensureConfiguration(ctx context.Context, c *cluster, conf *networkingv1beta1.Configuration) error {
+	conf.Namespace = c.tenantNamespace
+	confCopy := conf.DeepCopy()
+	_, err := controllerutil.CreateOrUpdate(ctx, c.Client, conf, func() error {
+		if conf.Labels == nil {
+			conf.Labels = make(map[string]string)
+		}
+		conf.Labels[consts.RemoteClusterID] = confCopy.Labels[consts.RemoteClusterID]
+		conf.Spec.Remote = confCopy.Spec.Remote
+		return nil
+	})
+	return err
+}
+
+// ensureNetworkConnected creates the gateway server in the provider cluster and the gateway client in the consumer one,
+// exchanges their public keys and checks the resulting connection (as "liqoctl network connect").
+func (r *PeeringReconciler) ensureNetworkConnected(ctx context.Context, peering *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	reverse, err := isReverseConnection(ctx, local, remote)
+	if err != nil {
+		return false, "", err
+	}
+
+	// If the connection has been established in the opposite direction, do not create a new one.
+	if !reverse {
+		if completed, message, err := r.ensureGateways(ctx, peering, local, remote); !completed || err != nil {
+			return completed, message, err
+		}
+	}
+
+	conn, err := getters.GetConnectionByClusterIDInNamespace(ctx, local.Client, string(remote.clusterID), local.tenantNamespace)
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, connectionPendingMessage, nil
+	case err != nil:
+		return false, "", fmt.Errorf("unable to retrieve the Connection: %w", err)
+	case conn.Status.Value != networkingv1beta1.Connected:
+		return false, connectionPendingMessage, nil
+	default:
+		return true, networkConnectedMessage, nil
+	}
+}
+
+// isReverseConnection returns whether the gateways have been created with the consumer cluster acting as server.
+func isReverseConnection(ctx context.Context, local, remote *cluster) (bool, error) {
+	_, err := getters.GetGatewayServerByClusterID(ctx, local.Client, remote.clusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("unable to retrieve the local gateway server: %w", err)
+	} else if err == nil {
+		return true, nil
+	}
+
+	_, err = getters.GetGatewayClientByClusterID(ctx, remote.Client, local.clusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return false, fmt.Errorf("unable to retrieve the remote gateway client: %w", err)
+	}
+	return err == nil, nil
+}
+
+func (r *PeeringReconciler) ensureGateways(ctx context.Context, peering *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	networking := &peering.Spec.Networking
+
+	gwServer, err := ensureGatewayServer(ctx, remote, &forge.GwServerOptions{
+		KubeClient:        remote.kubeClient,
+		RemoteClusterID:   local.clusterID,
+		GatewayType:       forge.DefaultGwServerType,
+		TemplateName:      forge.DefaultGwServerTemplateName,
+		TemplateNamespace: remote.liqoNamespace,
+		ServiceType:       networking.ServerServiceType,
+		MTU:               networking.MTU,
+		Port:              networking.ServerPort,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("unable to setup the gateway server: %w", err)
+	}
+	endpoint := gwServer.Status.Endpoint
+	if endpoint == nil || len(endpoint.Addresses) == 0 || endpoint.Protocol == nil {
+		return false, gatewayServerEndpointPending, nil
+	}
+
+	gwClient, err := ensureGatewayClient(ctx, local, &forge.GwClientOptions{
+		KubeClient:        local.kubeClient,
+		RemoteClusterID:   remote.clusterID,
+		GatewayType:       forge.DefaultGwClientType,
+		TemplateName:      forge.DefaultGwClientTemplateName,
+		TemplateNamespace: local.liqoNamespace,
+		MTU:               networking.MTU,
+		Addresses:         endpoint.Addresses,
+		Port:              endpoint.Port,
+		Protocol:          string(*endpoint.Protocol),
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("unable to setup the gateway client: %w", err)
+	}
+
+	if gwServer.Status.SecretRef == nil {
+		return false, gatewayServerKeyPending, nil
+	}
+	keyServer, err := nwgetters.ExtractKeyFromSecretRef(ctx, remote.Client, gwServer.Status.SecretRef)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to retrieve the gateway server public key: %w", err)
+	}
+	if err := ensurePublicKey(ctx, local, remote.clusterID, keyServer, gwClient); err != nil {
+		return false, "", fmt.Errorf("unable to setup the gateway server public key: %w", err)
+	}
+
+	if gwClient.Status.SecretRef == nil {
+		return false, gatewayClientKeyPending, nil
+	}
+	keyClient, err := nwgetters.ExtractKeyFromSecretRef(ctx, local.Client, gwClient.Status.SecretRef)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to retrieve the gateway client public key: %w", err)
+	}
+	if err := ensurePublicKey(ctx, remote, local.clusterID, keyClient, gwServer); err != nil {
+		return false, "", fmt.Errorf("unable to setup the gateway client public key: %w", err)
+	}
+
+	return true, "", nil
+}
+
+func ensureGatewayServer(ctx context.Context, c *cluster, opts *forge.GwServerOptions) (*networkingv1beta1.GatewayServer, error) {
+	// If the GatewayServer already exists, keep its name.
+	var name *string
+	existing, err := getters.GetGatewayServerByClusterID(ctx, c.Client, opts.RemoteClusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	} else if err == nil {
+		name = ptr.To(existing.Name)
+	}
+
+	gwServer, err := forge.GatewayServer(c.tenantNamespace, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.Client, gwServer, func() error {
+		return forge.MutateGatewayServer(gwServer, opts)
+	}); err != nil {
+		return nil, err
+	}
+	return gwServer, nil
+}
+
+func ensureGatewayClient(ctx context.Context, c *cluster, opts *forge.GwClientOptions) (*networkingv1beta1.GatewayClient, error) {
+	// If the GatewayClient already exists, keep its name.
+	var name *string
+	existing, err := getters.GetGatewayClientByClusterID(ctx, c.Client, opts.RemoteClusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	} else if err == nil {
+		name = ptr.To(existing.Name)
+	}
+
+	gwClient, err := forge.GatewayClient(c.tenantNamespace, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.Client, gwClient, func() error {
+		return forge.MutateGatewayClient(gwClient, opts)
+	}); err != nil {
+		return nil, err
+	}
+	return gwClient, nil
+}
+
+func ensurePublicKey(ctx context.Context, c *cluster, remoteClusterID liqov1beta1.ClusterID,
+	key []byte, ownerGateway metav1.Object) error {
+	// If the PublicKey already exists, keep its name.
+	var name *string
+	existing, err := getters.GetPublicKeyByClusterID(ctx, c.Client, remoteClusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		name = ptr.To(existing.Name)
+	}
+
+	pubKey, err := forge.PublicKey(c.tenantNamespace, name, remoteClusterID, key)
+	if err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, c.Client, pubKey, func() error {
+		if err := forge.MutatePublicKey(pubKey, remoteClusterID, key); err != nil {
+			return err
+		}
+		return controllerutil.SetOwnerReference(ownerGateway, pubKey, c.Scheme())
+	})
+	return err
+}
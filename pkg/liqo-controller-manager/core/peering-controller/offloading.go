@@ -0,0 +1,96 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication/forge"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// ensureResourceSlice creates the ResourceSlice requesting resources to the provider cluster,
+// and checks whether it has been accepted (as "liqoctl create resourceslice").
+func (r *PeeringReconciler) ensureResourceSlice(ctx context.Context, peering *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	offloading := &peering.Spec.Offloading
+
+	resources := make(map[corev1.ResourceName]string, len(offloading.Resources))
+	for name, quantity := range offloading.Resources {
+		resources[name] = quantity.String()
+	}
+
+	resourceSlice := forge.ResourceSlice(string(remote.clusterID), local.tenantNamespace)
+	if _, err := controllerutil.CreateOrUpdate(ctx, local.Client, resourceSlice, func() error {
+		return forge.MutateResourceSlice(resourceSlice, remote.clusterID, &forge.ResourceSliceOptions{
+			Class:     authv1beta1.ResourceSliceClass(offloading.ResourceSliceClass),
+			Resources: resources,
+		}, offloading.CreateVirtualNode)
+	}); err != nil {
+		return false, "", fmt.Errorf("unable to setup the ResourceSlice: %w", err)
+	}
+
+	for _, conditionType := range []authv1beta1.ResourceSliceConditionType{
+		authv1beta1.ResourceSliceConditionTypeAuthentication, authv1beta1.ResourceSliceConditionTypeResources} {
+		condition := authentication.GetCondition(resourceSlice, conditionType)
+		switch {
+		case condition == nil:
+			return false, resourceSlicePendingMessage, nil
+		case condition.Status == authv1beta1.ResourceSliceConditionDenied:
+			return false, "", fmt.Errorf("ResourceSlice %s condition denied by the provider cluster: %s", conditionType, condition.Message)
+		case condition.Status != authv1beta1.ResourceSliceConditionAccepted:
+			return false, resourceSlicePendingMessage, nil
+		}
+	}
+
+	return true, resourceSliceAcceptedMessage, nil
+}
+
+// checkVirtualNode checks whether the VirtualNode created for the ResourceSlice is ready.
+func (r *PeeringReconciler) checkVirtualNode(ctx context.Context, _ *liqov1beta1.Peering,
+	local, remote *cluster) (bool, string, error) {
+	// The VirtualNode is named after the ResourceSlice it is created for.
+	var virtualNode offloadingv1beta1.VirtualNode
+	key := client.ObjectKey{Namespace: local.tenantNamespace, Name: string(remote.clusterID)}
+	if err := local.Get(ctx, key, &virtualNode); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, virtualNodePendingMessage, nil
+		}
+		return false, "", fmt.Errorf("unable to retrieve the VirtualNode: %w", err)
+	}
+
+	node, err := getters.GetNodeFromVirtualNode(ctx, local.Client, &virtualNode)
+	switch {
+	case apierrors.IsNotFound(err):
+		return false, virtualNodeNotReadyMessage, nil
+	case err != nil:
+		return false, "", fmt.Errorf("unable to retrieve the Node: %w", err)
+	case !utils.IsNodeReady(node):
+		return false, virtualNodeNotReadyMessage, nil
+	default:
+		return true, virtualNodeReadyMessage, nil
+	}
+}
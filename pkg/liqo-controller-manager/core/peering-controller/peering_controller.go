@@ -0,0 +1,375 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
+	liqoutils "github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/kubeconfig"
+	"github.com/liqotech/liqo/pkg/utils/restcfg"
+)
+
+const (
+	// pendingRequeuePeriod is the period after which a Peering with pending steps is reconciled again,
+	// as the resources in the provider cluster are not watched.
+	pendingRequeuePeriod = 10 * time.Second
+
+	// peeringControllerFinalizer is the finalizer ensuring the peering is torn down before the Peering is deleted.
+	peeringControllerFinalizer = "peering-controller.liqo.io/finalizer"
+)
+
+// cluster groups the clients and the information concerning one of the two peered clusters.
+type cluster struct {
+	client.Client
+	kubeClient       kubernetes.Interface
+	namespaceManager tenantnamespace.Manager
+
+	liqoNamespace   string
+	clusterID       liqov1beta1.ClusterID
+	tenantNamespace string
+}
+
+// step is one of the operations performed to establish a Peering. It returns whether the step
+// has been completed, a message describing its state, and whether an error occurred.
+type step struct {
+	condition liqov1beta1.PeeringConditionType
+	enabled   bool
+	run       func(ctx context.Context, peering *liqov1beta1.Peering, local, remote *cluster) (bool, string, error)
+}
+
+// NewPeeringReconciler returns a new PeeringReconciler.
+func NewPeeringReconciler(cl client.Client, s *runtime.Scheme, recorder record.EventRecorder,
+	kubeClient kubernetes.Interface, namespaceManager tenantnamespace.Manager,
+	liqoNamespace string, localClusterID liqov1beta1.ClusterID, resyncPeriod time.Duration) *PeeringReconciler {
+	return &PeeringReconciler{
+		Client: cl,
+		Scheme: s,
+
+		eventRecorder: recorder,
+
+		kubeClient:       kubeClient,
+		namespaceManager: namespaceManager,
+		liqoNamespace:    liqoNamespace,
+		localClusterID:   localClusterID,
+		resyncPeriod:     resyncPeriod,
+	}
+}
+
+// PeeringReconciler reconciles a Peering object, performing the same steps of "liqoctl peer"
+// (network initialization and connection, authentication, ResourceSlice and VirtualNode creation).
+type PeeringReconciler struct {
+	client.Client
+	*runtime.Scheme
+
+	eventRecorder record.EventRecorder
+
+	kubeClient       kubernetes.Interface
+	namespaceManager tenantnamespace.Manager
+	liqoNamespace    string
+	localClusterID   liqov1beta1.ClusterID
+	resyncPeriod     time.Duration
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=core.liqo.io,resources=peerings,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core.liqo.io,resources=peerings/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liqo.io,resources=configurations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liqo.io,resources=gatewayclients;gatewayservers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liqo.io,resources=publickeys,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=networking.liqo.io,resources=connections,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authentication.liqo.io,resources=identities,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=authentication.liqo.io,resources=resourceslices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=virtualnodes,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core.liqo.io,resources=foreignclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;delete
+
+// Reconcile reconciles Peering resources.
+func (r *PeeringReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	var peering liqov1beta1.Peering
+	if err := r.Get(ctx, req.NamespacedName, &peering); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Peering %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Unable to get Peering %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !peering.DeletionTimestamp.IsZero() {
+		return r.unpeer(ctx, &peering)
+	}
+
+	if !controllerutil.ContainsFinalizer(&peering, peeringControllerFinalizer) {
+		controllerutil.AddFinalizer(&peering, peeringControllerFinalizer)
+		if err := r.Update(ctx, &peering); err != nil {
+			klog.Errorf("Unable to add the finalizer to Peering %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	defer func() {
+		peering.Status.ObservedGeneration = peering.Generation
+		if newErr := r.Status().Update(ctx, &peering); newErr != nil {
+			klog.Errorf("Unable to update the status of Peering %q: %v", req.NamespacedName, newErr)
+			err = newErr
+		}
+	}()
+
+	local := r.localCluster()
+	remote, err := r.remoteCluster(ctx, &peering)
+	if err == nil {
+		err = r.ensureTenantNamespaces(ctx, local, remote)
+	}
+	if err != nil {
+		klog.Errorf("Unable to setup the peering for Peering %q: %v", req.NamespacedName, err)
+		r.eventRecorder.Event(&peering, corev1.EventTypeWarning, "RemoteClusterUnreachable", err.Error())
+		peering.Status.Phase = liqov1beta1.PeeringPhaseError
+		for _, s := range r.steps(&peering) {
+			if s.enabled {
+				ensureCondition(&peering, s.condition, liqov1beta1.ConditionStatusError,
+					stepErrorReason, fmt.Sprintf(remoteClusterUnreachableFormat, err))
+			}
+		}
+		return ctrl.Result{}, err
+	}
+	peering.Status.RemoteClusterID = remote.clusterID
+
+	completed, stepErr := r.runSteps(ctx, &peering, local, remote)
+	switch {
+	case stepErr != nil:
+		peering.Status.Phase = liqov1beta1.PeeringPhaseError
+		return ctrl.Result{}, stepErr
+	case !completed:
+		peering.Status.Phase = liqov1beta1.PeeringPhaseInProgress
+		return ctrl.Result{RequeueAfter: pendingRequeuePeriod}, nil
+	default:
+		if peering.Status.Phase != liqov1beta1.PeeringPhaseEstablished {
+			klog.Infof("Peering %q with cluster %q established", req.NamespacedName, remote.clusterID)
+			r.eventRecorder.Event(&peering, corev1.EventTypeNormal, "Established", "Peering established")
+		}
+		peering.Status.Phase = liqov1beta1.PeeringPhaseEstablished
+		return ctrl.Result{RequeueAfter: r.resyncPeriod}, nil
+	}
+}
+
+// unpeer tears down the peering established by the given Peering, before allowing its deletion.
+func (r *PeeringReconciler) unpeer(ctx context.Context, peering *liqov1beta1.Peering) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(peering, peeringControllerFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	peering.Status.Phase = liqov1beta1.PeeringPhaseTerminating
+	updateStatus := func() error {
+		if err := r.Status().Update(ctx, peering); err != nil {
+			klog.Errorf("Unable to update the status of Peering %q: %v", client.ObjectKeyFromObject(peering), err)
+			return err
+		}
+		return nil
+	}
+
+	remote, err := r.remoteCluster(ctx, peering)
+	if err != nil {
+		message := fmt.Sprintf(unpeerRemoteUnreachableFormat, err, peeringControllerFinalizer)
+		klog.Errorf("Unable to tear down Peering %q: %s", client.ObjectKeyFromObject(peering), message)
+		r.eventRecorder.Event(peering, corev1.EventTypeWarning, "RemoteClusterUnreachable", message)
+		return ctrl.Result{}, errors.Join(err, updateStatus())
+	}
+
+	completed, err := r.runUnpeerSteps(ctx, peering, r.localCluster(), remote)
+	switch {
+	case err != nil:
+		return ctrl.Result{}, errors.Join(err, updateStatus())
+	case !completed:
+		return ctrl.Result{RequeueAfter: pendingRequeuePeriod}, updateStatus()
+	}
+
+	klog.Infof("Peering %q with cluster %q torn down", client.ObjectKeyFromObject(peering), remote.clusterID)
+	r.eventRecorder.Event(peering, corev1.EventTypeNormal, "Unpeered", "Peering torn down")
+	controllerutil.RemoveFinalizer(peering, peeringControllerFinalizer)
+	if err := r.Update(ctx, peering); err != nil {
+		klog.Errorf("Unable to remove the finalizer from Peering %q: %v", client.ObjectKeyFromObject(peering), err)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// steps returns the ordered list of steps to establish the given Peering.
+func (r *PeeringReconciler) steps(peering *liqov1beta1.Peering) []step {
+	offloading := peering.Spec.Offloading
+	return []step{
+		{condition: liqov1beta1.PeeringNetworkInitializedCondition, enabled: peering.Spec.Networking.Enabled, run: r.ensureNetworkInitialized},
+		{condition: liqov1beta1.PeeringNetworkConnectedCondition, enabled: peering.Spec.Networking.Enabled, run: r.ensureNetworkConnected},
+		{condition: liqov1beta1.PeeringAuthenticatedCondition, enabled: true, run: r.ensureAuthenticated},
+		{condition: liqov1beta1.PeeringResourceSliceAcceptedCondition, enabled: offloading.Enabled, run: r.ensureResourceSlice},
+		{condition: liqov1beta1.PeeringVirtualNodeReadyCondition, enabled: offloading.Enabled && offloading.CreateVirtualNode, run: r.checkVirtualNode},
+	}
+}
+
+// runSteps executes the steps to establish the Peering in order, stopping at the first one not yet completed,
+// and reflects their outcome in the status conditions. It returns whether all the steps have been completed.
+func (r *PeeringReconciler) runSteps(ctx context.Context, peering *liqov1beta1.Peering, local, remote *cluster) (bool, error) {
+	var stepErr error
+	blocked := false
+
+	for _, s := range r.steps(peering) {
+		switch {
+		case !s.enabled:
+			deleteCondition(peering, s.condition)
+		case blocked:
+			ensureCondition(peering, s.condition, liqov1beta1.ConditionStatusPending, waitingPreviousStepReason, waitingPreviousStepMessage)
+		default:
+			completed, message, err := s.run(ctx, peering, local, remote)
+			switch {
+			case err != nil:
+				klog.Errorf("Step %q of Peering %q failed: %v", s.condition, client.ObjectKeyFromObject(peering), err)
+				r.eventRecorder.Event(peering, corev1.EventTypeWarning, string(s.condition)+"Failed", err.Error())
+				ensureCondition(peering, s.condition, liqov1beta1.ConditionStatusError, stepErrorReason, err.Error())
+				stepErr, blocked = err, true
+			case !completed:
+				klog.V(4).Infof("Step %q of Peering %q pending: %s", s.condition, client.ObjectKeyFromObject(peering), message)
+				ensureCondition(peering, s.condition, liqov1beta1.ConditionStatusPending, stepPendingReason, message)
+				blocked = true
+			default:
+				ensureCondition(peering, s.condition, liqov1beta1.ConditionStatusEstablished, stepEstablishedReason, message)
+			}
+		}
+	}
+
+	return !blocked, stepErr
+}
+
+// localCluster returns the clients to interact with the consumer (i.e., local) cluster.
+func (r *PeeringReconciler) localCluster() *cluster {
+	return &cluster{
+		Client:           r.Client,
+		kubeClient:       r.kubeClient,
+		namespaceManager: r.namespaceManager,
+		liqoNamespace:    r.liqoNamespace,
+		clusterID:        r.localClusterID,
+	}
+}
+
+// remoteCluster builds the clients to interact with the provider cluster from the referenced kubeconfig secret.
+func (r *PeeringReconciler) remoteCluster(ctx context.Context, peering *liqov1beta1.Peering) (*cluster, error) {
+	var secret corev1.Secret
+	key := types.NamespacedName{Namespace: peering.Namespace, Name: peering.Spec.KubeconfigSecretRef.Name}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("unable to get the kubeconfig secret %q: %w", key, err)
+	}
+
+	config, err := kubeconfig.BuildConfigFromSecret(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build the REST config: %w", err)
+	}
+	restcfg.SetRateLimiter(config)
+
+	cl, err := client.New(config, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the client: %w", err)
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the clientset: %w", err)
+	}
+
+	remote := &cluster{
+		Client:           cl,
+		kubeClient:       kubeClient,
+		namespaceManager: tenantnamespace.NewManager(kubeClient, r.Scheme),
+		liqoNamespace:    peering.Spec.RemoteLiqoNamespace,
+	}
+
+	if remote.clusterID, err = liqoutils.GetClusterIDWithControllerClient(ctx, cl, remote.liqoNamespace); err != nil {
+		return nil, fmt.Errorf("unable to retrieve the cluster ID: %w", err)
+	}
+	if remote.clusterID == r.localClusterID {
+		return nil, fmt.Errorf("the kubeconfig refers to the local cluster")
+	}
+
+	return remote, nil
+}
+
+// ensureTenantNamespaces ensures the presence of the tenant namespaces in both clusters.
+func (r *PeeringReconciler) ensureTenantNamespaces(ctx context.Context, local, remote *cluster) error {
+	localNs, err := local.namespaceManager.CreateNamespace(ctx, remote.clusterID)
+	if err != nil {
+		return fmt.Errorf("unable to ensure the local tenant namespace: %w", err)
+	}
+	local.tenantNamespace = localNs.Name
+
+	remoteNs, err := remote.namespaceManager.CreateNamespace(ctx, local.clusterID)
+	if err != nil {
+		return fmt.Errorf("unable to ensure the remote tenant namespace: %w", err)
+	}
+	remote.tenantNamespace = remoteNs.Name
+
+	return nil
+}
+
+// deletionPredicate triggers the reconciliation of the Peerings being deleted, to tear down the peering.
+var deletionPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool { return !e.ObjectNew.GetDeletionTimestamp().IsZero() },
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PeeringReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlPeering).
+		For(&liqov1beta1.Peering{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, deletionPredicate))).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.peeringsForSecret)).
+		Complete(r)
+}
+
+// peeringsForSecret enqueues the Peerings referencing the given kubeconfig secret.
+func (r *PeeringReconciler) peeringsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var peerings liqov1beta1.PeeringList
+	if err := r.List(ctx, &peerings, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Unable to list the Peerings in namespace %q: %v", obj.GetNamespace(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range peerings.Items {
+		if peerings.Items[i].Spec.KubeconfigSecretRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&peerings.Items[i])})
+		}
+	}
+	return requests
+}
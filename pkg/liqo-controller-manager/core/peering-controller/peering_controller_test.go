@@ -0,0 +1,345 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("Peering controller", func() {
+	const (
+		namespace       = "liqo"
+		tenantNamespace = "liqo-tenant-remote"
+		localClusterID  = liqov1beta1.ClusterID("local")
+		remoteClusterID = liqov1beta1.ClusterID("remote")
+	)
+
+	var (
+		cl             client.Client
+		reconciler     *PeeringReconciler
+		peering        *liqov1beta1.Peering
+		local, remote  *cluster
+		existing       []client.Object
+		remoteExisting []client.Object
+		completed      bool
+		message        string
+		err            error
+		forgeReconcile = func() {
+			cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(existing...).
+				WithStatusSubresource(&liqov1beta1.Peering{}, &authv1beta1.ResourceSlice{}).Build()
+			reconciler = NewPeeringReconciler(cl, scheme.Scheme, record.NewFakeRecorder(10),
+				nil, nil, namespace, localClusterID, time.Minute)
+			local = &cluster{Client: cl, namespaceManager: tenantnamespace.NewManager(k8sfake.NewSimpleClientset(), scheme.Scheme),
+				liqoNamespace: namespace, clusterID: localClusterID, tenantNamespace: tenantNamespace}
+			remoteCl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(remoteExisting...).Build()
+			remote = &cluster{Client: remoteCl, namespaceManager: tenantnamespace.NewManager(k8sfake.NewSimpleClientset(), scheme.Scheme),
+				clusterID: remoteClusterID}
+		}
+	)
+
+	BeforeEach(func() {
+		peering = &liqov1beta1.Peering{
+			ObjectMeta: metav1.ObjectMeta{Name: "peering", Namespace: namespace},
+			Spec: liqov1beta1.PeeringSpec{
+				KubeconfigSecretRef: corev1.LocalObjectReference{Name: "kubeconfig"},
+				RemoteLiqoNamespace: namespace,
+				Networking:          liqov1beta1.PeeringNetworking{Enabled: true},
+				Offloading: liqov1beta1.PeeringOffloading{
+					Enabled: true, ResourceSliceClass: "default", CreateVirtualNode: true,
+					Resources: corev1.ResourceList{corev1.ResourceCPU: k8sresource.MustParse("2")},
+				},
+			},
+		}
+		existing = []client.Object{peering}
+		remoteExisting = nil
+	})
+
+	Describe("the Reconcile function", func() {
+		When("the kubeconfig secret does not exist", func() {
+			BeforeEach(func() {
+				forgeReconcile()
+				_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(peering)})
+			})
+
+			It("should return an error", func() { Expect(err).To(HaveOccurred()) })
+			It("should add the finalizer", func() {
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(peering), peering)).To(Succeed())
+				Expect(peering.Finalizers).To(ContainElement(peeringControllerFinalizer))
+			})
+			It("should mark the Peering as failed", func() {
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(peering), peering)).To(Succeed())
+				Expect(peering.Status.Phase).To(Equal(liqov1beta1.PeeringPhaseError))
+				Expect(peering.Status.Conditions).To(HaveLen(5))
+				for i := range peering.Status.Conditions {
+					Expect(peering.Status.Conditions[i].Status).To(Equal(liqov1beta1.ConditionStatusError))
+				}
+			})
+		})
+	})
+
+	Describe("the Reconcile function, when the Peering is being deleted", func() {
+		BeforeEach(func() {
+			peering.Finalizers = []string{peeringControllerFinalizer}
+			peering.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		})
+
+		When("the provider cluster is unreachable", func() {
+			BeforeEach(func() {
+				forgeReconcile()
+				_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(peering)})
+			})
+
+			It("should return an error", func() { Expect(err).To(HaveOccurred()) })
+			It("should preserve the finalizer, and mark the Peering as terminating", func() {
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(peering), peering)).To(Succeed())
+				Expect(peering.Finalizers).To(ContainElement(peeringControllerFinalizer))
+				Expect(peering.Status.Phase).To(Equal(liqov1beta1.PeeringPhaseTerminating))
+			})
+		})
+	})
+
+	Describe("the runUnpeerSteps function", func() {
+		BeforeEach(func() {
+			for _, condition := range []liqov1beta1.PeeringConditionType{liqov1beta1.PeeringNetworkInitializedCondition,
+				liqov1beta1.PeeringNetworkConnectedCondition, liqov1beta1.PeeringAuthenticatedCondition,
+				liqov1beta1.PeeringResourceSliceAcceptedCondition, liqov1beta1.PeeringVirtualNodeReadyCondition} {
+				ensureCondition(peering, condition, liqov1beta1.ConditionStatusEstablished, stepEstablishedReason, "")
+			}
+		})
+
+		JustBeforeEach(func() {
+			forgeReconcile()
+			completed, err = reconciler.runUnpeerSteps(ctx, peering, local, remote)
+		})
+
+		When("all the resources have already been removed", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should be completed", func() { Expect(completed).To(BeTrue()) })
+			It("should remove all the conditions", func() { Expect(peering.Status.Conditions).To(BeEmpty()) })
+		})
+
+		When("the offloading resources still exist", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeVirtualNode(tenantNamespace, remoteClusterID))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not be completed", func() { Expect(completed).To(BeFalse()) })
+			It("should delete the VirtualNode", func() {
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: tenantNamespace, Name: string(remoteClusterID)},
+					&offloadingv1beta1.VirtualNode{})).To(testutil.BeNotFound())
+			})
+			It("should mark the offloading conditions as tearing down, and preserve the others", func() {
+				Expect(GetCondition(peering, liqov1beta1.PeeringVirtualNodeReadyCondition)).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Status": Equal(liqov1beta1.ConditionStatusPending), "Reason": Equal(stepTearingDownReason)})))
+				Expect(GetCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)).To(PointTo(
+					HaveField("Status", liqov1beta1.ConditionStatusEstablished)))
+			})
+		})
+
+		When("the gateways still exist", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeGatewayClient(tenantNamespace, remoteClusterID))
+				remoteExisting = append(remoteExisting, forgeGatewayServer("liqo-tenant-local", localClusterID))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not be completed", func() { Expect(completed).To(BeFalse()) })
+			It("should delete the gateways in both clusters", func() {
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: tenantNamespace, Name: "gateway"},
+					&networkingv1beta1.GatewayClient{})).To(testutil.BeNotFound())
+				Expect(remote.Get(ctx, client.ObjectKey{Namespace: "liqo-tenant-local", Name: "gateway"},
+					&networkingv1beta1.GatewayServer{})).To(testutil.BeNotFound())
+			})
+			It("should remove the conditions of the steps reverted", func() {
+				Expect(GetCondition(peering, liqov1beta1.PeeringVirtualNodeReadyCondition)).To(BeNil())
+				Expect(GetCondition(peering, liqov1beta1.PeeringResourceSliceAcceptedCondition)).To(BeNil())
+				Expect(GetCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)).To(BeNil())
+				Expect(GetCondition(peering, liqov1beta1.PeeringNetworkConnectedCondition)).To(PointTo(
+					HaveField("Message", gatewaysTearingDownMessage)))
+			})
+		})
+
+		When("the peering is bidirectional", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeGatewayClient(tenantNamespace, remoteClusterID), &liqov1beta1.ForeignCluster{
+					ObjectMeta: metav1.ObjectMeta{Name: string(remoteClusterID), Labels: map[string]string{consts.RemoteClusterID: string(remoteClusterID)}},
+					Spec:       liqov1beta1.ForeignClusterSpec{ClusterID: remoteClusterID},
+					Status:     liqov1beta1.ForeignClusterStatus{Role: liqov1beta1.ConsumerAndProviderRole},
+				})
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should be completed", func() { Expect(completed).To(BeTrue()) })
+			It("should preserve the networking", func() {
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: tenantNamespace, Name: "gateway"}, &networkingv1beta1.GatewayClient{})).To(Succeed())
+			})
+			It("should remove all the conditions", func() { Expect(peering.Status.Conditions).To(BeEmpty()) })
+		})
+	})
+
+	Describe("the ensureResourceSlice function", func() {
+		var resourceSlice authv1beta1.ResourceSlice
+
+		JustBeforeEach(func() {
+			forgeReconcile()
+			completed, message, err = reconciler.ensureResourceSlice(ctx, peering, local, remote)
+		})
+
+		When("the ResourceSlice has not been processed yet", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not be completed", func() {
+				Expect(completed).To(BeFalse())
+				Expect(message).To(Equal(resourceSlicePendingMessage))
+			})
+			It("should create the ResourceSlice", func() {
+				key := client.ObjectKey{Namespace: tenantNamespace, Name: string(remoteClusterID)}
+				Expect(cl.Get(ctx, key, &resourceSlice)).To(Succeed())
+				Expect(resourceSlice.Spec.Class).To(BeEquivalentTo("default"))
+				Expect(resourceSlice.Spec.Resources).To(HaveKeyWithValue(corev1.ResourceCPU, k8sresource.MustParse("2")))
+				Expect(resourceSlice.Annotations).To(HaveKeyWithValue(consts.CreateVirtualNodeAnnotation, "true"))
+			})
+		})
+
+		When("the ResourceSlice has been accepted", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeResourceSlice(tenantNamespace, remoteClusterID, authv1beta1.ResourceSliceConditionAccepted))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should be completed", func() { Expect(completed).To(BeTrue()) })
+		})
+
+		When("the ResourceSlice has been denied", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeResourceSlice(tenantNamespace, remoteClusterID, authv1beta1.ResourceSliceConditionDenied))
+			})
+
+			It("should return an error", func() { Expect(err).To(HaveOccurred()) })
+			It("should not be completed", func() { Expect(completed).To(BeFalse()) })
+		})
+	})
+
+	Describe("the checkVirtualNode function", func() {
+		JustBeforeEach(func() {
+			forgeReconcile()
+			completed, message, err = reconciler.checkVirtualNode(ctx, peering, local, remote)
+		})
+
+		When("the VirtualNode does not exist", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not be completed", func() {
+				Expect(completed).To(BeFalse())
+				Expect(message).To(Equal(virtualNodePendingMessage))
+			})
+		})
+
+		When("the Node is not ready", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeVirtualNode(tenantNamespace, remoteClusterID), forgeNode(remoteClusterID, corev1.ConditionFalse))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not be completed", func() {
+				Expect(completed).To(BeFalse())
+				Expect(message).To(Equal(virtualNodeNotReadyMessage))
+			})
+		})
+
+		When("the Node is ready", func() {
+			BeforeEach(func() {
+				existing = append(existing, forgeVirtualNode(tenantNamespace, remoteClusterID), forgeNode(remoteClusterID, corev1.ConditionTrue))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should be completed", func() { Expect(completed).To(BeTrue()) })
+		})
+	})
+
+	Describe("the condition functions", func() {
+		It("should add, update and remove the conditions", func() {
+			ensureCondition(peering, liqov1beta1.PeeringAuthenticatedCondition, liqov1beta1.ConditionStatusPending, stepPendingReason, "foo")
+			Expect(GetCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)).To(PointTo(
+				HaveField("Status", liqov1beta1.ConditionStatusPending)))
+
+			ensureCondition(peering, liqov1beta1.PeeringAuthenticatedCondition, liqov1beta1.ConditionStatusEstablished, stepEstablishedReason, "bar")
+			Expect(peering.Status.Conditions).To(HaveLen(1))
+			Expect(GetCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)).To(PointTo(
+				HaveField("Status", liqov1beta1.ConditionStatusEstablished)))
+
+			deleteCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)
+			Expect(GetCondition(peering, liqov1beta1.PeeringAuthenticatedCondition)).To(BeNil())
+		})
+	})
+})
+
+func forgeResourceSlice(namespace string, clusterID liqov1beta1.ClusterID,
+	status authv1beta1.ResourceSliceConditionStatus) *authv1beta1.ResourceSlice {
+	return &authv1beta1.ResourceSlice{
+		ObjectMeta: metav1.ObjectMeta{Name: string(clusterID), Namespace: namespace},
+		Status: authv1beta1.ResourceSliceStatus{
+			Conditions: []authv1beta1.ResourceSliceCondition{
+				{Type: authv1beta1.ResourceSliceConditionTypeAuthentication, Status: authv1beta1.ResourceSliceConditionAccepted},
+				{Type: authv1beta1.ResourceSliceConditionTypeResources, Status: status},
+			},
+		},
+	}
+}
+
+func forgeVirtualNode(namespace string, clusterID liqov1beta1.ClusterID) *offloadingv1beta1.VirtualNode {
+	return &offloadingv1beta1.VirtualNode{
+		ObjectMeta: metav1.ObjectMeta{Name: string(clusterID), Namespace: namespace,
+			Labels: map[string]string{consts.RemoteClusterID: string(clusterID)}},
+		Spec: offloadingv1beta1.VirtualNodeSpec{ClusterID: clusterID},
+	}
+}
+
+func forgeGatewayClient(namespace string, clusterID liqov1beta1.ClusterID) *networkingv1beta1.GatewayClient {
+	return &networkingv1beta1.GatewayClient{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: namespace,
+		Labels: map[string]string{consts.RemoteClusterID: string(clusterID)}}}
+}
+
+func forgeGatewayServer(namespace string, clusterID liqov1beta1.ClusterID) *networkingv1beta1.GatewayServer {
+	return &networkingv1beta1.GatewayServer{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: namespace,
+		Labels: map[string]string{consts.RemoteClusterID: string(clusterID)}}}
+}
+
+func forgeNode(clusterID liqov1beta1.ClusterID, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: string(clusterID), Labels: map[string]string{consts.RemoteClusterID: string(clusterID)}},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+	}
+}
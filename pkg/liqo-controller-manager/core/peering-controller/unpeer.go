@@ -0,0 +1,243 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peeringcontroller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	fcutils "github.com/liqotech/liqo/pkg/utils/foreigncluster"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// unpeerStep is one of the operations performed to tear down a Peering, reverting the steps which established it.
+// It returns whether the step has been completed, a message describing its state, and whether an error occurred.
+type unpeerStep struct {
+	conditions []liqov1beta1.PeeringConditionType
+	enabled    bool
+	run        func(ctx context.Context, local, remote *cluster) (bool, string, error)
+}
+
+// unpeerSteps returns the ordered list of steps to tear down the given Peering (as "liqoctl unpeer").
+// In case of bidirectional peerings, the networking and the tenant namespaces are shared, hence preserved.
+func (r *PeeringReconciler) unpeerSteps(peering *liqov1beta1.Peering, bidirectional bool) []unpeerStep {
+	return []unpeerStep{
+		{conditions: []liqov1beta1.PeeringConditionType{liqov1beta1.PeeringVirtualNodeReadyCondition,
+			liqov1beta1.PeeringResourceSliceAcceptedCondition}, enabled: true, run: r.ensureOffloadingAbsence},
+		{conditions: []liqov1beta1.PeeringConditionType{liqov1beta1.PeeringAuthenticatedCondition}, enabled: true,
+			run: r.ensureAuthenticationAbsence},
+		{conditions: []liqov1beta1.PeeringConditionType{liqov1beta1.PeeringNetworkConnectedCondition,
+			liqov1beta1.PeeringNetworkInitializedCondition}, enabled: peering.Spec.Networking.Enabled && !bidirectional,
+			run: r.ensureNetworkAbsence},
+		{enabled: !bidirectional, run: r.ensureTenantNamespacesAbsence},
+	}
+}
+
+// runUnpeerSteps executes the steps to tear down the Peering in order, stopping at the first one not yet completed,
+// and removes the conditions of the steps reverted. It returns whether all the steps have been completed.
+func (r *PeeringReconciler) runUnpeerSteps(ctx context.Context, peering *liqov1beta1.Peering, local, remote *cluster) (bool, error) {
+	bidirectional, err := isBidirectionalPeering(ctx, local, remote)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range r.unpeerSteps(peering, bidirectional) {
+		if !s.enabled {
+			for _, condition := range s.conditions {
+				deleteCondition(peering, condition)
+			}
+			continue
+		}
+
+		completed, message, err := s.run(ctx, local, remote)
+		for _, condition := range s.conditions {
+			switch {
+			case err != nil:
+				ensureCondition(peering, condition, liqov1beta1.ConditionStatusError, stepErrorReason, err.Error())
+			case !completed:
+				ensureCondition(peering, condition, liqov1beta1.ConditionStatusPending, stepTearingDownReason, message)
+			default:
+				deleteCondition(peering, condition)
+			}
+		}
+
+		if err != nil {
+			klog.Errorf("Unable to tear down Peering %q: %v", client.ObjectKeyFromObject(peering), err)
+			r.eventRecorder.Event(peering, corev1.EventTypeWarning, "UnpeeringFailed", err.Error())
+			return false, err
+		}
+		if !completed {
+			klog.V(4).Infof("Tear down of Peering %q pending: %s", client.ObjectKeyFromObject(peering), message)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// isBidirectionalPeering returns whether the two clusters are peered in both directions (as checked by "liqoctl unpeer").
+func isBidirectionalPeering(ctx context.Context, local, remote *cluster) (bool, error) {
+	for _, c := range []struct {
+		cluster         *cluster
+		remoteClusterID liqov1beta1.ClusterID
+	}{{local, remote.clusterID}, {remote, local.clusterID}} {
+		fc, err := fcutils.GetForeignClusterByID(ctx, c.cluster.Client, c.remoteClusterID)
+		switch {
+		case apierrors.IsNotFound(err):
+			continue
+		case err != nil:
+			return false, fmt.Errorf("unable to retrieve the ForeignCluster: %w", err)
+		case fc.Status.Role == liqov1beta1.ConsumerAndProviderRole:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ensureOffloadingAbsence deletes the ResourceSlices and the VirtualNodes targeting the provider cluster,
+// and checks whether they have been removed.
+func (r *PeeringReconciler) ensureOffloadingAbsence(ctx context.Context, local, remote *cluster) (bool, string, error) {
+	resourceSlices, err := getters.ListResourceSlicesByLabel(ctx, local.Client, corev1.NamespaceAll, labels.SelectorFromSet(labels.Set{
+		consts.ReplicationRequestedLabel:   consts.ReplicationRequestedLabelValue,
+		consts.ReplicationDestinationLabel: string(remote.clusterID),
+	}))
+	if err != nil {
+		return false, "", fmt.Errorf("unable to list the ResourceSlices: %w", err)
+	}
+	for i := range resourceSlices {
+		if err := client.IgnoreNotFound(local.Delete(ctx, &resourceSlices[i])); err != nil {
+			return false, "", fmt.Errorf("unable to delete the ResourceSlice %q: %w", client.ObjectKeyFromObject(&resourceSlices[i]), err)
+		}
+	}
+
+	virtualNodes, err := getters.ListVirtualNodesByClusterID(ctx, local.Client, remote.clusterID)
+	if err != nil {
+		return false, "", fmt.Errorf("unable to list the VirtualNodes: %w", err)
+	}
+	for i := range virtualNodes {
+		if err := client.IgnoreNotFound(local.Delete(ctx, &virtualNodes[i])); err != nil {
+			return false, "", fmt.Errorf("unable to delete the VirtualNode %q: %w", client.ObjectKeyFromObject(&virtualNodes[i]), err)
+		}
+	}
+
+	if len(resourceSlices) > 0 || len(virtualNodes) > 0 {
+		return false, offloadingTearingDownMessage, nil
+	}
+	return true, "", nil
+}
+
+// ensureAuthenticationAbsence deletes the control plane Identity in the consumer cluster and the Tenant in the provider one.
+func (r *PeeringReconciler) ensureAuthenticationAbsence(ctx context.Context, local, remote *cluster) (bool, string, error) {
+	identity, err := getters.GetControlPlaneIdentityByClusterID(ctx, local.Client, remote.clusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return false, "", fmt.Errorf("unable to retrieve the Identity: %w", err)
+	} else if err == nil {
+		if err := client.IgnoreNotFound(local.Delete(ctx, identity)); err != nil {
+			return false, "", fmt.Errorf("unable to delete the Identity: %w", err)
+		}
+	}
+
+	tenant, err := getters.GetTenantByClusterID(ctx, remote.Client, local.clusterID)
+	if client.IgnoreNotFound(err) != nil {
+		return false, "", fmt.Errorf("unable to retrieve the Tenant: %w", err)
+	} else if err == nil {
+		if err := client.IgnoreNotFound(remote.Delete(ctx, tenant)); err != nil {
+			return false, "", fmt.Errorf("unable to delete the Tenant: %w", err)
+		}
+	}
+
+	return true, "", nil
+}
+
+// ensureNetworkAbsence deletes the gateways in both clusters and, once they have been removed,
+// the network Configurations (as "liqoctl network reset").
+func (r *PeeringReconciler) ensureNetworkAbsence(ctx context.Context, local, remote *cluster) (bool, string, error) {
+	pending := false
+	for _, c := range []struct {
+		cluster         *cluster
+		remoteClusterID liqov1beta1.ClusterID
+	}{{local, remote.clusterID}, {remote, local.clusterID}} {
+		gwServer, err := getters.GetGatewayServerByClusterID(ctx, c.cluster.Client, c.remoteClusterID)
+		if client.IgnoreNotFound(err) != nil {
+			return false, "", fmt.Errorf("unable to retrieve the gateway server: %w", err)
+		} else if err == nil {
+			if err := client.IgnoreNotFound(c.cluster.Delete(ctx, gwServer)); err != nil {
+				return false, "", fmt.Errorf("unable to delete the gateway server: %w", err)
+			}
+			pending = true
+		}
+
+		gwClient, err := getters.GetGatewayClientByClusterID(ctx, c.cluster.Client, c.remoteClusterID)
+		if client.IgnoreNotFound(err) != nil {
+			return false, "", fmt.Errorf("unable to retrieve the gateway client: %w", err)
+		} else if err == nil {
+			if err := client.IgnoreNotFound(c.cluster.Delete(ctx, gwClient)); err != nil {
+				return false, "", fmt.Errorf("unable to delete the gateway client: %w", err)
+			}
+			pending = true
+		}
+	}
+	if pending {
+		return false, gatewaysTearingDownMessage, nil
+	}
+
+	for _, c := range []struct {
+		cluster         *cluster
+		remoteClusterID liqov1beta1.ClusterID
+	}{{local, remote.clusterID}, {remote, local.clusterID}} {
+		conf, err := getters.GetConfigurationByClusterID(ctx, c.cluster.Client, c.remoteClusterID)
+		if client.IgnoreNotFound(err) != nil {
+			return false, "", fmt.Errorf("unable to retrieve the network Configuration: %w", err)
+		} else if err == nil {
+			if err := client.IgnoreNotFound(c.cluster.Delete(ctx, conf)); err != nil {
+				return false, "", fmt.Errorf("unable to delete the network Configuration: %w", err)
+			}
+		}
+	}
+
+	return true, "", nil
+}
+
+// ensureTenantNamespacesAbsence deletes the tenant namespaces in both clusters, and checks whether they have been removed.
+func (r *PeeringReconciler) ensureTenantNamespacesAbsence(ctx context.Context, local, remote *cluster) (bool, string, error) {
+	pending := false
+	for _, c := range []struct {
+		cluster         *cluster
+		remoteClusterID liqov1beta1.ClusterID
+	}{{local, remote.clusterID}, {remote, local.clusterID}} {
+		namespace, err := c.cluster.namespaceManager.GetNamespace(ctx, c.remoteClusterID)
+		if client.IgnoreNotFound(err) != nil {
+			return false, "", fmt.Errorf("unable to retrieve the tenant namespace: %w", err)
+		} else if err == nil {
+			if err := client.IgnoreNotFound(c.cluster.Delete(ctx, namespace)); err != nil {
+				return false, "", fmt.Errorf("unable to delete the tenant namespace: %w", err)
+			}
+			pending = true
+		}
+	}
+
+	if pending {
+		return false, tenantNamespacesTearingDownMessage, nil
+	}
+	return true, "", nil
+}
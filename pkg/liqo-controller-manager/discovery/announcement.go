@@ -0,0 +1,112 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+// ErrInvalidSignature is returned when the signature of an announcement does not match its content.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// AnnouncementOptions contains the information to be published in the announcement of the local cluster.
+type AnnouncementOptions struct {
+	ClusterID       liqov1beta1.ClusterID
+	APIServerURL    string
+	ResourceClasses []string
+	Labels          map[string]string
+	Networking      liqov1beta1.AnnouncedNetworking
+}
+
+// ForgeAnnouncement forges the announcement of the local cluster, valid for the given duration.
+func ForgeAnnouncement(opts *AnnouncementOptions, publicKey []byte, validity time.Duration) *liqov1beta1.ClusterAnnouncement {
+	return &liqov1beta1.ClusterAnnouncement{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: liqov1beta1.GroupVersion.String(),
+			Kind:       liqov1beta1.ClusterAnnouncementKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: string(opts.ClusterID),
+		},
+		Spec: liqov1beta1.ClusterAnnouncementSpec{
+			ClusterID:       opts.ClusterID,
+			APIServerURL:    opts.APIServerURL,
+			ResourceClasses: opts.ResourceClasses,
+			Labels:          opts.Labels,
+			Networking:      opts.Networking,
+			PublicKey:       publicKey,
+			ExpirationTime:  metav1.NewTime(time.Now().Add(validity).Truncate(time.Second)),
+		},
+	}
+}
+
+// Sign signs the spec of the given announcement with the private key of the announcing cluster.
+func Sign(announcement *liqov1beta1.ClusterAnnouncement, privateKey ed25519.PrivateKey) error {
+	data, err := json.Marshal(&announcement.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the announcement: %w", err)
+	}
+	announcement.Signature = ed25519.Sign(privateKey, data)
+	return nil
+}
+
+// Verify checks that the given announcement has been signed by the owner of the announced public key.
+func Verify(announcement *liqov1beta1.ClusterAnnouncement) error {
+	publicKey, err := ParsePublicKey(announcement.Spec.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&announcement.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the announcement: %w", err)
+	}
+	if !ed25519.Verify(publicKey, data, announcement.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// IsExpired returns whether the given announcement is expired at the given time.
+func IsExpired(announcement *liqov1beta1.ClusterAnnouncement, now time.Time) bool {
+	return announcement.Spec.ExpirationTime.Time.Before(now)
+}
+
+// ParsePublicKey parses an Ed25519 public key in PEM format.
+func ParsePublicKey(publicKey []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key in PEM format")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not of type Ed25519")
+	}
+	return edKey, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Announcements", func() {
+	It("should verify a correctly signed announcement", func() {
+		Expect(Verify(forgeSignedAnnouncement("cluster-a", nil))).To(Succeed())
+	})
+
+	It("should reject a tampered announcement", func() {
+		announcement := forgeSignedAnnouncement("cluster-a", nil)
+		announcement.Spec.APIServerURL = "https://malicious.example.com:6443"
+		Expect(Verify(announcement)).To(MatchError(ErrInvalidSignature))
+	})
+
+	It("should reject an announcement signed with a different key", func() {
+		announcement := forgeSignedAnnouncement("cluster-a", nil)
+		announcement.Spec.PublicKey = forgeSignedAnnouncement("cluster-b", nil).Spec.PublicKey
+		Expect(Verify(announcement)).To(MatchError(ErrInvalidSignature))
+	})
+
+	It("should reject an announcement with an invalid public key", func() {
+		announcement := forgeSignedAnnouncement("cluster-a", nil)
+		announcement.Spec.PublicKey = []byte("invalid")
+		Expect(Verify(announcement)).ToNot(Succeed())
+	})
+
+	It("should detect expired announcements", func() {
+		announcement := forgeSignedAnnouncement("cluster-a", nil)
+		Expect(IsExpired(announcement, time.Now())).To(BeFalse())
+		Expect(IsExpired(announcement, time.Now().Add(2*time.Hour))).To(BeTrue())
+	})
+})
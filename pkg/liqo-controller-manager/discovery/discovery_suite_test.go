@@ -0,0 +1,63 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication"
+)
+
+var ctx context.Context
+
+func TestDiscovery(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Discovery Suite")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = context.Background()
+	utilruntime.Must(liqov1beta1.AddToScheme(scheme.Scheme))
+})
+
+// forgeSignedAnnouncement returns a valid announcement of the given cluster, signed with a newly generated key.
+func forgeSignedAnnouncement(clusterID liqov1beta1.ClusterID, labels map[string]string) *liqov1beta1.ClusterAnnouncement {
+	privateKeyPEM, publicKeyPEM, err := authentication.GenerateEd25519Keys()
+	Expect(err).ToNot(HaveOccurred())
+
+	block, _ := pem.Decode(privateKeyPEM)
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	Expect(err).ToNot(HaveOccurred())
+
+	announcement := ForgeAnnouncement(&AnnouncementOptions{
+		ClusterID:       clusterID,
+		APIServerURL:    "https://" + string(clusterID) + ".example.com:6443",
+		ResourceClasses: []string{"default"},
+		Labels:          labels,
+	}, publicKeyPEM, time.Hour)
+	Expect(Sign(announcement, privateKey.(ed25519.PrivateKey))).To(Succeed())
+	return announcement
+}
@@ -0,0 +1,18 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package discovery implements the federated discovery of the candidate peers through a shared cluster registry.
+// Each cluster can publish a signed ClusterAnnouncement to the registry, while the other ones periodically
+// retrieve the valid announcements and mirror them locally, to be inspected before establishing a peering.
+package discovery
@@ -0,0 +1,151 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+// AnnouncementsPath is the path, relative to the registry URL, exposing the announcements.
+const AnnouncementsPath = "/announcements"
+
+var _ Registry = &HTTPRegistry{}
+
+// HTTPRegistry is a Registry accessed through a simple HTTP API:
+//   - GET {url}/announcements returns the ClusterAnnouncementList of the published announcements;
+//   - PUT {url}/announcements/{clusterID} creates or replaces the ClusterAnnouncement of the given cluster.
+type HTTPRegistry struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPRegistry returns a new HTTPRegistry for the given URL.
+func NewHTTPRegistry(registryURL string) *HTTPRegistry {
+	return &HTTPRegistry{URL: strings.TrimSuffix(registryURL, "/"), Client: http.DefaultClient}
+}
+
+// Publish uploads the announcement of the given cluster to the registry.
+func (hr *HTTPRegistry) Publish(ctx context.Context, announcement *liqov1beta1.ClusterAnnouncement) error {
+	data, err := json.Marshal(announcement)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the announcement: %w", err)
+	}
+
+	endpoint := hr.URL + AnnouncementsPath + "/" + url.PathEscape(string(announcement.Spec.ClusterID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := hr.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("registry returned unexpected status %q", resp.Status)
+	}
+	return nil
+}
+
+// List retrieves the announcements published in the registry.
+func (hr *HTTPRegistry) List(ctx context.Context) ([]liqov1beta1.ClusterAnnouncement, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hr.URL+AnnouncementsPath, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := hr.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned unexpected status %q", resp.Status)
+	}
+
+	var announcements liqov1beta1.ClusterAnnouncementList
+	if err := json.NewDecoder(resp.Body).Decode(&announcements); err != nil {
+		return nil, fmt.Errorf("failed to decode the announcements: %w", err)
+	}
+	return announcements.Items, nil
+}
+
+// NewHTTPHandler returns an http.Handler serving the HTTP registry API on top of the given registry.
+// Coupled with a MemoryRegistry, it can be used to run a lightweight registry (e.g., for local testing).
+func NewHTTPHandler(registry Registry) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+AnnouncementsPath, func(w http.ResponseWriter, r *http.Request) {
+		announcements, err := registry.List(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		list := liqov1beta1.ClusterAnnouncementList{Items: announcements}
+		list.APIVersion = liqov1beta1.GroupVersion.String()
+		list.Kind = "ClusterAnnouncementList"
+		if err := json.NewEncoder(w).Encode(&list); err != nil {
+			klog.Warningf("Failed to encode the announcements: %v", err)
+		}
+	})
+
+	mux.HandleFunc("PUT "+AnnouncementsPath+"/{clusterID}", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var announcement liqov1beta1.ClusterAnnouncement
+		if err := json.Unmarshal(data, &announcement); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if string(announcement.Spec.ClusterID) != r.PathValue("clusterID") {
+			http.Error(w, "cluster ID mismatch", http.StatusBadRequest)
+			return
+		}
+		// Reject the announcements not signed by the announcing cluster, to prevent tampering.
+		if err := Verify(&announcement); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if err := registry.Publish(r.Context(), &announcement); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
@@ -0,0 +1,61 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+var _ = Describe("HTTP registry", func() {
+	var (
+		server   *httptest.Server
+		registry *HTTPRegistry
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(NewHTTPHandler(NewMemoryRegistry()))
+		registry = NewHTTPRegistry(server.URL + "/")
+	})
+
+	AfterEach(func() { server.Close() })
+
+	It("should publish and list the announcements", func() {
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("cluster-b", nil))).To(Succeed())
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("cluster-a", nil))).To(Succeed())
+		// Publishing again replaces the previous announcement.
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("cluster-a", nil))).To(Succeed())
+
+		announcements, err := registry.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(announcements).To(HaveLen(2))
+		Expect(announcements[0].Spec.ClusterID).To(Equal(liqov1beta1.ClusterID("cluster-a")))
+		Expect(Verify(&announcements[0])).To(Succeed())
+	})
+
+	It("should reject the announcements with an invalid signature", func() {
+		announcement := forgeSignedAnnouncement("cluster-a", nil)
+		announcement.Spec.Labels = map[string]string{"tampered": "true"}
+		Expect(registry.Publish(ctx, announcement)).ToNot(Succeed())
+
+		announcements, err := registry.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(announcements).To(BeEmpty())
+	})
+})
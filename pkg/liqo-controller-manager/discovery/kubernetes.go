@@ -0,0 +1,62 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+var _ Registry = &KubernetesRegistry{}
+
+// KubernetesRegistry is a Registry storing the announcements as ClusterAnnouncement resources
+// in a given namespace of a (hub) Kubernetes cluster.
+type KubernetesRegistry struct {
+	Client    client.Client
+	Namespace string
+}
+
+// NewKubernetesRegistry returns a new KubernetesRegistry backed by the given namespace.
+func NewKubernetesRegistry(cl client.Client, namespace string) *KubernetesRegistry {
+	return &KubernetesRegistry{Client: cl, Namespace: namespace}
+}
+
+// Publish creates or updates the ClusterAnnouncement of the given cluster.
+func (kr *KubernetesRegistry) Publish(ctx context.Context, announcement *liqov1beta1.ClusterAnnouncement) error {
+	published := &liqov1beta1.ClusterAnnouncement{
+		ObjectMeta: metav1.ObjectMeta{Name: string(announcement.Spec.ClusterID), Namespace: kr.Namespace},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, kr.Client, published, func() error {
+		published.Spec = announcement.Spec
+		published.Signature = announcement.Signature
+		return nil
+	})
+	return err
+}
+
+// List returns the ClusterAnnouncements stored in the registry namespace.
+func (kr *KubernetesRegistry) List(ctx context.Context) ([]liqov1beta1.ClusterAnnouncement, error) {
+	var announcements liqov1beta1.ClusterAnnouncementList
+	if err := kr.Client.List(ctx, &announcements, client.InNamespace(kr.Namespace)); err != nil {
+		return nil, err
+	}
+	return announcements.Items, nil
+}
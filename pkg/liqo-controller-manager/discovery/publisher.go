@@ -0,0 +1,83 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/authentication"
+	ipamutils "github.com/liqotech/liqo/pkg/utils/ipam"
+)
+
+var _ manager.Runnable = &Publisher{}
+
+// Publisher periodically publishes the signed announcement of the local cluster to the registry.
+type Publisher struct {
+	Client        client.Client
+	Registry      Registry
+	LiqoNamespace string
+	Announcement  AnnouncementOptions
+	Interval      time.Duration
+}
+
+// Start starts publishing the announcement of the local cluster, until the context is canceled.
+func (p *Publisher) Start(ctx context.Context) error {
+	klog.Infof("Starting the publication of the announcement of cluster %q (interval: %v)", p.Announcement.ClusterID, p.Interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.publish(ctx); err != nil {
+			klog.Errorf("Failed to publish the cluster announcement: %v", err)
+		}
+	}, p.Interval)
+	return nil
+}
+
+func (p *Publisher) publish(ctx context.Context) error {
+	privateKey, _, err := authentication.GetClusterKeys(ctx, p.Client, p.LiqoNamespace)
+	if err != nil {
+		return err
+	}
+	_, publicKey, err := authentication.GetClusterKeysPEM(ctx, p.Client, p.LiqoNamespace)
+	if err != nil {
+		return err
+	}
+
+	opts := p.Announcement
+	// The network parameters are not available if the networking module is disabled.
+	if podCIDR, err := ipamutils.GetPodCIDR(ctx, p.Client); err == nil {
+		opts.Networking.PodCIDR = podCIDR
+	}
+	if externalCIDR, err := ipamutils.GetExternalCIDR(ctx, p.Client); err == nil {
+		opts.Networking.ExternalCIDR = externalCIDR
+	}
+
+	// The announcement remains valid for a few publication intervals, to tolerate transient failures.
+	announcement := ForgeAnnouncement(&opts, publicKey, 3*p.Interval)
+	if err := Sign(announcement, privateKey); err != nil {
+		return err
+	}
+
+	if err := p.Registry.Publish(ctx, announcement); err != nil {
+		return fmt.Errorf("failed to publish the announcement to the registry: %w", err)
+	}
+	klog.V(4).Infof("Announcement of cluster %q published (expiration: %v)", opts.ClusterID, announcement.Spec.ExpirationTime)
+	return nil
+}
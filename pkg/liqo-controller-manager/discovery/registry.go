@@ -0,0 +1,68 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+)
+
+// Registry is the interface implemented by the shared registries where the cluster announcements are published.
+type Registry interface {
+	// Publish creates or replaces the announcement of the given cluster.
+	Publish(ctx context.Context, announcement *liqov1beta1.ClusterAnnouncement) error
+	// List returns all the announcements currently published in the registry.
+	List(ctx context.Context) ([]liqov1beta1.ClusterAnnouncement, error)
+}
+
+var _ Registry = &MemoryRegistry{}
+
+// MemoryRegistry is an in-memory Registry, mainly intended to back local stubs of the HTTP registry.
+type MemoryRegistry struct {
+	mutex         sync.RWMutex
+	announcements map[liqov1beta1.ClusterID]*liqov1beta1.ClusterAnnouncement
+}
+
+// NewMemoryRegistry returns a new empty MemoryRegistry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{announcements: map[liqov1beta1.ClusterID]*liqov1beta1.ClusterAnnouncement{}}
+}
+
+// Publish stores the announcement of the given cluster.
+func (mr *MemoryRegistry) Publish(_ context.Context, announcement *liqov1beta1.ClusterAnnouncement) error {
+	mr.mutex.Lock()
+	defer mr.mutex.Unlock()
+	mr.announcements[announcement.Spec.ClusterID] = announcement.DeepCopy()
+	return nil
+}
+
+// List returns the stored announcements, sorted by cluster ID.
+func (mr *MemoryRegistry) List(_ context.Context) ([]liqov1beta1.ClusterAnnouncement, error) {
+	mr.mutex.RLock()
+	defer mr.mutex.RUnlock()
+
+	announcements := make([]liqov1beta1.ClusterAnnouncement, 0, len(mr.announcements))
+	for _, announcement := range mr.announcements {
+		announcements = append(announcements, *announcement.DeepCopy())
+	}
+	slices.SortFunc(announcements, func(a, b liqov1beta1.ClusterAnnouncement) int {
+		return strings.Compare(string(a.Spec.ClusterID), string(b.Spec.ClusterID))
+	})
+	return announcements, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// cluster-role
+// +kubebuilder:rbac:groups=core.liqo.io,resources=clusterannouncements,verbs=get;list;watch;create;update;patch;delete
+
+var _ manager.Runnable = &Syncer{}
+
+// Syncer periodically retrieves the announcements published in the registry, and mirrors
+// the valid ones in the local cluster, where they are picked up by the ForeignCluster controller.
+type Syncer struct {
+	Client         client.Client
+	Registry       Registry
+	LocalClusterID liqov1beta1.ClusterID
+	Namespace      string
+	Interval       time.Duration
+}
+
+// Start starts the synchronization of the announcements, until the context is canceled.
+func (s *Syncer) Start(ctx context.Context) error {
+	klog.Infof("Starting the synchronization of the cluster announcements (interval: %v)", s.Interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.sync(ctx, time.Now()); err != nil {
+			klog.Errorf("Failed to synchronize the cluster announcements: %v", err)
+		}
+	}, s.Interval)
+	return nil
+}
+
+func (s *Syncer) sync(ctx context.Context, now time.Time) error {
+	announcements, err := s.Registry.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the announcements from the registry: %w", err)
+	}
+
+	var mirrors liqov1beta1.ClusterAnnouncementList
+	if err := s.Client.List(ctx, &mirrors, client.InNamespace(s.Namespace), client.MatchingLabels{consts.DiscoveredLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list the mirrored announcements: %w", err)
+	}
+	existing := make(map[liqov1beta1.ClusterID]*liqov1beta1.ClusterAnnouncement, len(mirrors.Items))
+	for i := range mirrors.Items {
+		existing[mirrors.Items[i].Spec.ClusterID] = &mirrors.Items[i]
+	}
+
+	valid := map[liqov1beta1.ClusterID]bool{}
+	for i := range announcements {
+		announcement := &announcements[i]
+		clusterID := announcement.Spec.ClusterID
+		switch {
+		case clusterID == s.LocalClusterID:
+			continue
+		case IsExpired(announcement, now):
+			klog.V(4).Infof("Skipping expired announcement of cluster %q", clusterID)
+			continue
+		}
+
+		if err := Verify(announcement); err != nil {
+			klog.Warningf("Skipping announcement of cluster %q: %v", clusterID, err)
+			continue
+		}
+		// Trust on first use: reject the announcements of an already known cluster signed with a different key.
+		if mirror, found := existing[clusterID]; found && !bytes.Equal(mirror.Spec.PublicKey, announcement.Spec.PublicKey) {
+			klog.Warningf("Skipping announcement of cluster %q: public key does not match the previously discovered one", clusterID)
+			valid[clusterID] = true
+			continue
+		}
+
+		valid[clusterID] = true
+		if err := s.mirror(ctx, announcement); err != nil {
+			klog.Errorf("Failed to mirror the announcement of cluster %q: %v", clusterID, err)
+		}
+	}
+
+	for clusterID, mirror := range existing {
+		if valid[clusterID] {
+			continue
+		}
+		if err := s.Client.Delete(ctx, mirror); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete the announcement of cluster %q: %w", clusterID, err)
+		}
+		klog.Infof("Announcement of cluster %q no longer available, removed", clusterID)
+	}
+	return nil
+}
+
+func (s *Syncer) mirror(ctx context.Context, announcement *liqov1beta1.ClusterAnnouncement) error {
+	mirror := &liqov1beta1.ClusterAnnouncement{
+		ObjectMeta: metav1.ObjectMeta{Name: string(announcement.Spec.ClusterID), Namespace: s.Namespace},
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, s.Client, mirror, func() error {
+		if mirror.Labels == nil {
+			mirror.Labels = map[string]string{}
+		}
+		mirror.Labels[consts.DiscoveredLabel] = "true"
+		mirror.Labels[consts.RemoteClusterID] = string(announcement.Spec.ClusterID)
+		mirror.Spec = announcement.Spec
+		mirror.Signature = announcement.Signature
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if result == controllerutil.OperationResultCreated {
+		klog.Infof("Discovered cluster %q through the registry", announcement.Spec.ClusterID)
+	}
+	return nil
+}
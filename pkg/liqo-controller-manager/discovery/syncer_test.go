@@ -0,0 +1,84 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Syncer", func() {
+	const namespace = "liqo"
+
+	var (
+		cl       client.Client
+		registry *MemoryRegistry
+		syncer   *Syncer
+
+		listMirrors = func() []liqov1beta1.ClusterAnnouncement {
+			var mirrors liqov1beta1.ClusterAnnouncementList
+			Expect(cl.List(ctx, &mirrors, client.InNamespace(namespace))).To(Succeed())
+			return mirrors.Items
+		}
+	)
+
+	BeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		registry = NewMemoryRegistry()
+		syncer = &Syncer{Client: cl, Registry: registry, LocalClusterID: "local", Namespace: namespace}
+
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("local", nil))).To(Succeed())
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("remote", map[string]string{"region": "eu"}))).To(Succeed())
+
+		tampered := forgeSignedAnnouncement("tampered", nil)
+		tampered.Spec.APIServerURL = "https://malicious.example.com"
+		Expect(registry.Publish(ctx, tampered)).To(Succeed())
+
+		Expect(syncer.sync(ctx, time.Now())).To(Succeed())
+	})
+
+	It("should mirror only the valid announcements of the remote clusters", func() {
+		mirrors := listMirrors()
+		Expect(mirrors).To(HaveLen(1))
+		Expect(mirrors[0].Name).To(Equal("remote"))
+		Expect(mirrors[0].Labels).To(HaveKeyWithValue(consts.RemoteClusterID, "remote"))
+		Expect(mirrors[0].Labels).To(HaveKeyWithValue(consts.DiscoveredLabel, "true"))
+		Expect(mirrors[0].Spec.Labels).To(HaveKeyWithValue("region", "eu"))
+		Expect(Verify(&mirrors[0])).To(Succeed())
+	})
+
+	It("should ignore the announcements signed with a different key than the discovered one", func() {
+		original := listMirrors()[0].Spec.PublicKey
+		Expect(registry.Publish(ctx, forgeSignedAnnouncement("remote", nil))).To(Succeed())
+		Expect(syncer.sync(ctx, time.Now())).To(Succeed())
+
+		mirrors := listMirrors()
+		Expect(mirrors).To(HaveLen(1))
+		Expect(mirrors[0].Spec.PublicKey).To(Equal(original))
+	})
+
+	It("should remove the expired announcements", func() {
+		Expect(syncer.sync(ctx, time.Now().Add(2*time.Hour))).To(Succeed())
+		Expect(listMirrors()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,209 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+// cluster-role
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=usagerecords,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=usagerecords/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+var _ manager.Runnable = &Accountant{}
+
+// Accountant periodically samples the pods offloaded by the consumer clusters,
+// and accounts the consumed resources in hourly UsageRecords.
+type Accountant struct {
+	client.Client
+	NamespaceManager tenantnamespace.Manager
+	// MetricsClient is used to retrieve the actual usage of the offloaded pods. If nil, only the requests are accounted.
+	MetricsClient metricsv1beta1.PodMetricsesGetter
+
+	SampleInterval time.Duration
+	Retention      time.Duration
+
+	lastSample time.Time
+}
+
+// clusterUsage maps each offloaded namespace to the resources consumed therein.
+type clusterUsage map[string]*offloadingv1beta1.NamespaceUsage
+
+// Start starts the periodic sampling of the offloaded pods, until the context is canceled.
+func (a *Accountant) Start(ctx context.Context) error {
+	klog.Infof("Starting the accounting of offloaded resources (sample interval: %v)", a.SampleInterval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		a.sample(ctx, time.Now())
+	}, a.SampleInterval)
+	return nil
+}
+
+func (a *Accountant) sample(ctx context.Context, now time.Time) {
+	if a.lastSample.IsZero() {
+		// Nothing to integrate yet, as we do not know for how long the current pods have been running.
+		a.lastSample = now
+		return
+	}
+
+	// Cap the elapsed time, to prevent accounting resources while the accountant was not running.
+	elapsed := min(now.Sub(a.lastSample), 2*a.SampleInterval)
+	a.lastSample = now
+
+	usages, err := a.collect(ctx)
+	if err != nil {
+		klog.Errorf("Failed to collect the resources consumed by the offloaded pods: %v", err)
+		return
+	}
+
+	for clusterID, usage := range usages {
+		usage.observe(string(clusterID), elapsed)
+
+		// The elapsed interval is split at the period boundaries, so that each record is credited only with its share.
+		for from := now.Add(-elapsed); from.Before(now); {
+			until := from.UTC().Truncate(RecordPeriod).Add(RecordPeriod)
+			if until.After(now) {
+				until = now
+			}
+			if err := a.account(ctx, clusterID, usage.integrate(until.Sub(from)), from, until); err != nil {
+				klog.Errorf("Failed to account the resources consumed by cluster %q: %v", clusterID, err)
+			}
+			from = until
+		}
+	}
+
+	if err := a.garbageCollect(ctx, now); err != nil {
+		klog.Errorf("Failed to garbage collect the expired UsageRecords: %v", err)
+	}
+}
+
+// collect returns the resources currently reserved and used by the offloaded pods, grouped by consumer cluster and namespace.
+func (a *Accountant) collect(ctx context.Context) (map[liqov1beta1.ClusterID]clusterUsage, error) {
+	var pods corev1.PodList
+	if err := a.List(ctx, &pods, client.MatchingLabels{consts.ManagedByLabelKey: consts.ManagedByShadowPodValue}); err != nil {
+		return nil, err
+	}
+
+	usages := map[liqov1beta1.ClusterID]clusterUsage{}
+	podMetrics := map[string]map[string]corev1.ResourceList{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		clusterID, found := pod.Labels[forge.LiqoOriginClusterIDKey]
+		if !found || !isAccountable(pod) {
+			continue
+		}
+
+		if _, found := usages[liqov1beta1.ClusterID(clusterID)]; !found {
+			usages[liqov1beta1.ClusterID(clusterID)] = clusterUsage{}
+		}
+		usage := usages[liqov1beta1.ClusterID(clusterID)].namespace(pod.Namespace)
+
+		requests, _ := resourcehelper.PodRequestsAndLimits(pod)
+		addResources(usage.Requested, requests)
+
+		if a.MetricsClient == nil {
+			continue
+		}
+		if _, found := podMetrics[pod.Namespace]; !found {
+			podMetrics[pod.Namespace] = a.getPodMetrics(ctx, pod.Namespace)
+		}
+		if current, found := podMetrics[pod.Namespace][pod.Name]; found {
+			if usage.Used == nil {
+				usage.Used = corev1.ResourceList{}
+			}
+			addResources(usage.Used, current)
+		}
+	}
+
+	return usages, nil
+}
+
+// getPodMetrics returns the current usage of the pods in the given namespace, indexed by pod name.
+func (a *Accountant) getPodMetrics(ctx context.Context, namespace string) map[string]corev1.ResourceList {
+	metrics, err := a.MetricsClient.PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Failed to retrieve the metrics of the pods in namespace %q: %v", namespace, err)
+		return nil
+	}
+
+	usages := make(map[string]corev1.ResourceList, len(metrics.Items))
+	for i := range metrics.Items {
+		usage := corev1.ResourceList{}
+		for j := range metrics.Items[i].Containers {
+			addResources(usage, metrics.Items[i].Containers[j].Usage)
+		}
+		usages[metrics.Items[i].Name] = usage
+	}
+	return usages
+}
+
+func (cu clusterUsage) namespace(namespace string) *offloadingv1beta1.NamespaceUsage {
+	if _, found := cu[namespace]; !found {
+		cu[namespace] = &offloadingv1beta1.NamespaceUsage{Namespace: namespace, Requested: corev1.ResourceList{}}
+	}
+	return cu[namespace]
+}
+
+// integrate returns the resources consumed during the elapsed time, given the ones currently reserved and used.
+func (cu clusterUsage) integrate(elapsed time.Duration) clusterUsage {
+	integrated := make(clusterUsage, len(cu))
+	for namespace, usage := range cu {
+		integrated[namespace] = &offloadingv1beta1.NamespaceUsage{Namespace: namespace, Requested: integrate(usage.Requested, elapsed)}
+		if usage.Used != nil {
+			integrated[namespace].Used = integrate(usage.Used, elapsed)
+		}
+	}
+	return integrated
+}
+
+// observe adds the resources consumed during the elapsed time to the exported metrics.
+func (cu clusterUsage) observe(clusterID string, elapsed time.Duration) {
+	for namespace, usage := range cu {
+		observe(RequestedResourceSeconds, clusterID, namespace, integrate(usage.Requested, elapsed))
+		if usage.Used != nil {
+			observe(UsedResourceSeconds, clusterID, namespace, integrate(usage.Used, elapsed))
+		}
+	}
+}
+
+// isAccountable returns whether the given pod is currently reserving resources.
+func isAccountable(pod *corev1.Pod) bool {
+	return pod.Spec.NodeName != "" && pod.DeletionTimestamp == nil &&
+		pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+func observe(counter *prometheus.CounterVec, clusterID, namespace string, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		counter.WithLabelValues(clusterID, namespace, string(name)).Add(quantity.AsApproximateFloat64())
+	}
+}
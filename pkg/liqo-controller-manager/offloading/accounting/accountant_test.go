@@ -0,0 +1,162 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounting
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Accountant", func() {
+	const (
+		clusterID = liqov1beta1.ClusterID("consumer")
+		namespace = "offloaded"
+	)
+
+	var (
+		cl         client.Client
+		accountant *Accountant
+		tenantNs   *corev1.Namespace
+		now        time.Time
+
+		forgePod = func(name, cpu string, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name, Namespace: namespace,
+					Labels: map[string]string{
+						consts.ManagedByLabelKey:     consts.ManagedByShadowPodValue,
+						forge.LiqoOriginClusterIDKey: string(clusterID),
+					},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: nodeName,
+					Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					}}},
+				},
+				Status: corev1.PodStatus{Phase: phase},
+			}
+		}
+
+		getRecord = func(t time.Time) *offloadingv1beta1.UsageRecord {
+			var record offloadingv1beta1.UsageRecord
+			Expect(cl.Get(ctx, client.ObjectKey{Name: RecordName(clusterID, t), Namespace: tenantNs.Name}, &record)).To(Succeed())
+			return &record
+		}
+	)
+
+	BeforeEach(func() {
+		var err error
+		namespaceManager := tenantnamespace.NewManager(fake.NewSimpleClientset(), scheme.Scheme)
+		tenantNs, err = namespaceManager.CreateNamespace(ctx, clusterID)
+		Expect(err).ToNot(HaveOccurred())
+
+		cl = fakectrl.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(
+				forgePod("running", "500m", "node", corev1.PodRunning),
+				forgePod("pending", "2", "", corev1.PodPending),
+				forgePod("succeeded", "2", "node", corev1.PodSucceeded),
+			).
+			WithStatusSubresource(&offloadingv1beta1.UsageRecord{}).
+			Build()
+
+		accountant = &Accountant{
+			Client:           cl,
+			NamespaceManager: namespaceManager,
+			SampleInterval:   time.Minute,
+			Retention:        24 * time.Hour,
+		}
+		now = time.Date(2024, 5, 10, 12, 10, 0, 0, time.UTC)
+	})
+
+	When("sampling for the first time", func() {
+		It("should not account anything", func() {
+			accountant.sample(ctx, now)
+
+			var records offloadingv1beta1.UsageRecordList
+			Expect(cl.List(ctx, &records)).To(Succeed())
+			Expect(records.Items).To(BeEmpty())
+		})
+	})
+
+	When("sampling periodically", func() {
+		BeforeEach(func() {
+			accountant.sample(ctx, now)
+			accountant.sample(ctx, now.Add(time.Minute))
+		})
+
+		It("should create the UsageRecord of the current period", func() {
+			record := getRecord(now)
+			Expect(record.Name).To(Equal("consumer-2024051012"))
+			Expect(record.Labels).To(HaveKeyWithValue(consts.RemoteClusterID, string(clusterID)))
+			Expect(record.Spec.ClusterID).To(Equal(clusterID))
+			Expect(record.Spec.PeriodStart.Time).To(BeTemporally("==", time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)))
+			Expect(record.Spec.PeriodEnd.Time).To(BeTemporally("==", time.Date(2024, 5, 10, 13, 0, 0, 0, time.UTC)))
+		})
+
+		It("should account only the resources requested by the running pods", func() {
+			record := getRecord(now)
+			Expect(record.Status.Namespaces).To(HaveLen(1))
+			Expect(record.Status.Namespaces[0].Namespace).To(Equal(namespace))
+			Expect(record.Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 30))
+			Expect(record.Status.Namespaces[0].Used).To(BeNil())
+		})
+
+		It("should add the subsequent samples to the same record", func() {
+			accountant.sample(ctx, now.Add(2*time.Minute))
+			Expect(getRecord(now).Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 60))
+		})
+
+		It("should cap the elapsed time between two samples", func() {
+			accountant.sample(ctx, now.Add(time.Hour))
+			Expect(getRecord(now).Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 30))
+			Expect(getRecord(now.Add(time.Hour)).Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 60))
+		})
+
+		It("should split the elapsed time across the periods it spans", func() {
+			accountant.sample(ctx, time.Date(2024, 5, 10, 13, 0, 30, 0, time.UTC))
+
+			// The capped interval started at 12:58:30: 90 seconds are credited to the former period, 30 to the latter.
+			Expect(getRecord(now).Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 75))
+			latter := getRecord(now.Add(time.Hour))
+			Expect(latter.Status.Namespaces[0].Requested.Cpu().Value()).To(BeNumerically("==", 15))
+			Expect(latter.Status.LastSampleTime.Time).To(BeTemporally("==", time.Date(2024, 5, 10, 13, 0, 30, 0, time.UTC)))
+		})
+
+		It("should delete the records older than the retention period", func() {
+			accountant.sample(ctx, now.Add(48*time.Hour))
+
+			var records offloadingv1beta1.UsageRecordList
+			Expect(cl.List(ctx, &records)).To(Succeed())
+			Expect(records.Items).To(HaveLen(1))
+			Expect(records.Items[0].Name).To(Equal(RecordName(clusterID, now.Add(48*time.Hour))))
+		})
+	})
+})
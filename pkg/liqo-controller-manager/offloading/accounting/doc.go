@@ -0,0 +1,18 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accounting implements the logic to account the resources consumed by the pods offloaded by each consumer cluster.
+// The consumption is periodically sampled, integrated over time and persisted in hourly UsageRecords,
+// which can be exported for chargeback/showback purposes.
+package accounting
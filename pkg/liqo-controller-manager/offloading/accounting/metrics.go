@@ -0,0 +1,47 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounting
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// MetricsLabels is the labels that are used for the accounting metrics.
+	MetricsLabels = []string{"cluster_id", "namespace", "resource"}
+
+	// RequestedResourceSeconds is the metric that accounts the resources requested by the offloaded pods over time.
+	RequestedResourceSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "liqo_accounting_requested_resource_seconds_total",
+			Help: "Resources requested by the pods offloaded by a given consumer cluster, integrated over time.",
+		},
+		MetricsLabels,
+	)
+
+	// UsedResourceSeconds is the metric that accounts the resources actually used by the offloaded pods over time.
+	UsedResourceSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "liqo_accounting_used_resource_seconds_total",
+			Help: "Resources used by the pods offloaded by a given consumer cluster, integrated over time.",
+		},
+		MetricsLabels,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(RequestedResourceSeconds, UsedResourceSeconds)
+}
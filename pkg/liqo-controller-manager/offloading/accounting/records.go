@@ -0,0 +1,156 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// RecordPeriod is the duration of the accounting period covered by each UsageRecord.
+const RecordPeriod = time.Hour
+
+// RecordName returns the name of the UsageRecord accounting the consumption of the given cluster in the period including t.
+func RecordName(clusterID liqov1beta1.ClusterID, t time.Time) string {
+	return fmt.Sprintf("%s-%s", clusterID, t.UTC().Truncate(RecordPeriod).Format("2006010215"))
+}
+
+// account adds the consumption between from and until (both within the same period) to the corresponding UsageRecord,
+// creating it if necessary.
+func (a *Accountant) account(ctx context.Context, clusterID liqov1beta1.ClusterID, usage clusterUsage, from, until time.Time) error {
+	namespace, err := a.NamespaceManager.GetNamespace(ctx, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the tenant namespace: %w", err)
+	}
+
+	start := from.UTC().Truncate(RecordPeriod)
+	record := &offloadingv1beta1.UsageRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RecordName(clusterID, from),
+			Namespace: namespace.Name,
+			Labels:    map[string]string{consts.RemoteClusterID: string(clusterID)},
+		},
+		Spec: offloadingv1beta1.UsageRecordSpec{
+			ClusterID:   clusterID,
+			PeriodStart: metav1.NewTime(start),
+			PeriodEnd:   metav1.NewTime(start.Add(RecordPeriod)),
+		},
+	}
+
+	if err := a.Create(ctx, record); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create UsageRecord %q: %w", klog.KObj(record), err)
+		}
+		if err := a.Get(ctx, client.ObjectKeyFromObject(record), record); err != nil {
+			return fmt.Errorf("failed to retrieve UsageRecord %q: %w", klog.KObj(record), err)
+		}
+	}
+
+	record.Status.Namespaces = mergeUsage(record.Status.Namespaces, usage)
+	record.Status.LastSampleTime = &metav1.Time{Time: until}
+	if err := a.Status().Update(ctx, record); err != nil {
+		return fmt.Errorf("failed to update UsageRecord %q: %w", klog.KObj(record), err)
+	}
+	klog.V(4).Infof("UsageRecord %q updated", klog.KObj(record))
+	return nil
+}
+
+// garbageCollect deletes the UsageRecords whose accounting period ended before the retention window.
+func (a *Accountant) garbageCollect(ctx context.Context, now time.Time) error {
+	if a.Retention <= 0 {
+		return nil
+	}
+
+	var records offloadingv1beta1.UsageRecordList
+	if err := a.List(ctx, &records); err != nil {
+		return err
+	}
+
+	threshold := now.Add(-a.Retention)
+	for i := range records.Items {
+		record := &records.Items[i]
+		if !record.Spec.PeriodEnd.Time.Before(threshold) {
+			continue
+		}
+		if err := client.IgnoreNotFound(a.Delete(ctx, record)); err != nil {
+			return fmt.Errorf("failed to delete UsageRecord %q: %w", klog.KObj(record), err)
+		}
+		klog.V(4).Infof("UsageRecord %q expired and deleted", klog.KObj(record))
+	}
+	return nil
+}
+
+// mergeUsage adds the given consumption to the one already accounted, returning the result sorted by namespace.
+func mergeUsage(accounted []offloadingv1beta1.NamespaceUsage, usage clusterUsage) []offloadingv1beta1.NamespaceUsage {
+	merged := clusterUsage{}
+	for i := range accounted {
+		merged[accounted[i].Namespace] = &accounted[i]
+	}
+
+	for namespace, current := range usage {
+		nsUsage := merged.namespace(namespace)
+		if nsUsage.Requested == nil {
+			nsUsage.Requested = corev1.ResourceList{}
+		}
+		addResources(nsUsage.Requested, current.Requested)
+		if current.Used != nil {
+			if nsUsage.Used == nil {
+				nsUsage.Used = corev1.ResourceList{}
+			}
+			addResources(nsUsage.Used, current.Used)
+		}
+	}
+
+	result := make([]offloadingv1beta1.NamespaceUsage, 0, len(merged))
+	for _, nsUsage := range merged {
+		result = append(result, *nsUsage)
+	}
+	slices.SortFunc(result, func(a, b offloadingv1beta1.NamespaceUsage) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return result
+}
+
+// integrate returns the given resources multiplied by the elapsed time, expressed in resource-seconds.
+func integrate(resources corev1.ResourceList, elapsed time.Duration) corev1.ResourceList {
+	integrated := make(corev1.ResourceList, len(resources))
+	for name, quantity := range resources {
+		integrated[name] = *resource.NewMilliQuantity(int64(quantity.AsApproximateFloat64()*elapsed.Seconds()*1000), resource.DecimalSI)
+	}
+	return integrated
+}
+
+// addResources adds the quantities in toAdd to the ones in dst.
+func addResources(dst, toAdd corev1.ResourceList) {
+	for name, quantity := range toAdd {
+		current := dst[name]
+		current.Add(quantity)
+		dst[name] = current
+	}
+}
@@ -34,12 +34,14 @@ type VirtualNodeOptions struct {
 	KubeconfigSecretRef  corev1.LocalObjectReference `json:"kubeconfigSecretRef,omitempty"`
 	VkOptionsTemplateRef *corev1.ObjectReference     `json:"vkOptionsTemplateRef,omitempty"`
 
-	ResourceList        corev1.ResourceList            `json:"resourceList,omitempty"`
-	StorageClasses      []liqov1beta1.StorageType      `json:"storageClasses,omitempty"`
-	IngressClasses      []liqov1beta1.IngressType      `json:"ingressClasses,omitempty"`
-	LoadBalancerClasses []liqov1beta1.LoadBalancerType `json:"loadBalancerClasses,omitempty"`
-	NodeLabels          map[string]string              `json:"nodeLabels,omitempty"`
-	NodeSelector        map[string]string              `json:"nodeSelector,omitempty"`
+	ResourceList            corev1.ResourceList                           `json:"resourceList,omitempty"`
+	StorageClasses          []liqov1beta1.StorageType                     `json:"storageClasses,omitempty"`
+	IngressClasses          []liqov1beta1.IngressType                     `json:"ingressClasses,omitempty"`
+	LoadBalancerClasses     []liqov1beta1.LoadBalancerType                `json:"loadBalancerClasses,omitempty"`
+	Gateways                []liqov1beta1.GatewayType                     `json:"gateways,omitempty"`
+	ClusterScopedReflection []offloadingv1beta1.ClusterScopedResourceKind `json:"clusterScopedReflection,omitempty"`
+	NodeLabels              map[string]string                             `json:"nodeLabels,omitempty"`
+	NodeSelector            map[string]string                             `json:"nodeSelector,omitempty"`
 }
 
 // VirtualNode forges a VirtualNode resource.
@@ -88,6 +90,11 @@ func MutateVirtualNode(ctx context.Context, cl client.Client, virtualNode *offlo
 	virtualNode.Spec.StorageClasses = opts.StorageClasses
 	virtualNode.Spec.IngressClasses = opts.IngressClasses
 	virtualNode.Spec.LoadBalancerClasses = opts.LoadBalancerClasses
+	virtualNode.Spec.Gateways = opts.Gateways
+	// The cluster-scoped reflection allow-list is preserved unless explicitly configured, as not advertised by the ResourceSlice.
+	if opts.ClusterScopedReflection != nil {
+		virtualNode.Spec.ClusterScopedReflection = opts.ClusterScopedReflection
+	}
 
 	if len(opts.NodeSelector) > 0 {
 		if virtualNode.Spec.OffloadingPatch == nil {
@@ -125,6 +132,7 @@ func VirtualNodeOptionsFromResourceSlice(resourceSlice *authv1beta1.ResourceSlic
 		StorageClasses:      resourceSlice.Status.StorageClasses,
 		IngressClasses:      resourceSlice.Status.IngressClasses,
 		LoadBalancerClasses: resourceSlice.Status.LoadBalancerClasses,
+		Gateways:            resourceSlice.Status.Gateways,
 		NodeLabels:          resourceSlice.Status.NodeLabels,
 		NodeSelector:        resourceSlice.Status.NodeSelector,
 	}
@@ -0,0 +1,19 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serviceexportctrl implements a compatibility layer with the Multi-Cluster Services API (MCS). For each ServiceExport,
+// it creates the corresponding ServiceImport, backed by a derived service providing the clusterset virtual IP and by a copy
+// of the endpointslices of the exported service, aggregated with those of the services exported by the remote clusters
+// the namespace is offloaded to. The service is imported in each of them as well, backed by the reflected derived service.
+package serviceexportctrl
@@ -0,0 +1,165 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+const (
+	// ServiceNameLabel is the label identifying the multi-cluster service a resource refers to.
+	ServiceNameLabel = "multicluster.kubernetes.io/service-name"
+	// SourceClusterLabel is the label identifying the cluster the endpoints of an endpointslice originate from.
+	SourceClusterLabel = "multicluster.kubernetes.io/source-cluster"
+	// EndpointSliceNameLabel is the label identifying the derived endpointslice the IP resources of its remote endpoints refer to.
+	EndpointSliceNameLabel = "offloading.liqo.io/endpointslice-name"
+	// EndpointSliceManagedBy is the manager associated with the endpointslices of the derived services.
+	EndpointSliceManagedBy = "serviceexport.offloading.liqo.io"
+
+	// ServiceExportValidCondition is the type of the condition reporting whether a ServiceExport is valid.
+	ServiceExportValidCondition = "Valid"
+
+	// ServiceImportTypeClusterSetIP is the type of the ServiceImports backed by a clusterset virtual IP.
+	ServiceImportTypeClusterSetIP = "ClusterSetIP"
+	// ServiceImportTypeHeadless is the type of the ServiceImports corresponding to headless services.
+	ServiceImportTypeHeadless = "Headless"
+
+	derivedServicePrefix = "derived-"
+)
+
+var (
+	// ServiceExportGVK is the GroupVersionKind of the MCS ServiceExport resource.
+	ServiceExportGVK = schema.GroupVersionKind{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Kind: "ServiceExport"}
+	// ServiceImportGVK is the GroupVersionKind of the MCS ServiceImport resource.
+	ServiceImportGVK = schema.GroupVersionKind{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Kind: "ServiceImport"}
+)
+
+// NewServiceExport returns an empty ServiceExport object, with the GroupVersionKind set.
+func NewServiceExport() *unstructured.Unstructured {
+	export := &unstructured.Unstructured{}
+	export.SetGroupVersionKind(ServiceExportGVK)
+	return export
+}
+
+// NewServiceImport returns an empty ServiceImport object with the given namespace and name, and the GroupVersionKind set.
+func NewServiceImport(namespace, name string) *unstructured.Unstructured {
+	imp := &unstructured.Unstructured{}
+	imp.SetGroupVersionKind(ServiceImportGVK)
+	imp.SetNamespace(namespace)
+	imp.SetName(name)
+	return imp
+}
+
+// DerivedServiceName returns the name of the service providing the clusterset virtual IP of the given exported service.
+func DerivedServiceName(name string) string {
+	return derivedServicePrefix + shortHash(name)
+}
+
+// DerivedEndpointSliceName returns the name of the endpointslice of the derived service, mirroring the given one.
+func DerivedEndpointSliceName(derived, endpointslice string) string {
+	return derived + "-" + shortHash(endpointslice)
+}
+
+// DerivedServiceLabels returns the labels assigned to the derived service of the given exported service.
+func DerivedServiceLabels(name string) map[string]string {
+	return map[string]string{
+		consts.ManagedByLabelKey: consts.ManagedByServiceExportValue,
+		ServiceNameLabel:         name,
+	}
+}
+
+// MutateDerivedService forges the spec of the derived service, given the exported one.
+// The derived service has no selector, as its endpointslices are managed by the controller.
+func MutateDerivedService(derived, exported *corev1.Service) {
+	derived.SetLabels(DerivedServiceLabels(exported.GetName()))
+	derived.Spec.Type = corev1.ServiceTypeClusterIP
+	derived.Spec.Selector = nil
+	derived.Spec.SessionAffinity = exported.Spec.SessionAffinity
+
+	// The cluster IP is immutable, hence it is configured only at creation time.
+	if derived.GetResourceVersion() == "" && exported.Spec.ClusterIP == corev1.ClusterIPNone {
+		derived.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	derived.Spec.Ports = nil
+	for i := range exported.Spec.Ports {
+		port := &exported.Spec.Ports[i]
+		derived.Spec.Ports = append(derived.Spec.Ports, corev1.ServicePort{
+			Name: port.Name, Protocol: port.Protocol, AppProtocol: port.AppProtocol, Port: port.Port, TargetPort: port.TargetPort,
+		})
+	}
+}
+
+// MutateDerivedEndpointSlice forges the derived endpointslice, given the one of the exported service.
+func MutateDerivedEndpointSlice(derived, original *discoveryv1.EndpointSlice, derivedService, exportedService string,
+	sourceCluster string) {
+	derived.SetLabels(map[string]string{
+		discoveryv1.LabelServiceName: derivedService,
+		discoveryv1.LabelManagedBy:   EndpointSliceManagedBy,
+		ServiceNameLabel:             exportedService,
+		SourceClusterLabel:           sourceCluster,
+	})
+
+	derived.AddressType = original.AddressType
+	derived.Endpoints = original.DeepCopy().Endpoints
+	derived.Ports = original.DeepCopy().Ports
+}
+
+// ServiceImportSpec forges the spec of the ServiceImport, given the exported service and the derived one.
+func ServiceImportSpec(exported, derived *corev1.Service) map[string]interface{} {
+	ports := make([]interface{}, 0, len(exported.Spec.Ports))
+	for i := range exported.Spec.Ports {
+		port := map[string]interface{}{
+			"port":     int64(exported.Spec.Ports[i].Port),
+			"protocol": string(exported.Spec.Ports[i].Protocol),
+		}
+		if exported.Spec.Ports[i].Name != "" {
+			port["name"] = exported.Spec.Ports[i].Name
+		}
+		if exported.Spec.Ports[i].AppProtocol != nil {
+			port["appProtocol"] = *exported.Spec.Ports[i].AppProtocol
+		}
+		ports = append(ports, port)
+	}
+
+	spec := map[string]interface{}{"type": ServiceImportTypeClusterSetIP, "ports": ports}
+	switch derived.Spec.ClusterIP {
+	case corev1.ClusterIPNone:
+		spec["type"] = ServiceImportTypeHeadless
+	case "":
+		// The virtual IP has not yet been allocated.
+	default:
+		spec["ips"] = []interface{}{derived.Spec.ClusterIP}
+	}
+
+	if exported.Spec.SessionAffinity != "" {
+		spec["sessionAffinity"] = string(exported.Spec.SessionAffinity)
+	}
+
+	return spec
+}
+
+func shortHash(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:10]
+}
@@ -0,0 +1,423 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+	"github.com/liqotech/liqo/pkg/utils/ipam/mapping"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+// RemoteClientGetter returns a client to interact with the namespaces offloaded to the given remote cluster.
+type RemoteClientGetter func(ctx context.Context, clusterID liqov1beta1.ClusterID) (client.Client, error)
+
+// remoteCluster describes a remote cluster the namespace of a ServiceExport is offloaded to.
+type remoteCluster struct {
+	clusterID liqov1beta1.ClusterID
+	namespace string
+	client    client.Client
+	// node is the virtual node the endpoints originating from the remote cluster are assigned to.
+	node string
+
+	// exported reports whether the service is exported by the remote cluster as well.
+	exported bool
+	// endpointSlices are the endpointslices of the service natively generated in the remote cluster.
+	endpointSlices []discoveryv1.EndpointSlice
+	// offloaded are the names of the local pods offloaded to the remote cluster.
+	offloaded sets.Set[string]
+}
+
+// isOffloaded returns whether the given endpoint refers to a pod offloaded by the local cluster,
+// which is already part of the local endpointslices.
+func (rc *remoteCluster) isOffloaded(endpoint *discoveryv1.Endpoint) bool {
+	return endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" && rc.offloaded.Has(endpoint.TargetRef.Name)
+}
+
+// filteredEndpointSlices returns a copy of the native endpointslices, including only the endpoints matching the given filter.
+func (rc *remoteCluster) filteredEndpointSlices(filter func(*discoveryv1.Endpoint) bool) []discoveryv1.EndpointSlice {
+	filtered := make([]discoveryv1.EndpointSlice, 0, len(rc.endpointSlices))
+	for i := range rc.endpointSlices {
+		eps := rc.endpointSlices[i].DeepCopy()
+		eps.Endpoints = slices.DeleteFunc(eps.Endpoints, func(endpoint discoveryv1.Endpoint) bool { return !filter(&endpoint) })
+		filtered = append(filtered, *eps)
+	}
+	return filtered
+}
+
+// exportedEndpointSlices returns the endpointslices to be aggregated in the local cluster, i.e., those exported by the remote
+// cluster, excluding the pods offloaded by the local cluster.
+func (rc *remoteCluster) exportedEndpointSlices() []discoveryv1.EndpointSlice {
+	if !rc.exported {
+		return nil
+	}
+	return rc.filteredEndpointSlices(func(endpoint *discoveryv1.Endpoint) bool { return !rc.isOffloaded(endpoint) })
+}
+
+// importedEndpointSlices returns the endpointslices to be enforced in the remote cluster, i.e., those natively generated there,
+// which are not reflected by the virtual kubelet. They include the pods offloaded by the local cluster, and those exported there.
+func (rc *remoteCluster) importedEndpointSlices() []discoveryv1.EndpointSlice {
+	return rc.filteredEndpointSlices(func(endpoint *discoveryv1.Endpoint) bool { return rc.exported || rc.isOffloaded(endpoint) })
+}
+
+type cachedClient struct {
+	client.Client
+	identity string
+}
+
+// NewRemoteClientGetter returns a RemoteClientGetter leveraging the identities of the virtual kubelets targeting each remote cluster,
+// which are granted access to the offloaded namespaces only. Clients are cached, and recreated when the identity changes.
+func NewRemoteClientGetter(cl client.Client, scheme *runtime.Scheme, idReader identitymanager.IdentityReader) RemoteClientGetter {
+	var mutex sync.Mutex
+	clients := make(map[liqov1beta1.ClusterID]cachedClient)
+
+	return func(ctx context.Context, clusterID liqov1beta1.ClusterID) (client.Client, error) {
+		secrets, err := getters.GetResourceSliceKubeconfigSecretsByClusterID(ctx, cl, string(clusterID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the identities for cluster %q: %w", clusterID, err)
+		}
+		if len(secrets) == 0 {
+			return nil, fmt.Errorf("no virtual kubelet identity found for cluster %q", clusterID)
+		}
+
+		// Multiple identities may exist (i.e., one per ResourceSlice), and they are equivalent: pick a stable one.
+		secret := slices.MinFunc(secrets, func(a, b corev1.Secret) int { return strings.Compare(a.GetName(), b.GetName()) })
+		identity := fmt.Sprintf("%s/%s@%s", secret.GetNamespace(), secret.GetName(), secret.GetResourceVersion())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if cached, ok := clients[clusterID]; ok && cached.identity == identity {
+			return cached.Client, nil
+		}
+
+		cfg, err := idReader.GetConfigFromSecret(clusterID, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the configuration for cluster %q: %w", clusterID, err)
+		}
+		remote, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the client for cluster %q: %w", clusterID, err)
+		}
+
+		clients[clusterID] = cachedClient{Client: remote, identity: identity}
+		return remote, nil
+	}
+}
+
+// RemoteResourceLabels returns the labels assigned to the resources enforced in the remote clusters for the given exported service.
+func RemoteResourceLabels(name string, origin liqov1beta1.ClusterID) map[string]string {
+	return labels.Merge(DerivedServiceLabels(name), map[string]string{forge.LiqoOriginClusterIDKey: string(origin)})
+}
+
+// remoteClusters returns the remote clusters the given namespace is offloaded to, along with the native endpointslices
+// of the service with the given name. Unreachable clusters are skipped and reported as error.
+func (r *Reconciler) remoteClusters(ctx context.Context, namespace, name string) ([]remoteCluster, error) {
+	if r.RemoteClient == nil {
+		return nil, nil
+	}
+
+	nsmaps, err := getters.ListNamespaceMapsByLabel(ctx, r.Client, corev1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the NamespaceMaps: %w", err)
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %w", err)
+	}
+
+	var remotes []remoteCluster
+	var errs []error
+	for i := range nsmaps {
+		nsmapping, ok := nsmaps[i].Status.CurrentMapping[namespace]
+		clusterID, found := nsmaps[i].GetLabels()[consts.RemoteClusterID]
+		if !ok || !found || nsmapping.Phase != offloadingv1beta1.MappingAccepted {
+			continue
+		}
+
+		remote, err := r.remoteCluster(ctx, liqov1beta1.ClusterID(clusterID), nsmapping.RemoteNamespace, name, pods.Items)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		remotes = append(remotes, *remote)
+	}
+
+	slices.SortFunc(remotes, func(a, b remoteCluster) int { return strings.Compare(string(a.clusterID), string(b.clusterID)) })
+	return remotes, errors.Join(errs...)
+}
+
+// remoteCluster retrieves the information about the given remote cluster, concerning the service with the given name.
+func (r *Reconciler) remoteCluster(ctx context.Context, clusterID liqov1beta1.ClusterID, namespace, name string,
+	pods []corev1.Pod) (*remoteCluster, error) {
+	remote := &remoteCluster{clusterID: clusterID, namespace: namespace, offloaded: sets.New[string]()}
+
+	// The endpoints are assigned to a virtual node of the remote cluster, so that the virtual kubelet does not
+	// reflect them back to the cluster they originate from, where they are natively available.
+	nodes, err := getters.ListNodesByClusterID(ctx, r.Client, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the virtual nodes of cluster %q: %w", clusterID, err)
+	}
+	names := sets.New[string]()
+	for i := range nodes.Items {
+		names.Insert(nodes.Items[i].GetName())
+	}
+	remote.node = sets.List(names)[0]
+	for i := range pods {
+		if names.Has(pods[i].Spec.NodeName) {
+			remote.offloaded.Insert(pods[i].GetName())
+		}
+	}
+
+	if remote.client, err = r.RemoteClient(ctx, clusterID); err != nil {
+		return nil, err
+	}
+
+	remote.exported = true
+	if err := remote.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, NewServiceExport()); err != nil {
+		// The remote cluster might not even support the MCS API, which is equivalent to the service not being exported.
+		if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("failed to retrieve the ServiceExport in cluster %q: %w", clusterID, err)
+		}
+		remote.exported = false
+	}
+
+	var list discoveryv1.EndpointSliceList
+	if err := remote.client.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabels{discoveryv1.LabelServiceName: name}); err != nil {
+		return nil, fmt.Errorf("failed to list the endpointslices in cluster %q: %w", clusterID, err)
+	}
+	for i := range list.Items {
+		// Skip the endpointslices reflected from other clusters, as already considered at their origin.
+		if _, reflected := list.Items[i].GetLabels()[consts.ManagedByLabelKey]; !reflected {
+			remote.endpointSlices = append(remote.endpointSlices, list.Items[i])
+		}
+	}
+	return remote, nil
+}
+
+// enforceRemoteEndpointSlices ensures the endpointslices of the derived service include the endpoints exported by the
+// given remote cluster, remapped according to the network configuration, and returns the names of the enforced ones.
+func (r *Reconciler) enforceRemoteEndpointSlices(ctx context.Context, exported, derived *corev1.Service, remote *remoteCluster) ([]string, error) {
+	originals := remote.exportedEndpointSlices()
+	names := make([]string, 0, len(originals))
+	for i := range originals {
+		original := &originals[i]
+		for j := range original.Endpoints {
+			endpoint := &original.Endpoints[j]
+			for k := range endpoint.Addresses {
+				remapped, err := mapping.MapAddress(ctx, r.Client, remote.clusterID, endpoint.Addresses[k])
+				if err != nil {
+					return nil, fmt.Errorf("failed to remap the endpoints of cluster %q: %w", remote.clusterID, err)
+				}
+				endpoint.Addresses[k] = remapped
+			}
+			endpoint.NodeName = ptr.To(remote.node)
+			endpoint.Zone = nil
+		}
+
+		derivedEps := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+			Name:      DerivedEndpointSliceName(derived.GetName(), string(remote.clusterID)+"/"+original.GetName()),
+			Namespace: derived.GetNamespace()}}
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, derivedEps, func() error {
+			MutateDerivedEndpointSlice(derivedEps, original, derived.GetName(), exported.GetName(), string(remote.clusterID))
+			return controllerutil.SetControllerReference(derived, derivedEps, r.Scheme)
+		}); err != nil {
+			return nil, err
+		}
+
+		if err := r.enforceEndpointIPs(ctx, derivedEps); err != nil {
+			return nil, err
+		}
+		names = append(names, derivedEps.GetName())
+	}
+	return names, nil
+}
+
+// enforceEndpointIPs ensures the presence of the IP resources corresponding to the addresses of the given derived endpointslice,
+// which allow the virtual kubelets to make them reachable from the other remote clusters as well.
+func (r *Reconciler) enforceEndpointIPs(ctx context.Context, eps *discoveryv1.EndpointSlice) error {
+	expected := sets.New[string]()
+	for i := range eps.Endpoints {
+		for _, address := range eps.Endpoints[i].Addresses {
+			ip := &ipamv1alpha1.IP{ObjectMeta: metav1.ObjectMeta{Name: DerivedEndpointSliceName(eps.GetName(), address), Namespace: eps.GetNamespace()}}
+			expected.Insert(ip.GetName())
+			if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, ip, func() error {
+				ip.SetLabels(labels.Merge(ip.GetLabels(), map[string]string{EndpointSliceNameLabel: eps.GetName()}))
+				ip.Spec.IP = networkingv1beta1.IP(address)
+				ip.Spec.Masquerade = ptr.To(true)
+				return controllerutil.SetControllerReference(eps, ip, r.Scheme)
+			}); err != nil {
+				return fmt.Errorf("failed to enforce the IP for endpoint %q: %w", address, err)
+			}
+		}
+	}
+
+	var ips ipamv1alpha1.IPList
+	if err := r.List(ctx, &ips, client.InNamespace(eps.GetNamespace()), client.MatchingLabels{EndpointSliceNameLabel: eps.GetName()}); err != nil {
+		return err
+	}
+	for i := range ips.Items {
+		if !expected.Has(ips.Items[i].GetName()) {
+			if err := client.IgnoreNotFound(r.Delete(ctx, &ips.Items[i])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// enforceRemoteImport ensures the given remote cluster imports the multi-cluster service, backed by the derived service
+// reflected there by the virtual kubelet. The endpoints natively available in the remote cluster are not reflected,
+// hence they are added as endpointslices of the derived service.
+func (r *Reconciler) enforceRemoteImport(ctx context.Context, exported *corev1.Service, remote *remoteCluster, clusters []string) error {
+	derivedName := DerivedServiceName(exported.GetName())
+	originals := remote.importedEndpointSlices()
+	expected := sets.New[string]()
+	for i := range originals {
+		original := &originals[i]
+		eps := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+			Name: DerivedEndpointSliceName(derivedName, original.GetName()), Namespace: remote.namespace}}
+		expected.Insert(eps.GetName())
+		if _, err := controllerutil.CreateOrUpdate(ctx, remote.client, eps, func() error {
+			MutateDerivedEndpointSlice(eps, original, derivedName, exported.GetName(), string(remote.clusterID))
+			eps.SetLabels(labels.Merge(eps.GetLabels(), map[string]string{forge.LiqoOriginClusterIDKey: string(r.LocalClusterID)}))
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to enforce the endpointslices in cluster %q: %w", remote.clusterID, err)
+		}
+	}
+
+	var current discoveryv1.EndpointSliceList
+	if err := remote.client.List(ctx, &current, client.InNamespace(remote.namespace), client.MatchingLabels{
+		discoveryv1.LabelServiceName: derivedName, forge.LiqoOriginClusterIDKey: string(r.LocalClusterID)}); err != nil {
+		return fmt.Errorf("failed to list the endpointslices in cluster %q: %w", remote.clusterID, err)
+	}
+	for i := range current.Items {
+		if !expected.Has(current.Items[i].GetName()) {
+			if err := client.IgnoreNotFound(remote.client.Delete(ctx, &current.Items[i])); err != nil {
+				return fmt.Errorf("failed to delete the endpointslices in cluster %q: %w", remote.clusterID, err)
+			}
+		}
+	}
+
+	// The reflected derived service provides the clusterset virtual IP in the remote cluster.
+	var derived corev1.Service
+	if err := remote.client.Get(ctx, types.NamespacedName{Namespace: remote.namespace, Name: derivedName}, &derived); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to retrieve the derived service in cluster %q: %w", remote.clusterID, err)
+	}
+
+	imp := NewServiceImport(remote.namespace, exported.GetName())
+	if err := remote.client.Get(ctx, client.ObjectKeyFromObject(imp), imp); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to retrieve the ServiceImport in cluster %q: %w", remote.clusterID, err)
+	}
+	if imp.GetResourceVersion() != "" && !r.isRemoteImportOwned(imp) {
+		klog.Warningf("ServiceImport %q in cluster %q is not managed by the local cluster, skipping", klog.KObj(imp), remote.clusterID)
+		return nil
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, remote.client, imp, func() error {
+		imp.SetLabels(RemoteResourceLabels(exported.GetName(), r.LocalClusterID))
+		return unstructured.SetNestedField(imp.Object, ServiceImportSpec(exported, &derived), "spec")
+	}); err != nil {
+		return fmt.Errorf("failed to enforce the ServiceImport in cluster %q: %w", remote.clusterID, err)
+	}
+
+	status := make([]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		status = append(status, map[string]interface{}{"cluster": cluster})
+	}
+	if current, _, _ := unstructured.NestedSlice(imp.Object, "status", "clusters"); reflect.DeepEqual(current, status) {
+		return nil
+	}
+	if err := unstructured.SetNestedSlice(imp.Object, status, "status", "clusters"); err != nil {
+		return err
+	}
+	if err := remote.client.Status().Update(ctx, imp); err != nil {
+		return fmt.Errorf("failed to update the ServiceImport status in cluster %q: %w", remote.clusterID, err)
+	}
+	return nil
+}
+
+// ensureRemoteImportsAbsence ensures the resources enforced in the remote clusters for the given exported service are absent.
+func (r *Reconciler) ensureRemoteImportsAbsence(ctx context.Context, key types.NamespacedName) error {
+	remotes, err := r.remoteClusters(ctx, key.Namespace, key.Name)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range remotes {
+		remote := &remotes[i]
+
+		imp := NewServiceImport(remote.namespace, key.Name)
+		if err := remote.client.Get(ctx, client.ObjectKeyFromObject(imp), imp); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("failed to retrieve the ServiceImport in cluster %q: %w", remote.clusterID, err))
+			continue
+		}
+		if imp.GetResourceVersion() != "" && r.isRemoteImportOwned(imp) {
+			if err := client.IgnoreNotFound(remote.client.Delete(ctx, imp)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete the ServiceImport in cluster %q: %w", remote.clusterID, err))
+				continue
+			}
+		}
+
+		var current discoveryv1.EndpointSliceList
+		if err := remote.client.List(ctx, &current, client.InNamespace(remote.namespace), client.MatchingLabels{
+			discoveryv1.LabelServiceName: DerivedServiceName(key.Name), forge.LiqoOriginClusterIDKey: string(r.LocalClusterID)}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to list the endpointslices in cluster %q: %w", remote.clusterID, err))
+			continue
+		}
+		for j := range current.Items {
+			if err := client.IgnoreNotFound(remote.client.Delete(ctx, &current.Items[j])); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete the endpointslices in cluster %q: %w", remote.clusterID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isRemoteImportOwned returns whether the given remote ServiceImport is managed by the local cluster.
+func (r *Reconciler) isRemoteImportOwned(imp *unstructured.Unstructured) bool {
+	return imp.GetLabels()[consts.ManagedByLabelKey] == consts.ManagedByServiceExportValue &&
+		imp.GetLabels()[forge.LiqoOriginClusterIDKey] == string(r.LocalClusterID)
+}
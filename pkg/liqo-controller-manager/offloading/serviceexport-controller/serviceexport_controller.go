@@ -0,0 +1,403 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+const (
+	serviceExportControllerFinalizer = "serviceexport-controller.liqo.io/finalizer"
+)
+
+// Reconciler reconciles ServiceExport objects.
+type Reconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	LocalClusterID liqov1beta1.ClusterID
+
+	// RemoteClient returns the clients to interact with the remote clusters the namespaces are offloaded to.
+	// If nil, the services exported by the remote clusters are not aggregated, and not imported there.
+	RemoteClient RemoteClientGetter
+	// ResyncPeriod is the period the remote clusters are checked with, as their resources are not watched.
+	ResyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports,verbs=update;patch
+// +kubebuilder:rbac:groups=core,resources=nodes;pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespacemaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liqo.io,resources=configurations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ipam.liqo.io,resources=ips,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile ServiceExport objects.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	export := NewServiceExport()
+	if err := r.Get(ctx, req.NamespacedName, export); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The derived resources are garbage collected, as owned by the ServiceExport.
+			klog.V(4).Infof("ServiceExport %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve ServiceExport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !export.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(export, serviceExportControllerFinalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		// The local resources are garbage collected, while the remote ones need to be explicitly removed.
+		if err := r.ensureRemoteImportsAbsence(ctx, req.NamespacedName); err != nil {
+			klog.Errorf("Failed to remove the remote imports of ServiceExport %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+
+		controllerutil.RemoveFinalizer(export, serviceExportControllerFinalizer)
+		if err := r.Update(ctx, export); err != nil {
+			klog.Errorf("Failed to remove the finalizer from ServiceExport %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if r.RemoteClient != nil && !controllerutil.ContainsFinalizer(export, serviceExportControllerFinalizer) {
+		controllerutil.AddFinalizer(export, serviceExportControllerFinalizer)
+		if err := r.Update(ctx, export); err != nil {
+			klog.Errorf("Failed to add the finalizer to ServiceExport %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	var exported corev1.Service
+	if err := r.Get(ctx, req.NamespacedName, &exported); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to retrieve Service %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+
+		klog.Infof("Service %q exported by ServiceExport %q not found", req.NamespacedName, req.NamespacedName)
+		if err := r.ensureImportAbsence(ctx, req.NamespacedName); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.ensureRemoteImportsAbsence(ctx, req.NamespacedName); err != nil {
+			klog.Errorf("Failed to remove the remote imports of ServiceExport %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.setValidCondition(ctx, export, metav1.ConditionFalse, "ServiceNotFound",
+			fmt.Sprintf("Service %q not found", req.Name))
+	}
+
+	derived, err := r.enforceDerivedService(ctx, export, &exported)
+	if err != nil {
+		klog.Errorf("Failed to enforce the derived service of ServiceExport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	// Unreachable remote clusters are reported once the reachable ones have been enforced.
+	remotes, remoteErr := r.remoteClusters(ctx, req.Namespace, req.Name)
+	if remoteErr != nil {
+		klog.Errorf("Failed to retrieve the remote clusters of ServiceExport %q: %v", req.NamespacedName, remoteErr)
+	}
+
+	clusters, aggregationErr, err := r.enforceDerivedEndpointSlices(ctx, &exported, derived, remotes)
+	if err != nil {
+		klog.Errorf("Failed to enforce the derived endpointslices of ServiceExport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.enforceServiceImport(ctx, export, &exported, derived, clusters); err != nil {
+		klog.Errorf("Failed to enforce the ServiceImport of ServiceExport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	var importErrs []error
+	for i := range remotes {
+		if err := r.enforceRemoteImport(ctx, &exported, &remotes[i], clusters); err != nil {
+			klog.Errorf("Failed to enforce the remote import of ServiceExport %q: %v", req.NamespacedName, err)
+			importErrs = append(importErrs, err)
+		}
+	}
+
+	if err := r.setValidCondition(ctx, export, metav1.ConditionTrue, "ServiceExported",
+		fmt.Sprintf("Service %q correctly exported", req.Name)); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := errors.Join(append(importErrs, remoteErr, aggregationErr)...); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	klog.V(4).Infof("ServiceExport %q correctly enforced", req.NamespacedName)
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// enforceDerivedService ensures the presence of the service providing the clusterset virtual IP.
+func (r *Reconciler) enforceDerivedService(ctx context.Context, export client.Object, exported *corev1.Service) (*corev1.Service, error) {
+	derived := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: DerivedServiceName(exported.GetName()), Namespace: exported.GetNamespace()}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, derived, func() error {
+		MutateDerivedService(derived, exported)
+		return controllerutil.SetControllerReference(export, derived, r.Scheme)
+	}); err != nil {
+		return nil, err
+	}
+	return derived, nil
+}
+
+// enforceDerivedEndpointSlices ensures the endpointslices of the derived service mirror those of the exported service,
+// as well as those of the services exported by the remote clusters, and returns the set of clusters the endpoints originate from.
+// The remote clusters whose endpoints cannot be aggregated are reported separately, and their current endpointslices preserved.
+func (r *Reconciler) enforceDerivedEndpointSlices(ctx context.Context, exported, derived *corev1.Service,
+	remotes []remoteCluster) (clusters []string, aggregationErr, err error) {
+	var originals discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &originals, client.InNamespace(exported.GetNamespace()),
+		client.MatchingLabels{discoveryv1.LabelServiceName: exported.GetName()}); err != nil {
+		return nil, nil, err
+	}
+
+	sources := sets.New[string]()
+	expected := sets.New[string]()
+	for i := range originals.Items {
+		original := &originals.Items[i]
+		source := r.sourceCluster(original)
+		sources.Insert(source)
+		for _, cluster := range r.endpointsClusters(ctx, original) {
+			sources.Insert(cluster)
+		}
+
+		derivedEps := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+			Name: DerivedEndpointSliceName(derived.GetName(), original.GetName()), Namespace: derived.GetNamespace()}}
+		expected.Insert(derivedEps.GetName())
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, derivedEps, func() error {
+			MutateDerivedEndpointSlice(derivedEps, original, derived.GetName(), exported.GetName(), source)
+			return controllerutil.SetControllerReference(derived, derivedEps, r.Scheme)
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	failed := sets.New[string]()
+	var errs []error
+	for i := range remotes {
+		if !remotes[i].exported {
+			continue
+		}
+
+		names, err := r.enforceRemoteEndpointSlices(ctx, exported, derived, &remotes[i])
+		if err != nil {
+			klog.Errorf("Failed to aggregate the endpoints of Service %q exported by cluster %q: %v", klog.KObj(exported), remotes[i].clusterID, err)
+			failed.Insert(string(remotes[i].clusterID))
+			errs = append(errs, err)
+			continue
+		}
+		expected.Insert(names...)
+		sources.Insert(string(remotes[i].clusterID))
+	}
+
+	// Remove the derived endpointslices whose original counterpart does no longer exist.
+	var deriveds discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &deriveds, client.InNamespace(derived.GetNamespace()), client.MatchingLabels{
+		discoveryv1.LabelServiceName: derived.GetName(), discoveryv1.LabelManagedBy: EndpointSliceManagedBy}); err != nil {
+		return nil, nil, err
+	}
+	for i := range deriveds.Items {
+		if !expected.Has(deriveds.Items[i].GetName()) && !failed.Has(deriveds.Items[i].GetLabels()[SourceClusterLabel]) {
+			if err := client.IgnoreNotFound(r.Delete(ctx, &deriveds.Items[i])); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// The local cluster is always part of the clusterset exporting the service.
+	sources.Insert(string(r.LocalClusterID))
+	return sets.List(sources), errors.Join(errs...), nil
+}
+
+// sourceCluster returns the cluster the given endpointslice originates from.
+// Endpointslices reflected from a remote cluster are identified by the origin label.
+func (r *Reconciler) sourceCluster(eps *discoveryv1.EndpointSlice) string {
+	if clusterID, ok := utils.GetClusterIDFromLabelsWithKey(eps.GetLabels(), forge.LiqoOriginClusterIDKey); ok {
+		return string(clusterID)
+	}
+	return string(r.LocalClusterID)
+}
+
+// endpointsClusters returns the remote clusters hosting the endpoints of the given endpointslice (i.e., offloaded pods).
+func (r *Reconciler) endpointsClusters(ctx context.Context, eps *discoveryv1.EndpointSlice) []string {
+	var clusters []string
+	for i := range eps.Endpoints {
+		if eps.Endpoints[i].NodeName == nil {
+			continue
+		}
+
+		var node corev1.Node
+		if err := r.Get(ctx, types.NamespacedName{Name: *eps.Endpoints[i].NodeName}, &node); err != nil {
+			continue
+		}
+		if clusterID, ok := node.GetLabels()[consts.RemoteClusterID]; ok && !slices.Contains(clusters, clusterID) {
+			clusters = append(clusters, clusterID)
+		}
+	}
+	return clusters
+}
+
+// enforceServiceImport ensures the presence of the ServiceImport corresponding to the exported service.
+func (r *Reconciler) enforceServiceImport(ctx context.Context, export client.Object, exported, derived *corev1.Service, clusters []string) error {
+	imp := NewServiceImport(exported.GetNamespace(), exported.GetName())
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, imp, func() error {
+		imp.SetLabels(DerivedServiceLabels(exported.GetName()))
+		if err := unstructured.SetNestedField(imp.Object, ServiceImportSpec(exported, derived), "spec"); err != nil {
+			return err
+		}
+		return controllerutil.SetControllerReference(export, imp, r.Scheme)
+	}); err != nil {
+		return err
+	}
+
+	status := make([]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		status = append(status, map[string]interface{}{"cluster": cluster})
+	}
+
+	current, _, _ := unstructured.NestedSlice(imp.Object, "status", "clusters")
+	if reflect.DeepEqual(current, status) {
+		return nil
+	}
+	if err := unstructured.SetNestedSlice(imp.Object, status, "status", "clusters"); err != nil {
+		return err
+	}
+	return r.Status().Update(ctx, imp)
+}
+
+// ensureImportAbsence ensures the ServiceImport and the derived service associated with the given ServiceExport are absent.
+func (r *Reconciler) ensureImportAbsence(ctx context.Context, key types.NamespacedName) error {
+	imp := NewServiceImport(key.Namespace, key.Name)
+	if err := client.IgnoreNotFound(r.Delete(ctx, imp)); err != nil {
+		klog.Errorf("Failed to delete ServiceImport %q: %v", key, err)
+		return err
+	}
+
+	derived := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: DerivedServiceName(key.Name), Namespace: key.Namespace}}
+	if err := client.IgnoreNotFound(r.Delete(ctx, derived)); err != nil {
+		klog.Errorf("Failed to delete derived Service %q: %v", klog.KObj(derived), err)
+		return err
+	}
+	return nil
+}
+
+// setValidCondition updates the Valid condition of the given ServiceExport, if changed.
+func (r *Reconciler) setValidCondition(ctx context.Context, export *unstructured.Unstructured,
+	status metav1.ConditionStatus, reason, message string) error {
+	var conditions []metav1.Condition
+	raw, _, _ := unstructured.NestedSlice(export.Object, "status", "conditions")
+	for i := range raw {
+		item, ok := raw[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var condition metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item, &condition); err != nil {
+			return err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if !meta.SetStatusCondition(&conditions, metav1.Condition{
+		Type: ServiceExportValidCondition, Status: status, Reason: reason, Message: message, ObservedGeneration: export.GetGeneration(),
+	}) {
+		return nil
+	}
+
+	raw = make([]interface{}, 0, len(conditions))
+	for i := range conditions {
+		condition, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return err
+		}
+		raw = append(raw, condition)
+	}
+	// A null status (e.g., returned after updating the metadata) cannot be extended with the conditions.
+	if status, ok := export.Object["status"]; ok && status == nil {
+		delete(export.Object, "status")
+	}
+	if err := unstructured.SetNestedSlice(export.Object, raw, "status", "conditions"); err != nil {
+		return err
+	}
+
+	if err := r.Status().Update(ctx, export); err != nil {
+		klog.Errorf("Failed to update the status of ServiceExport %q: %v", klog.KObj(export), err)
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager registers a new controller for ServiceExport resources.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Both the exported and the derived services trigger the reconciliation of the corresponding ServiceExport.
+	serviceMapper := func(_ context.Context, obj client.Object) []reconcile.Request {
+		name := obj.GetName()
+		if exported, ok := obj.GetLabels()[ServiceNameLabel]; ok {
+			name = exported
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+	}
+
+	endpointSliceMapper := func(_ context.Context, obj client.Object) []reconcile.Request {
+		name, ok := obj.GetLabels()[discoveryv1.LabelServiceName]
+		if !ok || obj.GetLabels()[discoveryv1.LabelManagedBy] == EndpointSliceManagedBy {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlServiceExport).
+		For(NewServiceExport()).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(serviceMapper)).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(endpointSliceMapper)).
+		Complete(r)
+}
@@ -0,0 +1,398 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("ServiceExport controller", func() {
+	const (
+		namespace      = "foo"
+		name           = "database"
+		localClusterID = "local-cluster"
+		remoteCluster1 = "remote-cluster-1"
+		remoteCluster2 = "remote-cluster-2"
+		virtualNode    = "liqo-remote-cluster-1"
+		derivedIP      = "10.96.0.42"
+
+		remoteNamespace = "foo-local-cluster"
+		remoteDerivedIP = "10.100.0.7"
+	)
+
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		objects    []client.Object
+		reconciler *Reconciler
+		export     *unstructured.Unstructured
+		exported   *corev1.Service
+		err        error
+
+		remoteClient  client.Client
+		remoteObjects []client.Object
+
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+	)
+
+	GetServiceImport := func() *unstructured.Unstructured {
+		imp := NewServiceImport(namespace, name)
+		Expect(fakeClient.Get(ctx, key, imp)).To(Succeed())
+		return imp
+	}
+
+	GetValidCondition := func() map[string]interface{} {
+		updated := NewServiceExport()
+		Expect(fakeClient.Get(ctx, key, updated)).To(Succeed())
+		conditions, _, _ := unstructured.NestedSlice(updated.Object, "status", "conditions")
+		Expect(conditions).To(HaveLen(1))
+		return conditions[0].(map[string]interface{})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		export = NewServiceExport()
+		export.SetNamespace(namespace)
+		export.SetName(name)
+		export.SetUID("export-uid")
+		remoteObjects = nil
+
+		exported = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Selector:  map[string]string{"app": "db"},
+				ClusterIP: "10.96.0.10",
+				Ports:     []corev1.ServicePort{{Name: "sql", Port: 5432, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+
+		objects = []client.Object{
+			export,
+			testutil.FakeNodeWithNameAndLabels(virtualNode, map[string]string{consts.RemoteClusterID: remoteCluster1}),
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-abcde", Namespace: namespace,
+					Labels: map[string]string{discoveryv1.LabelServiceName: name}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{
+					{Addresses: []string{"10.0.0.1"}, NodeName: ptr.To("local-node")},
+					{Addresses: []string{"10.0.0.2"}, NodeName: ptr.To(virtualNode)},
+				},
+				Ports: []discoveryv1.EndpointPort{{Name: ptr.To("sql"), Port: ptr.To[int32](5432)}},
+			},
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-fghij", Namespace: namespace,
+					Labels: map[string]string{discoveryv1.LabelServiceName: name, forge.LiqoOriginClusterIDKey: remoteCluster2}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.1.0.1"}}},
+				Ports:       []discoveryv1.EndpointPort{{Name: ptr.To("sql"), Port: ptr.To[int32](5432)}},
+			},
+			// The derived service already exists, to simulate the allocation of the virtual IP.
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: DerivedServiceName(name), Namespace: namespace},
+				Spec:       corev1.ServiceSpec{ClusterIP: derivedIP},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+			WithStatusSubresource(NewServiceExport(), NewServiceImport("", "")).Build()
+		reconciler = &Reconciler{Client: fakeClient, Scheme: scheme, LocalClusterID: localClusterID}
+		if remoteObjects != nil {
+			remoteClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(remoteObjects...).
+				WithStatusSubresource(NewServiceImport("", "")).Build()
+			reconciler.RemoteClient = func(_ context.Context, clusterID liqov1beta1.ClusterID) (client.Client, error) {
+				Expect(clusterID).To(BeEquivalentTo(remoteCluster1))
+				return remoteClient, nil
+			}
+		}
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	})
+
+	When("the exported service exists", func() {
+		BeforeEach(func() { objects = append(objects, exported) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+		It("should configure the derived service", func() {
+			var derived corev1.Service
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: DerivedServiceName(name)}, &derived)).To(Succeed())
+			Expect(derived.Spec.Selector).To(BeEmpty())
+			Expect(derived.Spec.Ports).To(HaveLen(1))
+			Expect(derived.Labels).To(HaveKeyWithValue(ServiceNameLabel, name))
+			Expect(derived.OwnerReferences).To(HaveLen(1))
+			Expect(derived.OwnerReferences[0].UID).To(BeEquivalentTo("export-uid"))
+		})
+
+		It("should mirror the endpointslices for the derived service", func() {
+			var slices discoveryv1.EndpointSliceList
+			Expect(fakeClient.List(ctx, &slices, client.MatchingLabels{discoveryv1.LabelServiceName: DerivedServiceName(name)})).To(Succeed())
+			Expect(slices.Items).To(HaveLen(2))
+			for i := range slices.Items {
+				Expect(slices.Items[i].Labels).To(HaveKeyWithValue(ServiceNameLabel, name))
+				Expect(slices.Items[i].Labels).To(HaveKeyWithValue(discoveryv1.LabelManagedBy, EndpointSliceManagedBy))
+			}
+
+			var mirror discoveryv1.EndpointSlice
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace,
+				Name: DerivedEndpointSliceName(DerivedServiceName(name), name+"-fghij")}, &mirror)).To(Succeed())
+			Expect(mirror.Labels).To(HaveKeyWithValue(SourceClusterLabel, remoteCluster2))
+			Expect(mirror.Endpoints).To(HaveLen(1))
+			Expect(mirror.Endpoints[0].Addresses).To(ConsistOf("10.1.0.1"))
+		})
+
+		It("should create the ServiceImport", func() {
+			imp := GetServiceImport()
+			importType, _, _ := unstructured.NestedString(imp.Object, "spec", "type")
+			Expect(importType).To(Equal(ServiceImportTypeClusterSetIP))
+			ips, _, _ := unstructured.NestedStringSlice(imp.Object, "spec", "ips")
+			Expect(ips).To(ConsistOf(derivedIP))
+			ports, _, _ := unstructured.NestedSlice(imp.Object, "spec", "ports")
+			Expect(ports).To(ConsistOf(map[string]interface{}{"name": "sql", "port": int64(5432), "protocol": "TCP"}))
+		})
+
+		It("should report the clusters the endpoints originate from", func() {
+			clusters, _, _ := unstructured.NestedSlice(GetServiceImport().Object, "status", "clusters")
+			Expect(clusters).To(ConsistOf(
+				map[string]interface{}{"cluster": localClusterID},
+				map[string]interface{}{"cluster": remoteCluster1},
+				map[string]interface{}{"cluster": remoteCluster2},
+			))
+		})
+
+		It("should mark the ServiceExport as valid", func() {
+			condition := GetValidCondition()
+			Expect(condition).To(HaveKeyWithValue("type", ServiceExportValidCondition))
+			Expect(condition).To(HaveKeyWithValue("status", string(metav1.ConditionTrue)))
+		})
+	})
+
+	When("the exported service does not exist", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+		It("should not create the ServiceImport, and remove the derived service", func() {
+			Expect(fakeClient.Get(ctx, key, NewServiceImport(namespace, name))).To(testutil.BeNotFound())
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: DerivedServiceName(name)},
+				&corev1.Service{})).To(testutil.BeNotFound())
+		})
+
+		It("should mark the ServiceExport as not valid", func() {
+			condition := GetValidCondition()
+			Expect(condition).To(HaveKeyWithValue("status", string(metav1.ConditionFalse)))
+			Expect(condition).To(HaveKeyWithValue("reason", "ServiceNotFound"))
+		})
+	})
+
+	When("the namespace is offloaded to a remote cluster exporting the service as well", func() {
+		var remoteExport *unstructured.Unstructured
+
+		BeforeEach(func() {
+			objects = append(objects, exported,
+				&offloadingv1beta1.NamespaceMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "nsmap", Namespace: "liqo-tenant-remote-cluster-1",
+						Labels: map[string]string{consts.RemoteClusterID: remoteCluster1}},
+					Status: offloadingv1beta1.NamespaceMapStatus{CurrentMapping: map[string]offloadingv1beta1.RemoteNamespaceStatus{
+						namespace: {RemoteNamespace: remoteNamespace, Phase: offloadingv1beta1.MappingAccepted},
+					}},
+				},
+				&networkingv1beta1.Configuration{
+					ObjectMeta: metav1.ObjectMeta{Name: "configuration", Namespace: "liqo-tenant-remote-cluster-1",
+						Labels: map[string]string{consts.RemoteClusterID: remoteCluster1}},
+					Spec: networkingv1beta1.ConfigurationSpec{Remote: networkingv1beta1.ClusterConfig{
+						CIDR: networkingv1beta1.ClusterConfigCIDR{Pod: "10.244.0.0/16", External: "10.245.0.0/16"}}},
+					Status: networkingv1beta1.ConfigurationStatus{Remote: &networkingv1beta1.ClusterConfig{
+						CIDR: networkingv1beta1.ClusterConfigCIDR{Pod: "10.70.0.0/16", External: "10.71.0.0/16"}}},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "offloaded", Namespace: namespace},
+					Spec:       corev1.PodSpec{NodeName: virtualNode},
+				},
+			)
+
+			remoteExport = NewServiceExport()
+			remoteExport.SetNamespace(remoteNamespace)
+			remoteExport.SetName(name)
+			remoteObjects = []client.Object{
+				remoteExport,
+				&discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{Name: name + "-klmno", Namespace: remoteNamespace,
+						Labels: map[string]string{discoveryv1.LabelServiceName: name}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"10.244.0.5"}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "native"}},
+						{Addresses: []string{"10.244.0.6"}, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "offloaded"}},
+					},
+					Ports: []discoveryv1.EndpointPort{{Name: ptr.To("sql"), Port: ptr.To[int32](5432)}},
+				},
+				// The endpointslices reflected from the local cluster are not aggregated.
+				&discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{Name: name + "-pqrst", Namespace: remoteNamespace, Labels: map[string]string{
+						discoveryv1.LabelServiceName: name, consts.ManagedByLabelKey: consts.ManagedByShadowEndpointSliceValue}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.70.0.1"}}},
+				},
+				// The derived service reflected by the virtual kubelet.
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: DerivedServiceName(name), Namespace: remoteNamespace},
+					Spec:       corev1.ServiceSpec{ClusterIP: remoteDerivedIP},
+				},
+			}
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+		It("should add the finalizer to the ServiceExport", func() {
+			updated := NewServiceExport()
+			Expect(fakeClient.Get(ctx, key, updated)).To(Succeed())
+			Expect(updated.GetFinalizers()).To(ContainElement(serviceExportControllerFinalizer))
+		})
+
+		It("should aggregate the remapped endpoints exported by the remote cluster", func() {
+			var aggregated discoveryv1.EndpointSlice
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace,
+				Name: DerivedEndpointSliceName(DerivedServiceName(name), remoteCluster1+"/"+name+"-klmno")}, &aggregated)).To(Succeed())
+			Expect(aggregated.Labels).To(HaveKeyWithValue(SourceClusterLabel, remoteCluster1))
+			// The offloaded pod is already part of the local endpointslices.
+			Expect(aggregated.Endpoints).To(HaveLen(1))
+			Expect(aggregated.Endpoints[0].Addresses).To(ConsistOf("10.70.0.5"))
+			Expect(aggregated.Endpoints[0].NodeName).To(PointTo(Equal(virtualNode)))
+
+			var slices discoveryv1.EndpointSliceList
+			Expect(fakeClient.List(ctx, &slices, client.MatchingLabels{discoveryv1.LabelServiceName: DerivedServiceName(name)})).To(Succeed())
+			Expect(slices.Items).To(HaveLen(3))
+		})
+
+		It("should create the IP resources for the aggregated endpoints", func() {
+			var ips ipamv1alpha1.IPList
+			Expect(fakeClient.List(ctx, &ips, client.InNamespace(namespace))).To(Succeed())
+			Expect(ips.Items).To(HaveLen(1))
+			Expect(ips.Items[0].Spec.IP).To(BeEquivalentTo("10.70.0.5"))
+			Expect(ips.Items[0].Spec.Masquerade).To(PointTo(BeTrue()))
+		})
+
+		It("should import the service in the remote cluster", func() {
+			imp := NewServiceImport(remoteNamespace, name)
+			Expect(remoteClient.Get(ctx, client.ObjectKeyFromObject(imp), imp)).To(Succeed())
+			Expect(imp.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, localClusterID))
+			ips, _, _ := unstructured.NestedStringSlice(imp.Object, "spec", "ips")
+			Expect(ips).To(ConsistOf(remoteDerivedIP))
+			clusters, _, _ := unstructured.NestedSlice(imp.Object, "status", "clusters")
+			Expect(clusters).To(ConsistOf(
+				map[string]interface{}{"cluster": localClusterID},
+				map[string]interface{}{"cluster": remoteCluster1},
+				map[string]interface{}{"cluster": remoteCluster2},
+			))
+		})
+
+		It("should add the native endpoints to the derived service in the remote cluster", func() {
+			var slices discoveryv1.EndpointSliceList
+			Expect(remoteClient.List(ctx, &slices, client.InNamespace(remoteNamespace),
+				client.MatchingLabels{discoveryv1.LabelServiceName: DerivedServiceName(name)})).To(Succeed())
+			Expect(slices.Items).To(HaveLen(1))
+			Expect(slices.Items[0].Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, localClusterID))
+			Expect(slices.Items[0].Endpoints).To(HaveLen(2))
+			Expect(slices.Items[0].Endpoints[0].Addresses).To(ConsistOf("10.244.0.5"))
+			Expect(slices.Items[0].Endpoints[1].Addresses).To(ConsistOf("10.244.0.6"))
+		})
+
+		When("the remote cluster does not export the service", func() {
+			BeforeEach(func() { remoteObjects = remoteObjects[1:] })
+
+			It("should not aggregate the remote endpoints", func() {
+				var slices discoveryv1.EndpointSliceList
+				Expect(fakeClient.List(ctx, &slices, client.MatchingLabels{discoveryv1.LabelServiceName: DerivedServiceName(name)})).To(Succeed())
+				Expect(slices.Items).To(HaveLen(2))
+			})
+
+			It("should add the offloaded endpoints only to the derived service in the remote cluster", func() {
+				var slices discoveryv1.EndpointSliceList
+				Expect(remoteClient.List(ctx, &slices, client.InNamespace(remoteNamespace),
+					client.MatchingLabels{discoveryv1.LabelServiceName: DerivedServiceName(name)})).To(Succeed())
+				Expect(slices.Items).To(HaveLen(1))
+				Expect(slices.Items[0].Endpoints).To(HaveLen(1))
+				Expect(slices.Items[0].Endpoints[0].Addresses).To(ConsistOf("10.244.0.6"))
+			})
+		})
+
+		When("the ServiceExport is being deleted", func() {
+			BeforeEach(func() {
+				export.SetFinalizers([]string{serviceExportControllerFinalizer})
+				export.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+				imp := NewServiceImport(remoteNamespace, name)
+				imp.SetLabels(RemoteResourceLabels(name, localClusterID))
+				remoteObjects = append(remoteObjects, imp, &discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{Name: "derived", Namespace: remoteNamespace, Labels: map[string]string{
+						discoveryv1.LabelServiceName: DerivedServiceName(name), forge.LiqoOriginClusterIDKey: localClusterID}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+				})
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+			It("should remove the resources enforced in the remote cluster", func() {
+				Expect(remoteClient.Get(ctx, types.NamespacedName{Namespace: remoteNamespace, Name: name},
+					NewServiceImport(remoteNamespace, name))).To(testutil.BeNotFound())
+				Expect(remoteClient.Get(ctx, types.NamespacedName{Namespace: remoteNamespace, Name: "derived"},
+					&discoveryv1.EndpointSlice{})).To(testutil.BeNotFound())
+			})
+
+			It("should remove the finalizer", func() {
+				Expect(fakeClient.Get(ctx, key, NewServiceExport())).To(testutil.BeNotFound())
+			})
+		})
+
+		When("the ServiceImport in the remote cluster is not managed by the local cluster", func() {
+			BeforeEach(func() {
+				imp := NewServiceImport(remoteNamespace, name)
+				imp.SetLabels(map[string]string{"foo": "bar"})
+				remoteObjects = append(remoteObjects, imp)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+			It("should not modify it", func() {
+				imp := NewServiceImport(remoteNamespace, name)
+				Expect(remoteClient.Get(ctx, client.ObjectKeyFromObject(imp), imp)).To(Succeed())
+				Expect(imp.GetLabels()).To(Equal(map[string]string{"foo": "bar"}))
+				_, found, _ := unstructured.NestedFieldNoCopy(imp.Object, "spec")
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+})
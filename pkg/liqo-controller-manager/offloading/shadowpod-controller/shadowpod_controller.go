@@ -39,6 +39,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils"
 	clientutils "github.com/liqotech/liqo/pkg/utils/clients"
 	ipamips "github.com/liqotech/liqo/pkg/utils/ipam/mapping"
+	podutils "github.com/liqotech/liqo/pkg/utils/pod"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
@@ -51,6 +52,8 @@ type Reconciler struct {
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=shadowpods,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=shadowpods/finalizers,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods/resize,verbs=get;update;patch
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=shadowpods/status,verbs=get;update;patch
 
 // Reconcile ShadowPods objects.
@@ -98,6 +101,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			return ctrl.Result{}, err
 		}
 
+		// Propagate the in-place resize of the containers possibly requested through the shadowpod.
+		if err := r.ensureResources(ctx, &shadowPod, &existingPod); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// Add the ephemeral containers possibly added to the shadowpod (e.g., through kubectl debug).
+		if err := r.ensureEphemeralContainers(ctx, &shadowPod, &existingPod); err != nil {
+			return ctrl.Result{}, err
+		}
+
 		// Update ShadowPod status same as Pod status
 		shadowPod.Status.Phase = existingPod.Status.DeepCopy().Phase
 		if newErr := r.Client.Status().Update(ctx, &shadowPod); newErr != nil {
@@ -124,9 +137,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			Labels:      shadowPod.Labels,
 			Annotations: shadowPod.Annotations,
 		},
-		Spec: shadowPod.Spec.Pod,
+		Spec: *shadowPod.Spec.Pod.DeepCopy(),
 	}
 
+	// Ephemeral containers cannot be specified at creation time, and they are added afterwards through the dedicated subresource.
+	newPod.Spec.EphemeralContainers = nil
+
 	// Mutate PodSpec
 	if err := r.mutatePodSpec(ctx, &newPod.Spec, remoteClusterID); err != nil {
 		klog.Errorf("unable to mutate pod spec for shadowpod %q: %v", klog.KObj(&shadowPod), err)
@@ -142,9 +158,62 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	klog.Infof("created pod %q for shadowpod %q", klog.KObj(&newPod), klog.KObj(&shadowPod))
 
+	if err := r.ensureEphemeralContainers(ctx, &shadowPod, &newPod); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// ensureResources aligns the resources of the pod containers with the ones specified in the shadowpod,
+// leveraging the resize subresource to perform an in-place update (i.e., without restarting the pod).
+func (r *Reconciler) ensureResources(ctx context.Context, shadowPod *offloadingv1beta1.ShadowPod, pod *corev1.Pod) error {
+	if podutils.AreContainerResourcesAligned(pod.Spec.Containers, shadowPod.Spec.Pod.Containers) {
+		return nil
+	}
+
+	original := pod.DeepCopy()
+	for i := range pod.Spec.Containers {
+		for j := range shadowPod.Spec.Pod.Containers {
+			if pod.Spec.Containers[i].Name == shadowPod.Spec.Pod.Containers[j].Name {
+				pod.Spec.Containers[i].Resources = *shadowPod.Spec.Pod.Containers[j].Resources.DeepCopy()
+				break
+			}
+		}
+	}
+
+	err := r.SubResource("resize").Patch(ctx, pod, client.StrategicMergeFrom(original), client.FieldOwner("shadow-pod"))
+	if errors.IsNotFound(err) {
+		// The resize subresource is not available in older Kubernetes versions, where resources are directly mutable.
+		err = r.Patch(ctx, pod, client.StrategicMergeFrom(original), client.FieldOwner("shadow-pod"))
+	}
+	if err != nil {
+		klog.Errorf("unable to resize pod %q: %v", klog.KObj(pod), err)
+		return err
+	}
+
+	klog.Infof("resized pod %q", klog.KObj(pod))
+	return nil
+}
+
+// ensureEphemeralContainers adds to the pod the ephemeral containers specified in the shadowpod and not yet present,
+// leveraging the ephemeralcontainers subresource (as they cannot be added through standard updates).
+func (r *Reconciler) ensureEphemeralContainers(ctx context.Context, shadowPod *offloadingv1beta1.ShadowPod, pod *corev1.Pod) error {
+	missing := podutils.MissingEphemeralContainers(pod.Spec.EphemeralContainers, shadowPod.Spec.Pod.EphemeralContainers)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, missing...)
+	if err := r.SubResource("ephemeralcontainers").Update(ctx, pod, client.FieldOwner("shadow-pod")); err != nil {
+		klog.Errorf("unable to add ephemeral containers to pod %q: %v", klog.KObj(pod), err)
+		return err
+	}
+
+	klog.Infof("added %d ephemeral container(s) to pod %q", len(missing), klog.KObj(pod))
+	return nil
+}
+
 // SetupWithManager monitors only updates on ShadowPods.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, workers int) error {
 	// Trigger a reconciliation only for Delete and Update Events.
@@ -178,6 +178,26 @@ var _ = Describe("Reconcile", func() {
 		})
 	})
 
+	When("an ephemeral container has been added to the shadowpod", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &testPod)).To(Succeed())
+			testShadowPod.Spec.Pod.EphemeralContainers = []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: "debugger", Image: "busybox", TerminationMessagePolicy: corev1.TerminationMessageReadFile, ImagePullPolicy: corev1.PullAlways,
+				},
+			}}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		It("should add the ephemeral container to the pod", func() {
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.EphemeralContainers).To(HaveLen(1))
+			Expect(pod.Spec.EphemeralContainers[0].Name).To(Equal("debugger"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("added 1 ephemeral container(s) to pod %q", klog.KObj(&testPod))))
+		})
+	})
+
 	When("create pod", func() {
 		BeforeEach(func() {
 			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
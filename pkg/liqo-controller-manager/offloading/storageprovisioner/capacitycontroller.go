@@ -0,0 +1,287 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+const (
+	// defaultStorageClassAnnotation is the annotation marking the default storage class of the cluster.
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+	// localStorageCapacityName is the name of the CSIStorageCapacity published for the local nodes,
+	// in case the capacity of the local real storage class is not tracked.
+	localStorageCapacityName = "local"
+)
+
+// UnknownStorageCapacity is the capacity published for the nodes whose actual capacity is not tracked, so that they are
+// not excluded by the scheduler, which would otherwise consider them unable to host any volume.
+var UnknownStorageCapacity = resource.MustParse("1Ei")
+
+// StorageCapacityReconciler publishes the CSIStorageCapacity objects of the virtual storage class, which allow the scheduler
+// to exclude the nodes that cannot satisfy the storage requested by WaitForFirstConsumer PVCs. The capacity of each virtual
+// node is retrieved from the storage information shared by the remote cluster through the ResourceSlice, while that of
+// the local nodes mirrors the one of the local real storage class.
+type StorageCapacityReconciler struct {
+	client.Client
+
+	VirtualStorageClassName string
+	RealStorageClassName    string
+	StorageNamespace        string
+}
+
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csistoragecapacities,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=virtualnodes,verbs=get;list;watch
+
+// Reconcile aligns the CSIStorageCapacity objects of the virtual storage class with the current capacities.
+// All objects are reconciled at once, independently of the triggering event.
+func (r *StorageCapacityReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	desired, err := r.desiredStorageCapacities(ctx)
+	if err != nil {
+		klog.Errorf("Failed to compute the storage capacities of the virtual storage class: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	var current storagev1.CSIStorageCapacityList
+	if err := r.List(ctx, &current, client.InNamespace(r.StorageNamespace), client.HasLabels{consts.VirtualStorageCapacityLabel}); err != nil {
+		klog.Errorf("Failed to list the storage capacities of the virtual storage class: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	existing := make(map[string]*storagev1.CSIStorageCapacity, len(current.Items))
+	for i := range current.Items {
+		existing[current.Items[i].GetName()] = &current.Items[i]
+	}
+
+	for i := range desired {
+		if err := r.enforceStorageCapacity(ctx, &desired[i], existing[desired[i].GetName()]); err != nil {
+			klog.Errorf("Failed to enforce the CSIStorageCapacity %q: %v", client.ObjectKeyFromObject(&desired[i]), err)
+			return ctrl.Result{}, err
+		}
+		delete(existing, desired[i].GetName())
+	}
+
+	for _, capacity := range existing {
+		if err := client.IgnoreNotFound(r.Delete(ctx, capacity)); err != nil {
+			klog.Errorf("Failed to delete the stale CSIStorageCapacity %q: %v", client.ObjectKeyFromObject(capacity), err)
+			return ctrl.Result{}, err
+		}
+		klog.V(4).Infof("Deleted the stale CSIStorageCapacity %q", client.ObjectKeyFromObject(capacity))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// enforceStorageCapacity creates or updates the given CSIStorageCapacity, recreating it if any immutable field changed.
+func (r *StorageCapacityReconciler) enforceStorageCapacity(ctx context.Context, desired, existing *storagev1.CSIStorageCapacity) error {
+	if existing != nil && (existing.StorageClassName != desired.StorageClassName ||
+		!equality.Semantic.DeepEqual(existing.NodeTopology, desired.NodeTopology)) {
+		if err := client.IgnoreNotFound(r.Delete(ctx, existing)); err != nil {
+			return err
+		}
+		existing = nil
+	}
+
+	if existing == nil {
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		klog.Infof("Created the CSIStorageCapacity %q (capacity: %v)", client.ObjectKeyFromObject(desired), desired.Capacity)
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.Capacity, desired.Capacity) &&
+		equality.Semantic.DeepEqual(existing.MaximumVolumeSize, desired.MaximumVolumeSize) {
+		return nil
+	}
+
+	existing.Capacity = desired.Capacity
+	existing.MaximumVolumeSize = desired.MaximumVolumeSize
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	klog.Infof("Updated the CSIStorageCapacity %q (capacity: %v)", client.ObjectKeyFromObject(existing), existing.Capacity)
+	return nil
+}
+
+// desiredStorageCapacities returns the CSIStorageCapacity objects to be published for the virtual storage class.
+func (r *StorageCapacityReconciler) desiredStorageCapacities(ctx context.Context) ([]storagev1.CSIStorageCapacity, error) {
+	var virtualNodes offloadingv1beta1.VirtualNodeList
+	if err := r.List(ctx, &virtualNodes); err != nil {
+		return nil, fmt.Errorf("failed to list the virtual nodes: %w", err)
+	}
+
+	var capacities []storagev1.CSIStorageCapacity
+	for i := range virtualNodes.Items {
+		if capacity, ok := r.virtualNodeStorageCapacity(&virtualNodes.Items[i]); ok {
+			capacities = append(capacities, *capacity)
+		}
+	}
+
+	local, err := r.localStorageCapacities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(capacities, local...), nil
+}
+
+// virtualNodeStorageCapacity returns the CSIStorageCapacity of the given virtual node, based on the capacity of the
+// default storage class offered by the remote cluster. No capacity is returned if the virtual node does not offer storage.
+func (r *StorageCapacityReconciler) virtualNodeStorageCapacity(virtualNode *offloadingv1beta1.VirtualNode) (*storagev1.CSIStorageCapacity, bool) {
+	if virtualNode.Spec.CreateNode != nil && !*virtualNode.Spec.CreateNode {
+		return nil, false
+	}
+
+	class, ok := defaultStorageType(virtualNode.Spec.StorageClasses)
+	if !ok {
+		return nil, false
+	}
+
+	capacity := UnknownStorageCapacity.DeepCopy()
+	if class.Capacity != nil {
+		capacity = class.Capacity.DeepCopy()
+	}
+
+	return r.forgeStorageCapacity(fmt.Sprintf("virtual-node-%s", virtualNode.GetName()),
+		&metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelHostname: virtualNode.GetName()}}, &capacity, nil), true
+}
+
+// localStorageCapacities returns the CSIStorageCapacity objects of the local nodes, mirroring those of the local real storage class.
+// In case the capacity of the local real storage class is not tracked, a single object with unknown capacity is returned.
+func (r *StorageCapacityReconciler) localStorageCapacities(ctx context.Context) ([]storagev1.CSIStorageCapacity, error) {
+	realStorageClassName, err := r.realStorageClassName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var realCapacities storagev1.CSIStorageCapacityList
+	if err := r.List(ctx, &realCapacities); err != nil {
+		return nil, fmt.Errorf("failed to list the storage capacities: %w", err)
+	}
+
+	var capacities []storagev1.CSIStorageCapacity
+	for i := range realCapacities.Items {
+		realCapacity := &realCapacities.Items[i]
+		if _, found := realCapacity.GetLabels()[consts.VirtualStorageCapacityLabel]; found ||
+			realStorageClassName == "" || realCapacity.StorageClassName != realStorageClassName {
+			continue
+		}
+
+		topology := &metav1.LabelSelector{}
+		if realCapacity.NodeTopology != nil {
+			topology = realCapacity.NodeTopology.DeepCopy()
+		}
+		capacities = append(capacities, *r.forgeStorageCapacity(
+			fmt.Sprintf("%s-%s-%s", localStorageCapacityName, realCapacity.GetNamespace(), realCapacity.GetName()),
+			localNodesSelector(topology), realCapacity.Capacity, realCapacity.MaximumVolumeSize))
+	}
+
+	if len(capacities) == 0 {
+		capacity := UnknownStorageCapacity.DeepCopy()
+		capacities = append(capacities, *r.forgeStorageCapacity(localStorageCapacityName,
+			localNodesSelector(&metav1.LabelSelector{}), &capacity, nil))
+	}
+
+	return capacities, nil
+}
+
+// realStorageClassName returns the name of the local real storage class, defaulting to the default one of the cluster.
+func (r *StorageCapacityReconciler) realStorageClassName(ctx context.Context) (string, error) {
+	if r.RealStorageClassName != "" {
+		return r.RealStorageClassName, nil
+	}
+
+	var classes storagev1.StorageClassList
+	if err := r.List(ctx, &classes); err != nil {
+		return "", fmt.Errorf("failed to list the storage classes: %w", err)
+	}
+	for i := range classes.Items {
+		if classes.Items[i].GetAnnotations()[defaultStorageClassAnnotation] == "true" {
+			return classes.Items[i].GetName(), nil
+		}
+	}
+	return "", nil
+}
+
+// forgeStorageCapacity forges a CSIStorageCapacity of the virtual storage class.
+func (r *StorageCapacityReconciler) forgeStorageCapacity(name string, topology *metav1.LabelSelector,
+	capacity, maximumVolumeSize *resource.Quantity) *storagev1.CSIStorageCapacity {
+	return &storagev1.CSIStorageCapacity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: r.StorageNamespace,
+			Labels:    map[string]string{consts.VirtualStorageCapacityLabel: "true"},
+		},
+		StorageClassName:  r.VirtualStorageClassName,
+		NodeTopology:      topology,
+		Capacity:          capacity,
+		MaximumVolumeSize: maximumVolumeSize,
+	}
+}
+
+// localNodesSelector restricts the given selector to the local (i.e., non-virtual) nodes.
+func localNodesSelector(selector *metav1.LabelSelector) *metav1.LabelSelector {
+	selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key: consts.TypeLabel, Operator: metav1.LabelSelectorOpDoesNotExist,
+	})
+	return selector
+}
+
+// defaultStorageType returns the default storage class among the given ones (i.e., the one the remote PVCs are associated
+// with, unless otherwise specified), falling back to the first one.
+func defaultStorageType(classes []liqov1beta1.StorageType) (*liqov1beta1.StorageType, bool) {
+	if len(classes) == 0 {
+		return nil, false
+	}
+	for i := range classes {
+		if classes[i].Default {
+			return &classes[i], true
+		}
+	}
+	return &classes[0], true
+}
+
+// SetupWithManager monitors the virtual nodes, as well as the storage classes and capacities of the local cluster.
+func (r *StorageCapacityReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// All events are mapped to the same request, since the capacities are reconciled at once.
+	enqueuer := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, _ client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: r.StorageNamespace, Name: r.VirtualStorageClassName}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlStorageCapacity).
+		Watches(&offloadingv1beta1.VirtualNode{}, enqueuer).
+		Watches(&storagev1.CSIStorageCapacity{}, enqueuer).
+		Watches(&storagev1.StorageClass{}, enqueuer).
+		Complete(r)
+}
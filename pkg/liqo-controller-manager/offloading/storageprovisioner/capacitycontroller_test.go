@@ -0,0 +1,188 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Storage capacity tracking", func() {
+
+	const (
+		virtualStorageClassName = "liqo"
+		realStorageClassName    = "standard"
+		storageNamespace        = "liqo-storage"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *StorageCapacityReconciler
+
+		objects []client.Object
+		err     error
+	)
+
+	forgeVirtualNode := func(name string, classes ...liqov1beta1.StorageType) *offloadingv1beta1.VirtualNode {
+		return &offloadingv1beta1.VirtualNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "liqo-tenant-remote"},
+			Spec:       offloadingv1beta1.VirtualNodeSpec{CreateNode: ptr.To(true), StorageClasses: classes},
+		}
+	}
+
+	getCapacity := func(name string) *storagev1.CSIStorageCapacity {
+		var capacity storagev1.CSIStorageCapacity
+		Expect(cl.Get(ctx, types.NamespacedName{Namespace: storageNamespace, Name: name}, &capacity)).To(Succeed())
+		return &capacity
+	}
+
+	listCapacities := func() []storagev1.CSIStorageCapacity {
+		var capacities storagev1.CSIStorageCapacityList
+		Expect(cl.List(ctx, &capacities, client.InNamespace(storageNamespace),
+			client.HasLabels{consts.VirtualStorageCapacityLabel})).To(Succeed())
+		return capacities.Items
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(offloadingv1beta1.AddToScheme(scheme)).To(Succeed())
+
+		cl = ctrlfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		reconciler = &StorageCapacityReconciler{Client: cl, VirtualStorageClassName: virtualStorageClassName,
+			RealStorageClassName: realStorageClassName, StorageNamespace: storageNamespace}
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{})
+	})
+
+	When("a virtual node offers a storage class with a known capacity", func() {
+		BeforeEach(func() {
+			objects = append(objects, forgeVirtualNode("remote",
+				liqov1beta1.StorageType{StorageClassName: "slow", Capacity: ptr.To(resource.MustParse("1Ti"))},
+				liqov1beta1.StorageType{StorageClassName: "fast", Default: true, Capacity: ptr.To(resource.MustParse("10Gi"))}))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should publish the capacity of the default remote storage class", func() {
+			capacity := getCapacity("virtual-node-remote")
+			Expect(capacity.StorageClassName).To(Equal(virtualStorageClassName))
+			Expect(capacity.Labels).To(HaveKey(consts.VirtualStorageCapacityLabel))
+			Expect(capacity.NodeTopology).To(Equal(&metav1.LabelSelector{
+				MatchLabels: map[string]string{corev1.LabelHostname: "remote"}}))
+			Expect(capacity.Capacity.Cmp(resource.MustParse("10Gi"))).To(BeZero())
+		})
+	})
+
+	When("a virtual node offers a storage class with an unknown capacity", func() {
+		BeforeEach(func() {
+			objects = append(objects, forgeVirtualNode("remote", liqov1beta1.StorageType{StorageClassName: "slow"}))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should publish the unknown capacity", func() {
+			Expect(getCapacity("virtual-node-remote").Capacity.Cmp(UnknownStorageCapacity)).To(BeZero())
+		})
+	})
+
+	When("a virtual node does not offer any storage class", func() {
+		BeforeEach(func() { objects = append(objects, forgeVirtualNode("remote")) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not publish any capacity for the virtual node", func() {
+			Expect(listCapacities()).To(ConsistOf(HaveField("Name", localStorageCapacityName)))
+		})
+	})
+
+	When("the capacity of the local real storage class is tracked", func() {
+		BeforeEach(func() {
+			objects = append(objects,
+				&storagev1.CSIStorageCapacity{
+					ObjectMeta:       metav1.ObjectMeta{Name: "real", Namespace: "csi"},
+					StorageClassName: realStorageClassName,
+					NodeTopology:     &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+					Capacity:         ptr.To(resource.MustParse("100Gi")),
+				},
+				&storagev1.CSIStorageCapacity{
+					ObjectMeta:       metav1.ObjectMeta{Name: "other", Namespace: "csi"},
+					StorageClassName: "other",
+					Capacity:         ptr.To(resource.MustParse("1Gi")),
+				},
+			)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should mirror the capacity of the real storage class for the local nodes", func() {
+			capacity := getCapacity("local-csi-real")
+			Expect(capacity.StorageClassName).To(Equal(virtualStorageClassName))
+			Expect(capacity.NodeTopology).To(Equal(&metav1.LabelSelector{
+				MatchLabels: map[string]string{"zone": "a"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: consts.TypeLabel, Operator: metav1.LabelSelectorOpDoesNotExist}},
+			}))
+			Expect(capacity.Capacity.Cmp(resource.MustParse("100Gi"))).To(BeZero())
+		})
+		It("should not publish any other capacity", func() {
+			Expect(listCapacities()).To(ConsistOf(HaveField("Name", "local-csi-real")))
+		})
+	})
+
+	When("the capacity of the local real storage class is not tracked", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should publish the unknown capacity for the local nodes", func() {
+			capacity := getCapacity(localStorageCapacityName)
+			Expect(capacity.NodeTopology.MatchExpressions).To(ConsistOf(metav1.LabelSelectorRequirement{
+				Key: consts.TypeLabel, Operator: metav1.LabelSelectorOpDoesNotExist}))
+			Expect(capacity.Capacity.Cmp(UnknownStorageCapacity)).To(BeZero())
+		})
+	})
+
+	When("stale capacities exist", func() {
+		BeforeEach(func() {
+			objects = append(objects, &storagev1.CSIStorageCapacity{
+				ObjectMeta: metav1.ObjectMeta{Name: "virtual-node-stale", Namespace: storageNamespace,
+					Labels: map[string]string{consts.VirtualStorageCapacityLabel: "true"}},
+				StorageClassName: virtualStorageClassName,
+			})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should delete them", func() {
+			Expect(listCapacities()).To(ConsistOf(HaveField("Name", localStorageCapacityName)))
+		})
+	})
+})
@@ -17,9 +17,30 @@ package storageprovisioner
 import (
 	"context"
 
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
+
+	"github.com/liqotech/liqo/pkg/consts"
 )
 
+// NewProvisionControllers returns the controllers serving the virtual storage class through the given provisioner.
+// The provisioner name must match the one of the CSIDriver enabling the capacity tracking, if any. In that case, an additional
+// controller keeps serving the legacy name, to reclaim the volumes provisioned before the feature was enabled: the library only
+// deletes the volumes annotated with the name of the controller, regardless of its additional provisioner names.
+func NewProvisionControllers(clientset kubernetes.Interface, provisioner controller.Provisioner,
+	enableCapacity bool) []*controller.ProvisionController {
+	if !enableCapacity {
+		return []*controller.ProvisionController{
+			controller.NewProvisionController(clientset, consts.StorageProvisionerName, provisioner, controller.LeaderElection(false)),
+		}
+	}
+
+	return []*controller.ProvisionController{
+		controller.NewProvisionController(clientset, consts.StorageCSIDriverName, provisioner, controller.LeaderElection(false)),
+		controller.NewProvisionController(clientset, consts.StorageProvisionerName, provisioner, controller.LeaderElection(false)),
+	}
+}
+
 // StorageControllerRunnable wraps the storage ProvisionController to implement the Runnable interface.
 type StorageControllerRunnable struct {
 	Ctrl *controller.ProvisionController
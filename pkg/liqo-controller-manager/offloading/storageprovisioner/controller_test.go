@@ -0,0 +1,103 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// fakeProvisioner is a provisioner recording the deleted volumes.
+type fakeProvisioner struct {
+	mutex   sync.Mutex
+	deleted []string
+}
+
+func (p *fakeProvisioner) Provision(context.Context, controller.ProvisionOptions) (*corev1.PersistentVolume, controller.ProvisioningState, error) {
+	return nil, controller.ProvisioningFinished, nil
+}
+
+func (p *fakeProvisioner) Delete(_ context.Context, pv *corev1.PersistentVolume) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.deleted = append(p.deleted, pv.GetName())
+	return nil
+}
+
+func (p *fakeProvisioner) Deleted() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string{}, p.deleted...)
+}
+
+var _ = Describe("Provision controllers", func() {
+	var (
+		ctx         context.Context
+		cancel      context.CancelFunc
+		clientset   *fake.Clientset
+		provisioner *fakeProvisioner
+	)
+
+	forgePv := func(name, provisionerName string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{"pv.kubernetes.io/provisioned-by": provisionerName},
+			},
+			Spec:   corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeReleased},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		clientset = fake.NewClientset(forgePv("legacy", consts.StorageProvisionerName), forgePv("current", consts.StorageCSIDriverName))
+		provisioner = &fakeProvisioner{}
+	})
+
+	AfterEach(func() { cancel() })
+
+	run := func(enableCapacity bool) {
+		for _, ctrl := range NewProvisionControllers(clientset, provisioner, enableCapacity) {
+			go func() { _ = StorageControllerRunnable{Ctrl: ctrl}.Start(ctx) }()
+		}
+	}
+
+	When("the capacity tracking is disabled", func() {
+		BeforeEach(func() { run(false) })
+
+		It("should reclaim only the volumes provisioned with the legacy name", func() {
+			Eventually(provisioner.Deleted).Should(ConsistOf("legacy"))
+			Consistently(provisioner.Deleted).Should(ConsistOf("legacy"))
+		})
+	})
+
+	When("the capacity tracking is enabled", func() {
+		BeforeEach(func() { run(true) })
+
+		It("should reclaim also the volumes provisioned before the feature was enabled", func() {
+			Eventually(provisioner.Deleted).Should(ConsistOf("legacy", "current"))
+		})
+	})
+})
@@ -19,13 +19,16 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
 func (p *liqoLocalStorageProvisioner) provisionLocalPVC(ctx context.Context,
@@ -39,8 +42,13 @@ func (p *liqoLocalStorageProvisioner) provisionLocalPVC(ctx context.Context,
 		},
 	}
 
+	dataSource, err := p.realDataSource(ctx, virtualPvc)
+	if err != nil {
+		return nil, controller.ProvisioningInBackground, err
+	}
+
 	if operation, err := controllerutil.CreateOrUpdate(ctx, p.client, &realPvc, func() error {
-		return p.mutateLocalRealPVC(virtualPvc, &realPvc, options.SelectedNode)
+		return p.mutateLocalRealPVC(virtualPvc, &realPvc, options.SelectedNode, dataSource)
 	}); err != nil {
 		return nil, controller.ProvisioningInBackground, err
 	} else if operation != controllerutil.OperationResultNone {
@@ -99,9 +107,9 @@ func (p *liqoLocalStorageProvisioner) provisionLocalPVC(ctx context.Context,
 // with the ones coming from the virtualPVC.
 // i.e. the PVC spec is the copy of the virtual one, but the storage class is the one set in the
 // storage provisioner or the one previously set in the PVC (since it is a read-only field). The
-// real volumeName is preserved too.
+// real volumeName is preserved too, while the data source is replaced by the given one (if any).
 func (p *liqoLocalStorageProvisioner) mutateLocalRealPVC(virtualPvc, realPvc *v1.PersistentVolumeClaim,
-	selectedNode *v1.Node) error {
+	selectedNode *v1.Node, dataSource *v1.TypedLocalObjectReference) error {
 	if realPvc.ObjectMeta.Annotations == nil {
 		realPvc.ObjectMeta.Annotations = map[string]string{}
 	}
@@ -125,9 +133,43 @@ func (p *liqoLocalStorageProvisioner) mutateLocalRealPVC(virtualPvc, realPvc *v1
 	realPvc.Spec.VolumeName = realPvName
 	realPvc.Spec.StorageClassName = storageClassName
 
+	if dataSource != nil {
+		realPvc.Spec.DataSource = dataSource
+		realPvc.Spec.DataSourceRef = &v1.TypedObjectReference{APIGroup: dataSource.APIGroup, Kind: dataSource.Kind, Name: dataSource.Name}
+	}
+
 	return nil
 }
 
+// realDataSource returns the data source of the real PVC, translating the reference to the virtual snapshot
+// the virtual PVC is restored from (if any) into the reference to the corresponding real snapshot.
+func (p *liqoLocalStorageProvisioner) realDataSource(ctx context.Context, virtualPvc *v1.PersistentVolumeClaim) (*v1.TypedLocalObjectReference, error) {
+	if !forge.IsVolumeSnapshotDataSource(virtualPvc.Spec.DataSource) {
+		return nil, nil
+	}
+
+	virtualSnapshotKey := types.NamespacedName{Namespace: virtualPvc.GetNamespace(), Name: virtualPvc.Spec.DataSource.Name}
+	virtualSnapshot := newVolumeSnapshot()
+	if err := p.client.Get(ctx, virtualSnapshotKey, virtualSnapshot); err != nil {
+		return nil, fmt.Errorf("failed to retrieve the VolumeSnapshot %q: %w", virtualSnapshotKey, err)
+	}
+
+	realSnapshot := newVolumeSnapshot()
+	realSnapshotKey := types.NamespacedName{Namespace: p.storageNamespace, Name: string(virtualSnapshot.GetUID())}
+	if err := p.client.Get(ctx, realSnapshotKey, realSnapshot); apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("the VolumeSnapshot %q is not available in the local cluster, "+
+			"hence the volume can be restored only in the cluster hosting it", virtualSnapshotKey)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &v1.TypedLocalObjectReference{
+		APIGroup: ptr.To(forge.SnapshotAPIGroup),
+		Kind:     forge.VolumeSnapshotKind,
+		Name:     realSnapshot.GetName(),
+	}, nil
+}
+
 func mergeAffinities(vol1, vol2 *v1.PersistentVolumeSpec) *v1.VolumeNodeAffinity {
 	if emptyVolumeNodeAffinity(vol1) {
 		return vol2.NodeAffinity.DeepCopy()
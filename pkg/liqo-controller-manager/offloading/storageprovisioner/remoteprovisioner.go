@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1apply "k8s.io/client-go/applyconfigurations/core/v1"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	storagev1clients "k8s.io/client-go/kubernetes/typed/storage/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
@@ -40,13 +41,9 @@ func ProvisionRemotePVC(ctx context.Context,
 	forgingOpts *forge.ForgingOpts) (*corev1.PersistentVolume, controller.ProvisioningState, error) {
 	virtualPvc := options.PVC
 
-	labels := options.SelectedNode.GetLabels()
-	if labels == nil {
-		return nil, controller.ProvisioningInBackground, fmt.Errorf("no labels found for node %s", options.SelectedNode.GetName())
-	}
-	remoteClusterID, ok := labels[consts.RemoteClusterID]
-	if !ok {
-		return nil, controller.ProvisioningInBackground, fmt.Errorf("no remote cluster ID found for node %s", options.SelectedNode.GetName())
+	remoteClusterID, err := selectedNodeClusterID(&options)
+	if err != nil {
+		return nil, controller.ProvisioningInBackground, err
 	}
 
 	// get the storage class for the remote PVC,
@@ -69,6 +66,35 @@ func ProvisionRemotePVC(ctx context.Context,
 		return nil, controller.ProvisioningInBackground, err
 	}
 
+	return virtualPersistentVolume(&options, remoteClusterID), controller.ProvisioningFinished, nil
+}
+
+// ProvisionEphemeralPVC returns a virtual PV for a PVC backing a generic ephemeral volume. Differently from ProvisionRemotePVC,
+// the remote PVC is not created, since it is created by the remote cluster along with the offloaded pod.
+func ProvisionEphemeralPVC(options controller.ProvisionOptions) (*corev1.PersistentVolume, controller.ProvisioningState, error) {
+	remoteClusterID, err := selectedNodeClusterID(&options)
+	if err != nil {
+		return nil, controller.ProvisioningInBackground, err
+	}
+
+	return virtualPersistentVolume(&options, remoteClusterID), controller.ProvisioningFinished, nil
+}
+
+// selectedNodeClusterID returns the ID of the remote cluster the selected (virtual) node refers to.
+func selectedNodeClusterID(options *controller.ProvisionOptions) (string, error) {
+	labels := options.SelectedNode.GetLabels()
+	if labels == nil {
+		return "", fmt.Errorf("no labels found for node %s", options.SelectedNode.GetName())
+	}
+	remoteClusterID, ok := labels[consts.RemoteClusterID]
+	if !ok {
+		return "", fmt.Errorf("no remote cluster ID found for node %s", options.SelectedNode.GetName())
+	}
+	return remoteClusterID, nil
+}
+
+// virtualPersistentVolume forges the virtual PV for the given PVC, bound to the nodes of the given remote cluster.
+func virtualPersistentVolume(options *controller.ProvisionOptions, remoteClusterID string) *corev1.PersistentVolume {
 	pv := &corev1.PersistentVolume{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: options.PVName,
@@ -106,7 +132,31 @@ func ProvisionRemotePVC(ctx context.Context,
 		pv.Spec.PersistentVolumeReclaimPolicy = *options.StorageClass.ReclaimPolicy
 	}
 
-	return pv, controller.ProvisioningFinished, nil
+	return pv
+}
+
+// ExpandRemotePVC propagates the storage requested by the virtual PVC to the corresponding remote one.
+// An error wrapping ErrExpansionNotSupported is returned if the remote storage class does not allow volume expansion,
+// while any other error (e.g., the remote quota being exceeded) is returned as is, for the expansion to be retried.
+func ExpandRemotePVC(ctx context.Context, virtualPvc, remotePvc *corev1.PersistentVolumeClaim,
+	remoteClassesClient storagev1clients.StorageClassInterface, remotePvcClient corev1clients.PersistentVolumeClaimInterface,
+	forgingOpts *forge.ForgingOpts) error {
+	if remotePvc.Spec.StorageClassName == nil || *remotePvc.Spec.StorageClassName == "" {
+		return fmt.Errorf("%w (the remote PVC has no storage class)", ErrExpansionNotSupported)
+	}
+	remoteStorageClass := *remotePvc.Spec.StorageClassName
+
+	class, err := remoteClassesClient.Get(ctx, remoteStorageClass, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the remote storage class %q: %w", remoteStorageClass, err)
+	}
+	if err := CheckExpansionSupported(class); err != nil {
+		return err
+	}
+
+	mutation := remotePersistentVolumeClaim(virtualPvc, remoteStorageClass, remotePvc.GetNamespace(), forgingOpts)
+	_, err = remotePvcClient.Apply(ctx, mutation, forge.ApplyOptions())
+	return err
 }
 
 // remotePersistentVolumeClaim forges the apply patch for the reflected PersistentVolumeClaim, given the local one.
@@ -135,6 +185,14 @@ func remotePersistentVolumeClaimSpec(virtualPvc *corev1.PersistentVolumeClaim,
 		res.WithStorageClassName(storageClass)
 	}
 
+	// The volume snapshots are reflected to the remote cluster with the same name, hence the reference can be preserved.
+	if forge.IsVolumeSnapshotDataSource(virtualPvc.Spec.DataSource) {
+		res.WithDataSource(v1apply.TypedLocalObjectReference().
+			WithAPIGroup(forge.SnapshotAPIGroup).
+			WithKind(forge.VolumeSnapshotKind).
+			WithName(virtualPvc.Spec.DataSource.Name))
+	}
+
 	return res
 }
 
@@ -150,6 +208,7 @@ var controllerAnnotations = []string{
 	"volume.beta.kubernetes.io/storage-provisioner",
 	"volume.kubernetes.io/storage-provisioner",
 	"volume.kubernetes.io/selected-node",
+	consts.RemoteStorageClassAnnotation,
 	corev1.BetaStorageClassAnnotation,
 }
 
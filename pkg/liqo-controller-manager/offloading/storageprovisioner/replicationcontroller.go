@@ -0,0 +1,532 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/util"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/rsync"
+)
+
+const (
+	// DefaultReplicationInterval is the default interval between subsequent replications of a virtual PVC.
+	DefaultReplicationInterval = 5 * time.Minute
+
+	replicaPvcSuffix      = "-liqo-replica"
+	replicationNameSuffix = "-liqo-replication"
+
+	replicationComponentStandby = "standby"
+	replicationComponentSource  = "source"
+	replicationComponentSync    = "sync"
+
+	replicationPasswordKey = "password"
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+// ReplicaPvcName returns the name of the standby PVC replicating the given virtual PVC.
+func ReplicaPvcName(name string) string {
+	return name + replicaPvcSuffix
+}
+
+// VolumeReplicationReconciler asynchronously replicates the virtual PVCs annotated with the target node to a standby
+// virtual PVC hosted by that node. The data is exposed by an rsync server running alongside the original volume, and
+// periodically synchronized by a job running on the target node. The time of the last successful synchronization,
+// as well as the resulting lag, are exposed as annotations of the virtual PV.
+type VolumeReplicationReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	VirtualStorageClassName string
+	RsyncImage              string
+}
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=core,resources=pods;services;secrets,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;update;patch
+
+// Reconcile ensures the replication of a virtual PVC, according to its annotations.
+func (r *VolumeReplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, req.NamespacedName, &pvc); err != nil {
+		// The replication resources are garbage collected through the owner references.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	target, enabled := pvc.GetAnnotations()[consts.ReplicationTargetAnnotation]
+	if !enabled || !pvc.GetDeletionTimestamp().IsZero() {
+		// The replication resources are removed also when the PVC is being deleted, since the rsync server would prevent its deletion.
+		if err := r.disableReplication(ctx, &pvc); err != nil {
+			klog.Errorf("Failed to disable the replication of the virtual PVC %q: %v", klog.KObj(&pvc), err)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if pvc.Spec.VolumeName == "" {
+		// The replication starts as soon as the volume has been provisioned.
+		return ctrl.Result{}, nil
+	}
+
+	interval, err := r.checkReplicable(ctx, &pvc, target)
+	if err != nil {
+		klog.Warningf("Cannot replicate the virtual PVC %q: %v", klog.KObj(&pvc), err)
+		r.Recorder.Event(&pvc, corev1.EventTypeWarning, "ReplicationFailed", err.Error())
+		// Retrying would not help, until the PVC is modified.
+		return ctrl.Result{}, nil
+	}
+
+	standby, err := r.ensureStandby(ctx, &pvc)
+	if err != nil {
+		klog.Errorf("Failed to ensure the standby PVC replicating the virtual PVC %q: %v", klog.KObj(&pvc), err)
+		return ctrl.Result{}, err
+	}
+	if standby == nil {
+		klog.V(4).Infof("Skipping the replication of the virtual PVC %q, as its standby PVC is being promoted", klog.KObj(&pvc))
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.ensureSource(ctx, &pvc); err != nil {
+		klog.Errorf("Failed to ensure the rsync server exposing the virtual PVC %q: %v", klog.KObj(&pvc), err)
+		return ctrl.Result{}, err
+	}
+
+	requeue, err := r.synchronize(ctx, &pvc, standby, target, interval)
+	if err != nil {
+		klog.Errorf("Failed to synchronize the standby PVC replicating the virtual PVC %q: %v", klog.KObj(&pvc), err)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+// checkReplicable checks whether the given PVC can be replicated to the target node, and returns the replication interval.
+func (r *VolumeReplicationReconciler) checkReplicable(ctx context.Context, pvc *corev1.PersistentVolumeClaim, target string) (time.Duration, error) {
+	if pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == corev1.PersistentVolumeBlock {
+		return 0, fmt.Errorf("block volumes cannot be replicated")
+	}
+	if pvc.GetAnnotations()[selectedNodeAnnotation] == target {
+		return 0, fmt.Errorf("the replica must be hosted by a node different from the one hosting the volume (%s)", target)
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, types.NamespacedName{Name: target}, &node); err != nil {
+		return 0, fmt.Errorf("failed to retrieve the target node %q: %w", target, err)
+	}
+
+	interval := DefaultReplicationInterval
+	if value, found := pvc.GetAnnotations()[consts.ReplicationIntervalAnnotation]; found {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("invalid replication interval %q", value)
+		}
+		interval = parsed
+	}
+	return interval, nil
+}
+
+// ensureStandby ensures the existence of the standby PVC replicating the given one, aligning the requested storage in
+// case of expansion. No PVC is returned if the standby PVC has been detached from the given one, as it is being promoted.
+func (r *VolumeReplicationReconciler) ensureStandby(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	var standby corev1.PersistentVolumeClaim
+	err := r.Get(ctx, types.NamespacedName{Namespace: pvc.GetNamespace(), Name: ReplicaPvcName(pvc.GetName())}, &standby)
+	switch {
+	case apierrors.IsNotFound(err):
+		standby = *forgeStandbyPvc(pvc)
+		if err := controllerutil.SetControllerReference(pvc, &standby, r.Scheme()); err != nil {
+			return nil, err
+		}
+		if err := r.Create(ctx, &standby); err != nil {
+			return nil, err
+		}
+		klog.Infof("Created the standby PVC %q replicating the virtual PVC %q", klog.KObj(&standby), klog.KObj(pvc))
+		r.Recorder.Eventf(pvc, corev1.EventTypeNormal, "ReplicationStarted", "Replicating the volume to the standby PVC %s", standby.GetName())
+		return &standby, nil
+	case err != nil:
+		return nil, err
+	case standby.GetLabels()[consts.ReplicationSourceLabel] != pvc.GetName():
+		return nil, nil
+	}
+
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	current := standby.Spec.Resources.Requests[corev1.ResourceStorage]
+	if requested.Cmp(current) > 0 {
+		if standby.Spec.Resources.Requests == nil {
+			standby.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		standby.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+		if err := r.Update(ctx, &standby); err != nil {
+			return nil, err
+		}
+		klog.Infof("Expanded the standby PVC %q to %v", klog.KObj(&standby), requested.String())
+	}
+	return &standby, nil
+}
+
+// ensureSource ensures the existence of the rsync server exposing the data of the given PVC, along with the service
+// making it reachable from the target node and the secret holding the corresponding password.
+func (r *VolumeReplicationReconciler) ensureSource(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	name := pvc.GetName() + replicationNameSuffix
+	labels := replicationLabels(pvc, replicationComponentSource)
+
+	secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pvc.GetNamespace(), Labels: labels},
+		Data: map[string][]byte{replicationPasswordKey: []byte(utils.RandomString(16))}}
+	if err := r.createIfNotExists(ctx, pvc, &secret); err != nil {
+		return fmt.Errorf("failed to ensure the rsync secret: %w", err)
+	}
+
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pvc.GetNamespace(), Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Port: rsync.Port, TargetPort: intstr.FromInt(rsync.Port), Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	if err := r.createIfNotExists(ctx, pvc, &svc); err != nil {
+		return fmt.Errorf("failed to ensure the rsync service: %w", err)
+	}
+
+	// The rsync server runs on the node hosting the volume, to allow mounting volumes with the ReadWriteOnce access mode.
+	node := pvc.GetAnnotations()[selectedNodeAnnotation]
+	var pod corev1.Pod
+	err := r.Get(ctx, types.NamespacedName{Namespace: pvc.GetNamespace(), Name: name}, &pod)
+	switch {
+	case apierrors.IsNotFound(err):
+		pod = *r.forgeSourcePod(pvc, name, node)
+		if err := controllerutil.SetControllerReference(pvc, &pod, r.Scheme()); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, &pod); err != nil {
+			return fmt.Errorf("failed to create the rsync server: %w", err)
+		}
+		klog.V(4).Infof("Created the rsync server %q exposing the virtual PVC %q", klog.KObj(&pod), klog.KObj(pvc))
+	case err != nil:
+		return err
+	case pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded || pod.Spec.NodeName != node:
+		// The pod is recreated once deleted, as its deletion triggers a new reconciliation.
+		if err := client.IgnoreNotFound(r.Delete(ctx, &pod)); err != nil {
+			return fmt.Errorf("failed to delete the outdated rsync server: %w", err)
+		}
+		klog.Infof("Deleted the outdated rsync server %q exposing the virtual PVC %q", klog.KObj(&pod), klog.KObj(pvc))
+	}
+	return nil
+}
+
+// synchronize tracks the synchronization jobs, starting a new one if the replication interval elapsed since the last one,
+// and exposes the time of the last successful synchronization on the virtual PV. It returns the time to wait before the next one.
+func (r *VolumeReplicationReconciler) synchronize(ctx context.Context, pvc, standby *corev1.PersistentVolumeClaim,
+	target string, interval time.Duration) (time.Duration, error) {
+	var pv corev1.PersistentVolume
+	if err := r.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+		return 0, fmt.Errorf("failed to retrieve the virtual PV: %w", err)
+	}
+
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(pvc.GetNamespace()),
+		client.MatchingLabels(replicationLabels(pvc, replicationComponentSync))); err != nil {
+		return 0, fmt.Errorf("failed to list the synchronization jobs: %w", err)
+	}
+
+	lastSync, _ := time.Parse(time.RFC3339, pv.GetAnnotations()[consts.ReplicationLastSyncAnnotation])
+	var lastSyncCompletion, lastAttempt time.Time
+	running := false
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		switch {
+		case job.Status.Succeeded > 0:
+			if job.Status.StartTime != nil && !job.Status.StartTime.Time.Before(lastSync) {
+				lastSync = job.Status.StartTime.Time
+				if job.Status.CompletionTime != nil {
+					lastSyncCompletion = job.Status.CompletionTime.Time
+				}
+			}
+		case !isJobFailed(job):
+			running = true
+		}
+		if job.GetCreationTimestamp().After(lastAttempt) {
+			lastAttempt = job.GetCreationTimestamp().Time
+		}
+	}
+
+	if err := r.updateReplicationStatus(ctx, &pv, lastSync, lastSyncCompletion); err != nil {
+		return 0, fmt.Errorf("failed to update the replication status of the virtual PV: %w", err)
+	}
+
+	if running {
+		// The completion of the job triggers a new reconciliation.
+		return 0, nil
+	}
+	if wait := time.Until(lastAttempt.Add(interval)); wait > 0 {
+		return wait, nil
+	}
+
+	// The previous jobs are removed only when starting a new one, to track the time of the last attempt.
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if isJobFailed(job) {
+			r.Recorder.Eventf(pvc, corev1.EventTypeWarning, "ReplicationFailed", "The synchronization job %s failed", job.GetName())
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))); err != nil {
+			return 0, fmt.Errorf("failed to delete the synchronization job %q: %w", klog.KObj(job), err)
+		}
+	}
+
+	job := r.forgeSyncJob(pvc, standby, target)
+	if err := controllerutil.SetControllerReference(pvc, job, r.Scheme()); err != nil {
+		return 0, err
+	}
+	if err := r.Create(ctx, job); err != nil {
+		return 0, fmt.Errorf("failed to create the synchronization job: %w", err)
+	}
+	klog.V(4).Infof("Started the synchronization job %q replicating the virtual PVC %q", klog.KObj(job), klog.KObj(pvc))
+	return 0, nil
+}
+
+// updateReplicationStatus exposes the start time of the last successful synchronization on the virtual PV, as well as the
+// replication lag measured at its completion (if known), which does not depend on the time the virtual PV is observed.
+func (r *VolumeReplicationReconciler) updateReplicationStatus(ctx context.Context, pv *corev1.PersistentVolume,
+	lastSync, lastSyncCompletion time.Time) error {
+	if lastSync.IsZero() {
+		return nil
+	}
+
+	original := pv.DeepCopy()
+	if pv.Annotations == nil {
+		pv.Annotations = map[string]string{}
+	}
+	pv.Annotations[consts.ReplicationLastSyncAnnotation] = lastSync.UTC().Format(time.RFC3339)
+	if !lastSyncCompletion.IsZero() {
+		pv.Annotations[consts.ReplicationLagAnnotation] = lastSyncCompletion.Sub(lastSync).Round(time.Second).String()
+	}
+	if maps.Equal(original.Annotations, pv.Annotations) {
+		return nil
+	}
+	return r.Patch(ctx, pv, client.MergeFrom(original))
+}
+
+// disableReplication removes the resources replicating the given PVC, as well as the replication status of the virtual PV.
+func (r *VolumeReplicationReconciler) disableReplication(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	lists := []client.ObjectList{&batchv1.JobList{}, &corev1.PodList{}, &corev1.ServiceList{}, &corev1.SecretList{}, &corev1.PersistentVolumeClaimList{}}
+	for _, list := range lists {
+		if err := r.List(ctx, list, client.InNamespace(pvc.GetNamespace()),
+			client.MatchingLabels{consts.ReplicationSourceLabel: pvc.GetName()}); err != nil {
+			return err
+		}
+		if err := meta.EachListItem(list, func(item runtime.Object) error {
+			obj := item.(client.Object)
+			if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			klog.V(4).Infof("Deleted %q, as the replication of the virtual PVC %q is disabled", klog.KObj(obj), klog.KObj(pvc))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if pvc.Spec.VolumeName == "" {
+		return nil
+	}
+
+	var pv corev1.PersistentVolume
+	if err := r.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	original := pv.DeepCopy()
+	delete(pv.Annotations, consts.ReplicationLastSyncAnnotation)
+	delete(pv.Annotations, consts.ReplicationLagAnnotation)
+	if maps.Equal(original.Annotations, pv.Annotations) {
+		return nil
+	}
+	return r.Patch(ctx, &pv, client.MergeFrom(original))
+}
+
+// createIfNotExists creates the given object, controlled by the given PVC, unless it already exists.
+func (r *VolumeReplicationReconciler) createIfNotExists(ctx context.Context, pvc *corev1.PersistentVolumeClaim, obj client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopyObject().(client.Object)); !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(pvc, obj, r.Scheme()); err != nil {
+		return err
+	}
+	return client.IgnoreAlreadyExists(r.Create(ctx, obj))
+}
+
+// forgeStandbyPvc forges the standby PVC replicating the given one, mirroring its characteristics.
+func forgeStandbyPvc(pvc *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaim {
+	labels := maps.Clone(pvc.GetLabels())
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	maps.Copy(labels, replicationLabels(pvc, replicationComponentStandby))
+
+	annotations := map[string]string{}
+	if class, found := pvc.GetAnnotations()[consts.RemoteStorageClassAnnotation]; found {
+		annotations[consts.RemoteStorageClassAnnotation] = class
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ReplicaPvcName(pvc.GetName()),
+			Namespace:   pvc.GetNamespace(),
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        pvc.Spec.Resources,
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+		},
+	}
+}
+
+// forgeSourcePod forges the rsync server exposing (read-only) the data of the given PVC.
+func (r *VolumeReplicationReconciler) forgeSourcePod(pvc *corev1.PersistentVolumeClaim, name, node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pvc.GetNamespace(),
+			Labels:    replicationLabels(pvc, replicationComponentSource),
+		},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name:            "rsync",
+				Image:           r.RsyncImage,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", rsync.DaemonScript()},
+				Env:             []corev1.EnvVar{replicationPasswordEnv(name)},
+				Ports:           []corev1.ContainerPort{{ContainerPort: rsync.Port}},
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(rsync.Port)}},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: "source", MountPath: rsync.MountPath, ReadOnly: true}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "source",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName(), ReadOnly: true},
+				},
+			}},
+		},
+	}
+}
+
+// forgeSyncJob forges the job synchronizing the standby PVC from the rsync server, running on the target node.
+func (r *VolumeReplicationReconciler) forgeSyncJob(pvc, standby *corev1.PersistentVolumeClaim, target string) *batchv1.Job {
+	name := pvc.GetName() + replicationNameSuffix
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + "-",
+			Namespace:    pvc.GetNamespace(),
+			Labels:       replicationLabels(pvc, replicationComponentSync),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](3),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+									MatchExpressions: []corev1.NodeSelectorRequirement{{
+										Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{target},
+									}},
+								}},
+							},
+						},
+					},
+					Containers: []corev1.Container{{
+						Name:            "rsync",
+						Image:           r.RsyncImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						// The rsync service is resolved through the search domains, as it lives in the same namespace
+						// (possibly remapped in the target cluster) of the job.
+						Command:      rsync.ClientCommand(name),
+						Env:          []corev1.EnvVar{replicationPasswordEnv(name)},
+						VolumeMounts: []corev1.VolumeMount{{Name: "standby", MountPath: rsync.MountPath}},
+					}},
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Volumes: []corev1.Volume{{
+						Name: "standby",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: standby.GetName()},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// replicationPasswordEnv returns the environment variable holding the rsync password, retrieved from the given secret.
+func replicationPasswordEnv(secret string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: rsync.PasswordEnv,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: replicationPasswordKey,
+		}},
+	}
+}
+
+// replicationLabels returns the labels identifying the resources with the given role replicating the given PVC.
+func replicationLabels(pvc *corev1.PersistentVolumeClaim, component string) map[string]string {
+	return map[string]string{consts.ReplicationSourceLabel: pvc.GetName(), consts.ReplicationComponentLabel: component}
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for i := range job.Status.Conditions {
+		if job.Status.Conditions[i].Type == batchv1.JobFailed && job.Status.Conditions[i].Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager monitors the virtual PVCs, as well as the resources replicating them.
+func (r *VolumeReplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	virtualPvcs := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		return ok && util.GetPersistentVolumeClaimClass(pvc) == r.VirtualStorageClassName
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlVolumeReplication).
+		For(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(virtualPvcs)).
+		Owns(&batchv1.Job{}).
+		Owns(&corev1.Pod{}).
+		Complete(r)
+}
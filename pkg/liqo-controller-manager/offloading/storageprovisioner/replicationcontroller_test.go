@@ -0,0 +1,230 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("Volume replication", func() {
+
+	const (
+		virtualStorageClassName = "liqo"
+		namespace               = "foo"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		recorder   *record.FakeRecorder
+		reconciler *VolumeReplicationReconciler
+
+		pvc     *corev1.PersistentVolumeClaim
+		pv      *corev1.PersistentVolume
+		objects []client.Object
+		res     ctrl.Result
+		err     error
+	)
+
+	replicationKey := types.NamespacedName{Namespace: namespace, Name: "data-liqo-replication"}
+	standbyKey := types.NamespacedName{Namespace: namespace, Name: "data-liqo-replica"}
+
+	listSyncJobs := func() []batchv1.Job {
+		var jobs batchv1.JobList
+		Expect(cl.List(ctx, &jobs, client.InNamespace(namespace))).To(Succeed())
+		return jobs.Items
+	}
+
+	const syncDuration = 30 * time.Second
+	forgeSyncJob := func(name string, created time.Time, succeeded bool) *batchv1.Job {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace,
+			CreationTimestamp: metav1.NewTime(created), Labels: replicationLabels(pvc, replicationComponentSync)}}
+		if succeeded {
+			job.Status = batchv1.JobStatus{Succeeded: 1, StartTime: ptr.To(metav1.NewTime(created)),
+				CompletionTime: ptr.To(metav1.NewTime(created.Add(syncDuration)))}
+		}
+		return job
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: namespace, UID: "data-uid",
+				Labels: map[string]string{"app": "db"},
+				Annotations: map[string]string{
+					consts.ReplicationTargetAnnotation: "replica-node",
+					selectedNodeAnnotation:             "source-node",
+				}},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To(virtualStorageClassName),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+				VolumeName: "pv",
+			},
+		}
+		pv = &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv"}}
+		objects = []client.Object{pv, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "replica-node"}}}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+		cl = ctrlfake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, pvc)...).Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &VolumeReplicationReconciler{Client: cl, Recorder: recorder,
+			VirtualStorageClassName: virtualStorageClassName, RsyncImage: "rsync"}
+
+		res, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pvc)})
+	})
+
+	When("the replication has just been requested", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+		It("should create the standby PVC", func() {
+			var standby corev1.PersistentVolumeClaim
+			Expect(cl.Get(ctx, standbyKey, &standby)).To(Succeed())
+			Expect(standby.Labels).To(HaveKeyWithValue("app", "db"))
+			Expect(standby.Labels).To(HaveKeyWithValue(consts.ReplicationSourceLabel, "data"))
+			Expect(standby.Spec.StorageClassName).To(Equal(ptr.To(virtualStorageClassName)))
+			Expect(standby.Spec.VolumeName).To(BeEmpty())
+			Expect(standby.OwnerReferences).To(ConsistOf(HaveField("UID", pvc.UID)))
+		})
+
+		It("should create the rsync server on the node hosting the volume", func() {
+			var pod corev1.Pod
+			Expect(cl.Get(ctx, replicationKey, &pod)).To(Succeed())
+			Expect(pod.Spec.NodeName).To(Equal("source-node"))
+			Expect(pod.Spec.Volumes).To(ConsistOf(HaveField("VolumeSource.PersistentVolumeClaim",
+				Equal(&corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data", ReadOnly: true}))))
+
+			var svc corev1.Service
+			Expect(cl.Get(ctx, replicationKey, &svc)).To(Succeed())
+			Expect(svc.Spec.Selector).To(Equal(pod.Labels))
+			Expect(cl.Get(ctx, replicationKey, &corev1.Secret{})).To(Succeed())
+		})
+
+		It("should start the synchronization job on the target node", func() {
+			jobs := listSyncJobs()
+			Expect(jobs).To(HaveLen(1))
+			podSpec := &jobs[0].Spec.Template.Spec
+			Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(
+				ConsistOf(HaveField("MatchExpressions", ConsistOf(HaveField("Values", ConsistOf("replica-node"))))))
+			Expect(podSpec.Containers[0].Command).To(ContainElement("rsync://liqo@data-liqo-replication:873/data/"))
+			Expect(podSpec.Volumes).To(ConsistOf(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName", "data-liqo-replica")))
+		})
+	})
+
+	When("a synchronization job recently succeeded", func() {
+		started := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+		BeforeEach(func() {
+			objects = append(objects, forgeSyncJob("sync", started, true))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should expose the replication status on the virtual PV", func() {
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(pv), pv)).To(Succeed())
+			Expect(pv.Annotations).To(HaveKeyWithValue(consts.ReplicationLastSyncAnnotation, started.UTC().Format(time.RFC3339)))
+			Expect(pv.Annotations).To(HaveKeyWithValue(consts.ReplicationLagAnnotation, syncDuration.String()))
+		})
+		It("should wait for the replication interval to elapse", func() {
+			Expect(listSyncJobs()).To(ConsistOf(HaveField("Name", "sync")))
+			Expect(res.RequeueAfter).To(BeNumerically("~", DefaultReplicationInterval-time.Minute, 5*time.Second))
+		})
+	})
+
+	When("the replication interval elapsed since the last synchronization", func() {
+		BeforeEach(func() {
+			objects = append(objects, forgeSyncJob("sync", time.Now().Add(-DefaultReplicationInterval-time.Minute), true))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should replace the previous synchronization job", func() {
+			jobs := listSyncJobs()
+			Expect(jobs).To(HaveLen(1))
+			Expect(jobs[0].Name).ToNot(Equal("sync"))
+		})
+	})
+
+	When("the replica would be hosted by the same node of the volume", func() {
+		BeforeEach(func() { pvc.Annotations[selectedNodeAnnotation] = "replica-node" })
+
+		It("should not fail", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should record a warning event", func() { Expect(recorder.Events).To(Receive(ContainSubstring("ReplicationFailed"))) })
+		It("should not create the standby PVC", func() {
+			Expect(cl.Get(ctx, standbyKey, &corev1.PersistentVolumeClaim{})).To(BeNotFound())
+		})
+	})
+
+	When("the standby PVC is being promoted", func() {
+		BeforeEach(func() {
+			objects = append(objects, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+				Name: standbyKey.Name, Namespace: namespace}})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not synchronize it", func() {
+			Expect(cl.Get(ctx, replicationKey, &corev1.Pod{})).To(BeNotFound())
+			Expect(listSyncJobs()).To(BeEmpty())
+		})
+	})
+
+	When("the replication is disabled", func() {
+		BeforeEach(func() {
+			delete(pvc.Annotations, consts.ReplicationTargetAnnotation)
+			pv.Annotations = map[string]string{consts.ReplicationLastSyncAnnotation: "2024-01-01T00:00:00Z",
+				consts.ReplicationLagAnnotation: "30s"}
+
+			standby := forgeStandbyPvc(pvc)
+			objects = append(objects, standby, forgeSyncJob("sync", time.Now(), true),
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: replicationKey.Name, Namespace: namespace,
+					Labels: replicationLabels(pvc, replicationComponentSource)}})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should remove the replication resources", func() {
+			Expect(cl.Get(ctx, standbyKey, &corev1.PersistentVolumeClaim{})).To(BeNotFound())
+			Expect(cl.Get(ctx, replicationKey, &corev1.Pod{})).To(BeNotFound())
+			Expect(listSyncJobs()).To(BeEmpty())
+		})
+		It("should remove the replication status from the virtual PV", func() {
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(pv), pv)).To(Succeed())
+			Expect(pv.Annotations).ToNot(HaveKey(consts.ReplicationLastSyncAnnotation))
+			Expect(pv.Annotations).ToNot(HaveKey(consts.ReplicationLagAnnotation))
+		})
+	})
+})
@@ -0,0 +1,122 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ErrExpansionNotSupported is returned when the storage class backing a PVC does not allow volume expansion.
+var ErrExpansionNotSupported = errors.New("the storage class does not support volume expansion")
+
+// resizeConditions are the PVC conditions mirrored from the real PVC to the virtual one during an expansion.
+var resizeConditions = []corev1.PersistentVolumeClaimConditionType{
+	corev1.PersistentVolumeClaimResizing,
+	corev1.PersistentVolumeClaimFileSystemResizePending,
+	corev1.PersistentVolumeClaimControllerResizeError,
+	corev1.PersistentVolumeClaimNodeResizeError,
+}
+
+// ResizeRequested returns whether the storage requested by the virtual PVC exceeds the one requested by the real PVC.
+func ResizeRequested(virtualPvc, realPvc *corev1.PersistentVolumeClaim) bool {
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	current := realPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	return requested.Cmp(current) > 0
+}
+
+// CheckExpansionSupported returns an error wrapping ErrExpansionNotSupported if the given class does not allow volume expansion.
+func CheckExpansionSupported(class *storagev1.StorageClass) error {
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return fmt.Errorf("%w (class %q)", ErrExpansionNotSupported, class.GetName())
+	}
+	return nil
+}
+
+// ResizeStatus returns the status of the virtual PVC, updated according to the capacity and the resize conditions of the real one.
+// The capacity is only ever increased, while the resize conditions are mirrored until the virtual PVC reaches the requested size.
+// The second return value reports whether the status changed.
+func ResizeStatus(virtualPvc, realPvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaimStatus, bool) {
+	status := virtualPvc.Status.DeepCopy()
+	changed := false
+
+	if realCapacity, ok := realPvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		if current := status.Capacity[corev1.ResourceStorage]; realCapacity.Cmp(current) > 0 {
+			if status.Capacity == nil {
+				status.Capacity = corev1.ResourceList{}
+			}
+			status.Capacity[corev1.ResourceStorage] = realCapacity
+			changed = true
+		}
+	}
+
+	var conditions []corev1.PersistentVolumeClaimCondition
+	for i := range status.Conditions {
+		if !isResizeCondition(status.Conditions[i].Type) {
+			conditions = append(conditions, status.Conditions[i])
+		}
+	}
+
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if capacity := status.Capacity[corev1.ResourceStorage]; capacity.Cmp(requested) < 0 {
+		for i := range realPvc.Status.Conditions {
+			if isResizeCondition(realPvc.Status.Conditions[i].Type) {
+				conditions = append(conditions, realPvc.Status.Conditions[i])
+			}
+		}
+	}
+
+	if !equalConditions(status.Conditions, conditions) {
+		status.Conditions = conditions
+		changed = true
+	}
+
+	return status, changed
+}
+
+// ExpandVolume increases the capacity of the given virtual PV up to the given one, and returns whether it was modified.
+func ExpandVolume(pv *corev1.PersistentVolume, capacity resource.Quantity) bool {
+	if current := pv.Spec.Capacity[corev1.ResourceStorage]; capacity.Cmp(current) <= 0 {
+		return false
+	}
+
+	if pv.Spec.Capacity == nil {
+		pv.Spec.Capacity = corev1.ResourceList{}
+	}
+	pv.Spec.Capacity[corev1.ResourceStorage] = capacity
+	return true
+}
+
+func isResizeCondition(condition corev1.PersistentVolumeClaimConditionType) bool {
+	return slices.Contains(resizeConditions, condition)
+}
+
+func equalConditions(c1, c2 []corev1.PersistentVolumeClaimCondition) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+	for i := range c1 {
+		if c1[i].Type != c2[i].Type || c1[i].Status != c2[i].Status ||
+			c1[i].Reason != c2[i].Reason || c1[i].Message != c2[i].Message {
+			return false
+		}
+	}
+	return true
+}
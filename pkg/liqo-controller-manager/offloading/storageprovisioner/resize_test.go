@@ -0,0 +1,288 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutil "github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("Volume expansion", func() {
+
+	const (
+		virtualStorageClassName = "liqo"
+		realStorageClassName    = "real"
+		storageNamespace        = "liqo-storage"
+	)
+
+	forgePvc := func(namespace, name, class, volume, requested string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "virtual-uid"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To(class),
+				VolumeName:       volume,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		}
+	}
+
+	forgePv := func(name, capacity string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+			},
+		}
+	}
+
+	Describe("the ResizeStatus function", func() {
+		var (
+			virtualPvc, realPvc *corev1.PersistentVolumeClaim
+			status              *corev1.PersistentVolumeClaimStatus
+			changed             bool
+		)
+
+		BeforeEach(func() {
+			virtualPvc = forgePvc("foo", "bar", virtualStorageClassName, "virtual-pv", "2Gi")
+			realPvc = forgePvc(storageNamespace, "virtual-uid", realStorageClassName, "real-pv", "2Gi")
+		})
+
+		JustBeforeEach(func() { status, changed = ResizeStatus(virtualPvc, realPvc) })
+
+		When("the real volume is waiting for the file system expansion", func() {
+			BeforeEach(func() {
+				realPvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+					Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue}}
+			})
+
+			It("should report a change", func() { Expect(changed).To(BeTrue()) })
+			It("should mirror the condition", func() {
+				Expect(status.Conditions).To(ConsistOf(HaveField("Type", corev1.PersistentVolumeClaimFileSystemResizePending)))
+			})
+			It("should not modify the capacity", func() {
+				Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("1Gi")))
+			})
+		})
+
+		When("the real volume has been expanded", func() {
+			BeforeEach(func() {
+				realPvc.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("2Gi")
+				realPvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+					Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue}}
+				virtualPvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+					Type: corev1.PersistentVolumeClaimResizing, Status: corev1.ConditionTrue}}
+			})
+
+			It("should report a change", func() { Expect(changed).To(BeTrue()) })
+			It("should increase the capacity", func() {
+				Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+			})
+			It("should remove the resize conditions", func() { Expect(status.Conditions).To(BeEmpty()) })
+		})
+
+		When("nothing changed", func() {
+			It("should not report a change", func() { Expect(changed).To(BeFalse()) })
+		})
+	})
+
+	Describe("the VolumeResizeReconciler", func() {
+		var (
+			ctx        context.Context
+			cl         client.Client
+			recorder   *record.FakeRecorder
+			reconciler *VolumeResizeReconciler
+
+			virtualPvc, realPvc *corev1.PersistentVolumeClaim
+			allowExpansion      bool
+			err                 error
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			allowExpansion = true
+			virtualPvc = forgePvc("foo", "bar", virtualStorageClassName, "virtual-pv", "2Gi")
+			realPvc = forgePvc(storageNamespace, "virtual-uid", realStorageClassName, "real-pv", "1Gi")
+		})
+
+		JustBeforeEach(func() {
+			class := &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: realStorageClassName},
+				AllowVolumeExpansion: ptr.To(allowExpansion),
+			}
+
+			cl = ctrlfake.NewClientBuilder().WithScheme(scheme.Scheme).
+				WithObjects(class, virtualPvc, realPvc, forgePv("virtual-pv", "1Gi"), forgePv("real-pv", "1Gi")).
+				WithStatusSubresource(&corev1.PersistentVolumeClaim{}).Build()
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &VolumeResizeReconciler{Client: cl, Recorder: recorder,
+				VirtualStorageClassName: virtualStorageClassName, StorageNamespace: storageNamespace}
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(virtualPvc)})
+		})
+
+		When("the real storage class allows volume expansion", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should expand the real PVC", func() {
+				var pvc corev1.PersistentVolumeClaim
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(realPvc), &pvc)).To(Succeed())
+				Expect(pvc.Spec.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+			})
+		})
+
+		When("the real storage class does not allow volume expansion", func() {
+			BeforeEach(func() { allowExpansion = false })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not expand the real PVC", func() {
+				var pvc corev1.PersistentVolumeClaim
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(realPvc), &pvc)).To(Succeed())
+				Expect(pvc.Spec.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("1Gi")))
+			})
+			It("should record a warning event", func() {
+				Expect(recorder.Events).To(Receive(ContainSubstring("VolumeResizeFailed")))
+			})
+		})
+
+		When("the real volume has been expanded", func() {
+			BeforeEach(func() {
+				realPvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+				realPvc.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("2Gi")
+			})
+
+			JustBeforeEach(func() {
+				var pv corev1.PersistentVolume
+				Expect(cl.Get(ctx, types.NamespacedName{Name: "real-pv"}, &pv)).To(Succeed())
+				pv.Spec.Capacity[corev1.ResourceStorage] = resource.MustParse("2Gi")
+				Expect(cl.Update(ctx, &pv)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(virtualPvc)})
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should expand the virtual PV", func() {
+				var pv corev1.PersistentVolume
+				Expect(cl.Get(ctx, types.NamespacedName{Name: "virtual-pv"}, &pv)).To(Succeed())
+				Expect(pv.Spec.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+			})
+			It("should update the capacity of the virtual PVC", func() {
+				var pvc corev1.PersistentVolumeClaim
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(virtualPvc), &pvc)).To(Succeed())
+				Expect(pvc.Status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+			})
+		})
+	})
+
+	Describe("the ExpandRemotePVC function", func() {
+		const remoteNamespace = "remote"
+
+		var (
+			ctx         context.Context
+			clientset   *fake.Clientset
+			remoteClass *storagev1.StorageClass
+			virtualPvc  *corev1.PersistentVolumeClaim
+			remotePvc   *corev1.PersistentVolumeClaim
+			err         error
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			remoteClass = &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: realStorageClassName},
+				AllowVolumeExpansion: ptr.To(true),
+			}
+			virtualPvc = forgePvc("default", "pvc", virtualStorageClassName, "virtual-pv", "2Gi")
+			remotePvc = forgePvc(remoteNamespace, "pvc", realStorageClassName, "real-pv", "1Gi")
+		})
+
+		JustBeforeEach(func() {
+			clientset = fake.NewClientset(remoteClass, remotePvc)
+		})
+
+		expand := func() error {
+			return ExpandRemotePVC(ctx, virtualPvc, remotePvc, clientset.StorageV1().StorageClasses(),
+				clientset.CoreV1().PersistentVolumeClaims(remoteNamespace), testutil.FakeForgingOpts())
+		}
+
+		When("the remote storage class allows volume expansion", func() {
+			JustBeforeEach(func() { err = expand() })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should expand the remote PVC", func() {
+				pvc, err := clientset.CoreV1().PersistentVolumeClaims(remoteNamespace).Get(ctx, "pvc", metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pvc.Spec.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+			})
+		})
+
+		When("the remote storage class does not allow volume expansion", func() {
+			BeforeEach(func() { remoteClass.AllowVolumeExpansion = ptr.To(false) })
+			JustBeforeEach(func() { err = expand() })
+
+			It("should fail permanently", func() { Expect(err).To(MatchError(ErrExpansionNotSupported)) })
+		})
+
+		When("the remote API server refuses the expansion", func() {
+			JustBeforeEach(func() {
+				clientset.PrependReactor("patch", "persistentvolumeclaims", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "persistentvolumeclaims"}, "pvc",
+						errors.New("exceeded quota"))
+				})
+				err = expand()
+			})
+
+			It("should fail with a retriable error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).ToNot(MatchError(ErrExpansionNotSupported))
+			})
+		})
+
+		When("the remote storage class cannot be retrieved", func() {
+			BeforeEach(func() { remoteClass.Name = "other" })
+			JustBeforeEach(func() { err = expand() })
+
+			It("should fail with a retriable error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).ToNot(MatchError(ErrExpansionNotSupported))
+			})
+		})
+	})
+})
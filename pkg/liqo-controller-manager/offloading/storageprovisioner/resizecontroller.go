@@ -0,0 +1,168 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/util"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// VolumeResizeReconciler forwards the expansion of the virtual PVCs to the real PVCs backing them in the local cluster,
+// and reflects the resulting capacity and resize conditions back to the virtual PVCs and PVs.
+type VolumeResizeReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	VirtualStorageClassName string
+	StorageNamespace        string
+}
+
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims/status,verbs=get;update;patch
+
+// Reconcile propagates the storage requested by a virtual PVC to the corresponding real one.
+func (r *VolumeResizeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var virtualPvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, req.NamespacedName, &virtualPvc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if util.GetPersistentVolumeClaimClass(&virtualPvc) != r.VirtualStorageClassName || virtualPvc.Spec.VolumeName == "" {
+		return ctrl.Result{}, nil
+	}
+
+	var realPvc corev1.PersistentVolumeClaim
+	realPvcKey := types.NamespacedName{Namespace: r.StorageNamespace, Name: string(virtualPvc.GetUID())}
+	if err := r.Get(ctx, realPvcKey, &realPvc); err != nil {
+		// The real PVC does not exist if the volume has been provisioned in a remote cluster.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if ResizeRequested(&virtualPvc, &realPvc) {
+		return ctrl.Result{}, r.expand(ctx, &virtualPvc, &realPvc)
+	}
+
+	if realPvc.Spec.VolumeName != "" {
+		if err := r.expandVirtualVolume(ctx, virtualPvc.Spec.VolumeName, realPvc.Spec.VolumeName); err != nil {
+			klog.Errorf("Failed to update the capacity of the virtual PV %q: %v", virtualPvc.Spec.VolumeName, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if status, changed := ResizeStatus(&virtualPvc, &realPvc); changed {
+		virtualPvc.Status = *status
+		if err := r.Status().Update(ctx, &virtualPvc); err != nil {
+			klog.Errorf("Failed to update the status of the virtual PVC %q: %v", klog.KObj(&virtualPvc), err)
+			return ctrl.Result{}, err
+		}
+		klog.V(4).Infof("Reflected the resize status of the real PVC %q to the virtual PVC %q", klog.KObj(&realPvc), klog.KObj(&virtualPvc))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// expand updates the storage requested by the real PVC, provided that its storage class allows for volume expansion.
+func (r *VolumeResizeReconciler) expand(ctx context.Context, virtualPvc, realPvc *corev1.PersistentVolumeClaim) error {
+	if err := r.checkExpansionSupported(ctx, realPvc); err != nil {
+		klog.Warningf("Cannot expand the virtual PVC %q: %v", klog.KObj(virtualPvc), err)
+		r.Recorder.Event(virtualPvc, corev1.EventTypeWarning, "VolumeResizeFailed", err.Error())
+		// Retrying would not help, as the storage class cannot be changed.
+		return nil
+	}
+
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if realPvc.Spec.Resources.Requests == nil {
+		realPvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	realPvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+	if err := r.Update(ctx, realPvc); err != nil {
+		klog.Errorf("Failed to expand the real PVC %q: %v", klog.KObj(realPvc), err)
+		r.Recorder.Event(virtualPvc, corev1.EventTypeWarning, "VolumeResizeFailed", err.Error())
+		return err
+	}
+
+	klog.Infof("Expanding the real PVC %q to %v, as requested by the virtual PVC %q", klog.KObj(realPvc), requested.String(), klog.KObj(virtualPvc))
+	r.Recorder.Eventf(virtualPvc, corev1.EventTypeNormal, "Resizing", "Expanding the backing volume to %v", requested.String())
+	return nil
+}
+
+func (r *VolumeResizeReconciler) checkExpansionSupported(ctx context.Context, realPvc *corev1.PersistentVolumeClaim) error {
+	className := util.GetPersistentVolumeClaimClass(realPvc)
+	if className == "" {
+		return fmt.Errorf("%w (the real PVC %q has no storage class)", ErrExpansionNotSupported, klog.KObj(realPvc))
+	}
+
+	var class storagev1.StorageClass
+	if err := r.Get(ctx, types.NamespacedName{Name: className}, &class); err != nil {
+		return fmt.Errorf("failed to retrieve the storage class %q: %w", className, err)
+	}
+	return CheckExpansionSupported(&class)
+}
+
+// expandVirtualVolume aligns the capacity of the virtual PV to the one of the real PV.
+func (r *VolumeResizeReconciler) expandVirtualVolume(ctx context.Context, virtualPvName, realPvName string) error {
+	var realPv, virtualPv corev1.PersistentVolume
+	if err := r.Get(ctx, types.NamespacedName{Name: realPvName}, &realPv); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: virtualPvName}, &virtualPv); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !ExpandVolume(&virtualPv, realPv.Spec.Capacity[corev1.ResourceStorage]) {
+		return nil
+	}
+	return r.Update(ctx, &virtualPv)
+}
+
+// SetupWithManager monitors the virtual PVCs, as well as the real ones backing them.
+func (r *VolumeResizeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	virtualPvcs := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		return ok && util.GetPersistentVolumeClaimClass(pvc) == r.VirtualStorageClassName
+	})
+
+	// Real PVCs are mapped to the corresponding virtual ones through the labels set at provisioning time.
+	enqueuer := func(_ context.Context, obj client.Object) []reconcile.Request {
+		if obj.GetNamespace() != r.StorageNamespace {
+			return nil
+		}
+		namespace, nsok := obj.GetLabels()[consts.VirtualPvcNamespaceLabel]
+		name, nameok := obj.GetLabels()[consts.VirtualPvcNameLabel]
+		if !nsok || !nameok {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlVolumeResize).
+		For(&corev1.PersistentVolumeClaim{}, builder.WithPredicates(virtualPvcs)).
+		Watches(&corev1.PersistentVolumeClaim{}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
+		Complete(r)
+}
@@ -0,0 +1,244 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+// VolumeSnapshotReconciler takes the snapshots of the virtual PVCs backed by a real PVC in the local cluster,
+// creating a snapshot of the real PVC and reflecting its readiness and size to the VolumeSnapshotContent pre-bound
+// to the virtual snapshot, from which the snapshot controller propagates them to the virtual snapshot itself.
+// The snapshots of the virtual PVCs provisioned in a remote cluster are handled by the corresponding virtual kubelet.
+type VolumeSnapshotReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	VirtualStorageClassName string
+	StorageNamespace        string
+}
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete;update;patch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;create;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents/status,verbs=get;update;patch
+
+// Reconcile ensures the existence of the real snapshot corresponding to a virtual one, and reflects its status.
+func (r *VolumeSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	virtualSnapshot := newVolumeSnapshot()
+	if err := r.Get(ctx, req.NamespacedName, virtualSnapshot); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if forge.VolumeSnapshotClassName(virtualSnapshot) != r.VirtualStorageClassName {
+		return ctrl.Result{}, nil
+	}
+
+	realSnapshotKey := types.NamespacedName{Namespace: r.StorageNamespace, Name: string(virtualSnapshot.GetUID())}
+
+	if !virtualSnapshot.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(virtualSnapshot, consts.VolumeSnapshotFinalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		realSnapshot := newVolumeSnapshot()
+		realSnapshot.SetName(realSnapshotKey.Name)
+		realSnapshot.SetNamespace(realSnapshotKey.Namespace)
+		if err := client.IgnoreNotFound(r.Delete(ctx, realSnapshot)); err != nil {
+			klog.Errorf("Failed to delete the real VolumeSnapshot %q: %v", realSnapshotKey, err)
+			return ctrl.Result{}, err
+		}
+
+		// The content is deleted by the snapshot controller as well, in case it is running.
+		content := newVolumeSnapshotContent()
+		content.SetName(forge.VolumeSnapshotContentName(virtualSnapshot))
+		if err := client.IgnoreNotFound(r.Delete(ctx, content)); err != nil {
+			klog.Errorf("Failed to delete the VolumeSnapshotContent %q: %v", content.GetName(), err)
+			return ctrl.Result{}, err
+		}
+
+		controllerutil.RemoveFinalizer(virtualSnapshot, consts.VolumeSnapshotFinalizer)
+		if err := r.Update(ctx, virtualSnapshot); err != nil {
+			klog.Errorf("Failed to remove the finalizer from the virtual VolumeSnapshot %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+		klog.Infof("Deleted the real VolumeSnapshot %q, as the virtual VolumeSnapshot %q is being deleted", realSnapshotKey, req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	claimName, found := forge.VolumeSnapshotSource(virtualSnapshot)
+	if !found {
+		// Pre-provisioned snapshots (i.e., targeting an existing VolumeSnapshotContent) are not supported.
+		return ctrl.Result{}, nil
+	}
+
+	// The real PVC exists only if the volume has been provisioned in the local cluster.
+	var virtualPvc, realPvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: claimName}, &virtualPvc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: r.StorageNamespace, Name: string(virtualPvc.GetUID())}, &realPvc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !controllerutil.ContainsFinalizer(virtualSnapshot, consts.VolumeSnapshotFinalizer) {
+		controllerutil.AddFinalizer(virtualSnapshot, consts.VolumeSnapshotFinalizer)
+		if err := r.Update(ctx, virtualSnapshot); err != nil {
+			klog.Errorf("Failed to add the finalizer to the virtual VolumeSnapshot %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	content, err := r.ensureVolumeSnapshotContent(ctx, virtualSnapshot)
+	if err != nil {
+		klog.Errorf("Failed to ensure the VolumeSnapshotContent of the virtual VolumeSnapshot %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+	if content == nil {
+		return ctrl.Result{}, nil
+	}
+
+	realSnapshot := newVolumeSnapshot()
+	if err := r.Get(ctx, realSnapshotKey, realSnapshot); apierrors.IsNotFound(err) {
+		realSnapshot = forgeRealVolumeSnapshot(virtualSnapshot, realSnapshotKey, realPvc.GetName())
+		if err := r.Create(ctx, realSnapshot); err != nil {
+			klog.Errorf("Failed to create the real VolumeSnapshot %q: %v", realSnapshotKey, err)
+			r.Recorder.Event(virtualSnapshot, corev1.EventTypeWarning, "SnapshotCreationFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+		klog.Infof("Created the real VolumeSnapshot %q for the virtual VolumeSnapshot %q", realSnapshotKey, req.NamespacedName)
+		r.Recorder.Event(virtualSnapshot, corev1.EventTypeNormal, "SnapshotCreated", "Created the snapshot of the backing volume")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if updated, changed := forge.VirtualVolumeSnapshotContentStatus(content, realSnapshot, realSnapshotKey.String()); changed {
+		if err := r.Status().Update(ctx, updated); err != nil {
+			klog.Errorf("Failed to update the status of the VolumeSnapshotContent %q: %v", content.GetName(), err)
+			return ctrl.Result{}, err
+		}
+		klog.V(4).Infof("Reflected the status of the real VolumeSnapshot %q to the VolumeSnapshotContent %q", realSnapshotKey, content.GetName())
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ensureVolumeSnapshotContent ensures the existence of the VolumeSnapshotContent pre-bound to the given virtual snapshot, and returns it.
+// Nil is returned in case the content has been created by a different driver, hence it shall not be managed by Liqo.
+func (r *VolumeSnapshotReconciler) ensureVolumeSnapshotContent(ctx context.Context,
+	virtualSnapshot *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	content := newVolumeSnapshotContent()
+	if err := r.Get(ctx, types.NamespacedName{Name: forge.VolumeSnapshotContentName(virtualSnapshot)}, content); apierrors.IsNotFound(err) {
+		content = forge.VirtualVolumeSnapshotContent(virtualSnapshot, consts.StorageProvisionerName)
+		if err := r.Create(ctx, content); err != nil {
+			return nil, err
+		}
+		klog.Infof("Created the VolumeSnapshotContent %q bound to the virtual VolumeSnapshot %q", content.GetName(), klog.KObj(virtualSnapshot))
+		return content, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if forge.VolumeSnapshotContentDriver(content) != consts.StorageProvisionerName {
+		klog.Warningf("The VolumeSnapshotContent %q bound to the virtual VolumeSnapshot %q refers to a different driver",
+			content.GetName(), klog.KObj(virtualSnapshot))
+		return nil, nil
+	}
+	return content, nil
+}
+
+// SetupWithManager monitors the virtual VolumeSnapshots, as well as the real ones backing them.
+func (r *VolumeSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	virtualSnapshots := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		snapshot, ok := obj.(*unstructured.Unstructured)
+		return ok && forge.VolumeSnapshotClassName(snapshot) == r.VirtualStorageClassName
+	})
+
+	// Real snapshots are mapped to the corresponding virtual ones through the labels set at creation time.
+	enqueuer := func(_ context.Context, obj client.Object) []reconcile.Request {
+		if obj.GetNamespace() != r.StorageNamespace {
+			return nil
+		}
+		namespace, nsok := obj.GetLabels()[consts.VirtualVolumeSnapshotNamespaceLabel]
+		name, nameok := obj.GetLabels()[consts.VirtualVolumeSnapshotNameLabel]
+		if !nsok || !nameok {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).Named(consts.CtrlVolumeSnapshot).
+		For(newVolumeSnapshot(), builder.WithPredicates(virtualSnapshots)).
+		Watches(newVolumeSnapshot(), handler.EnqueueRequestsFromMapFunc(enqueuer)).
+		Complete(r)
+}
+
+// IsVolumeSnapshotSupported returns whether the CSI volume snapshot API is served by the cluster.
+func IsVolumeSnapshotSupported(mgr ctrl.Manager) (bool, error) {
+	gk := schema.GroupKind{Group: forge.SnapshotAPIGroup, Kind: forge.VolumeSnapshotKind}
+	if _, err := mgr.GetRESTMapper().RESTMapping(gk, forge.VolumeSnapshotGVR.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func newVolumeSnapshot() *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(forge.VolumeSnapshotGVR.GroupVersion().WithKind(forge.VolumeSnapshotKind))
+	return snapshot
+}
+
+func newVolumeSnapshotContent() *unstructured.Unstructured {
+	content := &unstructured.Unstructured{}
+	content.SetGroupVersionKind(forge.VolumeSnapshotContentGVR.GroupVersion().WithKind(forge.VolumeSnapshotContentKind))
+	return content
+}
+
+// forgeRealVolumeSnapshot forges the snapshot of the real PVC, corresponding to the given virtual snapshot.
+// The default snapshot class of the real storage is used, as the one of the virtual snapshot refers to the virtual storage.
+func forgeRealVolumeSnapshot(virtualSnapshot *unstructured.Unstructured, key types.NamespacedName, realPvcName string) *unstructured.Unstructured {
+	realSnapshot := newVolumeSnapshot()
+	realSnapshot.SetName(key.Name)
+	realSnapshot.SetNamespace(key.Namespace)
+	realSnapshot.SetLabels(map[string]string{
+		consts.VirtualVolumeSnapshotNamespaceLabel: virtualSnapshot.GetNamespace(),
+		consts.VirtualVolumeSnapshotNameLabel:      virtualSnapshot.GetName(),
+	})
+	utilruntime.Must(unstructured.SetNestedField(realSnapshot.Object, realPvcName, "spec", "source", "persistentVolumeClaimName"))
+	return realSnapshot
+}
@@ -0,0 +1,195 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Volume snapshots", func() {
+
+	const (
+		virtualStorageClassName = "liqo"
+		storageNamespace        = "liqo-storage"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		recorder   *record.FakeRecorder
+		reconciler *VolumeSnapshotReconciler
+
+		virtualSnapshot *unstructured.Unstructured
+		objects         []client.Object
+		err             error
+	)
+
+	realSnapshotKey := types.NamespacedName{Namespace: storageNamespace, Name: "snapshot-uid"}
+	contentKey := types.NamespacedName{Name: "snapcontent-snapshot-uid"}
+
+	forgeSnapshot := func(namespace, name string) *unstructured.Unstructured {
+		snapshot := newVolumeSnapshot()
+		snapshot.SetNamespace(namespace)
+		snapshot.SetName(name)
+		return snapshot
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		virtualSnapshot = forgeSnapshot("foo", "snap")
+		virtualSnapshot.SetUID("snapshot-uid")
+		virtualSnapshot.Object["spec"] = map[string]interface{}{
+			"volumeSnapshotClassName": virtualStorageClassName,
+			"source":                  map[string]interface{}{"persistentVolumeClaimName": "bar"},
+		}
+
+		objects = []client.Object{&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name: "bar", Namespace: "foo", UID: "virtual-uid"}, Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: ptr.To(virtualStorageClassName)}}}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		scheme.AddKnownTypeWithName(virtualSnapshot.GroupVersionKind(), &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(virtualSnapshot.GroupVersionKind().GroupVersion().WithKind(forge.VolumeSnapshotKind+"List"),
+			&unstructured.UnstructuredList{})
+		scheme.AddKnownTypeWithName(newVolumeSnapshotContent().GroupVersionKind(), &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(newVolumeSnapshotContent().GroupVersionKind().GroupVersion().WithKind(forge.VolumeSnapshotContentKind+"List"),
+			&unstructured.UnstructuredList{})
+
+		cl = ctrlfake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, virtualSnapshot)...).
+			WithStatusSubresource(newVolumeSnapshot(), newVolumeSnapshotContent()).Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &VolumeSnapshotReconciler{Client: cl, Recorder: recorder,
+			VirtualStorageClassName: virtualStorageClassName, StorageNamespace: storageNamespace}
+
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(virtualSnapshot)})
+	})
+
+	When("the source volume has been provisioned in the local cluster", func() {
+		BeforeEach(func() {
+			objects = append(objects, &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+				Name: "virtual-uid", Namespace: storageNamespace}})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should create the snapshot of the real PVC", func() {
+			realSnapshot := newVolumeSnapshot()
+			Expect(cl.Get(ctx, realSnapshotKey, realSnapshot)).To(Succeed())
+			source, found := forge.VolumeSnapshotSource(realSnapshot)
+			Expect(found).To(BeTrue())
+			Expect(source).To(Equal("virtual-uid"))
+			Expect(forge.VolumeSnapshotClassName(realSnapshot)).To(BeEmpty())
+			Expect(realSnapshot.GetLabels()).To(HaveKeyWithValue(consts.VirtualVolumeSnapshotNameLabel, "snap"))
+		})
+		It("should add the finalizer to the virtual snapshot", func() {
+			snapshot := newVolumeSnapshot()
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(virtualSnapshot), snapshot)).To(Succeed())
+			Expect(snapshot.GetFinalizers()).To(ContainElement(consts.VolumeSnapshotFinalizer))
+		})
+		It("should create the content pre-bound to the virtual snapshot", func() {
+			content := newVolumeSnapshotContent()
+			Expect(cl.Get(ctx, contentKey, content)).To(Succeed())
+			Expect(forge.VolumeSnapshotContentDriver(content)).To(Equal(consts.StorageProvisionerName))
+			Expect(content.Object["spec"]).To(HaveKeyWithValue("volumeSnapshotRef", SatisfyAll(
+				HaveKeyWithValue("namespace", "foo"), HaveKeyWithValue("name", "snap"), HaveKeyWithValue("uid", "snapshot-uid"))))
+		})
+		It("should record an event", func() { Expect(recorder.Events).To(Receive(ContainSubstring("SnapshotCreated"))) })
+
+		When("the real snapshot is ready", func() {
+			JustBeforeEach(func() {
+				realSnapshot := newVolumeSnapshot()
+				Expect(cl.Get(ctx, realSnapshotKey, realSnapshot)).To(Succeed())
+				realSnapshot.Object["status"] = map[string]interface{}{"readyToUse": true, "restoreSize": "1Gi"}
+				Expect(cl.Status().Update(ctx, realSnapshot)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(virtualSnapshot)})
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should reflect the status to the content", func() {
+				content := newVolumeSnapshotContent()
+				Expect(cl.Get(ctx, contentKey, content)).To(Succeed())
+				Expect(content.Object).To(HaveKeyWithValue("status", SatisfyAll(
+					HaveKeyWithValue("readyToUse", true), HaveKeyWithValue("restoreSize", BeNumerically("==", 1<<30)))))
+			})
+			It("should not update the status of the virtual snapshot, which is managed by the snapshot controller", func() {
+				snapshot := newVolumeSnapshot()
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(virtualSnapshot), snapshot)).To(Succeed())
+				_, found, _ := unstructured.NestedFieldNoCopy(snapshot.Object, "status", "readyToUse")
+				Expect(found).To(BeFalse())
+			})
+		})
+
+		When("the content has been created by a different driver", func() {
+			BeforeEach(func() {
+				content := forge.VirtualVolumeSnapshotContent(virtualSnapshot, "csi.example.com")
+				objects = append(objects, content)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not create any real snapshot", func() {
+				Expect(cl.Get(ctx, realSnapshotKey, newVolumeSnapshot())).To(BeNotFound())
+			})
+		})
+	})
+
+	When("the source volume has not been provisioned in the local cluster", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create any real snapshot", func() {
+			Expect(cl.Get(ctx, realSnapshotKey, newVolumeSnapshot())).To(BeNotFound())
+		})
+	})
+
+	When("the virtual snapshot is being deleted", func() {
+		BeforeEach(func() {
+			virtualSnapshot.SetFinalizers([]string{consts.VolumeSnapshotFinalizer})
+			virtualSnapshot.SetDeletionTimestamp(ptr.To(metav1.Now()))
+			objects = append(objects, forgeSnapshot(realSnapshotKey.Namespace, realSnapshotKey.Name),
+				forge.VirtualVolumeSnapshotContent(virtualSnapshot, consts.StorageProvisionerName))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should delete the real snapshot", func() {
+			Expect(cl.Get(ctx, realSnapshotKey, newVolumeSnapshot())).To(BeNotFound())
+		})
+		It("should delete the content", func() {
+			Expect(cl.Get(ctx, contentKey, newVolumeSnapshotContent())).To(BeNotFound())
+		})
+		It("should remove the finalizer, completing the deletion", func() {
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(virtualSnapshot), newVolumeSnapshot())).To(BeNotFound())
+		})
+	})
+})
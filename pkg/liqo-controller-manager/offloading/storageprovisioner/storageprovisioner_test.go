@@ -373,6 +373,45 @@ var _ = Describe("Test Storage Provisioner", func() {
 				Expect(realPvc.Annotations).ToNot(HaveKey(testutil.FakeNotReflectedAnnotKey))
 			})
 
+			It("provision ephemeral pvc", func() {
+				pv, state, err := ProvisionEphemeralPVC(controller.ProvisionOptions{
+					SelectedNode: &corev1.Node{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:   virtualNodeName,
+							Labels: map[string]string{liqoconst.RemoteClusterID: remoteClusterID},
+						},
+					},
+					PVC: &corev1.PersistentVolumeClaim{
+						ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: LocalNamespace, UID: "uuid"},
+						Spec: corev1.PersistentVolumeClaimSpec{
+							StorageClassName: pointer.String(virtualStorageClassName),
+							Resources: corev1.VolumeResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceStorage: *resource.NewQuantity(10, resource.BinarySI)},
+							},
+							AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						},
+					},
+					PVName:       pvName,
+					StorageClass: &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: virtualStorageClassName}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state).To(Equal(controller.ProvisioningFinished))
+				Expect(pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values).To(ConsistOf(remoteClusterID))
+				Expect(pv.Spec.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, *resource.NewQuantity(10, resource.BinarySI)))
+
+				_, err = testEnvClient.CoreV1().PersistentVolumeClaims(RemoteNamespace).Get(ctx, pvcName, metav1.GetOptions{})
+				Expect(err).To(testutil.BeNotFound())
+			})
+
+			It("fails to provision ephemeral pvc if the node is not virtual", func() {
+				_, _, err := ProvisionEphemeralPVC(controller.ProvisionOptions{
+					SelectedNode: &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "physical-node"}},
+					PVC:          &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: LocalNamespace}},
+					PVName:       pvName,
+					StorageClass: &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: virtualStorageClassName}},
+				})
+				Expect(err).To(HaveOccurred())
+			})
 		})
 
 	})
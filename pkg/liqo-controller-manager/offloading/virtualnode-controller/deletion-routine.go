@@ -196,6 +196,10 @@ func (dr *DeletionRoutine) deleteNode(ctx context.Context, node *corev1.Node, vn
 
 	klog.Infof("Node %s cordoned", node.Name)
 
+	if err := dr.vnr.checkRemoteVolumes(ctx, vn); err != nil {
+		return fmt.Errorf("error checking remote volumes: %w", err)
+	}
+
 	if err := client.IgnoreNotFound(drainNode(ctx, dr.vnr.Client, vn)); err != nil {
 		return fmt.Errorf("error draining node: %w", err)
 	}
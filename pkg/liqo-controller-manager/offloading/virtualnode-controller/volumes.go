@@ -0,0 +1,79 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualnodectrl
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// cluster-role
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch
+
+// checkRemoteVolumes warns in case the given virtual node is the last one targeting its remote cluster, while persistent
+// volumes hosted by that cluster still exist. Since virtual PVs are pinned to the remote cluster (rather than to the
+// virtual node), the pods mounting them are rescheduled on any other virtual node targeting the same cluster once drained,
+// or remain pending until a new one (e.g., the re-created virtual node) is available.
+func (r *VirtualNodeReconciler) checkRemoteVolumes(ctx context.Context, vn *offloadingv1beta1.VirtualNode) error {
+	virtualNodes, err := getters.ListVirtualNodesByClusterID(ctx, r.Client, vn.Spec.ClusterID)
+	if err != nil {
+		return fmt.Errorf("failed to list virtual nodes for cluster %q: %w", vn.Spec.ClusterID, err)
+	}
+
+	for i := range virtualNodes {
+		other := &virtualNodes[i]
+		if other.Name != vn.Name && other.DeletionTimestamp.IsZero() && (other.Spec.CreateNode == nil || *other.Spec.CreateNode) {
+			// Another virtual node is available to host the pods mounting the volumes of the remote cluster.
+			return nil
+		}
+	}
+
+	volumes, err := getRemoteVolumes(ctx, r.Client, string(vn.Spec.ClusterID))
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volumes for cluster %q: %w", vn.Spec.ClusterID, err)
+	}
+
+	if len(volumes) > 0 {
+		msg := fmt.Sprintf("%d persistent volumes are hosted by cluster %q, and no other virtual node targets it: "+
+			"pods mounting them will remain pending until a virtual node for that cluster is available", len(volumes), vn.Spec.ClusterID)
+		klog.Warningf("Virtual node %q: %s", client.ObjectKeyFromObject(vn), msg)
+		r.EventsRecorder.Event(vn, corev1.EventTypeWarning, "RemoteVolumesUnreachable", msg)
+	}
+	return nil
+}
+
+// getRemoteVolumes returns the persistent volumes hosted by the given remote cluster.
+func getRemoteVolumes(ctx context.Context, cl client.Client, clusterID string) ([]corev1.PersistentVolume, error) {
+	var pvs corev1.PersistentVolumeList
+	if err := cl.List(ctx, &pvs); err != nil {
+		return nil, err
+	}
+
+	var volumes []corev1.PersistentVolume
+	for i := range pvs.Items {
+		if id, found := utils.GetPersistentVolumeClusterID(&pvs.Items[i]); found && id == clusterID {
+			volumes = append(volumes, pvs.Items[i])
+		}
+	}
+	return volumes, nil
+}
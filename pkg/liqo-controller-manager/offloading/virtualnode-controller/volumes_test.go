@@ -0,0 +1,115 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualnodectrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Remote volumes check", func() {
+	var (
+		recorder *record.FakeRecorder
+		vnr      *VirtualNodeReconciler
+		objects  []client.Object
+	)
+
+	virtualNode := func(name, clusterID string, createNode bool) *offloadingv1beta1.VirtualNode {
+		return &offloadingv1beta1.VirtualNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "tenant",
+				Labels: map[string]string{liqoconst.RemoteClusterID: clusterID},
+			},
+			Spec: offloadingv1beta1.VirtualNodeSpec{ClusterID: liqov1beta1.ClusterID(clusterID), CreateNode: ptr.To(createNode)},
+		}
+	}
+
+	volume := func(name, clusterID string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{
+				NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{clusterID},
+					}}}},
+				}},
+			},
+		}
+	}
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(offloadingv1beta1.AddToScheme(scheme)).To(Succeed())
+
+		recorder = record.NewFakeRecorder(10)
+		vnr = &VirtualNodeReconciler{
+			Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			EventsRecorder: recorder,
+		}
+	})
+
+	When("other virtual nodes target the same remote cluster", func() {
+		BeforeEach(func() {
+			objects = []client.Object{
+				virtualNode("first", "cluster-1", true), virtualNode("second", "cluster-1", true),
+				volume("pv", "cluster-1"),
+			}
+		})
+
+		It("should not warn", func() {
+			Expect(vnr.checkRemoteVolumes(context.Background(), virtualNode("first", "cluster-1", true))).To(Succeed())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
+
+	When("the other virtual nodes targeting the same remote cluster do not create the node", func() {
+		BeforeEach(func() {
+			objects = []client.Object{
+				virtualNode("first", "cluster-1", true), virtualNode("second", "cluster-1", false),
+				volume("pv-1", "cluster-1"), volume("pv-2", "cluster-1"), volume("pv-3", "cluster-2"),
+			}
+		})
+
+		It("should warn about the volumes hosted by the remote cluster", func() {
+			Expect(vnr.checkRemoteVolumes(context.Background(), virtualNode("first", "cluster-1", true))).To(Succeed())
+			Expect(recorder.Events).To(Receive(And(ContainSubstring("RemoteVolumesUnreachable"), ContainSubstring("2 persistent volumes"))))
+		})
+	})
+
+	When("no volume is hosted by the remote cluster", func() {
+		BeforeEach(func() {
+			objects = []client.Object{virtualNode("first", "cluster-1", true), volume("pv", "cluster-2")}
+		})
+
+		It("should not warn", func() {
+			Expect(vnr.checkRemoteVolumes(context.Background(), virtualNode("first", "cluster-1", true))).To(Succeed())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
+})
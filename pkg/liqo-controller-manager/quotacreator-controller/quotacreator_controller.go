@@ -91,6 +91,11 @@ func (r *QuotaCreatorReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		},
 	}
 	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, &quota, func() error {
+		if quota.Labels == nil {
+			quota.Labels = map[string]string{}
+		}
+		quota.Labels[consts.RemoteClusterID] = string(*resourceSlice.Spec.ConsumerClusterID)
+
 		quota.Spec.User = userName
 		quota.Spec.LimitsEnforcement = r.DefaultLimitsEnforcement
 		quota.Spec.Resources = resourceSlice.Status.Resources.DeepCopy()
@@ -0,0 +1,174 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/discovery"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	fcutils "github.com/liqotech/liqo/pkg/utils/foreigncluster"
+)
+
+// Options encapsulates the arguments of the discover command.
+type Options struct {
+	*factory.Factory
+
+	RegistryURL        string
+	RegistryKubeconfig string
+	RegistryNamespace  string
+
+	Selector      string
+	ResourceClass string
+	OutputFormat  string
+}
+
+// NewOptions returns a new Options struct.
+func NewOptions(f *factory.Factory) *Options {
+	return &Options{
+		Factory: f,
+	}
+}
+
+// RunDiscover lists the candidate peers matching the given filters.
+func (o *Options) RunDiscover(ctx context.Context) error {
+	selector, err := labels.Parse(o.Selector)
+	if err != nil {
+		o.Printer.CheckErr(fmt.Errorf("invalid selector: %w", err))
+		return err
+	}
+
+	registry, err := o.registry()
+	if err != nil {
+		o.Printer.CheckErr(fmt.Errorf("unable to configure the cluster registry: %w", err))
+		return err
+	}
+
+	announcements, err := registry.List(ctx)
+	if err != nil {
+		o.Printer.CheckErr(fmt.Errorf("unable to retrieve the cluster announcements: %v", output.PrettyErr(err)))
+		return err
+	}
+
+	candidates := Filter(announcements, selector, o.ResourceClass, time.Now())
+
+	switch o.OutputFormat {
+	case "json", "yaml":
+		return o.printObjects(candidates)
+	default:
+		return o.printTable(ctx, candidates)
+	}
+}
+
+// registry returns the registry to be queried. If no registry is specified, the announcements
+// already retrieved by the local cluster are returned.
+func (o *Options) registry() (discovery.Registry, error) {
+	switch {
+	case o.RegistryURL != "":
+		return discovery.NewHTTPRegistry(o.RegistryURL), nil
+	case o.RegistryKubeconfig != "":
+		cfg, err := clientcmd.BuildConfigFromFlags("", o.RegistryKubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		cl, err := client.New(cfg, client.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return discovery.NewKubernetesRegistry(cl, o.RegistryNamespace), nil
+	default:
+		return discovery.NewKubernetesRegistry(o.CRClient, o.LiqoNamespace), nil
+	}
+}
+
+// Filter returns the valid and not expired announcements whose labels match the given selector,
+// and which offer the given resource class (if not empty).
+func Filter(announcements []liqov1beta1.ClusterAnnouncement, selector labels.Selector,
+	resourceClass string, now time.Time) []liqov1beta1.ClusterAnnouncement {
+	candidates := []liqov1beta1.ClusterAnnouncement{}
+	for i := range announcements {
+		announcement := &announcements[i]
+		if discovery.IsExpired(announcement, now) || discovery.Verify(announcement) != nil {
+			continue
+		}
+		if !selector.Matches(labels.Set(announcement.Spec.Labels)) {
+			continue
+		}
+		if resourceClass != "" && !slices.Contains(announcement.Spec.ResourceClasses, resourceClass) {
+			continue
+		}
+		candidates = append(candidates, *announcement)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Spec.ClusterID < candidates[j].Spec.ClusterID
+	})
+	return candidates
+}
+
+func (o *Options) printObjects(candidates []liqov1beta1.ClusterAnnouncement) error {
+	var printer printers.ResourcePrinter = &printers.JSONPrinter{}
+	if o.OutputFormat == "yaml" {
+		printer = &printers.YAMLPrinter{}
+	}
+
+	list := &liqov1beta1.ClusterAnnouncementList{Items: candidates}
+	list.APIVersion = liqov1beta1.GroupVersion.String()
+	list.Kind = "ClusterAnnouncementList"
+	return printer.PrintObj(list, os.Stdout)
+}
+
+func (o *Options) printTable(ctx context.Context, candidates []liqov1beta1.ClusterAnnouncement) error {
+	if len(candidates) == 0 {
+		o.Printer.Info.Println("No candidate peer found")
+		return nil
+	}
+
+	data := pterm.TableData{{"Cluster ID", "API server", "Resource classes", "Labels", "Pod CIDR", "Role"}}
+	for i := range candidates {
+		spec := &candidates[i].Spec
+
+		// Show whether the candidate is already known (e.g., peered) by the local cluster.
+		role := "-"
+		if fc, err := fcutils.GetForeignClusterByID(ctx, o.CRClient, spec.ClusterID); err == nil {
+			role = string(fc.Status.Role)
+		}
+
+		data = append(data, []string{
+			string(spec.ClusterID),
+			spec.APIServerURL,
+			strings.Join(spec.ResourceClasses, ","),
+			labels.Set(spec.Labels).String(),
+			spec.Networking.PodCIDR,
+			role,
+		})
+	}
+
+	return o.Printer.Table.WithData(data).Render()
+}
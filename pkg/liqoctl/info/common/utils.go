@@ -27,6 +27,12 @@ var statusStyles = map[ModuleStatus]*pterm.Style{
 	ModuleDisabled:  pterm.NewStyle(pterm.FgLightCyan, pterm.Bold),
 }
 
+var healthStyles = map[liqov1beta1.HealthStatus]*pterm.Style{
+	liqov1beta1.HealthStatusHealthy:  pterm.NewStyle(pterm.FgGreen, pterm.Bold),
+	liqov1beta1.HealthStatusDegraded: pterm.NewStyle(pterm.FgYellow, pterm.Bold),
+	liqov1beta1.HealthStatusDown:     pterm.NewStyle(pterm.FgRed, pterm.Bold),
+}
+
 // CheckModuleStatusAndAlerts returns the status and the alerts of the given module.
 func CheckModuleStatusAndAlerts(module liqov1beta1.Module) (status ModuleStatus, alerts []string) {
 	status = CheckModuleStatus(module)
@@ -69,3 +75,15 @@ func FormatStatus(moduleStatus ModuleStatus) string {
 	style := statusStyles[moduleStatus]
 	return style.Sprint(moduleStatus)
 }
+
+// FormatHealth returns a formatted string with the provided health summary.
+func FormatHealth(health *liqov1beta1.HealthSummary) string {
+	if health == nil {
+		return "Unknown"
+	}
+	style, ok := healthStyles[health.Status]
+	if !ok {
+		style = pterm.NewStyle(pterm.FgDefault)
+	}
+	return style.Sprintf("%s (score %d)", health.Status, health.Score)
+}
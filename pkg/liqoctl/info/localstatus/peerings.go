@@ -28,10 +28,11 @@ import (
 // PeeringInfo represents the peering with another cluster.
 type PeeringInfo struct {
 	liqov1beta1.ClusterID `json:"clusterID"`
-	Role                  liqov1beta1.RoleType `json:"role"`
-	NetworkingStatus      common.ModuleStatus  `json:"networkingStatus"`
-	AuthenticationStatus  common.ModuleStatus  `json:"authenticationStatus"`
-	OffloadingStatus      common.ModuleStatus  `json:"offloadingStatus"`
+	Role                  liqov1beta1.RoleType       `json:"role"`
+	NetworkingStatus      common.ModuleStatus        `json:"networkingStatus"`
+	AuthenticationStatus  common.ModuleStatus        `json:"authenticationStatus"`
+	OffloadingStatus      common.ModuleStatus        `json:"offloadingStatus"`
+	Health                *liqov1beta1.HealthSummary `json:"health,omitempty"`
 }
 
 // Peerings contains some brief data about the active peering of the local cluster.
@@ -78,6 +79,7 @@ func (p *PeeringChecker) Collect(ctx context.Context, options info.Options) {
 			NetworkingStatus:     moduleStatus[NetworkingModuleName],
 			AuthenticationStatus: moduleStatus[AuthenticationModuleName],
 			OffloadingStatus:     moduleStatus[OffloadingModuleName],
+			Health:               peer.Status.Health,
 		})
 	}
 }
@@ -92,6 +94,10 @@ func (p *PeeringChecker) Format(options info.Options) string {
 		peerSection.AddEntry("Networking status", common.FormatStatus(peer.NetworkingStatus))
 		peerSection.AddEntry("Authentication status", common.FormatStatus(peer.AuthenticationStatus))
 		peerSection.AddEntry("Offloading status", common.FormatStatus(peer.OffloadingStatus))
+		peerSection.AddEntry("Health", common.FormatHealth(peer.Health))
+		if peer.Health != nil && peer.Health.Message != "" {
+			peerSection.AddEntry("Health reason", peer.Health.Message)
+		}
 	}
 
 	return main.SprintForBox(options.Printer)
@@ -37,6 +37,8 @@ type VirtualNodeStatus struct {
 	Secret        string              `json:"secret"`
 	ResourceSlice string              `json:"resourceSlice,omitempty"`
 	Resources     corev1.ResourceList `json:"resources"`
+	// ReflectionAlerts contains the alerts about the reflectors failing to reflect some objects.
+	ReflectionAlerts []string `json:"reflectionAlerts,omitempty"`
 }
 
 // Offloading contains info about offloaded resources and virtual nodes.
@@ -93,6 +95,9 @@ func (oc *OffloadingChecker) FormatForClusterID(clusterID liqov1beta1.ClusterID,
 				currNodeSection.AddEntry("Status", common.FormatStatus(vNode.Status))
 				currNodeSection.AddEntry("Secret", vNode.Secret)
 				currNodeSection.AddEntry("Resource slice", vNode.ResourceSlice)
+				if len(vNode.ReflectionAlerts) > 0 {
+					currNodeSection.AddEntryWarning("Reflection alerts", vNode.ReflectionAlerts...)
+				}
 				resourcesSection := currNodeSection.AddSection("Resources")
 				for resource, quantity := range vNode.Resources {
 					resourcesSection.AddEntry(string(resource), quantity.String())
@@ -161,6 +166,32 @@ func (oc *OffloadingChecker) collectVirtualNodes(virtualNodes []offloadingv1beta
 			ResourceSlice: resourceSlice,
 			Secret:        secretName,
 			Resources:     vNode.Spec.ResourceQuota.Hard,
+
+			ReflectionAlerts: reflectionAlerts(vNode.Status.Reflection),
 		})
 	}
 }
+
+// reflectionAlerts returns the alerts about the reflectors failing to reflect some objects, according to the given status.
+func reflectionAlerts(status *offloadingv1beta1.ReflectionStatus) []string {
+	if status == nil {
+		return nil
+	}
+
+	var alerts []string
+	for i := range status.Reflectors {
+		for j := range status.Reflectors[i].Namespaces {
+			ns := &status.Reflectors[i].Namespaces[j]
+			if ns.Failed == 0 {
+				continue
+			}
+
+			alert := fmt.Sprintf("%s reflection failing for %d object(s) in namespace %q", status.Reflectors[i].Resource, ns.Failed, ns.Namespace)
+			if ns.LastError != "" {
+				alert = fmt.Sprintf("%s: %s", alert, ns.LastError)
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
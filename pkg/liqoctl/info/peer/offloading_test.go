@@ -132,6 +132,27 @@ var _ = Describe("OffloadingChecker tests", func() {
 				Expect(offloadingStatus.VirtualNodes[0].Status).To(Equal(common.ModuleUnhealthy),
 					"One condition unhealthy, expected virtual node to be unhealthy")
 			})
+
+			It("tests the collection of the reflection alerts", func() {
+				oc = &OffloadingChecker{}
+
+				vn := testutil.FakeVirtualNode("vn01", liqov1beta1.ClusterID(clusterID),
+					offloadingv1beta1.RunningConditionStatusType, expectedResourceList)
+				vn.Status.Reflection = &offloadingv1beta1.ReflectionStatus{
+					Reflectors: []offloadingv1beta1.ReflectorStatus{
+						{Resource: "ConfigMap", Namespaces: []offloadingv1beta1.ReflectorNamespaceStatus{{Namespace: "foo", Reflected: 2}}},
+						{Resource: "Secret", Namespaces: []offloadingv1beta1.ReflectorNamespaceStatus{
+							{Namespace: "foo", Reflected: 1, Failed: 2, LastError: "forbidden"},
+						}},
+					},
+				}
+				offloadingStatus := Offloading{}
+				oc.collectVirtualNodes([]offloadingv1beta1.VirtualNode{*vn}, &offloadingStatus)
+
+				Expect(offloadingStatus.VirtualNodes).To(HaveLen(1))
+				Expect(offloadingStatus.VirtualNodes[0].ReflectionAlerts).To(ConsistOf(
+					`Secret reflection failing for 2 object(s) in namespace "foo": forbidden`))
+			})
 		})
 
 		DescribeTable("FormatForClusterID function test", func(testCase Offloading) {
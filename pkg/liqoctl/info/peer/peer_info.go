@@ -21,13 +21,15 @@ import (
 
 	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	"github.com/liqotech/liqo/pkg/liqoctl/info"
+	"github.com/liqotech/liqo/pkg/liqoctl/info/common"
 	"github.com/liqotech/liqo/pkg/liqoctl/output"
 )
 
 // Info contains some info about the identity and the role of a peer cluster.
 type Info struct {
 	liqov1beta1.ClusterID `json:"clusterID"`
-	Role                  liqov1beta1.RoleType `json:"role"`
+	Role                  liqov1beta1.RoleType       `json:"role"`
+	Health                *liqov1beta1.HealthSummary `json:"health,omitempty"`
 }
 
 // InfoChecker collects some info about the identity and the role of a peer cluster.
@@ -45,6 +47,7 @@ func (ic *InfoChecker) Collect(_ context.Context, options info.Options) {
 		ic.data[clusterID] = Info{
 			ClusterID: clusterID,
 			Role:      options.ClustersInfo[clusterID].Status.Role,
+			Health:    options.ClustersInfo[clusterID].Status.Health,
 		}
 	}
 }
@@ -55,6 +58,10 @@ func (ic *InfoChecker) FormatForClusterID(clusterID liqov1beta1.ClusterID, optio
 		main := output.NewRootSection()
 		main.AddEntry("Cluster ID", string(data.ClusterID))
 		main.AddEntry("Role", string(data.Role))
+		main.AddEntry("Health", common.FormatHealth(data.Health))
+		if data.Health != nil && data.Health.Message != "" {
+			main.AddEntry("Health reason", data.Health.Message)
+		}
 
 		return main.SprintForBox(options.Printer)
 	}
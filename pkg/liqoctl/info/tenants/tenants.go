@@ -0,0 +1,169 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenants
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqoctl/info"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+// QuotaUsage contains the resource usage of a user of a tenant.
+type QuotaUsage struct {
+	User              string                              `json:"user"`
+	LimitsEnforcement offloadingv1beta1.LimitsEnforcement `json:"limitsEnforcement,omitempty"`
+	Cordoned          bool                                `json:"cordoned"`
+	Requested         corev1.ResourceList                 `json:"requested,omitempty"`
+	Used              corev1.ResourceList                 `json:"used,omitempty"`
+	Free              corev1.ResourceList                 `json:"free,omitempty"`
+	ShadowPods        int32                               `json:"shadowPods"`
+	LastUpdateTime    *metav1.Time                        `json:"lastUpdateTime,omitempty"`
+}
+
+// TenantInfo contains the resource usage of a consumer cluster.
+type TenantInfo struct {
+	liqov1beta1.ClusterID `json:"clusterID"`
+	Namespace             string                      `json:"namespace"`
+	Condition             authv1beta1.TenantCondition `json:"condition,omitempty"`
+	Quotas                []QuotaUsage                `json:"quotas"`
+}
+
+// Tenants contains the resource usage of all the consumer clusters of the local cluster.
+type Tenants struct {
+	Tenants []TenantInfo `json:"tenants"`
+}
+
+// UsageChecker collects the resource usage of the tenants of the local cluster.
+type UsageChecker struct {
+	info.CheckerCommon
+	data Tenants
+}
+
+// Collect the resource usage of the tenants of the local cluster.
+func (uc *UsageChecker) Collect(ctx context.Context, options info.Options) {
+	var tenantList authv1beta1.TenantList
+	if err := options.CRClient.List(ctx, &tenantList); err != nil {
+		uc.AddCollectionError(fmt.Errorf("unable to retrieve tenants: %w", err))
+		return
+	}
+
+	var quotaList offloadingv1beta1.QuotaList
+	if err := options.CRClient.List(ctx, &quotaList); err != nil {
+		uc.AddCollectionError(fmt.Errorf("unable to retrieve quotas: %w", err))
+		return
+	}
+
+	uc.data.Tenants = []TenantInfo{}
+	for i := range tenantList.Items {
+		tenant := &tenantList.Items[i]
+		tenantInfo := TenantInfo{
+			ClusterID: tenant.Spec.ClusterID,
+			Namespace: tenant.Status.TenantNamespace,
+			Condition: tenant.Spec.TenantCondition,
+			Quotas:    []QuotaUsage{},
+		}
+
+		for j := range quotaList.Items {
+			quota := &quotaList.Items[j]
+			if tenantInfo.Namespace == "" || quota.Namespace != tenantInfo.Namespace {
+				continue
+			}
+			tenantInfo.Quotas = append(tenantInfo.Quotas, QuotaUsage{
+				User:              quota.Spec.User,
+				LimitsEnforcement: quota.Spec.LimitsEnforcement,
+				Cordoned:          quota.Spec.Cordoned != nil && *quota.Spec.Cordoned,
+				Requested:         quota.Status.Usage.Requested,
+				Used:              quota.Status.Usage.Used,
+				Free:              quota.Status.Usage.Free,
+				ShadowPods:        quota.Status.Usage.ShadowPods,
+				LastUpdateTime:    quota.Status.LastUpdateTime,
+			})
+		}
+
+		uc.data.Tenants = append(uc.data.Tenants, tenantInfo)
+	}
+}
+
+// Format returns the collected data using a user friendly output.
+func (uc *UsageChecker) Format(options info.Options) string {
+	main := output.NewRootSection()
+	for i := range uc.data.Tenants {
+		tenant := &uc.data.Tenants[i]
+		tenantSection := main.AddSectionInfo(string(tenant.ClusterID))
+		tenantSection.AddEntry("Namespace", tenant.Namespace)
+		tenantSection.AddEntry("Condition", string(tenant.Condition))
+
+		if len(tenant.Quotas) == 0 {
+			tenantSection.AddEntryWarning("Alerts", "no quota found for this tenant")
+			continue
+		}
+
+		for j := range tenant.Quotas {
+			quota := &tenant.Quotas[j]
+			quotaSection := tenantSection.AddSection(quota.User)
+			quotaSection.AddEntry("Cordoned", strconv.FormatBool(quota.Cordoned))
+			quotaSection.AddEntry("Shadow pods", strconv.Itoa(int(quota.ShadowPods)))
+			if quota.LastUpdateTime == nil {
+				quotaSection.AddEntryWarning("Alerts", "usage not reported yet")
+				continue
+			}
+
+			resourcesSection := quotaSection.AddSection("Resources")
+			for _, name := range sortedResourceNames(quota.Requested) {
+				used := quota.Used[name]
+				free := quota.Free[name]
+				requested := quota.Requested[name]
+				resourcesSection.AddEntry(string(name),
+					fmt.Sprintf("used %s / free %s / requested %s", used.String(), free.String(), requested.String()))
+			}
+		}
+	}
+
+	return main.SprintForBox(options.Printer)
+}
+
+// GetData returns the data collected by the checker.
+func (uc *UsageChecker) GetData() interface{} {
+	return uc.data
+}
+
+// GetID returns the id of the section collected by the checker.
+func (uc *UsageChecker) GetID() string {
+	return "tenants"
+}
+
+// GetTitle returns the title of the section collected by the checker.
+func (uc *UsageChecker) GetTitle() string {
+	return "Tenants resource usage"
+}
+
+func sortedResourceNames(resources corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,113 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenants_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pterm/pterm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1beta1 "github.com/liqotech/liqo/apis/authentication/v1beta1"
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/info"
+	"github.com/liqotech/liqo/pkg/liqoctl/info/tenants"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("UsageChecker tests", func() {
+	var (
+		ctx     context.Context
+		options info.Options
+		uc      *tenants.UsageChecker
+	)
+
+	forgeTenant := func(clusterID liqov1beta1.ClusterID, namespace string) *authv1beta1.Tenant {
+		return &authv1beta1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: string(clusterID)},
+			Spec: authv1beta1.TenantSpec{
+				ClusterID:       clusterID,
+				TenantCondition: authv1beta1.TenantConditionActive,
+			},
+			Status: authv1beta1.TenantStatus{TenantNamespace: namespace},
+		}
+	}
+
+	forgeQuota := func(name, namespace string, used string) *offloadingv1beta1.Quota {
+		return &offloadingv1beta1.Quota{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: offloadingv1beta1.QuotaSpec{
+				User:      name,
+				Resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			},
+			Status: offloadingv1beta1.QuotaStatus{
+				Usage: offloadingv1beta1.TenantUsage{
+					Requested:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+					Used:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(used)},
+					Free:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					ShadowPods: 3,
+				},
+				LastUpdateTime: &metav1.Time{Time: time.Now()},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		options = info.Options{Factory: factory.NewForLocal()}
+		options.Printer = output.NewFakePrinter(GinkgoWriter)
+		options.CRClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			forgeTenant("cluster-1", "liqo-tenant-cluster-1"),
+			forgeTenant("cluster-2", "liqo-tenant-cluster-2"),
+			forgeQuota("user-1", "liqo-tenant-cluster-1", "3"),
+			forgeQuota("user-other", "other-namespace", "2"),
+		).Build()
+
+		uc = &tenants.UsageChecker{}
+		uc.Collect(ctx, options)
+	})
+
+	It("should not raise errors", func() {
+		Expect(uc.GetCollectionErrors()).To(BeEmpty())
+	})
+
+	It("should associate the quotas to the corresponding tenant", func() {
+		data := uc.GetData().(tenants.Tenants)
+		Expect(data.Tenants).To(HaveLen(2))
+		Expect(data.Tenants[0].ClusterID).To(Equal(liqov1beta1.ClusterID("cluster-1")))
+		Expect(data.Tenants[0].Quotas).To(HaveLen(1))
+		Expect(data.Tenants[0].Quotas[0].User).To(Equal("user-1"))
+		Expect(data.Tenants[0].Quotas[0].ShadowPods).To(BeNumerically("==", 3))
+		Expect(data.Tenants[1].Quotas).To(BeEmpty())
+	})
+
+	It("should format the collected data", func() {
+		text := pterm.RemoveColorFromString(uc.Format(options))
+		text = testutil.SqueezeWhitespaces(text)
+		Expect(text).To(ContainSubstring("cluster-1 Namespace: liqo-tenant-cluster-1 Condition: Active"))
+		Expect(text).To(ContainSubstring("cpu: used 3 / free 1 / requested 4"))
+		Expect(text).To(ContainSubstring("cluster-2 Namespace: liqo-tenant-cluster-2 Condition: Active Alerts: no quota found for this tenant"))
+	})
+})
@@ -0,0 +1,363 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// BackupOptions encapsulates the arguments of the backup namespace command.
+type BackupOptions struct {
+	Options
+
+	Location    string
+	S3          S3Options
+	SkipVolumes bool
+}
+
+// backupInfo describes the content of a backup.
+type backupInfo struct {
+	Namespace string      `json:"namespace"`
+	Timestamp metav1.Time `json:"timestamp"`
+	// RemoteNamespace is the name of the namespace hosting the reflected resources in the remote clusters, if offloaded.
+	RemoteNamespace string `json:"remoteNamespace,omitempty"`
+	// OffloadedClusters are the IDs of the remote clusters the namespace is offloaded to.
+	OffloadedClusters []string `json:"offloadedClusters,omitempty"`
+	// Volumes are the volumes whose data is included in the backup.
+	Volumes []backupVolume `json:"volumes,omitempty"`
+}
+
+// backupVolume describes a volume whose data is included in a backup.
+type backupVolume struct {
+	Name string            `json:"name"`
+	Size resource.Quantity `json:"size"`
+	// ClusterID is the ID of the remote cluster hosting the volume, or empty if it is hosted by the local cluster.
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// Run implements the backup namespace command.
+func (o *BackupOptions) Run(ctx context.Context) error {
+	// we need a context that is not canceled even if the user press Ctrl+C
+	deferCtx := context.Background()
+
+	s := o.Printer.StartSpinner("Running pre-flight checks")
+
+	store, err := newBackupStore(o.Location, &o.S3)
+	if err != nil {
+		s.Fail("Failed to initialize the backup location: ", output.PrettyErr(err))
+		return err
+	}
+	if found, err := store.exists(ctx, backupInfoKey); err != nil || found {
+		if err == nil {
+			err = fmt.Errorf("a backup already exists in %q", o.Location)
+		}
+		s.Fail("Failed to check the backup location: ", output.PrettyErr(err))
+		return err
+	}
+
+	info, pvcs, err := o.describeNamespace(ctx)
+	if err != nil {
+		s.Fail(fmt.Sprintf("Failed to retrieve namespace %q: %v", o.Namespace, output.PrettyErr(err)))
+		return err
+	}
+	s.Success("Pre-flight checks passed")
+
+	s = o.Printer.StartSpinner("Exporting the manifests")
+	manifests, err := collectManifests(ctx, o.CRClient, o.Namespace)
+	if err != nil {
+		s.Fail("Failed to collect the manifests: ", output.PrettyErr(err))
+		return err
+	}
+	data, err := encodeManifests(manifests)
+	if err == nil {
+		err = store.put(ctx, backupManifestsKey, data)
+	}
+	if err != nil {
+		s.Fail("Failed to store the manifests: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Exported %d manifests", len(manifests)))
+
+	if !o.SkipVolumes && len(pvcs) > 0 {
+		if err := o.backupVolumes(ctx, deferCtx, store, pvcs); err != nil {
+			return err
+		}
+		info.Volumes = make([]backupVolume, len(pvcs))
+		for i := range pvcs {
+			info.Volumes[i] = pvcs[i].volume
+		}
+	}
+
+	// The backup info is stored as last, to mark the backup as completed.
+	s = o.Printer.StartSpinner("Finalizing the backup")
+	if data, err = yaml.Marshal(info); err == nil {
+		err = store.put(ctx, backupInfoKey, data)
+	}
+	if err != nil {
+		s.Fail("Failed to store the backup information: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Namespace %q backed up to %q", o.Namespace, o.Location))
+	return nil
+}
+
+// backupPvc associates a PVC to be backed up with the node hosting it.
+type backupPvc struct {
+	pvc    corev1.PersistentVolumeClaim
+	node   *corev1.Node
+	volume backupVolume
+}
+
+// describeNamespace returns the backup information concerning the namespace, along with the bound PVCs it contains.
+func (o *BackupOptions) describeNamespace(ctx context.Context) (*backupInfo, []backupPvc, error) {
+	var namespace corev1.Namespace
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: o.Namespace}, &namespace); err != nil {
+		return nil, nil, err
+	}
+	info := &backupInfo{Namespace: o.Namespace, Timestamp: metav1.Now()}
+
+	var nsOffloading offloadingv1beta1.NamespaceOffloading
+	err := o.CRClient.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: liqoconst.DefaultNamespaceOffloadingName}, &nsOffloading)
+	switch {
+	case client.IgnoreNotFound(err) != nil:
+		return nil, nil, err
+	case err == nil:
+		info.RemoteNamespace = nsOffloading.Status.RemoteNamespaceName
+		for clusterID := range nsOffloading.Status.RemoteNamespacesConditions {
+			info.OffloadedClusters = append(info.OffloadedClusters, clusterID)
+		}
+		sort.Strings(info.OffloadedClusters)
+	}
+
+	var pvcList corev1.PersistentVolumeClaimList
+	if err := o.CRClient.List(ctx, &pvcList, client.InNamespace(o.Namespace)); err != nil {
+		return nil, nil, err
+	}
+
+	var pvcs []backupPvc
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if pvc.Spec.VolumeName == "" {
+			// Unbound PVCs do not contain any data.
+			continue
+		}
+
+		current := backupPvc{pvc: *pvc, volume: backupVolume{Name: pvc.Name, Size: pvc.Spec.Resources.Requests[corev1.ResourceStorage]}}
+		if _, found := pvc.Annotations["volume.kubernetes.io/selected-node"]; found {
+			node, err := getVolumeNode(ctx, o.CRClient, pvc)
+			if err != nil {
+				return nil, nil, err
+			}
+			current.node = node
+			if utils.IsVirtualNode(current.node) {
+				current.volume.ClusterID, _ = utils.GetNodeClusterID(current.node)
+			}
+		}
+		pvcs = append(pvcs, current)
+	}
+
+	return info, pvcs, nil
+}
+
+// backupVolumes stores the data of the given PVCs into the backup location, scaling down the workloads mounting them
+// during the process, to ensure the consistency of the data.
+func (o *BackupOptions) backupVolumes(ctx, deferCtx context.Context, store backupStore, pvcs []backupPvc) error {
+	s := o.Printer.StartSpinner("Scaling down the workloads mounting the volumes")
+	var workloads []scaledWorkload
+	for i := range pvcs {
+		mounters, err := getMounterWorkloads(ctx, o.CRClient, &pvcs[i].pvc)
+		if err != nil {
+			s.Fail("Failed to retrieve the workloads mounting the volumes: ", output.PrettyErr(err))
+			return err
+		}
+		for j := range mounters {
+			if !slices.ContainsFunc(workloads, func(w scaledWorkload) bool { return w.Kind == mounters[j].Kind && w.Name == mounters[j].Name }) {
+				workloads = append(workloads, mounters[j])
+			}
+		}
+	}
+
+	if err := scaleWorkloads(ctx, o.CRClient, o.Namespace, workloads, false); err != nil {
+		s.Fail("Failed to scale down the workloads: ", output.PrettyErr(err))
+		return err
+	}
+	defer func() {
+		s = o.Printer.StartSpinner("Restoring the workloads mounting the volumes")
+		if err := scaleWorkloads(deferCtx, o.CRClient, o.Namespace, workloads, true); err != nil {
+			s.Fail("Failed to restore the workloads: ", output.PrettyErr(err))
+			return
+		}
+		s.Success("Workloads restored")
+	}()
+
+	for i := range pvcs {
+		if err := waitForNoMounter(ctx, o.CRClient, &pvcs[i].pvc); err != nil {
+			s.Fail("Failed to wait for the workloads to be scaled down: ", output.PrettyErr(err))
+			return err
+		}
+	}
+	s.Success(fmt.Sprintf("Scaled down %d workloads", len(workloads)))
+
+	repositories, cleanup, err := o.prepareVolumeRepositories(ctx, deferCtx, store, pvcs)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	for i := range pvcs {
+		s = o.Printer.StartSpinner(fmt.Sprintf("Backing up volume %q", pvcs[i].pvc.Name))
+		job, err := o.createSnapshotterJobForRepository(ctx, &pvcs[i].pvc, repositories[i])
+		if err == nil {
+			err = waitForJob(ctx, o.CRClient, job)
+		}
+		if err != nil {
+			s.Fail(fmt.Sprintf("Failed to back up volume %q: %v", pvcs[i].pvc.Name, output.PrettyErr(err)))
+			return err
+		}
+		s.Success(fmt.Sprintf("Volume %q backed up", pvcs[i].pvc.Name))
+	}
+
+	if dir, ok := store.(*dirStore); ok {
+		s = o.Printer.StartSpinner("Downloading the volume repositories")
+		if err := o.downloadResticRegistry(ctx, dir.root); err != nil {
+			s.Fail("Failed to download the volume repositories: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Volume repositories downloaded")
+	}
+
+	return nil
+}
+
+// prepareVolumeRepositories returns the Restic repositories storing the data of the given PVCs. In case of S3-compatible
+// locations, the repositories are directly stored in the bucket, whose credentials are stored in a temporary secret.
+// Otherwise, they are temporarily hosted by an in-cluster Restic registry, whose content is transferred from/to the local
+// directory. The returned function releases the resources created to this end, and shall always be invoked.
+func (o *Options) prepareVolumeRepositories(ctx, deferCtx context.Context, store backupStore,
+	pvcs []backupPvc) (repositories []*resticRepository, cleanup func(), err error) {
+	cleanup = func() {}
+	repositories = make([]*resticRepository, len(pvcs))
+
+	if s3, ok := store.(*s3Store); ok {
+		s := o.Printer.StartSpinner("Storing the backup location credentials")
+		secret, err := s3.createCredentialsSecret(ctx, o.CRClient, o.Namespace)
+		if err != nil {
+			s.Fail("Failed to store the backup location credentials: ", output.PrettyErr(err))
+			return nil, cleanup, err
+		}
+		cleanup = func() {
+			s := o.Printer.StartSpinner("Removing the backup location credentials")
+			if err := client.IgnoreNotFound(o.CRClient.Delete(deferCtx, secret)); err != nil {
+				s.Fail("Failed to remove the backup location credentials: ", output.PrettyErr(err))
+				return
+			}
+			s.Success("Removed the backup location credentials")
+		}
+		s.Success("Backup location credentials stored")
+
+		for i := range pvcs {
+			repositories[i] = s3.resticRepository(pvcs[i].pvc.Name, secret.Name)
+		}
+		return repositories, cleanup, nil
+	}
+
+	var cleanups []func()
+	cleanup = func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+
+	var nodes []*corev1.Node
+	var size resource.Quantity
+	for i := range pvcs {
+		if pvcs[i].node != nil {
+			nodes = append(nodes, pvcs[i].node)
+		}
+		size.Add(pvcs[i].volume.Size)
+	}
+
+	remote := len(getRemoteNodeNames(nodes...)) > 0
+	if remote {
+		s := o.Printer.StartSpinner("Offloading the liqo-storage namespace")
+		if err := offloadLiqoStorageNamespace(ctx, o.CRClient, nodes...); err != nil {
+			s.Fail("Failed to offload the liqo-storage namespace: ", output.PrettyErr(err))
+			return nil, cleanup, err
+		}
+		cleanups = append(cleanups, func() {
+			s := o.Printer.StartSpinner("Repatriating the liqo-storage namespace")
+			if err := repatriateLiqoStorageNamespace(deferCtx, o.CRClient); err != nil {
+				s.Fail("Failed to repatriate the liqo-storage namespace: ", output.PrettyErr(err))
+				return
+			}
+			s.Success("Repatriated the liqo-storage namespace")
+		})
+		s.Success("Liqo-storage namespace offloaded")
+	}
+
+	s := o.Printer.StartSpinner("Ensuring restic repository")
+	if err := o.ensureResticRepositoryWithSize(ctx, size); err != nil {
+		s.Fail("Failed to ensure restic repository: ", output.PrettyErr(err))
+		return nil, cleanup, err
+	}
+	cleanups = append(cleanups, func() {
+		s := o.Printer.StartSpinner("Removing restic repository")
+		if err := deleteResticRepository(deferCtx, o.CRClient); err != nil {
+			s.Fail("Failed to remove restic repository: ", output.PrettyErr(err))
+			return
+		}
+		s.Success("Removed restic repository")
+	})
+	if err := waitForResticRepository(ctx, o.CRClient); err != nil {
+		s.Fail("Failed to wait for restic repository to be up and running: ", output.PrettyErr(err))
+		return nil, cleanup, err
+	}
+	s.Success("Restic repository is up and running")
+
+	localURL, err := getResticRepositoryURL(ctx, o.CRClient, true)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	remoteURL := localURL
+	if remote {
+		if remoteURL, err = getResticRepositoryURL(ctx, o.CRClient, false); err != nil {
+			return nil, cleanup, err
+		}
+	}
+
+	for i := range pvcs {
+		url := localURL
+		if pvcs[i].node != nil && utils.IsVirtualNode(pvcs[i].node) {
+			url = remoteURL
+		}
+		repositories[i] = &resticRepository{URL: url + path.Join(backupVolumesKey, pvcs[i].pvc.Name)}
+	}
+	return repositories, cleanup, nil
+}
@@ -0,0 +1,349 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+// fakeS3Server is a minimal in-memory stand-in of an S3-compatible server, supporting path-style object operations.
+type fakeS3Server struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[r.URL.Path] = data
+	case http.MethodGet, http.MethodHead:
+		data, found := s.objects[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+var _ = Context("Backup and restore namespaces", func() {
+
+	var ctx = context.Background()
+
+	Context("backup stores", func() {
+
+		var testStore = func(store backupStore) {
+			found, err := store.exists(ctx, "manifests.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			Expect(store.put(ctx, "manifests.yaml", []byte("data"))).To(Succeed())
+			found, err = store.exists(ctx, "manifests.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(store.get(ctx, "manifests.yaml")).To(Equal([]byte("data")))
+		}
+
+		It("should store the data in a local directory", func() {
+			root := GinkgoT().TempDir()
+			store, err := newBackupStore(filepath.Join(root, "backup"), &S3Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store).To(BeAssignableToTypeOf(&dirStore{}))
+
+			testStore(store)
+			Expect(os.ReadFile(filepath.Join(root, "backup", "manifests.yaml"))).To(Equal([]byte("data")))
+		})
+
+		It("should store the data in an S3-compatible bucket", func() {
+			server := &fakeS3Server{objects: map[string][]byte{}}
+			endpoint := httptest.NewServer(server)
+			defer endpoint.Close()
+
+			options := &S3Options{Endpoint: endpoint.URL, Region: DefaultS3Region, AccessKeyID: "id", SecretAccessKey: "secret"}
+			store, err := newBackupStore("s3://backups/foo/", options)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store).To(BeAssignableToTypeOf(&s3Store{}))
+
+			testStore(store)
+			Expect(server.objects).To(HaveKeyWithValue("/backups/foo/manifests.yaml", []byte("data")))
+
+			repository := store.(*s3Store).resticRepository("data", "credentials")
+			Expect(repository.URL).To(Equal("s3:" + endpoint.URL + "/backups/foo/volumes/data"))
+			Expect(repository.Env).To(ConsistOf(
+				corev1.EnvVar{Name: "AWS_ACCESS_KEY_ID", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}, Key: s3AccessKeyIDKey}}},
+				corev1.EnvVar{Name: "AWS_SECRET_ACCESS_KEY", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"}, Key: s3SecretAccessKeyKey}}},
+				corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: DefaultS3Region},
+			))
+
+			cl := fake.NewClientBuilder().Build()
+			secret, err := store.(*s3Store).createCredentialsSecret(ctx, cl, "foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+			Expect(secret.Name).To(HavePrefix(s3CredentialsSecretPrefix))
+			Expect(secret.Labels).To(HaveKey(moveComponentLabel))
+			Expect(secret.StringData).To(Equal(map[string]string{s3AccessKeyIDKey: "id", s3SecretAccessKeyKey: "secret"}))
+		})
+
+		DescribeTable("should reject invalid locations", func(location string) {
+			_, err := newBackupStore(location, &S3Options{})
+			Expect(err).To(HaveOccurred())
+		},
+			Entry("empty location", ""),
+			Entry("missing bucket", "s3://"),
+		)
+	})
+
+	Context("tar archives", func() {
+
+		It("should preserve the content of the archived directory", func() {
+			source, target := GinkgoT().TempDir(), GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(source, "volumes", "data", "keys"), 0o700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(source, "volumes", "data", "config"), []byte("config"), 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(source, "volumes", "data", "keys", "key"), []byte("key"), 0o600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(source, "manifests.yaml"), []byte("manifests"), 0o600)).To(Succeed())
+
+			var buffer bytes.Buffer
+			Expect(writeTar(&buffer, source, "volumes")).To(Succeed())
+			Expect(extractTar(&buffer, target)).To(Succeed())
+
+			Expect(os.ReadFile(filepath.Join(target, "volumes", "data", "config"))).To(Equal([]byte("config")))
+			Expect(os.ReadFile(filepath.Join(target, "volumes", "data", "keys", "key"))).To(Equal([]byte("key")))
+			Expect(filepath.Join(target, "manifests.yaml")).ToNot(BeAnExistingFile())
+		})
+
+		It("should reject paths outside the target directory", func() {
+			source, target := GinkgoT().TempDir(), GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(source, "volumes"), 0o700)).To(Succeed())
+
+			var buffer bytes.Buffer
+			Expect(writeTar(&buffer, filepath.Join(source, "volumes"), "..")).To(Succeed())
+			Expect(extractTar(&buffer, target)).ToNot(Succeed())
+		})
+	})
+
+	Context("manifests", func() {
+
+		var (
+			scheme *runtime.Scheme
+			cl     client.Client
+		)
+
+		BeforeEach(func() {
+			scheme = runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(offloadingv1beta1.AddToScheme(scheme)).To(Succeed())
+
+			deploy := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "foo", UID: "uid", ResourceVersion: "10",
+					Annotations: map[string]string{"deployment.kubernetes.io/revision": "3", "custom": "value"}},
+				Spec:   appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+				Status: appsv1.DeploymentStatus{ReadyReplicas: 3},
+			}
+			owned := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-xxx", Namespace: "foo",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-x", UID: "rs",
+					Controller: ptr.To(true)}}}}
+			offloaded := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "shadow", Namespace: "foo",
+				Labels: map[string]string{liqoconst.ManagedByLabelKey: liqoconst.ManagedByShadowPodValue}}}
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "foo",
+					Annotations: map[string]string{"volume.kubernetes.io/selected-node": "node"}},
+				Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv"},
+			}
+			headless := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "foo"},
+				Spec: corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone}}
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "foo"},
+				Spec: corev1.ServiceSpec{ClusterIP: "10.0.0.1", ClusterIPs: []string{"10.0.0.1"}}}
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "foo"}, Spec: batchv1.JobSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "uid"}},
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					"batch.kubernetes.io/controller-uid": "uid", "app": "job"}}},
+			}}
+
+			nsOffloading := newNamespaceOffloading(offloadingv1beta1.LocalAndRemotePodOffloadingStrategyType)
+			nsOffloading.Namespace = "foo"
+
+			cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{corev1.LabelMetadataName: "foo"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "foo"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "foo"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "other"}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "foo"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "foo"}, Type: corev1.SecretTypeServiceAccountToken},
+				nsOffloading, deploy, owned, offloaded, pvc, headless, svc, job,
+			).Build()
+		})
+
+		It("should collect and sanitize the manifests of the namespace", func() {
+			manifests, err := collectManifests(ctx, cl, "foo")
+			Expect(err).ToNot(HaveOccurred())
+
+			var names []string
+			for _, manifest := range manifests {
+				names = append(names, manifest.GetKind()+"/"+manifest.GetName())
+				Expect(manifest.GetUID()).To(BeEmpty())
+				Expect(manifest.GetResourceVersion()).To(BeEmpty())
+				Expect(manifest.Object).ToNot(HaveKey("status"))
+			}
+			Expect(names).To(Equal([]string{"Namespace/foo", "NamespaceOffloading/" + liqoconst.DefaultNamespaceOffloadingName,
+				"PersistentVolumeClaim/data", "ConfigMap/config", "Service/headless", "Service/svc", "Deployment/web", "Job/job"}))
+
+			field := func(manifest *unstructured.Unstructured, fields ...string) interface{} {
+				value, _, err := unstructured.NestedFieldNoCopy(manifest.Object, fields...)
+				Expect(err).ToNot(HaveOccurred())
+				return value
+			}
+
+			Expect(manifests[0].GetLabels()).ToNot(HaveKey(corev1.LabelMetadataName))
+			Expect(field(manifests[2], "spec", "volumeName")).To(BeNil())
+			Expect(manifests[2].GetAnnotations()).ToNot(HaveKey("volume.kubernetes.io/selected-node"))
+			Expect(field(manifests[4], "spec", "clusterIP")).To(Equal(corev1.ClusterIPNone))
+			Expect(field(manifests[5], "spec", "clusterIP")).To(BeNil())
+			Expect(field(manifests[5], "spec", "clusterIPs")).To(BeNil())
+			Expect(manifests[6].GetAnnotations()).To(Equal(map[string]string{"custom": "value"}))
+			Expect(field(manifests[6], "spec", "replicas")).To(BeNumerically("==", 3))
+			Expect(field(manifests[7], "spec", "selector")).To(BeNil())
+			Expect(field(manifests[7], "spec", "template", "metadata", "labels")).To(
+				Equal(map[string]interface{}{"app": "job"}))
+		})
+
+		It("should encode and decode the manifests", func() {
+			manifests, err := collectManifests(ctx, cl, "foo")
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := encodeManifests(manifests)
+			Expect(err).ToNot(HaveOccurred())
+			decoded, err := decodeManifests(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded).To(HaveLen(len(manifests)))
+			Expect(encodeManifests(decoded)).To(Equal(data))
+		})
+	})
+
+	Context("restore", func() {
+
+		var (
+			cl client.Client
+			o  RestoreOptions
+		)
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+			virtual := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "virtual", Labels: map[string]string{
+				liqoconst.TypeLabel: liqoconst.TypeNode, liqoconst.RemoteClusterID: "remote"}}}
+			cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(virtual,
+				&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "bar"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "bar"}},
+			).Build()
+			o = RestoreOptions{Options: Options{Factory: &factory.Factory{CRClient: cl, Printer: output.NewFakePrinter(GinkgoWriter),
+				Namespace: "bar"}}}
+		})
+
+		It("should restore the volumes in the clusters originally hosting them", func() {
+			pvcs, err := o.restoredVolumes(ctx, &backupInfo{Volumes: []backupVolume{
+				{Name: "local", Size: resource.MustParse("1Gi")}, {Name: "remote", ClusterID: "remote"}}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pvcs).To(HaveLen(2))
+
+			Expect(pvcs[0].pvc.Name).To(Equal("local"))
+			Expect(pvcs[0].pvc.Namespace).To(Equal("bar"))
+			Expect(pvcs[0].node).To(BeNil())
+			Expect(o.volumePlacement(&pvcs[0])).To(PointTo(Equal(corev1.NodeSelectorRequirement{
+				Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpDoesNotExist})))
+
+			Expect(pvcs[1].node.Name).To(Equal("virtual"))
+			Expect(o.volumePlacement(&pvcs[1])).To(PointTo(Equal(corev1.NodeSelectorRequirement{
+				Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{"remote"}})))
+		})
+
+		It("should restore the volumes in the target node, if specified", func() {
+			o.TargetNode = "virtual"
+			pvcs, err := o.restoredVolumes(ctx, &backupInfo{Volumes: []backupVolume{{Name: "local"}}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pvcs[0].node.Name).To(Equal("virtual"))
+			Expect(o.volumePlacement(&pvcs[0])).To(PointTo(Equal(corev1.NodeSelectorRequirement{
+				Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{"virtual"}})))
+		})
+
+		It("should fail if the cluster originally hosting a volume is not peered", func() {
+			_, err := o.restoredVolumes(ctx, &backupInfo{Volumes: []backupVolume{{Name: "remote", ClusterID: "other"}}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should create the manifests in the target namespace", func() {
+			manifest := &unstructured.Unstructured{}
+			manifest.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+			manifest.SetName("config")
+			manifest.SetNamespace("foo")
+			Expect(o.createManifest(ctx, manifest)).To(Succeed())
+			Expect(cl.Get(ctx, client.ObjectKey{Namespace: "bar", Name: "config"}, &corev1.ConfigMap{})).To(Succeed())
+		})
+
+		It("should skip the already existing objects, but not the PVCs", func() {
+			manifest := &unstructured.Unstructured{}
+			manifest.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+			manifest.SetName("existing")
+			Expect(o.createManifest(ctx, manifest)).To(Succeed())
+
+			manifest.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"))
+			Expect(o.createManifest(ctx, manifest)).ToNot(Succeed())
+
+			o.SkipVolumes = true
+			Expect(o.createManifest(ctx, manifest)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,340 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// S3Options contains the parameters to access an S3-compatible backup location.
+type S3Options struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// backupStore abstracts the location where backups are stored.
+type backupStore interface {
+	// put stores the given data, associating it with the given key.
+	put(ctx context.Context, key string, data []byte) error
+	// get retrieves the data associated with the given key.
+	get(ctx context.Context, key string) ([]byte, error)
+	// exists returns whether some data is associated with the given key.
+	exists(ctx context.Context, key string) (bool, error)
+}
+
+// newBackupStore returns the backup store corresponding to the given location,
+// which is either an S3-compatible bucket (i.e., s3://bucket/prefix) or a local directory.
+func newBackupStore(location string, s3Options *S3Options) (backupStore, error) {
+	if location == "" {
+		return nil, errors.New("the backup location must be specified")
+	}
+
+	if !strings.HasPrefix(location, "s3://") {
+		return &dirStore{root: location}, nil
+	}
+
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid backup location %q: the bucket must be specified", location)
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(s3Options.Endpoint),
+		Region:           aws.String(s3Options.Region),
+		Credentials:      credentials.NewStaticCredentials(s3Options.AccessKeyID, s3Options.SecretAccessKey, ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the S3 session: %w", err)
+	}
+
+	return &s3Store{client: s3.New(sess), options: s3Options, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// dirStore is a backup store backed by a local directory.
+type dirStore struct {
+	root string
+}
+
+func (d *dirStore) put(_ context.Context, key string, data []byte) error {
+	target := filepath.Join(d.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o600)
+}
+
+func (d *dirStore) get(_ context.Context, key string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.root, filepath.FromSlash(key)))
+}
+
+func (d *dirStore) exists(_ context.Context, key string) (bool, error) {
+	_, err := os.Stat(filepath.Join(d.root, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// s3Store is a backup store backed by an S3-compatible bucket.
+type s3Store struct {
+	client  s3iface.S3API
+	options *S3Options
+	bucket  string
+	prefix  string
+}
+
+func (s *s3Store) key(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+func (s *s3Store) put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *s3Store) get(ctx context.Context, key string) ([]byte, error) {
+	object, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Body.Close()
+	return io.ReadAll(object.Body)
+}
+
+func (s *s3Store) exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	var failure awserr.RequestFailure
+	if errors.As(err, &failure) && failure.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// resticRepository returns the Restic repository storing the data of the given volume directly in the bucket,
+// retrieving the credentials from the given secret (as created by createCredentialsSecret).
+func (s *s3Store) resticRepository(volume, secret string) *resticRepository {
+	credential := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: key,
+		}}
+	}
+
+	return &resticRepository{
+		URL: fmt.Sprintf("s3:%s/%s", strings.TrimSuffix(s.options.Endpoint, "/"),
+			path.Join(s.bucket, s.prefix, backupVolumesKey, volume)),
+		Env: []corev1.EnvVar{
+			{Name: "AWS_ACCESS_KEY_ID", ValueFrom: credential(s3AccessKeyIDKey)},
+			{Name: "AWS_SECRET_ACCESS_KEY", ValueFrom: credential(s3SecretAccessKeyKey)},
+			{Name: "AWS_DEFAULT_REGION", Value: s.options.Region},
+		},
+	}
+}
+
+// createCredentialsSecret creates a secret in the given namespace storing the credentials to access the bucket,
+// to be referenced by the Restic jobs rather than exposing the credentials in their specification.
+func (s *s3Store) createCredentialsSecret(ctx context.Context, cl client.Client, namespace string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: s3CredentialsSecretPrefix,
+			Namespace:    namespace,
+			Labels:       map[string]string{moveComponentLabel: "credentials"},
+		},
+		StringData: map[string]string{
+			s3AccessKeyIDKey:     s.options.AccessKeyID,
+			s3SecretAccessKeyKey: s.options.SecretAccessKey,
+		},
+	}
+	if err := cl.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// downloadResticRegistry copies the volume repositories hosted by the in-cluster Restic registry into the given directory.
+func (o *Options) downloadResticRegistry(ctx context.Context, root string) error {
+	reader, writer := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := extractTar(reader, root)
+		_ = reader.CloseWithError(err)
+		errCh <- err
+	}()
+
+	err := o.execInResticRegistry(ctx, []string{"tar", "-c", "-f", "-", "-C", resticRegistryDataPath, backupVolumesKey}, nil, writer)
+	_ = writer.CloseWithError(err)
+	if extractErr := <-errCh; err == nil {
+		err = extractErr
+	}
+	return err
+}
+
+// uploadResticRegistry copies the volume repositories stored in the given directory into the in-cluster Restic registry.
+func (o *Options) uploadResticRegistry(ctx context.Context, root string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(writeTar(writer, root, backupVolumesKey))
+	}()
+
+	err := o.execInResticRegistry(ctx, []string{"tar", "-x", "-f", "-", "-C", resticRegistryDataPath}, reader, io.Discard)
+	_ = reader.Close()
+	return err
+}
+
+// execInResticRegistry executes the given command in the in-cluster Restic registry, streaming its standard input and output.
+func (o *Options) execInResticRegistry(ctx context.Context, command []string, stdin io.Reader, stdout io.Writer) error {
+	url := o.KubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(resticRegistry+"-0").
+		Namespace(liqoStorageNamespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Command:   command,
+			Container: resticRegistry,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec).URL()
+
+	exec, err := remotecommand.NewSPDYExecutor(o.RESTConfig, "POST", url)
+	if err != nil {
+		return fmt.Errorf("failed to initialize command executor: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	if err := exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("failed to execute command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// writeTar writes a tar archive containing the given directory (relative to root) to the writer.
+func writeTar(w io.Writer, root, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(filepath.Join(root, dir), func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relative)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(filepath.Clean(current))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractTar extracts the directories and regular files of the tar archive read from the reader into root.
+func extractTar(r io.Reader, root string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(root, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(root)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %q in archive", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(filepath.Clean(target), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+			if err != nil {
+				return err
+			}
+			if _, err := io.CopyN(file, tr, header.Size); err != nil {
+				_ = file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -14,6 +14,8 @@
 
 package move
 
+import "github.com/liqotech/liqo/pkg/utils/rsync"
+
 const (
 	liqoStorageNamespace = "liqo-storage"
 	resticRegistry       = "restic-registry"
@@ -23,4 +25,50 @@ const (
 	DefaultResticServerImage = "restic/rest-server:0.11.0"
 	// DefaultResticImage is the default image used for the restic client.
 	DefaultResticImage = "restic/restic:0.14.0"
+
+	// DefaultRsyncImage is the default image used for the rsync server and client of the stream engine.
+	DefaultRsyncImage = rsync.DefaultImage
+	// DefaultLivePasses is the default number of synchronization passes performed while the workloads are running.
+	DefaultLivePasses = 2
+
+	stagingPvcSuffix            = "-liqo-move"
+	moveComponentLabel          = "liqo.io/move-component"
+	movePhaseAnnotation         = "liqo.io/move-phase"
+	scaledWorkloadsAnnotation   = "liqo.io/move-scaled-workloads"
+	moveReclaimPolicyAnnotation = "liqo.io/move-reclaim-policy"
+
+	s3CredentialsSecretPrefix = "liqo-backup-credentials-"
+	s3AccessKeyIDKey          = "AWS_ACCESS_KEY_ID"
+	s3SecretAccessKeyKey      = "AWS_SECRET_ACCESS_KEY"
+
+	backupInfoKey          = "backup.yaml"
+	backupManifestsKey     = "manifests.yaml"
+	backupVolumesKey       = "volumes"
+	resticRegistryDataPath = "/data"
+
+	// DefaultS3Endpoint is the default endpoint of the S3-compatible backup locations.
+	DefaultS3Endpoint = "https://s3.amazonaws.com"
+	// DefaultS3Region is the default region of the S3-compatible backup locations.
+	DefaultS3Region = "us-east-1"
+)
+
+// Engine identifies the engine used to move the data of the volume.
+type Engine string
+
+const (
+	// EngineRestic moves the data through a temporary Restic repository, requiring the volume not to be mounted.
+	EngineRestic Engine = "restic"
+	// EngineStream streams the data directly from the source to the target volume, through incremental rsync passes.
+	EngineStream Engine = "stream"
+)
+
+// streamPhase identifies the phase of a migration performed by the stream engine, to resume it after an interruption.
+type streamPhase string
+
+const (
+	// streamPhaseSync is the phase in which the data is synchronized from the original volume to the staging one.
+	streamPhaseSync streamPhase = "sync"
+	// streamPhaseCutover is the phase in which the original volume is being replaced by the staging one, which is bound
+	// to the new PVC (or, if hosted by a remote cluster, whose data is copied to the new volume).
+	streamPhaseCutover streamPhase = "cutover"
 )
@@ -12,5 +12,5 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package move contains the logic to move volumes between clusters.
+// Package move contains the logic to move volumes and workloads between clusters, and to back up and restore namespaces.
 package move
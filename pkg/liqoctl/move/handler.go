@@ -42,10 +42,24 @@ type Options struct {
 
 	ResticServerImage string
 	ResticImage       string
+
+	Engine        Engine
+	RsyncPassword string
+	RsyncImage    string
+	LivePasses    int
+
+	PromoteReplica bool
 }
 
 // Run implements the move volume command.
 func (o *Options) Run(ctx context.Context) error {
+	if o.PromoteReplica {
+		return o.runPromoteReplica(ctx)
+	}
+	if o.Engine == EngineStream {
+		return o.runStream(ctx)
+	}
+
 	// we need a context that is not canceled even if the user press Ctrl+C
 	deferCtx := context.Background()
 
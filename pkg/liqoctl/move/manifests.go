@@ -0,0 +1,201 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+// backupResources are the kinds of the namespaced resources included in the backups,
+// in the order they shall be restored (i.e., dependencies first).
+var backupResources = []schema.GroupVersionKind{
+	{Group: "offloading.liqo.io", Version: "v1beta1", Kind: "NamespaceOffloading"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Version: "v1", Kind: "ServiceAccount"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Service"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+	{Version: "v1", Kind: "Pod"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+}
+
+// droppedAnnotations are the annotations set by the control plane, which are not included in the backups.
+var droppedAnnotations = []string{
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.kubernetes.io/selected-node",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"deployment.kubernetes.io/revision",
+}
+
+// droppedJobLabels are the labels automatically added by the job controller to the pod template.
+var droppedJobLabels = []string{
+	"controller-uid",
+	"job-name",
+	"batch.kubernetes.io/controller-uid",
+	"batch.kubernetes.io/job-name",
+}
+
+// collectManifests returns the sanitized manifests of the given namespace and of the resources it contains,
+// excluding those managed by other resources (e.g., the pods of a deployment) or automatically created.
+func collectManifests(ctx context.Context, cl client.Client, namespace string) ([]*unstructured.Unstructured, error) {
+	ns := &unstructured.Unstructured{}
+	ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	if err := cl.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+	sanitizeManifest(ns)
+	manifests := []*unstructured.Unstructured{ns}
+
+	for _, gvk := range backupResources {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			if meta.IsNoMatchError(err) {
+				// The resource is not available in the cluster, hence there is nothing to back up.
+				continue
+			}
+			return nil, err
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !isBackupManifest(obj) {
+				continue
+			}
+			sanitizeManifest(obj)
+			manifests = append(manifests, obj)
+		}
+	}
+
+	return manifests, nil
+}
+
+// isBackupManifest returns whether the given object shall be included in the backups.
+func isBackupManifest(obj *unstructured.Unstructured) bool {
+	if metav1.GetControllerOf(obj) != nil {
+		return false
+	}
+	if _, found := obj.GetLabels()[liqoconst.ManagedByLabelKey]; found {
+		return false
+	}
+	if _, found := obj.GetLabels()[moveComponentLabel]; found {
+		return false
+	}
+
+	switch obj.GetKind() {
+	case "ConfigMap":
+		return obj.GetName() != "kube-root-ca.crt"
+	case "ServiceAccount":
+		return obj.GetName() != "default"
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType != string(corev1.SecretTypeServiceAccountToken)
+	}
+	return true
+}
+
+// sanitizeManifest removes from the given object the fields set by the control plane, so that it can be created again.
+func sanitizeManifest(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp",
+		"deletionGracePeriodSeconds", "managedFields", "selfLink", "ownerReferences", "finalizers"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		for _, annotation := range droppedAnnotations {
+			delete(annotations, annotation)
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	switch obj.GetKind() {
+	case "Namespace":
+		unstructured.RemoveNestedField(obj.Object, "spec")
+		unstructured.RemoveNestedField(obj.Object, "metadata", "labels", corev1.LabelMetadataName)
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case "Service":
+		if clusterIP, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); clusterIP != corev1.ClusterIPNone {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+	case "Pod":
+		unstructured.RemoveNestedField(obj.Object, "spec", "nodeName")
+	case "Job":
+		if manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector"); !manual {
+			unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+			for _, label := range droppedJobLabels {
+				unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", label)
+			}
+		}
+	}
+}
+
+// encodeManifests encodes the given manifests as a multi-document YAML.
+func encodeManifests(manifests []*unstructured.Unstructured) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, manifest := range manifests {
+		data, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString("---\n")
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+// decodeManifests decodes the manifests contained in the given multi-document YAML.
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+
+	var manifests []*unstructured.Unstructured
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); errors.Is(err, io.EOF) {
+			return manifests, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(obj) > 0 {
+			manifests = append(manifests, &unstructured.Unstructured{Object: obj})
+		}
+	}
+}
@@ -16,7 +16,9 @@ package move
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -30,12 +32,15 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
 	. "github.com/liqotech/liqo/pkg/utils/testutil"
 )
 
@@ -130,6 +135,25 @@ var _ = Context("Move Volumes", func() {
 		}
 	}
 
+	var withClusterID = func(node *corev1.Node, clusterID string) *corev1.Node {
+		node.Labels[liqoconst.RemoteClusterID] = clusterID
+		return node
+	}
+
+	var withVolume = func(pvc *corev1.PersistentVolumeClaim, volumeName string) *corev1.PersistentVolumeClaim {
+		pvc.Spec.VolumeName = volumeName
+		return pvc
+	}
+
+	var withRemoteVolume = func(pv *corev1.PersistentVolume, clusterID string) *corev1.PersistentVolume {
+		pv.Spec.NodeAffinity = &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{clusterID},
+			}}}},
+		}}
+		return pv
+	}
+
 	Context("volume utils", func() {
 
 		type mounterTestcase struct {
@@ -189,6 +213,19 @@ var _ = Context("Move Volumes", func() {
 			expectedErr:   BeNil(),
 			expectedLocal: BeFalse(),
 			expectedNode:  &MatchObject{Name: "node1"},
+		}), Entry("should return a virtual node of the same cluster if the original one no longer exists", isLocalVolumeTestcase{
+			client: fake.NewClientBuilder().WithObjects(
+				withClusterID(newNode("node2", false), "cluster-1"), withRemoteVolume(newPv("pv1"), "cluster-1")).Build(),
+			pvc:           withVolume(addNodeMount(newPvc("pvc1"), "node1"), "pv1"),
+			expectedErr:   BeNil(),
+			expectedLocal: BeFalse(),
+			expectedNode:  &MatchObject{Name: "node2"},
+		}), Entry("should return error if the original node no longer exists and the volume is not remote", isLocalVolumeTestcase{
+			client:        fake.NewClientBuilder().WithObjects(newPv("pv1")).Build(),
+			pvc:           withVolume(addNodeMount(newPvc("pvc1"), "node1"), "pv1"),
+			expectedErr:   HaveOccurred(),
+			expectedLocal: BeFalse(),
+			expectedNode:  BeNil(),
 		}))
 
 		Context("recreatePvc function", func() {
@@ -675,4 +712,348 @@ var _ = Context("Move Volumes", func() {
 
 	})
 
+	Context("stream engine", func() {
+
+		var (
+			o  Options
+			cl client.Client
+		)
+
+		BeforeEach(func() {
+			cl = fake.NewClientBuilder().Build()
+			o = Options{Factory: &factory.Factory{CRClient: cl}, TargetNode: "target-node",
+				RsyncImage: DefaultRsyncImage, RsyncPassword: "rsync-password"}
+		})
+
+		DescribeTable("parseRsyncProgress function", func(output, expected string, expectedFound bool) {
+			progress, found := parseRsyncProgress(output)
+			Expect(found).To(Equal(expectedFound))
+			Expect(progress).To(Equal(expected))
+		},
+			Entry("no output", "", "", false),
+			Entry("unrelated output", "receiving incremental file list\n", "", false),
+			Entry("single update", "     32,768   0%    0.00kB/s    0:00:00 (xfr#1, to-chk=10/12)\n",
+				"0%, 32,768 bytes transferred at 0.00kB/s", true),
+			Entry("multiple updates", "  1,048,576  10%    1.00MB/s    0:00:01\r 52,428,800  50%   10.00MB/s    0:00:05 (xfr#5, to-chk=5/12)",
+				"50%, 52,428,800 bytes transferred at 10.00MB/s", true),
+		)
+
+		When("creating the rsync server", func() {
+
+			var (
+				svc *corev1.Service
+				pod corev1.Pod
+			)
+
+			BeforeEach(func() {
+				var err error
+				svc, err = o.createStreamSource(ctx, newPvc("pvc1"), "node1")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cl.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &pod)).To(Succeed())
+			})
+
+			It("should create the service in the namespace of the PVC", func() {
+				Expect(svc.Namespace).To(Equal("default"))
+			})
+
+			It("should select the rsync server pod", func() {
+				Expect(svc.Spec.Selector).ToNot(BeEmpty())
+				Expect(pod.Labels).To(Equal(svc.Spec.Selector))
+			})
+
+			It("should mount the PVC in read-only mode", func() {
+				Expect(pod.Spec.Volumes).To(ConsistOf(HaveField("VolumeSource.PersistentVolumeClaim",
+					PointTo(Equal(corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc1", ReadOnly: true})))))
+			})
+
+			It("should run on the given node", func() {
+				Expect(pod.Spec.NodeName).To(Equal("node1"))
+			})
+
+			It("should be removed by deleteStreamSource", func() {
+				Expect(deleteStreamSource(ctx, cl, svc)).To(Succeed())
+				Expect(cl.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &pod)).To(BeNotFound())
+			})
+		})
+
+		When("creating a synchronization pass job", func() {
+
+			var (
+				job     *batchv1.Job
+				podSpec *corev1.PodSpec
+			)
+
+			BeforeEach(func() {
+				var err error
+				job, err = o.createStreamPassJob(ctx, newService("liqo-stream-source-abcde", "default"), newPvc("pvc1-liqo-move"))
+				Expect(err).ToNot(HaveOccurred())
+				podSpec = &job.Spec.Template.Spec
+			})
+
+			It("should create a job with the correct namespace", func() {
+				Expect(job.Namespace).To(Equal("default"))
+			})
+
+			It("should connect to the rsync server", func() {
+				Expect(podSpec.Containers).To(HaveLen(1))
+				Expect(podSpec.Containers[0].Command).To(ContainElement("rsync://liqo@liqo-stream-source-abcde:873/data/"))
+				Expect(podSpec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "RSYNC_PASSWORD", Value: "rsync-password"}))
+			})
+
+			It("should bind the pod to the target node", func() {
+				Expect(podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(
+					ConsistOf(HaveField("MatchExpressions", ConsistOf(HaveField("Values", ConsistOf("target-node"))))))
+			})
+
+			It("should mount the target PVC", func() {
+				Expect(podSpec.Volumes).To(ConsistOf(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName", "pvc1-liqo-move")))
+			})
+		})
+
+		Context("workloads mounting the volume", func() {
+
+			var (
+				replicaSet  *appsv1.ReplicaSet
+				deployment  *appsv1.Deployment
+				statefulSet *appsv1.StatefulSet
+			)
+
+			var ownedBy = func(pod *corev1.Pod, owner client.Object, kind string) *corev1.Pod {
+				pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner.GetName(), Controller: ptr.To(true)}}
+				return pod
+			}
+
+			BeforeEach(func() {
+				deployment = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "default"},
+					Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)}}
+				replicaSet = &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "deploy-12345", Namespace: "default"}}
+				replicaSet.OwnerReferences = []metav1.OwnerReference{{Kind: "Deployment", Name: deployment.Name, Controller: ptr.To(true)}}
+				statefulSet = newStatefulSet("sts", "default")
+				statefulSet.Spec.Replicas = ptr.To[int32](3)
+			})
+
+			It("should return the workloads managing the mounting pods", func() {
+				cl = fake.NewClientBuilder().WithObjects(deployment, replicaSet, statefulSet,
+					ownedBy(newPod("pod1", "default", []string{"pvc1"}), replicaSet, "ReplicaSet"),
+					ownedBy(newPod("pod2", "default", []string{"pvc1"}), replicaSet, "ReplicaSet"),
+					ownedBy(newPod("pod3", "default", []string{"pvc1"}), statefulSet, "StatefulSet"),
+					ownedBy(newPod("pod4", "default", []string{"pvc2"}), statefulSet, "StatefulSet")).Build()
+
+				workloads, err := getMounterWorkloads(ctx, cl, newPvc("pvc1"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(workloads).To(ConsistOf(
+					scaledWorkload{Kind: "Deployment", Name: "deploy", Replicas: 2},
+					scaledWorkload{Kind: "StatefulSet", Name: "sts", Replicas: 3},
+				))
+			})
+
+			It("should fail if a mounting pod is not managed by a scalable workload", func() {
+				cl = fake.NewClientBuilder().WithObjects(newPod("pod1", "default", []string{"pvc1"})).Build()
+
+				_, err := getMounterWorkloads(ctx, cl, newPvc("pvc1"))
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should scale down and restore the workloads", func() {
+				cl = fake.NewClientBuilder().WithObjects(deployment, statefulSet).Build()
+				workloads := []scaledWorkload{{Kind: "Deployment", Name: "deploy", Replicas: 2}, {Kind: "StatefulSet", Name: "sts", Replicas: 3}}
+
+				Expect(scaleWorkloads(ctx, cl, "default", workloads, false)).To(Succeed())
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+				Expect(deployment.Spec.Replicas).To(PointTo(BeNumerically("==", 0)))
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet)).To(Succeed())
+				Expect(statefulSet.Spec.Replicas).To(PointTo(BeNumerically("==", 0)))
+
+				Expect(scaleWorkloads(ctx, cl, "default", workloads, true)).To(Succeed())
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+				Expect(deployment.Spec.Replicas).To(PointTo(BeNumerically("==", 2)))
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet)).To(Succeed())
+				Expect(statefulSet.Spec.Replicas).To(PointTo(BeNumerically("==", 3)))
+			})
+		})
+
+		Context("staging volume", func() {
+
+			var pvc *corev1.PersistentVolumeClaim
+
+			BeforeEach(func() {
+				pvc = newPvc("pvc1")
+				pvc.Labels = map[string]string{"app": "db"}
+				pvc.Spec.StorageClassName = ptr.To("liqo")
+				pvc.Spec.VolumeName = "pv1"
+			})
+
+			It("should not find a staging PVC if not existing", func() {
+				Expect(getStagingPvc(ctx, cl, pvc.Namespace, pvc.Name)).To(BeNil())
+			})
+
+			It("should create and track the staging PVC", func() {
+				staging, err := createStagingPvc(ctx, cl, pvc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(staging.Name).To(Equal("pvc1-liqo-move"))
+				Expect(staging.Spec.StorageClassName).To(PointTo(Equal("liqo")))
+				Expect(staging.Spec.VolumeName).To(BeEmpty())
+				Expect(staging.Annotations).To(HaveKeyWithValue(movePhaseAnnotation, string(streamPhaseSync)))
+
+				workloads := []scaledWorkload{{Kind: "Deployment", Name: "deploy", Replicas: 2}}
+				encoded, err := json.Marshal(workloads)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(setStagingAnnotation(ctx, cl, staging, scaledWorkloadsAnnotation, string(encoded))).To(Succeed())
+
+				retrieved, err := getStagingPvc(ctx, cl, pvc.Namespace, pvc.Name)
+				Expect(err).ToNot(HaveOccurred())
+				recorded, found, err := stagingWorkloads(retrieved)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(recorded).To(Equal(workloads))
+			})
+
+			It("should forge the replacement PVC from the staging one", func() {
+				staging, err := createStagingPvc(ctx, cl, pvc)
+				Expect(err).ToNot(HaveOccurred())
+
+				replacement := pvcFromStaging(staging, pvc.Name)
+				Expect(replacement.Name).To(Equal("pvc1"))
+				Expect(replacement.Labels).To(Equal(pvc.Labels))
+				Expect(replacement.Spec.StorageClassName).To(PointTo(Equal("liqo")))
+				Expect(isReplacementPvc(replacement)).To(BeTrue())
+			})
+
+			It("should bind the staging volume to the new PVC", func() {
+				deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "default"},
+					Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](0)}}
+				staging := newPvc("pvc1-liqo-move")
+				staging.Spec.VolumeName = "pv2"
+				staging.Annotations = map[string]string{movePhaseAnnotation: string(streamPhaseCutover),
+					scaledWorkloadsAnnotation: `[{"kind":"Deployment","name":"deploy","replicas":2}]`}
+				pv := newPv("pv2")
+				pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimDelete
+				pv.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: staging.Name, UID: staging.UID}
+
+				// Simulate the binding of the new PVC, performed by the persistent volume controller.
+				cl = fake.NewClientBuilder().WithObjects(pvc, staging, pv, deployment).WithInterceptorFuncs(interceptor.Funcs{
+					Create: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+						if created, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+							created.Status.Phase = corev1.ClaimBound
+						}
+						return cl.Create(ctx, obj, opts...)
+					},
+				}).Build()
+				o.CRClient = cl
+				o.Namespace, o.VolumeName = "default", "pvc1"
+				o.Printer = output.NewFakePrinter(GinkgoWriter)
+
+				Expect(o.completeFromStaging(ctx, pvc, staging)).To(Succeed())
+
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(staging), staging)).To(BeNotFound())
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)).To(Succeed())
+				Expect(pvc.Spec.VolumeName).To(Equal("pv2"))
+				Expect(pvc.Annotations).ToNot(HaveKey(movePhaseAnnotation))
+				Expect(pvc.Annotations).ToNot(HaveKey(scaledWorkloadsAnnotation))
+
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(pv), pv)).To(Succeed())
+				Expect(pv.Spec.ClaimRef).ToNot(BeNil())
+				Expect(pv.Spec.ClaimRef.Name).To(Equal("pvc1"))
+				Expect(pv.Spec.ClaimRef.UID).To(Equal(pvc.UID))
+				Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+				Expect(pv.Annotations).ToNot(HaveKey(moveReclaimPolicyAnnotation))
+
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+				Expect(deployment.Spec.Replicas).To(PointTo(BeNumerically("==", 2)))
+			})
+		})
+
+		Context("replica promotion", func() {
+
+			var pvc, replica *corev1.PersistentVolumeClaim
+
+			BeforeEach(func() {
+				pvc = newPvc("pvc1")
+				pvc.Labels = map[string]string{"app": "db"}
+				pvc.Spec.VolumeName = "pv1"
+
+				replica = addNodeMount(newPvc("pvc1-liqo-replica"), "replica-node")
+				replica.Labels = map[string]string{"app": "db",
+					liqoconst.ReplicationSourceLabel: "pvc1", liqoconst.ReplicationComponentLabel: "standby"}
+				replica.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "PersistentVolumeClaim", Name: "pvc1", UID: "pvc1"}}
+				replica.Spec.VolumeName = "pv2"
+
+				o.Printer = output.NewFakePrinter(GinkgoWriter)
+			})
+
+			It("should fail the checks if the replica has never been synchronized", func() {
+				cl = fake.NewClientBuilder().WithObjects(pvc, newPv("pv1")).Build()
+				o.CRClient = cl
+				Expect(o.checkReplicaSynchronized(ctx, pvc)).ToNot(Succeed())
+			})
+
+			It("should pass the checks if the replica has been synchronized", func() {
+				pv := newPv("pv1")
+				pv.Annotations = map[string]string{liqoconst.ReplicationLastSyncAnnotation: "2024-01-01T00:00:00Z"}
+				cl = fake.NewClientBuilder().WithObjects(pvc, pv).Build()
+				o.CRClient = cl
+				Expect(o.checkReplicaSynchronized(ctx, pvc)).To(Succeed())
+			})
+
+			It("should not consider the replication and the terminated pods as mounters", func() {
+				source := newPod("source", "default", []string{"pvc1"})
+				source.Labels = map[string]string{liqoconst.ReplicationComponentLabel: "source"}
+				completed := newPod("completed", "default", []string{"pvc1"})
+				completed.Status.Phase = corev1.PodSucceeded
+				cl = fake.NewClientBuilder().WithObjects(source, completed, newPod("pod1", "default", []string{"pvc1"})).Build()
+
+				Expect(getMounterPods(ctx, cl, pvc)).To(ConsistOf(HaveField("Name", "pod1")))
+			})
+
+			It("should not consider the terminating pods hosted by not ready nodes as mounters", func() {
+				ready := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ready"}, Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}}
+				unreachable := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unreachable"}, Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}}}}
+
+				terminating := func(name, node string) *corev1.Pod {
+					pod := newPod(name, "default", []string{"pvc1"})
+					pod.Spec.NodeName = node
+					pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+					pod.Finalizers = []string{"test"}
+					return pod
+				}
+				cl = fake.NewClientBuilder().WithObjects(ready, unreachable, terminating("pod1", "ready"),
+					terminating("pod2", "unreachable"), terminating("pod3", "missing")).Build()
+
+				Expect(getMounterPods(ctx, cl, pvc)).To(ConsistOf(HaveField("Name", "pod1")))
+			})
+
+			It("should detach the replica from the original PVC", func() {
+				cl = fake.NewClientBuilder().WithObjects(pvc, replica).Build()
+				o.CRClient = cl
+				Expect(o.detachReplica(ctx, pvc, replica)).To(Succeed())
+
+				Expect(cl.Get(ctx, client.ObjectKeyFromObject(replica), replica)).To(Succeed())
+				Expect(replica.Labels).To(Equal(map[string]string{"app": "db"}))
+				Expect(replica.OwnerReferences).To(BeEmpty())
+				Expect(replica.Annotations).To(HaveKeyWithValue(movePhaseAnnotation, string(streamPhaseSync)))
+				_, found, err := stagingWorkloads(replica)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+
+				replacement := pvcFromStaging(replica, pvc.Name)
+				Expect(replacement.Labels).To(Equal(pvc.Labels))
+			})
+		})
+
+		DescribeTable("checkStreamable function", func(mutate func(*corev1.PersistentVolumeClaim), expected OmegaMatcher) {
+			pvc := newPvc("pvc1")
+			pvc.Spec.VolumeName = "pv1"
+			mutate(pvc)
+			Expect(checkStreamable(pvc)).To(expected)
+		},
+			Entry("bound filesystem volume", func(*corev1.PersistentVolumeClaim) {}, Succeed()),
+			Entry("unbound volume", func(pvc *corev1.PersistentVolumeClaim) { pvc.Spec.VolumeName = "" }, Not(Succeed())),
+			Entry("block volume", func(pvc *corev1.PersistentVolumeClaim) {
+				pvc.Spec.VolumeMode = ptr.To(corev1.PersistentVolumeBlock)
+			}, Not(Succeed())),
+		)
+	})
+
 })
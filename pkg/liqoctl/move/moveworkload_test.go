@@ -0,0 +1,231 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Context("Move Workloads", func() {
+
+	var ctx = context.Background()
+
+	var newNode = func(name string, virtual bool) *corev1.Node {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelHostname: name}}}
+		if virtual {
+			node.Labels[liqoconst.TypeLabel] = liqoconst.TypeNode
+		}
+		return node
+	}
+
+	var newBoundPvc = func(name, node string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default",
+				Annotations: map[string]string{"volume.kubernetes.io/selected-node": node}},
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv-" + name},
+		}
+	}
+
+	DescribeTable("ParseWorkload function", func(workload, expectedKind, expectedName string, expectedErr OmegaMatcher) {
+		kind, name, err := ParseWorkload(workload)
+		Expect(err).To(expectedErr)
+		Expect(kind).To(Equal(expectedKind))
+		Expect(name).To(Equal(expectedName))
+	},
+		Entry("statefulset", "statefulset/db", "StatefulSet", "db", Not(HaveOccurred())),
+		Entry("statefulset short name", "sts/db", "StatefulSet", "db", Not(HaveOccurred())),
+		Entry("deployment", "Deployment/web", "Deployment", "web", Not(HaveOccurred())),
+		Entry("deployment short name", "deploy/web", "Deployment", "web", Not(HaveOccurred())),
+		Entry("missing kind", "web", "", "", HaveOccurred()),
+		Entry("missing name", "deployment/", "", "", HaveOccurred()),
+		Entry("unsupported kind", "daemonset/agent", "", "", HaveOccurred()),
+	)
+
+	Context("withNodeRequirement function", func() {
+
+		var requirement = corev1.NodeSelectorRequirement{
+			Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{"target"}}
+
+		It("should create the node affinity if not present", func() {
+			affinity := withNodeRequirement(nil, requirement)
+			Expect(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(
+				ConsistOf(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{requirement}}))
+		})
+
+		It("should add the requirement to every term, without modifying the original affinity", func() {
+			zone := corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpExists}
+			original := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{zone}}, {},
+				}},
+			}}
+
+			affinity := withNodeRequirement(original, requirement)
+			Expect(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(ConsistOf(
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{zone, requirement}},
+				corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{requirement}},
+			))
+			Expect(original.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(HaveLen(1))
+		})
+	})
+
+	Context("describeWorkload function", func() {
+
+		var template = corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+			{Name: "shared", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "shared"}}},
+			{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}}}
+
+		It("should return the PVCs generated from the templates of a StatefulSet", func() {
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:             ptr.To[int32](2),
+					Template:             template,
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+				},
+			}
+
+			replicas, _, _, claims := describeWorkload(sts)
+			Expect(replicas).To(BeNumerically("==", 2))
+			Expect(claims).To(ConsistOf("data-db-0", "data-db-1", "shared"))
+		})
+
+		It("should return the PVCs mounted by a Deployment", func() {
+			deploy := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Template: template},
+			}
+
+			replicas, _, _, claims := describeWorkload(deploy)
+			Expect(replicas).To(BeNumerically("==", 1))
+			Expect(claims).To(ConsistOf("shared"))
+		})
+	})
+
+	It("getWorkloadVolumes should return only the bound volumes not yet in the target node", func() {
+		unbound := newBoundPvc("unbound", "")
+		unbound.Spec.VolumeName = ""
+
+		cl := fake.NewClientBuilder().WithObjects(newNode("source", false), newNode("target", true),
+			newBoundPvc("data-0", "source"), newBoundPvc("data-1", "target"), unbound).Build()
+
+		volumes, err := getWorkloadVolumes(ctx, cl, "default", []string{"data-0", "data-1", "unbound", "missing"}, newNode("target", true))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(volumes).To(ConsistOf(movedVolume{Name: "data-0", OriginNode: "source"}))
+	})
+
+	It("getWorkloadVolumes should skip the volumes hosted by the same remote cluster of the target node", func() {
+		withClusterID := func(node *corev1.Node, clusterID string) *corev1.Node {
+			node.Labels[liqoconst.RemoteClusterID] = clusterID
+			return node
+		}
+
+		target := withClusterID(newNode("target", true), "cluster-1")
+		cl := fake.NewClientBuilder().WithObjects(target,
+			withClusterID(newNode("sibling", true), "cluster-1"), withClusterID(newNode("other", true), "cluster-2"),
+			newBoundPvc("data-0", "sibling"), newBoundPvc("data-1", "other")).Build()
+
+		volumes, err := getWorkloadVolumes(ctx, cl, "default", []string{"data-0", "data-1"}, target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(volumes).To(ConsistOf(movedVolume{Name: "data-1", OriginNode: "other"}))
+	})
+
+	DescribeTable("checkNamespaceOffloading function", func(target *corev1.Node, objects []client.Object, expected OmegaMatcher) {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		Expect(offloadingv1beta1.AddToScheme(scheme)).To(Succeed())
+
+		cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		Expect(checkNamespaceOffloading(ctx, cl, "default", target)).To(expected)
+	},
+		Entry("local node, namespace not offloaded", newNode("local", false), nil, Succeed()),
+		Entry("virtual node, namespace not offloaded", newNode("virtual", true), nil, Not(Succeed())),
+		Entry("virtual node, namespace offloaded to all clusters", newNode("virtual", true), []client.Object{
+			newNamespaceOffloading(offloadingv1beta1.LocalAndRemotePodOffloadingStrategyType)}, Succeed()),
+		Entry("virtual node, namespace offloaded to the selected cluster", newNode("virtual", true), []client.Object{
+			newNamespaceOffloading(offloadingv1beta1.RemotePodOffloadingStrategyType, "virtual")}, Succeed()),
+		Entry("virtual node, namespace offloaded to a different cluster", newNode("virtual", true), []client.Object{
+			newNamespaceOffloading(offloadingv1beta1.RemotePodOffloadingStrategyType, "other")}, Not(Succeed())),
+		Entry("virtual node, pods not allowed to be offloaded", newNode("virtual", true), []client.Object{
+			newNamespaceOffloading(offloadingv1beta1.LocalPodOffloadingStrategyType)}, Not(Succeed())),
+		Entry("local node, pods allowed only remotely", newNode("local", false), []client.Object{
+			newNamespaceOffloading(offloadingv1beta1.RemotePodOffloadingStrategyType)}, Not(Succeed())),
+	)
+
+	Context("workload updates", func() {
+
+		var (
+			cl  client.Client
+			sts *appsv1.StatefulSet
+		)
+
+		BeforeEach(func() {
+			sts = &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Generation: 1},
+				Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To[int32](2)},
+			}
+			cl = fake.NewClientBuilder().WithObjects(sts).WithStatusSubresource(sts).Build()
+		})
+
+		It("should update the replicas and the pod template", func() {
+			affinity := withNodeRequirement(nil, corev1.NodeSelectorRequirement{
+				Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{"target"}})
+			Expect(updateWorkload(ctx, cl, "default", "StatefulSet", "db", func(replicas *int32, template *corev1.PodTemplateSpec) {
+				*replicas = 0
+				template.Spec.Affinity = affinity
+			})).To(Succeed())
+
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(sts), sts)).To(Succeed())
+			Expect(sts.Spec.Replicas).To(PointTo(BeNumerically("==", 0)))
+			Expect(sts.Spec.Template.Spec.Affinity).To(Equal(affinity))
+		})
+
+		It("should report the readiness of the workload", func() {
+			Expect(isWorkloadReady(ctx, cl, "default", "StatefulSet", "db")).To(BeFalse())
+
+			Expect(cl.Get(ctx, client.ObjectKeyFromObject(sts), sts)).To(Succeed())
+			sts.Status = appsv1.StatefulSetStatus{ObservedGeneration: sts.Generation, UpdatedReplicas: 2, ReadyReplicas: 2}
+			Expect(cl.Status().Update(ctx, sts)).To(Succeed())
+			Expect(isWorkloadReady(ctx, cl, "default", "StatefulSet", "db")).To(BeTrue())
+		})
+	})
+})
+
+func newNamespaceOffloading(strategy offloadingv1beta1.PodOffloadingStrategyType, nodes ...string) *offloadingv1beta1.NamespaceOffloading {
+	nsoff := &offloadingv1beta1.NamespaceOffloading{
+		ObjectMeta: metav1.ObjectMeta{Name: liqoconst.DefaultNamespaceOffloadingName, Namespace: "default"},
+		Spec:       offloadingv1beta1.NamespaceOffloadingSpec{PodOffloadingStrategy: strategy},
+	}
+	if len(nodes) > 0 {
+		nsoff.Spec.ClusterSelector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: nodes}}}}
+	}
+	return nsoff
+}
@@ -24,6 +24,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
+	k8shelper "k8s.io/component-helpers/scheduling/corev1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
@@ -31,7 +32,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils"
 )
 
-func offloadLiqoStorageNamespace(ctx context.Context, cl client.Client, originNode, targetNode *corev1.Node) error {
+func offloadLiqoStorageNamespace(ctx context.Context, cl client.Client, nodes ...*corev1.Node) error {
 	namespaceOffloading := &offloadingv1beta1.NamespaceOffloading{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      liqoconst.DefaultNamespaceOffloadingName,
@@ -47,7 +48,7 @@ func offloadLiqoStorageNamespace(ctx context.Context, cl client.Client, originNo
 							{
 								Key:      "kubernetes.io/hostname",
 								Operator: corev1.NodeSelectorOpIn,
-								Values:   getRemoteNodeNames(originNode, targetNode),
+								Values:   getRemoteNodeNames(nodes...),
 							},
 						},
 					},
@@ -118,3 +119,36 @@ func getRemoteStorageNamespaceName(ctx context.Context, cl client.Client, backof
 
 	return nsOffloading.Status.RemoteNamespaceName, nil
 }
+
+// checkNamespaceOffloading checks whether the offloading of the given namespace allows its pods to be scheduled onto the target node.
+func checkNamespaceOffloading(ctx context.Context, cl client.Client, namespace string, targetNode *corev1.Node) error {
+	var nsOffloading offloadingv1beta1.NamespaceOffloading
+	err := cl.Get(ctx, client.ObjectKey{Name: liqoconst.DefaultNamespaceOffloadingName, Namespace: namespace}, &nsOffloading)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	if !utils.IsVirtualNode(targetNode) {
+		if err == nil && nsOffloading.Spec.PodOffloadingStrategy == offloadingv1beta1.RemotePodOffloadingStrategyType {
+			return fmt.Errorf("namespace %q does not allow pods to be scheduled onto the local cluster", namespace)
+		}
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("namespace %q is not offloaded", namespace)
+	}
+	if nsOffloading.Spec.PodOffloadingStrategy == offloadingv1beta1.LocalPodOffloadingStrategyType {
+		return fmt.Errorf("namespace %q does not allow pods to be offloaded to remote clusters", namespace)
+	}
+	if len(nsOffloading.Spec.ClusterSelector.NodeSelectorTerms) > 0 {
+		match, err := k8shelper.MatchNodeSelectorTerms(targetNode, &nsOffloading.Spec.ClusterSelector)
+		if err != nil {
+			return err
+		}
+		if !match {
+			return fmt.Errorf("namespace %q is not offloaded to the cluster represented by node %q", namespace, targetNode.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/storageprovisioner"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+// runPromoteReplica implements the move volume command promoting the standby replica of the volume, as configured through
+// the replication annotations. The replica is detached from the original volume, which is then replaced by a new PVC
+// bound to the volume of the replica (or, if hosted by a remote cluster, populated from it without crossing the network fabric).
+// As in case of the stream engine, the progress is tracked on the replica, so that an interrupted promotion can be resumed
+// by executing the same command again.
+func (o *Options) runPromoteReplica(ctx context.Context) error {
+	s := o.Printer.StartSpinner("Running pre-flight checks")
+
+	var replica, pvc corev1.PersistentVolumeClaim
+	replicaKey := client.ObjectKey{Namespace: o.Namespace, Name: storageprovisioner.ReplicaPvcName(o.VolumeName)}
+	replicaErr := o.CRClient.Get(ctx, replicaKey, &replica)
+	pvcErr := o.CRClient.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.VolumeName}, &pvc)
+
+	var phase streamPhase
+	switch {
+	case replicaErr == nil:
+		phase = streamPhase(replica.Annotations[movePhaseAnnotation])
+	case apierrors.IsNotFound(replicaErr) && pvcErr == nil && isReplacementPvc(&pvc):
+		// The replica has already been replaced by the new PVC, which is still to be completed.
+		phase = streamPhaseCutover
+	default:
+		s.Fail(fmt.Sprintf("Failed to get the replica of PVC %s/%s: %v", o.Namespace, o.VolumeName, output.PrettyErr(replicaErr)))
+		return replicaErr
+	}
+
+	// The original PVC is missing only if the promotion was interrupted while replacing it.
+	if client.IgnoreNotFound(pvcErr) != nil || (pvcErr != nil && phase != streamPhaseCutover) {
+		s.Fail(fmt.Sprintf("Failed to get PVC %s/%s: %v", o.Namespace, o.VolumeName, output.PrettyErr(pvcErr)))
+		return pvcErr
+	}
+
+	var staging *corev1.PersistentVolumeClaim
+	if replicaErr == nil {
+		staging = &replica
+
+		// The replica is possibly copied to a new volume created on the node hosting it.
+		if replica.Spec.VolumeName == "" || replica.Annotations["volume.kubernetes.io/selected-node"] == "" {
+			err := fmt.Errorf("the replica (%s/%s) has not been provisioned yet", replica.Namespace, replica.Name)
+			s.Fail("Failed to check the replica: ", output.PrettyErr(err))
+			return err
+		}
+		targetNode, err := getVolumeNode(ctx, o.CRClient, &replica)
+		if err != nil {
+			s.Fail("Failed to retrieve the node hosting the replica: ", output.PrettyErr(err))
+			return err
+		}
+		o.TargetNode = targetNode.Name
+	}
+
+	if phase == "" {
+		if err := o.checkReplicaSynchronized(ctx, &pvc); err != nil {
+			s.Fail("Failed to check the replica: ", output.PrettyErr(err))
+			return err
+		}
+	}
+	s.Success("Pre-flight checks passed")
+
+	if phase != "" {
+		o.Printer.Info.Printfln("Resuming the interrupted promotion of the replica of PVC %s/%s", o.Namespace, o.VolumeName)
+	}
+
+	if phase != streamPhaseCutover {
+		if err := o.detachReplica(ctx, &pvc, &replica); err != nil {
+			o.Printer.Info.Println("Execute the same command again to resume the promotion")
+			return err
+		}
+
+		// The synchronization possibly in progress is let complete, to avoid promoting a partially updated replica.
+		s = o.Printer.StartSpinner("Waiting for the replication to be stopped")
+		if err := waitForNoMounter(ctx, o.CRClient, &replica); err != nil {
+			s.Fail("Failed to wait for the replication to be stopped: ", output.PrettyErr(err))
+			o.Printer.Info.Println("Execute the same command again to resume the promotion")
+			return err
+		}
+		s.Success("Replication stopped")
+
+		if err := setStagingAnnotation(ctx, o.CRClient, &replica, movePhaseAnnotation, string(streamPhaseCutover)); err != nil {
+			o.Printer.Error.Println("Failed to update the replica: ", output.PrettyErr(err))
+			return err
+		}
+	}
+
+	var original *corev1.PersistentVolumeClaim
+	if pvcErr == nil {
+		original = &pvc
+	}
+	if err := o.completeFromStaging(ctx, original, staging); err != nil {
+		o.Printer.Info.Println("Execute the same command again to resume the promotion")
+		return err
+	}
+	return nil
+}
+
+// checkReplicaSynchronized checks whether the replica of the given PVC has been synchronized at least once,
+// and informs the user about the data that would be lost in case of promotion.
+func (o *Options) checkReplicaSynchronized(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	var pv corev1.PersistentVolume
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+		return err
+	}
+
+	lastSync, found := pv.Annotations[consts.ReplicationLastSyncAnnotation]
+	if !found {
+		return fmt.Errorf("the replica of the volume (%s/%s) has never been synchronized", pvc.Namespace, pvc.Name)
+	}
+	lag := "unknown"
+	if parsed, err := time.Parse(time.RFC3339, lastSync); err == nil {
+		lag = time.Since(parsed).Round(time.Second).String()
+	}
+	o.Printer.Info.Printfln("The replica was last synchronized at %s (lag: %s); subsequent changes are lost", lastSync, lag)
+	return nil
+}
+
+// detachReplica detaches the replica from the original PVC, so that it is no longer synchronized (nor garbage collected
+// along with the original PVC), and scales down the workloads mounting the original PVC. The workloads are recorded on
+// the replica, to be restored once the promotion completes.
+func (o *Options) detachReplica(ctx context.Context, pvc, replica *corev1.PersistentVolumeClaim) error {
+	s := o.Printer.StartSpinner("Scaling down the workloads mounting the volume")
+
+	workloads, scaled, err := stagingWorkloads(replica)
+	if err != nil {
+		s.Fail("Failed to retrieve the workloads mounting the volume: ", output.PrettyErr(err))
+		return err
+	}
+
+	if !scaled {
+		if workloads, err = getMounterWorkloads(ctx, o.CRClient, pvc); err != nil {
+			s.Fail("Failed to retrieve the workloads mounting the volume: ", output.PrettyErr(err))
+			return err
+		}
+		encoded, err := json.Marshal(workloads)
+		if err != nil {
+			s.Fail("Failed to encode the workloads: ", output.PrettyErr(err))
+			return err
+		}
+
+		delete(replica.Labels, consts.ReplicationSourceLabel)
+		delete(replica.Labels, consts.ReplicationComponentLabel)
+		replica.OwnerReferences = nil
+		if replica.Annotations == nil {
+			replica.Annotations = map[string]string{}
+		}
+		replica.Annotations[movePhaseAnnotation] = string(streamPhaseSync)
+		replica.Annotations[scaledWorkloadsAnnotation] = string(encoded)
+		if err := o.CRClient.Update(ctx, replica); err != nil {
+			s.Fail("Failed to detach the replica: ", output.PrettyErr(err))
+			return err
+		}
+	}
+
+	if err := scaleWorkloads(ctx, o.CRClient, pvc.Namespace, workloads, false); err != nil {
+		s.Fail("Failed to scale down the workloads: ", output.PrettyErr(err))
+		return err
+	}
+	if err := waitForNoMounter(ctx, o.CRClient, pvc); err != nil {
+		s.Fail("Failed to wait for the workloads to be scaled down: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Scaled down %d workloads mounting the volume", len(workloads)))
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
@@ -29,7 +30,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// resticRepository identifies a Restic repository, along with the environment variables required to access it (e.g., the credentials).
+type resticRepository struct {
+	URL string
+	Env []corev1.EnvVar
+}
+
+// resticEnv returns the environment variables configuring the Restic client to access the given repository.
+func (o *Options) resticEnv(repository *resticRepository) []corev1.EnvVar {
+	return append([]corev1.EnvVar{{Name: "RESTIC_PASSWORD", Value: o.ResticPassword}}, repository.Env...)
+}
+
 func (o *Options) ensureResticRepository(ctx context.Context, targetPvc *corev1.PersistentVolumeClaim) error {
+	return o.ensureResticRepositoryWithSize(ctx, targetPvc.Spec.Resources.Requests[corev1.ResourceStorage])
+}
+
+func (o *Options) ensureResticRepositoryWithSize(ctx context.Context, size resource.Quantity) error {
 	svc := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      resticRegistry,
@@ -126,7 +142,7 @@ func (o *Options) ensureResticRepository(ctx context.Context, targetPvc *corev1.
 						},
 						Resources: corev1.VolumeResourceRequirements{
 							Requests: corev1.ResourceList{
-								corev1.ResourceStorage: targetPvc.Spec.Resources.Requests[corev1.ResourceStorage],
+								corev1.ResourceStorage: size,
 							},
 						},
 					},
@@ -0,0 +1,271 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// RestoreOptions encapsulates the arguments of the restore namespace command.
+type RestoreOptions struct {
+	Options
+
+	Location    string
+	S3          S3Options
+	SkipVolumes bool
+}
+
+// Run implements the restore namespace command.
+func (o *RestoreOptions) Run(ctx context.Context) error {
+	// we need a context that is not canceled even if the user press Ctrl+C
+	deferCtx := context.Background()
+
+	s := o.Printer.StartSpinner("Running pre-flight checks")
+
+	store, err := newBackupStore(o.Location, &o.S3)
+	if err != nil {
+		s.Fail("Failed to initialize the backup location: ", output.PrettyErr(err))
+		return err
+	}
+
+	info, manifests, err := loadBackup(ctx, store)
+	if err != nil {
+		s.Fail(fmt.Sprintf("Failed to load the backup from %q: %v", o.Location, output.PrettyErr(err)))
+		return err
+	}
+
+	var pvcs []backupPvc
+	if !o.SkipVolumes {
+		if pvcs, err = o.restoredVolumes(ctx, info); err != nil {
+			s.Fail("Failed to determine where the volumes shall be restored: ", output.PrettyErr(err))
+			return err
+		}
+	}
+	s.Success(fmt.Sprintf("Loaded the backup of namespace %q, taken at %s", info.Namespace, info.Timestamp.Format(time.RFC3339)))
+
+	s = o.Printer.StartSpinner(fmt.Sprintf("Restoring namespace %q", o.Namespace))
+	for _, manifest := range manifests {
+		switch manifest.GetKind() {
+		case "Namespace":
+			manifest.SetName(o.Namespace)
+		case "NamespaceOffloading", "PersistentVolumeClaim":
+		default:
+			continue
+		}
+
+		if err := o.createManifest(ctx, manifest); err != nil {
+			s.Fail(fmt.Sprintf("Failed to restore %s %q: %v", manifest.GetKind(), manifest.GetName(), output.PrettyErr(err)))
+			return err
+		}
+	}
+
+	if err := o.waitForNamespaceOffloading(ctx, manifests, pvcs); err != nil {
+		s.Fail("Failed to wait for the namespace to be offloaded: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Namespace %q restored", o.Namespace))
+
+	if len(pvcs) > 0 {
+		if err := o.restoreVolumes(ctx, deferCtx, store, pvcs); err != nil {
+			return err
+		}
+	}
+
+	s = o.Printer.StartSpinner("Restoring the manifests")
+	var restored int
+	for _, manifest := range manifests {
+		switch manifest.GetKind() {
+		case "Namespace", "NamespaceOffloading", "PersistentVolumeClaim":
+			continue
+		}
+
+		if err := o.createManifest(ctx, manifest); err != nil {
+			s.Fail(fmt.Sprintf("Failed to restore %s %q: %v", manifest.GetKind(), manifest.GetName(), output.PrettyErr(err)))
+			return err
+		}
+		restored++
+	}
+	s.Success(fmt.Sprintf("Restored %d manifests", restored))
+	return nil
+}
+
+// loadBackup retrieves the backup information and the manifests from the given backup location.
+func loadBackup(ctx context.Context, store backupStore) (*backupInfo, []*unstructured.Unstructured, error) {
+	data, err := store.get(ctx, backupInfoKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve the backup information: %w", err)
+	}
+	var info backupInfo
+	if err := yaml.Unmarshal(data, &info); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the backup information: %w", err)
+	}
+
+	if data, err = store.get(ctx, backupManifestsKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve the manifests: %w", err)
+	}
+	manifests, err := decodeManifests(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode the manifests: %w", err)
+	}
+
+	return &info, manifests, nil
+}
+
+// createManifest creates the given object in the target namespace. Already existing namespaces and objects are
+// left untouched, apart from PVCs, which would otherwise be restored over existing data.
+func (o *RestoreOptions) createManifest(ctx context.Context, manifest *unstructured.Unstructured) error {
+	if manifest.GetKind() != "Namespace" {
+		manifest.SetNamespace(o.Namespace)
+	}
+
+	err := o.CRClient.Create(ctx, manifest)
+	switch {
+	case apierrors.IsAlreadyExists(err) && manifest.GetKind() == "PersistentVolumeClaim" && !o.SkipVolumes:
+		return fmt.Errorf("the PVC already exists, and its data would be overwritten")
+	case apierrors.IsAlreadyExists(err):
+		if manifest.GetKind() != "Namespace" {
+			o.Printer.Warning.Printfln("%s %q already exists, skipping", manifest.GetKind(), manifest.GetName())
+		}
+		return nil
+	default:
+		return err
+	}
+}
+
+// restoredVolumes returns the volumes to be restored, along with the node they shall be restored onto.
+// The volumes are restored onto the target node, if specified, or onto the cluster originally hosting them.
+func (o *RestoreOptions) restoredVolumes(ctx context.Context, info *backupInfo) ([]backupPvc, error) {
+	var target *corev1.Node
+	if o.TargetNode != "" {
+		target = &corev1.Node{}
+		if err := o.CRClient.Get(ctx, client.ObjectKey{Name: o.TargetNode}, target); err != nil {
+			return nil, err
+		}
+	}
+
+	pvcs := make([]backupPvc, len(info.Volumes))
+	for i := range info.Volumes {
+		volume := info.Volumes[i]
+		pvcs[i] = backupPvc{volume: volume, node: target}
+		pvcs[i].pvc.SetName(volume.Name)
+		pvcs[i].pvc.SetNamespace(o.Namespace)
+
+		if target != nil || volume.ClusterID == "" {
+			continue
+		}
+
+		nodes, err := getters.ListNodesByClusterID(ctx, o.CRClient, liqov1beta1.ClusterID(volume.ClusterID))
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				err = fmt.Errorf("volume %q was hosted by cluster %q, which is not peered (use --target-node to select a different node)",
+					volume.Name, volume.ClusterID)
+			}
+			return nil, err
+		}
+		pvcs[i].node = &nodes.Items[0]
+	}
+
+	return pvcs, nil
+}
+
+// volumePlacement returns the node requirement attracting the restorer of the given volume in the appropriate cluster.
+func (o *RestoreOptions) volumePlacement(pvc *backupPvc) *corev1.NodeSelectorRequirement {
+	switch {
+	case o.TargetNode != "":
+		return &corev1.NodeSelectorRequirement{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{o.TargetNode}}
+	case pvc.volume.ClusterID != "":
+		return &corev1.NodeSelectorRequirement{
+			Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{pvc.volume.ClusterID}}
+	default:
+		return &corev1.NodeSelectorRequirement{Key: liqoconst.TypeLabel, Operator: corev1.NodeSelectorOpDoesNotExist}
+	}
+}
+
+// waitForNamespaceOffloading waits for the restored namespace to be offloaded, if required, and checks that the volumes
+// can be restored onto the selected nodes.
+func (o *RestoreOptions) waitForNamespaceOffloading(ctx context.Context, manifests []*unstructured.Unstructured, pvcs []backupPvc) error {
+	for _, manifest := range manifests {
+		if manifest.GetKind() != "NamespaceOffloading" {
+			continue
+		}
+
+		if err := waitFor(ctx, 5*time.Minute, func() (bool, error) {
+			var nsOffloading offloadingv1beta1.NamespaceOffloading
+			if err := o.CRClient.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: manifest.GetName()}, &nsOffloading); err != nil {
+				return false, err
+			}
+			return nsOffloading.Status.OffloadingPhase == offloadingv1beta1.ReadyOffloadingPhaseType, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i := range pvcs {
+		if pvcs[i].node == nil {
+			continue
+		}
+		if err := checkNamespaceOffloading(ctx, o.CRClient, o.Namespace, pvcs[i].node); err != nil {
+			return fmt.Errorf("volume %q cannot be restored: %w", pvcs[i].volume.Name, err)
+		}
+	}
+	return nil
+}
+
+// restoreVolumes restores the data of the given PVCs from the backup location.
+func (o *RestoreOptions) restoreVolumes(ctx, deferCtx context.Context, store backupStore, pvcs []backupPvc) error {
+	repositories, cleanup, err := o.prepareVolumeRepositories(ctx, deferCtx, store, pvcs)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if dir, ok := store.(*dirStore); ok {
+		s := o.Printer.StartSpinner("Uploading the volume repositories")
+		if err := o.uploadResticRegistry(ctx, dir.root); err != nil {
+			s.Fail("Failed to upload the volume repositories: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Volume repositories uploaded")
+	}
+
+	for i := range pvcs {
+		s := o.Printer.StartSpinner(fmt.Sprintf("Restoring volume %q", pvcs[i].volume.Name))
+		job, err := o.createRestorerJobForRepository(ctx, o.Namespace, pvcs[i].volume.Name, repositories[i], o.volumePlacement(&pvcs[i]))
+		if err == nil {
+			err = waitForJob(ctx, o.CRClient, job)
+		}
+		if err != nil {
+			s.Fail(fmt.Sprintf("Failed to restore volume %q: %v", pvcs[i].volume.Name, output.PrettyErr(err)))
+			return err
+		}
+		s.Success(fmt.Sprintf("Volume %q restored", pvcs[i].volume.Name))
+	}
+
+	return nil
+}
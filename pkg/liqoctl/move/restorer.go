@@ -37,10 +37,21 @@ func (o *Options) restoreSnapshot(ctx context.Context,
 func (o *Options) createRestorerJob(ctx context.Context,
 	oldPvc, newPvc *corev1.PersistentVolumeClaim,
 	resticRepositoryURL string) (*batchv1.Job, error) {
+	return o.createRestorerJobForRepository(ctx, oldPvc.GetNamespace(), newPvc.GetName(),
+		&resticRepository{URL: fmt.Sprintf("%s%s", resticRepositoryURL, oldPvc.GetUID())},
+		&corev1.NodeSelectorRequirement{
+			Key:      "kubernetes.io/hostname",
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{o.TargetNode},
+		})
+}
+
+func (o *Options) createRestorerJobForRepository(ctx context.Context, namespace, claimName string,
+	repository *resticRepository, placement *corev1.NodeSelectorRequirement) (*batchv1.Job, error) {
 	job := batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "liqo-restorer-",
-			Namespace:    oldPvc.GetNamespace(),
+			Namespace:    namespace,
 		},
 		Spec: batchv1.JobSpec{
 			TTLSecondsAfterFinished: pointer.Int32Ptr(10),
@@ -51,13 +62,7 @@ func (o *Options) createRestorerJob(ctx context.Context,
 							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
 								NodeSelectorTerms: []corev1.NodeSelectorTerm{
 									{
-										MatchExpressions: []corev1.NodeSelectorRequirement{
-											{
-												Key:      "kubernetes.io/hostname",
-												Operator: corev1.NodeSelectorOpIn,
-												Values:   []string{o.TargetNode},
-											},
-										},
+										MatchExpressions: []corev1.NodeSelectorRequirement{*placement},
 									},
 								},
 							},
@@ -70,16 +75,11 @@ func (o *Options) createRestorerJob(ctx context.Context,
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Args: []string{
 								"-r",
-								fmt.Sprintf("%s%s", resticRepositoryURL, oldPvc.GetUID()),
+								repository.URL,
 								"restore", "latest",
 								"--target", "/restore",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:       o.resticEnv(repository),
 							Resources: o.forgeContainerResources(),
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -95,7 +95,7 @@ func (o *Options) createRestorerJob(ctx context.Context,
 							Name: "restore",
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: newPvc.GetName(),
+									ClaimName: claimName,
 								},
 							},
 						},
@@ -37,6 +37,12 @@ func (o *Options) takeSnapshot(ctx context.Context, pvc *corev1.PersistentVolume
 
 func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.PersistentVolumeClaim,
 	resticRepositoryURL string) (*batchv1.Job, error) {
+	return o.createSnapshotterJobForRepository(ctx, pvc,
+		&resticRepository{URL: fmt.Sprintf("%s%s", resticRepositoryURL, pvc.GetUID())})
+}
+
+func (o *Options) createSnapshotterJobForRepository(ctx context.Context, pvc *corev1.PersistentVolumeClaim,
+	repository *resticRepository) (*batchv1.Job, error) {
 	var pv corev1.PersistentVolume
 	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, &pv); err != nil {
 		return nil, err
@@ -58,15 +64,10 @@ func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.Persiste
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Args: []string{
 								"-r",
-								fmt.Sprintf("%s%s", resticRepositoryURL, pvc.GetUID()),
+								repository.URL,
 								"init",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:       o.resticEnv(repository),
 							Resources: o.forgeContainerResources(),
 						},
 					},
@@ -77,16 +78,11 @@ func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.Persiste
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Args: []string{
 								"-r",
-								fmt.Sprintf("%s%s", resticRepositoryURL, pvc.GetUID()),
+								repository.URL,
 								"backup", ".",
 								"--host", "liqo",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:        o.resticEnv(repository),
 							Resources:  o.forgeContainerResources(),
 							WorkingDir: "/backup",
 							VolumeMounts: []corev1.VolumeMount{
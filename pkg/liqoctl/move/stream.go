@@ -0,0 +1,487 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// runStream implements the move volume command leveraging the stream engine. The data is first synchronized to a staging
+// volume in the target cluster while the mounting workloads are running, and then again (incrementally) once they have
+// been scaled down. The original volume is eventually replaced by the staging one, which is bound to the new PVC.
+// The progress is tracked on the staging volume (and then on the new PVC), so that an interrupted migration can be resumed
+// by executing the same command again.
+func (o *Options) runStream(ctx context.Context) error {
+	s := o.Printer.StartSpinner("Running pre-flight checks")
+
+	var targetNode corev1.Node
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: o.TargetNode}, &targetNode); err != nil {
+		s.Fail("Failed to get target node: ", output.PrettyErr(err))
+		return err
+	}
+
+	staging, err := getStagingPvc(ctx, o.CRClient, o.Namespace, o.VolumeName)
+	if err != nil {
+		s.Fail("Failed to get the staging PVC: ", output.PrettyErr(err))
+		return err
+	}
+
+	var pvc corev1.PersistentVolumeClaim
+	pvcErr := o.CRClient.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.VolumeName}, &pvc)
+	phase := streamPhaseSync
+	switch {
+	case staging != nil:
+		phase = streamPhase(staging.Annotations[movePhaseAnnotation])
+	case pvcErr == nil && isReplacementPvc(&pvc):
+		// The staging PVC has already been replaced by the new one, which is still to be completed.
+		phase = streamPhaseCutover
+	}
+
+	// The original PVC is missing only if the migration was interrupted while replacing it.
+	if client.IgnoreNotFound(pvcErr) != nil || (pvcErr != nil && phase != streamPhaseCutover) {
+		s.Fail(fmt.Sprintf("Failed to get PVC %s/%s: %v", o.Namespace, o.VolumeName, output.PrettyErr(pvcErr)))
+		return pvcErr
+	}
+
+	if staging == nil && phase == streamPhaseSync {
+		if err := checkStreamable(&pvc); err != nil {
+			s.Fail("Failed to check the PVC: ", output.PrettyErr(err))
+			return err
+		}
+	}
+	s.Success("Pre-flight checks passed")
+
+	if staging != nil || phase == streamPhaseCutover {
+		o.Printer.Info.Printfln("Resuming the interrupted migration of PVC %s/%s", o.Namespace, o.VolumeName)
+	}
+
+	if phase == streamPhaseSync {
+		if staging, err = o.streamToStaging(ctx, &pvc, staging); err != nil {
+			o.Printer.Info.Println("Execute the same command again to resume the migration")
+			return err
+		}
+
+		if err := setStagingAnnotation(ctx, o.CRClient, staging, movePhaseAnnotation, string(streamPhaseCutover)); err != nil {
+			o.Printer.Error.Println("Failed to update the staging PVC: ", output.PrettyErr(err))
+			return err
+		}
+	}
+
+	var original *corev1.PersistentVolumeClaim
+	if pvcErr == nil {
+		original = &pvc
+	}
+	if err := o.completeFromStaging(ctx, original, staging); err != nil {
+		o.Printer.Info.Println("Execute the same command again to resume the migration")
+		return err
+	}
+
+	return nil
+}
+
+// streamToStaging synchronizes the data of the original PVC to the staging one, which is created if not yet existing.
+// The configured number of passes is performed while the mounting workloads are running, followed by a final one
+// after they have been scaled down.
+func (o *Options) streamToStaging(ctx context.Context, pvc, staging *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	var err error
+	if staging == nil {
+		s := o.Printer.StartSpinner("Creating the staging volume")
+		if staging, err = createStagingPvc(ctx, o.CRClient, pvc); err != nil {
+			s.Fail("Failed to create the staging PVC: ", output.PrettyErr(err))
+			return nil, err
+		}
+		s.Success("Staging volume created")
+	}
+
+	// The workloads are retrieved from the staging PVC if already scaled down by a previous execution.
+	workloads, scaled, err := stagingWorkloads(staging)
+	if err != nil {
+		return nil, err
+	}
+	var node string
+	if !scaled {
+		if workloads, err = getMounterWorkloads(ctx, o.CRClient, pvc); err != nil {
+			o.Printer.Error.Println("Failed to retrieve the workloads mounting the volume: ", output.PrettyErr(err))
+			return nil, err
+		}
+		if node, err = getMounterNode(ctx, o.CRClient, pvc); err != nil {
+			o.Printer.Error.Println("Failed to retrieve the pods mounting the volume: ", output.PrettyErr(err))
+			return nil, err
+		}
+	}
+
+	return staging, o.withStreamSource(ctx, pvc, node, func(source *corev1.Service) error {
+		if !scaled && len(workloads) > 0 {
+			for i := 1; i <= o.LivePasses; i++ {
+				description := fmt.Sprintf("Synchronizing the volume while running (pass %d/%d)", i, o.LivePasses)
+				s := o.Printer.StartSpinner(description)
+				if err := o.streamPass(ctx, s, description, source, staging); err != nil {
+					s.Fail("Failed to synchronize the volume: ", output.PrettyErr(err))
+					return err
+				}
+				s.Success(fmt.Sprintf("Volume synchronized while running (pass %d/%d)", i, o.LivePasses))
+			}
+		}
+
+		if !scaled {
+			s := o.Printer.StartSpinner("Scaling down the workloads mounting the volume")
+			encoded, err := json.Marshal(workloads)
+			if err != nil {
+				s.Fail("Failed to encode the workloads: ", output.PrettyErr(err))
+				return err
+			}
+			if err := setStagingAnnotation(ctx, o.CRClient, staging, scaledWorkloadsAnnotation, string(encoded)); err != nil {
+				s.Fail("Failed to update the staging PVC: ", output.PrettyErr(err))
+				return err
+			}
+			if err := scaleWorkloads(ctx, o.CRClient, pvc.Namespace, workloads, false); err != nil {
+				s.Fail("Failed to scale down the workloads: ", output.PrettyErr(err))
+				return err
+			}
+			if err := waitForNoMounter(ctx, o.CRClient, pvc); err != nil {
+				s.Fail("Failed to wait for the workloads to be scaled down: ", output.PrettyErr(err))
+				return err
+			}
+			s.Success(fmt.Sprintf("Scaled down %d workloads mounting the volume", len(workloads)))
+		}
+
+		description := "Performing the final synchronization"
+		s := o.Printer.StartSpinner(description)
+		if err := o.streamPass(ctx, s, description, source, staging); err != nil {
+			s.Fail("Failed to synchronize the volume: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Final synchronization completed")
+		return nil
+	})
+}
+
+// completeFromStaging replaces the original PVC (nil if already deleted) with a new one in the target cluster, starting
+// from the given staging PVC (nil if already replaced by a previous execution). The staging volume is directly bound to
+// the new PVC, so that the workloads can be restored as soon as the PVCs have been swapped. Volumes hosted by remote
+// clusters are an exception, as the real PVC is named after the virtual one: their data is hence copied to a new volume.
+func (o *Options) completeFromStaging(ctx context.Context, pvc, staging *corev1.PersistentVolumeClaim) error {
+	if staging == nil {
+		return o.bindStagingVolume(ctx, pvc)
+	}
+
+	var pv corev1.PersistentVolume
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: staging.Spec.VolumeName}, &pv); err != nil {
+		o.Printer.Error.Println("Failed to get the staging volume: ", output.PrettyErr(err))
+		return err
+	}
+
+	if _, remote := utils.GetPersistentVolumeClusterID(&pv); remote {
+		o.Printer.Info.Println("The staging volume is hosted by a remote cluster, hence its data is copied to the new volume")
+
+		s := o.Printer.StartSpinner("Replacing the original volume")
+		if _, err := replacePvc(ctx, o.CRClient, pvc, pvcFromStaging(staging, o.VolumeName)); err != nil {
+			s.Fail("Failed to recreate PVC: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Original volume replaced")
+		return o.streamFromStaging(ctx, staging)
+	}
+
+	s := o.Printer.StartSpinner("Replacing the original volume")
+	// Retain the staging volume, so that it is not reclaimed along with the staging PVC.
+	if err := updateVolume(ctx, o.CRClient, pv.Name, func(pv *corev1.PersistentVolume) {
+		if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+			metav1.SetMetaDataAnnotation(&pv.ObjectMeta, moveReclaimPolicyAnnotation, string(pv.Spec.PersistentVolumeReclaimPolicy))
+			pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		}
+	}); err != nil {
+		s.Fail("Failed to retain the staging volume: ", output.PrettyErr(err))
+		return err
+	}
+
+	replacement := pvcFromStaging(staging, o.VolumeName)
+	replacement.Spec.VolumeName = pv.Name
+	replacement, err := replacePvc(ctx, o.CRClient, pvc, replacement)
+	if err != nil {
+		s.Fail("Failed to recreate PVC: ", output.PrettyErr(err))
+		return err
+	}
+	if err := client.IgnoreNotFound(o.CRClient.Delete(ctx, staging)); err != nil {
+		s.Fail("Failed to remove the staging PVC: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Original volume replaced")
+
+	return o.bindStagingVolume(ctx, replacement)
+}
+
+// bindStagingVolume binds the staging volume to the PVC replacing the original one, restores its reclaim policy,
+// and eventually restores the workloads scaled down during the migration.
+func (o *Options) bindStagingVolume(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	s := o.Printer.StartSpinner("Binding the staging volume to the new PVC")
+	if err := updateVolume(ctx, o.CRClient, pvc.Spec.VolumeName, func(pv *corev1.PersistentVolume) {
+		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.UID != pvc.UID {
+			pv.Spec.ClaimRef = &corev1.ObjectReference{APIVersion: "v1", Kind: "PersistentVolumeClaim",
+				Namespace: pvc.Namespace, Name: pvc.Name, UID: pvc.UID}
+		}
+	}); err != nil {
+		s.Fail("Failed to bind the staging volume: ", output.PrettyErr(err))
+		return err
+	}
+
+	if err := waitFor(ctx, time.Minute*5, func() (bool, error) {
+		if err := o.CRClient.Get(ctx, client.ObjectKeyFromObject(pvc), pvc); err != nil {
+			return false, err
+		}
+		return pvc.Status.Phase == corev1.ClaimBound, nil
+	}); err != nil {
+		s.Fail("Failed to wait for the new PVC to be bound: ", output.PrettyErr(err))
+		return err
+	}
+
+	if err := updateVolume(ctx, o.CRClient, pvc.Spec.VolumeName, func(pv *corev1.PersistentVolume) {
+		if policy, found := pv.Annotations[moveReclaimPolicyAnnotation]; found {
+			pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(policy)
+			delete(pv.Annotations, moveReclaimPolicyAnnotation)
+		}
+	}); err != nil {
+		s.Fail("Failed to restore the reclaim policy of the volume: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Staging volume bound to the new PVC")
+
+	workloads, _, err := stagingWorkloads(pvc)
+	if err != nil {
+		return err
+	}
+
+	s = o.Printer.StartSpinner("Restoring the workloads mounting the volume")
+	if err := scaleWorkloads(ctx, o.CRClient, o.Namespace, workloads, true); err != nil {
+		s.Fail("Failed to restore the workloads: ", output.PrettyErr(err))
+		return err
+	}
+	if err := completeReplacementPvc(ctx, o.CRClient, pvc); err != nil {
+		s.Fail("Failed to update the new PVC: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Migration completed")
+	return nil
+}
+
+// streamFromStaging copies the data from the staging PVC to the new one in the target cluster (hence, without crossing
+// the network fabric), restores the workloads scaled down during the migration, and finally removes the staging PVC.
+func (o *Options) streamFromStaging(ctx context.Context, staging *corev1.PersistentVolumeClaim) error {
+	workloads, _, err := stagingWorkloads(staging)
+	if err != nil {
+		return err
+	}
+
+	target := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: o.VolumeName, Namespace: o.Namespace}}
+	if err := o.withStreamSource(ctx, staging, "", func(source *corev1.Service) error {
+		description := "Populating the volume in the target cluster"
+		s := o.Printer.StartSpinner(description)
+		if err := o.streamPass(ctx, s, description, source, &target); err != nil {
+			s.Fail("Failed to populate the volume: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Volume populated in the target cluster")
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	s := o.Printer.StartSpinner("Restoring the workloads mounting the volume")
+	if err := scaleWorkloads(ctx, o.CRClient, o.Namespace, workloads, true); err != nil {
+		s.Fail("Failed to restore the workloads: ", output.PrettyErr(err))
+		return err
+	}
+	if err := client.IgnoreNotFound(o.CRClient.Delete(ctx, staging)); err != nil {
+		s.Fail("Failed to remove the staging PVC: ", output.PrettyErr(err))
+		return err
+	}
+	if err := o.CRClient.Get(ctx, client.ObjectKeyFromObject(&target), &target); err != nil {
+		s.Fail("Failed to get the new PVC: ", output.PrettyErr(err))
+		return err
+	}
+	if err := completeReplacementPvc(ctx, o.CRClient, &target); err != nil {
+		s.Fail("Failed to update the new PVC: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Migration completed")
+	return nil
+}
+
+// withStreamSource starts the rsync server exposing the data of the given PVC, and executes the given function.
+// The server is always torn down before returning.
+func (o *Options) withStreamSource(ctx context.Context, pvc *corev1.PersistentVolumeClaim, node string,
+	fn func(source *corev1.Service) error) error {
+	s := o.Printer.StartSpinner("Starting the rsync server")
+	source, err := o.createStreamSource(ctx, pvc, node)
+	if err != nil {
+		s.Fail("Failed to create the rsync server: ", output.PrettyErr(err))
+		return err
+	}
+
+	defer func() {
+		// we need a context that is not canceled even if the user press Ctrl+C
+		s = o.Printer.StartSpinner("Removing the rsync server")
+		if err := deleteStreamSource(context.Background(), o.CRClient, source); err != nil {
+			s.Fail("Failed to remove the rsync server: ", output.PrettyErr(err))
+			return
+		}
+		s.Success("Removed the rsync server")
+	}()
+
+	if err := waitForStreamSource(ctx, o.CRClient, source); err != nil {
+		s.Fail("Failed to wait for the rsync server to be up and running: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Rsync server is up and running")
+
+	return fn(source)
+}
+
+// checkStreamable checks whether the given PVC can be moved through the stream engine.
+func checkStreamable(pvc *corev1.PersistentVolumeClaim) error {
+	if pvc.Spec.VolumeName == "" {
+		return fmt.Errorf("the volume (%s/%s) is not bound", pvc.Namespace, pvc.Name)
+	}
+	if pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == corev1.PersistentVolumeBlock {
+		return fmt.Errorf("the volume (%s/%s) is a block volume, which is not supported by the stream engine", pvc.Namespace, pvc.Name)
+	}
+	return nil
+}
+
+// getMounterNode returns the node hosting the pods mounting the given PVC, if any.
+func getMounterNode(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	mounters, err := getMounterPods(ctx, cl, pvc)
+	if err != nil || len(mounters) == 0 {
+		return "", err
+	}
+	return mounters[0].Spec.NodeName, nil
+}
+
+func stagingPvcName(name string) string {
+	return name + stagingPvcSuffix
+}
+
+// getStagingPvc returns the staging PVC associated with the given one, or nil if it does not exist.
+func getStagingPvc(ctx context.Context, cl client.Client, namespace, name string) (*corev1.PersistentVolumeClaim, error) {
+	var staging corev1.PersistentVolumeClaim
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: stagingPvcName(name)}, &staging); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return &staging, nil
+}
+
+// createStagingPvc creates the staging PVC associated with the given one, mirroring its characteristics.
+func createStagingPvc(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	labels := maps.Clone(pvc.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[moveComponentLabel] = "staging"
+
+	staging := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        stagingPvcName(pvc.Name),
+			Namespace:   pvc.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{movePhaseAnnotation: string(streamPhaseSync)},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        pvc.Spec.Resources,
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+		},
+	}
+
+	if err := cl.Create(ctx, &staging); err != nil {
+		return nil, err
+	}
+	return &staging, nil
+}
+
+// pvcFromStaging forges the PVC replacing the original one, starting from the corresponding staging PVC.
+// The workloads scaled down during the migration are recorded on the new PVC, to be restored even if the staging
+// PVC has already been removed.
+func pvcFromStaging(staging *corev1.PersistentVolumeClaim, name string) *corev1.PersistentVolumeClaim {
+	labels := maps.Clone(staging.Labels)
+	delete(labels, moveComponentLabel)
+
+	annotations := map[string]string{movePhaseAnnotation: string(streamPhaseCutover)}
+	if workloads, found := staging.Annotations[scaledWorkloadsAnnotation]; found {
+		annotations[scaledWorkloadsAnnotation] = workloads
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   staging.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      staging.Spec.AccessModes,
+			Resources:        staging.Spec.Resources,
+			StorageClassName: staging.Spec.StorageClassName,
+			VolumeMode:       staging.Spec.VolumeMode,
+		},
+	}
+}
+
+// isReplacementPvc returns whether the given PVC replaces the original one, and the migration is still to be completed.
+func isReplacementPvc(pvc *corev1.PersistentVolumeClaim) bool {
+	return streamPhase(pvc.Annotations[movePhaseAnnotation]) == streamPhaseCutover
+}
+
+// completeReplacementPvc removes the annotations tracking the progress of the migration from the PVC replacing the original one.
+func completeReplacementPvc(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) error {
+	if _, found := pvc.Annotations[movePhaseAnnotation]; !found {
+		return nil
+	}
+	delete(pvc.Annotations, movePhaseAnnotation)
+	delete(pvc.Annotations, scaledWorkloadsAnnotation)
+	return cl.Update(ctx, pvc)
+}
+
+// setStagingAnnotation sets the given annotation on the staging PVC, to track the progress of the migration.
+func setStagingAnnotation(ctx context.Context, cl client.Client, staging *corev1.PersistentVolumeClaim, key, value string) error {
+	if staging.Annotations == nil {
+		staging.Annotations = map[string]string{}
+	}
+	staging.Annotations[key] = value
+	return cl.Update(ctx, staging)
+}
+
+// stagingWorkloads returns the workloads scaled down during the migration, as recorded on the staging PVC.
+func stagingWorkloads(staging *corev1.PersistentVolumeClaim) (workloads []scaledWorkload, found bool, err error) {
+	encoded, found := staging.Annotations[scaledWorkloadsAnnotation]
+	if !found {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal([]byte(encoded), &workloads); err != nil {
+		return nil, true, fmt.Errorf("failed to decode the workloads recorded on the staging PVC: %w", err)
+	}
+	return workloads, true, nil
+}
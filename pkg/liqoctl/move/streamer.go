@@ -0,0 +1,326 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/utils/rsync"
+)
+
+// rsyncProgressRegex matches the overall progress reported by rsync when started with the --info=progress2 flag,
+// e.g., "    123,456,789  45%   10.00MB/s    0:00:10 (xfr#12, to-chk=3/20)".
+var rsyncProgressRegex = regexp.MustCompile(`^\s*([\d,.]+\S*)\s+(\d+)%\s+(\S+/s)`)
+
+// createStreamSource creates the rsync server exposing the data of the given PVC, along with the service making it reachable
+// from the target cluster through the Liqo network fabric. If node is not empty, the server is forced to run on that node,
+// to allow mounting volumes with the ReadWriteOnce access mode concurrently with the existing pods.
+func (o *Options) createStreamSource(ctx context.Context, pvc *corev1.PersistentVolumeClaim, node string) (*corev1.Service, error) {
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "liqo-stream-source-",
+			Namespace:    pvc.GetNamespace(),
+			Labels:       map[string]string{moveComponentLabel: "source"},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Port:       rsync.Port,
+					TargetPort: intstr.FromInt(rsync.Port),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+	if err := o.CRClient.Create(ctx, &svc); err != nil {
+		return nil, err
+	}
+
+	// The service and the pod are coupled through the (unique) name of the service.
+	svc.Spec.Selector = map[string]string{moveComponentLabel: svc.GetName()}
+	if err := o.CRClient.Update(ctx, &svc); err != nil {
+		return nil, err
+	}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.GetName(),
+			Namespace: pvc.GetNamespace(),
+			Labels:    map[string]string{moveComponentLabel: svc.GetName()},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{
+				{
+					Name:            "rsync",
+					Image:           o.RsyncImage,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", rsync.DaemonScript()},
+					Env: []corev1.EnvVar{
+						{
+							Name:  rsync.PasswordEnv,
+							Value: o.RsyncPassword,
+						},
+					},
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: rsync.Port,
+						},
+					},
+					ReadinessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(rsync.Port)},
+						},
+					},
+					Resources: o.forgeContainerResources(),
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "source",
+							MountPath: rsync.MountPath,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "source",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: pvc.GetName(),
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := o.CRClient.Create(ctx, &pod); err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// deleteStreamSource deletes the rsync server and the corresponding service, waiting for the pod to be actually removed,
+// so that the source volume is no longer mounted.
+func deleteStreamSource(ctx context.Context, cl client.Client, svc *corev1.Service) error {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: svc.GetName(), Namespace: svc.GetNamespace()}}
+	if err := client.IgnoreNotFound(cl.Delete(ctx, &pod)); err != nil {
+		return err
+	}
+	if err := client.IgnoreNotFound(cl.Delete(ctx, svc)); err != nil {
+		return err
+	}
+
+	return waitFor(ctx, time.Minute*5, func() (bool, error) {
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(&pod), &pod); err != nil {
+			return apierrors.IsNotFound(err), client.IgnoreNotFound(err)
+		}
+		return false, nil
+	})
+}
+
+// waitForStreamSource waits for the rsync server to be ready to accept connections.
+func waitForStreamSource(ctx context.Context, cl client.Client, svc *corev1.Service) error {
+	var pod corev1.Pod
+	return waitFor(ctx, time.Minute*5, func() (bool, error) {
+		if err := cl.Get(ctx, client.ObjectKey{Name: svc.GetName(), Namespace: svc.GetNamespace()}, &pod); err != nil {
+			return false, err
+		}
+		for i := range pod.Status.Conditions {
+			if pod.Status.Conditions[i].Type == corev1.PodReady {
+				return pod.Status.Conditions[i].Status == corev1.ConditionTrue, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// createStreamPassJob creates the job performing an incremental synchronization pass from the given source to the target PVC.
+func (o *Options) createStreamPassJob(ctx context.Context, source *corev1.Service,
+	target *corev1.PersistentVolumeClaim) (*batchv1.Job, error) {
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "liqo-stream-pass-",
+			Namespace:    target.GetNamespace(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr.To[int32](3),
+			TTLSecondsAfterFinished: ptr.To[int32](60),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{
+									{
+										MatchExpressions: []corev1.NodeSelectorRequirement{
+											{
+												Key:      "kubernetes.io/hostname",
+												Operator: corev1.NodeSelectorOpIn,
+												Values:   []string{o.TargetNode},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:            "rsync",
+							Image:           o.RsyncImage,
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							// The source service is resolved through the search domains, as it lives in the same namespace
+							// (possibly remapped in the target cluster) of the job.
+							Command: rsync.ClientCommand(source.GetName()),
+							Env: []corev1.EnvVar{
+								{
+									Name:  rsync.PasswordEnv,
+									Value: o.RsyncPassword,
+								},
+							},
+							Resources: o.forgeContainerResources(),
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "target",
+									MountPath: rsync.MountPath,
+								},
+							},
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Volumes: []corev1.Volume{
+						{
+							Name: "target",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: target.GetName(),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := o.CRClient.Create(ctx, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// streamPass performs an incremental synchronization pass from the given source to the target PVC,
+// updating the spinner text with the progress reported by rsync.
+func (o *Options) streamPass(ctx context.Context, s *pterm.SpinnerPrinter, description string,
+	source *corev1.Service, target *corev1.PersistentVolumeClaim) error {
+	job, err := o.createStreamPassJob(ctx, source, target)
+	if err != nil {
+		return err
+	}
+
+	return waitFor(ctx, 0, func() (bool, error) {
+		if err := o.CRClient.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+			return false, nil
+		}
+
+		if job.Status.Succeeded > 0 {
+			return true, nil
+		}
+		for i := range job.Status.Conditions {
+			if job.Status.Conditions[i].Type == batchv1.JobFailed && job.Status.Conditions[i].Status == corev1.ConditionTrue {
+				return false, fmt.Errorf("job %s/%s failed: %s", job.GetNamespace(), job.GetName(), job.Status.Conditions[i].Message)
+			}
+		}
+
+		if progress, found := o.streamPassProgress(ctx, job); found {
+			s.UpdateText(fmt.Sprintf("%s (%s)", description, progress))
+		}
+		return false, nil
+	})
+}
+
+// streamPassProgress returns the last progress reported by the pod of the given job, if any.
+func (o *Options) streamPassProgress(ctx context.Context, job *batchv1.Job) (string, bool) {
+	if o.KubeClient == nil {
+		return "", false
+	}
+
+	var pods corev1.PodList
+	if err := o.CRClient.List(ctx, &pods, client.InNamespace(job.GetNamespace()),
+		client.MatchingLabels{batchv1.JobNameLabel: job.GetName()}); err != nil || len(pods.Items) == 0 {
+		return "", false
+	}
+
+	logs, err := o.KubeClient.CoreV1().Pods(job.GetNamespace()).GetLogs(pods.Items[0].GetName(),
+		&corev1.PodLogOptions{TailLines: ptr.To[int64](1)}).DoRaw(ctx)
+	if err != nil {
+		return "", false
+	}
+	return parseRsyncProgress(string(logs))
+}
+
+// parseRsyncProgress extracts the most recent progress update from the output of rsync,
+// which separates the subsequent updates with carriage returns.
+func parseRsyncProgress(output string) (string, bool) {
+	updates := strings.Split(strings.TrimSpace(output), "\r")
+	for i := len(updates) - 1; i >= 0; i-- {
+		if match := rsyncProgressRegex.FindStringSubmatch(updates[i]); match != nil {
+			return fmt.Sprintf("%s%%, %s bytes transferred at %s", match[2], match[1], match[3]), true
+		}
+	}
+	return "", false
+}
+
+// waitFor polls the given condition until it is satisfied, it returns an error, or the timeout (if positive) expires.
+func waitFor(ctx context.Context, timeout time.Duration, condition func() (bool, error)) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			done, err := condition()
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+		}
+	}
+}
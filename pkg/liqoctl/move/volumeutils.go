@@ -26,24 +26,70 @@ import (
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	liqov1beta1 "github.com/liqotech/liqo/apis/core/v1beta1"
 	"github.com/liqotech/liqo/pkg/utils"
+	"github.com/liqotech/liqo/pkg/utils/getters"
 )
 
 func isLocalVolume(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) (bool, *corev1.Node, error) {
+	node, err := getVolumeNode(ctx, cl, pvc)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return !utils.IsVirtualNode(node), node, nil
+}
+
+// getVolumeNode returns the node the given PVC has been provisioned through. Since remote volumes are bound to the
+// remote cluster rather than to the virtual node, any virtual node targeting the same cluster is returned in case the
+// original one no longer exists (e.g., because it has been re-created with a different name).
+func getVolumeNode(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) (*corev1.Node, error) {
 	if pvc.Annotations == nil {
-		return false, nil, fmt.Errorf("pvc %s/%s has no annotations, cannot determine on which cluster is stored", pvc.Namespace, pvc.Name)
+		return nil, fmt.Errorf("pvc %s/%s has no annotations, cannot determine on which cluster is stored", pvc.Namespace, pvc.Name)
 	}
 	nodeName, found := pvc.Annotations["volume.kubernetes.io/selected-node"]
 	if !found {
-		return false, nil, fmt.Errorf("pvc %s/%s has no selected-node annotation, cannot determine on which cluster is stored", pvc.Namespace, pvc.Name)
+		return nil, fmt.Errorf("pvc %s/%s has no selected-node annotation, cannot determine on which cluster is stored", pvc.Namespace, pvc.Name)
 	}
 
 	node := corev1.Node{}
-	if err := cl.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
-		return false, nil, err
+	err := cl.Get(ctx, client.ObjectKey{Name: nodeName}, &node)
+	switch {
+	case err == nil:
+		return &node, nil
+	case !apierrors.IsNotFound(err) || pvc.Spec.VolumeName == "":
+		return nil, err
+	}
+
+	var pv corev1.PersistentVolume
+	if pvErr := cl.Get(ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, &pv); pvErr != nil {
+		return nil, pvErr
+	}
+	clusterID, found := utils.GetPersistentVolumeClusterID(&pv)
+	if !found {
+		return nil, err
+	}
+
+	nodes, err := getters.ListNodesByClusterID(ctx, cl, liqov1beta1.ClusterID(clusterID))
+	if err != nil {
+		return nil, err
 	}
+	return &nodes.Items[0], nil
+}
 
-	return !utils.IsVirtualNode(&node), &node, nil
+// sameVolumeLocation returns whether a volume provisioned through the origin node is also available to the target node,
+// that is, whether they are the same node, or virtual nodes targeting the same remote cluster.
+func sameVolumeLocation(origin, target *corev1.Node) bool {
+	if origin.Name == target.Name {
+		return true
+	}
+	if !utils.IsVirtualNode(origin) || !utils.IsVirtualNode(target) {
+		return false
+	}
+
+	originID, originFound := utils.GetNodeClusterID(origin)
+	targetID, targetFound := utils.GetNodeClusterID(target)
+	return originFound && targetFound && originID == targetID
 }
 
 func checkNoMounter(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) error {
@@ -77,8 +123,20 @@ func recreatePvc(ctx context.Context, cl client.Client, oldPvc *corev1.Persisten
 	}
 	newPvc.Spec.VolumeName = ""
 
-	if err := cl.Delete(ctx, oldPvc); err != nil {
-		return nil, err
+	return replacePvc(ctx, cl, oldPvc, &newPvc)
+}
+
+// replacePvc deletes the old PVC (if not nil), and creates the new one as soon as the former is gone.
+// The old PVC is returned as is if it already replaced the original one during a previous (interrupted) execution.
+func replacePvc(ctx context.Context, cl client.Client, oldPvc, newPvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	if oldPvc != nil && isReplacementPvc(oldPvc) {
+		return oldPvc, nil
+	}
+
+	if oldPvc != nil {
+		if err := cl.Delete(ctx, oldPvc); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
 	}
 
 	if err := retry.OnError(
@@ -89,10 +147,22 @@ func recreatePvc(ctx context.Context, cl client.Client, oldPvc *corev1.Persisten
 		},
 		apierrors.IsAlreadyExists,
 		func() error {
-			return cl.Create(ctx, &newPvc)
+			return cl.Create(ctx, newPvc)
 		}); err != nil {
 		return nil, err
 	}
 
-	return &newPvc, nil
+	return newPvc, nil
+}
+
+// updateVolume retrieves the given PV, applies the mutation function, and updates it, retrying in case of conflicts.
+func updateVolume(ctx context.Context, cl client.Client, name string, mutate func(pv *corev1.PersistentVolume)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var pv corev1.PersistentVolume
+		if err := cl.Get(ctx, client.ObjectKey{Name: name}, &pv); err != nil {
+			return err
+		}
+		mutate(&pv)
+		return cl.Update(ctx, &pv)
+	})
 }
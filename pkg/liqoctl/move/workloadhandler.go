@@ -0,0 +1,323 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+// DefaultWorkloadTimeout is the default timeout to wait for the moved workload to become ready.
+const DefaultWorkloadTimeout = 10 * time.Minute
+
+// WorkloadOptions encapsulates the arguments of the move workload command.
+type WorkloadOptions struct {
+	// Options holds the parameters used to move each volume of the workload.
+	Options
+
+	WorkloadKind string
+	WorkloadName string
+
+	Timeout      time.Duration
+	SkipRollback bool
+}
+
+// movedVolume identifies a volume of the workload, along with the node it was originally bound to.
+type movedVolume struct {
+	Name       string
+	OriginNode string
+}
+
+// ParseWorkload parses the workload identifier, in the <kind>/<name> form, returning the normalized kind and the name.
+func ParseWorkload(workload string) (kind, name string, err error) {
+	kind, name, found := strings.Cut(workload, "/")
+	if !found || name == "" {
+		return "", "", fmt.Errorf("invalid workload %q, expected the <kind>/<name> form", workload)
+	}
+
+	switch strings.ToLower(kind) {
+	case "statefulset", "statefulsets", "sts":
+		return "StatefulSet", name, nil
+	case "deployment", "deployments", "deploy":
+		return "Deployment", name, nil
+	default:
+		return "", "", fmt.Errorf("unsupported workload kind %q, expected either statefulset or deployment", kind)
+	}
+}
+
+// Run implements the move workload command.
+func (o *WorkloadOptions) Run(ctx context.Context) error {
+	s := o.Printer.StartSpinner("Running pre-flight checks")
+
+	var targetNode corev1.Node
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: o.TargetNode}, &targetNode); err != nil {
+		s.Fail("Failed to get target node: ", output.PrettyErr(err))
+		return err
+	}
+
+	obj, err := newWorkloadObject(o.WorkloadKind)
+	if err != nil {
+		s.Fail("Failed to get the workload: ", output.PrettyErr(err))
+		return err
+	}
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: o.WorkloadName}, obj); err != nil {
+		s.Fail(fmt.Sprintf("Failed to get %s %s/%s: %v", o.WorkloadKind, o.Namespace, o.WorkloadName, output.PrettyErr(err)))
+		return err
+	}
+
+	if err := checkNamespaceOffloading(ctx, o.CRClient, o.Namespace, &targetNode); err != nil {
+		s.Fail("Failed to check the namespace offloading: ", output.PrettyErr(err))
+		return err
+	}
+
+	replicas, template, selector, claims := describeWorkload(obj)
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		s.Fail("Failed to parse the workload selector: ", output.PrettyErr(err))
+		return err
+	}
+
+	volumes, err := getWorkloadVolumes(ctx, o.CRClient, o.Namespace, claims, &targetNode)
+	if err != nil {
+		s.Fail("Failed to retrieve the volumes of the workload: ", output.PrettyErr(err))
+		return err
+	}
+
+	sources, err := getSourceNodes(ctx, o.CRClient, o.Namespace, podSelector, volumes, o.TargetNode)
+	if err != nil {
+		s.Fail("Failed to retrieve the nodes hosting the workload: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Pre-flight checks passed (%d volumes to be moved)", len(volumes)))
+
+	original := scaledWorkload{Kind: o.WorkloadKind, Name: o.WorkloadName, Replicas: replicas}
+	originalAffinity := template.Spec.Affinity.DeepCopy()
+
+	s = o.Printer.StartSpinner("Excluding the source nodes through node affinity and scaling down the workload")
+	if err := o.scaleDown(ctx, podSelector, func(template *corev1.PodTemplateSpec) {
+		// The source nodes are excluded (rather than cordoned, which would affect the other workloads as well) to prevent
+		// the workload from being scheduled there again, until it is pinned to the target node.
+		if len(sources) > 0 {
+			template.Spec.Affinity = withNodeRequirement(originalAffinity, corev1.NodeSelectorRequirement{
+				Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpNotIn, Values: sources})
+		}
+	}); err != nil {
+		s.Fail("Failed to scale down the workload: ", output.PrettyErr(err))
+		return o.rollback(original, originalAffinity, podSelector, nil, err)
+	}
+	s.Success("Source nodes excluded through node affinity and workload scaled down")
+
+	var moved []movedVolume
+	for i := range volumes {
+		o.Printer.Info.Printfln("Moving volume %s/%s (%d/%d)", o.Namespace, volumes[i].Name, i+1, len(volumes))
+		volumeOptions := o.Options
+		volumeOptions.VolumeName = volumes[i].Name
+		if err := volumeOptions.Run(ctx); err != nil {
+			return o.rollback(original, originalAffinity, podSelector, moved, err)
+		}
+		moved = append(moved, volumes[i])
+	}
+
+	s = o.Printer.StartSpinner("Pinning the workload to the target node and scaling it up")
+	if err := updateWorkload(ctx, o.CRClient, o.Namespace, o.WorkloadKind, o.WorkloadName,
+		func(replicas *int32, template *corev1.PodTemplateSpec) {
+			*replicas = original.Replicas
+			template.Spec.Affinity = withNodeRequirement(originalAffinity, corev1.NodeSelectorRequirement{
+				Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{o.TargetNode}})
+		}); err != nil {
+		s.Fail("Failed to update the workload: ", output.PrettyErr(err))
+		return o.rollback(original, originalAffinity, podSelector, moved, err)
+	}
+	s.Success("Workload pinned to the target node and scaled up")
+
+	s = o.Printer.StartSpinner("Waiting for the workload to be ready")
+	if err := waitFor(ctx, o.Timeout, func() (bool, error) {
+		return isWorkloadReady(ctx, o.CRClient, o.Namespace, o.WorkloadKind, o.WorkloadName)
+	}); err != nil {
+		s.Fail("Failed to wait for the workload to be ready: ", output.PrettyErr(err))
+		return o.rollback(original, originalAffinity, podSelector, moved, err)
+	}
+	s.Success(fmt.Sprintf("%s %s/%s moved to node %s", o.WorkloadKind, o.Namespace, o.WorkloadName, o.TargetNode))
+
+	return nil
+}
+
+// scaleDown scales the workload down to zero replicas, applying the given mutation to its pod template,
+// and waits for all its pods to be terminated.
+func (o *WorkloadOptions) scaleDown(ctx context.Context, podSelector labels.Selector, mutate func(template *corev1.PodTemplateSpec)) error {
+	if err := updateWorkload(ctx, o.CRClient, o.Namespace, o.WorkloadKind, o.WorkloadName,
+		func(replicas *int32, template *corev1.PodTemplateSpec) {
+			*replicas = 0
+			mutate(template)
+		}); err != nil {
+		return err
+	}
+
+	return waitFor(ctx, time.Minute*5, func() (bool, error) {
+		var pods corev1.PodList
+		err := o.CRClient.List(ctx, &pods, client.InNamespace(o.Namespace), client.MatchingLabelsSelector{Selector: podSelector})
+		return len(pods.Items) == 0, err
+	})
+}
+
+// rollback moves the already moved volumes back to the original nodes, and restores the original configuration of
+// the workload. It returns the error which caused the rollback.
+func (o *WorkloadOptions) rollback(original scaledWorkload, affinity *corev1.Affinity,
+	podSelector labels.Selector, moved []movedVolume, cause error) error {
+	if o.SkipRollback {
+		o.Printer.Warning.Println("Rollback skipped: the workload is left scaled down, and the volumes in their current location")
+		return cause
+	}
+
+	// we need a context that is not canceled even if the user press Ctrl+C
+	ctx := context.Background()
+
+	if len(moved) > 0 {
+		s := o.Printer.StartSpinner("Rolling back: scaling down the workload")
+		if err := o.scaleDown(ctx, podSelector, func(*corev1.PodTemplateSpec) {}); err != nil {
+			s.Fail("Failed to scale down the workload: ", output.PrettyErr(err))
+			return cause
+		}
+		s.Success("Rolling back: workload scaled down")
+
+		for i := len(moved) - 1; i >= 0; i-- {
+			o.Printer.Info.Printfln("Rolling back: moving volume %s/%s back to node %s", o.Namespace, moved[i].Name, moved[i].OriginNode)
+			volumeOptions := o.Options
+			volumeOptions.VolumeName = moved[i].Name
+			volumeOptions.TargetNode = moved[i].OriginNode
+			if err := volumeOptions.Run(ctx); err != nil {
+				o.Printer.Warning.Printfln("Failed to move volume %s/%s back to node %s: %v",
+					o.Namespace, moved[i].Name, moved[i].OriginNode, output.PrettyErr(err))
+			}
+		}
+	}
+
+	s := o.Printer.StartSpinner("Rolling back: restoring the workload")
+	if err := updateWorkload(ctx, o.CRClient, o.Namespace, original.Kind, original.Name,
+		func(replicas *int32, template *corev1.PodTemplateSpec) {
+			*replicas = original.Replicas
+			template.Spec.Affinity = affinity
+		}); err != nil {
+		s.Fail("Failed to restore the workload: ", output.PrettyErr(err))
+		return cause
+	}
+	s.Success("Rolling back: workload restored")
+	return cause
+}
+
+// describeWorkload returns the number of replicas, the pod template, the selector and the PVCs of the given workload.
+func describeWorkload(obj client.Object) (replicas int32, template *corev1.PodTemplateSpec,
+	selector *metav1.LabelSelector, claims []string) {
+	switch workload := obj.(type) {
+	case *appsv1.StatefulSet:
+		replicas, template, selector = ptr.Deref(workload.Spec.Replicas, 1), &workload.Spec.Template, workload.Spec.Selector
+		// The PVCs generated from the templates are named <template>-<statefulset>-<ordinal>.
+		for i := range workload.Spec.VolumeClaimTemplates {
+			for ordinal := range replicas {
+				claims = append(claims, fmt.Sprintf("%s-%s-%d", workload.Spec.VolumeClaimTemplates[i].Name, workload.Name, ordinal))
+			}
+		}
+	case *appsv1.Deployment:
+		replicas, template, selector = ptr.Deref(workload.Spec.Replicas, 1), &workload.Spec.Template, workload.Spec.Selector
+	}
+
+	for i := range template.Spec.Volumes {
+		if pvc := template.Spec.Volumes[i].PersistentVolumeClaim; pvc != nil && !slices.Contains(claims, pvc.ClaimName) {
+			claims = append(claims, pvc.ClaimName)
+		}
+	}
+	return replicas, template, selector, claims
+}
+
+// getWorkloadVolumes returns the given PVCs which need to be moved to the target node. PVCs not yet bound are skipped,
+// as they will be eventually provisioned where the pods of the workload are scheduled onto, as well as the ones already
+// available to the target node (i.e., hosted by the same remote cluster the target virtual node refers to).
+func getWorkloadVolumes(ctx context.Context, cl client.Client, namespace string, claims []string,
+	targetNode *corev1.Node) ([]movedVolume, error) {
+	var volumes []movedVolume
+	for _, claim := range claims {
+		var pvc corev1.PersistentVolumeClaim
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: claim}, &pvc); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		} else if err != nil || pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		_, originNode, err := isLocalVolume(ctx, cl, &pvc)
+		if err != nil {
+			return nil, err
+		}
+		if !sameVolumeLocation(originNode, targetNode) {
+			volumes = append(volumes, movedVolume{Name: claim, OriginNode: originNode.Name})
+		}
+	}
+	return volumes, nil
+}
+
+// getSourceNodes returns the nodes currently hosting either the pods or the volumes of the workload, excluding the target one.
+func getSourceNodes(ctx context.Context, cl client.Client, namespace string, podSelector labels.Selector,
+	volumes []movedVolume, targetNode string) ([]string, error) {
+	var pods corev1.PodList
+	if err := cl.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: podSelector}); err != nil {
+		return nil, err
+	}
+
+	var nodes []string
+	add := func(node string) {
+		if node != "" && node != targetNode && !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	for i := range pods.Items {
+		add(pods.Items[i].Spec.NodeName)
+	}
+	for i := range volumes {
+		add(volumes[i].OriginNode)
+	}
+	return nodes, nil
+}
+
+// withNodeRequirement returns a copy of the given affinity, with the additional requirement added to every node selector term.
+func withNodeRequirement(affinity *corev1.Affinity, requirement corev1.NodeSelectorRequirement) *corev1.Affinity {
+	affinity = affinity.DeepCopy()
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		required = &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{}}}
+	}
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, requirement)
+	}
+	affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	return affinity
+}
@@ -0,0 +1,233 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// scaledWorkload identifies a workload mounting the moved volume, along with its original number of replicas.
+type scaledWorkload struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Replicas int32  `json:"replicas"`
+}
+
+// getMounterPods returns the (non terminated) pods mounting the given PVC, excluding the ones created to move or replicate it.
+// Terminating pods hosted by not ready nodes (e.g., virtual nodes whose remote cluster is unreachable) are excluded as well,
+// as their termination cannot be confirmed until the node comes back, while they can no longer write to the volume.
+func getMounterPods(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := cl.List(ctx, &podList, client.InNamespace(pvc.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var mounters []corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if _, found := pod.Labels[moveComponentLabel]; found {
+			continue
+		}
+		if _, found := pod.Labels[consts.ReplicationComponentLabel]; found {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if !pod.DeletionTimestamp.IsZero() {
+			ready, err := isPodNodeReady(ctx, cl, pod)
+			if err != nil {
+				return nil, err
+			}
+			if !ready {
+				continue
+			}
+		}
+		for j := range pod.Spec.Volumes {
+			volume := &pod.Spec.Volumes[j]
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				mounters = append(mounters, *pod)
+				break
+			}
+		}
+	}
+
+	return mounters, nil
+}
+
+// isPodNodeReady returns whether the node hosting the given pod exists and is ready.
+func isPodNodeReady(ctx context.Context, cl client.Client, pod *corev1.Pod) (bool, error) {
+	if pod.Spec.NodeName == "" {
+		return true, nil
+	}
+
+	var node corev1.Node
+	if err := cl.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, &node); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return utils.IsNodeReady(&node), nil
+}
+
+// getMounterWorkloads returns the workloads (i.e., Deployments and StatefulSets) managing the pods mounting the given PVC.
+func getMounterWorkloads(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) ([]scaledWorkload, error) {
+	mounters, err := getMounterPods(ctx, cl, pvc)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []scaledWorkload
+	for i := range mounters {
+		workload, err := getPodWorkload(ctx, cl, &mounters[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.ContainsFunc(workloads, func(w scaledWorkload) bool { return w.Kind == workload.Kind && w.Name == workload.Name }) {
+			workloads = append(workloads, *workload)
+		}
+	}
+
+	return workloads, nil
+}
+
+// getPodWorkload returns the workload (i.e., Deployment or StatefulSet) managing the given pod.
+func getPodWorkload(ctx context.Context, cl client.Client, pod *corev1.Pod) (*scaledWorkload, error) {
+	notScalable := fmt.Errorf("pod %s/%s mounts the volume, but it is not managed by a Deployment or StatefulSet, hence it cannot be scaled down",
+		pod.Namespace, pod.Name)
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil, notScalable
+	}
+
+	switch owner.Kind {
+	case "StatefulSet":
+		var statefulSet appsv1.StatefulSet
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, &statefulSet); err != nil {
+			return nil, err
+		}
+		return &scaledWorkload{Kind: owner.Kind, Name: owner.Name, Replicas: ptr.Deref(statefulSet.Spec.Replicas, 1)}, nil
+	case "ReplicaSet":
+		var replicaSet appsv1.ReplicaSet
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, &replicaSet); err != nil {
+			return nil, err
+		}
+		if owner = metav1.GetControllerOf(&replicaSet); owner == nil || owner.Kind != "Deployment" {
+			return nil, notScalable
+		}
+
+		var deployment appsv1.Deployment
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, &deployment); err != nil {
+			return nil, err
+		}
+		return &scaledWorkload{Kind: owner.Kind, Name: owner.Name, Replicas: ptr.Deref(deployment.Spec.Replicas, 1)}, nil
+	default:
+		return nil, notScalable
+	}
+}
+
+// waitForNoMounter waits for the given PVC not to be mounted by any pod, except the ones created to move it.
+func waitForNoMounter(ctx context.Context, cl client.Client, pvc *corev1.PersistentVolumeClaim) error {
+	return waitFor(ctx, time.Minute*5, func() (bool, error) {
+		mounters, err := getMounterPods(ctx, cl, pvc)
+		return len(mounters) == 0, err
+	})
+}
+
+// newWorkloadObject returns an empty object of the given workload kind.
+func newWorkloadObject(kind string) (client.Object, error) {
+	switch kind {
+	case "StatefulSet":
+		return &appsv1.StatefulSet{}, nil
+	case "Deployment":
+		return &appsv1.Deployment{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+}
+
+// updateWorkload retrieves the given workload, applies the mutation function to its replicas and pod template, and updates it.
+func updateWorkload(ctx context.Context, cl client.Client, namespace, kind, name string,
+	mutate func(replicas *int32, template *corev1.PodTemplateSpec)) error {
+	obj, err := newWorkloadObject(kind)
+	if err != nil {
+		return err
+	}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return err
+	}
+
+	switch workload := obj.(type) {
+	case *appsv1.StatefulSet:
+		workload.Spec.Replicas = ptr.To(ptr.Deref(workload.Spec.Replicas, 1))
+		mutate(workload.Spec.Replicas, &workload.Spec.Template)
+	case *appsv1.Deployment:
+		workload.Spec.Replicas = ptr.To(ptr.Deref(workload.Spec.Replicas, 1))
+		mutate(workload.Spec.Replicas, &workload.Spec.Template)
+	}
+	return cl.Update(ctx, obj)
+}
+
+// scaleWorkloads scales the given workloads, setting either zero or their original number of replicas.
+func scaleWorkloads(ctx context.Context, cl client.Client, namespace string, workloads []scaledWorkload, restore bool) error {
+	for i := range workloads {
+		replicas := int32(0)
+		if restore {
+			replicas = workloads[i].Replicas
+		}
+
+		if err := updateWorkload(ctx, cl, namespace, workloads[i].Kind, workloads[i].Name,
+			func(r *int32, _ *corev1.PodTemplateSpec) { *r = replicas }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isWorkloadReady returns whether all the replicas of the given workload have been updated and are ready.
+func isWorkloadReady(ctx context.Context, cl client.Client, namespace, kind, name string) (bool, error) {
+	obj, err := newWorkloadObject(kind)
+	if err != nil {
+		return false, err
+	}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return false, err
+	}
+
+	switch workload := obj.(type) {
+	case *appsv1.StatefulSet:
+		replicas := ptr.Deref(workload.Spec.Replicas, 1)
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.UpdatedReplicas == replicas && workload.Status.ReadyReplicas == replicas, nil
+	case *appsv1.Deployment:
+		replicas := ptr.Deref(workload.Spec.Replicas, 1)
+		return workload.Status.ObservedGeneration >= workload.Generation &&
+			workload.Status.UpdatedReplicas == replicas && workload.Status.ReadyReplicas == replicas, nil
+	}
+	return false, nil
+}
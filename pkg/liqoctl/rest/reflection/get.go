@@ -0,0 +1,167 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reflection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/liqoctl/rest"
+	"github.com/liqotech/liqo/pkg/utils/args"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+)
+
+const liqoctlGetReflectionLongHelp = `Get the outcome of the reflection of an object towards a remote cluster.
+
+This command asks the virtual kubelet managing the given virtual node to forge the remote object
+corresponding to the given local one, as it would be reflected to the remote cluster, without
+actually enforcing it. The forged object is then printed, along with a diff against the object
+currently existing in the remote cluster (managed fields and status are not compared).
+
+The object is identified by the name of the reflector (e.g., pod, service, ingress, configmap)
+and by its name and namespace in the local cluster.
+
+Examples:
+  $ {{ .Executable }} get reflection service my-service --namespace my-namespace --node my-virtual-node
+or
+  $ {{ .Executable }} get reflection pod my-pod --namespace my-namespace --node my-virtual-node --output yaml`
+
+// Get implements the get command.
+func (o *Options) Get(ctx context.Context, options *rest.GetOptions) *cobra.Command {
+	outputFormat := args.NewEnum([]string{"diff", "yaml", "json"}, "diff")
+
+	o.getOptions = options
+
+	cmd := &cobra.Command{
+		Use:     "reflection",
+		Aliases: []string{"reflections"},
+		Short:   "Get the outcome of the reflection of an object",
+		Long:    liqoctlGetReflectionLongHelp,
+		Args:    cobra.ExactArgs(2),
+
+		PreRun: func(_ *cobra.Command, args []string) {
+			options.OutputFormat = outputFormat.Value
+			o.getOptions = options
+			o.reflector = args[0]
+			o.name = args[1]
+		},
+
+		Run: func(_ *cobra.Command, _ []string) {
+			output.ExitOnErr(o.handleGet(ctx))
+		},
+	}
+
+	cmd.Flags().VarP(outputFormat, "output", "o",
+		"Output format of the result. Supported formats: diff (forged object and diff against the remote one), yaml, json")
+	cmd.Flags().StringVar(&o.node, "node", "", "The virtual node the object is reflected through")
+
+	runtime.Must(cmd.MarkFlagRequired("node"))
+
+	runtime.Must(cmd.RegisterFlagCompletionFunc("output", completion.Enumeration(outputFormat.Allowed)))
+	runtime.Must(cmd.RegisterFlagCompletionFunc("node", completion.VirtualNodes(ctx, o.getOptions.Factory, completion.NoLimit)))
+
+	return cmd
+}
+
+func (o *Options) handleGet(ctx context.Context) error {
+	opts := o.getOptions
+
+	// The request is forwarded to the virtual kubelet through the node proxy of the API server.
+	raw, err := opts.KubeClient.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", o.node, "proxy", "reflection", o.reflector, opts.Namespace, o.name).DoRaw(ctx)
+	if err != nil {
+		opts.Printer.CheckErr(fmt.Errorf("unable to retrieve the reflection of %s %q: %v", o.reflector, o.name, output.PrettyErr(err)))
+		return err
+	}
+
+	var result manager.DryRunResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		opts.Printer.CheckErr(fmt.Errorf("unable to decode the reflection result: %w", err))
+		return err
+	}
+
+	switch opts.OutputFormat {
+	case "yaml":
+		return rest.OutputYAML(result)
+	case "json":
+		return rest.OutputJSON(result)
+	}
+
+	forged, err := yaml.Marshal(result.Forged)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "# Remote object forged by the %s reflector\n%s\n", result.Reflector, forged)
+
+	if result.Current == nil {
+		opts.Printer.Info.Println("The remote object does not exist yet, and it will be created")
+		return nil
+	}
+
+	diff, err := forgeDiff(result.Current, result.Forged)
+	if err != nil {
+		return err
+	}
+
+	if diff == "" {
+		opts.Printer.Success.Println("The remote object is up-to-date")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stdout, "# Diff against the current remote object\n%s", diff)
+	return nil
+}
+
+// forgeDiff returns the unified diff between the YAML representations of the current and the forged objects,
+// excluding the fields which are not managed by the reflection process (i.e., managed fields and status).
+func forgeDiff(current, forged interface{}) (string, error) {
+	toYAML := func(obj interface{}) ([]string, error) {
+		if content, ok := obj.(map[string]interface{}); ok {
+			unstructured.RemoveNestedField(content, "metadata", "managedFields")
+			unstructured.RemoveNestedField(content, "status")
+			obj = content
+		}
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		return difflib.SplitLines(string(data)), nil
+	}
+
+	from, err := toYAML(current)
+	if err != nil {
+		return "", err
+	}
+	to, err := toYAML(forged)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A: from, B: to, FromFile: "current", ToFile: "forged", Context: 3,
+	})
+}
@@ -0,0 +1,208 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usagerecord
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/liqoctl/rest"
+	"github.com/liqotech/liqo/pkg/utils/args"
+)
+
+const liqoctlGetUsageRecordLongHelp = `Get the UsageRecords.
+
+The UsageRecords account the resources consumed by the pods offloaded by the consumer clusters,
+integrated over time (e.g., CPU core-seconds, memory byte-seconds). They are generated by the
+provider cluster when the accounting is enabled, and can be exported for chargeback/showback purposes.
+
+Each output row contains the consumption of a given resource in a given namespace and accounting period.
+
+Examples:
+  $ {{ .Executable }} get usagerecords --output csv
+or
+  $ {{ .Executable }} get usagerecords --remote-cluster-id remote-cluster-id --since 24h --output json`
+
+// Row is the flattened representation of the consumption of a resource in a given namespace and accounting period.
+type Row struct {
+	ClusterID   string    `json:"clusterID"`
+	Namespace   string    `json:"namespace"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	Resource    string    `json:"resource"`
+	Requested   string    `json:"requested"`
+	Used        string    `json:"used,omitempty"`
+}
+
+// Get implements the get command.
+func (o *Options) Get(ctx context.Context, options *rest.GetOptions) *cobra.Command {
+	outputFormat := args.NewEnum([]string{"csv", "json"}, "csv")
+
+	o.getOptions = options
+
+	cmd := &cobra.Command{
+		Use:     "usagerecords",
+		Aliases: []string{"usagerecord", "ur"},
+		Short:   "Get the usage records",
+		Long:    liqoctlGetUsageRecordLongHelp,
+		Args:    cobra.NoArgs,
+
+		PreRun: func(_ *cobra.Command, _ []string) {
+			options.OutputFormat = outputFormat.Value
+			o.getOptions = options
+		},
+
+		Run: func(_ *cobra.Command, _ []string) {
+			output.ExitOnErr(o.handleGet(ctx))
+		},
+	}
+
+	cmd.Flags().VarP(outputFormat, "output", "o", "Output format of the resulting usage records. Supported formats: csv, json")
+	cmd.Flags().Var(&o.clusterID, "remote-cluster-id", "The cluster ID of the consumer cluster (defaults to all clusters)")
+	cmd.Flags().DurationVar(&o.since, "since", 0, "Only return the records of the accounting periods ended within this duration (defaults to all)")
+
+	runtime.Must(cmd.RegisterFlagCompletionFunc("output", completion.Enumeration(outputFormat.Allowed)))
+	runtime.Must(cmd.RegisterFlagCompletionFunc("remote-cluster-id", completion.ClusterIDs(ctx,
+		o.getOptions.Factory, completion.NoLimit)))
+
+	return cmd
+}
+
+func (o *Options) handleGet(ctx context.Context) error {
+	opts := o.getOptions
+
+	var selector client.MatchingLabels
+	if clusterID := o.clusterID.GetClusterID(); clusterID != "" {
+		selector = client.MatchingLabels{consts.RemoteClusterID: string(clusterID)}
+	}
+
+	var records offloadingv1beta1.UsageRecordList
+	if err := opts.CRClient.List(ctx, &records, selector); err != nil {
+		opts.Printer.CheckErr(fmt.Errorf("unable to retrieve usage records: %v", output.PrettyErr(err)))
+		return err
+	}
+
+	var since time.Time
+	if o.since > 0 {
+		since = time.Now().Add(-o.since)
+	}
+	rows := ForgeRows(records.Items, since)
+
+	switch opts.OutputFormat {
+	case "json":
+		return WriteJSON(os.Stdout, rows)
+	case "csv":
+		return WriteCSV(os.Stdout, rows)
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.OutputFormat)
+	}
+}
+
+// ForgeRows flattens the given UsageRecords, ignoring the ones whose accounting period ended before since.
+// Rows are sorted by cluster, period, namespace and resource.
+func ForgeRows(records []offloadingv1beta1.UsageRecord, since time.Time) []Row {
+	rows := []Row{}
+	for i := range records {
+		record := &records[i]
+		if record.Spec.PeriodEnd.Time.Before(since) {
+			continue
+		}
+
+		for j := range record.Status.Namespaces {
+			nsUsage := &record.Status.Namespaces[j]
+			for _, name := range sortedResourceNames(nsUsage.Requested, nsUsage.Used) {
+				row := Row{
+					ClusterID:   string(record.Spec.ClusterID),
+					Namespace:   nsUsage.Namespace,
+					PeriodStart: record.Spec.PeriodStart.UTC(),
+					PeriodEnd:   record.Spec.PeriodEnd.UTC(),
+					Resource:    string(name),
+				}
+				requested := nsUsage.Requested[name]
+				row.Requested = requested.String()
+				if used, found := nsUsage.Used[name]; found {
+					row.Used = used.String()
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b Row) int {
+		if c := strings.Compare(a.ClusterID, b.ClusterID); c != 0 {
+			return c
+		}
+		if c := a.PeriodStart.Compare(b.PeriodStart); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Resource, b.Resource)
+	})
+	return rows
+}
+
+// WriteCSV writes the given rows in CSV format, including the header.
+func WriteCSV(w io.Writer, rows []Row) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"cluster_id", "namespace", "period_start", "period_end", "resource", "requested", "used"}); err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := writer.Write([]string{rows[i].ClusterID, rows[i].Namespace, rows[i].PeriodStart.Format(time.RFC3339),
+			rows[i].PeriodEnd.Format(time.RFC3339), rows[i].Resource, rows[i].Requested, rows[i].Used}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the given rows in JSON format.
+func WriteJSON(w io.Writer, rows []Row) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+func sortedResourceNames(lists ...corev1.ResourceList) []corev1.ResourceName {
+	names := []corev1.ResourceName{}
+	for _, list := range lists {
+		for name := range list {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +39,7 @@ import (
 	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
 	"github.com/liqotech/liqo/pkg/utils/args"
 	"github.com/liqotech/liqo/pkg/utils/getters"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
 )
 
 const liqoctlCreateVirtualNodeLongHelp = `Create a VirtualNode.
@@ -100,6 +102,11 @@ func (o *Options) Create(ctx context.Context, options *rest.CreateOptions) *cobr
 		[]string{}, "The ingress classes offered by the remote cluster. The first one will be used as default")
 	cmd.Flags().StringSliceVar(&o.loadBalancerClasses, "load-balancer-classes",
 		[]string{}, "The load balancer classes offered by the remote cluster. The first one will be used as default")
+	cmd.Flags().StringSliceVar(&o.gateways, "gateways", []string{},
+		"The Gateway API gateways offered by the remote cluster, in the <namespace>/<name> form. The first one will be used as default")
+	cmd.Flags().StringSliceVar(&o.clusterScopedReflection, "cluster-scoped-reflection", []string{},
+		"The kinds of the cluster-scoped resources to be reflected to the remote cluster, among PriorityClass, RuntimeClass, "+
+			"StorageClass and IngressClass. The remote cluster must grant the corresponding permission to the tenant")
 	cmd.Flags().StringToStringVar(&o.labels, "labels", map[string]string{}, "The labels to be added to the virtual node")
 	cmd.Flags().StringToStringVar(&o.nodeSelector, "node-selector", map[string]string{}, "The node selector to be applied to offloaded pods")
 
@@ -156,6 +163,13 @@ func (o *Options) handleCreate(ctx context.Context) error {
 		return err
 	}
 
+	if len(o.clusterScopedReflection) > 0 {
+		if vnOpts.ClusterScopedReflection, err = resources.ParseClusterScopedKinds(o.clusterScopedReflection); err != nil {
+			opts.Printer.CheckErr(fmt.Errorf("--cluster-scoped-reflection is not valid: %w", err))
+			return err
+		}
+	}
+
 	if opts.OutputFormat != "" {
 		opts.Printer.CheckErr(o.output(ctx, opts.Name, tenantNamespace, vnOpts))
 		return nil
@@ -257,6 +271,15 @@ func (o *Options) forgeVirtualNodeOptions(vkOptionsTemplateRef *corev1.ObjectRef
 		loadBalancerClasses[i] = lbc
 	}
 
+	gateways := make([]liqov1beta1.GatewayType, len(o.gateways))
+	for i, gateway := range o.gateways {
+		namespace, name, found := strings.Cut(gateway, "/")
+		if !found || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid gateway %q, expected in the <namespace>/<name> form", gateway)
+		}
+		gateways[i] = liqov1beta1.GatewayType{Name: name, Namespace: namespace, Default: i == 0}
+	}
+
 	return &forge.VirtualNodeOptions{
 		KubeconfigSecretRef:  corev1.LocalObjectReference{Name: o.kubeconfigSecretName},
 		VkOptionsTemplateRef: vkOptionsTemplateRef,
@@ -269,6 +292,7 @@ func (o *Options) forgeVirtualNodeOptions(vkOptionsTemplateRef *corev1.ObjectRef
 		StorageClasses:      storageClasses,
 		IngressClasses:      ingressClasses,
 		LoadBalancerClasses: loadBalancerClasses,
+		Gateways:            gateways,
 		NodeLabels:          o.labels,
 		NodeSelector:        o.nodeSelector,
 	}, nil
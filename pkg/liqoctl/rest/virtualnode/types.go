@@ -37,11 +37,13 @@ type Options struct {
 	memory string
 	pods   string
 
-	storageClasses      []string
-	ingressClasses      []string
-	loadBalancerClasses []string
-	labels              map[string]string
-	nodeSelector        map[string]string
+	storageClasses          []string
+	ingressClasses          []string
+	loadBalancerClasses     []string
+	gateways                []string
+	clusterScopedReflection []string
+	labels                  map[string]string
+	nodeSelector            map[string]string
 }
 
 var _ rest.API = &Options{}
@@ -117,6 +117,11 @@ func GetMessage(conditions []liqov1beta1.Condition, conditionType liqov1beta1.Co
 	return ""
 }
 
+// GetCondition returns the condition of the given type. If the condition is not set, it returns nil.
+func GetCondition(conditions []liqov1beta1.Condition, conditionType liqov1beta1.ConditionType) *liqov1beta1.Condition {
+	return findCondition(conditions, conditionType)
+}
+
 // findCondition returns a condition given its type.
 func findCondition(conditions []liqov1beta1.Condition, conditionType liqov1beta1.ConditionType) *liqov1beta1.Condition {
 	for i := range conditions {
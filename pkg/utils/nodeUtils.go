@@ -110,3 +110,23 @@ func GetNodeClusterID(selectedNode *corev1.Node) (string, bool) {
 
 	return remoteClusterID, true
 }
+
+// GetPersistentVolumeClusterID returns the clusterID of the remote cluster hosting the real volume backing the given
+// virtual PV, as persisted in its node affinity. Virtual PVs are pinned to the cluster (rather than to a given
+// virtual node), hence the binding survives the re-creation of the virtual node and spans all the virtual nodes
+// targeting the same remote cluster.
+func GetPersistentVolumeClusterID(pv *corev1.PersistentVolume) (string, bool) {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return "", false
+	}
+
+	for i := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, requirement := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms[i].MatchExpressions {
+			if requirement.Key == liqoconst.RemoteClusterID && requirement.Operator == corev1.NodeSelectorOpIn &&
+				len(requirement.Values) == 1 && requirement.Values[0] != "" {
+				return requirement.Values[0], true
+			}
+		}
+	}
+	return "", false
+}
@@ -301,4 +301,60 @@ var _ = Describe("NodeUtils", func() {
 
 	})
 
+	Context("getPersistentVolumeClusterID", func() {
+
+		var (
+			getVolume = func(requirements ...v1.NodeSelectorRequirement) *v1.PersistentVolume {
+				return &v1.PersistentVolume{
+					Spec: v1.PersistentVolumeSpec{
+						NodeAffinity: &v1.VolumeNodeAffinity{
+							Required: &v1.NodeSelector{
+								NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: requirements}},
+							},
+						},
+					},
+				}
+			}
+		)
+
+		type getPersistentVolumeClusterIDTestcase struct {
+			volume            *v1.PersistentVolume
+			expectedClusterID string
+			expectedFound     bool
+		}
+
+		DescribeTable("getPersistentVolumeClusterID table",
+			func(c getPersistentVolumeClusterIDTestcase) {
+				clusterID, found := GetPersistentVolumeClusterID(c.volume)
+				Expect(found).To(Equal(c.expectedFound))
+				Expect(clusterID).To(Equal(c.expectedClusterID))
+			},
+
+			Entry("no node affinity", getPersistentVolumeClusterIDTestcase{
+				volume: &v1.PersistentVolume{},
+			}),
+
+			Entry("node affinity with other requirements", getPersistentVolumeClusterIDTestcase{
+				volume: getVolume(v1.NodeSelectorRequirement{
+					Key: "kubernetes.io/hostname", Operator: v1.NodeSelectorOpIn, Values: []string{"node"},
+				}),
+			}),
+
+			Entry("node affinity with multiple cluster IDs", getPersistentVolumeClusterIDTestcase{
+				volume: getVolume(v1.NodeSelectorRequirement{
+					Key: liqoconst.RemoteClusterID, Operator: v1.NodeSelectorOpIn, Values: []string{"foo", "bar"},
+				}),
+			}),
+
+			Entry("node affinity with the cluster ID", getPersistentVolumeClusterIDTestcase{
+				volume: getVolume(v1.NodeSelectorRequirement{
+					Key: liqoconst.RemoteClusterID, Operator: v1.NodeSelectorOpIn, Values: []string{"foo"},
+				}),
+				expectedClusterID: "foo",
+				expectedFound:     true,
+			}),
+		)
+
+	})
+
 })
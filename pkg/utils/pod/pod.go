@@ -58,10 +58,14 @@ func IsPodSpecEqual(previous, updated *corev1.PodSpec) bool {
 	// * spec.initContainers[*].image
 	// * spec.activeDeadlineSeconds
 	// * spec.tolerations (only new entries can be added)
+	// * spec.containers[*].resources (through the resize subresource)
+	// * spec.ephemeralContainers (only new entries can be added, through the dedicated subresource)
 	return AreContainersEqual(previous.Containers, updated.Containers) &&
+		AreContainerResourcesAligned(previous.Containers, updated.Containers) &&
 		AreContainersEqual(previous.InitContainers, updated.InitContainers) &&
 		ptr.Equal(previous.ActiveDeadlineSeconds, updated.ActiveDeadlineSeconds) &&
-		len(previous.Tolerations) == len(updated.Tolerations)
+		len(previous.Tolerations) == len(updated.Tolerations) &&
+		len(previous.EphemeralContainers) == len(updated.EphemeralContainers)
 }
 
 // CheckShadowPodUpdate returns whether updated equals previous, except for the fields that are allowed to be updated.
@@ -72,17 +76,111 @@ func CheckShadowPodUpdate(previous, updated *corev1.PodSpec) bool {
 	// * spec.initContainers[*].image
 	// * spec.activeDeadlineSeconds
 	// * spec.tolerations (only new entries can be added)
+	// * spec.containers[*].resources (in-place resize)
+	// * spec.ephemeralContainers (only new entries can be added)
+	if !AreEphemeralContainersAppended(previous.EphemeralContainers, updated.EphemeralContainers) {
+		return false
+	}
+
 	for i := range updated.Containers {
 		updated.Containers[i].Image = previous.Containers[i].Image
+		updated.Containers[i].Resources = previous.Containers[i].Resources
 	}
 	for i := range updated.InitContainers {
 		updated.InitContainers[i].Image = previous.InitContainers[i].Image
 	}
 	updated.ActiveDeadlineSeconds = previous.ActiveDeadlineSeconds
 	updated.Tolerations = previous.Tolerations
+	updated.EphemeralContainers = previous.EphemeralContainers
 	return reflect.DeepEqual(previous, updated)
 }
 
+// AreContainerResourcesAligned returns whether the resources of the current containers match the ones of the
+// desired containers with the same name. Resources not specified in the desired containers are ignored,
+// as they might have been defaulted by the API server (e.g., requests set equal to the corresponding limits).
+func AreContainerResourcesAligned(current, desired []corev1.Container) bool {
+	for i := range desired {
+		for j := range current {
+			if desired[i].Name == current[j].Name {
+				if !isResourceListContained(desired[i].Resources.Requests, current[j].Resources.Requests) ||
+					!isResourceListContained(desired[i].Resources.Limits, current[j].Resources.Limits) {
+					return false
+				}
+				break
+			}
+		}
+	}
+
+	return true
+}
+
+// AreContainerResourcesEqual returns whether the resources of the previous containers are exactly equal to the ones of the
+// updated containers with the same name. Differently from AreContainerResourcesAligned, the removal of a request or
+// a limit is also considered a change.
+func AreContainerResourcesEqual(previous, updated []corev1.Container) bool {
+	for i := range updated {
+		for j := range previous {
+			if updated[i].Name == previous[j].Name {
+				if !areResourceListsEqual(updated[i].Resources.Requests, previous[j].Resources.Requests) ||
+					!areResourceListsEqual(updated[i].Resources.Limits, previous[j].Resources.Limits) {
+					return false
+				}
+				break
+			}
+		}
+	}
+
+	return true
+}
+
+// areResourceListsEqual returns whether the two resource lists contain the same resources with the same values.
+func areResourceListsEqual(first, second corev1.ResourceList) bool {
+	return len(first) == len(second) && isResourceListContained(first, second)
+}
+
+// isResourceListContained returns whether all the resources in the subset are present with the same value in the superset.
+func isResourceListContained(subset, superset corev1.ResourceList) bool {
+	for name, quantity := range subset {
+		if value, found := superset[name]; !found || value.Cmp(quantity) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AreEphemeralContainersAppended returns whether the updated list of ephemeral containers is obtained by appending
+// new entries to the previous one, as existing ephemeral containers can be neither modified nor removed.
+func AreEphemeralContainersAppended(previous, updated []corev1.EphemeralContainer) bool {
+	if len(updated) < len(previous) {
+		return false
+	}
+
+	for i := range previous {
+		if !reflect.DeepEqual(previous[i], updated[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MissingEphemeralContainers returns the ephemeral containers of the desired list which are not present in the current one.
+func MissingEphemeralContainers(current, desired []corev1.EphemeralContainer) []corev1.EphemeralContainer {
+	var missing []corev1.EphemeralContainer
+
+outer:
+	for i := range desired {
+		for j := range current {
+			if desired[i].Name == current[j].Name {
+				continue outer
+			}
+		}
+		missing = append(missing, desired[i])
+	}
+
+	return missing
+}
+
 // AreContainersEqual returns whether two container lists are equal according to the
 // fields that can be modified after start-up time (i.e. the image field).
 func AreContainersEqual(previous, updated []corev1.Container) bool {
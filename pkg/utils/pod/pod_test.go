@@ -110,9 +110,161 @@ var _ = Describe("Pod utility functions", func() {
 				updated:  corev1.PodSpec{ActiveDeadlineSeconds: nil},
 				expected: BeFalse(),
 			}),
+			Entry("container resources are different", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: resources("100m")}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: resources("200m")}}},
+				expected: BeFalse(),
+			}),
+			Entry("more ephemeral containers are present", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo"), ephemeralContainer("bar")}},
+				expected: BeFalse(),
+			}),
+		)
+	})
+
+	Describe("The CheckShadowPodUpdate function", func() {
+		type TestCase struct {
+			previous corev1.PodSpec
+			updated  corev1.PodSpec
+			expected types.GomegaMatcher
+		}
+
+		DescribeTable("tests table",
+			func(c TestCase) {
+				Expect(pod.CheckShadowPodUpdate(&c.previous, &c.updated)).To(c.expected)
+			},
+			Entry("both specs are empty", TestCase{expected: BeTrue()}),
+			Entry("the container images are changed", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "baz"}}},
+				expected: BeTrue(),
+			}),
+			Entry("the container resources are changed", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: resources("100m")}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: resources("200m")}}},
+				expected: BeTrue(),
+			}),
+			Entry("other container fields are changed", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", WorkingDir: "/tmp"}}},
+				expected: BeFalse(),
+			}),
+			Entry("an ephemeral container is added", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo"), ephemeralContainer("bar")}},
+				expected: BeTrue(),
+			}),
+			Entry("the first ephemeral container is added", TestCase{
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo")}},
+				expected: BeTrue(),
+			}),
+			Entry("an ephemeral container is removed", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo"), ephemeralContainer("bar")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo")}},
+				expected: BeFalse(),
+			}),
+			Entry("an ephemeral container is modified", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("foo")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{ephemeralContainer("bar")}},
+				expected: BeFalse(),
+			}),
+		)
+	})
+
+	Describe("The AreContainerResourcesAligned function", func() {
+		type TestCase struct {
+			current  []corev1.Container
+			desired  []corev1.Container
+			expected types.GomegaMatcher
+		}
+
+		DescribeTable("tests table",
+			func(c TestCase) {
+				Expect(pod.AreContainerResourcesAligned(c.current, c.desired)).To(c.expected)
+			},
+			Entry("both lists are nil", TestCase{expected: BeTrue()}),
+			Entry("the resources are equal", TestCase{
+				current:  []corev1.Container{{Name: "foo", Resources: resources("100m")}},
+				desired:  []corev1.Container{{Name: "foo", Resources: resources("0.1")}},
+				expected: BeTrue(),
+			}),
+			Entry("the resources are different", TestCase{
+				current:  []corev1.Container{{Name: "foo", Resources: resources("100m")}},
+				desired:  []corev1.Container{{Name: "foo", Resources: resources("200m")}},
+				expected: BeFalse(),
+			}),
+			Entry("the current resources include additional defaulted values", TestCase{
+				current: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				}}},
+				desired: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				}}},
+				expected: BeTrue(),
+			}),
 		)
 	})
 
+	Describe("The AreContainerResourcesEqual function", func() {
+		type TestCase struct {
+			previous []corev1.Container
+			updated  []corev1.Container
+			expected types.GomegaMatcher
+		}
+
+		DescribeTable("tests table",
+			func(c TestCase) {
+				Expect(pod.AreContainerResourcesEqual(c.previous, c.updated)).To(c.expected)
+			},
+			Entry("both lists are nil", TestCase{expected: BeTrue()}),
+			Entry("the resources are equal", TestCase{
+				previous: []corev1.Container{{Name: "foo", Resources: resources("100m")}},
+				updated:  []corev1.Container{{Name: "foo", Resources: resources("0.1")}},
+				expected: BeTrue(),
+			}),
+			Entry("the resources are different", TestCase{
+				previous: []corev1.Container{{Name: "foo", Resources: resources("100m")}},
+				updated:  []corev1.Container{{Name: "foo", Resources: resources("200m")}},
+				expected: BeFalse(),
+			}),
+			Entry("a request is removed", TestCase{
+				previous: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				}}},
+				updated: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				}}},
+				expected: BeFalse(),
+			}),
+			Entry("a limit is removed", TestCase{
+				previous: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				}}},
+				updated:  []corev1.Container{{Name: "foo"}},
+				expected: BeFalse(),
+			}),
+		)
+	})
+
+	Describe("The MissingEphemeralContainers function", func() {
+		It("should return the ephemeral containers not yet present", func() {
+			Expect(pod.MissingEphemeralContainers(
+				[]corev1.EphemeralContainer{ephemeralContainer("foo")},
+				[]corev1.EphemeralContainer{ephemeralContainer("foo"), ephemeralContainer("bar")},
+			)).To(ConsistOf(ephemeralContainer("bar")))
+		})
+
+		It("should return nothing if all ephemeral containers are already present", func() {
+			Expect(pod.MissingEphemeralContainers(
+				[]corev1.EphemeralContainer{ephemeralContainer("foo"), ephemeralContainer("bar")},
+				[]corev1.EphemeralContainer{ephemeralContainer("bar")},
+			)).To(BeEmpty())
+		})
+	})
+
 	Describe("The AreContainersReady function", func() {
 		type TestCase struct {
 			previous []corev1.Container
@@ -174,3 +326,14 @@ var _ = Describe("Pod utility functions", func() {
 		)
 	})
 })
+
+func ephemeralContainer(name string) corev1.EphemeralContainer {
+	return corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: "busybox"}}
+}
+
+func resources(cpu string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+	}
+}
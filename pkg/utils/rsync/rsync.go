@@ -0,0 +1,65 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsync
+
+import "fmt"
+
+const (
+	// Port is the port the rsync daemon listens on.
+	Port = 873
+	// Module is the name of the rsync module exposing the content of the volume.
+	Module = "data"
+	// User is the name of the user authorized to access the rsync module.
+	User = "liqo"
+	// PasswordEnv is the environment variable holding the password of the rsync user, both for the daemon and the client.
+	PasswordEnv = "RSYNC_PASSWORD"
+	// MountPath is the path the synchronized volume is mounted at, both by the daemon and the client.
+	MountPath = "/data"
+
+	// DefaultImage is the default image used for the rsync daemon and client.
+	DefaultImage = "instrumentisto/rsync-ssh:alpine3.20"
+)
+
+// DaemonScript returns the script configuring and starting the rsync daemon, which exposes (read-only) the mounted volume
+// to the authenticated clients.
+func DaemonScript() string {
+	return fmt.Sprintf(`set -e
+printf '%[1]s:%%s\n' "$%[4]s" > /tmp/rsyncd.secrets
+chmod 600 /tmp/rsyncd.secrets
+cat > /tmp/rsyncd.conf <<EOF
+port = %[2]d
+use chroot = no
+[%[3]s]
+    path = %[5]s
+    read only = true
+    uid = 0
+    gid = 0
+    numeric ids = true
+    auth users = %[1]s
+    secrets file = /tmp/rsyncd.secrets
+EOF
+exec rsync --daemon --no-detach --config=/tmp/rsyncd.conf --log-file=/dev/stdout`, User, Port, Module, PasswordEnv, MountPath)
+}
+
+// ClientCommand returns the command performing an incremental synchronization from the rsync daemon reachable
+// at the given host to the locally mounted volume, reporting the overall progress.
+func ClientCommand(host string) []string {
+	return []string{
+		"rsync", "--archive", "--hard-links", "--numeric-ids", "--delete", "--partial",
+		"--info=progress2", "--no-inc-recursive",
+		fmt.Sprintf("rsync://%s@%s:%d/%s/", User, host, Port, Module),
+		MountPath + "/",
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+// systemPriorityClassPrefix is the prefix reserved to the built-in priority classes, which exist in every cluster.
+const systemPriorityClassPrefix = "system-"
+
+// defaultClassAnnotations are the annotations marking a class as the cluster default, which are never reflected
+// to prevent altering the behavior of the remote cluster.
+var defaultClassAnnotations = []string{
+	"storageclass.kubernetes.io/is-default-class",
+	"storageclass.beta.kubernetes.io/is-default-class",
+	"ingressclass.kubernetes.io/is-default-class",
+}
+
+// RemoteClusterScopedName returns the name of the remote counterpart of the given cluster-scoped object,
+// prefixed with the local cluster ID to prevent collisions among the objects reflected by different consumers.
+func RemoteClusterScopedName(name string) string {
+	return fmt.Sprintf("%s-%s", LocalCluster, name)
+}
+
+// LocalClusterScopedName returns the name of the local counterpart of the given remote cluster-scoped object,
+// and whether the remote name carries the prefix associated with the local cluster.
+func LocalClusterScopedName(remote string) (string, bool) {
+	return strings.CutPrefix(remote, fmt.Sprintf("%s-", LocalCluster))
+}
+
+// IsClusterScopedReflectable returns whether the given cluster-scoped object can be reflected to the remote cluster.
+// Built-in priority classes are excluded, as they already exist in every cluster and their value cannot be replicated.
+func IsClusterScopedReflectable(kind offloadingv1beta1.ClusterScopedResourceKind, name string) bool {
+	return kind != offloadingv1beta1.PriorityClassKind || !strings.HasPrefix(name, systemPriorityClassPrefix)
+}
+
+// RemoteClusterScopedResource forges the apply patch for the reflected cluster-scoped resource, given the local one.
+// The remote object is renamed through RemoteClusterScopedName, and it is never marked as the cluster default.
+func RemoteClusterScopedResource(local *unstructured.Unstructured, forgingOpts *ForgingOpts) *unstructured.Unstructured {
+	remote := RemoteCustomResource(local, "", forgingOpts)
+	remote.SetName(RemoteClusterScopedName(local.GetName()))
+
+	if annotations := remote.GetAnnotations(); annotations != nil {
+		for _, key := range defaultClassAnnotations {
+			delete(annotations, key)
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		remote.SetAnnotations(annotations)
+	}
+
+	if offloadingv1beta1.ClusterScopedResourceKind(local.GetKind()) == offloadingv1beta1.PriorityClassKind {
+		remote.Object["globalDefault"] = false
+	}
+
+	return remote
+}
@@ -0,0 +1,101 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Cluster-scoped resources forging", func() {
+	Describe("the RemoteClusterScopedName and LocalClusterScopedName functions", func() {
+		It("should prefix the name with the local cluster ID", func() {
+			Expect(forge.RemoteClusterScopedName("name")).To(Equal(string(LocalClusterID) + "-name"))
+		})
+
+		It("should retrieve the original name from the prefixed one", func() {
+			name, found := forge.LocalClusterScopedName(string(LocalClusterID) + "-name")
+			Expect(found).To(BeTrue())
+			Expect(name).To(Equal("name"))
+		})
+
+		It("should not retrieve the original name if the prefix does not match", func() {
+			_, found := forge.LocalClusterScopedName("other-cluster-name")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("the IsClusterScopedReflectable function", func() {
+		DescribeTable("should return the expected result",
+			func(kind offloadingv1beta1.ClusterScopedResourceKind, name string, expected bool) {
+				Expect(forge.IsClusterScopedReflectable(kind, name)).To(Equal(expected))
+			},
+			Entry("a custom priority class", offloadingv1beta1.PriorityClassKind, "high-priority", true),
+			Entry("a built-in priority class", offloadingv1beta1.PriorityClassKind, "system-node-critical", false),
+			Entry("a runtime class", offloadingv1beta1.RuntimeClassKind, "system-runtime", true),
+		)
+	})
+
+	Describe("the RemoteClusterScopedResource function", func() {
+		var local, output *unstructured.Unstructured
+
+		BeforeEach(func() {
+			local = &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "scheduling.k8s.io/v1",
+				"kind":       "PriorityClass",
+				"metadata": map[string]interface{}{
+					"name": "high-priority", "uid": "uid", "resourceVersion": "42",
+					"labels": map[string]interface{}{"foo": "bar"},
+					"annotations": map[string]interface{}{
+						"bar": "baz", "storageclass.kubernetes.io/is-default-class": "true", testutil.FakeNotReflectedAnnotKey: "true",
+					},
+				},
+				"value":         int64(1000),
+				"globalDefault": true,
+			}}
+		})
+
+		JustBeforeEach(func() { output = forge.RemoteClusterScopedResource(local, testutil.FakeForgingOpts()) })
+
+		It("should correctly set the type and object metadata", func() {
+			Expect(output.GetAPIVersion()).To(Equal("scheduling.k8s.io/v1"))
+			Expect(output.GetKind()).To(Equal("PriorityClass"))
+			Expect(output.GetName()).To(Equal(string(LocalClusterID) + "-high-priority"))
+			Expect(output.GetNamespace()).To(BeEmpty())
+			Expect(output.GetUID()).To(BeEmpty())
+			Expect(output.GetResourceVersion()).To(BeEmpty())
+			Expect(output.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(LocalClusterID)))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, string(RemoteClusterID)))
+		})
+
+		It("should not reflect the default class annotations", func() {
+			Expect(output.GetAnnotations()).To(HaveKeyWithValue("bar", "baz"))
+			Expect(output.GetAnnotations()).ToNot(HaveKey("storageclass.kubernetes.io/is-default-class"))
+			Expect(output.GetAnnotations()).ToNot(HaveKey(testutil.FakeNotReflectedAnnotKey))
+		})
+
+		It("should preserve the other fields, except for the global default flag", func() {
+			Expect(output.Object).To(HaveKeyWithValue("value", int64(1000)))
+			Expect(output.Object).To(HaveKeyWithValue("globalDefault", false))
+			Expect(local.Object).To(HaveKeyWithValue("globalDefault", true))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RemoteCustomResource forges the apply patch for the reflected custom resource, given the local one.
+// The status and the server-managed metadata (e.g., UID, resource version, owner references and finalizers) are not reflected.
+func RemoteCustomResource(local *unstructured.Unstructured, targetNamespace string, forgingOpts *ForgingOpts) *unstructured.Unstructured {
+	remote := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range local.Object {
+		if key == "metadata" || key == "status" {
+			continue
+		}
+		remote.Object[key] = runtime.DeepCopyJSONValue(value)
+	}
+
+	remote.SetAPIVersion(local.GetAPIVersion())
+	remote.SetKind(local.GetKind())
+	remote.SetName(local.GetName())
+	remote.SetNamespace(targetNamespace)
+	remote.SetLabels(labels.Merge(FilterNotReflected(local.GetLabels(), forgingOpts.LabelsNotReflected), ReflectionLabels()))
+	if annotations := FilterNotReflected(local.GetAnnotations(), forgingOpts.AnnotationsNotReflected); len(annotations) > 0 {
+		remote.SetAnnotations(annotations)
+	}
+
+	return remote
+}
+
+// LocalCustomResourceStatus returns a copy of the local custom resource with the status of the remote one,
+// and whether it differs from the current local status.
+func LocalCustomResourceStatus(local, remote *unstructured.Unstructured) (*unstructured.Unstructured, bool) {
+	remoteStatus, remoteFound := remote.Object["status"]
+	localStatus, localFound := local.Object["status"]
+	if remoteFound == localFound && equality.Semantic.DeepEqual(remoteStatus, localStatus) {
+		return local, false
+	}
+
+	output := local.DeepCopy()
+	if remoteFound {
+		output.Object["status"] = runtime.DeepCopyJSONValue(remoteStatus)
+	} else {
+		delete(output.Object, "status")
+	}
+	return output, true
+}
@@ -0,0 +1,110 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Custom resources forging", func() {
+	var local *unstructured.Unstructured
+
+	BeforeEach(func() {
+		local = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "AppConfig",
+			"metadata": map[string]interface{}{
+				"name": "name", "namespace": "original", "uid": "uid", "resourceVersion": "42", "generation": int64(3),
+				"labels":          map[string]interface{}{"foo": "bar", testutil.FakeNotReflectedLabelKey: "true"},
+				"annotations":     map[string]interface{}{"bar": "baz", testutil.FakeNotReflectedAnnotKey: "true"},
+				"finalizers":      []interface{}{"example.com/finalizer"},
+				"ownerReferences": []interface{}{map[string]interface{}{"name": "owner"}},
+			},
+			"spec":   map[string]interface{}{"replicas": int64(3), "nested": map[string]interface{}{"key": "value"}},
+			"data":   "top-level",
+			"status": map[string]interface{}{"ready": true},
+		}}
+	})
+
+	Describe("the RemoteCustomResource function", func() {
+		var output *unstructured.Unstructured
+
+		JustBeforeEach(func() { output = forge.RemoteCustomResource(local, "reflected", testutil.FakeForgingOpts()) })
+
+		It("should correctly set the type and object metadata", func() {
+			Expect(output.GetAPIVersion()).To(Equal("example.com/v1"))
+			Expect(output.GetKind()).To(Equal("AppConfig"))
+			Expect(output.GetName()).To(Equal("name"))
+			Expect(output.GetNamespace()).To(Equal("reflected"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(LocalClusterID)))
+			Expect(output.GetLabels()).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+			Expect(output.GetAnnotations()).To(HaveKeyWithValue("bar", "baz"))
+			Expect(output.GetAnnotations()).ToNot(HaveKey(testutil.FakeNotReflectedAnnotKey))
+		})
+
+		It("should strip the server-managed fields and the status", func() {
+			Expect(output.GetUID()).To(BeEmpty())
+			Expect(output.GetResourceVersion()).To(BeEmpty())
+			Expect(output.GetGeneration()).To(BeZero())
+			Expect(output.GetFinalizers()).To(BeEmpty())
+			Expect(output.GetOwnerReferences()).To(BeEmpty())
+			Expect(output.Object).ToNot(HaveKey("status"))
+		})
+
+		It("should preserve the other fields, without sharing them with the local object", func() {
+			Expect(output.Object).To(HaveKeyWithValue("spec", local.Object["spec"]))
+			Expect(output.Object).To(HaveKeyWithValue("data", "top-level"))
+
+			Expect(unstructured.SetNestedField(output.Object, "modified", "spec", "nested", "key")).To(Succeed())
+			Expect(local.Object["spec"]).To(HaveKeyWithValue("nested", HaveKeyWithValue("key", "value")))
+		})
+	})
+
+	Describe("the LocalCustomResourceStatus function", func() {
+		var remote *unstructured.Unstructured
+
+		BeforeEach(func() {
+			remote = local.DeepCopy()
+			remote.SetNamespace("reflected")
+		})
+
+		It("should report no changes if the status is the same", func() {
+			_, changed := forge.LocalCustomResourceStatus(local, remote)
+			Expect(changed).To(BeFalse())
+		})
+
+		It("should copy the remote status if it differs", func() {
+			remote.Object["status"] = map[string]interface{}{"ready": false}
+			output, changed := forge.LocalCustomResourceStatus(local, remote)
+			Expect(changed).To(BeTrue())
+			Expect(output.GetNamespace()).To(Equal("original"))
+			Expect(output.Object).To(HaveKeyWithValue("status", HaveKeyWithValue("ready", false)))
+			Expect(local.Object).To(HaveKeyWithValue("status", HaveKeyWithValue("ready", true)))
+		})
+
+		It("should remove the local status if the remote one is not set", func() {
+			delete(remote.Object, "status")
+			output, changed := forge.LocalCustomResourceStatus(local, remote)
+			Expect(changed).To(BeTrue())
+			Expect(output.Object).ToNot(HaveKey("status"))
+		})
+	})
+})
@@ -38,6 +38,9 @@ const (
 
 	// EventFailedSATokensReflection -> the reason for the event when the reflection of service account tokens fails.
 	EventFailedSATokensReflection = "FailedSATokensReflection"
+
+	// EventSkippedEndpoints -> the reason for the event when some endpoints are skipped, as not reachable from the local cluster.
+	EventSkippedEndpoints = "SkippedEndpoints"
 )
 
 // EventSuccessfulReflectionMsg returns the message for the event when the outgoing reflection completes successfully.
@@ -66,6 +69,13 @@ func EventFailedReflectionAlreadyExistsMsg() string {
 	return fmt.Sprintf("Error reflecting object to cluster %q: remote object already exists", RemoteCluster)
 }
 
+// EventSkippedEndpointsMsg returns the message for the event when some endpoints of a remote object are skipped,
+// as no IP resource exists to remap them.
+func EventSkippedEndpointsMsg(addresses []string) string {
+	return fmt.Sprintf("Skipped endpoints %v of cluster %q, as not reachable from the local cluster (no IP resource exists for them)",
+		addresses, RemoteCluster)
+}
+
 // EventFailedLabelsUpdateMsg returns the message for the event when it is impossible to update the labels of a local object.
 func EventFailedLabelsUpdateMsg(err error) string {
 	return fmt.Sprintf("Error updating local object labels: %v", err)
@@ -0,0 +1,117 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/utils/pointer"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+// IsExportedToConsumer returns whether the given remote service is marked to be exported back to the consumer (i.e., local) cluster.
+func IsExportedToConsumer(remote metav1.Object) bool {
+	value, ok := remote.GetAnnotations()[liqoconst.ExportToConsumerAnnotationKey]
+	return ok && strings.EqualFold(value, "true")
+}
+
+// LocalExportedService forges the apply patch for the local service corresponding to the one exported by the remote cluster.
+func LocalExportedService(remote *corev1.Service, targetNamespace string, forgingOpts *ForgingOpts) *corev1apply.ServiceApplyConfiguration {
+	annotationsNotReflected := append([]string{liqoconst.ExportToConsumerAnnotationKey}, forgingOpts.AnnotationsNotReflected...)
+	return corev1apply.Service(remote.GetName(), targetNamespace).
+		WithLabels(FilterNotReflected(remote.GetLabels(), forgingOpts.LabelsNotReflected)).WithLabels(ReverseReflectionLabels()).
+		WithAnnotations(FilterNotReflected(remote.GetAnnotations(), annotationsNotReflected)).
+		WithSpec(LocalExportedServiceSpec(remote.Spec.DeepCopy()))
+}
+
+// LocalExportedServiceSpec forges the apply patch for the specs of the local service corresponding to the one exported by the remote cluster.
+// The resulting service is always of type ClusterIP and without selector, as its endpoints are populated by the reflection logic.
+// It expects the remote object to be a deepcopy, as it is mutated.
+func LocalExportedServiceSpec(remote *corev1.ServiceSpec) *corev1apply.ServiceSpecApplyConfiguration {
+	local := corev1apply.ServiceSpec().WithType(corev1.ServiceTypeClusterIP)
+	for i := range remote.Ports {
+		port := corev1apply.ServicePort().WithName(remote.Ports[i].Name).WithPort(remote.Ports[i].Port).
+			WithTargetPort(remote.Ports[i].TargetPort).WithProtocol(remote.Ports[i].Protocol)
+		port.AppProtocol = remote.Ports[i].AppProtocol
+		local.WithPorts(port)
+	}
+
+	local.PublishNotReadyAddresses = &remote.PublishNotReadyAddresses
+	local.SessionAffinity = &remote.SessionAffinity
+
+	if remote.ClusterIP == corev1.ClusterIPNone {
+		local.ClusterIP = pointer.String(corev1.ClusterIPNone)
+	}
+
+	return local
+}
+
+// LocalExportedShadowEndpointSlice forges the local shadowendpointslice, given the remote endpointslice of an exported service.
+// The resulting object is controlled by the given local service, so that it is garbage collected as soon as the service is deleted.
+func LocalExportedShadowEndpointSlice(remote *discoveryv1.EndpointSlice, local *offloadingv1beta1.ShadowEndpointSlice,
+	service *corev1.Service, translator EndpointTranslator, forgingOpts *ForgingOpts) *offloadingv1beta1.ShadowEndpointSlice {
+	if local == nil {
+		// The local is nil if not already created.
+		local = &offloadingv1beta1.ShadowEndpointSlice{ObjectMeta: metav1.ObjectMeta{Name: remote.GetName(), Namespace: service.GetNamespace()}}
+	}
+
+	objectMeta := local.ObjectMeta.DeepCopy()
+	objectMeta.SetLabels(labels.Merge(FilterNotReflected(remote.GetLabels(), forgingOpts.LabelsNotReflected),
+		labels.Merge(EndpointSliceLabels(), ReverseReflectionLabels())))
+	objectMeta.SetAnnotations(FilterNotReflected(remote.GetAnnotations(), forgingOpts.AnnotationsNotReflected))
+	objectMeta.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(service, corev1.SchemeGroupVersion.WithKind("Service"))})
+
+	return &offloadingv1beta1.ShadowEndpointSlice{
+		ObjectMeta: *objectMeta,
+		Spec: offloadingv1beta1.ShadowEndpointSliceSpec{
+			Template: offloadingv1beta1.EndpointSliceTemplate{
+				AddressType: remote.AddressType,
+				Endpoints:   LocalExportedEndpoints(remote.Endpoints, translator),
+				Ports:       RemoteEndpointSlicePorts(remote.Ports),
+			},
+		},
+	}
+}
+
+// LocalExportedEndpoints forges the endpoints of the local shadowendpointslice, given the remote ones.
+// Node names and topology hints are dropped, as meaningless in the local cluster, as well as the endpoints
+// none of whose addresses can be translated.
+func LocalExportedEndpoints(remotes []discoveryv1.Endpoint, translator EndpointTranslator) []discoveryv1.Endpoint {
+	var locals []discoveryv1.Endpoint
+
+	for i := range remotes {
+		remote := remotes[i].DeepCopy()
+		addresses := translator(remote.Addresses)
+		if len(addresses) == 0 {
+			continue
+		}
+
+		locals = append(locals, discoveryv1.Endpoint{
+			Addresses:  addresses,
+			Conditions: discoveryv1.EndpointConditions{Ready: remote.Conditions.Ready},
+			Hostname:   remote.Hostname,
+			TargetRef:  RemoteEndpointTargetRef(remote.TargetRef),
+		})
+	}
+
+	return locals
+}
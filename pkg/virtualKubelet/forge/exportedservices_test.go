@@ -0,0 +1,216 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/utils/pointer"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Exported Services Forging", func() {
+	Describe("the IsExportedToConsumer function", func() {
+		DescribeTable("should return the correct result",
+			func(annotations map[string]string, expected bool) {
+				svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+				Expect(forge.IsExportedToConsumer(svc)).To(BeIdenticalTo(expected))
+			},
+			Entry("no annotations", nil, false),
+			Entry("annotation set to true", map[string]string{consts.ExportToConsumerAnnotationKey: "true"}, true),
+			Entry("annotation set to True", map[string]string{consts.ExportToConsumerAnnotationKey: "True"}, true),
+			Entry("annotation set to false", map[string]string{consts.ExportToConsumerAnnotationKey: "false"}, false),
+		)
+	})
+
+	Describe("the LocalExportedService function", func() {
+		var (
+			input  *corev1.Service
+			output *corev1apply.ServiceApplyConfiguration
+		)
+
+		BeforeEach(func() {
+			input = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "name", Namespace: "remote",
+					Labels: map[string]string{"foo": "bar", testutil.FakeNotReflectedLabelKey: "true"},
+					Annotations: map[string]string{"bar": "baz", testutil.FakeNotReflectedAnnotKey: "true",
+						consts.ExportToConsumerAnnotationKey: "true"},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					Selector:  map[string]string{"app": "db"},
+					ClusterIP: "10.0.0.1",
+					Ports: []corev1.ServicePort{{Name: "sql", Port: 5432, TargetPort: intstr.FromInt(5433),
+						Protocol: corev1.ProtocolTCP, NodePort: 30000}},
+					SessionAffinity: corev1.ServiceAffinityClientIP,
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			output = forge.LocalExportedService(input, "local", testutil.FakeForgingOpts())
+		})
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(PointTo(Equal("name")))
+			Expect(output.Namespace).To(PointTo(Equal("local")))
+		})
+
+		It("should correctly set the labels", func() {
+			Expect(output.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(RemoteClusterID)))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, string(LocalClusterID)))
+			Expect(output.Labels).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+		})
+
+		It("should correctly set the annotations", func() {
+			Expect(output.Annotations).To(HaveKeyWithValue("bar", "baz"))
+			Expect(output.Annotations).ToNot(HaveKey(testutil.FakeNotReflectedAnnotKey))
+			Expect(output.Annotations).ToNot(HaveKey(consts.ExportToConsumerAnnotationKey))
+		})
+
+		It("should forge a selectorless ClusterIP service", func() {
+			Expect(output.Spec.Type).To(PointTo(Equal(corev1.ServiceTypeClusterIP)))
+			Expect(output.Spec.Selector).To(BeNil())
+			Expect(output.Spec.ClusterIP).To(BeNil())
+			Expect(output.Spec.SessionAffinity).To(PointTo(Equal(corev1.ServiceAffinityClientIP)))
+		})
+
+		It("should correctly set the ports", func() {
+			Expect(output.Spec.Ports).To(HaveLen(1))
+			Expect(output.Spec.Ports[0].Name).To(PointTo(Equal("sql")))
+			Expect(output.Spec.Ports[0].Port).To(PointTo(BeNumerically("==", 5432)))
+			Expect(output.Spec.Ports[0].TargetPort).To(PointTo(Equal(intstr.FromInt(5433))))
+			Expect(output.Spec.Ports[0].Protocol).To(PointTo(Equal(corev1.ProtocolTCP)))
+			Expect(output.Spec.Ports[0].NodePort).To(BeNil())
+		})
+
+		When("the remote service is headless", func() {
+			BeforeEach(func() { input.Spec.ClusterIP = corev1.ClusterIPNone })
+			It("should preserve the headless nature", func() {
+				Expect(output.Spec.ClusterIP).To(PointTo(Equal(corev1.ClusterIPNone)))
+			})
+		})
+	})
+
+	Describe("the LocalExportedShadowEndpointSlice function", func() {
+		var (
+			input   *discoveryv1.EndpointSlice
+			current *offloadingv1beta1.ShadowEndpointSlice
+			service *corev1.Service
+			output  *offloadingv1beta1.ShadowEndpointSlice
+		)
+
+		Translator := func(inputs []string) (outputs []string) {
+			for _, input := range inputs {
+				if input != "unreachable" {
+					outputs = append(outputs, input+"-translated")
+				}
+			}
+			return outputs
+		}
+
+		BeforeEach(func() {
+			input = &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "name-abcde", Namespace: "remote",
+					Labels: map[string]string{discoveryv1.LabelServiceName: "name", discoveryv1.LabelManagedBy: "endpointslice-controller.k8s.io",
+						testutil.FakeNotReflectedLabelKey: "true"},
+					Annotations: map[string]string{"bar": "baz"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true), Serving: pointer.Bool(true)},
+					Hostname:   pointer.String("db-0"),
+					TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "db-0"},
+					NodeName:   pointer.String("remote-node"),
+					Zone:       pointer.String("zone"),
+				}},
+				Ports: []discoveryv1.EndpointPort{{Name: pointer.String("sql"), Port: pointer.Int32(5433)}},
+			}
+			current = nil
+			service = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "local", UID: "uid"}}
+		})
+
+		JustBeforeEach(func() {
+			output = forge.LocalExportedShadowEndpointSlice(input, current, service, Translator, testutil.FakeForgingOpts())
+		})
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(Equal("name-abcde"))
+			Expect(output.Namespace).To(Equal("local"))
+		})
+
+		It("should correctly set the labels", func() {
+			Expect(output.Labels).To(HaveKeyWithValue(discoveryv1.LabelServiceName, "name"))
+			Expect(output.Labels).To(HaveKeyWithValue(discoveryv1.LabelManagedBy, forge.EndpointSliceManagedBy))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(RemoteClusterID)))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, string(LocalClusterID)))
+			Expect(output.Labels).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+			Expect(forge.IsReverseReflected(output)).To(BeTrue())
+		})
+
+		It("should be controlled by the local service", func() {
+			Expect(output.OwnerReferences).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"Kind": Equal("Service"), "Name": Equal("name"), "UID": BeEquivalentTo("uid"), "Controller": PointTo(BeTrue()),
+			})))
+		})
+
+		It("should correctly forge the endpoints", func() {
+			Expect(output.Spec.Template.AddressType).To(Equal(discoveryv1.AddressTypeIPv4))
+			Expect(output.Spec.Template.Endpoints).To(ConsistOf(discoveryv1.Endpoint{
+				Addresses:  []string{"10.0.0.1-translated"},
+				Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)},
+				Hostname:   pointer.String("db-0"),
+				TargetRef:  &corev1.ObjectReference{Kind: "RemotePod", Name: "db-0"},
+			}))
+			Expect(output.Spec.Template.Ports).To(Equal(input.Ports))
+		})
+
+		When("none of the addresses of an endpoint can be translated", func() {
+			BeforeEach(func() {
+				input.Endpoints = append(input.Endpoints, discoveryv1.Endpoint{Addresses: []string{"unreachable"}})
+			})
+
+			It("should skip the endpoint", func() {
+				Expect(output.Spec.Template.Endpoints).To(HaveLen(1))
+				Expect(output.Spec.Template.Endpoints[0].Addresses).To(ConsistOf("10.0.0.1-translated"))
+			})
+		})
+
+		When("the local shadowendpointslice already exists", func() {
+			BeforeEach(func() {
+				current = &offloadingv1beta1.ShadowEndpointSlice{ObjectMeta: metav1.ObjectMeta{
+					Name: "name-abcde", Namespace: "local", ResourceVersion: "42"}}
+			})
+
+			It("should preserve the existing metadata", func() {
+				Expect(output.ResourceVersion).To(Equal("42"))
+			})
+		})
+	})
+})
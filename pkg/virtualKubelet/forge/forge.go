@@ -70,6 +70,13 @@ func ApplyOptions() metav1.ApplyOptions {
 	}
 }
 
+// DryRunApplyOptions returns the apply options configured for the dry-run reflection of objects.
+func DryRunApplyOptions() metav1.ApplyOptions {
+	opts := ApplyOptions()
+	opts.DryRun = []string{metav1.DryRunAll}
+	return opts
+}
+
 // ForgingOpts contains options to forge the reflected resources.
 type ForgingOpts struct {
 	LabelsNotReflected      []string
@@ -0,0 +1,162 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const (
+	// GatewayAPIGroup is the API group of the Gateway API resources.
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+	// GatewayKind is the kind of the Gateway API gateways.
+	GatewayKind = "Gateway"
+)
+
+// RemoteGatewayRoute forges the apply patch for the reflected Gateway API route (e.g., HTTPRoute, GRPCRoute, TLSRoute), given the local one.
+// In case the remote gateway is specified, the parent references targeting a gateway are replaced by a single reference to the remote one,
+// while the other parent references (e.g., services) are dropped, as referring to the local cluster.
+func RemoteGatewayRoute(local *unstructured.Unstructured, targetNamespace string, remoteGateway *types.NamespacedName,
+	forgingOpts *ForgingOpts) *unstructured.Unstructured {
+	remote := RemoteCustomResource(local, targetNamespace, forgingOpts)
+
+	if remoteGateway != nil {
+		parentRefs, _, _ := unstructured.NestedSlice(local.Object, "spec", "parentRefs")
+		if remoteParentRefs := RemoteGatewayParentRefs(parentRefs, *remoteGateway); len(remoteParentRefs) > 0 {
+			utilruntime.Must(unstructured.SetNestedSlice(remote.Object, remoteParentRefs, "spec", "parentRefs"))
+		} else {
+			unstructured.RemoveNestedField(remote.Object, "spec", "parentRefs")
+		}
+	}
+
+	// Backend references explicitly targeting the local namespace are translated to the remote one.
+	rules, _, _ := unstructured.NestedSlice(remote.Object, "spec", "rules")
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for j := range backendRefs {
+			if backendRef, ok := backendRefs[j].(map[string]interface{}); ok && backendRef["namespace"] == local.GetNamespace() {
+				backendRef["namespace"] = targetNamespace
+			}
+		}
+		if len(backendRefs) > 0 {
+			utilruntime.Must(unstructured.SetNestedSlice(rule, backendRefs, "backendRefs"))
+		}
+	}
+	if len(rules) > 0 {
+		utilruntime.Must(unstructured.SetNestedSlice(remote.Object, rules, "spec", "rules"))
+	}
+
+	return remote
+}
+
+// RemoteGatewayParentRefs returns the parent references of the reflected route, given the local ones.
+// A single reference to the remote gateway is returned in case at least one local parent reference targets a gateway.
+func RemoteGatewayParentRefs(local []interface{}, remoteGateway types.NamespacedName) []interface{} {
+	for i := range local {
+		if parentRef, ok := local[i].(map[string]interface{}); ok && IsGatewayParentRef(parentRef) {
+			return []interface{}{map[string]interface{}{
+				"group":     GatewayAPIGroup,
+				"kind":      GatewayKind,
+				"name":      remoteGateway.Name,
+				"namespace": remoteGateway.Namespace,
+			}}
+		}
+	}
+	return nil
+}
+
+// IsGatewayParentRef returns whether the given parent reference targets a gateway (which is the default when group and kind are not set).
+func IsGatewayParentRef(parentRef map[string]interface{}) bool {
+	group, found, _ := unstructured.NestedString(parentRef, "group")
+	if found && group != GatewayAPIGroup {
+		return false
+	}
+	kind, found, _ := unstructured.NestedString(parentRef, "kind")
+	return !found || kind == GatewayKind
+}
+
+// LocalGatewayRouteStatus returns a copy of the local route with the status of the remote one, and whether it differs from the current local status.
+// In case the remote gateway is specified, the status entries concerning it are mapped to each local parent reference targeting a gateway,
+// replacing those previously set by the same controllers, while the entries set by other controllers are preserved.
+func LocalGatewayRouteStatus(local, remote *unstructured.Unstructured, remoteGateway *types.NamespacedName) (*unstructured.Unstructured, bool) {
+	if remoteGateway == nil {
+		return LocalCustomResourceStatus(local, remote)
+	}
+
+	localParentRefs, _, _ := unstructured.NestedSlice(local.Object, "spec", "parentRefs")
+	localParents, _, _ := unstructured.NestedSlice(local.Object, "status", "parents")
+	remoteParents, _, _ := unstructured.NestedSlice(remote.Object, "status", "parents")
+
+	// Retrieve the status entries concerning the remote gateway.
+	var reflected []map[string]interface{}
+	controllers := map[string]struct{}{}
+	for i := range remoteParents {
+		parent, ok := remoteParents[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parentRef, _, _ := unstructured.NestedMap(parent, "parentRef")
+		name, _, _ := unstructured.NestedString(parentRef, "name")
+		namespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if !IsGatewayParentRef(parentRef) || name != remoteGateway.Name || namespace != remoteGateway.Namespace {
+			continue
+		}
+		controller, _, _ := unstructured.NestedString(parent, "controllerName")
+		controllers[controller] = struct{}{}
+		reflected = append(reflected, parent)
+	}
+
+	// Preserve the local status entries set by other controllers.
+	parents := []interface{}{}
+	for i := range localParents {
+		parent, ok := localParents[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		controller, _, _ := unstructured.NestedString(parent, "controllerName")
+		if _, found := controllers[controller]; !found {
+			parents = append(parents, runtime.DeepCopyJSONValue(parent))
+		}
+	}
+
+	// Map the remote status entries to each local parent reference targeting a gateway.
+	for i := range localParentRefs {
+		parentRef, ok := localParentRefs[i].(map[string]interface{})
+		if !ok || !IsGatewayParentRef(parentRef) {
+			continue
+		}
+		for _, parent := range reflected {
+			mapped := runtime.DeepCopyJSONValue(parent).(map[string]interface{})
+			mapped["parentRef"] = runtime.DeepCopyJSONValue(parentRef)
+			parents = append(parents, mapped)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(parents, localParents) || (len(parents) == 0 && len(localParents) == 0) {
+		return local, false
+	}
+
+	output := local.DeepCopy()
+	utilruntime.Must(unstructured.SetNestedSlice(output.Object, parents, "status", "parents"))
+	return output, true
+}
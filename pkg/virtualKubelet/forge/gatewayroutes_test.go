@@ -0,0 +1,206 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Gateway API routes Forging", func() {
+	var (
+		local         *unstructured.Unstructured
+		remoteGateway *types.NamespacedName
+	)
+
+	gatewayRef := func(name, namespace string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "namespace": namespace}
+	}
+
+	routeStatus := func(parentRef map[string]interface{}, controller, reason string) map[string]interface{} {
+		return map[string]interface{}{
+			"parentRef":      parentRef,
+			"controllerName": controller,
+			"conditions":     []interface{}{map[string]interface{}{"type": "Accepted", "status": "True", "reason": reason}},
+		}
+	}
+
+	BeforeEach(func() {
+		local = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "HTTPRoute",
+			"spec": map[string]interface{}{
+				"hostnames": []interface{}{"foo.example.com"},
+				"parentRefs": []interface{}{
+					gatewayRef("local-gateway", "gateways"),
+					map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "other", "sectionName": "https"},
+					map[string]interface{}{"group": "", "kind": "Service", "name": "mesh"},
+				},
+				"rules": []interface{}{map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "backend", "port": int64(80)},
+						map[string]interface{}{"name": "explicit", "namespace": "local-namespace", "port": int64(80)},
+						map[string]interface{}{"name": "other", "namespace": "other-namespace", "port": int64(80)},
+					},
+				}},
+			},
+		}}
+		local.SetName("name")
+		local.SetNamespace("local-namespace")
+		local.SetLabels(map[string]string{"foo": "bar", testutil.FakeNotReflectedLabelKey: "true"})
+		remoteGateway = &types.NamespacedName{Namespace: "remote-gateways", Name: "remote-gateway"}
+	})
+
+	Describe("the RemoteGatewayRoute function", func() {
+		var output *unstructured.Unstructured
+
+		JustBeforeEach(func() {
+			output = forge.RemoteGatewayRoute(local, "remote-namespace", remoteGateway, testutil.FakeForgingOpts())
+		})
+
+		It("should correctly set the metadata", func() {
+			Expect(output.GetName()).To(Equal("name"))
+			Expect(output.GetNamespace()).To(Equal("remote-namespace"))
+			Expect(output.GetKind()).To(Equal("HTTPRoute"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(LocalClusterID)))
+			Expect(output.GetLabels()).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+		})
+
+		It("should reflect the hostnames verbatim", func() {
+			Expect(output.Object["spec"]).To(HaveKeyWithValue("hostnames", ConsistOf("foo.example.com")))
+		})
+
+		It("should replace the gateway parent references with the remote gateway", func() {
+			parentRefs, _, _ := unstructured.NestedSlice(output.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(ConsistOf(map[string]interface{}{
+				"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "remote-gateway", "namespace": "remote-gateways",
+			}))
+		})
+
+		It("should translate the backend references targeting the local namespace", func() {
+			rules, _, _ := unstructured.NestedSlice(output.Object, "spec", "rules")
+			Expect(rules).To(HaveLen(1))
+			backendRefs, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "backendRefs")
+			Expect(backendRefs).To(ConsistOf(
+				map[string]interface{}{"name": "backend", "port": int64(80)},
+				map[string]interface{}{"name": "explicit", "namespace": "remote-namespace", "port": int64(80)},
+				map[string]interface{}{"name": "other", "namespace": "other-namespace", "port": int64(80)},
+			))
+		})
+
+		It("should not mutate the local object", func() {
+			parentRefs, _, _ := unstructured.NestedSlice(local.Object, "spec", "parentRefs")
+			Expect(parentRefs).To(HaveLen(3))
+		})
+
+		When("the remote gateway is not specified", func() {
+			BeforeEach(func() { remoteGateway = nil })
+
+			It("should reflect the parent references verbatim", func() {
+				parentRefs, _, _ := unstructured.NestedSlice(output.Object, "spec", "parentRefs")
+				Expect(parentRefs).To(HaveLen(3))
+				Expect(parentRefs[0]).To(Equal(gatewayRef("local-gateway", "gateways")))
+			})
+		})
+
+		When("no parent reference targets a gateway", func() {
+			BeforeEach(func() {
+				Expect(unstructured.SetNestedSlice(local.Object, []interface{}{
+					map[string]interface{}{"group": "", "kind": "Service", "name": "mesh"}}, "spec", "parentRefs")).To(Succeed())
+			})
+
+			It("should not set any parent reference", func() {
+				_, found, _ := unstructured.NestedSlice(output.Object, "spec", "parentRefs")
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("the LocalGatewayRouteStatus function", func() {
+		var (
+			remote  *unstructured.Unstructured
+			output  *unstructured.Unstructured
+			changed bool
+		)
+
+		BeforeEach(func() {
+			remote = forge.RemoteGatewayRoute(local, "remote-namespace", remoteGateway, testutil.FakeForgingOpts())
+			Expect(unstructured.SetNestedSlice(remote.Object, []interface{}{
+				routeStatus(gatewayRef("remote-gateway", "remote-gateways"), "example.com/gateway-controller", "Accepted"),
+				routeStatus(gatewayRef("unrelated", "remote-gateways"), "example.com/other-controller", "Accepted"),
+			}, "status", "parents")).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			output, changed = forge.LocalGatewayRouteStatus(local, remote, remoteGateway)
+		})
+
+		It("should map the status of the remote gateway to the local gateway parent references", func() {
+			Expect(changed).To(BeTrue())
+			parents, _, _ := unstructured.NestedSlice(output.Object, "status", "parents")
+			Expect(parents).To(ConsistOf(
+				routeStatus(gatewayRef("local-gateway", "gateways"), "example.com/gateway-controller", "Accepted"),
+				routeStatus(map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "other", "sectionName": "https"},
+					"example.com/gateway-controller", "Accepted"),
+			))
+		})
+
+		When("the local status contains entries set by other controllers", func() {
+			BeforeEach(func() {
+				Expect(unstructured.SetNestedSlice(local.Object, []interface{}{
+					routeStatus(gatewayRef("local-gateway", "gateways"), "example.com/gateway-controller", "Pending"),
+					routeStatus(map[string]interface{}{"kind": "Service", "name": "mesh"}, "example.com/mesh-controller", "Accepted"),
+				}, "status", "parents")).To(Succeed())
+			})
+
+			It("should replace only the entries set by the remote controllers", func() {
+				Expect(changed).To(BeTrue())
+				parents, _, _ := unstructured.NestedSlice(output.Object, "status", "parents")
+				Expect(parents).To(HaveLen(3))
+				Expect(parents).To(ContainElement(
+					routeStatus(map[string]interface{}{"kind": "Service", "name": "mesh"}, "example.com/mesh-controller", "Accepted")))
+				Expect(parents).To(ContainElement(
+					routeStatus(gatewayRef("local-gateway", "gateways"), "example.com/gateway-controller", "Accepted")))
+			})
+		})
+
+		When("the local status is already aligned", func() {
+			BeforeEach(func() {
+				aligned, _ := forge.LocalGatewayRouteStatus(local, remote, remoteGateway)
+				local = aligned
+			})
+
+			It("should report no changes", func() {
+				Expect(changed).To(BeFalse())
+				Expect(output).To(Equal(local))
+			})
+		})
+
+		When("the remote gateway is not specified", func() {
+			BeforeEach(func() { remoteGateway = nil })
+
+			It("should reflect the remote status verbatim", func() {
+				Expect(changed).To(BeTrue())
+				Expect(output.Object["status"]).To(Equal(remote.Object["status"]))
+			})
+		})
+	})
+})
@@ -57,6 +57,19 @@ func IsReflected(obj metav1.Object) bool {
 	return ReflectedLabelSelector().Matches(labels.Set(obj.GetLabels()))
 }
 
+// ReverseReflectionLabels returns the labels assigned to the objects reflected from the remote to the local cluster.
+func ReverseReflectionLabels() labels.Set {
+	return map[string]string{
+		LiqoOriginClusterIDKey:      string(RemoteCluster),
+		LiqoDestinationClusterIDKey: string(LocalCluster),
+	}
+}
+
+// IsReverseReflected returns whether the current object has been reflected from the remote to the local cluster.
+func IsReverseReflected(obj metav1.Object) bool {
+	return ReverseReflectionLabels().AsSelectorPreValidated().Matches(labels.Set(obj.GetLabels()))
+}
+
 // RemoteObjectMeta forges the local ObjectMeta for a reflected object.
 func RemoteObjectMeta(local, remote *metav1.ObjectMeta) metav1.ObjectMeta {
 	output := remote.DeepCopy()
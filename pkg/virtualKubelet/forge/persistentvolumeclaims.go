@@ -0,0 +1,27 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsEphemeralPersistentVolumeClaim returns whether the given PersistentVolumeClaim backs a generic ephemeral volume,
+// that is, it has been created by the ephemeral volume controller and it is controlled by the corresponding pod.
+func IsEphemeralPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) bool {
+	owner := metav1.GetControllerOf(pvc)
+	return owner != nil && owner.APIVersion == corev1.SchemeGroupVersion.String() && owner.Kind == "Pod"
+}
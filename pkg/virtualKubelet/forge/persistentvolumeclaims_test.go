@@ -0,0 +1,59 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("PersistentVolumeClaims Forging", func() {
+	Describe("the IsEphemeralPersistentVolumeClaim function", func() {
+		var owners []metav1.OwnerReference
+
+		pvc := func() *corev1.PersistentVolumeClaim {
+			return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pod-data", OwnerReferences: owners}}
+		}
+
+		When("the PVC has no owner", func() {
+			BeforeEach(func() { owners = nil })
+			It("should return false", func() { Expect(forge.IsEphemeralPersistentVolumeClaim(pvc())).To(BeFalse()) })
+		})
+
+		When("the PVC is controlled by a pod", func() {
+			BeforeEach(func() {
+				owners = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: "pod", Controller: ptr.To(true)}}
+			})
+			It("should return true", func() { Expect(forge.IsEphemeralPersistentVolumeClaim(pvc())).To(BeTrue()) })
+		})
+
+		When("the PVC is owned, but not controlled, by a pod", func() {
+			BeforeEach(func() { owners = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: "pod"}} })
+			It("should return false", func() { Expect(forge.IsEphemeralPersistentVolumeClaim(pvc())).To(BeFalse()) })
+		})
+
+		When("the PVC is controlled by a different kind of object", func() {
+			BeforeEach(func() {
+				owners = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "sts", Controller: ptr.To(true)}}
+			})
+			It("should return false", func() { Expect(forge.IsEphemeralPersistentVolumeClaim(pvc())).To(BeFalse()) })
+		})
+	})
+})
@@ -0,0 +1,99 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	metav1apply "k8s.io/client-go/applyconfigurations/meta/v1"
+	policyv1apply "k8s.io/client-go/applyconfigurations/policy/v1"
+
+	"github.com/liqotech/liqo/pkg/utils/pod"
+)
+
+// RemotePodDisruptionBudget forges the apply patch for the reflected poddisruptionbudget, given the local one.
+// The budget is always expressed in terms of an absolute minAvailable value, which corresponds to the share of the
+// local budget enforced by the remote cluster, as remote pods are controlled by ShadowPods (which are not scalable).
+func RemotePodDisruptionBudget(local *policyv1.PodDisruptionBudget, minAvailable int32,
+	targetNamespace string, forgingOpts *ForgingOpts) *policyv1apply.PodDisruptionBudgetApplyConfiguration {
+	spec := policyv1apply.PodDisruptionBudgetSpec().
+		WithMinAvailable(intstr.FromInt32(minAvailable)).
+		WithSelector(RemoteLabelSelector(local.Spec.Selector))
+
+	if local.Spec.UnhealthyPodEvictionPolicy != nil {
+		spec = spec.WithUnhealthyPodEvictionPolicy(*local.Spec.UnhealthyPodEvictionPolicy)
+	}
+
+	return policyv1apply.PodDisruptionBudget(local.GetName(), targetNamespace).
+		WithLabels(FilterNotReflected(local.GetLabels(), forgingOpts.LabelsNotReflected)).WithLabels(ReflectionLabels()).
+		WithAnnotations(FilterNotReflected(local.GetAnnotations(), forgingOpts.AnnotationsNotReflected)).
+		WithSpec(spec)
+}
+
+// RemotePodDisruptionBudgetMinAvailable computes the minimum number of pods which shall be available in the remote cluster,
+// given the number of healthy pods offloaded to the remote cluster and the status of the local budget. Specifically, the
+// remote cluster is allowed to disrupt at most the number of pods the local budget currently allows to be disrupted,
+// since the local status accounts for the pods spanning both the local and the remote clusters.
+func RemotePodDisruptionBudgetMinAvailable(local *policyv1.PodDisruptionBudget, remoteHealthy int32) int32 {
+	// Conservatively prevent any disruption if the local status is not yet up-to-date.
+	if local.Status.ObservedGeneration < local.GetGeneration() {
+		return remoteHealthy
+	}
+
+	return remoteHealthy - min(max(local.Status.DisruptionsAllowed, 0), remoteHealthy)
+}
+
+// RemotePodDisruptionBudgetHealthyPods returns the number of pods which are offloaded to the remote cluster,
+// match the selector of the given poddisruptionbudget, and are currently healthy (i.e., ready and not terminating).
+func RemotePodDisruptionBudgetHealthyPods(local *policyv1.PodDisruptionBudget, offloaded []*corev1.Pod) (int32, error) {
+	// A nil selector matches no pods, while an empty one matches all pods in the namespace.
+	if local.Spec.Selector == nil {
+		return 0, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(local.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+
+	var healthy int32
+	for _, po := range offloaded {
+		if !selector.Matches(labels.Set(po.GetLabels())) || !po.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if ready, _ := pod.IsPodReady(po); ready {
+			healthy++
+		}
+	}
+	return healthy, nil
+}
+
+// RemoteLabelSelector forges the apply patch for a reflected label selector.
+func RemoteLabelSelector(selector *metav1.LabelSelector) *metav1apply.LabelSelectorApplyConfiguration {
+	if selector == nil {
+		return nil
+	}
+
+	output := metav1apply.LabelSelector().WithMatchLabels(selector.MatchLabels)
+	for i := range selector.MatchExpressions {
+		expression := &selector.MatchExpressions[i]
+		output = output.WithMatchExpressions(metav1apply.LabelSelectorRequirement().
+			WithKey(expression.Key).WithOperator(expression.Operator).WithValues(expression.Values...))
+	}
+	return output
+}
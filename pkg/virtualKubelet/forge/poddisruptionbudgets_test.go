@@ -0,0 +1,131 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	policyv1apply "k8s.io/client-go/applyconfigurations/policy/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("PodDisruptionBudgets Forging", func() {
+	var local *policyv1.PodDisruptionBudget
+
+	BeforeEach(func() {
+		local = &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "name", Namespace: "namespace", Generation: 2,
+				Labels:      map[string]string{"foo": "bar", testutil.FakeNotReflectedLabelKey: "true"},
+				Annotations: map[string]string{"bar": "baz", testutil.FakeNotReflectedAnnotKey: "true"},
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: ptr.To(intstr.FromString("50%")),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "quorum"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"db"}},
+					},
+				},
+				UnhealthyPodEvictionPolicy: ptr.To(policyv1.AlwaysAllow),
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{ObservedGeneration: 2, DisruptionsAllowed: 1},
+		}
+	})
+
+	Describe("the RemotePodDisruptionBudget function", func() {
+		var output *policyv1apply.PodDisruptionBudgetApplyConfiguration
+
+		JustBeforeEach(func() {
+			output = forge.RemotePodDisruptionBudget(local, 2, "remote-namespace", testutil.FakeForgingOpts())
+		})
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.Name).To(PointTo(Equal("name")))
+			Expect(output.Namespace).To(PointTo(Equal("remote-namespace")))
+		})
+
+		It("should correctly set the labels and annotations", func() {
+			Expect(output.Labels).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(LocalClusterID)))
+			Expect(output.Labels).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, string(RemoteClusterID)))
+			Expect(output.Labels).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+			Expect(output.Annotations).To(HaveKeyWithValue("bar", "baz"))
+			Expect(output.Annotations).ToNot(HaveKey(testutil.FakeNotReflectedAnnotKey))
+		})
+
+		It("should express the budget as an absolute minAvailable value", func() {
+			Expect(output.Spec.MinAvailable).To(PointTo(Equal(intstr.FromInt32(2))))
+			Expect(output.Spec.MaxUnavailable).To(BeNil())
+		})
+
+		It("should correctly translate the selector and the eviction policy", func() {
+			Expect(output.Spec.Selector.MatchLabels).To(HaveKeyWithValue("app", "quorum"))
+			Expect(output.Spec.Selector.MatchExpressions).To(HaveLen(1))
+			Expect(output.Spec.Selector.MatchExpressions[0].Key).To(PointTo(Equal("tier")))
+			Expect(output.Spec.Selector.MatchExpressions[0].Operator).To(PointTo(Equal(metav1.LabelSelectorOpIn)))
+			Expect(output.Spec.Selector.MatchExpressions[0].Values).To(ConsistOf("db"))
+			Expect(output.Spec.UnhealthyPodEvictionPolicy).To(PointTo(Equal(policyv1.AlwaysAllow)))
+		})
+	})
+
+	Describe("the RemotePodDisruptionBudgetMinAvailable function", func() {
+		It("should allow at most the disruptions allowed by the local budget", func() {
+			Expect(forge.RemotePodDisruptionBudgetMinAvailable(local, 3)).To(BeNumerically("==", 2))
+		})
+
+		It("should not exceed the number of healthy remote pods", func() {
+			local.Status.DisruptionsAllowed = 5
+			Expect(forge.RemotePodDisruptionBudgetMinAvailable(local, 3)).To(BeNumerically("==", 0))
+		})
+
+		It("should prevent all disruptions if the local status is outdated", func() {
+			local.Status.ObservedGeneration = 1
+			Expect(forge.RemotePodDisruptionBudgetMinAvailable(local, 3)).To(BeNumerically("==", 3))
+		})
+	})
+
+	Describe("the RemotePodDisruptionBudgetHealthyPods function", func() {
+		ReadyPod := func(name string, ready corev1.ConditionStatus, lbls map[string]string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace", Labels: lbls},
+				Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+			}
+		}
+
+		It("should count the ready pods matching the selector", func() {
+			pods := []*corev1.Pod{
+				ReadyPod("ready", corev1.ConditionTrue, map[string]string{"app": "quorum", "tier": "db"}),
+				ReadyPod("not-ready", corev1.ConditionFalse, map[string]string{"app": "quorum", "tier": "db"}),
+				ReadyPod("not-matching", corev1.ConditionTrue, map[string]string{"app": "other"}),
+			}
+			Expect(forge.RemotePodDisruptionBudgetHealthyPods(local, pods)).To(BeNumerically("==", 1))
+		})
+
+		It("should not count any pod if the selector is nil", func() {
+			local.Spec.Selector = nil
+			pods := []*corev1.Pod{ReadyPod("ready", corev1.ConditionTrue, map[string]string{"app": "quorum", "tier": "db"})}
+			Expect(forge.RemotePodDisruptionBudgetHealthyPods(local, pods)).To(BeNumerically("==", 0))
+		})
+	})
+})
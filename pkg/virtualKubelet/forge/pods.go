@@ -31,6 +31,7 @@ import (
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils/maps"
+	"github.com/liqotech/liqo/pkg/utils/pod"
 )
 
 const (
@@ -79,9 +80,17 @@ type KubernetesServiceIPGetter func() string
 
 // LocalPod forges the object meta and status of the local pod, given the remote one.
 func LocalPod(local, remote *corev1.Pod, translator PodIPTranslator, restarts int32, mutators ...RemotePodStatusMutator) *corev1.Pod {
+	status := LocalPodStatus(remote.Status.DeepCopy(), translator, restarts, mutators...)
+	EphemeralContainerStatusesMutator(local.Spec.EphemeralContainers)(&status)
+
+	// Mark the resize as in progress until the remote pod has been aligned with the resources requested locally.
+	if status.Resize == "" && !pod.AreContainerResourcesAligned(remote.Spec.Containers, local.Spec.Containers) {
+		status.Resize = corev1.PodResizeStatusInProgress
+	}
+
 	return &corev1.Pod{
 		ObjectMeta: *local.ObjectMeta.DeepCopy(),
-		Status:     LocalPodStatus(remote.Status.DeepCopy(), translator, restarts, mutators...),
+		Status:     status,
 	}
 }
 
@@ -193,6 +202,13 @@ func RemoteShadowPod(local *corev1.Pod, remote *offloadingv1beta1.ShadowPod,
 // RemotePodSpec forges the specs of the reflected pod specs, given the local ones.
 // It expects the local and remote objects to be deepcopies, as they are mutated.
 func RemotePodSpec(creation bool, local, remote *corev1.PodSpec, mutators ...RemotePodSpecMutator) corev1.PodSpec {
+	// Ephemeral containers are always propagated, since they can be added to running pods (e.g., through kubectl debug).
+	// The remote ShadowPod controller is then in charge of injecting them through the ephemeralcontainers subresource.
+	remote.EphemeralContainers = local.EphemeralContainers
+
+	// Similarly, container resources can be updated through in-place pod resize, and they are propagated as well.
+	remote.Containers = RemoteContainersResources(local.Containers, remote.Containers)
+
 	// Do not mutate the pod specifications after it has been created, since it is likely the modification
 	// would be rejected by the API server, as only a very limited set of fields can be mutated.
 	// Additionally, such modification would not be currently propagated by the remote ShadowPod controller.
@@ -317,6 +333,23 @@ func OpaqueIPTranslationMutator() RemotePodStatusMutator {
 	}
 }
 
+// EphemeralContainerStatusesMutator is a mutator which retains only the statuses of the ephemeral containers
+// which are part of the local pod specification, ignoring the ones possibly added directly to the remote pod.
+func EphemeralContainerStatusesMutator(ephemeralContainers []corev1.EphemeralContainer) RemotePodStatusMutator {
+	return func(remote *corev1.PodStatus) {
+		var statuses []corev1.ContainerStatus
+		for i := range remote.EphemeralContainerStatuses {
+			for j := range ephemeralContainers {
+				if remote.EphemeralContainerStatuses[i].Name == ephemeralContainers[j].Name {
+					statuses = append(statuses, remote.EphemeralContainerStatuses[i])
+					break
+				}
+			}
+		}
+		remote.EphemeralContainerStatuses = statuses
+	}
+}
+
 // AntiAffinityPropagateMutator is a mutator which implements the support to propagate a given anti-affinity constraint.
 func AntiAffinityPropagateMutator(affinity *corev1.Affinity) RemotePodSpecMutator {
 	return func(remote *corev1.PodSpec) {
@@ -431,6 +464,19 @@ func RemoteContainersAPIServerSupport(containers []corev1.Container, saName, hom
 	return containers
 }
 
+// RemoteContainersResources aligns the resources of the remote containers with the ones of the local containers with the same name.
+func RemoteContainersResources(local, remote []corev1.Container) []corev1.Container {
+	for i := range remote {
+		for j := range local {
+			if remote[i].Name == local[j].Name {
+				remote[i].Resources = local[j].Resources
+				break
+			}
+		}
+	}
+	return remote
+}
+
 // RemoteContainerEnvVariablesAPIServerSupport forges the environment variables to enable offloaded containers to
 // contact back the local API server, instead of the remote one. In addition, it also hardcodes the
 // service account name in case it was retrieved from the pod spec, as it is not reflected remotely.
@@ -627,3 +673,59 @@ func SumContainerStats(stats []statsv1alpha1.ContainerStats, retriever func(stat
 	}
 	return &sum
 }
+
+// PriorityClassMutator is a mutator which implements the support to propagate the priority class,
+// referring to the counterpart reflected in the remote cluster (built-in priority classes are preserved as is).
+func PriorityClassMutator(priorityClassName string) RemotePodSpecMutator {
+	return func(remote *corev1.PodSpec) {
+		if priorityClassName == "" {
+			return
+		}
+
+		remote.PriorityClassName = priorityClassName
+		if IsClusterScopedReflectable(offloadingv1beta1.PriorityClassKind, priorityClassName) {
+			remote.PriorityClassName = RemoteClusterScopedName(priorityClassName)
+		}
+	}
+}
+
+// RuntimeClassMutator is a mutator which implements the support to propagate the runtime class,
+// referring to the counterpart reflected in the remote cluster.
+func RuntimeClassMutator(runtimeClassName *string) RemotePodSpecMutator {
+	return func(remote *corev1.PodSpec) {
+		if runtimeClassName == nil || *runtimeClassName == "" {
+			return
+		}
+
+		remote.RuntimeClassName = ptr.To(RemoteClusterScopedName(*runtimeClassName))
+	}
+}
+
+// EphemeralVolumesMutator is a mutator which implements the support for generic ephemeral volumes, translating
+// the claim templates referring to the virtual storage class, so that the corresponding PVCs are created by the remote
+// cluster for the offloaded pod. The remote storage class is selected through the dedicated annotation, if present,
+// or defaults to the remote real one. Claim templates referring to other storage classes are preserved as is.
+func EphemeralVolumesMutator(virtualStorageClassName, remoteRealStorageClassName string) RemotePodSpecMutator {
+	return func(remote *corev1.PodSpec) {
+		for i := range remote.Volumes {
+			if remote.Volumes[i].Ephemeral == nil || remote.Volumes[i].Ephemeral.VolumeClaimTemplate == nil {
+				continue
+			}
+
+			template := remote.Volumes[i].Ephemeral.VolumeClaimTemplate.DeepCopy()
+			if template.Spec.StorageClassName != nil && *template.Spec.StorageClassName != virtualStorageClassName {
+				continue
+			}
+
+			template.Spec.StorageClassName = nil
+			if class := template.Annotations[liqoconst.RemoteStorageClassAnnotation]; class != "" {
+				template.Spec.StorageClassName = ptr.To(class)
+			} else if remoteRealStorageClassName != "" {
+				template.Spec.StorageClassName = ptr.To(remoteRealStorageClassName)
+			}
+			delete(template.Annotations, liqoconst.RemoteStorageClassAnnotation)
+
+			remote.Volumes[i].Ephemeral = &corev1.EphemeralVolumeSource{VolumeClaimTemplate: template}
+		}
+	}
+}
@@ -68,6 +68,37 @@ var _ = Describe("Pod forging", func() {
 			Expect(output.Status.ContainerStatuses[0].Ready).To(BeTrue())
 			Expect(output.Status.ContainerStatuses[0].RestartCount).To(BeNumerically("==", 4))
 		})
+
+		When("the remote pod has ephemeral containers", func() {
+			BeforeEach(func() {
+				local.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"}}}
+				remote.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{
+					{Name: "debugger", Ready: true}, {Name: "other", Ready: true}}
+			})
+
+			It("should reflect only the statuses of the ephemeral containers part of the local spec", func() {
+				Expect(output.Status.EphemeralContainerStatuses).To(ConsistOf(corev1.ContainerStatus{Name: "debugger", Ready: true}))
+			})
+		})
+
+		When("the local pod has been resized", func() {
+			BeforeEach(func() {
+				local.Spec.Containers = []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}}}}
+				remote.Spec.Containers = []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}}}}
+			})
+
+			It("should mark the resize as in progress", func() {
+				Expect(output.Status.Resize).To(Equal(corev1.PodResizeStatusInProgress))
+			})
+
+			When("the remote pod has already been resized", func() {
+				BeforeEach(func() { remote.Spec.Containers[0].Resources = local.Spec.Containers[0].Resources })
+				It("should reflect the remote resize status", func() { Expect(output.Status.Resize).To(BeEmpty()) })
+			})
+		})
 	})
 
 	Describe("the LocalPodOffloadedLabel function", func() {
@@ -213,6 +244,38 @@ var _ = Describe("Pod forging", func() {
 				Expect(output.Spec.Pod).To(Equal(corev1.PodSpec{}))
 			})
 		})
+
+		Context("the remote pod already exists, and an ephemeral container has been added", func() {
+			BeforeEach(func() {
+				remote = &offloadingv1beta1.ShadowPod{ObjectMeta: metav1.ObjectMeta{Name: "remote-name", Namespace: "remote-namespace", UID: "remote-uid"}}
+				local.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"}}}
+			})
+
+			It("should propagate the ephemeral containers only", func() {
+				Expect(output.Spec.Pod).To(Equal(corev1.PodSpec{EphemeralContainers: local.Spec.EphemeralContainers}))
+			})
+		})
+
+		Context("the remote pod already exists, and it has been resized", func() {
+			BeforeEach(func() {
+				remote = &offloadingv1beta1.ShadowPod{
+					ObjectMeta: metav1.ObjectMeta{Name: "remote-name", Namespace: "remote-namespace", UID: "remote-uid"},
+					Spec: offloadingv1beta1.ShadowPodSpec{Pod: corev1.PodSpec{Containers: []corev1.Container{
+						{Name: "foo", Image: "bar", Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}}},
+					}}},
+				}
+				local.Spec.Containers = []corev1.Container{{Name: "foo", Image: "baz", Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}}}}
+			})
+
+			It("should propagate the container resources only", func() {
+				Expect(output.Spec.Pod.Containers).To(HaveLen(1))
+				Expect(output.Spec.Pod.Containers[0].Image).To(Equal("bar"))
+				Expect(output.Spec.Pod.Containers[0].Resources).To(Equal(local.Spec.Containers[0].Resources))
+			})
+		})
 	})
 
 	Describe("the APIServerSupportMutator function", func() {
@@ -421,6 +484,134 @@ var _ = Describe("Pod forging", func() {
 		})
 	})
 
+	Describe("the PriorityClassMutator function", func() {
+		var (
+			name   string
+			remote *corev1.PodSpec
+		)
+
+		BeforeEach(func() { remote = &corev1.PodSpec{} })
+		JustBeforeEach(func() { forge.PriorityClassMutator(name)(remote) })
+
+		When("the priority class is not set", func() {
+			BeforeEach(func() { name = "" })
+			It("should not mutate the remote pod", func() { Expect(remote).To(Equal(&corev1.PodSpec{})) })
+		})
+
+		When("the priority class is a custom one", func() {
+			BeforeEach(func() { name = "high-priority" })
+			It("should refer to the reflected priority class", func() {
+				Expect(remote.PriorityClassName).To(Equal(string(LocalClusterID) + "-high-priority"))
+			})
+		})
+
+		When("the priority class is a built-in one", func() {
+			BeforeEach(func() { name = "system-cluster-critical" })
+			It("should preserve the original priority class", func() {
+				Expect(remote.PriorityClassName).To(Equal("system-cluster-critical"))
+			})
+		})
+	})
+
+	Describe("the RuntimeClassMutator function", func() {
+		var (
+			name   *string
+			remote *corev1.PodSpec
+		)
+
+		BeforeEach(func() { remote = &corev1.PodSpec{} })
+		JustBeforeEach(func() { forge.RuntimeClassMutator(name)(remote) })
+
+		When("the runtime class is not set", func() {
+			BeforeEach(func() { name = nil })
+			It("should not mutate the remote pod", func() { Expect(remote).To(Equal(&corev1.PodSpec{})) })
+		})
+
+		When("the runtime class is set", func() {
+			BeforeEach(func() { name = pointer.String("gvisor") })
+			It("should refer to the reflected runtime class", func() {
+				Expect(remote.RuntimeClassName).To(PointTo(Equal(string(LocalClusterID) + "-gvisor")))
+			})
+		})
+	})
+
+	Describe("the EphemeralVolumesMutator function", func() {
+		var (
+			template   *corev1.PersistentVolumeClaimTemplate
+			remote     *corev1.PodSpec
+			remoteReal string
+		)
+
+		ephemeral := func(spec *corev1.PodSpec) *corev1.PersistentVolumeClaimTemplate {
+			return spec.Volumes[1].Ephemeral.VolumeClaimTemplate
+		}
+
+		BeforeEach(func() {
+			remoteReal = "remote-real"
+			template = &corev1.PersistentVolumeClaimTemplate{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"foo": "bar"}},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					StorageClassName: pointer.String("liqo"),
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			remote = &corev1.PodSpec{Volumes: []corev1.Volume{
+				{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "data", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{VolumeClaimTemplate: template}}},
+			}}
+			forge.EphemeralVolumesMutator("liqo", remoteReal)(remote)
+		})
+
+		When("the claim template refers to the virtual storage class", func() {
+			It("should refer to the remote real storage class", func() {
+				Expect(ephemeral(remote).Spec.StorageClassName).To(PointTo(Equal("remote-real")))
+			})
+			It("should preserve the other fields", func() {
+				Expect(ephemeral(remote).Labels).To(HaveKeyWithValue("foo", "bar"))
+				Expect(ephemeral(remote).Spec.AccessModes).To(ConsistOf(corev1.ReadWriteOnce))
+			})
+			It("should not mutate the original template", func() { Expect(template.Spec.StorageClassName).To(PointTo(Equal("liqo"))) })
+			It("should not mutate the other volumes", func() { Expect(remote.Volumes[0].EmptyDir).ToNot(BeNil()) })
+		})
+
+		When("the claim template does not specify the storage class", func() {
+			BeforeEach(func() { template.Spec.StorageClassName = nil })
+			It("should refer to the remote real storage class", func() {
+				Expect(ephemeral(remote).Spec.StorageClassName).To(PointTo(Equal("remote-real")))
+			})
+		})
+
+		When("the remote real storage class is not set", func() {
+			BeforeEach(func() { remoteReal = "" })
+			It("should refer to the remote default storage class", func() {
+				Expect(ephemeral(remote).Spec.StorageClassName).To(BeNil())
+			})
+		})
+
+		When("the remote storage class is selected through the annotation", func() {
+			BeforeEach(func() {
+				template.Annotations = map[string]string{consts.RemoteStorageClassAnnotation: "fast", "baz": "qux"}
+			})
+			It("should refer to the selected storage class", func() {
+				Expect(ephemeral(remote).Spec.StorageClassName).To(PointTo(Equal("fast")))
+			})
+			It("should drop the annotation", func() {
+				Expect(ephemeral(remote).Annotations).ToNot(HaveKey(consts.RemoteStorageClassAnnotation))
+				Expect(ephemeral(remote).Annotations).To(HaveKeyWithValue("baz", "qux"))
+			})
+		})
+
+		When("the claim template refers to a different storage class", func() {
+			BeforeEach(func() { template.Spec.StorageClassName = pointer.String("other") })
+			It("should preserve the storage class", func() {
+				Expect(ephemeral(remote).Spec.StorageClassName).To(PointTo(Equal("other")))
+			})
+		})
+	})
+
 	Describe("the FilterAntiAffinityLabels function", func() {
 		var (
 			input, output map[string]string
@@ -0,0 +1,148 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const (
+	// SnapshotAPIGroup is the API group of the CSI volume snapshot resources.
+	SnapshotAPIGroup = "snapshot.storage.k8s.io"
+	// VolumeSnapshotKind is the kind of the CSI volume snapshots.
+	VolumeSnapshotKind = "VolumeSnapshot"
+	// VolumeSnapshotContentKind is the kind of the CSI volume snapshot contents.
+	VolumeSnapshotContentKind = "VolumeSnapshotContent"
+
+	volumeSnapshotContentPrefix = "snapcontent-"
+)
+
+var (
+	// VolumeSnapshotGVR is the group version resource of the CSI volume snapshots.
+	VolumeSnapshotGVR = schema.GroupVersionResource{Group: SnapshotAPIGroup, Version: "v1", Resource: "volumesnapshots"}
+	// VolumeSnapshotContentGVR is the group version resource of the CSI volume snapshot contents.
+	VolumeSnapshotContentGVR = schema.GroupVersionResource{Group: SnapshotAPIGroup, Version: "v1", Resource: "volumesnapshotcontents"}
+)
+
+// VolumeSnapshotSource returns the name of the PersistentVolumeClaim the given volume snapshot originates from, if any.
+func VolumeSnapshotSource(snapshot *unstructured.Unstructured) (string, bool) {
+	claim, found, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	return claim, found && claim != ""
+}
+
+// VolumeSnapshotClassName returns the name of the class of the given volume snapshot, if any.
+func VolumeSnapshotClassName(snapshot *unstructured.Unstructured) string {
+	class, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	return class
+}
+
+// IsVolumeSnapshotDataSource returns whether the given data source refers to a volume snapshot.
+func IsVolumeSnapshotDataSource(ref *corev1.TypedLocalObjectReference) bool {
+	return ref != nil && ref.APIGroup != nil && *ref.APIGroup == SnapshotAPIGroup && ref.Kind == VolumeSnapshotKind
+}
+
+// RemoteVolumeSnapshot forges the apply patch for the volume snapshot corresponding to the local one, which targets the
+// given PersistentVolumeClaim of the target namespace. The snapshot class is dropped, unless explicitly specified, as
+// the one of the local snapshot refers to the virtual storage: the default class of the target cluster is used in that case.
+func RemoteVolumeSnapshot(local *unstructured.Unstructured, targetNamespace, claimName, snapshotClass string,
+	forgingOpts *ForgingOpts) *unstructured.Unstructured {
+	remote := RemoteCustomResource(local, targetNamespace, forgingOpts)
+	remote.Object["spec"] = map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claimName},
+	}
+	if snapshotClass != "" {
+		utilruntime.Must(unstructured.SetNestedField(remote.Object, snapshotClass, "spec", "volumeSnapshotClassName"))
+	}
+	return remote
+}
+
+// VolumeSnapshotContentName returns the name of the VolumeSnapshotContent bound to the given dynamically provisioned volume
+// snapshot. It matches the one expected by the snapshot controller, which binds the snapshot to the content rather than creating it.
+func VolumeSnapshotContentName(snapshot *unstructured.Unstructured) string {
+	return volumeSnapshotContentPrefix + string(snapshot.GetUID())
+}
+
+// VirtualVolumeSnapshotContent forges the VolumeSnapshotContent pre-bound to the given virtual volume snapshot, served by
+// the given driver. Being no CSI driver serving the virtual storage, its status is managed by Liqo only, and propagated to the
+// virtual snapshot by the snapshot controller, which is hence the only one writing the status of the virtual snapshot.
+func VirtualVolumeSnapshotContent(snapshot *unstructured.Unstructured, driver string) *unstructured.Unstructured {
+	claim, _ := VolumeSnapshotSource(snapshot)
+
+	content := &unstructured.Unstructured{}
+	content.SetGroupVersionKind(VolumeSnapshotContentGVR.GroupVersion().WithKind(VolumeSnapshotContentKind))
+	content.SetName(VolumeSnapshotContentName(snapshot))
+	content.Object["spec"] = map[string]interface{}{
+		"driver":                  driver,
+		"deletionPolicy":          "Delete",
+		"volumeSnapshotClassName": VolumeSnapshotClassName(snapshot),
+		"source":                  map[string]interface{}{"volumeHandle": snapshot.GetNamespace() + "/" + claim},
+		"volumeSnapshotRef": map[string]interface{}{
+			"apiVersion": VolumeSnapshotGVR.GroupVersion().String(),
+			"kind":       VolumeSnapshotKind,
+			"namespace":  snapshot.GetNamespace(),
+			"name":       snapshot.GetName(),
+			"uid":        string(snapshot.GetUID()),
+		},
+	}
+	return content
+}
+
+// VolumeSnapshotContentDriver returns the driver of the given VolumeSnapshotContent.
+func VolumeSnapshotContentDriver(content *unstructured.Unstructured) string {
+	driver, _, _ := unstructured.NestedString(content.Object, "spec", "driver")
+	return driver
+}
+
+// VirtualVolumeSnapshotContentStatus returns a copy of the VolumeSnapshotContent bound to a virtual snapshot with the status of
+// the real snapshot identified by the given handle (i.e., its readiness, size and possible errors), converted to the format of
+// the contents, and whether it differs from the current one.
+func VirtualVolumeSnapshotContentStatus(content, real *unstructured.Unstructured, handle string) (*unstructured.Unstructured, bool) {
+	realStatus, _, _ := unstructured.NestedMap(real.Object, "status")
+	current, _, _ := unstructured.NestedMap(content.Object, "status")
+
+	status := map[string]interface{}{"snapshotHandle": handle}
+	if ready, found := realStatus["readyToUse"].(bool); found {
+		status["readyToUse"] = ready
+	}
+	if size, found := realStatus["restoreSize"].(string); found {
+		if quantity, err := resource.ParseQuantity(size); err == nil {
+			status["restoreSize"] = quantity.Value()
+		}
+	}
+	if created, found := realStatus["creationTime"].(string); found {
+		if timestamp, err := time.Parse(time.RFC3339, created); err == nil {
+			status["creationTime"] = timestamp.UnixNano()
+		}
+	}
+	if snapshotErr, found := realStatus["error"]; found {
+		status["error"] = runtime.DeepCopyJSONValue(snapshotErr)
+	}
+
+	if equality.Semantic.DeepEqual(status, current) {
+		return content, false
+	}
+
+	output := content.DeepCopy()
+	utilruntime.Must(unstructured.SetNestedMap(output.Object, status, "status"))
+	return output, true
+}
@@ -0,0 +1,158 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("VolumeSnapshots Forging", func() {
+	var local *unstructured.Unstructured
+
+	BeforeEach(func() {
+		local = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "snapshot.storage.k8s.io/v1",
+			"kind":       "VolumeSnapshot",
+			"spec": map[string]interface{}{
+				"source":                  map[string]interface{}{"persistentVolumeClaimName": "claim"},
+				"volumeSnapshotClassName": "liqo",
+			},
+			"status": map[string]interface{}{
+				"boundVolumeSnapshotContentName": "local-content",
+			},
+		}}
+		local.SetName("name")
+		local.SetNamespace("local-namespace")
+		local.SetLabels(map[string]string{"foo": "bar", testutil.FakeNotReflectedLabelKey: "true"})
+	})
+
+	Describe("the RemoteVolumeSnapshot function", func() {
+		var remote *unstructured.Unstructured
+
+		When("no snapshot class is specified", func() {
+			BeforeEach(func() {
+				remote = forge.RemoteVolumeSnapshot(local, "remote-namespace", "real-claim", "", testutil.FakeForgingOpts())
+			})
+
+			It("should target the given namespace", func() {
+				Expect(remote.GetName()).To(Equal("name"))
+				Expect(remote.GetNamespace()).To(Equal("remote-namespace"))
+			})
+			It("should reflect the labels", func() {
+				Expect(remote.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+				Expect(remote.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, string(LocalClusterID)))
+				Expect(remote.GetLabels()).ToNot(HaveKey(testutil.FakeNotReflectedLabelKey))
+			})
+			It("should target the given claim", func() {
+				claim, found := forge.VolumeSnapshotSource(remote)
+				Expect(found).To(BeTrue())
+				Expect(claim).To(Equal("real-claim"))
+			})
+			It("should drop the snapshot class", func() {
+				Expect(forge.VolumeSnapshotClassName(remote)).To(BeEmpty())
+			})
+			It("should not reflect the status", func() {
+				Expect(remote.Object).ToNot(HaveKey("status"))
+			})
+		})
+
+		When("a snapshot class is specified", func() {
+			BeforeEach(func() {
+				remote = forge.RemoteVolumeSnapshot(local, "remote-namespace", "real-claim", "csi-snapclass", testutil.FakeForgingOpts())
+			})
+
+			It("should set the snapshot class", func() {
+				Expect(forge.VolumeSnapshotClassName(remote)).To(Equal("csi-snapclass"))
+			})
+		})
+	})
+
+	Describe("the VirtualVolumeSnapshotContent function", func() {
+		var content *unstructured.Unstructured
+
+		BeforeEach(func() {
+			local.SetUID("uid")
+			content = forge.VirtualVolumeSnapshotContent(local, "liqo.io/storage")
+		})
+
+		It("should be named as expected by the snapshot controller", func() {
+			Expect(content.GetName()).To(Equal("snapcontent-uid"))
+			Expect(content.GetKind()).To(Equal(forge.VolumeSnapshotContentKind))
+		})
+		It("should refer to the given driver", func() {
+			Expect(forge.VolumeSnapshotContentDriver(content)).To(Equal("liqo.io/storage"))
+		})
+		It("should be pre-bound to the snapshot", func() {
+			Expect(content.Object["spec"]).To(HaveKeyWithValue("volumeSnapshotRef", SatisfyAll(
+				HaveKeyWithValue("namespace", "local-namespace"), HaveKeyWithValue("name", "name"), HaveKeyWithValue("uid", "uid"))))
+			Expect(content.Object["spec"]).To(HaveKeyWithValue("source", HaveKeyWithValue("volumeHandle", "local-namespace/claim")))
+		})
+	})
+
+	Describe("the VirtualVolumeSnapshotContentStatus function", func() {
+		var (
+			content *unstructured.Unstructured
+			remote  *unstructured.Unstructured
+			output  *unstructured.Unstructured
+			changed bool
+		)
+
+		BeforeEach(func() {
+			content = forge.VirtualVolumeSnapshotContent(local, "liqo.io/storage")
+			remote = local.DeepCopy()
+			remote.Object["status"] = map[string]interface{}{
+				"boundVolumeSnapshotContentName": "remote-content",
+				"creationTime":                   "2024-01-01T00:00:00Z",
+				"readyToUse":                     true,
+				"restoreSize":                    "1Gi",
+			}
+		})
+
+		JustBeforeEach(func() {
+			output, changed = forge.VirtualVolumeSnapshotContentStatus(content, remote, "remote-namespace/name")
+		})
+
+		It("should report a change", func() { Expect(changed).To(BeTrue()) })
+		It("should reflect the readiness, the size and the creation time, converted to the content format", func() {
+			Expect(output.Object["status"]).To(HaveKeyWithValue("readyToUse", true))
+			Expect(output.Object["status"]).To(HaveKeyWithValue("restoreSize", int64(1<<30)))
+			Expect(output.Object["status"]).To(HaveKeyWithValue("creationTime", int64(1704067200000000000)))
+		})
+		It("should set the snapshot handle", func() {
+			Expect(output.Object["status"]).To(HaveKeyWithValue("snapshotHandle", "remote-namespace/name"))
+		})
+		It("should not reflect the bound content", func() {
+			Expect(output.Object["status"]).ToNot(HaveKey("boundVolumeSnapshotContentName"))
+		})
+
+		When("the status is already aligned", func() {
+			BeforeEach(func() {
+				content.Object["status"] = map[string]interface{}{
+					"snapshotHandle": "remote-namespace/name",
+					"creationTime":   int64(1704067200000000000),
+					"readyToUse":     true,
+					"restoreSize":    int64(1 << 30),
+				}
+			})
+
+			It("should not report a change", func() { Expect(changed).To(BeFalse()) })
+		})
+	})
+})
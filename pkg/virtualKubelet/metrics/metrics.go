@@ -30,6 +30,8 @@ var (
 	// ItemsCounter is the counter of the reflected resources.
 	// A fast increase of this metric can indicate a race condition between local and remote operators.
 	ItemsCounter *prometheus.CounterVec
+	// ReflectionStatusGauge is the gauge of the objects handled by each reflector, grouped by reflection state.
+	ReflectionStatusGauge *prometheus.GaugeVec
 )
 
 // Init initializes the metrics. If no error occurs or no item is processed, the corresponding metric is not exported.
@@ -51,6 +53,14 @@ func init() {
 		},
 		MetricsLabels,
 	)
+
+	ReflectionStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "liqo_virtual_kubelet_reflection_status",
+			Help: "The number of objects handled by each reflector, grouped by state (reflected, pending, failed).",
+		},
+		append(MetricsLabels, "state"),
+	)
 }
 
 // SetupMetricHandler sets up the metric handler.
@@ -59,6 +69,8 @@ func SetupMetricHandler(metricsAddress string) {
 	prometheus.MustRegister(ErrorsCounter)
 	// Register the metrics to the prometheus registry.
 	prometheus.MustRegister(ItemsCounter)
+	// Register the metrics to the prometheus registry.
+	prometheus.MustRegister(ReflectionStatusGauge)
 
 	http.Handle("/metrics", promhttp.Handler())
 
@@ -16,12 +16,17 @@ package provider
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -36,7 +41,9 @@ import (
 	liqoclient "github.com/liqotech/liqo/pkg/client/clientset/versioned"
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/clusterscoped"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/configuration"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/customresource"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/event"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
@@ -65,16 +72,22 @@ type InitConfig struct {
 	LocalPodCIDR         string
 	InformerResyncPeriod time.Duration
 
-	ReflectorsConfigs map[resources.ResourceReflected]offloadingv1beta1.ReflectorConfig
+	ReflectorsConfigs       map[resources.ResourceReflected]offloadingv1beta1.ReflectorConfig
+	CustomReflectorsConfigs map[schema.GroupVersionResource]offloadingv1beta1.ReflectorConfig
+	ClusterScopedReflection []offloadingv1beta1.ClusterScopedResourceKind
 
 	EnableAPIServerSupport          bool
 	EnableStorage                   bool
 	VirtualStorageClassName         string
 	RemoteRealStorageClassName      string
+	RemoteStorageClasses            []string
 	EnableIngress                   bool
 	RemoteRealIngressClassName      string
 	EnableLoadBalancer              bool
 	RemoteRealLoadBalancerClassName string
+	EnableGateway                   bool
+	RemoteGatewayName               string
+	RemoteGatewayNamespace          string
 	EnableMetrics                   bool
 
 	HomeAPIServerHost string
@@ -96,9 +109,11 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 	forge.Init(cfg.LocalCluster, cfg.RemoteCluster, cfg.NodeName, cfg.NodeIP)
 	localClient := kubernetes.NewForConfigOrDie(cfg.LocalConfig)
 	localLiqoClient := liqoclient.NewForConfigOrDie(cfg.LocalConfig)
+	localDynamicClient := dynamic.NewForConfigOrDie(cfg.LocalConfig)
 
 	remoteClient := kubernetes.NewForConfigOrDie(cfg.RemoteConfig)
 	remoteLiqoClient := liqoclient.NewForConfigOrDie(cfg.RemoteConfig)
+	remoteDynamicClient := dynamic.NewForConfigOrDie(cfg.RemoteConfig)
 	remoteMetricsClient := metrics.NewForConfigOrDie(cfg.RemoteConfig).MetricsV1beta1().PodMetricses
 
 	apiServerSupport := forge.APIServerSupportDisabled
@@ -137,14 +152,18 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 
 			return string(v), nil
 		},
-		NetConfiguration: cfg.NetConfiguration,
+		NetConfiguration:           cfg.NetConfiguration,
+		ClusterScopedReflection:    cfg.ClusterScopedReflection,
+		VirtualStorageClassName:    cfg.VirtualStorageClassName,
+		RemoteRealStorageClassName: cfg.RemoteRealStorageClassName,
 	}
 
 	podreflector := workload.NewPodReflector(cfg.RemoteConfig, remoteMetricsClient, &podReflectorConfig, ptr.To(cfg.ReflectorsConfigs[resources.Pod]))
 
 	forgingOpts := forge.NewForgingOpts(cfg.OffloadingPatch)
 
-	reflectionManager := manager.New(localClient, remoteClient, localLiqoClient, remoteLiqoClient, cfg.InformerResyncPeriod, eb, &forgingOpts).
+	reflectionManager := manager.New(localClient, remoteClient, localLiqoClient, remoteLiqoClient,
+		localDynamicClient, remoteDynamicClient, cfg.InformerResyncPeriod, eb, &forgingOpts).
 		With(podreflector).
 		With(exposition.NewServiceReflector(ptr.To(cfg.ReflectorsConfigs[resources.Service]), cfg.EnableLoadBalancer, cfg.RemoteRealLoadBalancerClassName)).
 		With(exposition.NewIngressReflector(ptr.To(cfg.ReflectorsConfigs[resources.Ingress]), cfg.EnableIngress, cfg.RemoteRealIngressClassName)).
@@ -153,12 +172,68 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 		With(configuration.NewServiceAccountReflector(apiServerSupport == forge.APIServerSupportTokenAPI,
 			ptr.To(cfg.ReflectorsConfigs[resources.ServiceAccount]))).
 		With(storage.NewPersistentVolumeClaimReflector(cfg.VirtualStorageClassName, cfg.RemoteRealStorageClassName,
-			cfg.EnableStorage, ptr.To(cfg.ReflectorsConfigs[resources.PersistentVolumeClaim]))).
+			cfg.RemoteStorageClasses, cfg.EnableStorage, ptr.To(cfg.ReflectorsConfigs[resources.PersistentVolumeClaim]))).
 		With(event.NewEventReflector(ptr.To(cfg.ReflectorsConfigs[resources.Event]))).
+		With(workload.NewPodDisruptionBudgetReflector(ptr.To(cfg.ReflectorsConfigs[resources.PodDisruptionBudget]))).
 		WithNamespaceHandler(namespacemap.NewHandler(localLiqoClient, cfg.Namespace, cfg.InformerResyncPeriod))
 
 	if !cfg.DisableIPReflection {
 		reflectionManager.With(exposition.NewEndpointSliceReflector(cfg.LocalPodCIDR, ptr.To(cfg.ReflectorsConfigs[resources.EndpointSlice])))
+
+		// The remote pod CIDR is known only if the networking module is enabled. Otherwise, no address translation is required.
+		var remotePodCIDR string
+		if cfg.NetConfiguration != nil {
+			remotePodCIDR = cfg.NetConfiguration.Spec.Remote.CIDR.Pod.String()
+		}
+		reflectionManager.With(exposition.NewExportedServiceReflector(remotePodCIDR, ptr.To(cfg.ReflectorsConfigs[resources.ExportedService])))
+	}
+
+	var remoteGateway *types.NamespacedName
+	if cfg.EnableGateway {
+		remoteGateway = &types.NamespacedName{Namespace: cfg.RemoteGatewayNamespace, Name: cfg.RemoteGatewayName}
+	}
+	for _, route := range exposition.GatewayRoutes {
+		// The Gateway API CRDs are not part of the core APIs, hence the routes are reflected only if available in both clusters.
+		supported, err := isResourceSupported(route.GVR, localClient, remoteClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check whether %v are supported", route.GVR.GroupResource())
+		}
+		if !supported {
+			klog.V(4).Infof("Disabled reflection of %v, as not supported by both clusters", route.GVR.GroupResource())
+			continue
+		}
+		reflectionManager.With(exposition.NewGatewayRouteReflector(route, ptr.To(cfg.ReflectorsConfigs[route.Resource]), remoteGateway))
+	}
+
+	if cfg.EnableStorage {
+		// The CSI snapshot CRDs are not part of the core APIs, hence the snapshots are reflected only if available in both clusters.
+		supported, err := isResourceSupported(forge.VolumeSnapshotGVR, localClient, remoteClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check whether %v are supported", forge.VolumeSnapshotGVR.GroupResource())
+		}
+		if supported {
+			reflectionManager.With(storage.NewVolumeSnapshotReflector(cfg.VirtualStorageClassName,
+				ptr.To(cfg.ReflectorsConfigs[resources.VolumeSnapshot])))
+		} else {
+			klog.V(4).Infof("Disabled reflection of %v, as not supported by both clusters", forge.VolumeSnapshotGVR.GroupResource())
+		}
+	}
+
+	for gvr, reflectorConfig := range cfg.CustomReflectorsConfigs {
+		// The custom resources are reflected only if available in both clusters, to avoid informers that never sync.
+		supported, err := isResourceSupported(gvr, localClient, remoteClient)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check whether %v are supported", gvr.GroupResource())
+		}
+		if !supported {
+			klog.Warningf("Disabled reflection of %v, as not supported by both clusters", gvr.GroupResource())
+			continue
+		}
+		reflectionManager.With(customresource.NewCustomResourceReflector(gvr, ptr.To(reflectorConfig)))
+	}
+
+	for _, kind := range cfg.ClusterScopedReflection {
+		reflectionManager.WithClusterScoped(clusterscoped.NewClusterScopedReflector(kind))
 	}
 
 	reflectionManager.Start(ctx)
@@ -174,6 +249,16 @@ func (p *LiqoProvider) PodHandler() workload.PodHandler {
 	return p.podHandler
 }
 
+// DryRun forges the remote object corresponding to the given local one through the given reflector, without enforcing it.
+func (p *LiqoProvider) DryRun(ctx context.Context, reflector, namespace, name string) (*manager.DryRunResult, error) {
+	return p.reflectionManager.DryRun(ctx, reflector, namespace, name)
+}
+
+// ReflectionStatus returns the aggregated statistics about the objects handled by each reflector.
+func (p *LiqoProvider) ReflectionStatus() []offloadingv1beta1.ReflectorStatus {
+	return p.reflectionManager.Status()
+}
+
 func isSATokenAPISupport(localClient kubernetes.Interface) (bool, error) {
 	res, err := localClient.Discovery().ServerResourcesForGroupVersion(corev1.SchemeGroupVersion.String())
 	if err != nil {
@@ -189,6 +274,25 @@ func isSATokenAPISupport(localClient kubernetes.Interface) (bool, error) {
 	return false, nil
 }
 
+// isResourceSupported returns whether the given resource is served by the API servers of all the given clusters.
+func isResourceSupported(gvr schema.GroupVersionResource, clients ...kubernetes.Interface) (bool, error) {
+	for _, cl := range clients {
+		res, err := cl.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if !slices.ContainsFunc(res.APIResources, func(resource metav1.APIResource) bool { return resource.Name == gvr.Resource }) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // Resync force the resync of all informers contained in the reflection manager.
 func (p *LiqoProvider) Resync() error {
 	return p.reflectionManager.Resync()
@@ -0,0 +1,281 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterscoped
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/leaderelection"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+)
+
+// workers is the number of workers of each cluster-scoped reflector, as the number of reflected objects is expected to be limited.
+const workers = 1
+
+var _ manager.ClusterScopedReflector = (*ClusterScopedReflector)(nil)
+
+// ClusterScopedReflector manages the reflection of a given kind of cluster-scoped resources.
+// Remote objects are named after the local ones, prefixed with the local cluster ID to prevent collisions among different consumers.
+type ClusterScopedReflector struct {
+	name string
+	kind offloadingv1beta1.ClusterScopedResourceKind
+	gvr  schema.GroupVersionResource
+
+	workqueue workqueue.RateLimitingInterface
+
+	localObjects        cache.GenericLister
+	remoteObjects       cache.GenericLister
+	remoteObjectsClient dynamic.ResourceInterface
+
+	ready       func() bool
+	forgingOpts *forge.ForgingOpts
+}
+
+// NewClusterScopedReflector returns a new ClusterScopedReflector for the given kind of cluster-scoped resources.
+func NewClusterScopedReflector(kind offloadingv1beta1.ClusterScopedResourceKind) *ClusterScopedReflector {
+	name := strings.ToLower(string(kind))
+	return &ClusterScopedReflector{
+		name:      name,
+		kind:      kind,
+		gvr:       resources.ClusterScopedResources[kind],
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
+	}
+}
+
+// String returns the name of the ClusterScopedReflector.
+func (csr *ClusterScopedReflector) String() string {
+	return csr.name
+}
+
+// Start starts the reflector.
+func (csr *ClusterScopedReflector) Start(ctx context.Context, opts *options.ClusterScopedOpts) {
+	klog.Infof("Starting the %v cluster-scoped reflector with %v workers", csr.name, workers)
+	csr.Init(opts)
+
+	for i := 0; i < workers; i++ {
+		go wait.Until(csr.runWorker, time.Second, ctx.Done())
+	}
+
+	// Make sure the working queue is properly stopped when the context is closed.
+	go func() {
+		<-ctx.Done()
+		csr.workqueue.ShutDown()
+	}()
+}
+
+// Init configures the listers and the event handlers of the reflector, without starting the workers.
+func (csr *ClusterScopedReflector) Init(opts *options.ClusterScopedOpts) {
+	local := opts.LocalDynamicFactory.ForResource(csr.gvr)
+	remote := opts.RemoteDynamicFactory.ForResource(csr.gvr)
+
+	_, err := local.Informer().AddEventHandler(csr.handlers(func(name string) (string, bool) { return name, true }))
+	utilruntime.Must(err)
+	_, err = remote.Informer().AddEventHandler(csr.handlers(forge.LocalClusterScopedName))
+	utilruntime.Must(err)
+
+	csr.localObjects = local.Lister()
+	csr.remoteObjects = remote.Lister()
+	csr.remoteObjectsClient = opts.RemoteDynamicClient.Resource(csr.gvr)
+	csr.ready = opts.Ready
+	csr.forgingOpts = opts.ForgingOpts
+}
+
+// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
+func (csr *ClusterScopedReflector) Handle(ctx context.Context, name string) error {
+	remoteName := forge.RemoteClusterScopedName(name)
+	klog.V(4).Infof("Handling reflection of local %v %q (remote: %q)", csr.name, name, remoteName)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	local, lerr := csr.get(csr.localObjects, name)
+	if lerr != nil && !kerrors.IsNotFound(lerr) {
+		return lerr
+	}
+	remote, rerr := csr.get(csr.remoteObjects, remoteName)
+	if rerr != nil && !kerrors.IsNotFound(rerr) {
+		return rerr
+	}
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Warningf("Skipping reflection of local %v %q as remote already exists and is not managed by us", csr.name, name)
+		}
+		return nil
+	}
+
+	// Let pretend the local object does not exist in case it cannot be reflected, so that the remote one gets deleted (if any).
+	if lerr == nil && !forge.IsClusterScopedReflectable(csr.kind, name) {
+		klog.V(4).Infof("Skipping reflection of local %v %q, as not reflectable", csr.name, name)
+		lerr = kerrors.NewNotFound(csr.gvr.GroupResource(), name)
+	}
+
+	if kerrors.IsNotFound(lerr) {
+		if kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Local %v %q and remote %v %q both vanished", csr.name, name, csr.name, remoteName)
+			return nil
+		}
+
+		klog.V(4).Infof("Deleting remote %v %q, since local %q does no longer exist", csr.name, remoteName, name)
+		err := csr.remoteObjectsClient.Delete(ctx, remoteName, *metav1.NewPreconditionDeleteOptions(string(remote.GetUID())))
+		if err != nil && !kerrors.IsNotFound(err) {
+			klog.Errorf("Failed to delete remote %v %q: %v", csr.name, remoteName, err)
+			return err
+		}
+		klog.Infof("Remote %v %q successfully deleted", csr.name, remoteName)
+		return nil
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteClusterScopedResource(local, csr.forgingOpts)
+	if _, err := csr.remoteObjectsClient.Apply(ctx, remoteName, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote %v %q (local: %q): %v", csr.name, remoteName, name, err)
+		return err
+	}
+
+	klog.Infof("Remote %v %q successfully enforced (local: %q)", csr.name, remoteName, name)
+	return nil
+}
+
+// Resync triggers a resync of the reflector.
+func (csr *ClusterScopedReflector) Resync() error {
+	for _, lister := range []cache.GenericLister{csr.localObjects, csr.remoteObjects} {
+		if lister == nil {
+			continue
+		}
+
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return err
+		}
+		for i := range objs {
+			obj, ok := objs[i].(metav1.Object)
+			if !ok {
+				continue
+			}
+			csr.enqueue(obj.GetName(), lister == csr.remoteObjects)
+		}
+	}
+
+	klog.Infof("Resynced %v cluster-scoped reflector", csr.name)
+	return nil
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the workqueue.
+func (csr *ClusterScopedReflector) runWorker() {
+	for {
+		if !csr.processNextWorkItem() {
+			return
+		}
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and attempt to process it, by calling the handler.
+func (csr *ClusterScopedReflector) processNextWorkItem() bool {
+	key, shutdown := csr.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer csr.workqueue.Done(key)
+
+	if !leaderelection.IsLeader() {
+		klog.V(4).Infof("Skipping %v reflector item %v because the node is not the leader", csr.name, key)
+		return true
+	}
+
+	// The reflector may not be completely initialized in case the informer factories have not yet synced.
+	if !csr.ready() {
+		klog.V(4).Infof("%v reflection not yet completely initialized (item: %q)", csr.name, key)
+		csr.workqueue.AddRateLimited(key)
+		return true
+	}
+
+	if err := csr.Handle(context.Background(), key.(string)); err != nil {
+		// Put the item back on the workqueue to handle any transient errors.
+		csr.workqueue.AddRateLimited(key)
+		return true
+	}
+
+	csr.workqueue.Forget(key)
+	return true
+}
+
+// handlers returns the event handlers enqueueing the name of the local objects, as retrieved by the given function.
+func (csr *ClusterScopedReflector) handlers(localName func(string) (string, bool)) cache.ResourceEventHandler {
+	eh := func(obj interface{}) {
+		// The object might be a tombstone in case of deletion events.
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+
+		metadata, err := meta.Accessor(obj)
+		utilruntime.Must(err)
+
+		if name, ok := localName(metadata.GetName()); ok {
+			csr.workqueue.Add(name)
+		}
+	}
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    eh,
+		UpdateFunc: func(_, obj interface{}) { eh(obj) },
+		DeleteFunc: eh,
+	}
+}
+
+// enqueue adds the given object to the workqueue, converting the remote names into the local ones.
+func (csr *ClusterScopedReflector) enqueue(name string, remote bool) {
+	if remote {
+		var ok bool
+		if name, ok = forge.LocalClusterScopedName(name); !ok {
+			return
+		}
+	}
+	csr.workqueue.Add(name)
+}
+
+// get retrieves the given object from the lister, converting it to the unstructured representation.
+func (csr *ClusterScopedReflector) get(lister cache.GenericLister, name string) (*unstructured.Unstructured, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, kerrors.NewInternalError(fmt.Errorf("unexpected type %T for %v %q", obj, csr.name, name))
+	}
+	return u, nil
+}
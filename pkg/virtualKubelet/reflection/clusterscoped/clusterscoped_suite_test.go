@@ -0,0 +1,69 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterscoped_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+const (
+	LocalClusterID  = "local-cluster-id"
+	RemoteClusterID = "remote-cluster-id"
+
+	LiqoNodeName = "local-node"
+	LiqoNodeIP   = "1.1.1.1"
+)
+
+var (
+	testEnv envtest.Environment
+	client  dynamic.Interface
+
+	ctx    context.Context
+	cancel context.CancelFunc
+)
+
+func TestClusterScoped(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cluster-scoped Resources Reflection Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testutil.LogsToGinkgoWriter()
+
+	testEnv = envtest.Environment{}
+	cfg, err := testEnv.Start()
+	Expect(err).ToNot(HaveOccurred())
+
+	// Need to use a real client, as server side apply seems not to be currently supported by the fake one.
+	client = dynamic.NewForConfigOrDie(cfg)
+
+	forge.Init(LocalClusterID, RemoteClusterID, LiqoNodeName, LiqoNodeIP)
+})
+
+var _ = BeforeEach(func() { ctx, cancel = context.WithCancel(context.Background()) })
+var _ = AfterEach(func() { cancel() })
+
+var _ = AfterSuite(func() {
+	Expect(testEnv.Stop()).To(Succeed())
+})
@@ -0,0 +1,153 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterscoped_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/clusterscoped"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+)
+
+var _ = Describe("Cluster-scoped resources reflection", func() {
+	priorityClasses := resources.ClusterScopedResources[offloadingv1beta1.PriorityClassKind]
+
+	Describe("NewClusterScopedReflector", func() {
+		It("should create a non-nil reflector", func() {
+			reflector := clusterscoped.NewClusterScopedReflector(offloadingv1beta1.PriorityClassKind)
+			Expect(reflector).NotTo(BeNil())
+			Expect(reflector.String()).To(Equal("priorityclass"))
+		})
+	})
+
+	Describe("Handle", func() {
+		const (
+			LocalName  = "high-priority"
+			RemoteName = LocalClusterID + "-" + LocalName
+		)
+
+		var (
+			reflector *clusterscoped.ClusterScopedReflector
+			name      string
+			err       error
+		)
+
+		NewPriorityClass := func(name string, value int64) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion":    "scheduling.k8s.io/v1",
+				"kind":          "PriorityClass",
+				"value":         value,
+				"globalDefault": false,
+			}}
+			obj.SetName(name)
+			return obj
+		}
+
+		Create := func(obj *unstructured.Unstructured) {
+			_, errcreate := client.Resource(priorityClasses).Create(ctx, obj, metav1.CreateOptions{})
+			Expect(errcreate).ToNot(HaveOccurred())
+		}
+
+		Get := func(name string) *unstructured.Unstructured {
+			obj, errget := client.Resource(priorityClasses).Get(ctx, name, metav1.GetOptions{})
+			Expect(errget).ToNot(HaveOccurred())
+			return obj
+		}
+
+		BeforeEach(func() { name = LocalName })
+
+		AfterEach(func() {
+			for _, name := range []string{LocalName, RemoteName} {
+				Expect(client.Resource(priorityClasses).Delete(ctx, name, metav1.DeleteOptions{})).To(
+					Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+			}
+		})
+
+		JustBeforeEach(func() {
+			factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 10*time.Hour)
+			reflector = clusterscoped.NewClusterScopedReflector(offloadingv1beta1.PriorityClassKind)
+			reflector.Init(options.NewClusterScoped().
+				WithDynamicLocal(client, factory).WithDynamicRemote(client, factory).
+				WithReadinessFunc(func() bool { return true }).
+				WithForgingOpts(FakeForgingOpts()))
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(ctx, name)
+		})
+
+		When("the local object does exist", func() {
+			BeforeEach(func() { Create(NewPriorityClass(LocalName, 1000)) })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should be created with the prefixed name", func() {
+				remote := Get(RemoteName)
+				Expect(remote.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+				Expect(remote.GetLabels()).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, RemoteClusterID))
+				Expect(remote.Object).To(HaveKeyWithValue("value", BeEquivalentTo(1000)))
+			})
+		})
+
+		When("the local object is a built-in priority class", func() {
+			BeforeEach(func() { name = "system-cluster-critical" })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should not be created", func() {
+				_, err = client.Resource(priorityClasses).Get(ctx, LocalClusterID+"-system-cluster-critical", metav1.GetOptions{})
+				Expect(err).To(BeNotFound())
+			})
+		})
+
+		When("the local object does not exist, and the remote one is managed by us", func() {
+			BeforeEach(func() {
+				remote := NewPriorityClass(RemoteName, 1000)
+				remote.SetLabels(forge.ReflectionLabels())
+				Create(remote)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should be deleted", func() {
+				_, err = client.Resource(priorityClasses).Get(ctx, RemoteName, metav1.GetOptions{})
+				Expect(err).To(BeNotFound())
+			})
+		})
+
+		When("the remote object already exists, and it is not managed by us", func() {
+			BeforeEach(func() {
+				Create(NewPriorityClass(LocalName, 1000))
+				Create(NewPriorityClass(RemoteName, 10))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should not be mutated", func() {
+				remote := Get(RemoteName)
+				Expect(remote.GetLabels()).ToNot(HaveKey(forge.LiqoOriginClusterIDKey))
+				Expect(remote.Object).To(HaveKeyWithValue("value", BeEquivalentTo(10)))
+			})
+		})
+	})
+})
@@ -36,6 +36,8 @@ import (
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
 
+var _ manager.DryRunner = (*NamespacedConfigMapReflector)(nil)
+
 const (
 	// ConfigMapReflectorName is the name associated with the ConfigMap reflector.
 	ConfigMapReflectorName = "ConfigMap"
@@ -183,6 +185,25 @@ func (ncr *NamespacedConfigMapReflector) ShouldSkipReflection(obj metav1.Object)
 	return ncr.NamespacedReflector.ShouldSkipReflection(obj)
 }
 
+// DryRun forges the remote ConfigMap corresponding to the given local one, without enforcing it.
+func (ncr *NamespacedConfigMapReflector) DryRun(ctx context.Context, name string) (forged, current interface{}, err error) {
+	local, err := ncr.localConfigMaps.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mutation := forge.RemoteConfigMap(local, ncr.RemoteNamespace(), ncr.ForgingOpts)
+	if forged, err = ncr.remoteConfigMapsClient.Apply(ctx, mutation, forge.DryRunApplyOptions()); err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := ncr.remoteConfigMaps.Get(forge.RemoteConfigMapName(name))
+	if err != nil {
+		return forged, nil, client.IgnoreNotFound(err)
+	}
+	return forged, remote, nil
+}
+
 // List returns the list of objects.
 func (ncr *NamespacedConfigMapReflector) List() ([]interface{}, error) {
 	return virtualkubelet.List[virtualkubelet.Lister[*corev1.ConfigMap], *corev1.ConfigMap](
@@ -0,0 +1,220 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customresource
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedCustomResourceReflector)(nil)
+
+// NamespacedCustomResourceReflector manages the reflection of a custom resource for a given pair of local and remote namespaces.
+type NamespacedCustomResourceReflector struct {
+	generic.NamespacedReflector
+
+	name          string
+	gvr           schema.GroupVersionResource
+	reflectStatus bool
+
+	localObjects        cache.GenericNamespaceLister
+	remoteObjects       cache.GenericNamespaceLister
+	localObjectsClient  dynamic.ResourceInterface
+	remoteObjectsClient dynamic.ResourceInterface
+}
+
+// NewCustomResourceReflector builds a reflector for the given custom resource.
+func NewCustomResourceReflector(gvr schema.GroupVersionResource, reflectorConfig *offloadingv1beta1.ReflectorConfig) manager.Reflector {
+	name := resources.CustomResourceKey(gvr)
+	return generic.NewReflector(name, NewNamespacedCustomResourceReflector(gvr, reflectorConfig.ReflectStatus),
+		generic.WithoutFallback(), reflectorConfig.NumWorkers, reflectorConfig.Type, generic.ConcurrencyModeLeader)
+}
+
+// NewNamespacedCustomResourceReflector returns a function generating NamespacedCustomResourceReflector instances.
+func NewNamespacedCustomResourceReflector(gvr schema.GroupVersionResource, reflectStatus bool) generic.NamespacedReflectorFactoryFunc {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		name := resources.CustomResourceKey(gvr)
+		local := opts.LocalDynamicFactory.ForResource(gvr)
+		remote := opts.RemoteDynamicFactory.ForResource(gvr)
+
+		// Using opts.LocalNamespace for both event handlers so that the object will be put in the same workqueue
+		// no matter the cluster, hence it will be processed by the handle function in the same way.
+		local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+
+		return &NamespacedCustomResourceReflector{
+			NamespacedReflector: generic.NewNamespacedReflector(opts, name),
+			name:                name,
+			gvr:                 gvr,
+			reflectStatus:       reflectStatus,
+			localObjects:        local.Lister().ByNamespace(opts.LocalNamespace),
+			remoteObjects:       remote.Lister().ByNamespace(opts.RemoteNamespace),
+			localObjectsClient:  opts.LocalDynamicClient.Resource(gvr).Namespace(opts.LocalNamespace),
+			remoteObjectsClient: opts.RemoteDynamicClient.Resource(gvr).Namespace(opts.RemoteNamespace),
+		}
+	}
+}
+
+// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
+func (ncr *NamespacedCustomResourceReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local %v %q (remote: %q)", ncr.name, ncr.LocalRef(name), ncr.RemoteRef(name))
+
+	local, lerr := ncr.get(ncr.localObjects, name)
+	if lerr != nil && !kerrors.IsNotFound(lerr) {
+		return lerr
+	}
+	remote, rerr := ncr.get(ncr.remoteObjects, name)
+	if rerr != nil && !kerrors.IsNotFound(rerr) {
+		return rerr
+	}
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local %v %q as remote already exists and is not managed by us", ncr.name, ncr.LocalRef(name))
+			ncr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	if !kerrors.IsNotFound(lerr) {
+		skipReflection, err := ncr.ShouldSkipReflection(local)
+		if err != nil {
+			klog.Errorf("Failed to check whether local %v %q should be reflected: %v", ncr.name, ncr.LocalRef(name), err)
+			return err
+		}
+		if skipReflection {
+			if ncr.GetReflectionType() == offloadingv1beta1.DenyList {
+				klog.Infof("Skipping reflection of local %v %q as marked with the skip annotation", ncr.name, ncr.LocalRef(name))
+			} else { // AllowList
+				klog.Infof("Skipping reflection of local %v %q as not marked with the allow annotation", ncr.name, ncr.LocalRef(name))
+			}
+			ncr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(ncr.GetReflectionType()))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(ncr.gvr.GroupResource(), local.GetName())
+		}
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	if kerrors.IsNotFound(lerr) {
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote %v %q, since local %q does no longer exist", ncr.name, ncr.RemoteRef(name), ncr.LocalRef(name))
+			return ncr.DeleteRemote(ctx, deleter{ncr.remoteObjectsClient}, ncr.name, remote.GetName(), remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local %v %q and remote %v %q both vanished", ncr.name, ncr.LocalRef(name), ncr.name, ncr.RemoteRef(name))
+		return nil
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteCustomResource(local, ncr.RemoteNamespace(), ncr.ForgingOpts)
+	tracer.Step("Remote mutation created")
+
+	if _, err := ncr.remoteObjectsClient.Apply(ctx, name, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote %v %q (local: %q): %v", ncr.name, ncr.RemoteRef(name), ncr.LocalRef(name), err)
+		ncr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the remote object")
+	klog.Infof("Remote %v %q successfully enforced (local: %q)", ncr.name, ncr.RemoteRef(name), ncr.LocalRef(name))
+
+	if ncr.reflectStatus && rerr == nil {
+		defer tracer.Step("Reflected the status of the remote object")
+		if updated, changed := forge.LocalCustomResourceStatus(local, remote); changed {
+			if _, err := ncr.localObjectsClient.UpdateStatus(ctx, updated, metav1.UpdateOptions{FieldManager: forge.ReflectionFieldManager}); err != nil {
+				klog.Errorf("Failed to update the status of local %v %q (remote: %q): %v", ncr.name, ncr.LocalRef(name), ncr.RemoteRef(name), err)
+				ncr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+				return err
+			}
+			klog.Infof("Status of local %v %q successfully updated (remote: %q)", ncr.name, ncr.LocalRef(name), ncr.RemoteRef(name))
+		}
+	}
+
+	ncr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	return nil
+}
+
+// List returns the list of objects.
+func (ncr *NamespacedCustomResourceReflector) List() ([]interface{}, error) {
+	var keys []interface{}
+	for _, lister := range []cache.GenericNamespaceLister{ncr.localObjects, ncr.remoteObjects} {
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for i := range objs {
+			obj, ok := objs[i].(metav1.Object)
+			if !ok {
+				continue
+			}
+			keys = append(keys, types.NamespacedName{Namespace: ncr.LocalNamespace(), Name: obj.GetName()})
+		}
+	}
+	return keys, nil
+}
+
+// get retrieves the given object from the lister, converting it to the unstructured representation.
+func (ncr *NamespacedCustomResourceReflector) get(lister cache.GenericNamespaceLister, name string) (*unstructured.Unstructured, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, kerrors.NewInternalError(fmt.Errorf("unexpected type %T for %v %q", obj, ncr.name, name))
+	}
+	return u, nil
+}
+
+// deleter adapts a dynamic.ResourceInterface to the generic.ResourceDeleter interface.
+type deleter struct {
+	dynamic.ResourceInterface
+}
+
+// Delete deletes the object with the given name.
+func (d deleter) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return d.ResourceInterface.Delete(ctx, name, opts)
+}
@@ -0,0 +1,254 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customresource_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/trace"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/customresource"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("Custom resource reflection", func() {
+	Describe("NewCustomResourceReflector", func() {
+		It("should create a non-nil reflector", func() {
+			reflectorConfig := offloadingv1beta1.ReflectorConfig{NumWorkers: 1, Type: offloadingv1beta1.DenyList}
+			reflector := customresource.NewCustomResourceReflector(appConfig, &reflectorConfig)
+			Expect(reflector).NotTo(BeNil())
+			Expect(reflector.String()).To(Equal("appconfigs.v1.example.com"))
+		})
+	})
+
+	Describe("Handle", func() {
+		const AppConfigName = "name"
+
+		var (
+			reflector      manager.NamespacedReflector
+			reflectionType offloadingv1beta1.ReflectionType
+			reflectStatus  bool
+
+			local, remote *unstructured.Unstructured
+			err           error
+		)
+
+		NewAppConfig := func(namespace string) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "AppConfig",
+				"spec":       map[string]interface{}{"replicas": int64(1)},
+			}}
+			obj.SetName(AppConfigName)
+			obj.SetNamespace(namespace)
+			return obj
+		}
+
+		Get := func(namespace string) *unstructured.Unstructured {
+			obj, errget := client.Resource(appConfig).Namespace(namespace).Get(ctx, AppConfigName, metav1.GetOptions{})
+			Expect(errget).ToNot(HaveOccurred())
+			return obj
+		}
+
+		Create := func(obj *unstructured.Unstructured) *unstructured.Unstructured {
+			created, errcreate := client.Resource(appConfig).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+			Expect(errcreate).ToNot(HaveOccurred())
+			return created
+		}
+
+		WhenBodyRemoteShouldNotExist := func(createRemote bool) func() {
+			return func() {
+				BeforeEach(func() {
+					if createRemote {
+						remote.SetLabels(forge.ReflectionLabels())
+						Create(remote)
+					}
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the remote object should not be present", func() {
+					_, err = client.Resource(appConfig).Namespace(RemoteNamespace).Get(ctx, AppConfigName, metav1.GetOptions{})
+					Expect(err).To(BeNotFound())
+				})
+			}
+		}
+
+		BeforeEach(func() {
+			local = NewAppConfig(LocalNamespace)
+			remote = NewAppConfig(RemoteNamespace)
+			reflectionType = offloadingv1beta1.DenyList
+			reflectStatus = false
+		})
+
+		AfterEach(func() {
+			for _, namespace := range []string{LocalNamespace, RemoteNamespace} {
+				Expect(client.Resource(appConfig).Namespace(namespace).Delete(ctx, AppConfigName, metav1.DeleteOptions{})).To(
+					Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+			}
+		})
+
+		JustBeforeEach(func() {
+			factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 10*time.Hour)
+			reflector = customresource.NewNamespacedCustomResourceReflector(appConfig, reflectStatus)(options.NewNamespaced().
+				WithLocal(LocalNamespace, nil, nil).
+				WithRemote(RemoteNamespace, nil, nil).
+				WithDynamicLocal(client, factory).
+				WithDynamicRemote(client, factory).
+				WithHandlerFactory(FakeEventHandler).
+				WithEventBroadcaster(record.NewBroadcaster()).
+				WithReflectionType(reflectionType).
+				WithForgingOpts(FakeForgingOpts()))
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("AppConfig")), AppConfigName)
+		})
+
+		When("the local object does not exist", func() {
+			When("the remote object does not exist", WhenBodyRemoteShouldNotExist(false))
+			When("the remote object does exist", WhenBodyRemoteShouldNotExist(true))
+		})
+
+		When("the local object does exist", func() {
+			BeforeEach(func() {
+				local.SetLabels(map[string]string{"foo": "bar", FakeNotReflectedLabelKey: "true"})
+				local.SetAnnotations(map[string]string{"bar": "baz", FakeNotReflectedAnnotKey: "true"})
+				local.SetFinalizers([]string{"example.com/finalizer"})
+				created := Create(local)
+				created.Object["status"] = map[string]interface{}{"phase": "Local"}
+				_, errstatus := client.Resource(appConfig).Namespace(LocalNamespace).UpdateStatus(ctx, created, metav1.UpdateOptions{})
+				Expect(errstatus).ToNot(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				obj, errget := client.Resource(appConfig).Namespace(LocalNamespace).Get(ctx, AppConfigName, metav1.GetOptions{})
+				if errget == nil {
+					obj.SetFinalizers(nil)
+					_, errupdate := client.Resource(appConfig).Namespace(LocalNamespace).Update(ctx, obj, metav1.UpdateOptions{})
+					Expect(errupdate).ToNot(HaveOccurred())
+				}
+			})
+
+			When("the remote object does not exist", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+				It("the metadata should have been correctly replicated to the remote object", func() {
+					remoteAfter := Get(RemoteNamespace)
+					Expect(remoteAfter.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+					Expect(remoteAfter.GetLabels()).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, RemoteClusterID))
+					Expect(remoteAfter.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+					Expect(remoteAfter.GetLabels()).ToNot(HaveKey(FakeNotReflectedLabelKey))
+					Expect(remoteAfter.GetAnnotations()).To(HaveKeyWithValue("bar", "baz"))
+					Expect(remoteAfter.GetAnnotations()).ToNot(HaveKey(FakeNotReflectedAnnotKey))
+					Expect(remoteAfter.GetFinalizers()).To(BeEmpty())
+				})
+
+				It("the spec, but not the status, should have been replicated to the remote object", func() {
+					remoteAfter := Get(RemoteNamespace)
+					Expect(remoteAfter.Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", BeEquivalentTo(1))))
+					Expect(remoteAfter.Object).ToNot(HaveKey("status"))
+				})
+			})
+
+			When("the remote object already exists, and the status reflection is enabled", func() {
+				BeforeEach(func() {
+					reflectStatus = true
+					remote.SetLabels(forge.ReflectionLabels())
+					created := Create(remote)
+					created.Object["status"] = map[string]interface{}{"phase": "Remote"}
+					_, errstatus := client.Resource(appConfig).Namespace(RemoteNamespace).UpdateStatus(ctx, created, metav1.UpdateOptions{})
+					Expect(errstatus).ToNot(HaveOccurred())
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the status should have been reflected back to the local object", func() {
+					localAfter := Get(LocalNamespace)
+					Expect(localAfter.Object).To(HaveKeyWithValue("status", HaveKeyWithValue("phase", "Remote")))
+				})
+			})
+
+			When("the remote object already exists, and the status reflection is disabled", func() {
+				BeforeEach(func() {
+					remote.SetLabels(forge.ReflectionLabels())
+					created := Create(remote)
+					created.Object["status"] = map[string]interface{}{"phase": "Remote"}
+					_, errstatus := client.Resource(appConfig).Namespace(RemoteNamespace).UpdateStatus(ctx, created, metav1.UpdateOptions{})
+					Expect(errstatus).ToNot(HaveOccurred())
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the status of the local object should be unmodified", func() {
+					localAfter := Get(LocalNamespace)
+					Expect(localAfter.Object).To(HaveKeyWithValue("status", HaveKeyWithValue("phase", "Local")))
+				})
+			})
+
+			When("the remote object already exists, but is not managed by the reflection", func() {
+				var remoteBefore *unstructured.Unstructured
+
+				BeforeEach(func() { remoteBefore = Create(remote) })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the remote object should be unmodified", func() {
+					Expect(Get(RemoteNamespace)).To(Equal(remoteBefore))
+				})
+			})
+		})
+
+		When("the local object does exist, but has the skip annotation", func() {
+			BeforeEach(func() {
+				local.SetAnnotations(map[string]string{consts.SkipReflectionAnnotationKey: "whatever"})
+				Create(local)
+			})
+
+			When("the remote object does not exist", WhenBodyRemoteShouldNotExist(false))
+			When("the remote object does exist", WhenBodyRemoteShouldNotExist(true))
+		})
+
+		When("the reflection type is AllowList", func() {
+			BeforeEach(func() { reflectionType = offloadingv1beta1.AllowList })
+
+			When("the local object does exist, but does not have the allow annotation", func() {
+				BeforeEach(func() { Create(local) })
+
+				When("the remote object does not exist", WhenBodyRemoteShouldNotExist(false))
+				When("the remote object does exist", WhenBodyRemoteShouldNotExist(true))
+			})
+
+			When("the local object does exist, and does have the allow annotation", func() {
+				BeforeEach(func() {
+					local.SetAnnotations(map[string]string{consts.AllowReflectionAnnotationKey: "whatever"})
+					Create(local)
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("the remote object should be present", func() { Expect(Get(RemoteNamespace)).ToNot(BeNil()) })
+			})
+		})
+	})
+})
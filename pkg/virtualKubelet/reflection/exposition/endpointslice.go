@@ -135,6 +135,12 @@ func (ner *NamespacedEndpointSliceReflector) Handle(ctx context.Context, name st
 
 	tracer.Step("Retrieved the local and remote objects")
 
+	// Skip the endpointslices of the services exported by the remote cluster, as they are managed by the ExportedService reflector.
+	if localExists && forge.IsReverseReflected(local) {
+		klog.V(4).Infof("Skipping reflection of local EndpointSlice %q as exported by the remote cluster", ner.LocalRef(name))
+		return nil
+	}
+
 	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
 	if remoteExists && (!forge.IsReflected(remote) || !forge.IsEndpointSliceManagedByReflection(remote)) {
 		// Prevent misleading warnings triggered by remote non-reflected endpointslices, since they inherit
@@ -251,11 +257,16 @@ func (ner *NamespacedEndpointSliceReflector) Handle(ctx context.Context, name st
 func (ner *NamespacedEndpointSliceReflector) ShouldUpdateShadowEndpointSlice(ctx context.Context,
 	remote, target *offloadingv1beta1.ShadowEndpointSlice) bool {
 	defer trace.FromContext(ctx).Step("Checked whether a shadowendpointslice update was needed")
-	return !labels.Equals(remote.GetLabels(), target.GetLabels()) ||
-		!labels.Equals(remote.GetAnnotations(), target.GetAnnotations()) ||
-		!reflect.DeepEqual(remote.Spec.Template.AddressType, target.Spec.Template.AddressType) ||
-		!reflect.DeepEqual(remote.Spec.Template.Endpoints, target.Spec.Template.Endpoints) ||
-		!reflect.DeepEqual(remote.Spec.Template.Ports, target.Spec.Template.Ports)
+	return shadowEndpointSliceDiffers(remote, target)
+}
+
+// shadowEndpointSliceDiffers returns whether the current shadowendpointslice differs from the target one.
+func shadowEndpointSliceDiffers(current, target *offloadingv1beta1.ShadowEndpointSlice) bool {
+	return !labels.Equals(current.GetLabels(), target.GetLabels()) ||
+		!labels.Equals(current.GetAnnotations(), target.GetAnnotations()) ||
+		!reflect.DeepEqual(current.Spec.Template.AddressType, target.Spec.Template.AddressType) ||
+		!reflect.DeepEqual(current.Spec.Template.Endpoints, target.Spec.Template.Endpoints) ||
+		!reflect.DeepEqual(current.Spec.Template.Ports, target.Spec.Template.Ports)
 }
 
 // MapEndpointIPFromIPResource maps an IP string using an IP resource.
@@ -0,0 +1,319 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	offloadingv1beta1clients "github.com/liqotech/liqo/pkg/client/clientset/versioned/typed/offloading/v1beta1"
+	ipamv1alpha1listers "github.com/liqotech/liqo/pkg/client/listers/ipam/v1alpha1"
+	offloadingv1beta1listers "github.com/liqotech/liqo/pkg/client/listers/offloading/v1beta1"
+	ipamutils "github.com/liqotech/liqo/pkg/utils/ipam"
+	"github.com/liqotech/liqo/pkg/utils/virtualkubelet"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedExportedServiceReflector)(nil)
+
+const (
+	// ExportedServiceReflectorName -> The name associated with the ExportedService reflector.
+	ExportedServiceReflectorName = "ExportedService"
+)
+
+// errIPResourceNotFound is returned when no IP resource exists to remap a given endpoint address.
+var errIPResourceNotFound = errors.New("IP resource not found")
+
+// NamespacedExportedServiceReflector manages the reverse reflection of the services exported by the remote cluster
+// (i.e., marked with the export-to-consumer annotation) for a given pair of local and remote namespaces.
+// Differently from the other reflectors, the remote objects are the source of truth, and they are mirrored
+// locally as a selectorless service, whose endpoints are populated through shadowendpointslices.
+type NamespacedExportedServiceReflector struct {
+	generic.NamespacedReflector
+
+	localServices                   corev1listers.ServiceNamespaceLister
+	localServicesClient             corev1clients.ServiceInterface
+	localShadowEndpointSlices       offloadingv1beta1listers.ShadowEndpointSliceNamespaceLister
+	localShadowEndpointSlicesClient offloadingv1beta1clients.ShadowEndpointSliceInterface
+	remoteServices                  corev1listers.ServiceNamespaceLister
+	remoteEndpointSlices            discoveryv1listers.EndpointSliceNamespaceLister
+	remoteIPs                       ipamv1alpha1listers.IPNamespaceLister
+
+	remotePodCIDR *net.IPNet
+}
+
+// NewExportedServiceReflector returns a new ExportedServiceReflector instance.
+// The remote pod CIDR is used to identify the endpoint addresses requiring an IP resource to be remapped,
+// and it is expected to be empty if the networking module is disabled, hence no translation is needed.
+func NewExportedServiceReflector(remotePodCIDR string, reflectorConfig *offloadingv1beta1.ReflectorConfig) manager.Reflector {
+	return generic.NewReflector(ExportedServiceReflectorName, NewNamespacedExportedServiceReflector(remotePodCIDR),
+		generic.WithoutFallback(), reflectorConfig.NumWorkers, reflectorConfig.Type, generic.ConcurrencyModeLeader)
+}
+
+// NewNamespacedExportedServiceReflector returns a function generating NamespacedExportedServiceReflector instances.
+func NewNamespacedExportedServiceReflector(remotePodCIDR string) func(*options.NamespacedOpts) manager.NamespacedReflector {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		localServices := opts.LocalFactory.Core().V1().Services()
+		localShadow := opts.LocalLiqoFactory.Offloading().V1beta1().ShadowEndpointSlices()
+		remoteServices := opts.RemoteFactory.Core().V1().Services()
+		remoteEndpointSlices := opts.RemoteFactory.Discovery().V1().EndpointSlices()
+
+		_, err := localServices.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+		_, err = remoteServices.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+		_, err = localShadow.Informer().AddEventHandler(opts.HandlerFactory(ServiceNameKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+		_, err = remoteEndpointSlices.Informer().AddEventHandler(opts.HandlerFactory(ServiceNameKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+
+		nesr := &NamespacedExportedServiceReflector{
+			NamespacedReflector:             generic.NewNamespacedReflector(opts, ExportedServiceReflectorName),
+			localServices:                   localServices.Lister().Services(opts.LocalNamespace),
+			localServicesClient:             opts.LocalClient.CoreV1().Services(opts.LocalNamespace),
+			localShadowEndpointSlices:       localShadow.Lister().ShadowEndpointSlices(opts.LocalNamespace),
+			localShadowEndpointSlicesClient: opts.LocalLiqoClient.OffloadingV1beta1().ShadowEndpointSlices(opts.LocalNamespace),
+			remoteServices:                  remoteServices.Lister().Services(opts.RemoteNamespace),
+			remoteEndpointSlices:            remoteEndpointSlices.Lister().EndpointSlices(opts.RemoteNamespace),
+		}
+
+		// The IP resources are available only if the networking module is enabled.
+		if remotePodCIDR != "" {
+			_, podCIDR, err := net.ParseCIDR(remotePodCIDR)
+			utilruntime.Must(err)
+			nesr.remotePodCIDR = podCIDR
+			nesr.remoteIPs = opts.RemoteLiqoFactory.Ipam().V1alpha1().IPs().Lister().IPs(opts.RemoteNamespace)
+		}
+
+		return nesr
+	}
+}
+
+// Handle reconciles the services exported by the remote cluster, along with the corresponding endpointslices.
+func (nesr *NamespacedExportedServiceReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling export of remote Service %q (local: %q)", nesr.RemoteRef(name), nesr.LocalRef(name))
+	remote, rerr := nesr.remoteServices.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	local, lerr := nesr.localServices.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Services reflected from the local cluster are never exported back, to prevent loops.
+	exported := rerr == nil && forge.IsExportedToConsumer(remote) && !forge.IsReflected(remote)
+
+	// Abort the reflection if the local object is not managed by us, as we do not want to mutate others' objects.
+	if lerr == nil && !forge.IsReverseReflected(local) {
+		if exported { // Do not output the warning event in case the remote service is not meant to be exported.
+			klog.Infof("Skipping export of remote Service %q as local already exists and is not managed by us", nesr.RemoteRef(name))
+			nesr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	// The remote service does no longer exist, or it is no longer exported. Ensure the local one is absent.
+	// The local shadowendpointslices are owned by the local service, hence they are garbage collected.
+	if !exported {
+		defer tracer.Step("Ensured the absence of the local object")
+		if lerr == nil {
+			klog.V(4).Infof("Deleting local Service %q, since remote %q is no longer exported", nesr.LocalRef(name), nesr.RemoteRef(name))
+			return nesr.DeleteLocal(ctx, nesr.localServicesClient, ExportedServiceReflectorName, name, local.GetUID())
+		}
+
+		klog.V(4).Infof("Remote Service %q is not exported and local %q does not exist", nesr.RemoteRef(name), nesr.LocalRef(name))
+		return nil
+	}
+
+	mutation := forge.LocalExportedService(remote, nesr.LocalNamespace(), nesr.ForgingOpts)
+	local, err := nesr.localServicesClient.Apply(ctx, mutation, forge.ApplyOptions())
+	if err != nil {
+		klog.Errorf("Failed to enforce local Service %q (remote: %q): %v", nesr.LocalRef(name), nesr.RemoteRef(name), err)
+		return err
+	}
+	tracer.Step("Enforced the correctness of the local service")
+
+	if err := nesr.HandleEndpointSlices(ctx, local); err != nil {
+		klog.Errorf("Failed to enforce the endpointslices of local Service %q (remote: %q): %v", nesr.LocalRef(name), nesr.RemoteRef(name), err)
+		nesr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the local shadowendpointslices")
+
+	klog.Infof("Local Service %q successfully enforced (remote: %q)", nesr.LocalRef(name), nesr.RemoteRef(name))
+	nesr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	return nil
+}
+
+// HandleEndpointSlices ensures the local shadowendpointslices associated with the given service
+// match the endpointslices of the corresponding remote service.
+func (nesr *NamespacedExportedServiceReflector) HandleEndpointSlices(ctx context.Context, service *corev1.Service) error {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.GetName()})
+	remotes, err := nesr.remoteEndpointSlices.List(selector)
+	utilruntime.Must(err)
+	locals, err := nesr.localShadowEndpointSlices.List(selector)
+	utilruntime.Must(err)
+
+	existing := make(map[string]*offloadingv1beta1.ShadowEndpointSlice, len(locals))
+	for _, shadow := range locals {
+		if forge.IsReverseReflected(shadow) {
+			existing[shadow.GetName()] = shadow
+		}
+	}
+
+	var skipped []string
+	for _, remote := range remotes {
+		// Wrap the address translation logic, so that we do not have to handle errors in the forge logic.
+		var terr error
+		translator := func(originals []string) []string {
+			// Avoid processing further addresses if one already failed.
+			if terr != nil {
+				return nil
+			}
+
+			translations, unreachable, err := nesr.MapEndpointIPs(originals)
+			skipped, terr = append(skipped, unreachable...), err
+			return translations
+		}
+
+		current := existing[remote.GetName()]
+		delete(existing, remote.GetName())
+
+		target := forge.LocalExportedShadowEndpointSlice(remote, current, service, translator, nesr.ForgingOpts)
+		if terr != nil {
+			return fmt.Errorf("failed to translate the endpoints of remote EndpointSlice %q: %w", nesr.RemoteRef(remote.GetName()), terr)
+		}
+
+		if current == nil {
+			_, err := nesr.localShadowEndpointSlicesClient.Create(ctx, target, metav1.CreateOptions{FieldManager: forge.ReflectionFieldManager})
+			if err != nil && !kerrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create local shadowendpointslice %q: %w", nesr.LocalRef(target.GetName()), err)
+			}
+			klog.V(4).Infof("Local shadowendpointslice %q successfully created", nesr.LocalRef(target.GetName()))
+			continue
+		}
+
+		if shadowEndpointSliceDiffers(current, target) {
+			_, err := nesr.localShadowEndpointSlicesClient.Update(ctx, target, metav1.UpdateOptions{FieldManager: forge.ReflectionFieldManager})
+			if err != nil {
+				return fmt.Errorf("failed to update local shadowendpointslice %q: %w", nesr.LocalRef(target.GetName()), err)
+			}
+			klog.V(4).Infof("Local shadowendpointslice %q successfully updated", nesr.LocalRef(target.GetName()))
+		}
+	}
+
+	// Delete the local shadowendpointslices whose remote counterpart does no longer exist.
+	for name, shadow := range existing {
+		if err := nesr.DeleteLocal(ctx, nesr.localShadowEndpointSlicesClient, "ShadowEndpointSlice", name, shadow.GetUID()); err != nil {
+			return err
+		}
+	}
+
+	if len(skipped) > 0 {
+		klog.Warningf("Skipped endpoints %v of remote Service %q, as no IP resource exists for them", skipped, nesr.RemoteRef(service.GetName()))
+		nesr.Event(service, corev1.EventTypeWarning, forge.EventSkippedEndpoints, forge.EventSkippedEndpointsMsg(skipped))
+	}
+
+	return nil
+}
+
+// MapEndpointIPs maps the remote set of addresses to the corresponding local ones. Addresses belonging to the
+// remote pod CIDR are left untouched, as they are remapped by the shadowendpointslice controller according to
+// the network configuration, while the other ones are translated through the corresponding remote IP resource.
+// The addresses for which no IP resource exists are skipped, and returned separately, as not reachable from the local cluster.
+func (nesr *NamespacedExportedServiceReflector) MapEndpointIPs(originals []string) (translations, skipped []string, err error) {
+	if nesr.remotePodCIDR == nil {
+		return originals, nil, nil
+	}
+
+	translations = make([]string, 0, len(originals))
+	for _, original := range originals {
+		translation := original
+		if !nesr.remotePodCIDR.Contains(net.ParseIP(original)) {
+			if translation, err = nesr.MapEndpointIPFromIPResource(original); err != nil {
+				if errors.Is(err, errIPResourceNotFound) {
+					skipped = append(skipped, original)
+					continue
+				}
+				return nil, nil, fmt.Errorf("failed to translate endpoint IP %v: %w", original, err)
+			}
+		}
+
+		translations = append(translations, translation)
+		klog.V(6).Infof("Translated remote endpoint IP %v to local %v", original, translation)
+	}
+
+	return translations, skipped, nil
+}
+
+// MapEndpointIPFromIPResource maps an IP string using a remote IP resource.
+// An error wrapping errIPResourceNotFound is returned if no IP resource exists for the given address.
+func (nesr *NamespacedExportedServiceReflector) MapEndpointIPFromIPResource(original string) (string, error) {
+	ips, err := nesr.remoteIPs.List(labels.Everything())
+	if err != nil {
+		return "", fmt.Errorf("failed to list IPs: %w", err)
+	}
+	for i := range ips {
+		if ips[i].Spec.IP.String() == original {
+			if len(ips[i].Status.IPMappings) > 0 {
+				return ipamutils.GetRemappedIP(ips[i]).String(), nil
+			}
+			return "", fmt.Errorf("resource IP %s has not been mapped yet", ips[i].Name)
+		}
+	}
+	return "", fmt.Errorf("%w for %s", errIPResourceNotFound, original)
+}
+
+// List returns the list of services to be reflected.
+func (nesr *NamespacedExportedServiceReflector) List() ([]interface{}, error) {
+	return virtualkubelet.List[virtualkubelet.Lister[*corev1.Service], *corev1.Service](
+		nesr.remoteServices,
+		nesr.localServices,
+	)
+}
+
+// ServiceNameKeyer returns a keyer mapping the given endpointslice-like object to the name of the associated service.
+func ServiceNameKeyer(namespace string) func(metadata metav1.Object) []types.NamespacedName {
+	return func(metadata metav1.Object) []types.NamespacedName {
+		name, ok := metadata.GetLabels()[discoveryv1.LabelServiceName]
+		if !ok {
+			return nil
+		}
+		return []types.NamespacedName{{Namespace: namespace, Name: name}}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"k8s.io/utils/trace"
+
+	ipamv1alpha1 "github.com/liqotech/liqo/apis/ipam/v1alpha1"
+	networkingv1beta1 "github.com/liqotech/liqo/apis/networking/v1beta1"
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/cmd/virtual-kubelet/root"
+	liqoclient "github.com/liqotech/liqo/pkg/client/clientset/versioned"
+	liqoclientfake "github.com/liqotech/liqo/pkg/client/clientset/versioned/fake"
+	liqoinformers "github.com/liqotech/liqo/pkg/client/informers/externalversions"
+	"github.com/liqotech/liqo/pkg/consts"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+)
+
+const (
+	remotePodCIDR string = "10.200.0.0/16"
+)
+
+var _ = Describe("ExportedService Reflection Tests", func() {
+	Describe("the NewExportedServiceReflector function", func() {
+		It("should not return a nil reflector", func() {
+			reflectorConfig := offloadingv1beta1.ReflectorConfig{
+				NumWorkers: 1,
+				Type:       root.DefaultReflectorsTypes[resources.ExportedService],
+			}
+			Expect(exposition.NewExportedServiceReflector(remotePodCIDR, &reflectorConfig)).ToNot(BeNil())
+		})
+	})
+
+	Describe("exported service handling", func() {
+		const ServiceName = "exported"
+		const EndpointSliceName = "exported-abcde"
+
+		var (
+			err        error
+			reflector  manager.NamespacedReflector
+			liqoClient liqoclient.Interface
+
+			remote         corev1.Service
+			remoteEndpoint discoveryv1.EndpointSlice
+		)
+
+		GetLocalService := func() (*corev1.Service, error) {
+			return client.CoreV1().Services(LocalNamespace).Get(ctx, ServiceName, metav1.GetOptions{})
+		}
+
+		BeforeEach(func() {
+			liqoClient = liqoclientfake.NewSimpleClientset()
+			remote = corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: ServiceName, Namespace: RemoteNamespace,
+					Annotations: map[string]string{consts.ExportToConsumerAnnotationKey: "true"}},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{"app": "db"},
+					Ports:    []corev1.ServicePort{{Name: "sql", Port: 5432, Protocol: corev1.ProtocolTCP}},
+				},
+			}
+			remoteEndpoint = discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: EndpointSliceName, Namespace: RemoteNamespace,
+					Labels: map[string]string{discoveryv1.LabelServiceName: ServiceName}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{
+					{Addresses: []string{"10.200.0.10"}},
+					{Addresses: []string{"172.16.0.10"}},
+				},
+				Ports: []discoveryv1.EndpointPort{{Name: ptr.To("sql"), Port: ptr.To[int32](5432)}},
+			}
+
+			ip := &ipamv1alpha1.IP{ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: RemoteNamespace},
+				Spec: ipamv1alpha1.IPSpec{IP: "172.16.0.10"}}
+			ip.Status.IPMappings = map[string]networkingv1beta1.IP{LocalClusterID: "10.70.0.10"}
+			_, err = liqoClient.IpamV1alpha1().IPs(RemoteNamespace).Create(ctx, ip, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			for _, namespace := range []string{LocalNamespace, RemoteNamespace} {
+				Expect(client.CoreV1().Services(namespace).Delete(ctx, ServiceName, metav1.DeleteOptions{})).To(
+					Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+			}
+			Expect(client.DiscoveryV1().EndpointSlices(RemoteNamespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{})).To(
+				Or(BeNil(), WithTransform(kerrors.IsNotFound, BeTrue())))
+		})
+
+		JustBeforeEach(func() {
+			factory := informers.NewSharedInformerFactory(client, 10*time.Hour)
+			liqoFactory := liqoinformers.NewSharedInformerFactory(liqoClient, 10*time.Hour)
+			reflector = exposition.NewNamespacedExportedServiceReflector(remotePodCIDR)(options.NewNamespaced().
+				WithLocal(LocalNamespace, client, factory).
+				WithLiqoLocal(liqoClient, liqoFactory).
+				WithRemote(RemoteNamespace, client, factory).
+				WithLiqoRemote(liqoClient, liqoFactory).
+				WithHandlerFactory(FakeEventHandler).
+				WithEventBroadcaster(record.NewBroadcaster()).
+				WithReflectionType(root.DefaultReflectorsTypes[resources.ExportedService]).
+				WithForgingOpts(FakeForgingOpts()))
+
+			factory.Start(ctx.Done())
+			liqoFactory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+			liqoFactory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("ExportedService")), ServiceName)
+		})
+
+		When("the remote service is exported", func() {
+			BeforeEach(func() {
+				_, err = client.CoreV1().Services(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = client.DiscoveryV1().EndpointSlices(RemoteNamespace).Create(ctx, &remoteEndpoint, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should create the local selectorless service", func() {
+				local, err := GetLocalService()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(forge.IsReverseReflected(local)).To(BeTrue())
+				Expect(local.Spec.Selector).To(BeEmpty())
+				Expect(local.Spec.Ports).To(HaveLen(1))
+				Expect(local.Spec.Ports[0].Port).To(BeNumerically("==", 5432))
+			})
+			It("should create the local shadowendpointslice with translated addresses", func() {
+				shadow, err := liqoClient.OffloadingV1beta1().ShadowEndpointSlices(LocalNamespace).Get(ctx, EndpointSliceName, metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(forge.IsReverseReflected(shadow)).To(BeTrue())
+				Expect(shadow.Labels).To(HaveKeyWithValue(discoveryv1.LabelServiceName, ServiceName))
+				Expect(shadow.Spec.Template.Endpoints).To(HaveLen(2))
+				Expect(shadow.Spec.Template.Endpoints[0].Addresses).To(ConsistOf("10.200.0.10"))
+				Expect(shadow.Spec.Template.Endpoints[1].Addresses).To(ConsistOf("10.70.0.10"))
+			})
+		})
+
+		When("an endpoint address is external to the remote pod CIDR and no IP resource exists for it", func() {
+			BeforeEach(func() {
+				remoteEndpoint.Endpoints = append(remoteEndpoint.Endpoints, discoveryv1.Endpoint{Addresses: []string{"172.16.0.20"}})
+				_, err = client.CoreV1().Services(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = client.DiscoveryV1().EndpointSlices(RemoteNamespace).Create(ctx, &remoteEndpoint, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should skip the corresponding endpoint", func() {
+				shadow, err := liqoClient.OffloadingV1beta1().ShadowEndpointSlices(LocalNamespace).Get(ctx, EndpointSliceName, metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(shadow.Spec.Template.Endpoints).To(HaveLen(2))
+				Expect(shadow.Spec.Template.Endpoints[0].Addresses).To(ConsistOf("10.200.0.10"))
+				Expect(shadow.Spec.Template.Endpoints[1].Addresses).To(ConsistOf("10.70.0.10"))
+			})
+		})
+
+		When("the remote service is not exported", func() {
+			BeforeEach(func() {
+				remote.SetAnnotations(nil)
+				_, err = client.CoreV1().Services(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not create the local service", func() {
+				_, err := GetLocalService()
+				Expect(err).To(BeNotFound())
+			})
+
+			When("the local service was previously exported", func() {
+				BeforeEach(func() {
+					local := corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: ServiceName, Namespace: LocalNamespace,
+						Labels: forge.ReverseReflectionLabels()},
+						Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 5432}}}}
+					_, err = client.CoreV1().Services(LocalNamespace).Create(ctx, &local, metav1.CreateOptions{})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should delete the local service", func() {
+					_, err := GetLocalService()
+					Expect(err).To(BeNotFound())
+				})
+			})
+		})
+
+		When("the local service already exists and is not managed by us", func() {
+			BeforeEach(func() {
+				_, err = client.CoreV1().Services(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				local := corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: ServiceName, Namespace: LocalNamespace},
+					Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "local"}, Ports: []corev1.ServicePort{{Port: 80}}}}
+				_, err = client.CoreV1().Services(LocalNamespace).Create(ctx, &local, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not mutate the local service", func() {
+				local, err := GetLocalService()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(forge.IsReverseReflected(local)).To(BeFalse())
+				Expect(local.Spec.Selector).To(HaveKeyWithValue("app", "local"))
+			})
+		})
+	})
+})
@@ -0,0 +1,242 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedGatewayRouteReflector)(nil)
+
+// GatewayRoute describes a Gateway API route type supported by the reflection.
+type GatewayRoute struct {
+	// Resource is the identifier of the route type in the reflectors configuration.
+	Resource resources.ResourceReflected
+	// Name is the name associated with the reflector of the route type.
+	Name string
+	// GVR is the group version resource of the route type.
+	GVR schema.GroupVersionResource
+}
+
+// GatewayRoutes is the list of Gateway API route types supported by the reflection.
+var GatewayRoutes = []GatewayRoute{
+	{Resource: resources.HTTPRoute, Name: "HTTPRoute",
+		GVR: schema.GroupVersionResource{Group: forge.GatewayAPIGroup, Version: "v1", Resource: "httproutes"}},
+	{Resource: resources.GRPCRoute, Name: "GRPCRoute",
+		GVR: schema.GroupVersionResource{Group: forge.GatewayAPIGroup, Version: "v1", Resource: "grpcroutes"}},
+	{Resource: resources.TLSRoute, Name: "TLSRoute",
+		GVR: schema.GroupVersionResource{Group: forge.GatewayAPIGroup, Version: "v1alpha2", Resource: "tlsroutes"}},
+}
+
+// NamespacedGatewayRouteReflector manages the reflection of a Gateway API route type for a given pair of local and remote namespaces.
+type NamespacedGatewayRouteReflector struct {
+	generic.NamespacedReflector
+
+	route         GatewayRoute
+	remoteGateway *types.NamespacedName
+
+	localRoutes        cache.GenericNamespaceLister
+	remoteRoutes       cache.GenericNamespaceLister
+	localRoutesClient  dynamic.ResourceInterface
+	remoteRoutesClient dynamic.ResourceInterface
+}
+
+// NewGatewayRouteReflector builds a reflector for the given Gateway API route type.
+// The reflected routes are attached to the given remote gateway, if specified, or keep the original parent references otherwise.
+func NewGatewayRouteReflector(route GatewayRoute, reflectorConfig *offloadingv1beta1.ReflectorConfig,
+	remoteGateway *types.NamespacedName) manager.Reflector {
+	return generic.NewReflector(route.Name, NewNamespacedGatewayRouteReflector(route, remoteGateway),
+		generic.WithoutFallback(), reflectorConfig.NumWorkers, reflectorConfig.Type, generic.ConcurrencyModeLeader)
+}
+
+// NewNamespacedGatewayRouteReflector returns a function generating NamespacedGatewayRouteReflector instances.
+func NewNamespacedGatewayRouteReflector(route GatewayRoute, remoteGateway *types.NamespacedName) generic.NamespacedReflectorFactoryFunc {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		local := opts.LocalDynamicFactory.ForResource(route.GVR)
+		remote := opts.RemoteDynamicFactory.ForResource(route.GVR)
+
+		// Using opts.LocalNamespace for both event handlers so that the object will be put in the same workqueue
+		// no matter the cluster, hence it will be processed by the handle function in the same way.
+		_, err := local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+		_, err = remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+
+		return &NamespacedGatewayRouteReflector{
+			NamespacedReflector: generic.NewNamespacedReflector(opts, route.Name),
+			route:               route,
+			remoteGateway:       remoteGateway,
+			localRoutes:         local.Lister().ByNamespace(opts.LocalNamespace),
+			remoteRoutes:        remote.Lister().ByNamespace(opts.RemoteNamespace),
+			localRoutesClient:   opts.LocalDynamicClient.Resource(route.GVR).Namespace(opts.LocalNamespace),
+			remoteRoutesClient:  opts.RemoteDynamicClient.Resource(route.GVR).Namespace(opts.RemoteNamespace),
+		}
+	}
+}
+
+// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
+func (ngr *NamespacedGatewayRouteReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local %v %q (remote: %q)", ngr.route.Name, ngr.LocalRef(name), ngr.RemoteRef(name))
+
+	local, lerr := ngr.get(ngr.localRoutes, name)
+	if lerr != nil && !kerrors.IsNotFound(lerr) {
+		return lerr
+	}
+	remote, rerr := ngr.get(ngr.remoteRoutes, name)
+	if rerr != nil && !kerrors.IsNotFound(rerr) {
+		return rerr
+	}
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local %v %q as remote already exists and is not managed by us", ngr.route.Name, ngr.LocalRef(name))
+			ngr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	if !kerrors.IsNotFound(lerr) {
+		skipReflection, err := ngr.ShouldSkipReflection(local)
+		if err != nil {
+			klog.Errorf("Failed to check whether local %v %q should be reflected: %v", ngr.route.Name, ngr.LocalRef(name), err)
+			return err
+		}
+		if skipReflection {
+			if ngr.GetReflectionType() == offloadingv1beta1.DenyList {
+				klog.Infof("Skipping reflection of local %v %q as marked with the skip annotation", ngr.route.Name, ngr.LocalRef(name))
+			} else { // AllowList
+				klog.Infof("Skipping reflection of local %v %q as not marked with the allow annotation", ngr.route.Name, ngr.LocalRef(name))
+			}
+			ngr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(ngr.GetReflectionType()))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(ngr.route.GVR.GroupResource(), local.GetName())
+		}
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	if kerrors.IsNotFound(lerr) {
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote %v %q, since local %q does no longer exist", ngr.route.Name, ngr.RemoteRef(name), ngr.LocalRef(name))
+			return ngr.DeleteRemote(ctx, routeDeleter{ngr.remoteRoutesClient}, ngr.route.Name, remote.GetName(), remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local %v %q and remote %v %q both vanished", ngr.route.Name, ngr.LocalRef(name), ngr.route.Name, ngr.RemoteRef(name))
+		return nil
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteGatewayRoute(local, ngr.RemoteNamespace(), ngr.remoteGateway, ngr.ForgingOpts)
+	tracer.Step("Remote mutation created")
+
+	if _, err := ngr.remoteRoutesClient.Apply(ctx, name, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote %v %q (local: %q): %v", ngr.route.Name, ngr.RemoteRef(name), ngr.LocalRef(name), err)
+		ngr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the remote object")
+	klog.Infof("Remote %v %q successfully enforced (local: %q)", ngr.route.Name, ngr.RemoteRef(name), ngr.LocalRef(name))
+
+	// Reflect the status of the remote route (i.e., whether it has been accepted by the remote gateway) back to the local one.
+	if rerr == nil {
+		defer tracer.Step("Reflected the status of the remote object")
+		if updated, changed := forge.LocalGatewayRouteStatus(local, remote, ngr.remoteGateway); changed {
+			if _, err := ngr.localRoutesClient.UpdateStatus(ctx, updated, metav1.UpdateOptions{FieldManager: forge.ReflectionFieldManager}); err != nil {
+				klog.Errorf("Failed to update the status of local %v %q (remote: %q): %v", ngr.route.Name, ngr.LocalRef(name), ngr.RemoteRef(name), err)
+				ngr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+				return err
+			}
+			klog.Infof("Status of local %v %q successfully updated (remote: %q)", ngr.route.Name, ngr.LocalRef(name), ngr.RemoteRef(name))
+		}
+	}
+
+	ngr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	return nil
+}
+
+// List returns the list of objects.
+func (ngr *NamespacedGatewayRouteReflector) List() ([]interface{}, error) {
+	var keys []interface{}
+	for _, lister := range []cache.GenericNamespaceLister{ngr.localRoutes, ngr.remoteRoutes} {
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for i := range objs {
+			obj, ok := objs[i].(metav1.Object)
+			if !ok {
+				continue
+			}
+			keys = append(keys, types.NamespacedName{Namespace: ngr.LocalNamespace(), Name: obj.GetName()})
+		}
+	}
+	return keys, nil
+}
+
+// get retrieves the given object from the lister, converting it to the unstructured representation.
+func (ngr *NamespacedGatewayRouteReflector) get(lister cache.GenericNamespaceLister, name string) (*unstructured.Unstructured, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, kerrors.NewInternalError(fmt.Errorf("unexpected type %T for %v %q", obj, ngr.route.Name, name))
+	}
+	return u, nil
+}
+
+// routeDeleter adapts a dynamic.ResourceInterface to the generic.ResourceDeleter interface.
+type routeDeleter struct {
+	dynamic.ResourceInterface
+}
+
+// Delete deletes the object with the given name.
+func (d routeDeleter) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return d.ResourceInterface.Delete(ctx, name, opts)
+}
@@ -0,0 +1,40 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exposition_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
+)
+
+var _ = Describe("Gateway API route Reflection", func() {
+	Describe("NewGatewayRouteReflector", func() {
+		DescribeTable("should create a non-nil reflector for each supported route type",
+			func(route exposition.GatewayRoute, remoteGateway *types.NamespacedName) {
+				reflectorConfig := offloadingv1beta1.ReflectorConfig{NumWorkers: 1, Type: offloadingv1beta1.DenyList}
+				reflector := exposition.NewGatewayRouteReflector(route, &reflectorConfig, remoteGateway)
+				Expect(reflector).NotTo(BeNil())
+				Expect(reflector.String()).To(Equal(route.Name))
+			},
+			Entry("HTTPRoute", exposition.GatewayRoutes[0], &types.NamespacedName{Namespace: "gateways", Name: "public"}),
+			Entry("GRPCRoute", exposition.GatewayRoutes[1], &types.NamespacedName{Namespace: "gateways", Name: "public"}),
+			Entry("TLSRoute", exposition.GatewayRoutes[2], nil),
+		)
+	})
+})
@@ -36,6 +36,7 @@ import (
 )
 
 var _ manager.NamespacedReflector = (*NamespacedIngressReflector)(nil)
+var _ manager.DryRunner = (*NamespacedIngressReflector)(nil)
 
 const (
 	// IngressReflectorName -> The name associated with the Ingress reflector.
@@ -159,6 +160,25 @@ func (nir *NamespacedIngressReflector) Handle(ctx context.Context, name string)
 	return nil
 }
 
+// DryRun forges the remote Ingress corresponding to the given local one, without enforcing it.
+func (nir *NamespacedIngressReflector) DryRun(ctx context.Context, name string) (forged, current interface{}, err error) {
+	local, err := nir.localIngresses.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mutation := forge.RemoteIngress(local, nir.RemoteNamespace(), nir.enableIngress, nir.remoteRealIngressClassName, nir.ForgingOpts)
+	if forged, err = nir.remoteIngressesClient.Apply(ctx, mutation, forge.DryRunApplyOptions()); err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := nir.remoteIngresses.Get(name)
+	if err != nil {
+		return forged, nil, client.IgnoreNotFound(err)
+	}
+	return forged, remote, nil
+}
+
 // List returns the list of ingress objects to be reflected.
 func (nir *NamespacedIngressReflector) List() ([]interface{}, error) {
 	return virtualkubelet.List[virtualkubelet.Lister[*netv1.Ingress], *netv1.Ingress](
@@ -35,6 +35,7 @@ import (
 )
 
 var _ manager.NamespacedReflector = (*NamespacedServiceReflector)(nil)
+var _ manager.DryRunner = (*NamespacedServiceReflector)(nil)
 
 const (
 	// ServiceReflectorName -> The name associated with the Service reflector.
@@ -105,6 +106,12 @@ func (nsr *NamespacedServiceReflector) Handle(ctx context.Context, name string)
 	utilruntime.Must(client.IgnoreNotFound(rerr))
 	tracer.Step("Retrieved the local and remote objects")
 
+	// Skip the services exported by the remote cluster, as they are managed by the ExportedService reflector.
+	if lerr == nil && forge.IsReverseReflected(local) {
+		klog.V(4).Infof("Skipping reflection of local Service %q as exported by the remote cluster", nsr.LocalRef(name))
+		return nil
+	}
+
 	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
 	if rerr == nil && !forge.IsReflected(remote) {
 		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
@@ -168,6 +175,25 @@ func (nsr *NamespacedServiceReflector) Handle(ctx context.Context, name string)
 	return nil
 }
 
+// DryRun forges the remote Service corresponding to the given local one, without enforcing it.
+func (nsr *NamespacedServiceReflector) DryRun(ctx context.Context, name string) (forged, current interface{}, err error) {
+	local, err := nsr.localServices.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mutation := forge.RemoteService(local, nsr.RemoteNamespace(), nsr.enableLoadBalancer, nsr.remoteRealLoadBalancerClassName, nsr.ForgingOpts)
+	if forged, err = nsr.remoteServicesClient.Apply(ctx, mutation, forge.DryRunApplyOptions()); err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := nsr.remoteServices.Get(name)
+	if err != nil {
+		return forged, nil, client.IgnoreNotFound(err)
+	}
+	return forged, remote, nil
+}
+
 // List returns the list of services to be reflected.
 func (nsr *NamespacedServiceReflector) List() ([]interface{}, error) {
 	return virtualkubelet.List[virtualkubelet.Lister[*corev1.Service], *corev1.Service](
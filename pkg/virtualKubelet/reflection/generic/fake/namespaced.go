@@ -46,3 +46,8 @@ func (r *NamespacedReflector) SetReady() { r.ready = true }
 
 // List returns the list of handled namespaces.
 func (r *NamespacedReflector) List() ([]interface{}, error) { return []interface{}{}, nil }
+
+// DryRun returns the given name as forged object, and no current object.
+func (r *NamespacedReflector) DryRun(_ context.Context, name string) (forged, current interface{}, err error) {
+	return name, nil, nil
+}
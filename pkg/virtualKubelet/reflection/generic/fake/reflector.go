@@ -17,6 +17,7 @@ package fake
 import (
 	"context"
 
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
 
@@ -66,3 +67,13 @@ func (r *Reflector) IsLeaderRestricted() bool {
 func (r *Reflector) Resync() error {
 	return nil
 }
+
+// DryRun returns the given namespace and name as forged object, and no current object.
+func (r *Reflector) DryRun(_ context.Context, namespace, name string) (forged, current interface{}, err error) {
+	return namespace + "/" + name, nil, nil
+}
+
+// Status returns a single namespace with one reflected object.
+func (r *Reflector) Status() []offloadingv1beta1.ReflectorNamespaceStatus {
+	return []offloadingv1beta1.ReflectorNamespaceStatus{{Namespace: "fake", Reflected: 1}}
+}
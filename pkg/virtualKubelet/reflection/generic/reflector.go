@@ -43,6 +43,8 @@ import (
 )
 
 var _ manager.Reflector = (*reflector)(nil)
+var _ manager.ReflectorDryRunner = (*reflector)(nil)
+var _ manager.ReflectorStatusReporter = (*reflector)(nil)
 var _ manager.Reflector = (*dummyreflector)(nil)
 
 // NamespacedReflectorFactoryFunc represents the function type to create a new NamespacedReflector.
@@ -68,6 +70,8 @@ type reflector struct {
 
 	concurrencyMode ConcurrencyMode
 	reflectionType  offloadingv1beta1.ReflectionType
+
+	tracker *tracker
 }
 
 // String returns the name of the reflector.
@@ -112,6 +116,8 @@ func newReflector(name string, namespaced NamespacedReflectorFactoryFunc, fallba
 
 		concurrencyMode: concurrencyMode,
 		reflectionType:  reflectionType,
+
+		tracker: newTracker(),
 	}
 }
 
@@ -172,6 +178,7 @@ func (gr *reflector) StopNamespace(local, remote string) {
 	}
 
 	delete(gr.reflectors, local)
+	gr.tracker.forgetNamespace(local)
 
 	// In case a fallback reflector exists, re-enqueue all the elements returned for the given namespace.
 	if gr.fallback != nil {
@@ -240,11 +247,15 @@ func (gr *reflector) processNextWorkItem() bool {
 		}).Inc()
 
 		if errors.As(err, &eae) {
+			gr.tracker.requeued(key.(types.NamespacedName))
+
 			// Put the item back on the workqueue after the given duration elapsed.
 			gr.workqueue.AddAfter(key, eae.duration)
 			return true
 		}
 
+		gr.tracker.failed(key.(types.NamespacedName), err)
+
 		// Put the item back on the workqueue to handle any transient errors.
 		gr.workqueue.AddRateLimited(key)
 
@@ -259,6 +270,8 @@ func (gr *reflector) processNextWorkItem() bool {
 		"node_name":          forge.LiqoNodeName,
 	}).Inc()
 
+	gr.tracker.succeeded(key.(types.NamespacedName))
+
 	// Finally, if no error occurs we Forget this item so it does not
 	// get queued again until another change happens.
 	gr.workqueue.Forget(key)
@@ -317,6 +330,7 @@ func (gr *reflector) handlers(keyer options.Keyer, filters ...options.EventFilte
 		for _, key := range keyer(metadata) {
 			klog.V(5).Infof("Enqueuing %q for reconciliation with key %q through the %v reflector",
 				klog.KRef(metadata.GetNamespace(), metadata.GetName()), key, gr.name)
+			gr.tracker.enqueued(key, ev == watch.Deleted)
 			gr.workqueue.Add(key)
 		}
 	}
@@ -357,6 +371,30 @@ func (gr *reflector) Resync() error {
 	return nil
 }
 
+// DryRun forges the remote object corresponding to the given local one, without enforcing it.
+func (gr *reflector) DryRun(ctx context.Context, namespace, name string) (forged, current interface{}, err error) {
+	reflector, found := gr.namespace(namespace)
+	if !found {
+		return nil, nil, fmt.Errorf("%w for local namespace %q", manager.ErrReflectorNotFound, namespace)
+	}
+
+	dryRunner, ok := reflector.(manager.DryRunner)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w by the %v reflector", manager.ErrDryRunNotSupported, gr.name)
+	}
+
+	if !reflector.Ready() {
+		return nil, nil, fmt.Errorf("%v reflection not yet completely initialized for local namespace %q", gr.name, namespace)
+	}
+
+	return dryRunner.DryRun(ctx, name)
+}
+
+// Status returns the aggregated statistics about the objects handled by the reflector, grouped by namespace.
+func (gr *reflector) Status() []offloadingv1beta1.ReflectorNamespaceStatus {
+	return gr.tracker.status()
+}
+
 // BasicKeyer returns a keyer retrieving the name and namespace from the object metadata.
 func BasicKeyer() func(metadata metav1.Object) []types.NamespacedName {
 	return func(metadata metav1.Object) []types.NamespacedName {
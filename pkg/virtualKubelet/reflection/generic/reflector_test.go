@@ -230,6 +230,38 @@ var _ = Describe("Reflector tests", func() {
 							})
 						})
 					})
+
+					Context("an item is dry-run", func() {
+						var (
+							namespace string
+							ready     bool
+							forged    interface{}
+							err       error
+						)
+
+						BeforeEach(func() { ready = false })
+						JustBeforeEach(func() {
+							if ready {
+								nsrfl.SetReady()
+							}
+							forged, _, err = rfl.(*reflector).DryRun(ctx, namespace, "foo")
+						})
+						When("the namespace exists", func() {
+							BeforeEach(func() { namespace = localNamespace })
+							When("the namespaced reflector is not ready", func() {
+								It("should return an error", func() { Expect(err).To(HaveOccurred()) })
+							})
+							When("the namespaced reflector is ready", func() {
+								BeforeEach(func() { ready = true })
+								It("should not return an error", func() { Expect(err).ToNot(HaveOccurred()) })
+								It("should return the forged object", func() { Expect(forged).To(Equal("foo")) })
+							})
+						})
+						When("the namespace does not exist", func() {
+							BeforeEach(func() { namespace = "whatever" })
+							It("should return a reflector not found error", func() { Expect(err).To(MatchError(manager.ErrReflectorNotFound)) })
+						})
+					})
 				})
 			})
 
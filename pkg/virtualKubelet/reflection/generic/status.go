@@ -0,0 +1,174 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+// itemState represents the reflection state of a given item.
+type itemState int
+
+const (
+	itemPending itemState = iota
+	itemReflected
+	itemFailed
+)
+
+// itemStatus contains the reflection state of a given item.
+type itemStatus struct {
+	state itemState
+	// deleted is true if the last event observed for the item was a deletion.
+	deleted bool
+}
+
+// namespaceError contains the last error occurred while reflecting an item of a given namespace.
+type namespaceError struct {
+	message string
+	time    metav1.Time
+}
+
+// tracker keeps track of the reflection state of the items handled by a reflector, to report aggregated statistics.
+type tracker struct {
+	sync.Mutex
+
+	items      map[types.NamespacedName]*itemStatus
+	lastErrors map[string]namespaceError
+}
+
+// newTracker returns a new tracker instance.
+func newTracker() *tracker {
+	return &tracker{
+		items:      make(map[types.NamespacedName]*itemStatus),
+		lastErrors: make(map[string]namespaceError),
+	}
+}
+
+// enqueued marks the given item as waiting to be reflected.
+func (t *tracker) enqueued(key types.NamespacedName, deleted bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	status, found := t.items[key]
+	if !found {
+		status = &itemStatus{state: itemPending}
+		t.items[key] = status
+	}
+
+	// A failed item is still considered as failed, until it is successfully reflected.
+	if status.state == itemReflected {
+		status.state = itemPending
+	}
+	status.deleted = deleted
+}
+
+// requeued marks the given item as waiting to be reflected, following an explicit requeue request.
+func (t *tracker) requeued(key types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+
+	if status, found := t.items[key]; found {
+		status.state = itemPending
+	}
+}
+
+// succeeded marks the given item as successfully reflected, and forgets it in case it has been deleted.
+func (t *tracker) succeeded(key types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+
+	status, found := t.items[key]
+	switch {
+	case !found:
+	case status.deleted:
+		delete(t.items, key)
+	default:
+		status.state = itemReflected
+	}
+}
+
+// failed marks the given item as failed, and records the corresponding error.
+func (t *tracker) failed(key types.NamespacedName, err error) {
+	t.Lock()
+	defer t.Unlock()
+
+	status, found := t.items[key]
+	if !found {
+		status = &itemStatus{}
+		t.items[key] = status
+	}
+
+	status.state = itemFailed
+	t.lastErrors[key.Namespace] = namespaceError{message: err.Error(), time: metav1.Now()}
+}
+
+// forgetNamespace forgets all the items belonging to the given namespace.
+func (t *tracker) forgetNamespace(namespace string) {
+	t.Lock()
+	defer t.Unlock()
+
+	for key := range t.items {
+		if key.Namespace == namespace {
+			delete(t.items, key)
+		}
+	}
+	delete(t.lastErrors, namespace)
+}
+
+// status returns the aggregated reflection statistics, grouped by namespace and sorted by namespace name.
+// The last error is reported only for the namespaces which currently include failed items.
+func (t *tracker) status() []offloadingv1beta1.ReflectorNamespaceStatus {
+	t.Lock()
+	defer t.Unlock()
+
+	namespaces := make(map[string]*offloadingv1beta1.ReflectorNamespaceStatus)
+	for key, status := range t.items {
+		ns, found := namespaces[key.Namespace]
+		if !found {
+			ns = &offloadingv1beta1.ReflectorNamespaceStatus{Namespace: key.Namespace}
+			namespaces[key.Namespace] = ns
+		}
+
+		switch status.state {
+		case itemPending:
+			ns.Pending++
+		case itemReflected:
+			ns.Reflected++
+		case itemFailed:
+			ns.Failed++
+		}
+	}
+
+	output := make([]offloadingv1beta1.ReflectorNamespaceStatus, 0, len(namespaces))
+	for _, ns := range namespaces {
+		if lastError, found := t.lastErrors[ns.Namespace]; found && ns.Failed > 0 {
+			ns.LastError = lastError.message
+			ns.LastErrorTime = lastError.time.DeepCopy()
+		}
+		output = append(output, *ns)
+	}
+
+	slices.SortFunc(output, func(a, b offloadingv1beta1.ReflectorNamespaceStatus) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return output
+}
@@ -0,0 +1,108 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+var _ = Describe("Tracker tests", func() {
+	var (
+		trk      *tracker
+		foo, bar types.NamespacedName
+		status   []offloadingv1beta1.ReflectorNamespaceStatus
+	)
+
+	// counters returns the reflected, pending and failed counters of the given status.
+	counters := func(ns offloadingv1beta1.ReflectorNamespaceStatus) [3]int32 {
+		return [3]int32{ns.Reflected, ns.Pending, ns.Failed}
+	}
+
+	BeforeEach(func() {
+		trk = newTracker()
+		foo = types.NamespacedName{Namespace: "ns", Name: "foo"}
+		bar = types.NamespacedName{Namespace: "ns", Name: "bar"}
+	})
+
+	JustBeforeEach(func() { status = trk.status() })
+
+	When("no item has been tracked", func() {
+		It("should return an empty status", func() { Expect(status).To(BeEmpty()) })
+	})
+
+	When("some items are enqueued", func() {
+		BeforeEach(func() {
+			trk.enqueued(foo, false)
+			trk.enqueued(bar, false)
+		})
+
+		It("should report them as pending", func() {
+			Expect(status).To(HaveLen(1))
+			Expect(counters(status[0])).To(Equal([3]int32{0, 2, 0}))
+		})
+
+		When("the items are processed", func() {
+			BeforeEach(func() {
+				trk.succeeded(foo)
+				trk.failed(bar, errors.New("something went wrong"))
+			})
+
+			It("should report the corresponding counters", func() {
+				Expect(status).To(HaveLen(1))
+				Expect(status[0].Namespace).To(Equal("ns"))
+				Expect(counters(status[0])).To(Equal([3]int32{1, 0, 1}))
+			})
+			It("should report the last error", func() {
+				Expect(status[0].LastError).To(Equal("something went wrong"))
+				Expect(status[0].LastErrorTime).ToNot(BeNil())
+			})
+
+			When("the failed item is enqueued again", func() {
+				BeforeEach(func() { trk.enqueued(bar, false) })
+				It("should still report it as failed", func() { Expect(counters(status[0])).To(Equal([3]int32{1, 0, 1})) })
+			})
+
+			When("the failed item is then successfully reflected", func() {
+				BeforeEach(func() { trk.succeeded(bar) })
+				It("should report it as reflected", func() { Expect(counters(status[0])).To(Equal([3]int32{2, 0, 0})) })
+				It("should not report the last error", func() { Expect(status[0].LastError).To(BeEmpty()) })
+			})
+
+			When("a reflected item is requeued", func() {
+				BeforeEach(func() { trk.requeued(foo) })
+				It("should report it as pending", func() { Expect(counters(status[0])).To(Equal([3]int32{0, 1, 1})) })
+			})
+
+			When("a reflected item is deleted", func() {
+				BeforeEach(func() {
+					trk.enqueued(foo, true)
+					trk.succeeded(foo)
+				})
+				It("should forget it", func() { Expect(counters(status[0])).To(Equal([3]int32{0, 0, 1})) })
+			})
+
+			When("the namespace is forgotten", func() {
+				BeforeEach(func() { trk.forgetNamespace("ns") })
+				It("should return an empty status", func() { Expect(status).To(BeEmpty()) })
+			})
+		})
+	})
+})
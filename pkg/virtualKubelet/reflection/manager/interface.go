@@ -16,9 +16,11 @@ package manager
 
 import (
 	"context"
+	"errors"
 
 	"k8s.io/apimachinery/pkg/types"
 
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
 
@@ -26,16 +28,40 @@ import (
 type Manager interface {
 	// With registers the given reflector to the manager.
 	With(reflector Reflector) Manager
+	// WithClusterScoped registers the given cluster-scoped reflector to the manager.
+	WithClusterScoped(reflector ClusterScopedReflector) Manager
 	// WithNamespaceHandler add the given NamespaceHandler to the manager.
 	WithNamespaceHandler(handler NamespaceHandler) Manager
 	// Start starts the reflection manager. It panics if executed twice.
 	Start(ctx context.Context)
 	// Resync triggers a resync of the reflectors.
 	Resync() error
+	// DryRun forges the remote object corresponding to the given local one through the reflector with the given name,
+	// without enforcing it in the remote cluster.
+	DryRun(ctx context.Context, reflector, namespace, name string) (*DryRunResult, error)
+	// Status returns the aggregated statistics about the objects handled by each reflector.
+	Status() []offloadingv1beta1.ReflectorStatus
 
 	NamespaceStartStopper
 }
 
+var (
+	// ErrReflectorNotFound is returned when the reflector targeted by a dry-run request does not exist.
+	ErrReflectorNotFound = errors.New("reflector not found")
+	// ErrDryRunNotSupported is returned when the reflector targeted by a dry-run request does not support it.
+	ErrDryRunNotSupported = errors.New("dry-run not supported")
+)
+
+// DryRunResult contains the outcome of the dry-run reflection of an object.
+type DryRunResult struct {
+	// Reflector is the name of the reflector which processed the request.
+	Reflector string `json:"reflector"`
+	// Forged is the remote object forged starting from the local one.
+	Forged interface{} `json:"forged"`
+	// Current is the corresponding object currently existing in the remote cluster, if any.
+	Current interface{} `json:"current,omitempty"`
+}
+
 // NamespaceStartStopper manages the reflection at the namespace level.
 type NamespaceStartStopper interface {
 	// StartNamespace starts the reflection for a given namespace.
@@ -58,6 +84,28 @@ type Reflector interface {
 	Resync() error
 }
 
+// ClusterScopedReflector implements the reflection of cluster-scoped objects between the local and the remote cluster.
+type ClusterScopedReflector interface {
+	// String returns the name of the reflector.
+	String() string
+	// Start starts the reflector.
+	Start(ctx context.Context, opts *options.ClusterScopedOpts)
+	// Resync triggers a resync of the reflector.
+	Resync() error
+}
+
+// ReflectorDryRunner is implemented by the Reflectors supporting the dry-run reflection of objects.
+type ReflectorDryRunner interface {
+	// DryRun forges the remote object corresponding to the given local one, without enforcing it.
+	DryRun(ctx context.Context, namespace, name string) (forged, current interface{}, err error)
+}
+
+// ReflectorStatusReporter is implemented by the Reflectors reporting aggregated statistics about the handled objects.
+type ReflectorStatusReporter interface {
+	// Status returns the aggregated statistics about the handled objects, grouped by namespace.
+	Status() []offloadingv1beta1.ReflectorNamespaceStatus
+}
+
 // NamespacedReflector implements the reflection between a local and a remote namespace.
 type NamespacedReflector interface {
 	// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
@@ -68,6 +116,13 @@ type NamespacedReflector interface {
 	List() ([]interface{}, error)
 }
 
+// DryRunner is implemented by the NamespacedReflectors supporting the dry-run reflection of objects, to ease troubleshooting.
+type DryRunner interface {
+	// DryRun forges the remote object corresponding to the given local one, without enforcing it.
+	// It returns the forged object, along with the one currently existing in the remote cluster (nil if not found).
+	DryRun(ctx context.Context, name string) (forged, current interface{}, err error)
+}
+
 // FallbackReflector implements fallback reflection for "orphan" local objects not managed by namespaced reflectors.
 type FallbackReflector interface {
 	// Handle is responsible for reconciling the given "orphan" object.
@@ -17,11 +17,14 @@ package manager
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -29,6 +32,7 @@ import (
 	"k8s.io/utils/ptr"
 	"k8s.io/utils/trace"
 
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	liqoclient "github.com/liqotech/liqo/pkg/client/clientset/versioned"
 	liqoinformers "github.com/liqotech/liqo/pkg/client/informers/externalversions"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
@@ -46,10 +50,13 @@ type manager struct {
 	remote           kubernetes.Interface
 	localLiqo        liqoclient.Interface
 	remoteLiqo       liqoclient.Interface
+	localDynamic     dynamic.Interface
+	remoteDynamic    dynamic.Interface
 	resync           time.Duration
 	eventBroadcaster record.EventBroadcaster
 
 	reflectors              []Reflector
+	clusterScopedReflectors []ClusterScopedReflector
 	localPodInformerFactory informers.SharedInformerFactory
 
 	namespaceHandler NamespaceHandler
@@ -61,8 +68,8 @@ type manager struct {
 }
 
 // New returns a new manager to start the reflection towards a remote cluster.
-func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.Interface, resync time.Duration,
-	eb record.EventBroadcaster, forgingOpts *forge.ForgingOpts) Manager {
+func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.Interface, localDynamic, remoteDynamic dynamic.Interface,
+	resync time.Duration, eb record.EventBroadcaster, forgingOpts *forge.ForgingOpts) Manager {
 	// Configure the field selector to retrieve only the pods scheduled on the current virtual node.
 	localPodTweakListOptions := func(opts *metav1.ListOptions) {
 		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", forge.LiqoNodeName).String()
@@ -73,10 +80,13 @@ func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.In
 		remote:           remote,
 		localLiqo:        localLiqo,
 		remoteLiqo:       remoteLiqo,
+		localDynamic:     localDynamic,
+		remoteDynamic:    remoteDynamic,
 		resync:           resync,
 		eventBroadcaster: eb,
 
-		reflectors: make([]Reflector, 0),
+		reflectors:              make([]Reflector, 0),
+		clusterScopedReflectors: make([]ClusterScopedReflector, 0),
 		localPodInformerFactory: informers.NewSharedInformerFactoryWithOptions(local, resync,
 			informers.WithTweakListOptions(localPodTweakListOptions)),
 
@@ -97,6 +107,16 @@ func (m *manager) With(reflector Reflector) Manager {
 	return m
 }
 
+// WithClusterScoped registers the given cluster-scoped reflector to the manager.
+func (m *manager) WithClusterScoped(reflector ClusterScopedReflector) Manager {
+	if m.started {
+		panic("Attempted to register a new cluster-scoped reflector while already running")
+	}
+
+	m.clusterScopedReflectors = append(m.clusterScopedReflectors, reflector)
+	return m
+}
+
 func (m *manager) WithNamespaceHandler(handler NamespaceHandler) Manager {
 	if m.started {
 		panic("Attempted to register a namespace event handler while already running")
@@ -126,6 +146,10 @@ func (m *manager) Start(ctx context.Context) {
 
 	m.started = true
 
+	if len(m.clusterScopedReflectors) > 0 {
+		m.startClusterScoped(ctx)
+	}
+
 	if m.namespaceHandler != nil {
 		m.namespaceHandler.Start(ctx, m)
 	} else {
@@ -144,6 +168,42 @@ func (m *manager) Start(ctx context.Context) {
 	}()
 }
 
+// startClusterScoped starts the reflection of cluster-scoped objects.
+func (m *manager) startClusterScoped(ctx context.Context) {
+	// The dynamic informer factories, which select all cluster-scoped resources of the configured kinds.
+	// We do not filter the remote resources by label selector, to be able to abort reflection in case the remote object already exists.
+	localFactory := dynamicinformer.NewDynamicSharedInformerFactory(m.localDynamic, m.resync)
+	remoteFactory := dynamicinformer.NewDynamicSharedInformerFactory(m.remoteDynamic, m.resync)
+
+	ready := false
+	for _, reflector := range m.clusterScopedReflectors {
+		opts := options.NewClusterScoped().
+			WithDynamicLocal(m.localDynamic, localFactory).WithDynamicRemote(m.remoteDynamic, remoteFactory).
+			WithReadinessFunc(func() bool { return ready }).WithForgingOpts(&m.forgingOpts)
+		reflector.Start(ctx, opts)
+	}
+
+	// The initialization is executed in a separate go routine, as cache synchronization might require some time to complete.
+	go func() {
+		localFactory.Start(ctx.Done())
+		remoteFactory.Start(ctx.Done())
+
+		localFactory.WaitForCacheSync(ctx.Done())
+		remoteFactory.WaitForCacheSync(ctx.Done())
+
+		// If the context was closed before the cache was ready, let abort the setup
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			break
+		}
+
+		klog.Info("Reflection of cluster-scoped resources correctly started")
+		ready = true
+	}()
+}
+
 // StartNamespace starts the reflection for a given namespace.
 func (m *manager) StartNamespace(local, remote string) {
 	m.Lock()
@@ -174,12 +234,40 @@ func (m *manager) StartNamespace(local, remote string) {
 
 	ready := false
 	for _, reflector := range m.reflectors {
+		// The dynamic informer factories, used by the reflectors of custom resources (if any). They are dedicated to each reflector,
+		// and synced separately, so that a missing CRD or permission does not block the reflection of the other resources.
+		localDynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(m.localDynamic, m.resync, local, nil)
+		remoteDynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(m.remoteDynamic, m.resync, remote, nil)
+
+		dynamicReady := false
 		opts := options.NewNamespaced().
 			WithLocal(local, m.local, localFactory).WithLiqoLocal(m.localLiqo, localLiqoFactory).
 			WithRemote(remote, m.remote, remoteFactory).WithLiqoRemote(m.remoteLiqo, remoteLiqoFactory).
-			WithReadinessFunc(func() bool { return ready }).WithEventBroadcaster(m.eventBroadcaster).
+			WithDynamicLocal(m.localDynamic, localDynamicFactory).WithDynamicRemote(m.remoteDynamic, remoteDynamicFactory).
+			WithReadinessFunc(func() bool { return ready && dynamicReady }).WithEventBroadcaster(m.eventBroadcaster).
 			WithForgingOpts(&m.forgingOpts)
 		reflector.StartNamespace(opts)
+
+		// This is a no-op in case no dynamic informers have been retrieved by the reflector.
+		go func() {
+			localDynamicFactory.Start(ctx.Done())
+			remoteDynamicFactory.Start(ctx.Done())
+
+			localDynamicFactory.WaitForCacheSync(ctx.Done())
+			remoteDynamicFactory.WaitForCacheSync(ctx.Done())
+
+			// If the context was closed before the cache was ready, let abort the setup
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				break
+			}
+
+			klog.V(4).Infof("Dynamic informers of the %v reflector for local namespace %q and remote namespace %q correctly synced",
+				reflector, local, remote)
+			dynamicReady = true
+		}()
 	}
 
 	// The initialization is executed in a separate go routine, as cache synchronization might require some time to complete.
@@ -240,5 +328,52 @@ func (m *manager) Resync() error {
 			klog.Errorf("Error while resyncing the %s reflector: %s", m.reflectors[i], err)
 		}
 	}
+	for i := range m.clusterScopedReflectors {
+		if err := m.clusterScopedReflectors[i].Resync(); err != nil {
+			klog.Errorf("Error while resyncing the %s cluster-scoped reflector: %s", m.clusterScopedReflectors[i], err)
+		}
+	}
 	return nil
 }
+
+// DryRun forges the remote object corresponding to the given local one through the reflector with the given name
+// (case-insensitive), without enforcing it in the remote cluster.
+func (m *manager) DryRun(ctx context.Context, reflector, namespace, name string) (*DryRunResult, error) {
+	for i := range m.reflectors {
+		if !strings.EqualFold(m.reflectors[i].String(), reflector) {
+			continue
+		}
+
+		dryRunner, ok := m.reflectors[i].(ReflectorDryRunner)
+		if !ok {
+			return nil, fmt.Errorf("%w by the %v reflector", ErrDryRunNotSupported, m.reflectors[i])
+		}
+
+		forged, current, err := dryRunner.DryRun(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+
+		return &DryRunResult{Reflector: m.reflectors[i].String(), Forged: forged, Current: current}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrReflectorNotFound, reflector)
+}
+
+// Status returns the aggregated statistics about the objects handled by each reflector.
+// Reflectors not supporting status reporting (e.g., the disabled ones) are not included.
+func (m *manager) Status() []offloadingv1beta1.ReflectorStatus {
+	reflectors := make([]offloadingv1beta1.ReflectorStatus, 0, len(m.reflectors))
+	for i := range m.reflectors {
+		reporter, ok := m.reflectors[i].(ReflectorStatusReporter)
+		if !ok {
+			continue
+		}
+
+		reflectors = append(reflectors, offloadingv1beta1.ReflectorStatus{
+			Resource:   m.reflectors[i].String(),
+			Namespaces: reporter.Status(),
+		})
+	}
+	return reflectors
+}
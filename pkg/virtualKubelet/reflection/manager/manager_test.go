@@ -20,8 +20,14 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
@@ -29,6 +35,7 @@ import (
 	liqoclientfake "github.com/liqotech/liqo/pkg/client/clientset/versioned/fake"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	reflectionfake "github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic/fake"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
 
 var _ = Describe("Manager tests", func() {
@@ -43,6 +50,8 @@ var _ = Describe("Manager tests", func() {
 		remoteClient     kubernetes.Interface
 		localLiqoClient  liqoclient.Interface
 		remoteLiqoClient liqoclient.Interface
+		localDynClient   dynamic.Interface
+		remoteDynClient  dynamic.Interface
 		broadcaster      record.EventBroadcaster
 		offloadingPatch  offloadingv1beta1.OffloadingPatch
 		forgingOpts      forge.ForgingOpts
@@ -57,13 +66,15 @@ var _ = Describe("Manager tests", func() {
 		remoteClient = fake.NewSimpleClientset()
 		localLiqoClient = liqoclientfake.NewSimpleClientset()
 		remoteLiqoClient = liqoclientfake.NewSimpleClientset()
+		localDynClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		remoteDynClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
 		broadcaster = record.NewBroadcaster()
 		forgingOpts = forge.NewForgingOpts(&offloadingPatch)
 	})
 	AfterEach(func() { cancel() })
 
 	JustBeforeEach(func() {
-		mgr = New(localClient, remoteClient, localLiqoClient, remoteLiqoClient, 1*time.Hour, broadcaster, &forgingOpts)
+		mgr = New(localClient, remoteClient, localLiqoClient, remoteLiqoClient, localDynClient, remoteDynClient, 1*time.Hour, broadcaster, &forgingOpts)
 	})
 
 	Context("a new manager is created", func() {
@@ -73,6 +84,8 @@ var _ = Describe("Manager tests", func() {
 			Expect(mgr.(*manager).remote).To(Equal(remoteClient))
 			Expect(mgr.(*manager).localLiqo).To(Equal(localLiqoClient))
 			Expect(mgr.(*manager).remoteLiqo).To(Equal(remoteLiqoClient))
+			Expect(mgr.(*manager).localDynamic).To(Equal(localDynClient))
+			Expect(mgr.(*manager).remoteDynamic).To(Equal(remoteDynClient))
 			Expect(mgr.(*manager).resync).To(Equal(1 * time.Hour))
 			Expect(mgr.(*manager).eventBroadcaster).To(Equal(broadcaster))
 
@@ -140,6 +153,38 @@ var _ = Describe("Manager tests", func() {
 				})
 				It("should panic if started twice", func() { Expect(func() { mgr.Start(ctx) }).To(Panic()) })
 
+				Context("an object is dry-run", func() {
+					var (
+						reflectorName string
+						result        *DryRunResult
+						err           error
+					)
+
+					JustBeforeEach(func() { result, err = mgr.DryRun(ctx, reflectorName, localNamespace, "foo") })
+
+					When("the reflector exists", func() {
+						BeforeEach(func() { reflectorName = "FakeReflector" })
+						It("should not return an error", func() { Expect(err).ToNot(HaveOccurred()) })
+						It("should return the dry-run result", func() {
+							Expect(result.Reflector).To(Equal(reflector.String()))
+							Expect(result.Forged).To(Equal(localNamespace + "/foo"))
+							Expect(result.Current).To(BeNil())
+						})
+					})
+
+					When("the reflector does not exist", func() {
+						BeforeEach(func() { reflectorName = "whatever" })
+						It("should return a reflector not found error", func() { Expect(err).To(MatchError(ErrReflectorNotFound)) })
+					})
+				})
+
+				It("should return the status of the reflectors", func() {
+					Expect(mgr.Status()).To(ConsistOf(offloadingv1beta1.ReflectorStatus{
+						Resource:   reflector.String(),
+						Namespaces: []offloadingv1beta1.ReflectorNamespaceStatus{{Namespace: "fake", Reflected: 1}},
+					}))
+				})
+
 				Context("a namespace is started", func() {
 					JustBeforeEach(func() { mgr.StartNamespace(localNamespace, remoteNamespace) })
 
@@ -177,6 +222,76 @@ var _ = Describe("Manager tests", func() {
 				})
 			})
 		})
+
+		Context("a reflector of custom resources whose informers cannot sync is registered", func() {
+			var (
+				reflector        *reflectionfake.Reflector
+				dynamicReflector *fakeDynamicReflector
+			)
+
+			BeforeEach(func() {
+				gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "appconfigs"}
+				fakeDynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{gvr: "AppConfigList"})
+				// Simulate the lack of the permissions to list the custom resources in the remote cluster.
+				fakeDynClient.PrependReactor("list", gvr.Resource, func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, kerrors.NewForbidden(gvr.GroupResource(), "", nil)
+				})
+				remoteDynClient = fakeDynClient
+
+				reflector = reflectionfake.NewReflector(false)
+				dynamicReflector = &fakeDynamicReflector{Reflector: reflectionfake.NewReflector(false), gvr: gvr}
+			})
+
+			JustBeforeEach(func() {
+				mgr.With(reflector).With(dynamicReflector).WithNamespaceHandler(&fakeNamespaceHandler{})
+				mgr.Start(ctx)
+				mgr.StartNamespace(localNamespace, remoteNamespace)
+			})
+
+			It("should eventually mark the namespace as ready for the other reflectors", func() {
+				Eventually(reflector.NamespaceStarted[localNamespace].Ready).Should(BeTrue())
+			})
+			It("should not mark the namespace as ready for the reflector of custom resources", func() {
+				Consistently(dynamicReflector.NamespaceStarted[localNamespace].Ready).Should(BeFalse())
+			})
+		})
+
+		Context("a cluster-scoped reflector is registered", func() {
+			var (
+				returned  Manager
+				reflector *fakeClusterScopedReflector
+			)
+
+			BeforeEach(func() { reflector = &fakeClusterScopedReflector{} })
+			JustBeforeEach(func() { returned = mgr.WithClusterScoped(reflector) })
+
+			It("should return the receiver manager", func() { Expect(mgr).To(BeIdenticalTo(returned)) })
+			It("should correctly add the reflector to the list", func() {
+				Expect(mgr.(*manager).clusterScopedReflectors).To(ConsistOf(reflector))
+			})
+
+			Context("the manager is started", func() {
+				JustBeforeEach(func() {
+					mgr.WithNamespaceHandler(&fakeNamespaceHandler{})
+					mgr.Start(ctx)
+				})
+
+				It("should start the registered reflector", func() { Expect(reflector.Opts).ToNot(BeNil()) })
+				It("should correctly populate the reflector options", func() {
+					Expect(reflector.Opts.LocalDynamicClient).To(Equal(localDynClient))
+					Expect(reflector.Opts.LocalDynamicFactory).ToNot(BeNil())
+					Expect(reflector.Opts.RemoteDynamicClient).To(Equal(remoteDynClient))
+					Expect(reflector.Opts.RemoteDynamicFactory).ToNot(BeNil())
+					Expect(reflector.Opts.ForgingOpts).ToNot(BeNil())
+				})
+				It("should eventually mark the reflection as ready", func() { Eventually(reflector.Opts.Ready).Should(BeTrue()) })
+				It("should resync the registered reflector", func() {
+					Expect(mgr.Resync()).To(Succeed())
+					Expect(reflector.ResyncCalled).To(BeEquivalentTo(1))
+				})
+			})
+		})
 	})
 })
 
@@ -189,3 +304,35 @@ type fakeNamespaceHandler struct {
 func (nh *fakeNamespaceHandler) Start(_ context.Context, _ NamespaceStartStopper) {
 	nh.StartCalled++
 }
+
+// fakeClusterScopedReflector implements a fake ClusterScopedReflector for testing purpouses.
+type fakeClusterScopedReflector struct {
+	Opts         *options.ClusterScopedOpts
+	ResyncCalled int
+}
+
+// String is the fake String method.
+func (csr *fakeClusterScopedReflector) String() string { return "fake" }
+
+// Start is the fake Start method.
+func (csr *fakeClusterScopedReflector) Start(_ context.Context, opts *options.ClusterScopedOpts) {
+	csr.Opts = opts
+}
+
+// Resync is the fake Resync method.
+func (csr *fakeClusterScopedReflector) Resync() error {
+	csr.ResyncCalled++
+	return nil
+}
+
+// fakeDynamicReflector implements a fake Reflector retrieving a dynamic informer for the given resource, for testing purpouses.
+type fakeDynamicReflector struct {
+	*reflectionfake.Reflector
+	gvr schema.GroupVersionResource
+}
+
+// StartNamespace retrieves the remote dynamic informer, and marks the given namespace as started.
+func (r *fakeDynamicReflector) StartNamespace(opts *options.NamespacedOpts) {
+	opts.RemoteDynamicFactory.ForResource(r.gvr)
+	r.Reflector.StartNamespace(opts)
+}
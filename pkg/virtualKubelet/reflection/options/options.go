@@ -18,6 +18,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -75,15 +77,19 @@ type NamespacedOpts struct {
 	LocalNamespace  string
 	RemoteNamespace string
 
-	LocalClient      kubernetes.Interface
-	RemoteClient     kubernetes.Interface
-	LocalLiqoClient  liqoclient.Interface
-	RemoteLiqoClient liqoclient.Interface
+	LocalClient         kubernetes.Interface
+	RemoteClient        kubernetes.Interface
+	LocalLiqoClient     liqoclient.Interface
+	RemoteLiqoClient    liqoclient.Interface
+	LocalDynamicClient  dynamic.Interface
+	RemoteDynamicClient dynamic.Interface
 
-	LocalFactory      informers.SharedInformerFactory
-	RemoteFactory     informers.SharedInformerFactory
-	LocalLiqoFactory  liqoinformers.SharedInformerFactory
-	RemoteLiqoFactory liqoinformers.SharedInformerFactory
+	LocalFactory         informers.SharedInformerFactory
+	RemoteFactory        informers.SharedInformerFactory
+	LocalLiqoFactory     liqoinformers.SharedInformerFactory
+	RemoteLiqoFactory    liqoinformers.SharedInformerFactory
+	LocalDynamicFactory  dynamicinformer.DynamicSharedInformerFactory
+	RemoteDynamicFactory dynamicinformer.DynamicSharedInformerFactory
 
 	EventBroadcaster record.EventBroadcaster
 
@@ -129,6 +135,20 @@ func (ro *NamespacedOpts) WithLiqoRemote(client liqoclient.Interface, factory li
 	return ro
 }
 
+// WithDynamicLocal configures the local dynamic client and informer factory parameters of the NamespacedOpts.
+func (ro *NamespacedOpts) WithDynamicLocal(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *NamespacedOpts {
+	ro.LocalDynamicClient = client
+	ro.LocalDynamicFactory = factory
+	return ro
+}
+
+// WithDynamicRemote configures the remote dynamic client and informer factory parameters of the NamespacedOpts.
+func (ro *NamespacedOpts) WithDynamicRemote(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *NamespacedOpts {
+	ro.RemoteDynamicClient = client
+	ro.RemoteDynamicFactory = factory
+	return ro
+}
+
 // WithHandlerFactory configures the handler factory of the NamespacedOpts.
 func (ro *NamespacedOpts) WithHandlerFactory(handler func(Keyer, ...EventFilter) cache.ResourceEventHandler) *NamespacedOpts {
 	ro.HandlerFactory = handler
@@ -159,6 +179,49 @@ func (ro *NamespacedOpts) WithReflectionType(reflectionType offloadingv1beta1.Re
 	return ro
 }
 
+// ClusterScopedOpts is a structure grouping the parameters to start a ClusterScopedReflector.
+type ClusterScopedOpts struct {
+	LocalDynamicClient  dynamic.Interface
+	RemoteDynamicClient dynamic.Interface
+
+	LocalDynamicFactory  dynamicinformer.DynamicSharedInformerFactory
+	RemoteDynamicFactory dynamicinformer.DynamicSharedInformerFactory
+
+	Ready       func() bool
+	ForgingOpts *forge.ForgingOpts
+}
+
+// NewClusterScoped returns a new ClusterScopedOpts object.
+func NewClusterScoped() *ClusterScopedOpts {
+	return &ClusterScopedOpts{}
+}
+
+// WithDynamicLocal configures the local dynamic client and informer factory parameters of the ClusterScopedOpts.
+func (ro *ClusterScopedOpts) WithDynamicLocal(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *ClusterScopedOpts {
+	ro.LocalDynamicClient = client
+	ro.LocalDynamicFactory = factory
+	return ro
+}
+
+// WithDynamicRemote configures the remote dynamic client and informer factory parameters of the ClusterScopedOpts.
+func (ro *ClusterScopedOpts) WithDynamicRemote(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *ClusterScopedOpts {
+	ro.RemoteDynamicClient = client
+	ro.RemoteDynamicFactory = factory
+	return ro
+}
+
+// WithReadinessFunc configures the readiness function of the ClusterScopedOpts.
+func (ro *ClusterScopedOpts) WithReadinessFunc(ready func() bool) *ClusterScopedOpts {
+	ro.Ready = ready
+	return ro
+}
+
+// WithForgingOpts configures the reflection options of the ClusterScopedOpts.
+func (ro *ClusterScopedOpts) WithForgingOpts(opts *forge.ForgingOpts) *ClusterScopedOpts {
+	ro.ForgingOpts = opts
+	return ro
+}
+
 // EventFilterCreate ignores events of type create.
 func EventFilterCreate(et watch.EventType) bool { return et == watch.Added }
 
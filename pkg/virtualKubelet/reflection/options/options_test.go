@@ -19,6 +19,10 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -370,4 +374,60 @@ var _ = Describe("Options", func() {
 			})
 		})
 	})
+
+	Describe("The With functions of ClusterScopedOpts", func() {
+		var (
+			original, opts *options.ClusterScopedOpts
+			client         dynamic.Interface
+			factory        dynamicinformer.DynamicSharedInformerFactory
+		)
+
+		BeforeEach(func() {
+			original = options.NewClusterScoped()
+			client = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			factory = dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+		})
+
+		It("should return a non-nil pointer", func() { Expect(original).ToNot(BeNil()) })
+
+		Describe("The WithDynamicLocal function", func() {
+			JustBeforeEach(func() { opts = original.WithDynamicLocal(client, factory) })
+
+			It("should return the same pointer of the receiver", func() { Expect(opts).To(BeIdenticalTo(original)) })
+			It("should correctly set the local fields", func() {
+				Expect(opts.LocalDynamicClient).To(Equal(client))
+				Expect(opts.LocalDynamicFactory).To(Equal(factory))
+				Expect(opts.RemoteDynamicClient).To(BeNil())
+				Expect(opts.RemoteDynamicFactory).To(BeNil())
+			})
+		})
+
+		Describe("The WithDynamicRemote function", func() {
+			JustBeforeEach(func() { opts = original.WithDynamicRemote(client, factory) })
+
+			It("should return the same pointer of the receiver", func() { Expect(opts).To(BeIdenticalTo(original)) })
+			It("should correctly set the remote fields", func() {
+				Expect(opts.RemoteDynamicClient).To(Equal(client))
+				Expect(opts.RemoteDynamicFactory).To(Equal(factory))
+				Expect(opts.LocalDynamicClient).To(BeNil())
+				Expect(opts.LocalDynamicFactory).To(BeNil())
+			})
+		})
+
+		Describe("The WithReadinessFunc function", func() {
+			JustBeforeEach(func() { opts = original.WithReadinessFunc(func() bool { return true }) })
+
+			It("should return the same pointer of the receiver", func() { Expect(opts).To(BeIdenticalTo(original)) })
+			It("should correctly set the readiness function", func() { Expect(opts.Ready()).To(BeTrue()) })
+		})
+
+		Describe("The WithForgingOpts function", func() {
+			var forgingOpts forge.ForgingOpts
+
+			JustBeforeEach(func() { opts = original.WithForgingOpts(&forgingOpts) })
+
+			It("should return the same pointer of the receiver", func() { Expect(opts).To(BeIdenticalTo(original)) })
+			It("should correctly set the forging options value", func() { Expect(opts.ForgingOpts).To(BeIdenticalTo(&forgingOpts)) })
+		})
+	})
 })
@@ -14,6 +14,20 @@
 
 package resources
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+// DefaultCustomResourceWorkers is the default number of workers for the reflectors of custom resources.
+const DefaultCustomResourceWorkers = 3
+
 // ResourceReflected represents a resource that can be reflected.
 type ResourceReflected string
 
@@ -28,10 +42,113 @@ const (
 	ServiceAccount        ResourceReflected = "serviceaccount"
 	PersistentVolumeClaim ResourceReflected = "persistentvolumeclaim"
 	Event                 ResourceReflected = "event"
+	PodDisruptionBudget   ResourceReflected = "poddisruptionbudget"
+	HTTPRoute             ResourceReflected = "httproute"
+	GRPCRoute             ResourceReflected = "grpcroute"
+	TLSRoute              ResourceReflected = "tlsroute"
+	ExportedService       ResourceReflected = "exportedservice"
+	VolumeSnapshot        ResourceReflected = "volumesnapshot"
 )
 
 // Reflectors is the list of all resources that can be reflected.
-var Reflectors = []ResourceReflected{Pod, Service, EndpointSlice, Ingress, ConfigMap, Secret, ServiceAccount, PersistentVolumeClaim, Event}
+var Reflectors = []ResourceReflected{Pod, Service, EndpointSlice, Ingress, ConfigMap, Secret, ServiceAccount, PersistentVolumeClaim, Event,
+	PodDisruptionBudget, HTTPRoute, GRPCRoute, TLSRoute, ExportedService, VolumeSnapshot}
 
 // ReflectorsCustomizableType is the list of resources for which the reflection type can be customized.
-var ReflectorsCustomizableType = []ResourceReflected{Service, Ingress, ConfigMap, Secret, Event}
+var ReflectorsCustomizableType = []ResourceReflected{Service, Ingress, ConfigMap, Secret, Event, PodDisruptionBudget,
+	HTTPRoute, GRPCRoute, TLSRoute}
+
+// ClusterScopedResources maps the kinds of the cluster-scoped resources which can be reflected to the corresponding resources.
+var ClusterScopedResources = map[offloadingv1beta1.ClusterScopedResourceKind]schema.GroupVersionResource{
+	offloadingv1beta1.PriorityClassKind: {Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"},
+	offloadingv1beta1.RuntimeClassKind:  {Group: "node.k8s.io", Version: "v1", Resource: "runtimeclasses"},
+	offloadingv1beta1.StorageClassKind:  {Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"},
+	offloadingv1beta1.IngressClassKind:  {Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"},
+}
+
+// ParseClusterScopedKinds validates the given kinds of cluster-scoped resources, returning them in the typed form.
+func ParseClusterScopedKinds(values []string) ([]offloadingv1beta1.ClusterScopedResourceKind, error) {
+	kinds := make([]offloadingv1beta1.ClusterScopedResourceKind, 0, len(values))
+	for _, value := range values {
+		kind := offloadingv1beta1.ClusterScopedResourceKind(value)
+		if _, found := ClusterScopedResources[kind]; !found {
+			return nil, fmt.Errorf("cluster-scoped resource kind %q is not supported", value)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// CustomResourceKey returns the key identifying the reflector of the given custom resource, in the <resource>.<version>.<group> form.
+func CustomResourceKey(gvr schema.GroupVersionResource) string {
+	return fmt.Sprintf("%s.%s.%s", gvr.Resource, gvr.Version, gvr.Group)
+}
+
+// ParseCustomResourceKey parses a key in the <resource>.<version>.<group> form, returning the corresponding custom resource.
+// It returns false if the key refers to a built-in reflector, or it is not in the expected form.
+func ParseCustomResourceKey(key string) (schema.GroupVersionResource, bool) {
+	if slices.Contains(Reflectors, ResourceReflected(key)) {
+		return schema.GroupVersionResource{}, false
+	}
+
+	gvr, _ := schema.ParseResourceArg(key)
+	if gvr == nil || gvr.Resource == "" || gvr.Version == "" || gvr.Group == "" {
+		return schema.GroupVersionResource{}, false
+	}
+	return *gvr, true
+}
+
+// FormatCustomResourceReflector returns the textual representation of the configuration of the given custom resource reflector,
+// in the <resource>.<version>.<group>,workers=<workers>,type=<type>[,reflectStatus=true] form.
+func FormatCustomResourceReflector(gvr schema.GroupVersionResource, config *offloadingv1beta1.ReflectorConfig) string {
+	output := fmt.Sprintf("%s,workers=%d", CustomResourceKey(gvr), config.NumWorkers)
+	if config.Type != "" {
+		output += fmt.Sprintf(",type=%s", config.Type)
+	}
+	if config.ReflectStatus {
+		output += ",reflectStatus=true"
+	}
+	return output
+}
+
+// ParseCustomResourceReflector parses the textual representation of the configuration of a custom resource reflector.
+// Unspecified parameters are set to their default values.
+func ParseCustomResourceReflector(value string) (schema.GroupVersionResource, *offloadingv1beta1.ReflectorConfig, error) {
+	fields := strings.Split(value, ",")
+	gvr, ok := ParseCustomResourceKey(fields[0])
+	if !ok {
+		return gvr, nil, fmt.Errorf("invalid custom resource %q, expected in the <resource>.<version>.<group> form", fields[0])
+	}
+
+	config := &offloadingv1beta1.ReflectorConfig{NumWorkers: DefaultCustomResourceWorkers, Type: offloadingv1beta1.DenyList}
+	for _, field := range fields[1:] {
+		key, val, found := strings.Cut(field, "=")
+		if !found {
+			return gvr, nil, fmt.Errorf("invalid parameter %q for custom resource %q, expected in the key=value form", field, fields[0])
+		}
+
+		switch key {
+		case "workers":
+			workers, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return gvr, nil, fmt.Errorf("invalid number of workers %q for custom resource %q: %w", val, fields[0], err)
+			}
+			config.NumWorkers = uint(workers)
+		case "type":
+			config.Type = offloadingv1beta1.ReflectionType(val)
+			if config.Type != offloadingv1beta1.DenyList && config.Type != offloadingv1beta1.AllowList {
+				return gvr, nil, fmt.Errorf("reflection type %q is not valid for custom resource %q. Ammitted values: %q, %q",
+					val, fields[0], offloadingv1beta1.DenyList, offloadingv1beta1.AllowList)
+			}
+		case "reflectStatus":
+			reflectStatus, err := strconv.ParseBool(val)
+			if err != nil {
+				return gvr, nil, fmt.Errorf("invalid reflectStatus value %q for custom resource %q: %w", val, fields[0], err)
+			}
+			config.ReflectStatus = reflectStatus
+		default:
+			return gvr, nil, fmt.Errorf("unknown parameter %q for custom resource %q", key, fields[0])
+		}
+	}
+	return gvr, config, nil
+}
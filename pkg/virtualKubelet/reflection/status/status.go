@@ -0,0 +1,133 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/leaderelection"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/metrics"
+)
+
+// Func is the function returning the current status of the reflectors.
+type Func func() []offloadingv1beta1.ReflectorStatus
+
+// Publisher periodically publishes the status of the reflectors in the corresponding VirtualNode and as Prometheus metrics.
+type Publisher struct {
+	client      client.Client
+	virtualNode types.NamespacedName
+	status      Func
+	period      time.Duration
+
+	// last is the last status successfully published, to avoid unnecessary updates.
+	last []offloadingv1beta1.ReflectorStatus
+}
+
+// NewPublisher returns a new Publisher instance, publishing the status at most once every period.
+func NewPublisher(cl client.Client, virtualNode types.NamespacedName, status Func, period time.Duration) *Publisher {
+	return &Publisher{
+		client:      cl,
+		virtualNode: virtualNode,
+		status:      status,
+		period:      period,
+	}
+}
+
+// Start starts the periodic publication of the reflection status, until the context is canceled.
+func (p *Publisher) Start(ctx context.Context) {
+	klog.Infof("Starting the publication of the reflection status every %v", p.period)
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.Publish(ctx); err != nil {
+			klog.Errorf("Failed to publish the reflection status to VirtualNode %q: %v", p.virtualNode, err)
+		}
+	}, p.period)
+}
+
+// Publish publishes the current reflection status, in case the current virtual kubelet is the leader.
+// The VirtualNode is patched only in case the status changed since the last publication.
+func (p *Publisher) Publish(ctx context.Context) error {
+	// Only the leader reflects the objects, hence it is the only one owning meaningful statistics.
+	if !leaderelection.IsLeader() {
+		return nil
+	}
+
+	return p.publish(ctx)
+}
+
+// publish publishes the current reflection status, regardless of the leader election.
+func (p *Publisher) publish(ctx context.Context) error {
+	reflectors := p.status()
+	updateMetrics(reflectors)
+
+	if p.last != nil && equality.Semantic.DeepEqual(p.last, reflectors) {
+		return nil
+	}
+
+	// A merge patch is used to replace the reflection status only, without interfering with the conditions.
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"reflection": offloadingv1beta1.ReflectionStatus{LastUpdateTime: metav1.Now(), Reflectors: reflectors},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	vn := offloadingv1beta1.VirtualNode{ObjectMeta: metav1.ObjectMeta{Name: p.virtualNode.Name, Namespace: p.virtualNode.Namespace}}
+	if err := p.client.Status().Patch(ctx, &vn, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+
+	klog.V(4).Infof("Reflection status correctly published to VirtualNode %q", p.virtualNode)
+	p.last = reflectors
+	return nil
+}
+
+// updateMetrics updates the Prometheus metrics corresponding to the given reflection status.
+func updateMetrics(reflectors []offloadingv1beta1.ReflectorStatus) {
+	// Reset the metrics, to remove the entries corresponding to the namespaces no longer reflected.
+	metrics.ReflectionStatusGauge.Reset()
+
+	for i := range reflectors {
+		for j := range reflectors[i].Namespaces {
+			ns := &reflectors[i].Namespaces[j]
+			labels := func(state string) prometheus.Labels {
+				return prometheus.Labels{
+					"namespace":          ns.Namespace,
+					"reflector_resource": reflectors[i].Resource,
+					"cluster_id":         string(forge.RemoteCluster),
+					"node_name":          forge.LiqoNodeName,
+					"state":              state,
+				}
+			}
+
+			metrics.ReflectionStatusGauge.With(labels("reflected")).Set(float64(ns.Reflected))
+			metrics.ReflectionStatusGauge.With(labels("pending")).Set(float64(ns.Pending))
+			metrics.ReflectionStatusGauge.With(labels("failed")).Set(float64(ns.Failed))
+		}
+	}
+}
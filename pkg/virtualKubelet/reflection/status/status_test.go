@@ -0,0 +1,102 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+var _ = Describe("Publisher tests", func() {
+	var (
+		ctx        context.Context
+		cl         client.Client
+		publisher  *Publisher
+		reflectors []offloadingv1beta1.ReflectorStatus
+		key        types.NamespacedName
+		err        error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Name: "virtual-node", Namespace: "tenant-namespace"}
+
+		scheme := runtime.NewScheme()
+		Expect(offloadingv1beta1.AddToScheme(scheme)).To(Succeed())
+
+		vn := &offloadingv1beta1.VirtualNode{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Status: offloadingv1beta1.VirtualNodeStatus{Conditions: []offloadingv1beta1.VirtualNodeCondition{{
+				Type: offloadingv1beta1.VirtualKubeletConditionType, Status: offloadingv1beta1.RunningConditionStatusType,
+			}}},
+		}
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(vn).WithStatusSubresource(vn).Build()
+
+		reflectors = []offloadingv1beta1.ReflectorStatus{{
+			Resource: "Secret",
+			Namespaces: []offloadingv1beta1.ReflectorNamespaceStatus{
+				{Namespace: "foo", Reflected: 3, Pending: 1, Failed: 1, LastError: "forbidden"},
+			},
+		}}
+		publisher = NewPublisher(cl, key, func() []offloadingv1beta1.ReflectorStatus { return reflectors }, time.Second)
+	})
+
+	getVirtualNode := func() *offloadingv1beta1.VirtualNode {
+		var vn offloadingv1beta1.VirtualNode
+		Expect(cl.Get(ctx, key, &vn)).To(Succeed())
+		return &vn
+	}
+
+	Context("the status is published", func() {
+		JustBeforeEach(func() { err = publisher.publish(ctx) })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should set the reflection status of the VirtualNode", func() {
+			vn := getVirtualNode()
+			Expect(vn.Status.Reflection).ToNot(BeNil())
+			Expect(vn.Status.Reflection.Reflectors).To(Equal(reflectors))
+			Expect(vn.Status.Reflection.LastUpdateTime.IsZero()).To(BeFalse())
+		})
+		It("should preserve the existing conditions", func() {
+			Expect(getVirtualNode().Status.Conditions).To(HaveLen(1))
+		})
+
+		When("the status did not change", func() {
+			It("should not update the VirtualNode again", func() {
+				before := getVirtualNode().ResourceVersion
+				Expect(publisher.publish(ctx)).To(Succeed())
+				Expect(getVirtualNode().ResourceVersion).To(Equal(before))
+			})
+		})
+
+		When("the status changed", func() {
+			It("should update the VirtualNode", func() {
+				reflectors = []offloadingv1beta1.ReflectorStatus{{Resource: "Secret"}}
+				Expect(publisher.publish(ctx)).To(Succeed())
+				Expect(getVirtualNode().Status.Reflection.Reflectors).To(Equal(reflectors))
+			})
+		})
+	})
+})
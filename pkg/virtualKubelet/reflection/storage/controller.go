@@ -27,6 +27,8 @@ import (
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/util"
+
+	"github.com/liqotech/liqo/pkg/consts"
 )
 
 const (
@@ -84,8 +86,10 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) shouldProvision(claim *co
 	return false, nil
 }
 
+// knownProvisioner returns whether the given provisioner corresponds to the liqo one, which is named after
+// the CSIDriver in case the capacity tracking is enabled.
 func (npvcr *NamespacedPersistentVolumeClaimReflector) knownProvisioner(provisioner string) bool {
-	return provisioner == npvcr.provisionerName
+	return provisioner == npvcr.provisionerName || provisioner == consts.StorageCSIDriverName
 }
 
 type provisionFunc func(context.Context, controller.ProvisionOptions) (*corev1.PersistentVolume, controller.ProvisioningState, error)
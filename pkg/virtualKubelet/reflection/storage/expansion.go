@@ -0,0 +1,106 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/offloading/storageprovisioner"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+const (
+	eventVolumeResizing     = "Resizing"
+	eventVolumeResizeFailed = "VolumeResizeFailed"
+)
+
+// handleExpansion propagates the expansion of a bound local PersistentVolumeClaim to the remote one,
+// and reflects the resulting capacity and resize conditions back to the local PersistentVolumeClaim and PersistentVolume.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) handleExpansion(ctx context.Context,
+	local, remote *corev1.PersistentVolumeClaim) error {
+	if liqostorageprovisioner.ResizeRequested(local, remote) {
+		err := liqostorageprovisioner.ExpandRemotePVC(ctx, local, remote,
+			npvcr.remoteStorageClassesClient, npvcr.remotePersistentVolumesClaimsClient, npvcr.ForgingOpts)
+		if err != nil {
+			klog.Errorf("Failed to expand the remote PersistentVolumeClaim %q: %v", npvcr.RemoteRef(remote.GetName()), err)
+			npvcr.Event(local, corev1.EventTypeWarning, eventVolumeResizeFailed, err.Error())
+			if errors.Is(err, liqostorageprovisioner.ErrExpansionNotSupported) {
+				// Retrying would not help, as the storage class cannot be changed.
+				return nil
+			}
+			return err
+		}
+
+		requested := local.Spec.Resources.Requests[corev1.ResourceStorage]
+		klog.Infof("Expanding remote PersistentVolumeClaim %q to %v", npvcr.RemoteRef(remote.GetName()), requested.String())
+		npvcr.Event(local, corev1.EventTypeNormal, eventVolumeResizing, "Expanding the remote volume to "+requested.String())
+		return nil
+	}
+
+	return npvcr.reflectResizeStatus(ctx, local, remote)
+}
+
+// reflectResizeStatus reflects the capacity and the resize conditions of the remote PersistentVolumeClaim
+// to the local PersistentVolumeClaim and PersistentVolume.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) reflectResizeStatus(ctx context.Context,
+	local, remote *corev1.PersistentVolumeClaim) error {
+	if err := npvcr.expandLocalVolume(ctx, local.Spec.VolumeName, remote); err != nil {
+		klog.Errorf("Failed to update the capacity of local PersistentVolume %q: %v", local.Spec.VolumeName, err)
+		return err
+	}
+
+	if status, changed := liqostorageprovisioner.ResizeStatus(local, remote); changed {
+		local.Status = *status
+		if _, err := npvcr.localPersistentVolumeClaimsClient.UpdateStatus(ctx, local, metav1.UpdateOptions{}); err != nil {
+			if !kerrors.IsConflict(err) {
+				npvcr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+			}
+			return err
+		}
+		klog.V(4).Infof("Reflected the resize status of remote PersistentVolumeClaim %q to local %q",
+			npvcr.RemoteRef(remote.GetName()), npvcr.LocalRef(local.GetName()))
+	}
+
+	return nil
+}
+
+// expandLocalVolume aligns the capacity of the local PersistentVolume to the one of the remote PersistentVolumeClaim.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) expandLocalVolume(ctx context.Context,
+	name string, remote *corev1.PersistentVolumeClaim) error {
+	capacity, found := remote.Status.Capacity[corev1.ResourceStorage]
+	if !found {
+		return nil
+	}
+
+	volume, err := npvcr.volumes.Get(name)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	volume = volume.DeepCopy()
+	if !liqostorageprovisioner.ExpandVolume(volume, capacity) {
+		return nil
+	}
+	_, err = npvcr.localPersistentVolumesClient.Update(ctx, volume, metav1.UpdateOptions{})
+	return err
+}
@@ -17,18 +17,22 @@ package storage
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	storagev1clients "k8s.io/client-go/kubernetes/typed/storage/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	storagev1listers "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/util"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	"github.com/liqotech/liqo/pkg/consts"
@@ -58,6 +62,7 @@ type NamespacedPersistentVolumeClaimReflector struct {
 	localPersistentVolumeClaims         corev1listers.PersistentVolumeClaimNamespaceLister
 	remotePersistentVolumeClaims        corev1listers.PersistentVolumeClaimNamespaceLister
 	remotePersistentVolumesClaimsClient corev1clients.PersistentVolumeClaimInterface
+	remoteStorageClassesClient          storagev1clients.StorageClassInterface
 	localPersistentVolumesClient        corev1clients.PersistentVolumeInterface
 	localPersistentVolumeClaimsClient   corev1clients.PersistentVolumeClaimInterface
 
@@ -66,19 +71,20 @@ type NamespacedPersistentVolumeClaimReflector struct {
 
 	virtualStorageClassName    string
 	remoteRealStorageClassName string
+	remoteStorageClasses       []string
 }
 
 // NewPersistentVolumeClaimReflector returns a new PersistentVolumeClaimReflector instance.
-func NewPersistentVolumeClaimReflector(virtualStorageClassName, remoteRealStorageClassName string,
+func NewPersistentVolumeClaimReflector(virtualStorageClassName, remoteRealStorageClassName string, remoteStorageClasses []string,
 	storageEnabled bool, reflectorConfig *offloadingv1beta1.ReflectorConfig) manager.Reflector {
 	return generic.NewReflector(PersistentVolumeClaimReflectorName,
-		NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName, remoteRealStorageClassName, storageEnabled),
+		NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName, remoteRealStorageClassName, remoteStorageClasses, storageEnabled),
 		generic.WithoutFallback(), reflectorConfig.NumWorkers, offloadingv1beta1.CustomLiqo, generic.ConcurrencyModeLeader)
 }
 
 // NewNamespacedPersistentVolumeClaimReflector returns a function generating NamespacedPersistentVolumeClaimReflector instances.
-func NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName,
-	remoteRealStorageClassName string, storageEnabled bool) func(*options.NamespacedOpts) manager.NamespacedReflector {
+func NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName, remoteRealStorageClassName string,
+	remoteStorageClasses []string, storageEnabled bool) func(*options.NamespacedOpts) manager.NamespacedReflector {
 	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
 		local := opts.LocalFactory.Core().V1().PersistentVolumeClaims()
 		remote := opts.RemoteFactory.Core().V1().PersistentVolumeClaims()
@@ -98,6 +104,7 @@ func NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName,
 			localPersistentVolumeClaims:         local.Lister().PersistentVolumeClaims(opts.LocalNamespace),
 			remotePersistentVolumeClaims:        remote.Lister().PersistentVolumeClaims(opts.RemoteNamespace),
 			remotePersistentVolumesClaimsClient: opts.RemoteClient.CoreV1().PersistentVolumeClaims(opts.RemoteNamespace),
+			remoteStorageClassesClient:          opts.RemoteClient.StorageV1().StorageClasses(),
 			localPersistentVolumesClient:        opts.LocalClient.CoreV1().PersistentVolumes(),
 			localPersistentVolumeClaimsClient:   opts.LocalClient.CoreV1().PersistentVolumeClaims(opts.LocalNamespace),
 
@@ -106,6 +113,7 @@ func NewNamespacedPersistentVolumeClaimReflector(virtualStorageClassName,
 
 			virtualStorageClassName:    virtualStorageClassName,
 			remoteRealStorageClassName: remoteRealStorageClassName,
+			remoteStorageClasses:       remoteStorageClasses,
 		}
 	}
 }
@@ -122,8 +130,11 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) Handle(ctx context.Contex
 	utilruntime.Must(client.IgnoreNotFound(rerr))
 	tracer.Step("Retrieved the local and remote objects")
 
+	// The remote counterparts of generic ephemeral volumes are created by the remote cluster along with the offloaded pod.
+	ephemeral := lerr == nil && rerr == nil && forge.IsEphemeralPersistentVolumeClaim(local) && forge.IsEphemeralPersistentVolumeClaim(remote)
+
 	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
-	if rerr == nil && !forge.IsReflected(remote) {
+	if rerr == nil && !forge.IsReflected(remote) && !ephemeral {
 		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
 			klog.Infof("Skipping reflection of local PersistentVolumeClaim %q as remote already exists and is not managed by us", npvcr.LocalRef(name))
 			npvcr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
@@ -147,6 +158,19 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) Handle(ctx context.Contex
 	// DeepCopy the local object to allow modifications.
 	local = local.DeepCopy()
 
+	// The remote ephemeral volume has been created for the offloaded pod. Reflect its status for visibility,
+	// while expansions are not propagated, as the remote object is managed by the remote cluster.
+	if ephemeral && local.Spec.VolumeName != "" {
+		defer tracer.Step("Reflected the status of the remote ephemeral object")
+		return npvcr.reflectResizeStatus(ctx, local, remote)
+	}
+
+	// The volume has already been provisioned in the remote cluster. Propagate possible expansions.
+	if local.Spec.VolumeName != "" && rerr == nil && util.GetPersistentVolumeClaimClass(local) == npvcr.virtualStorageClassName {
+		defer tracer.Step("Ensured the expansion of the remote object")
+		return npvcr.handleExpansion(ctx, local, remote)
+	}
+
 	// Check if we should provision storage for that PVC. We have to check if no volume is already provisioned and the storage class is the expected one.
 	if should, err := npvcr.shouldProvision(local); err != nil {
 		klog.V(4).Infof("Error checking if should provision a local PersistentVolumeClaim %q: %v", npvcr.LocalRef(name), err.Error())
@@ -180,10 +204,22 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) Handle(ctx context.Contex
 				return nil, controller.ProvisioningFinished, &controller.IgnoredError{Reason: "this provisioner is not provisioning storage on that node"}
 			}
 
-			pv, state, err := liqostorageprovisioner.ProvisionRemotePVC(ctx,
-				options, npvcr.RemoteNamespace(), npvcr.remoteRealStorageClassName,
-				npvcr.remotePersistentVolumeClaims, npvcr.remotePersistentVolumesClaimsClient,
-				npvcr.ForgingOpts)
+			remoteStorageClass, err := npvcr.remoteStorageClass(options.PVC)
+			if err != nil {
+				return nil, controller.ProvisioningFinished, err
+			}
+
+			var pv *corev1.PersistentVolume
+			var state controller.ProvisioningState
+			if forge.IsEphemeralPersistentVolumeClaim(options.PVC) {
+				// The remote PVC is created by the remote cluster, according to the claim template of the offloaded pod.
+				pv, state, err = liqostorageprovisioner.ProvisionEphemeralPVC(options)
+			} else {
+				pv, state, err = liqostorageprovisioner.ProvisionRemotePVC(ctx,
+					options, npvcr.RemoteNamespace(), remoteStorageClass,
+					npvcr.remotePersistentVolumeClaims, npvcr.remotePersistentVolumesClaimsClient,
+					npvcr.ForgingOpts)
+			}
 			if err == nil && state == controller.ProvisioningFinished {
 				local.Spec.VolumeName = options.PVName
 			}
@@ -215,6 +251,21 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) Handle(ctx context.Contex
 	}
 }
 
+// remoteStorageClass returns the storage class of the remote PersistentVolumeClaim, which can be selected through the
+// dedicated annotation among the ones offered by the remote cluster. The remote real storage class is used otherwise.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) remoteStorageClass(claim *corev1.PersistentVolumeClaim) (string, error) {
+	class, found := claim.GetAnnotations()[consts.RemoteStorageClassAnnotation]
+	if !found || class == "" {
+		return npvcr.remoteRealStorageClassName, nil
+	}
+
+	if !slices.Contains(npvcr.remoteStorageClasses, class) {
+		return "", fmt.Errorf("storage class %q is not offered by the remote cluster (available: [%s])",
+			class, strings.Join(npvcr.remoteStorageClasses, ", "))
+	}
+	return class, nil
+}
+
 // List lists all PersistentVolumeClaims in the local cluster.
 func (npvcr *NamespacedPersistentVolumeClaimReflector) List() ([]interface{}, error) {
 	return virtualkubelet.List[virtualkubelet.Lister[*corev1.PersistentVolumeClaim], *corev1.PersistentVolumeClaim](
@@ -19,8 +19,11 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
+	"github.com/liqotech/liqo/pkg/consts"
 	. "github.com/liqotech/liqo/pkg/utils/testutil"
 )
 
@@ -106,6 +109,121 @@ var _ = Describe("reflector methods", func() {
 				})
 			})
 
+			When("the PVC backs a generic ephemeral volume", func() {
+
+				var (
+					name         string
+					localPvc     *corev1.PersistentVolumeClaim
+					ephemeralPvc func(namespace string) *corev1.PersistentVolumeClaim
+				)
+
+				BeforeEach(func() {
+					ephemeralPvc = func(namespace string) *corev1.PersistentVolumeClaim {
+						return &corev1.PersistentVolumeClaim{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      name,
+								Namespace: namespace,
+								Annotations: map[string]string{
+									annStorageProvisioner: consts.StorageProvisionerName,
+									annSelectedNode:       VirtualNodeName,
+								},
+								OwnerReferences: []metav1.OwnerReference{{
+									APIVersion: "v1", Kind: "Pod", Name: "pod", UID: "pod-uid", Controller: ptr.To(true),
+								}},
+							},
+							Spec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: ptr.To(VirtualStorageClassName),
+								AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+								Resources: corev1.VolumeResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+								},
+							},
+						}
+					}
+				})
+
+				JustBeforeEach(func() {
+					err = reflector.Handle(ctx, name)
+				})
+
+				When("the local PVC is not bound", func() {
+					BeforeEach(func() {
+						name = "pod-data"
+						checkErrIgnoreAlreadyExists(k8sClient.CoreV1().PersistentVolumeClaims(LocalNamespace).
+							Create(ctx, ephemeralPvc(LocalNamespace), metav1.CreateOptions{}))
+					})
+
+					It("should not create the remote PVC", func() {
+						Expect(err).ToNot(HaveOccurred())
+
+						_, err = k8sClient.CoreV1().PersistentVolumeClaims(RemoteNamespace).Get(ctx, name, metav1.GetOptions{})
+						Expect(err).To(BeNotFound())
+					})
+
+					It("should bind the local PVC to a virtual PV", func() {
+						Expect(err).ToNot(HaveOccurred())
+
+						localPvc, err = k8sClient.CoreV1().PersistentVolumeClaims(LocalNamespace).Get(ctx, name, metav1.GetOptions{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(localPvc.Spec.VolumeName).ToNot(BeEmpty())
+
+						localPv, err := k8sClient.CoreV1().PersistentVolumes().Get(ctx, localPvc.Spec.VolumeName, metav1.GetOptions{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(localPv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values).To(ConsistOf(RemoteClusterID))
+					})
+				})
+
+				When("the remote PVC has been created by the remote cluster", func() {
+					BeforeEach(func() {
+						name = "pod-cache"
+
+						pv := &corev1.PersistentVolume{
+							ObjectMeta: metav1.ObjectMeta{Name: "pv-pod-cache"},
+							Spec: corev1.PersistentVolumeSpec{
+								StorageClassName:       VirtualStorageClassName,
+								AccessModes:            []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+								Capacity:               corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+								PersistentVolumeSource: corev1.PersistentVolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/tmp"}},
+							},
+						}
+						checkErrIgnoreAlreadyExists(k8sClient.CoreV1().PersistentVolumes().Create(ctx, pv, metav1.CreateOptions{}))
+
+						local := ephemeralPvc(LocalNamespace)
+						local.Spec.VolumeName = pv.Name
+						checkErrIgnoreAlreadyExists(k8sClient.CoreV1().PersistentVolumeClaims(LocalNamespace).Create(ctx, local, metav1.CreateOptions{}))
+
+						remote := ephemeralPvc(RemoteNamespace)
+						remote.Spec.StorageClassName = ptr.To(RealRemoteStorageClassName)
+						remote, err = k8sClient.CoreV1().PersistentVolumeClaims(RemoteNamespace).Create(ctx, remote, metav1.CreateOptions{})
+						if err == nil {
+							remote.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}
+							checkErr(k8sClient.CoreV1().PersistentVolumeClaims(RemoteNamespace).UpdateStatus(ctx, remote, metav1.UpdateOptions{}))
+						}
+					})
+
+					It("should not mutate the remote PVC", func() {
+						Expect(err).ToNot(HaveOccurred())
+
+						remote, err := k8sClient.CoreV1().PersistentVolumeClaims(RemoteNamespace).Get(ctx, name, metav1.GetOptions{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(remote.Labels).To(BeEmpty())
+						Expect(remote.Spec.StorageClassName).To(PointTo(Equal(RealRemoteStorageClassName)))
+					})
+
+					It("should reflect the capacity to the local PVC and PV", func() {
+						Expect(err).ToNot(HaveOccurred())
+
+						localPvc, err = k8sClient.CoreV1().PersistentVolumeClaims(LocalNamespace).Get(ctx, name, metav1.GetOptions{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(localPvc.Status.Capacity.Storage().String()).To(Equal("2Gi"))
+
+						localPv, err := k8sClient.CoreV1().PersistentVolumes().Get(ctx, localPvc.Spec.VolumeName, metav1.GetOptions{})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(localPv.Spec.Capacity.Storage().String()).To(Equal("2Gi"))
+					})
+				})
+			})
+
 			When("the PVC is not on the virtual node", func() {
 				JustBeforeEach(func() {
 					// the function has to succeed, we have not to reenqueue this item
@@ -124,4 +242,41 @@ var _ = Describe("reflector methods", func() {
 
 	})
 
+	Context("remoteStorageClass method", func() {
+
+		type remoteStorageClassTestcase struct {
+			annotations   map[string]string
+			expectedClass string
+			expectedErr   OmegaMatcher
+		}
+
+		DescribeTable("remoteStorageClass table",
+			func(c remoteStorageClassTestcase) {
+				class, err := reflector.remoteStorageClass(&corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+				})
+				Expect(err).To(c.expectedErr)
+				Expect(class).To(Equal(c.expectedClass))
+			},
+
+			Entry("no annotation", remoteStorageClassTestcase{
+				expectedClass: RealRemoteStorageClassName,
+				expectedErr:   Not(HaveOccurred()),
+			}),
+
+			Entry("offered storage class", remoteStorageClassTestcase{
+				annotations:   map[string]string{consts.RemoteStorageClassAnnotation: FastRemoteStorageClassName},
+				expectedClass: FastRemoteStorageClassName,
+				expectedErr:   Not(HaveOccurred()),
+			}),
+
+			Entry("storage class not offered by the remote cluster", remoteStorageClassTestcase{
+				annotations:   map[string]string{consts.RemoteStorageClassAnnotation: "not-offered"},
+				expectedClass: "",
+				expectedErr:   HaveOccurred(),
+			}),
+		)
+
+	})
+
 })
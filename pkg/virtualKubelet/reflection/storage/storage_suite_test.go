@@ -51,6 +51,7 @@ const (
 
 	VirtualStorageClassName    = "liqo"
 	RealRemoteStorageClassName = "other-class"
+	FastRemoteStorageClassName = "fast"
 
 	VirtualNodeName = "liqo-node"
 	RealNodeName    = "real-node"
@@ -209,7 +210,7 @@ var _ = BeforeEach(func() {
 	forge.Init(LocalClusterID, RemoteClusterID, virtualNode.Name, "127.0.0.1")
 
 	reflectorBuilder = NewNamespacedPersistentVolumeClaimReflector(VirtualStorageClassName,
-		RealRemoteStorageClassName, true)
+		RealRemoteStorageClassName, []string{RealRemoteStorageClassName, FastRemoteStorageClassName}, true)
 	factory = informers.NewSharedInformerFactory(k8sClient, 10*time.Hour)
 })
 
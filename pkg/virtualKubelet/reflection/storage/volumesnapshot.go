@@ -0,0 +1,249 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+const (
+	// VolumeSnapshotReflectorName -> The name associated with the VolumeSnapshot reflector.
+	VolumeSnapshotReflectorName = "VolumeSnapshot"
+)
+
+var _ manager.NamespacedReflector = (*NamespacedVolumeSnapshotReflector)(nil)
+
+// NamespacedVolumeSnapshotReflector manages the VolumeSnapshot reflection for a given pair of local and remote namespaces.
+// Only the snapshots of the virtual PersistentVolumeClaims provisioned in the remote cluster are reflected, so that the
+// actual snapshot is taken by the remote cluster, while its readiness and size are reflected back to the VolumeSnapshotContent
+// pre-bound to the local one, from which the snapshot controller propagates them to the local snapshot itself.
+type NamespacedVolumeSnapshotReflector struct {
+	generic.NamespacedReflector
+
+	localSnapshots               cache.GenericNamespaceLister
+	remoteSnapshots              cache.GenericNamespaceLister
+	remoteSnapshotsClient        dynamic.ResourceInterface
+	localContentsClient          dynamic.ResourceInterface
+	remotePersistentVolumeClaims corev1listers.PersistentVolumeClaimNamespaceLister
+
+	virtualSnapshotClassName string
+}
+
+// NewVolumeSnapshotReflector returns a new VolumeSnapshotReflector instance, handling the snapshots of the given virtual class.
+func NewVolumeSnapshotReflector(virtualSnapshotClassName string, reflectorConfig *offloadingv1beta1.ReflectorConfig) manager.Reflector {
+	return generic.NewReflector(VolumeSnapshotReflectorName, NewNamespacedVolumeSnapshotReflector(virtualSnapshotClassName),
+		generic.WithoutFallback(), reflectorConfig.NumWorkers, offloadingv1beta1.CustomLiqo, generic.ConcurrencyModeLeader)
+}
+
+// NewNamespacedVolumeSnapshotReflector returns a function generating NamespacedVolumeSnapshotReflector instances.
+func NewNamespacedVolumeSnapshotReflector(virtualSnapshotClassName string) generic.NamespacedReflectorFactoryFunc {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		local := opts.LocalDynamicFactory.ForResource(forge.VolumeSnapshotGVR)
+		remote := opts.RemoteDynamicFactory.ForResource(forge.VolumeSnapshotGVR)
+		remotePvcs := opts.RemoteFactory.Core().V1().PersistentVolumeClaims()
+
+		_, err := local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+		_, err = remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+		utilruntime.Must(err)
+
+		return &NamespacedVolumeSnapshotReflector{
+			NamespacedReflector: generic.NewNamespacedReflector(opts, VolumeSnapshotReflectorName),
+
+			localSnapshots:               local.Lister().ByNamespace(opts.LocalNamespace),
+			remoteSnapshots:              remote.Lister().ByNamespace(opts.RemoteNamespace),
+			remoteSnapshotsClient:        opts.RemoteDynamicClient.Resource(forge.VolumeSnapshotGVR).Namespace(opts.RemoteNamespace),
+			localContentsClient:          opts.LocalDynamicClient.Resource(forge.VolumeSnapshotContentGVR),
+			remotePersistentVolumeClaims: remotePvcs.Lister().PersistentVolumeClaims(opts.RemoteNamespace),
+
+			virtualSnapshotClassName: virtualSnapshotClassName,
+		}
+	}
+}
+
+// Handle reconciles VolumeSnapshot objects.
+func (nvsr *NamespacedVolumeSnapshotReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local VolumeSnapshot %q (remote: %q)", nvsr.LocalRef(name), nvsr.RemoteRef(name))
+	local, lerr := nvsr.get(nvsr.localSnapshots, name)
+	if lerr != nil && !kerrors.IsNotFound(lerr) {
+		return lerr
+	}
+	remote, rerr := nvsr.get(nvsr.remoteSnapshots, name)
+	if rerr != nil && !kerrors.IsNotFound(rerr) {
+		return rerr
+	}
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local VolumeSnapshot %q as remote already exists and is not managed by us", nvsr.LocalRef(name))
+			nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+	tracer.Step("Performed the sanity checks")
+
+	// The local VolumeSnapshot does no longer exist. Ensure it is also absent from the remote cluster.
+	if kerrors.IsNotFound(lerr) {
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote VolumeSnapshot %q, since local %q does no longer exist", nvsr.RemoteRef(name), nvsr.LocalRef(name))
+			return nvsr.DeleteRemote(ctx, snapshotDeleter{nvsr.remoteSnapshotsClient}, VolumeSnapshotReflectorName, name, remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local VolumeSnapshot %q and remote VolumeSnapshot %q both vanished", nvsr.LocalRef(name), nvsr.RemoteRef(name))
+		return nil
+	}
+
+	// Skip the snapshots not targeting a virtual PersistentVolumeClaim provisioned in the remote cluster.
+	claim, found := forge.VolumeSnapshotSource(local)
+	if !found || forge.VolumeSnapshotClassName(local) != nvsr.virtualSnapshotClassName || !nvsr.isRemoteClaim(claim) {
+		klog.V(4).Infof("Skipping local VolumeSnapshot %q, since not referring to a remote volume", nvsr.LocalRef(name))
+		return nil
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteVolumeSnapshot(local, nvsr.RemoteNamespace(), claim, "", nvsr.ForgingOpts)
+	tracer.Step("Remote mutation created")
+
+	if _, err := nvsr.remoteSnapshotsClient.Apply(ctx, name, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote VolumeSnapshot %q (local: %q): %v", nvsr.RemoteRef(name), nvsr.LocalRef(name), err)
+		nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the remote object")
+	klog.Infof("Remote VolumeSnapshot %q successfully enforced (local: %q)", nvsr.RemoteRef(name), nvsr.LocalRef(name))
+
+	content, err := nvsr.ensureContent(ctx, local)
+	if err != nil {
+		klog.Errorf("Failed to ensure the VolumeSnapshotContent of local VolumeSnapshot %q: %v", nvsr.LocalRef(name), err)
+		nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Ensured the existence of the local content")
+
+	// Reflect the readiness and the size of the remote snapshot back to the content bound to the local one.
+	if rerr == nil && content != nil {
+		defer tracer.Step("Reflected the status of the remote object")
+		if updated, changed := forge.VirtualVolumeSnapshotContentStatus(content, remote, nvsr.RemoteRef(name).String()); changed {
+			if _, err := nvsr.localContentsClient.UpdateStatus(ctx, updated, metav1.UpdateOptions{FieldManager: forge.ReflectionFieldManager}); err != nil {
+				klog.Errorf("Failed to update the status of VolumeSnapshotContent %q (remote: %q): %v", content.GetName(), nvsr.RemoteRef(name), err)
+				nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+				return err
+			}
+			klog.Infof("Status of VolumeSnapshotContent %q successfully updated (remote: %q)", content.GetName(), nvsr.RemoteRef(name))
+		}
+	}
+
+	nvsr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	return nil
+}
+
+// List returns the list of objects.
+func (nvsr *NamespacedVolumeSnapshotReflector) List() ([]interface{}, error) {
+	var keys []interface{}
+	for _, lister := range []cache.GenericNamespaceLister{nvsr.localSnapshots, nvsr.remoteSnapshots} {
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for i := range objs {
+			obj, ok := objs[i].(metav1.Object)
+			if !ok {
+				continue
+			}
+			keys = append(keys, types.NamespacedName{Namespace: nvsr.LocalNamespace(), Name: obj.GetName()})
+		}
+	}
+	return keys, nil
+}
+
+// ensureContent ensures the existence of the VolumeSnapshotContent pre-bound to the given local snapshot, and returns it.
+// Nil is returned in case the content has been created by a different driver, hence it shall not be managed by Liqo.
+func (nvsr *NamespacedVolumeSnapshotReflector) ensureContent(ctx context.Context,
+	local *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	content, err := nvsr.localContentsClient.Get(ctx, forge.VolumeSnapshotContentName(local), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		content = forge.VirtualVolumeSnapshotContent(local, consts.StorageProvisionerName)
+		if content, err = nvsr.localContentsClient.Create(ctx, content, metav1.CreateOptions{FieldManager: forge.ReflectionFieldManager}); err != nil {
+			return nil, err
+		}
+		klog.Infof("Created VolumeSnapshotContent %q bound to local VolumeSnapshot %q", content.GetName(), klog.KObj(local))
+		return content, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if forge.VolumeSnapshotContentDriver(content) != consts.StorageProvisionerName {
+		klog.Warningf("VolumeSnapshotContent %q bound to local VolumeSnapshot %q refers to a different driver", content.GetName(), klog.KObj(local))
+		return nil, nil
+	}
+	return content, nil
+}
+
+// isRemoteClaim returns whether the given PersistentVolumeClaim has been provisioned in the remote cluster.
+func (nvsr *NamespacedVolumeSnapshotReflector) isRemoteClaim(name string) bool {
+	claim, err := nvsr.remotePersistentVolumeClaims.Get(name)
+	return err == nil && forge.IsReflected(claim)
+}
+
+// get retrieves the given object from the lister, converting it to the unstructured representation.
+func (nvsr *NamespacedVolumeSnapshotReflector) get(lister cache.GenericNamespaceLister, name string) (*unstructured.Unstructured, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, kerrors.NewInternalError(fmt.Errorf("unexpected type %T for VolumeSnapshot %q", obj, name))
+	}
+	return u, nil
+}
+
+// snapshotDeleter adapts a dynamic.ResourceInterface to the generic.ResourceDeleter interface.
+type snapshotDeleter struct {
+	dynamic.ResourceInterface
+}
+
+// Delete deletes the object with the given name.
+func (d snapshotDeleter) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return d.ResourceInterface.Delete(ctx, name, opts)
+}
@@ -97,6 +97,15 @@ type PodReflectorConfig struct {
 
 	KubernetesServiceIPMapper func(context.Context) (string, error)
 	NetConfiguration          *networkingv1beta1.Configuration
+
+	// ClusterScopedReflection contains the kinds of the cluster-scoped resources reflected towards the remote cluster,
+	// to propagate the corresponding references (e.g., the priority class) to the remote pods.
+	ClusterScopedReflection []offloadingv1beta1.ClusterScopedResourceKind
+
+	// VirtualStorageClassName and RemoteRealStorageClassName are used to translate the claim templates
+	// of generic ephemeral volumes, so that the corresponding PVCs are created by the remote cluster.
+	VirtualStorageClassName    string
+	RemoteRealStorageClassName string
 }
 
 // FallbackPodReflector handles the "orphan" pods outside the managed namespaces.
@@ -0,0 +1,234 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	policyv1clients "k8s.io/client-go/kubernetes/typed/policy/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	policyv1listers "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/utils/virtualkubelet"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ manager.Reflector = (*PodDisruptionBudgetReflector)(nil)
+
+const (
+	// PodDisruptionBudgetReflectorName is the name associated with the PodDisruptionBudget reflector.
+	PodDisruptionBudgetReflectorName = "PodDisruptionBudget"
+)
+
+// PodDisruptionBudgetReflector manages the PodDisruptionBudget reflection towards a remote cluster.
+type PodDisruptionBudgetReflector struct {
+	manager.Reflector
+
+	localPods corev1listers.PodLister
+	localPDBs sync.Map /* implicit signature: map[string]policyv1listers.PodDisruptionBudgetNamespaceLister */
+}
+
+// NamespacedPodDisruptionBudgetReflector manages the PodDisruptionBudget reflection for a given pair of local and remote namespaces.
+type NamespacedPodDisruptionBudgetReflector struct {
+	generic.NamespacedReflector
+
+	localPods        corev1listers.PodNamespaceLister
+	localPDBs        policyv1listers.PodDisruptionBudgetNamespaceLister
+	remotePDBs       policyv1listers.PodDisruptionBudgetNamespaceLister
+	remotePDBsClient policyv1clients.PodDisruptionBudgetInterface
+}
+
+// NewPodDisruptionBudgetReflector builds a PodDisruptionBudgetReflector.
+func NewPodDisruptionBudgetReflector(reflectorConfig *offloadingv1beta1.ReflectorConfig) manager.Reflector {
+	reflector := &PodDisruptionBudgetReflector{}
+	genericReflector := generic.NewReflector(PodDisruptionBudgetReflectorName, reflector.NewNamespaced,
+		reflector.NewFallback, reflectorConfig.NumWorkers, reflectorConfig.Type, generic.ConcurrencyModeLeader)
+	reflector.Reflector = genericReflector
+	return reflector
+}
+
+// NewNamespaced returns a new NamespacedPodDisruptionBudgetReflector instance.
+func (pdbr *PodDisruptionBudgetReflector) NewNamespaced(opts *options.NamespacedOpts) manager.NamespacedReflector {
+	local := opts.LocalFactory.Policy().V1().PodDisruptionBudgets()
+	remote := opts.RemoteFactory.Policy().V1().PodDisruptionBudgets()
+
+	// Using opts.LocalNamespace for both event handlers so that the object will be put in the same workqueue
+	// no matter the cluster, hence it will be processed by the handle function in the same way.
+	_, err := local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	utilruntime.Must(err)
+	_, err = remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	utilruntime.Must(err)
+
+	localPDBs := local.Lister().PodDisruptionBudgets(opts.LocalNamespace)
+	pdbr.localPDBs.Store(opts.LocalNamespace, localPDBs)
+
+	return &NamespacedPodDisruptionBudgetReflector{
+		NamespacedReflector: generic.NewNamespacedReflector(opts, PodDisruptionBudgetReflectorName),
+		localPods:           pdbr.localPods.Pods(opts.LocalNamespace),
+		localPDBs:           localPDBs,
+		remotePDBs:          remote.Lister().PodDisruptionBudgets(opts.RemoteNamespace),
+		remotePDBsClient:    opts.RemoteClient.PolicyV1().PodDisruptionBudgets(opts.RemoteNamespace),
+	}
+}
+
+// NewFallback configures the handlers to enqueue the poddisruptionbudgets selecting the offloaded pods, whenever they change.
+// No actual fallback reflector is returned, since poddisruptionbudgets outside the offloaded namespaces do not need to be handled.
+func (pdbr *PodDisruptionBudgetReflector) NewFallback(opts *options.ReflectorOpts) manager.FallbackReflector {
+	_, err := opts.LocalPodInformer.Informer().AddEventHandler(opts.HandlerFactory(pdbr.PodKeyer))
+	utilruntime.Must(err)
+	return nil
+}
+
+// Start starts the reflector.
+func (pdbr *PodDisruptionBudgetReflector) Start(ctx context.Context, opts *options.ReflectorOpts) {
+	pdbr.localPods = opts.LocalPodInformer.Lister()
+	pdbr.Reflector.Start(ctx, opts)
+}
+
+// StopNamespace stops the reflection for a given namespace.
+func (pdbr *PodDisruptionBudgetReflector) StopNamespace(local, remote string) {
+	pdbr.localPDBs.Delete(local)
+	pdbr.Reflector.StopNamespace(local, remote)
+}
+
+// PodKeyer returns the keys of the poddisruptionbudgets selecting the given offloaded pod.
+func (pdbr *PodDisruptionBudgetReflector) PodKeyer(metadata metav1.Object) []types.NamespacedName {
+	lister, found := pdbr.localPDBs.Load(metadata.GetNamespace())
+	if !found {
+		return nil
+	}
+
+	pdbs, err := lister.(policyv1listers.PodDisruptionBudgetNamespaceLister).List(labels.Everything())
+	utilruntime.Must(err)
+
+	var keys []types.NamespacedName
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || pdb.Spec.Selector == nil || !selector.Matches(labels.Set(metadata.GetLabels())) {
+			continue
+		}
+		keys = append(keys, types.NamespacedName{Namespace: pdb.GetNamespace(), Name: pdb.GetName()})
+	}
+	return keys
+}
+
+// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
+func (npdbr *NamespacedPodDisruptionBudgetReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local PodDisruptionBudget %q (remote: %q)", npdbr.LocalRef(name), npdbr.RemoteRef(name))
+
+	local, lerr := npdbr.localPDBs.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	remote, rerr := npdbr.remotePDBs.Get(name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local PodDisruptionBudget %q as remote already exists and is not managed by us", npdbr.LocalRef(name))
+			npdbr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	if !kerrors.IsNotFound(lerr) {
+		skipReflection, err := npdbr.ShouldSkipReflection(local)
+		if err != nil {
+			klog.Errorf("Failed to check whether local PodDisruptionBudget %q should be reflected: %v", npdbr.LocalRef(name), err)
+			return err
+		}
+		if skipReflection {
+			if npdbr.GetReflectionType() == offloadingv1beta1.DenyList {
+				klog.Infof("Skipping reflection of local PodDisruptionBudget %q as marked with the skip annotation", npdbr.LocalRef(name))
+			} else { // AllowList
+				klog.Infof("Skipping reflection of local PodDisruptionBudget %q as not marked with the allow annotation", npdbr.LocalRef(name))
+			}
+			npdbr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg(npdbr.GetReflectionType()))
+			if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+				return nil
+			}
+
+			// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+			lerr = kerrors.NewNotFound(policyv1.Resource("poddisruptionbudget"), local.GetName())
+		}
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	if kerrors.IsNotFound(lerr) {
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote PodDisruptionBudget %q, since local %q does no longer exist", npdbr.RemoteRef(name), npdbr.LocalRef(name))
+			return npdbr.DeleteRemote(ctx, npdbr.remotePDBsClient, PodDisruptionBudgetReflectorName, name, remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local PodDisruptionBudget %q and remote PodDisruptionBudget %q both vanished", npdbr.LocalRef(name), npdbr.RemoteRef(name))
+		return nil
+	}
+
+	// Compute the share of the local budget which shall be enforced by the remote cluster.
+	offloaded, err := npdbr.localPods.List(labels.Everything())
+	utilruntime.Must(err)
+	healthy, err := forge.RemotePodDisruptionBudgetHealthyPods(local, offloaded)
+	if err != nil {
+		klog.Errorf("Failed to compute the remote share of local PodDisruptionBudget %q: %v", npdbr.LocalRef(name), err)
+		npdbr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemotePodDisruptionBudget(local, forge.RemotePodDisruptionBudgetMinAvailable(local, healthy),
+		npdbr.RemoteNamespace(), npdbr.ForgingOpts)
+	tracer.Step("Remote mutation created")
+
+	defer tracer.Step("Enforced the correctness of the remote object")
+	if _, err := npdbr.remotePDBsClient.Apply(ctx, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote PodDisruptionBudget %q (local: %q): %v", npdbr.RemoteRef(name), npdbr.LocalRef(name), err)
+		npdbr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+
+	klog.Infof("Remote PodDisruptionBudget %q successfully enforced (local: %q)", npdbr.RemoteRef(name), npdbr.LocalRef(name))
+	npdbr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+
+	return nil
+}
+
+// List returns the list of objects.
+func (npdbr *NamespacedPodDisruptionBudgetReflector) List() ([]interface{}, error) {
+	return virtualkubelet.List[virtualkubelet.Lister[*policyv1.PodDisruptionBudget], *policyv1.PodDisruptionBudget](
+		npdbr.localPDBs,
+		npdbr.remotePDBs,
+	)
+}
@@ -0,0 +1,183 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"k8s.io/utils/trace"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/cmd/virtual-kubelet/root"
+	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/resources"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/workload"
+)
+
+var _ = Describe("PodDisruptionBudget Reflection Tests", func() {
+	const PDBName = "name"
+
+	var (
+		rfl          *workload.PodDisruptionBudgetReflector
+		reflector    manager.NamespacedReflector
+		localClient  *fake.Clientset
+		remoteClient *fake.Clientset
+		local        policyv1.PodDisruptionBudget
+		err          error
+	)
+
+	OffloadedPod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: LocalNamespace, Labels: map[string]string{"app": "quorum"}},
+			Spec:       corev1.PodSpec{NodeName: LiqoNodeName},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+		}
+	}
+
+	GetRemotePDB := func() (*policyv1.PodDisruptionBudget, error) {
+		return remoteClient.PolicyV1().PodDisruptionBudgets(RemoteNamespace).Get(ctx, PDBName, metav1.GetOptions{})
+	}
+
+	BeforeEach(func() {
+		localClient = fake.NewClientset(OffloadedPod("ready-1", corev1.ConditionTrue),
+			OffloadedPod("ready-2", corev1.ConditionTrue), OffloadedPod("not-ready", corev1.ConditionFalse))
+		remoteClient = fake.NewClientset()
+
+		local = policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: PDBName, Namespace: LocalNamespace, Generation: 1},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: ptr.To(intstr.FromInt32(3)),
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "quorum"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{ObservedGeneration: 1, DisruptionsAllowed: 1},
+		}
+	})
+
+	JustBeforeEach(func() {
+		localFactory := informers.NewSharedInformerFactory(localClient, 10*time.Hour)
+		remoteFactory := informers.NewSharedInformerFactory(remoteClient, 10*time.Hour)
+
+		reflectorConfig := offloadingv1beta1.ReflectorConfig{
+			NumWorkers: 0,
+			Type:       root.DefaultReflectorsTypes[resources.PodDisruptionBudget],
+		}
+		rfl = workload.NewPodDisruptionBudgetReflector(&reflectorConfig).(*workload.PodDisruptionBudgetReflector)
+		rfl.Start(ctx, options.New(localClient, localFactory.Core().V1().Pods()).
+			WithHandlerFactory(FakeEventHandler).WithEventBroadcaster(record.NewBroadcaster()))
+		reflector = rfl.NewNamespaced(options.NewNamespaced().
+			WithLocal(LocalNamespace, localClient, localFactory).
+			WithRemote(RemoteNamespace, remoteClient, remoteFactory).
+			WithHandlerFactory(FakeEventHandler).WithEventBroadcaster(record.NewBroadcaster()).
+			WithReflectionType(offloadingv1beta1.DenyList).WithForgingOpts(FakeForgingOpts()))
+
+		localFactory.Start(ctx.Done())
+		remoteFactory.Start(ctx.Done())
+		localFactory.WaitForCacheSync(ctx.Done())
+		remoteFactory.WaitForCacheSync(ctx.Done())
+
+		err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("PodDisruptionBudget")), PDBName)
+	})
+
+	When("the local object does exist", func() {
+		BeforeEach(func() {
+			_, err := localClient.PolicyV1().PodDisruptionBudgets(LocalNamespace).Create(ctx, &local, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("the remote object should be created with the remote share of the budget", func() {
+			remote, err := GetRemotePDB()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(forge.IsReflected(remote)).To(BeTrue())
+			Expect(remote.Spec.Selector.MatchLabels).To(HaveKeyWithValue("app", "quorum"))
+			// Two healthy offloaded pods, one disruption allowed globally.
+			Expect(remote.Spec.MinAvailable).To(PointTo(Equal(intstr.FromInt32(1))))
+			Expect(remote.Spec.MaxUnavailable).To(BeNil())
+		})
+
+		It("the PodKeyer function should return the key of the poddisruptionbudget selecting the pod", func() {
+			Expect(rfl.PodKeyer(OffloadedPod("ready-1", corev1.ConditionTrue))).To(ConsistOf(
+				types.NamespacedName{Namespace: LocalNamespace, Name: PDBName}))
+			Expect(rfl.PodKeyer(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: LocalNamespace}})).To(BeEmpty())
+		})
+
+		It("the PodKeyer function should return no keys once the namespace reflection is stopped", func() {
+			rfl.StopNamespace(LocalNamespace, RemoteNamespace)
+			Expect(rfl.PodKeyer(OffloadedPod("ready-1", corev1.ConditionTrue))).To(BeEmpty())
+		})
+
+		When("the local status is outdated", func() {
+			BeforeEach(func() {
+				local.Generation = 2
+				_, err := localClient.PolicyV1().PodDisruptionBudgets(LocalNamespace).Update(ctx, &local, metav1.UpdateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should prevent any disruption", func() {
+				remote, err := GetRemotePDB()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(remote.Spec.MinAvailable).To(PointTo(Equal(intstr.FromInt32(2))))
+			})
+		})
+
+		When("the remote object already exists and is not managed by the reflection", func() {
+			BeforeEach(func() {
+				remote := policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: PDBName, Namespace: RemoteNamespace}}
+				_, err := remoteClient.PolicyV1().PodDisruptionBudgets(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("the remote object should be unmodified", func() {
+				remote, err := GetRemotePDB()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(remote.Spec.MinAvailable).To(BeNil())
+			})
+		})
+	})
+
+	When("the local object does not exist and the remote one is managed by the reflection", func() {
+		BeforeEach(func() {
+			remote := policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{
+				Name: PDBName, Namespace: RemoteNamespace, Labels: forge.ReflectionLabels(),
+			}}
+			_, err := remoteClient.PolicyV1().PodDisruptionBudgets(RemoteNamespace).Create(ctx, &remote, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("the remote object should be deleted", func() {
+			_, err := GetRemotePDB()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
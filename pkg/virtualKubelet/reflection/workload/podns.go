@@ -22,6 +22,7 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -62,6 +63,7 @@ import (
 
 var _ manager.NamespacedReflector = (*NamespacedPodReflector)(nil)
 var _ NamespacedPodHandler = (*NamespacedPodReflector)(nil)
+var _ manager.DryRunner = (*NamespacedPodReflector)(nil)
 
 // NamespacedPodHandler exposes an interface to interact with pods offloaded to the remote cluster in a given namespace.
 type NamespacedPodHandler interface {
@@ -332,7 +334,15 @@ func (npr *NamespacedPodReflector) ForgeShadowPod(ctx context.Context, local *co
 	mutators = append(mutators,
 		forge.APIServerSupportMutator(npr.config.APIServerSupport, local.Annotations, pod.ServiceAccountName(local),
 			saSecretRetriever, ipGetter, npr.config.HomeAPIServerHost, npr.config.HomeAPIServerPort),
-		forge.ServiceAccountMutator(npr.config.APIServerSupport, local.Annotations))
+		forge.ServiceAccountMutator(npr.config.APIServerSupport, local.Annotations),
+		forge.EphemeralVolumesMutator(npr.config.VirtualStorageClassName, npr.config.RemoteRealStorageClassName))
+
+	if slices.Contains(npr.config.ClusterScopedReflection, offloadingv1beta1.PriorityClassKind) {
+		mutators = append(mutators, forge.PriorityClassMutator(local.Spec.PriorityClassName))
+	}
+	if slices.Contains(npr.config.ClusterScopedReflection, offloadingv1beta1.RuntimeClassKind) {
+		mutators = append(mutators, forge.RuntimeClassMutator(local.Spec.RuntimeClassName))
+	}
 
 	if forgingOpts != nil {
 		mutators = append(mutators,
@@ -742,6 +752,41 @@ func (npr *NamespacedPodReflector) InferAdditionalRestarts(local, remote *corev1
 	return 0
 }
 
+// DryRun forges the remote shadowpod corresponding to the given local pod, and performs a server-side dry-run
+// of the operation which would enforce it, so that the result is comparable with the current remote object.
+func (npr *NamespacedPodReflector) DryRun(ctx context.Context, name string) (forged, current interface{}, err error) {
+	local, err := npr.localPods.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shadow, err := npr.remoteShadowPods.Get(name)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, nil, err
+	}
+
+	target, err := npr.ForgeShadowPod(ctx, local, shadow, npr.RetrievePodInfo(name), npr.ForgingOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if shadow == nil {
+		forged, err = npr.remoteShadowPodsClient.Create(ctx, target,
+			metav1.CreateOptions{FieldManager: forge.ReflectionFieldManager, DryRun: []string{metav1.DryRunAll}})
+		if err != nil {
+			return nil, nil, err
+		}
+		return forged, nil, nil
+	}
+
+	forged, err = npr.remoteShadowPodsClient.Update(ctx, target,
+		metav1.UpdateOptions{FieldManager: forge.ReflectionFieldManager, DryRun: []string{metav1.DryRunAll}})
+	if err != nil {
+		return nil, nil, err
+	}
+	return forged, shadow, nil
+}
+
 // List retrieves the list of reflected pods.
 func (npr *NamespacedPodReflector) List() ([]interface{}, error) {
 	listShPod, err := virtualkubelet.List[virtualkubelet.Lister[*offloadingv1beta1.ShadowPod], *offloadingv1beta1.ShadowPod](
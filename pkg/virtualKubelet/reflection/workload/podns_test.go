@@ -91,7 +91,7 @@ var _ = Describe("Namespaced Pod Reflection Tests", func() {
 								},
 							},
 						},
-					}}, &reflectorConfig)
+					}, nil, "", ""}, &reflectorConfig)
 			rfl.Start(ctx, options.New(client, factory.Core().V1().Pods()).WithEventBroadcaster(broadcaster))
 			reflector = rfl.NewNamespaced(options.NewNamespaced().
 				WithLocal(LocalNamespace, client, factory).WithLiqoLocal(liqoClient, liqoFactory).
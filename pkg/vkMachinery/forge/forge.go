@@ -16,6 +16,7 @@ package forge
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -66,10 +67,20 @@ func getDefaultLoadBalancerClass(loadBalancerClasses []liqov1beta1.LoadBalancerT
 	return loadBalancerClasses[0]
 }
 
+func getDefaultGateway(gateways []liqov1beta1.GatewayType) liqov1beta1.GatewayType {
+	for _, gateway := range gateways {
+		if gateway.Default {
+			return gateway
+		}
+	}
+	return gateways[0]
+}
+
 func forgeVKContainers(
 	homeCluster, remoteCluster liqov1beta1.ClusterID,
 	nodeName, vkNamespace, localPodCIDR, liqoNamespace string,
 	storageClasses []liqov1beta1.StorageType, ingressClasses []liqov1beta1.IngressType, loadBalancerClasses []liqov1beta1.LoadBalancerType,
+	gateways []liqov1beta1.GatewayType, clusterScopedReflection []offloadingv1beta1.ClusterScopedResourceKind,
 	opts *offloadingv1beta1.VkOptionsTemplate) []v1.Container {
 	command := []string{
 		"/usr/bin/virtual-kubelet",
@@ -86,9 +97,14 @@ func forgeVKContainers(
 	}
 
 	if len(storageClasses) > 0 {
+		classes := make([]string, len(storageClasses))
+		for i := range storageClasses {
+			classes[i] = storageClasses[i].StorageClassName
+		}
 		args = append(args, string(EnableStorage),
 			StringifyArgument(string(RemoteRealStorageClassName),
-				getDefaultStorageClass(storageClasses).StorageClassName))
+				getDefaultStorageClass(storageClasses).StorageClassName),
+			StringifyArgument(string(RemoteStorageClasses), strings.Join(classes, ",")))
 	}
 	if len(ingressClasses) > 0 {
 		args = append(args, string(EnableIngress),
@@ -100,9 +116,24 @@ func forgeVKContainers(
 			StringifyArgument(string(RemoteRealLoadBalancerClassName),
 				getDefaultLoadBalancerClass(loadBalancerClasses).LoadBalancerClassName))
 	}
+	if len(gateways) > 0 {
+		gateway := getDefaultGateway(gateways)
+		args = append(args, string(EnableGateway),
+			StringifyArgument(string(RemoteGatewayName), gateway.Name),
+			StringifyArgument(string(RemoteGatewayNamespace), gateway.Namespace))
+	}
+
+	if len(clusterScopedReflection) > 0 {
+		kinds := make([]string, len(clusterScopedReflection))
+		for i := range clusterScopedReflection {
+			kinds[i] = string(clusterScopedReflection[i])
+		}
+		args = append(args, StringifyArgument(string(ClusterScopedReflection), strings.Join(kinds, ",")))
+	}
 
 	args = appendArgsReflectorsWorkers(args, opts.Spec.ReflectorsConfig)
 	args = appendArgsReflectorsType(args, opts.Spec.ReflectorsConfig)
+	args = appendArgsCustomResourceReflectors(args, opts.Spec.ReflectorsConfig)
 
 	if extraAnnotations := opts.Spec.NodeExtraAnnotations; len(extraAnnotations) != 0 {
 		stringifiedMap := argsutils.StringMap{StringMap: extraAnnotations}.String()
@@ -170,7 +201,7 @@ func forgeVKPodSpec(vkNamespace string, homeCluster liqov1beta1.ClusterID, local
 			homeCluster, virtualNode.Spec.ClusterID,
 			virtualNode.Name, vkNamespace, localPodCIDR, liqoNamespace,
 			virtualNode.Spec.StorageClasses, virtualNode.Spec.IngressClasses, virtualNode.Spec.LoadBalancerClasses,
-			opts),
+			virtualNode.Spec.Gateways, virtualNode.Spec.ClusterScopedReflection, opts),
 		ServiceAccountName: virtualNode.Name,
 	}
 }
@@ -208,3 +239,23 @@ func appendArgsReflectorsType(args []string, reflectorsConfig map[string]offload
 
 	return args
 }
+
+func appendArgsCustomResourceReflectors(args []string, reflectorsConfig map[string]offloadingv1beta1.ReflectorConfig) []string {
+	keys := make([]string, 0, len(reflectorsConfig))
+	for key := range reflectorsConfig {
+		keys = append(keys, key)
+	}
+	// Sort the keys, to generate the arguments in a deterministic order.
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		gvr, ok := resources.ParseCustomResourceKey(key)
+		if !ok {
+			continue
+		}
+		reflector := reflectorsConfig[key]
+		args = append(args, StringifyArgument(string(CustomResourceReflection), resources.FormatCustomResourceReflector(gvr, &reflector)))
+	}
+
+	return args
+}
@@ -39,6 +39,8 @@ const (
 	EnableStorage VirtualKubeletOptsFlag = "--enable-storage"
 	// RemoteRealStorageClassName is the flag used to specify the remote real storage class name.
 	RemoteRealStorageClassName VirtualKubeletOptsFlag = "--remote-real-storage-class-name"
+	// RemoteStorageClasses is the flag used to specify the storage classes offered by the remote cluster.
+	RemoteStorageClasses VirtualKubeletOptsFlag = "--remote-storage-classes"
 	// EnableIngress is the flag used to enable the ingress.
 	EnableIngress VirtualKubeletOptsFlag = "--enable-ingress"
 	// RemoteRealIngressClassName is the flag used to specify the remote real ingress class name.
@@ -47,6 +49,12 @@ const (
 	EnableLoadBalancer VirtualKubeletOptsFlag = "--enable-load-balancer"
 	// RemoteRealLoadBalancerClassName is the flag used to specify the remote real load balancer class name.
 	RemoteRealLoadBalancerClassName VirtualKubeletOptsFlag = "--remote-real-load-balancer-class-name"
+	// EnableGateway is the flag used to enable the Gateway API routes.
+	EnableGateway VirtualKubeletOptsFlag = "--enable-gateway"
+	// RemoteGatewayName is the flag used to specify the name of the remote gateway.
+	RemoteGatewayName VirtualKubeletOptsFlag = "--remote-gateway-name"
+	// RemoteGatewayNamespace is the flag used to specify the namespace of the remote gateway.
+	RemoteGatewayNamespace VirtualKubeletOptsFlag = "--remote-gateway-namespace"
 	// NodeExtraAnnotations is the flag used to specify the node extra annotations.
 	NodeExtraAnnotations VirtualKubeletOptsFlag = "--node-extra-annotations"
 	// NodeExtraLabels is the flag used to specify the node extra labels.
@@ -59,4 +67,8 @@ const (
 	CreateNode VirtualKubeletOptsFlag = "--create-node"
 	// NodeCheckNetwork is the flag used to specify if the network must be checked.
 	NodeCheckNetwork VirtualKubeletOptsFlag = "--node-check-network"
+	// CustomResourceReflection is the flag used to specify a custom resource to be reflected.
+	CustomResourceReflection VirtualKubeletOptsFlag = "--custom-resource-reflection"
+	// ClusterScopedReflection is the flag used to specify the kinds of the cluster-scoped resources to be reflected.
+	ClusterScopedReflection VirtualKubeletOptsFlag = "--cluster-scoped-reflection"
 )
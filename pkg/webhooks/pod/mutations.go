@@ -95,6 +95,25 @@ func createNodeSelectorFromNamespaceOffloading(nsoff *offloadingv1beta1.Namespac
 	return &nodeSelector, nil
 }
 
+// createNodeSelectorFromRemoteVolumes creates the NodeSelector pinning a pod to the remote clusters hosting its volumes.
+// The selector matches the cluster ID label of the virtual nodes, hence it holds across the re-creation of the virtual
+// node the volumes were provisioned through, as well as when multiple virtual nodes target the same remote cluster.
+func createNodeSelectorFromRemoteVolumes(clusterIDs []string) *corev1.NodeSelector {
+	if len(clusterIDs) == 0 {
+		return nil
+	}
+
+	term := corev1.NodeSelectorTerm{}
+	for _, clusterID := range clusterIDs {
+		term.MatchExpressions = append(term.MatchExpressions, corev1.NodeSelectorRequirement{
+			Key:      liqoconst.RemoteClusterID,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{clusterID},
+		})
+	}
+	return &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{term}}
+}
+
 // fillPodWithTheNewNodeSelector gets the previously computed NodeSelector imposed by the PodOffloadingStrategy and
 // merges it with the Pod NodeSelector if it is already present. It simply adds it to the Pod if previously unset.
 func fillPodWithTheNewNodeSelector(imposedNodeSelector *corev1.NodeSelector, pod *corev1.Pod) {
@@ -19,8 +19,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
@@ -30,10 +32,12 @@ import (
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
 )
 
 // cluster-role
 // +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespaceoffloadings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims;persistentvolumes,verbs=get;list;watch
 
 type podwh struct {
 	client  client.Client
@@ -70,6 +74,42 @@ func (w *podwh) CreatePatchResponse(req *admission.Request, pod *corev1.Pod) adm
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
 
+// getRemoteVolumesClusterIDs returns the IDs of the remote clusters hosting the persistent volumes mounted by the given pod.
+// Claims not yet existing or bound are skipped, as their volume is provisioned according to the scheduling decision.
+func (w *podwh) getRemoteVolumesClusterIDs(ctx context.Context, namespace string, pod *corev1.Pod) ([]string, error) {
+	var clusterIDs []string
+	for i := range pod.Spec.Volumes {
+		claim := pod.Spec.Volumes[i].PersistentVolumeClaim
+		if claim == nil {
+			continue
+		}
+
+		var pvc corev1.PersistentVolumeClaim
+		if err := w.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: claim.ClaimName}, &pvc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+
+		var pv corev1.PersistentVolume
+		if err := w.client.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		if clusterID, found := utils.GetPersistentVolumeClusterID(&pv); found && !slices.Contains(clusterIDs, clusterID) {
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+	}
+	return clusterIDs, nil
+}
+
 // Handle implements the pod mutating webhook logic.
 //
 //nolint:gocritic // The signature of this method is imposed by controller runtime.
@@ -95,5 +135,16 @@ func (w *podwh) Handle(ctx context.Context, req admission.Request) admission.Res
 		return admission.Errored(http.StatusInternalServerError, errors.New("failed constructing pod mutation"))
 	}
 
+	if nsoff.Spec.PodOffloadingStrategy != offloadingv1beta1.LocalPodOffloadingStrategyType {
+		var clusterIDs []string
+		if clusterIDs, err = w.getRemoteVolumesClusterIDs(ctx, req.Namespace, pod); err != nil {
+			klog.Errorf("Failed retrieving the volumes of pod %q in namespace %q: %v", pod.Name, req.Namespace, err)
+			return admission.Errored(http.StatusInternalServerError, errors.New("failed retrieving the pod volumes"))
+		}
+
+		// Pin the pod to the remote clusters hosting its volumes, regardless of the virtual node they were provisioned through.
+		fillPodWithTheNewNodeSelector(createNodeSelectorFromRemoteVolumes(clusterIDs), pod)
+	}
+
 	return w.CreatePatchResponse(&req, pod)
 }
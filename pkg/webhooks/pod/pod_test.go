@@ -15,6 +15,7 @@
 package pod
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -23,6 +24,7 @@ import (
 	. "github.com/onsi/gomega/gstruct"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
@@ -292,4 +294,91 @@ var _ = Describe("Webhook", func() {
 			Expect(*podTest.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(Equal(mergedNodeSelector))
 		})
 	})
+
+	Context("6 - Check the NodeSelector pinning the pod to the remote clusters hosting its volumes", func() {
+		var (
+			ctx context.Context
+			wh  *podwh
+			pod *corev1.Pod
+		)
+
+		volumeRequirement := func(clusterID string) corev1.NodeSelectorRequirement {
+			return corev1.NodeSelectorRequirement{Key: liqoconst.RemoteClusterID, Operator: corev1.NodeSelectorOpIn, Values: []string{clusterID}}
+		}
+
+		claim := func(name, volume string) *corev1.PersistentVolumeClaim {
+			return &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+				Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: volume},
+			}
+		}
+
+		volume := func(name string, requirement corev1.NodeSelectorRequirement) *corev1.PersistentVolume {
+			return &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: corev1.PersistentVolumeSpec{
+					NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{requirement}}},
+					}},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			wh = &podwh{client: fake.NewClientBuilder().WithObjects(
+				claim("remote-1", "pv-remote-1"), volume("pv-remote-1", volumeRequirement("cluster-1")),
+				claim("remote-2", "pv-remote-2"), volume("pv-remote-2", volumeRequirement("cluster-1")),
+				claim("local", "pv-local"), volume("pv-local", corev1.NodeSelectorRequirement{
+					Key: "kubernetes.io/hostname", Operator: corev1.NodeSelectorOpIn, Values: []string{"node"}}),
+				claim("unbound", ""),
+			).Build()}
+
+			pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "test"}}
+		})
+
+		addClaims := func(names ...string) {
+			for _, name := range names {
+				pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name}}})
+			}
+		}
+
+		It("Should not pin the pod without remote volumes", func() {
+			addClaims("local", "unbound", "missing")
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+				Name: "empty", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+
+			clusterIDs, err := wh.getRemoteVolumesClusterIDs(ctx, "test", pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clusterIDs).To(BeEmpty())
+			Expect(createNodeSelectorFromRemoteVolumes(clusterIDs)).To(BeNil())
+		})
+
+		It("Should pin the pod to the cluster hosting its remote volumes", func() {
+			addClaims("remote-1", "remote-2", "local")
+
+			clusterIDs, err := wh.getRemoteVolumesClusterIDs(ctx, "test", pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clusterIDs).To(ConsistOf("cluster-1"))
+
+			fillPodWithTheNewNodeSelector(createNodeSelectorFromRemoteVolumes(clusterIDs), pod)
+			Expect(*pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(Equal(corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{volumeRequirement("cluster-1")}}},
+			}))
+		})
+
+		It("Should add the cluster requirement to every term imposed by the NamespaceOffloading", func() {
+			namespaceOffloading := testutils.GetNamespaceOffloading(offloadingv1beta1.LocalAndRemotePodOffloadingStrategyType)
+			Expect(mutatePod(&namespaceOffloading, pod, false)).To(Succeed())
+			terms := len(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+
+			fillPodWithTheNewNodeSelector(createNodeSelectorFromRemoteVolumes([]string{"cluster-1"}), pod)
+			selector := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+			Expect(selector.NodeSelectorTerms).To(HaveLen(terms))
+			for i := range selector.NodeSelectorTerms {
+				Expect(selector.NodeSelectorTerms[i].MatchExpressions).To(ContainElement(volumeRequirement("cluster-1")))
+			}
+		})
+	})
 })
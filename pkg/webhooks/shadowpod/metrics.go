@@ -0,0 +1,127 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadowpod
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var (
+	// MetricsTenantLabels is the labels that are used for the tenant metrics.
+	MetricsTenantLabels = []string{"cluster_id", "user"}
+
+	// MetricsTenantResourceRequested is the metric that exposes the amount of resources granted to a given tenant.
+	MetricsTenantResourceRequested = prometheus.NewDesc(
+		"liqo_tenant_resource_requested",
+		"Amount of resources granted to a given tenant (i.e., the quota limits).",
+		append(MetricsTenantLabels, "resource"),
+		nil,
+	)
+
+	// MetricsTenantResourceUsed is the metric that exposes the amount of resources used by a given tenant.
+	MetricsTenantResourceUsed = prometheus.NewDesc(
+		"liqo_tenant_resource_used",
+		"Amount of resources used by the ShadowPods of a given tenant.",
+		append(MetricsTenantLabels, "resource"),
+		nil,
+	)
+
+	// MetricsTenantResourceFree is the metric that exposes the amount of resources still available to a given tenant.
+	MetricsTenantResourceFree = prometheus.NewDesc(
+		"liqo_tenant_resource_free",
+		"Amount of resources still available to a given tenant.",
+		append(MetricsTenantLabels, "resource"),
+		nil,
+	)
+
+	// MetricsTenantShadowPods is the metric that exposes the number of ShadowPods running for a given tenant.
+	MetricsTenantShadowPods = prometheus.NewDesc(
+		"liqo_tenant_shadowpods",
+		"Number of ShadowPods running for a given tenant.",
+		MetricsTenantLabels,
+		nil,
+	)
+)
+
+var _ prometheus.Collector = &UsageCollector{}
+
+// UsageCollector is a prometheus.Collector that collects the per-tenant resource usage tracked by the validator.
+type UsageCollector struct {
+	validator *Validator
+}
+
+// NewUsageCollector creates a new UsageCollector.
+func NewUsageCollector(spv *Validator) *UsageCollector {
+	return &UsageCollector{validator: spv}
+}
+
+// Describe implements prometheus.Collector.
+func (uc *UsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- MetricsTenantResourceRequested
+	ch <- MetricsTenantResourceUsed
+	ch <- MetricsTenantResourceFree
+	ch <- MetricsTenantShadowPods
+}
+
+// Collect implements prometheus.Collector.
+func (uc *UsageCollector) Collect(ch chan<- prometheus.Metric) {
+	if !uc.validator.PeeringCache.ready {
+		return
+	}
+
+	ctx := context.WithoutCancel(context.Background())
+	quotaList := offloadingv1beta1.QuotaList{}
+	if err := uc.validator.client.List(ctx, &quotaList); err != nil {
+		uc.metricsErrorHandler(fmt.Errorf("error collecting tenant metrics: %w", err), ch)
+		return
+	}
+
+	for i := range quotaList.Items {
+		quota := &quotaList.Items[i]
+		pi, found := uc.validator.PeeringCache.getPeeringInfo(quota.Spec.User)
+		if !found {
+			continue
+		}
+
+		usage := pi.getUsage()
+		labels := []string{quota.Labels[consts.RemoteClusterID], quota.Spec.User}
+
+		collectResources(ch, MetricsTenantResourceRequested, usage.Requested, labels)
+		collectResources(ch, MetricsTenantResourceUsed, usage.Used, labels)
+		collectResources(ch, MetricsTenantResourceFree, usage.Free, labels)
+		ch <- prometheus.MustNewConstMetric(MetricsTenantShadowPods, prometheus.GaugeValue, float64(usage.ShadowPods), labels...)
+	}
+}
+
+func (uc *UsageCollector) metricsErrorHandler(err error, ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(MetricsTenantResourceRequested, err)
+	ch <- prometheus.NewInvalidMetric(MetricsTenantResourceUsed, err)
+	ch <- prometheus.NewInvalidMetric(MetricsTenantResourceFree, err)
+	ch <- prometheus.NewInvalidMetric(MetricsTenantShadowPods, err)
+}
+
+func collectResources(ch chan<- prometheus.Metric, desc *prometheus.Desc, resources corev1.ResourceList, labels []string) {
+	for name, quantity := range resources {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, quantity.AsApproximateFloat64(),
+			append(labels, string(name))...)
+	}
+}
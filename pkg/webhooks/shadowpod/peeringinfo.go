@@ -21,6 +21,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	quotav1 "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -174,9 +175,52 @@ func (pi *peeringInfo) updateDeletion(sp *offloadingv1beta1.ShadowPod, dryRun bo
 	return nil
 }
 
+func (pi *peeringInfo) testAndUpdateResize(sp *offloadingv1beta1.ShadowPod,
+	limitsEnforcement offloadingv1beta1.LimitsEnforcement, dryRun bool) error {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	spd, err := pi.getShadowPodDescription(sp)
+	if err != nil {
+		return err
+	}
+
+	newQuota, err := getQuotaFromShadowPod(sp, limitsEnforcement)
+	if err != nil {
+		return err
+	}
+
+	// Only the resources which are increased by the resize need to be checked against the free quota.
+	increase := corev1.ResourceList{}
+	for key, val := range quotav1.Subtract(*newQuota, spd.quota) {
+		if val.Sign() > 0 {
+			increase[key] = val
+		}
+	}
+
+	klog.V(5).Infof("ShadowPod resource limits %s (previous %s)", quotaFormatter(*newQuota), quotaFormatter(spd.quota))
+	klog.V(5).Infof("Cluster %q free quota %s", pi.userName, quotaFormatter(pi.getFreeQuota()))
+
+	if err := pi.checkQuota(increase); err != nil {
+		return err
+	}
+	if !dryRun && spd.running {
+		pi.subUsedResources(spd.quota)
+		pi.addUsedResources(*newQuota)
+		spd.quota = *newQuota
+		klog.V(5).Infof("Cluster %q updated used quota %s", pi.userName, quotaFormatter(pi.usedQuota))
+		klog.V(5).Infof("Cluster %q updated free quota %s", pi.userName, quotaFormatter(pi.getFreeQuota()))
+	}
+	return nil
+}
+
 func (pi *peeringInfo) checkResources(spd *Description) error {
+	return pi.checkQuota(spd.quota)
+}
+
+func (pi *peeringInfo) checkQuota(quota corev1.ResourceList) error {
 	freePeeringQuota := pi.getFreeQuota()
-	for key, val := range spd.quota {
+	for key, val := range quota {
 		if freeQuota, ok := freePeeringQuota[key]; ok {
 			if freeQuota.Cmp(val) < 0 {
 				return fmt.Errorf("peering %s quota usage exceeded - free %s / requested %s",
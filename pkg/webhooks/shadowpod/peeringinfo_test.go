@@ -233,4 +233,65 @@ var _ = Describe("Peering Info", func() {
 			})
 		})
 	})
+
+	Describe("Test and update resize", func() {
+		JustBeforeEach(func() {
+			err = peeringInfo.testAndUpdateResize(shadowPod, offloadingv1beta1.SoftLimitsEnforcement, dryRun)
+		})
+
+		BeforeEach(func() {
+			peeringInfo = createPeeringInfo(userName, *resourceQuota)
+			peeringInfo.addShadowPod(createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota2))
+		})
+
+		When("the shadow pod is scaled up and resources are available", func() {
+			BeforeEach(func() {
+				dryRun = false
+				shadowPod = forgeShadowPodWithResourceRequests([]containerResource{{cpu: int64(resourceCPU), memory: int64(resourceMemory)}}, nil)
+			})
+			It("should not return any error and available resources will be decremented", func() {
+				Expect(err).To(BeNil())
+				Expect(peeringInfo.usedQuota).To(Equal(*resourceQuota))
+				Expect(peeringInfo.getFreeQuota()).To(Equal(*freeQuotaZero))
+			})
+		})
+		When("the shadow pod is scaled up and resources are available, but dryRun flag is true", func() {
+			BeforeEach(func() {
+				dryRun = true
+				shadowPod = forgeShadowPodWithResourceRequests([]containerResource{{cpu: int64(resourceCPU), memory: int64(resourceMemory)}}, nil)
+			})
+			It("should not return any error and available resources will not be decremented", func() {
+				Expect(err).To(BeNil())
+				Expect(peeringInfo.usedQuota).To(Equal(*resourceQuota2))
+			})
+		})
+		When("the shadow pod is scaled up and resources are not available", func() {
+			BeforeEach(func() {
+				dryRun = false
+				shadowPod = forgeShadowPodWithResourceRequests([]containerResource{{cpu: int64(2 * resourceCPU), memory: int64(resourceMemory)}}, nil)
+			})
+			It("should return an error and available resources will not be decremented", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(peeringInfo.usedQuota).To(Equal(*resourceQuota2))
+			})
+		})
+		When("the shadow pod is scaled down", func() {
+			BeforeEach(func() {
+				dryRun = false
+				shadowPod = forgeShadowPodWithResourceRequests([]containerResource{{cpu: int64(resourceCPU / 4), memory: int64(resourceMemory / 4)}}, nil)
+			})
+			It("should not return any error and available resources will be incremented", func() {
+				Expect(err).To(BeNil())
+				Expect(peeringInfo.usedQuota).To(Equal(*resourceQuota4))
+			})
+		})
+		When("Shadow pod description does not exist", func() {
+			BeforeEach(func() {
+				peeringInfo = createPeeringInfo(userName, *resourceQuota)
+			})
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+			})
+		})
+	})
 })
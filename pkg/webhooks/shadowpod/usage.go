@@ -0,0 +1,103 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadowpod
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+// cluster-role
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=quotas/status,verbs=get;update;patch
+
+// UsageReporter is a wrapper function that receives a ShadowPodValidator
+// and starts a timer to periodically report the usage tracked in cache in the status of the Quotas.
+func (spv *Validator) UsageReporter(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return wait.PollUntilContextCancel(ctx, interval, false, spv.reportUsage)
+	}
+}
+
+func (spv *Validator) reportUsage(ctx context.Context) (done bool, err error) {
+	if !spv.PeeringCache.ready {
+		klog.V(4).Infof("Usage report skipped: cache not ready")
+		return false, nil
+	}
+
+	quotaList := offloadingv1beta1.QuotaList{}
+	if err := spv.client.List(ctx, &quotaList); err != nil {
+		klog.Warningf("Failed listing quotas: %v", err)
+		return false, nil
+	}
+
+	klog.V(4).Infof("Usage report started")
+	for i := range quotaList.Items {
+		if err := spv.reportQuotaUsage(ctx, &quotaList.Items[i]); err != nil {
+			klog.Warningf("Failed reporting usage for Quota %q: %v", klog.KObj(&quotaList.Items[i]), err)
+		}
+	}
+	klog.V(4).Infof("Usage report completed")
+	return false, nil
+}
+
+func (spv *Validator) reportQuotaUsage(ctx context.Context, quota *offloadingv1beta1.Quota) error {
+	pi, found := spv.PeeringCache.getPeeringInfo(quota.Spec.User)
+	if !found {
+		// The PeeringInfo will be created by the next cache refresh.
+		return nil
+	}
+
+	usage := pi.getUsage()
+	if equality.Semantic.DeepEqual(quota.Status.Usage, *usage) {
+		return nil
+	}
+
+	original := quota.DeepCopy()
+	quota.Status.Usage = *usage
+	quota.Status.LastUpdateTime = &metav1.Time{Time: time.Now()}
+	if err := spv.client.Status().Patch(ctx, quota, client.MergeFrom(original)); err != nil {
+		return err
+	}
+	klog.V(5).Infof("Usage of Quota %q updated: used %s", klog.KObj(quota), quotaFormatter(usage.Used))
+	return nil
+}
+
+// getUsage returns a snapshot of the resource usage of the peering.
+func (pi *peeringInfo) getUsage() *offloadingv1beta1.TenantUsage {
+	pi.mu.RLock()
+	defer pi.mu.RUnlock()
+
+	var running int32
+	for _, spd := range pi.shadowPods {
+		if spd.running {
+			running++
+		}
+	}
+
+	return &offloadingv1beta1.TenantUsage{
+		Requested:  pi.totalQuota.DeepCopy(),
+		Used:       pi.usedQuota.DeepCopy(),
+		Free:       pi.getFreeQuota(),
+		ShadowPods: running,
+	}
+}
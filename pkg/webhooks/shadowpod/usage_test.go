@@ -0,0 +1,97 @@
+// Copyright 2019-2024 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadowpod
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offloadingv1beta1 "github.com/liqotech/liqo/apis/offloading/v1beta1"
+)
+
+var _ = Describe("Usage reporting", func() {
+	var (
+		fakeClient  client.Client
+		spValidator *Validator
+	)
+
+	BeforeEach(func() {
+		fakeClient = fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(fakeShadowPod, fakeShadowPod2, quota.DeepCopy(), quota2.DeepCopy()).
+			WithStatusSubresource(&offloadingv1beta1.Quota{}).
+			Build()
+		spValidator = NewValidator(fakeClient, true)
+	})
+
+	When("the cache is not ready", func() {
+		It("should not update the Quota status", func() {
+			Expect(spValidator.reportUsage(ctx)).To(BeFalse())
+
+			q := &offloadingv1beta1.Quota{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota), q)).To(Succeed())
+			Expect(q.Status.LastUpdateTime).To(BeNil())
+		})
+	})
+
+	When("the cache is ready", func() {
+		BeforeEach(func() {
+			Expect(spValidator.initializeCache(ctx)).To(Succeed())
+			Expect(spValidator.reportUsage(ctx)).To(BeFalse())
+		})
+
+		It("should report the usage in the Quota status", func() {
+			q := &offloadingv1beta1.Quota{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota), q)).To(Succeed())
+			Expect(q.Status.LastUpdateTime).ToNot(BeNil())
+			Expect(q.Status.Usage.ShadowPods).To(BeNumerically("==", 2))
+			Expect(q.Status.Usage.Requested.Cpu().Value()).To(Equal(resourceQuota.Cpu().Value()))
+			Expect(q.Status.Usage.Used.Cpu().Value()).To(Equal(resourceQuota2.Cpu().Value()))
+			Expect(q.Status.Usage.Free.Memory().Value()).To(Equal(resourceQuota2.Memory().Value()))
+		})
+
+		It("should report an empty usage for users without ShadowPods", func() {
+			q := &offloadingv1beta1.Quota{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota2), q)).To(Succeed())
+			Expect(q.Status.Usage.ShadowPods).To(BeNumerically("==", 0))
+			Expect(q.Status.Usage.Used.Cpu().IsZero()).To(BeTrue())
+		})
+
+		It("should not update the Quota status if the usage did not change", func() {
+			q := &offloadingv1beta1.Quota{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota), q)).To(Succeed())
+			lastUpdate := q.Status.LastUpdateTime
+
+			Expect(spValidator.reportUsage(ctx)).To(BeFalse())
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota), q)).To(Succeed())
+			Expect(q.Status.LastUpdateTime).To(Equal(lastUpdate))
+		})
+
+		It("should expose the usage as prometheus metrics", func() {
+			expected := `
+# HELP liqo_tenant_shadowpods Number of ShadowPods running for a given tenant.
+# TYPE liqo_tenant_shadowpods gauge
+liqo_tenant_shadowpods{cluster_id="test-cluster-id",user="test-user-name"} 2
+liqo_tenant_shadowpods{cluster_id="test-cluster-id-2",user="test-user-name-2"} 0
+`
+			Expect(testutil.CollectAndCompare(NewUsageCollector(spValidator),
+				strings.NewReader(expected), "liqo_tenant_shadowpods")).To(Succeed())
+		})
+	})
+})
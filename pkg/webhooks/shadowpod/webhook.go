@@ -175,11 +175,39 @@ func (spv *Validator) HandleUpdate(ctx context.Context, req *admission.Request)
 		return admission.Denied("shadopow Cluster ID label is changed")
 	}
 
-	if pod.CheckShadowPodUpdate(&shadowpod.Spec.Pod, &oldShadowpod.Spec.Pod) {
+	// The check is performed on a copy, since the updated object gets mutated.
+	if !pod.CheckShadowPodUpdate(&oldShadowpod.Spec.Pod, shadowpod.Spec.Pod.DeepCopy()) {
+		return admission.Denied("")
+	}
+
+	if !spv.enableResourceValidation || pod.AreContainerResourcesEqual(oldShadowpod.Spec.Pod.Containers, shadowpod.Spec.Pod.Containers) {
 		return admission.Allowed("")
 	}
 
-	return admission.Denied("")
+	// The shadowpod is being resized, hence the consumer quota needs to be validated again.
+	return spv.HandleResize(ctx, req, shadowpod)
+}
+
+// HandleResize is the function in charge of validating the in-place resize of shadowpods against the consumer quota.
+func (spv *Validator) HandleResize(ctx context.Context, req *admission.Request, shadowpod *offloadingv1beta1.ShadowPod) admission.Response {
+	creatorName, found := shadowpod.Labels[consts.CreatorLabelKey]
+	if !found {
+		return admission.Denied("missing creator label")
+	}
+
+	quota, err := getters.GetQuotaByUser(ctx, spv.client, creatorName)
+	if err != nil {
+		klog.Warningf("Failed getting quota for user %s: %v", creatorName, err)
+		return admission.Denied("failed getting quota")
+	}
+
+	peeringInfo := spv.PeeringCache.getOrCreatePeeringInfo(creatorName, quota.Spec.Resources)
+	if err := peeringInfo.testAndUpdateResize(shadowpod, quota.Spec.LimitsEnforcement, *req.DryRun); err != nil {
+		klog.Warning(err)
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
 }
 
 // HandleDelete is the function in charge of handling Deletion requests.